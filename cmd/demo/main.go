@@ -47,9 +47,7 @@ func main() {
 	if *verbose {
 		logLevel = slog.LevelDebug
 	}
-	logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	logger = slog.New(config.NewLogHandler(os.Stdout, logLevel))
 
 	// Check if ports are available before starting
 	if err := checkPortAvailable(*mockPort); err != nil {
@@ -67,6 +67,12 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultShutdownTimeout)
 		defer cancel()
 
+		// Drain first so requests still being served cannot start anything
+		// the drain would miss.
+		if _, err := appInst.Shutdown(ctx); err != nil {
+			logger.Error("operation drain failed", slog.String("error", err.Error()))
+		}
+
 		srv.SetKeepAlivesEnabled(false)
 		if err := srv.Shutdown(ctx); err != nil {
 			logger.Error("server shutdown failed", slog.String("error", err.Error()))
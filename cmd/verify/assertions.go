@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Assertion checks one observable value while a mock_state scenario runs.
+//
+// Check names the value:
+//
+//	cluster_status             status of the operation's cluster
+//	writer                     ID of the cluster's writer instance
+//	writer_instance_type       instance class of the writer
+//	instance_type:<id>         instance class of an instance
+//	instance_status:<id>       status of an instance
+//	operation_state            state of the operation
+//	pause_reason               why the operation paused
+//	events                     the operation's event messages, one per line
+//
+// An assertion with AfterStep runs right after that step (1-based) completes,
+// before the next one starts; without it, the assertion runs once the
+// operation has stopped. Equals and Contains may be combined.
+type Assertion struct {
+	AfterStep int    `yaml:"after_step,omitempty" json:"after_step,omitempty"`
+	Check     string `yaml:"check" json:"check"`
+	Equals    string `yaml:"equals,omitempty" json:"equals,omitempty"`
+	Contains  string `yaml:"contains,omitempty" json:"contains,omitempty"`
+}
+
+// String describes the assertion the way it is reported when it fails.
+func (a Assertion) String() string {
+	var parts []string
+	if a.Equals != "" {
+		parts = append(parts, fmt.Sprintf("%s == %q", a.Check, a.Equals))
+	}
+	if a.Contains != "" {
+		parts = append(parts, fmt.Sprintf("%s contains %q", a.Check, a.Contains))
+	}
+	when := "by end"
+	if a.AfterStep > 0 {
+		when = fmt.Sprintf("after step %d", a.AfterStep)
+	}
+	return strings.Join(parts, " and ") + " " + when
+}
+
+// validateAssertions rejects assertions that could never be checked, and
+// after_step assertions out of step order.
+func validateAssertions(assertions []Assertion) error {
+	lastStep := 0
+	for i, a := range assertions {
+		if a.Check == "" || (a.Equals == "" && a.Contains == "") {
+			return fmt.Errorf("assertion %d: check and one of equals or contains are required", i+1)
+		}
+		if a.AfterStep < 0 {
+			return fmt.Errorf("assertion %d: after_step must be positive", i+1)
+		}
+		if a.AfterStep > 0 {
+			if a.AfterStep < lastStep {
+				return fmt.Errorf("assertion %d: after_step %d comes after step %d; list assertions in step order", i+1, a.AfterStep, lastStep)
+			}
+			lastStep = a.AfterStep
+		}
+	}
+	return nil
+}
+
+// assertionRunner checks a scenario's assertions against the mock state and
+// the engine as the operation progresses. It is the engine's notifier, so
+// after_step assertions see the state between two steps.
+type assertionRunner struct {
+	notifiers.NullNotifier
+
+	state      *mock.State
+	engine     *machine.Engine
+	assertions []Assertion
+
+	mu       sync.Mutex
+	next     int // index of the first after_step assertion not yet checked
+	failures []string
+}
+
+var _ machine.Notifier = (*assertionRunner)(nil)
+
+// NotifyStepCompleted checks the assertions for the step that just finished.
+func (r *assertionRunner) NotifyStepCompleted(ctx context.Context, op *types.Operation, step *types.Step) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	completed := op.CurrentStepIndex
+	for r.next < len(r.assertions) {
+		a := r.assertions[r.next]
+		if a.AfterStep == 0 {
+			r.next++
+			continue
+		}
+		if a.AfterStep > completed {
+			break
+		}
+		r.check(op, a)
+		r.next++
+	}
+	return nil
+}
+
+// finish checks the end-of-run assertions, and fails any after_step
+// assertion the operation stopped before reaching.
+func (r *assertionRunner) finish(op *types.Operation) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, a := range r.assertions[r.next:] {
+		if a.AfterStep > 0 {
+			r.failures = append(r.failures, fmt.Sprintf("%s: not reached, operation stopped %s after %d of %d steps",
+				a, op.State, op.CurrentStepIndex, len(op.Steps)))
+		}
+	}
+	r.next = len(r.assertions)
+	for _, a := range r.assertions {
+		if a.AfterStep == 0 {
+			r.check(op, a)
+		}
+	}
+	return r.failures
+}
+
+// check records a failure if the assertion does not hold.
+func (r *assertionRunner) check(op *types.Operation, a Assertion) {
+	actual, err := r.observe(op, a.Check)
+	switch {
+	case err != nil:
+		r.failures = append(r.failures, fmt.Sprintf("%s: %v", a, err))
+	case a.Equals != "" && actual != a.Equals,
+		a.Contains != "" && !strings.Contains(actual, a.Contains):
+		r.failures = append(r.failures, fmt.Sprintf("%s: actual %q", a, actual))
+	}
+}
+
+// observe reads the value an assertion checks.
+func (r *assertionRunner) observe(op *types.Operation, check string) (string, error) {
+	name, arg, _ := strings.Cut(check, ":")
+	switch name {
+	case "cluster_status":
+		cluster, ok := r.state.GetCluster(op.ClusterID)
+		if !ok {
+			return "", fmt.Errorf("cluster %s not found", op.ClusterID)
+		}
+		return cluster.Status, nil
+	case "writer", "writer_instance_type":
+		cluster, ok := r.state.GetCluster(op.ClusterID)
+		if !ok {
+			return "", fmt.Errorf("cluster %s not found", op.ClusterID)
+		}
+		for _, id := range cluster.Members {
+			if inst, ok := r.state.GetInstance(id); ok && inst.IsWriter {
+				if name == "writer" {
+					return inst.ID, nil
+				}
+				return inst.InstanceType, nil
+			}
+		}
+		return "", fmt.Errorf("cluster %s has no writer", op.ClusterID)
+	case "instance_type", "instance_status":
+		inst, ok := r.state.GetInstance(arg)
+		if !ok {
+			return "", fmt.Errorf("instance %q not found", arg)
+		}
+		if name == "instance_type" {
+			return inst.InstanceType, nil
+		}
+		return inst.Status, nil
+	case "operation_state":
+		return string(op.State), nil
+	case "pause_reason":
+		return op.PauseReason, nil
+	case "events":
+		events, err := r.engine.GetEvents(op.ID)
+		if err != nil {
+			return "", err
+		}
+		messages := make([]string, 0, len(events))
+		for _, event := range events {
+			messages = append(messages, event.Message)
+		}
+		return strings.Join(messages, "\n"), nil
+	default:
+		return "", fmt.Errorf("unknown check %q", check)
+	}
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
@@ -19,6 +21,7 @@ func main() {
 	scenarioFile := flag.String("scenarios", "fixtures/scenarios.yaml", "path to test scenarios file")
 	verbose := flag.Bool("verbose", false, "enable verbose output")
 	scenarioFilter := flag.String("filter", "", "run only scenarios matching this name")
+	parallel := flag.Int("parallel", 1, "number of scenarios to run at once")
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
@@ -56,23 +59,69 @@ func main() {
 		os.Exit(1)
 	}
 
-	passed := 0
-	failed := 0
+	// Each scenario runs against mock servers of its own and writes its report
+	// to a buffer, which is printed in scenario order once the scenario and
+	// all before it are done.
+	type result struct {
+		out      bytes.Buffer
+		err      error
+		duration time.Duration
+		done     chan struct{}
+	}
+	var selected []TestScenario
 	skipped := 0
-
 	for _, scenario := range scenarios {
 		if *scenarioFilter != "" && !strings.Contains(scenario.Name, *scenarioFilter) {
 			skipped++
 			continue
 		}
+		selected = append(selected, scenario)
+	}
+	results := make([]*result, len(selected))
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+
+	workers := max(*parallel, 1)
+	next := make(chan int)
+	go func() {
+		for i := range selected {
+			next <- i
+		}
+		close(next)
+	}()
+
+	start := time.Now()
+	for range workers {
+		go func() {
+			for i := range next {
+				r := results[i]
+				scenarioLogger := slog.New(slog.NewTextHandler(&r.out, &slog.HandlerOptions{
+					Level: slog.LevelInfo,
+				}))
+				began := time.Now()
+				r.err = runScenario(ctx, selected[i], *verbose, &r.out, scenarioLogger)
+				r.duration = time.Since(began)
+				close(r.done)
+			}
+		}()
+	}
 
-		if err := runScenario(ctx, scenario, *verbose, logger); err != nil {
-			fmt.Printf("  FAILED: %v\n\n", err)
+	passed := 0
+	failed := 0
+	var serial time.Duration
+	for _, r := range results {
+		<-r.done
+		os.Stdout.Write(r.out.Bytes())
+		if r.err != nil {
+			fmt.Printf("  FAILED: %v\n\n", r.err)
 			failed++
 		} else {
 			passed++
 		}
+		serial += r.duration
 	}
+	elapsed := time.Since(start)
 
 	fmt.Printf("\n")
 	separator := strings.Repeat("=", 60)
@@ -82,6 +131,12 @@ func main() {
 	} else {
 		fmt.Printf("  Test Results: All %d tests passed, %d skipped\n", passed, skipped)
 	}
+	if workers > 1 {
+		fmt.Printf("  Ran in %s with %d workers, %s less than one at a time (%s)\n",
+			elapsed.Round(time.Millisecond), workers, (serial - elapsed).Round(time.Millisecond), serial.Round(time.Millisecond))
+	} else {
+		fmt.Printf("  Ran in %s\n", elapsed.Round(time.Millisecond))
+	}
 	fmt.Printf("%s\n", separator)
 
 	if failed > 0 {
@@ -40,10 +40,12 @@ type MockServer struct {
 	requests  []RequestRecord
 	responses []MockResponse // Keep as list for action matching
 	verbose   bool
+	out       io.Writer // receives the verbose request log
 }
 
-// NewMockServer creates a new mock HTTP server.
-func NewMockServer(name string, responses []MockResponse, verbose bool) *MockServer {
+// NewMockServer creates a new mock HTTP server. With verbose, requests are
+// logged to out.
+func NewMockServer(name string, responses []MockResponse, verbose bool, out io.Writer) *MockServer {
 	filtered := make([]MockResponse, 0)
 	for _, r := range responses {
 		if strings.EqualFold(r.Service, name) {
@@ -55,6 +57,7 @@ func NewMockServer(name string, responses []MockResponse, verbose bool) *MockSer
 		requests:  make([]RequestRecord, 0),
 		responses: filtered,
 		verbose:   verbose,
+		out:       out,
 	}
 }
 
@@ -89,7 +92,7 @@ func (ms *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if action != "" {
 			actionStr = fmt.Sprintf(" [%s]", action)
 		}
-		fmt.Printf("    -> %-6s %-4s %s%s\n", ms.name, r.Method, r.URL.Path, actionStr)
+		fmt.Fprintf(ms.out, "    -> %-6s %-4s %s%s\n", ms.name, r.Method, r.URL.Path, actionStr)
 	}
 
 	// Find matching response by action first, then path
@@ -112,7 +115,7 @@ func (ms *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Default response
 	if ms.verbose {
-		fmt.Printf("    !  %-6s No mock for: %s %s (action: %s)\n", ms.name, r.Method, r.URL.Path, action)
+		fmt.Fprintf(ms.out, "    !  %-6s No mock for: %s %s (action: %s)\n", ms.name, r.Method, r.URL.Path, action)
 	}
 	w.Header().Set("Content-Type", "text/xml")
 	w.WriteHeader(http.StatusNotFound)
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/app"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// mockStateRunTimeout bounds how long a mock_state scenario's operation may
+// run before it is reported as stuck.
+const mockStateRunTimeout = 60 * time.Second
+
+// ScenarioFault is a fault injected into the mock before a mock_state
+// scenario's operation starts.
+type ScenarioFault struct {
+	Type        string  `yaml:"type" json:"type"`
+	Action      string  `yaml:"action,omitempty" json:"action,omitempty"`
+	Target      string  `yaml:"target,omitempty" json:"target,omitempty"`
+	ErrorCode   string  `yaml:"error_code,omitempty" json:"error_code,omitempty"`
+	ErrorMsg    string  `yaml:"error_message,omitempty" json:"error_message,omitempty"`
+	Probability float64 `yaml:"probability,omitempty" json:"probability,omitempty"` // Defaults to 1
+}
+
+// ScenarioStatusScript is a sequence of statuses an instance plays back in a
+// mock_state scenario. With on_modify it starts when the operation modifies
+// the instance, otherwise when the scenario starts.
+type ScenarioStatusScript struct {
+	InstanceID string           `yaml:"instance_id" json:"instance_id"`
+	OnModify   bool             `yaml:"on_modify,omitempty" json:"on_modify,omitempty"`
+	Statuses   []ScenarioStatus `yaml:"statuses" json:"statuses"`
+}
+
+// ScenarioStatus is one status of a ScenarioStatusScript.
+type ScenarioStatus struct {
+	Status     string `yaml:"status" json:"status"`
+	DurationMs int    `yaml:"duration_ms,omitempty" json:"duration_ms,omitempty"`
+}
+
+// runMockStateScenario runs a scenario's operation to completion, or until
+// it pauses or fails, against the stateful mock seeded with the demo
+// clusters, checking its assertions along the way.
+func runMockStateScenario(ctx context.Context, scenario TestScenario, verbose bool, out io.Writer, logger *slog.Logger) error {
+	if scenario.Action != "create_operation" {
+		return fmt.Errorf("mock_state scenarios support only the create_operation action, got %q", scenario.Action)
+	}
+	if err := validateAssertions(scenario.Assertions); err != nil {
+		return err
+	}
+
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	for i, f := range scenario.Faults {
+		fault := mock.Fault{
+			Type:        mock.FaultType(f.Type),
+			Action:      f.Action,
+			Target:      f.Target,
+			ErrorCode:   f.ErrorCode,
+			ErrorMsg:    f.ErrorMsg,
+			Probability: f.Probability,
+			Enabled:     true,
+		}
+		if fault.Probability == 0 {
+			fault.Probability = 1
+		}
+		if err := fault.Validate(); err != nil {
+			return fmt.Errorf("fault %d: %w", i+1, err)
+		}
+		state.Faults().AddFault(fault)
+	}
+	for _, script := range scenario.StatusScripts {
+		entries := make([]mock.StatusScriptEntry, len(script.Statuses))
+		for i, s := range script.Statuses {
+			entries[i] = mock.StatusScriptEntry{Status: s.Status, DurationMs: s.DurationMs}
+		}
+		if err := state.ScriptInstanceStatus(script.InstanceID, entries, script.OnModify); err != nil {
+			return fmt.Errorf("status script: %w", err)
+		}
+	}
+
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	cfg, err := scenarioConfig(scenario)
+	if err != nil {
+		return err
+	}
+
+	runner := &assertionRunner{state: state, assertions: scenario.Assertions}
+	engine := machine.NewEngine(machine.EngineConfig{
+		ClientManager: rds.NewClientManager(rds.ClientManagerConfig{
+			BaseConfig: aws.Config{
+				Region:           "us-east-1",
+				Credentials:      aws.AnonymousCredentials{},
+				RetryMaxAttempts: 1,
+			},
+			DemoMode: true,
+			BaseURL:  server.URL,
+		}),
+		Logger:              logger,
+		Notifier:            runner,
+		DefaultRegion:       "us-east-1",
+		DefaultWaitTimeout:  30 * time.Second,
+		DefaultPollInterval: 50 * time.Millisecond,
+	})
+	runner.engine = engine
+	appInst := app.NewWithEngine(cfg, engine, runner)
+
+	var params json.RawMessage
+	if scenario.Params != nil {
+		if params, err = json.Marshal(scenario.Params); err != nil {
+			return fmt.Errorf("marshal params: %w", err)
+		}
+	}
+
+	op, err := appInst.CreateOperation(ctx, app.CreateOperationRequest{
+		Type:      types.OperationType(scenario.OperationType),
+		ClusterID: scenario.ClusterID,
+		Params:    params,
+	})
+	if scenario.ExpectError {
+		if err == nil {
+			return fmt.Errorf("expected error but succeeded")
+		}
+		if verbose {
+			fmt.Fprintf(out, "  Expected error occurred: %v\n", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unexpected error: %w", err)
+	}
+	if scenario.ExpectSteps > 0 && len(op.Steps) != scenario.ExpectSteps {
+		return fmt.Errorf("expected %d steps but got %d", scenario.ExpectSteps, len(op.Steps))
+	}
+
+	if err := appInst.StartOperation(ctx, op.ID); err != nil {
+		return fmt.Errorf("start operation: %w", err)
+	}
+	if err := waitForOperationStop(ctx, engine, op.ID); err != nil {
+		return err
+	}
+	op, err = engine.GetOperation(op.ID)
+	if err != nil {
+		return err
+	}
+
+	if verbose {
+		fmt.Fprintf(out, "  Operation %s after %d of %d steps\n", op.State, op.CurrentStepIndex, len(op.Steps))
+		for i, step := range op.Steps {
+			fmt.Fprintf(out, "    [%d] %s %s: %s\n", i+1, step.State, step.Action, step.Name)
+		}
+	}
+
+	if failures := runner.finish(op); len(failures) > 0 {
+		fmt.Fprintf(out, "\n  Assertions:\n")
+		for _, failure := range failures {
+			fmt.Fprintf(out, "    FAILED: %s\n", failure)
+		}
+		return fmt.Errorf("%d of %d assertions failed", len(failures), len(scenario.Assertions))
+	}
+	return nil
+}
+
+// waitForOperationStop waits until the operation pauses or reaches a
+// terminal state.
+func waitForOperationStop(ctx context.Context, engine *machine.Engine, id string) error {
+	deadline := time.Now().Add(mockStateRunTimeout)
+	for {
+		op, err := engine.GetOperation(id)
+		if err != nil {
+			return err
+		}
+		if op.State.IsTerminal() || op.State == types.StatePaused {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("operation still %s after %s, at step %d of %d",
+				op.State, mockStateRunTimeout, op.CurrentStepIndex+1, len(op.Steps))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
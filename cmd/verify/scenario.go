@@ -5,10 +5,12 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -36,6 +38,15 @@ type TestScenario struct {
 	MockResponses   []MockResponse    `yaml:"mock_responses" json:"mock_responses"`
 	ExpectError     bool              `yaml:"expect_error,omitempty" json:"expect_error,omitempty"`
 	ExpectSteps     int               `yaml:"expect_steps,omitempty" json:"expect_steps,omitempty"` // Expected number of steps
+
+	// MockState runs the operation against the stateful mock seeded with the
+	// demo clusters instead of canned responses, checking Assertions as it
+	// goes. Faults are injected and status scripts set up in the mock before
+	// it starts.
+	MockState     bool                   `yaml:"mock_state,omitempty" json:"mock_state,omitempty"`
+	Faults        []ScenarioFault        `yaml:"faults,omitempty" json:"faults,omitempty"`
+	StatusScripts []ScenarioStatusScript `yaml:"status_scripts,omitempty" json:"status_scripts,omitempty"`
+	Assertions    []Assertion            `yaml:"assertions,omitempty" json:"assertions,omitempty"`
 }
 
 // ExpectedCall defines an HTTP API call the test expects.
@@ -46,18 +57,31 @@ type ExpectedCall struct {
 	Action  string `yaml:"action,omitempty" json:"action,omitempty"` // AWS action
 }
 
-// runScenario executes a single test scenario with mock HTTP servers.
-func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logger *slog.Logger) error {
+// runScenario executes a single test scenario with mock HTTP servers of its
+// own, writing its report to out. Scenarios share nothing, so several may run
+// at once.
+func runScenario(ctx context.Context, scenario TestScenario, verbose bool, out io.Writer, logger *slog.Logger) error {
 	startTime := time.Now()
 
-	fmt.Printf("\n> Running: %s\n", scenario.Name)
+	fmt.Fprintf(out, "\n> Running: %s\n", scenario.Name)
 	if scenario.Description != "" {
-		fmt.Printf("  %s\n", scenario.Description)
+		fmt.Fprintf(out, "  %s\n", scenario.Description)
+	}
+
+	if scenario.MockState {
+		if err := runMockStateScenario(ctx, scenario, verbose, out, logger); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "  PASSED (%.2fs)\n", time.Since(startTime).Seconds())
+		return nil
+	}
+	if len(scenario.Assertions) > 0 || len(scenario.Faults) > 0 {
+		return fmt.Errorf("assertions and faults require mock_state")
 	}
 
 	// Set up mock servers
-	rdsMock := NewMockServer("RDS", scenario.MockResponses, verbose)
-	slackMock := NewMockServer("Slack", scenario.MockResponses, verbose)
+	rdsMock := NewMockServer("RDS", scenario.MockResponses, verbose, out)
+	slackMock := NewMockServer("Slack", scenario.MockResponses, verbose, out)
 
 	// Generate TLS cert for mock servers at runtime
 	tlsCert, certPool, err := generateSelfSignedCert()
@@ -121,24 +145,10 @@ func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logge
 		Timeout: 10 * time.Second,
 	}
 
-	// Also update the default transport for any other HTTP calls
-	http.DefaultTransport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			RootCAs: certPool,
-		},
-	}
-
-	// Apply config overrides
-	for key, value := range scenario.ConfigOverrides {
-		os.Setenv(key, value)
-	}
-
-	// Create config
-	cfg, err := config.NewConfig()
+	cfg, err := scenarioConfig(scenario)
 	if err != nil {
-		return fmt.Errorf("config creation failed: %w", err)
+		return err
 	}
-	cfg.SlackEnabled = false // Disable Slack for tests
 
 	// Create AWS config with custom HTTP client
 	awsCfg := aws.Config{
@@ -179,7 +189,7 @@ func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logge
 	appInst := app.NewWithEngine(cfg, engine, &notifiers.NullNotifier{})
 
 	if verbose {
-		fmt.Printf("\n  Application Output:\n")
+		fmt.Fprintf(out, "\n  Application Output:\n")
 	}
 
 	// Convert params map to JSON for the request
@@ -216,7 +226,7 @@ func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logge
 			return fmt.Errorf("expected error but succeeded")
 		}
 		if verbose {
-			fmt.Printf("  Expected error occurred: %v\n", processErr)
+			fmt.Fprintf(out, "  Expected error occurred: %v\n", processErr)
 		}
 	} else {
 		if processErr != nil {
@@ -230,9 +240,9 @@ func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logge
 			return fmt.Errorf("expected %d steps but got %d", scenario.ExpectSteps, len(operation.Steps))
 		}
 		if verbose {
-			fmt.Printf("  Steps created: %d\n", len(operation.Steps))
+			fmt.Fprintf(out, "  Steps created: %d\n", len(operation.Steps))
 			for i, step := range operation.Steps {
-				fmt.Printf("    [%d] %s: %s\n", i+1, step.Action, step.Name)
+				fmt.Fprintf(out, "    [%d] %s: %s\n", i+1, step.Action, step.Name)
 			}
 		}
 	}
@@ -250,40 +260,68 @@ func runScenario(ctx context.Context, scenario TestScenario, verbose bool, logge
 	// Validate expected actions if specified
 	if len(scenario.ExpectedActions) > 0 {
 		if err := validateExpectedActions(scenario.ExpectedActions, rdsReqs); err != nil {
-			fmt.Printf("\n  Validation:\n")
-			fmt.Printf("    FAILED: %v\n", err)
-			fmt.Printf("\n  Captured RDS actions:\n")
+			fmt.Fprintf(out, "\n  Validation:\n")
+			fmt.Fprintf(out, "    FAILED: %v\n", err)
+			fmt.Fprintf(out, "\n  Captured RDS actions:\n")
 			for i, req := range rdsReqs {
-				fmt.Printf("      [%d] %s\n", i+1, req.Action)
+				fmt.Fprintf(out, "      [%d] %s\n", i+1, req.Action)
 			}
 			return err
 		}
 	}
 
 	if err := validateExpectedCalls(scenario.ExpectedCalls, allReqs); err != nil {
-		fmt.Printf("\n  Validation:\n")
-		fmt.Printf("    FAILED: %v\n", err)
-		fmt.Printf("\n  Captured requests:\n")
+		fmt.Fprintf(out, "\n  Validation:\n")
+		fmt.Fprintf(out, "    FAILED: %v\n", err)
+		fmt.Fprintf(out, "\n  Captured requests:\n")
 		if len(rdsReqs) > 0 {
-			fmt.Printf("    RDS (%d):\n", len(rdsReqs))
+			fmt.Fprintf(out, "    RDS (%d):\n", len(rdsReqs))
 			for i, req := range rdsReqs {
-				fmt.Printf("      [%d] %s %s [%s]\n", i+1, req.Method, req.Path, req.Action)
+				fmt.Fprintf(out, "      [%d] %s %s [%s]\n", i+1, req.Method, req.Path, req.Action)
 			}
 		}
 		if len(slackReqs) > 0 {
-			fmt.Printf("    Slack (%d):\n", len(slackReqs))
+			fmt.Fprintf(out, "    Slack (%d):\n", len(slackReqs))
 			for i, req := range slackReqs {
-				fmt.Printf("      [%d] %s %s\n", i+1, req.Method, req.Path)
+				fmt.Fprintf(out, "      [%d] %s %s\n", i+1, req.Method, req.Path)
 			}
 		}
 		return err
 	}
 
 	duration := time.Since(startTime)
-	fmt.Printf("  PASSED (%.2fs)\n", duration.Seconds())
+	fmt.Fprintf(out, "  PASSED (%.2fs)\n", duration.Seconds())
 	return nil
 }
 
+// envMu serialises scenarios' changes to the environment, which
+// config.NewConfig reads.
+var envMu sync.Mutex
+
+// scenarioConfig builds the app config with the scenario's config overrides
+// set in the environment, restoring the environment afterwards so they do not
+// leak into other scenarios.
+func scenarioConfig(scenario TestScenario) (*config.Config, error) {
+	envMu.Lock()
+	defer envMu.Unlock()
+
+	for key, value := range scenario.ConfigOverrides {
+		if previous, ok := os.LookupEnv(key); ok {
+			defer os.Setenv(key, previous)
+		} else {
+			defer os.Unsetenv(key)
+		}
+		os.Setenv(key, value)
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("config creation failed: %w", err)
+	}
+	cfg.SlackEnabled = false // Disable Slack for tests
+	return cfg, nil
+}
+
 // validateExpectedCalls verifies that all expected calls were made.
 func validateExpectedCalls(expected []ExpectedCall, allReqs map[string][]RequestRecord) error {
 	for _, exp := range expected {
@@ -8,10 +8,15 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/cockroachdb/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/config"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/metrics"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/pricing"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
@@ -25,6 +30,9 @@ type App struct {
 	ClientManager *rds.ClientManager
 	Store         storage.Store
 	Notifier      machine.Notifier
+	Metrics       *metrics.Registry // nil unless metrics are enabled
+
+	options optionsCache // recent upgrade targets and instance options per cluster
 }
 
 // New creates a new App instance.
@@ -38,7 +46,14 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 
 	// Initialize storage
 	var store storage.Store
-	if cfg.DataDir != "" {
+	if cfg.DynamoDBTable != "" {
+		awsCfg, err := cfg.LoadAWSConfig(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "load aws config for dynamodb")
+		}
+		store = storage.NewDynamoStore(dynamodb.NewFromConfig(awsCfg), cfg.DynamoDBTable)
+		logger.Info("using dynamodb storage", slog.String("table", cfg.DynamoDBTable))
+	} else if cfg.DataDir != "" {
 		fileStore, err := storage.NewFileStore(cfg.DataDir)
 		if err != nil {
 			return nil, errors.Wrap(err, "create file store")
@@ -53,6 +68,17 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 
 	// Initialize ClientManager
 	var clientManager *rds.ClientManager
+	callTimeouts := rds.CallTimeouts{
+		Default:   time.Duration(cfg.RDSCallTimeout) * time.Second,
+		PerAction: make(map[string]time.Duration, len(cfg.RDSActionTimeouts)),
+	}
+	for action, seconds := range cfg.RDSActionTimeouts {
+		callTimeouts.PerAction[action] = time.Duration(seconds) * time.Second
+	}
+	tagLookup := rds.TagLookupConfig{
+		Concurrency:   cfg.RDSTagConcurrency,
+		RatePerSecond: cfg.RDSTagRate,
+	}
 
 	if cfg.DemoMode && cfg.RDSEndpoint != "" {
 		// In demo mode, create a minimal AWS config that won't try to fetch real credentials
@@ -62,9 +88,12 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 			Credentials:      aws.AnonymousCredentials{},
 		}
 		clientManager = rds.NewClientManager(rds.ClientManagerConfig{
-			BaseConfig: awsCfg,
-			DemoMode:   true,
-			BaseURL:    cfg.RDSEndpoint,
+			BaseConfig:   awsCfg,
+			DemoMode:     true,
+			BaseURL:      cfg.RDSEndpoint,
+			CallTimeouts: callTimeouts,
+			TagLookup:    tagLookup,
+			Logger:       logger,
 		})
 		logger.Info("using demo mode with mock RDS endpoint", slog.String("endpoint", cfg.RDSEndpoint))
 	} else {
@@ -75,21 +104,72 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		}
 
 		clientManager = rds.NewClientManager(rds.ClientManagerConfig{
-			BaseConfig: awsCfg,
-			Profile:    cfg.AWSProfile,
+			BaseConfig:   awsCfg,
+			Profile:      cfg.AWSProfile,
+			CallTimeouts: callTimeouts,
+			Retry: rds.RetryConfig{
+				Mode:        aws.RetryMode(cfg.RDSRetryMode),
+				MaxAttempts: cfg.RDSMaxAttempts,
+			},
+			TagLookup: tagLookup,
+			Logger:    logger,
 		})
 	}
 	app.ClientManager = clientManager
 
-	// Initialize notifier
-	var notifier machine.Notifier
+	// Initialize notifiers
+	var sinks notifiers.Multi
 	if cfg.SlackEnabled && cfg.SlackToken != "" {
-		notifier = notifiers.NewSlackNotifier(cfg.SlackToken, cfg.SlackChannel)
-	} else {
+		sinks = append(sinks, notifiers.NewSlackNotifier(cfg.SlackToken, cfg.SlackChannel))
+	}
+	if cfg.WebhookURL != "" {
+		sinks = append(sinks, notifiers.NewWebhookNotifier(notifiers.WebhookConfig{
+			URL:    cfg.WebhookURL,
+			Secret: cfg.WebhookSecret,
+			Events: func(operationID string) ([]types.Event, error) {
+				return app.Engine.GetEvents(operationID)
+			},
+			Logger: logger,
+		}))
+	}
+	var notifier machine.Notifier
+	switch len(sinks) {
+	case 0:
 		notifier = &notifiers.NullNotifier{}
+	case 1:
+		notifier = sinks[0]
+	default:
+		notifier = sinks
 	}
 	app.Notifier = notifier
 
+	if cfg.MetricsEnabled {
+		app.Metrics = metrics.New()
+	}
+
+	var window *maintwindow.Schedule
+	if cfg.MaintenanceWindow != "" {
+		var err error
+		window, err = maintwindow.Parse(cfg.MaintenanceWindow, cfg.MaintenanceTimezone)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse APP_MAINTENANCE_WINDOW")
+		}
+		logger.Info("new operations restricted to maintenance windows", slog.String("windows", window.String()))
+	}
+
+	prices, err := pricing.Load(cfg.PriceTablePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "load APP_PRICE_TABLE_PATH")
+	}
+
+	templates, err := machine.LoadTemplates(cfg.TemplatesPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "load APP_TEMPLATES_PATH")
+	}
+	if len(templates) > 0 {
+		logger.Info("loaded operation templates", slog.Int("count", len(templates)), slog.String("path", cfg.TemplatesPath))
+	}
+
 	// Initialize engine
 	app.Engine = machine.NewEngine(machine.EngineConfig{
 		ClientManager:       clientManager,
@@ -99,6 +179,28 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		DefaultRegion:       cfg.AWSRegion,
 		DefaultWaitTimeout:  time.Duration(cfg.DefaultWaitTimeout) * time.Second,
 		DefaultPollInterval: time.Duration(cfg.DefaultPollInterval) * time.Second,
+		MaxPollInterval:     time.Duration(cfg.MaxPollInterval) * time.Second,
+		InitialPollDelay:    time.Duration(cfg.InitialPollDelay) * time.Second,
+		ModifyVerifyPolls:   cfg.ModifyVerifyPolls,
+		TempFinalSnapshot:   cfg.TempFinalSnapshot,
+		DefaultStorageType:  cfg.DefaultStorageType,
+		Metrics:             app.Metrics,
+		MaintenanceWindow:   window,
+
+		MaxConcurrentOperations: cfg.MaxConcurrentOps,
+		SnapshotRetentionDays:   cfg.SnapshotRetention,
+		AllowedRegions:          cfg.AllowedRegions,
+		ClusterAllowlist:        cfg.ClusterAllowlist,
+		ClusterDenylist:         cfg.ClusterDenylist,
+		AlarmNamePatterns:       cfg.AlarmNamePatterns,
+		IdempotencyTTL:          time.Duration(cfg.IdempotencyTTL) * time.Second,
+		ClusterInstanceLimit:    cfg.InstanceLimit,
+		OrphanTTL:               time.Duration(cfg.OrphanTTL) * time.Second,
+		PriceTable:              prices,
+		MajorUpgradeApproval:    cfg.ApproveMajorUpgrade,
+		MaxOperationDuration:    time.Duration(cfg.MaxOpDuration) * time.Second,
+		BlueGreenMaxDuration:    time.Duration(cfg.BlueGreenDuration) * time.Second,
+		Templates:               templates,
 	})
 
 	// Load state from storage
@@ -109,6 +211,12 @@ func New(ctx context.Context, cfg *config.Config) (*App, error) {
 		// Resume or pause running operations based on config
 		app.Engine.ResumeRunningOperations(ctx, runningOps, cfg.AutoResume)
 	}
+	app.Engine.ResumeBatchOperations()
+
+	// Orphans are only recognisable once stored operations are loaded.
+	if cfg.ReconcileOnStartup {
+		go app.reconcileOrphansOnStartup(context.WithoutCancel(ctx))
+	}
 
 	return app, nil
 }
@@ -123,6 +231,13 @@ func NewWithEngine(cfg *config.Config, engine *machine.Engine, notifier machine.
 	}
 }
 
+// Shutdown drains the engine before the process exits: no new operations are
+// accepted and every operation in flight is saved so a restarted process can
+// resume it.
+func (a *App) Shutdown(ctx context.Context) (machine.DrainSummary, error) {
+	return a.Engine.Drain(ctx)
+}
+
 // StatusResponse contains application status.
 type StatusResponse struct {
 	Status       string `json:"status"`
@@ -138,7 +253,10 @@ type StatusResponse struct {
 
 // GetStatus returns the current application status.
 func (a *App) GetStatus() StatusResponse {
-	ops := a.Engine.ListOperations()
+	var ops []types.OperationSummary
+	if page, err := a.Engine.ListOperations(types.OperationFilter{}); err == nil {
+		ops = page.Operations
+	}
 
 	status := StatusResponse{
 		Status:       "ok",
@@ -164,16 +282,113 @@ func (a *App) GetStatus() StatusResponse {
 
 // CreateOperationRequest is the request to create a new operation.
 type CreateOperationRequest struct {
-	Type        types.OperationType `json:"type"`
-	ClusterID   string              `json:"cluster_id"`
-	Region      string              `json:"region,omitempty"`
-	Params      json.RawMessage     `json:"params"`
-	WaitTimeout int                 `json:"wait_timeout,omitempty"` // seconds
+	Type             types.OperationType `json:"type"`
+	ClusterID        string              `json:"cluster_id"`
+	Region           string              `json:"region,omitempty"`
+	Params           json.RawMessage     `json:"params"`
+	WaitTimeout      int                 `json:"wait_timeout,omitempty"`       // seconds
+	WaitForAvailable bool                `json:"wait_for_available,omitempty"` // wait instead of rejecting a busy cluster
+	DryRun           bool                `json:"dry_run,omitempty"`            // build the plan without starting it
+	TemplateID       string              `json:"template_id,omitempty"`        // create from a template, by ID or name; params override it
+	OverrideWindow   bool                `json:"override_window,omitempty"`    // create outside the maintenance window (admin only)
+	ClientToken      string              `json:"client_token,omitempty"`       // idempotency key, for callers that cannot set the header
+	RequiresApproval bool                `json:"requires_approval,omitempty"`  // hold the operation until someone else approves it
+	CreatedBy        string              `json:"-"`                            // authenticated creator, from APP_IDENTITY_HEADER; required when approval is needed
+	PollMode         bool                `json:"poll_mode,omitempty"`          // advance only on POST /poll, checking waits once per call
+	MaxDuration      int                 `json:"max_duration_seconds"`         // seconds the operation may run; negative for no deadline
+
+	ScheduleInClusterWindow bool `json:"schedule_in_cluster_window,omitempty"` // once started, wait for the cluster's maintenance window
 }
 
 // CreateOperation creates a new maintenance operation.
 func (a *App) CreateOperation(ctx context.Context, req CreateOperationRequest) (*types.Operation, error) {
-	return a.Engine.CreateOperation(ctx, req.Type, req.ClusterID, req.Region, req.Params, req.WaitTimeout)
+	opts := machine.CreateOptions{
+		WaitTimeout:      req.WaitTimeout,
+		WaitForAvailable: req.WaitForAvailable,
+		DryRun:           req.DryRun,
+		OverrideWindow:   req.OverrideWindow,
+		IdempotencyKey:   req.ClientToken,
+		RequiresApproval: req.RequiresApproval,
+		CreatedBy:        req.CreatedBy,
+		PollMode:         req.PollMode,
+		MaxDuration:      req.MaxDuration,
+
+		ScheduleInClusterWindow: req.ScheduleInClusterWindow,
+	}
+
+	if req.TemplateID != "" {
+		if req.Type != "" {
+			tmpl, err := a.Engine.GetTemplate(req.TemplateID)
+			if err != nil {
+				return nil, err
+			}
+			if tmpl.Type != req.Type {
+				return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+					"template %s creates %s operations, not %s", tmpl.ID, tmpl.Type, req.Type)
+			}
+		}
+		return a.Engine.CreateOperationFromTemplate(ctx, req.TemplateID, req.ClusterID, req.Region, req.Params, opts)
+	}
+
+	return a.Engine.CreateOperation(ctx, req.Type, req.ClusterID, req.Region, req.Params, opts)
+}
+
+// CreateBatchOperationRequest is the request to run one operation against
+// several clusters.
+type CreateBatchOperationRequest struct {
+	Type                types.OperationType `json:"type"`
+	ClusterIDs          []string            `json:"cluster_ids"`
+	Region              string              `json:"region,omitempty"`
+	Params              json.RawMessage     `json:"params"`
+	WaitTimeout         int                 `json:"wait_timeout,omitempty"`          // seconds, for every child operation
+	MaxDuration         int                 `json:"max_duration_seconds"`            // seconds each child operation may run
+	CreatedBy           string              `json:"-"`                               // authenticated creator, from APP_IDENTITY_HEADER; recorded on every child
+	MaxParallelClusters int                 `json:"max_parallel_clusters,omitempty"` // child operations active at once; default 1
+}
+
+// CreateBatchOperation creates an operation on each cluster of the request
+// and runs them a few clusters at a time.
+func (a *App) CreateBatchOperation(ctx context.Context, req CreateBatchOperationRequest) (*types.BatchOperation, error) {
+	return a.Engine.CreateBatchOperation(ctx, machine.BatchSpec{
+		Type:                req.Type,
+		ClusterIDs:          req.ClusterIDs,
+		Region:              req.Region,
+		Params:              req.Params,
+		WaitTimeout:         req.WaitTimeout,
+		MaxDuration:         req.MaxDuration,
+		CreatedBy:           req.CreatedBy,
+		MaxParallelClusters: req.MaxParallelClusters,
+	})
+}
+
+// GetBatchOperation returns a batch operation with its children's states.
+func (a *App) GetBatchOperation(id string) (*types.BatchOperation, error) {
+	return a.Engine.GetBatchOperation(id)
+}
+
+// SaveTemplate saves a reusable operation template.
+func (a *App) SaveTemplate(ctx context.Context, spec machine.TemplateSpec) (*types.Template, error) {
+	return a.Engine.SaveTemplate(ctx, spec)
+}
+
+// ListTemplates returns all saved operation templates.
+func (a *App) ListTemplates() []*types.Template {
+	return a.Engine.ListTemplates()
+}
+
+// DeleteTemplate removes a saved operation template.
+func (a *App) DeleteTemplate(ctx context.Context, id string) error {
+	return a.Engine.DeleteTemplate(ctx, id)
+}
+
+// ApproveOperation approves an operation awaiting approval and starts it.
+func (a *App) ApproveOperation(ctx context.Context, id, approver, comment string) error {
+	return a.Engine.ApproveOperation(ctx, id, approver, comment)
+}
+
+// PollOperation advances an operation in poll mode by one poll.
+func (a *App) PollOperation(ctx context.Context, id string) (*types.PollResult, error) {
+	return a.Engine.PollOperation(ctx, id)
 }
 
 // GetOperation returns an operation by ID.
@@ -181,9 +396,44 @@ func (a *App) GetOperation(id string) (*types.Operation, error) {
 	return a.Engine.GetOperation(id)
 }
 
-// ListOperations returns all operations.
-func (a *App) ListOperations() []*types.Operation {
-	return a.Engine.ListOperations()
+// GetStepPlan returns an operation's steps with their resolved parameters.
+func (a *App) GetStepPlan(id string) ([]types.PlannedStep, error) {
+	return a.Engine.GetStepPlan(id)
+}
+
+// ListOperations returns a page of operation summaries matching the filter.
+func (a *App) ListOperations(filter types.OperationFilter) (*types.OperationPage, error) {
+	return a.Engine.ListOperations(filter)
+}
+
+// GetCostEstimate estimates the extra spend of an operation.
+func (a *App) GetCostEstimate(ctx context.Context, id string) (*types.CostEstimate, error) {
+	return a.Engine.CostEstimate(ctx, id)
+}
+
+// VerifyEndState compares an operation's cluster with the end state the
+// operation intended and records the report on the operation.
+func (a *App) VerifyEndState(ctx context.Context, id string) (*types.EndStateReport, error) {
+	op, err := a.Engine.GetOperation(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.Engine.VerifyEndState(ctx, op)
+}
+
+// GetDurationStats returns historical duration statistics per operation type.
+func (a *App) GetDurationStats(ctx context.Context) (map[types.OperationType]types.DurationStats, error) {
+	return a.Engine.DurationStats(ctx)
+}
+
+// ListInterventions returns the paused operations awaiting an operator decision.
+func (a *App) ListInterventions() []types.PendingIntervention {
+	return a.Engine.PendingInterventions()
+}
+
+// ListActiveOperations returns the operations currently holding a cluster.
+func (a *App) ListActiveOperations() []types.ActiveOperation {
+	return a.Engine.ActiveOperations()
 }
 
 // GetEvents returns events for an operation.
@@ -191,16 +441,51 @@ func (a *App) GetEvents(operationID string) ([]types.Event, error) {
 	return a.Engine.GetEvents(operationID)
 }
 
+// GetEventsSince returns an operation's events that occurred after since.
+func (a *App) GetEventsSince(operationID string, since time.Time) ([]types.Event, error) {
+	return a.Engine.GetEventsSince(operationID, since)
+}
+
+// SubscribeOperation registers for live updates of an operation.
+func (a *App) SubscribeOperation(id string) (<-chan machine.OperationUpdate, func(), error) {
+	return a.Engine.Subscribe(id)
+}
+
+// GetProgress returns a snapshot of an operation's current state.
+func (a *App) GetProgress(id string) (types.OperationProgress, error) {
+	return a.Engine.Progress(id)
+}
+
 // StartOperation starts an operation.
 func (a *App) StartOperation(ctx context.Context, id string) error {
 	return a.Engine.StartOperation(ctx, id)
 }
 
+// ConfirmOperation starts a dry-run operation.
+func (a *App) ConfirmOperation(ctx context.Context, id string) error {
+	return a.Engine.ConfirmOperation(ctx, id)
+}
+
+// CancelOperation cancels a running or paused operation.
+func (a *App) CancelOperation(ctx context.Context, id string) error {
+	return a.Engine.CancelOperation(ctx, id)
+}
+
 // ResumeOperation resumes a paused operation.
 func (a *App) ResumeOperation(ctx context.Context, id string, response types.InterventionResponse) error {
 	return a.Engine.ResumeOperation(ctx, id, response)
 }
 
+// ResumeOptions returns the decisions a paused operation accepts.
+func (a *App) ResumeOptions(id string) ([]string, error) {
+	return a.Engine.ResumeOptions(id)
+}
+
+// RollbackOperation rolls back a failed or paused operation.
+func (a *App) RollbackOperation(ctx context.Context, id string) error {
+	return a.Engine.RollbackOperation(ctx, id)
+}
+
 // PauseOperation pauses a running operation.
 func (a *App) PauseOperation(ctx context.Context, id string, reason string) error {
 	return a.Engine.PauseOperation(ctx, id, reason)
@@ -219,11 +504,14 @@ func (a *App) DeleteOperation(ctx context.Context, id string) error {
 // DeleteAllOperations force-deletes all operations (for demo mode reset).
 // Returns count of deleted operations and any errors encountered.
 func (a *App) DeleteAllOperations(ctx context.Context) (int, []string) {
-	ops := a.Engine.ListOperations()
+	page, err := a.Engine.ListOperations(types.OperationFilter{})
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
 	deleted := 0
 	var errs []string
 
-	for _, op := range ops {
+	for _, op := range page.Operations {
 		if err := a.Engine.ForceDeleteOperation(ctx, op.ID); err != nil {
 			errs = append(errs, op.ID+": "+err.Error())
 		} else {
@@ -234,18 +522,44 @@ func (a *App) DeleteAllOperations(ctx context.Context) (int, []string) {
 	return deleted, errs
 }
 
-// ListRegions returns available AWS regions.
+// ListRegions returns available AWS regions, limited to the allowed regions
+// when an allow-list is configured.
 func (a *App) ListRegions(ctx context.Context) ([]string, error) {
-	return a.ClientManager.ListRegions(ctx)
+	regions, err := a.ClientManager.ListRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowed := regions[:0]
+	for _, region := range regions {
+		if a.Engine.RegionAllowed(region) {
+			allowed = append(allowed, region)
+		}
+	}
+	return allowed, nil
 }
 
-// ListClusters returns Aurora clusters in the specified region.
-func (a *App) ListClusters(ctx context.Context, region string) ([]types.ClusterSummary, error) {
+// ListClusters returns Aurora clusters in the specified region, each marked
+// with the operation in progress on it, if any.
+func (a *App) ListClusters(ctx context.Context, region string, includeInstances bool) ([]types.ClusterSummary, error) {
 	client, err := a.ClientManager.GetClient(ctx, region)
 	if err != nil {
 		return nil, err
 	}
-	return client.ListClusters(ctx)
+	clusters, err := client.ListClusters(ctx, includeInstances)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]string)
+	for _, op := range a.Engine.ActiveOperations() {
+		if op.Region == region {
+			active[op.ClusterID] = op.OperationID
+		}
+	}
+	for i := range clusters {
+		clusters[i].ActiveOperationID = active[clusters[i].ClusterID]
+	}
+	return clusters, nil
 }
 
 // GetClusterInfo returns detailed cluster information.
@@ -270,3 +584,46 @@ func (a *App) GetBlueGreenDeployments(ctx context.Context, region, clusterID str
 	}
 	return client.ListBlueGreenDeploymentsForCluster(ctx, clusterARN)
 }
+
+// ReconcileOrphans deletes the orphaned temp instances in a region.
+func (a *App) ReconcileOrphans(ctx context.Context, region string) (*types.OrphanReconcileResult, error) {
+	return a.Engine.ReconcileOrphans(ctx, region)
+}
+
+// reconcileOrphansOnStartup sweeps the allowed regions, or the default region
+// when any is allowed, for temp instances a previous run left behind.
+func (a *App) reconcileOrphansOnStartup(ctx context.Context) {
+	regions := a.Config.AllowedRegions
+	if len(regions) == 0 {
+		regions = []string{a.Config.AWSRegion}
+	}
+	for _, region := range regions {
+		result, err := a.ReconcileOrphans(ctx, region)
+		if err != nil {
+			a.Logger.Warn("failed to reconcile orphaned temp instances",
+				slog.String("region", region),
+				slog.String("error", err.Error()))
+			continue
+		}
+		a.Logger.Info("reconciled orphaned temp instances",
+			slog.String("region", region),
+			slog.Int("deleted", len(result.Deleted)),
+			slog.Int("retained", len(result.Retained)),
+			slog.Int("failed", len(result.Failed)))
+	}
+}
+
+// CleanupSnapshots deletes the expired maintenance snapshots in a region.
+func (a *App) CleanupSnapshots(ctx context.Context, region string) (*types.SnapshotCleanupResult, error) {
+	return a.Engine.CleanupExpiredSnapshots(ctx, region)
+}
+
+// GetPendingMaintenanceActions returns the pending maintenance actions for a
+// cluster and its instances.
+func (a *App) GetPendingMaintenanceActions(ctx context.Context, region, clusterID string) ([]rds.PendingMaintenanceAction, error) {
+	client, err := a.ClientManager.GetClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetPendingMaintenanceActions(ctx, clusterID)
+}
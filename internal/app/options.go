@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+)
+
+// clusterOptionsTTL is how long a cluster's upgrade targets and instance
+// options are reused, so a form opened several times in a row describes them
+// once.
+const clusterOptionsTTL = 30 * time.Second
+
+// UpgradeTargetOptions lists the versions a cluster can be upgraded to, with
+// its current version to pre-select.
+type UpgradeTargetOptions struct {
+	ClusterID      string              `json:"cluster_id"`
+	Engine         string              `json:"engine"`
+	CurrentVersion string              `json:"current_version"`
+	UpgradeTargets []rds.UpgradeTarget `json:"upgrade_targets"`
+}
+
+// InstanceOptions lists the instance classes orderable for a cluster's engine
+// version, sorted and without duplicates, with the writer's current class to
+// pre-select.
+type InstanceOptions struct {
+	ClusterID           string                      `json:"cluster_id"`
+	Engine              string                      `json:"engine"`
+	EngineVersion       string                      `json:"engine_version"`
+	CurrentInstanceType string                      `json:"current_instance_type"`
+	InstanceTypes       []rds.OrderableInstanceType `json:"instance_types"`
+}
+
+// optionsCache holds recent cluster options by region, cluster and kind. The
+// zero value is ready to use.
+type optionsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedOptions
+}
+
+type cachedOptions struct {
+	value   any
+	expires time.Time
+}
+
+func (c *optionsCache) get(key string, now time.Time) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *optionsCache) put(key string, value any, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedOptions)
+	}
+	c.entries[key] = cachedOptions{value: value, expires: now.Add(clusterOptionsTTL)}
+}
+
+// cachedClusterOptions returns the cached options of a kind for a cluster, or
+// loads and caches them. Failed loads are not cached.
+func cachedClusterOptions[T any](a *App, kind, region, clusterID string, load func() (*T, error)) (*T, error) {
+	key := kind + "/" + region + "/" + clusterID
+	if value, ok := a.options.get(key, time.Now()); ok {
+		return value.(*T), nil
+	}
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	a.options.put(key, value, time.Now())
+	return value, nil
+}
+
+// GetUpgradeTargetOptions returns the valid upgrade targets for a cluster's
+// engine version.
+func (a *App) GetUpgradeTargetOptions(ctx context.Context, region, clusterID string) (*UpgradeTargetOptions, error) {
+	return cachedClusterOptions(a, "upgrade-targets", region, clusterID, func() (*UpgradeTargetOptions, error) {
+		client, err := a.ClientManager.GetClient(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		info, err := client.GetClusterInfo(ctx, clusterID)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := client.GetValidUpgradeTargets(ctx, info.Engine, info.EngineVersion)
+		if err != nil {
+			return nil, err
+		}
+		if targets == nil {
+			targets = []rds.UpgradeTarget{}
+		}
+		return &UpgradeTargetOptions{
+			ClusterID:      clusterID,
+			Engine:         info.Engine,
+			CurrentVersion: info.EngineVersion,
+			UpgradeTargets: targets,
+		}, nil
+	})
+}
+
+// GetInstanceOptions returns the instance classes orderable for a cluster's
+// engine version.
+func (a *App) GetInstanceOptions(ctx context.Context, region, clusterID string) (*InstanceOptions, error) {
+	return cachedClusterOptions(a, "instance-options", region, clusterID, func() (*InstanceOptions, error) {
+		client, err := a.ClientManager.GetClient(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		info, err := client.GetClusterInfo(ctx, clusterID)
+		if err != nil {
+			return nil, err
+		}
+		instanceTypes, err := client.GetOrderableInstanceTypes(ctx, info.Engine, info.EngineVersion)
+		if err != nil {
+			return nil, err
+		}
+		options := &InstanceOptions{
+			ClusterID:     clusterID,
+			Engine:        info.Engine,
+			EngineVersion: info.EngineVersion,
+			InstanceTypes: instanceTypes,
+		}
+		for _, inst := range info.Instances {
+			if inst.Role == "writer" {
+				options.CurrentInstanceType = inst.InstanceType
+				break
+			}
+		}
+		return options, nil
+	})
+}
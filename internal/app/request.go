@@ -4,15 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"maps"
+	"math"
 	"net/http"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/app/templates"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/app/ui"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
 	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
 )
@@ -22,6 +30,7 @@ type Request struct {
 	Method  string            `json:"method,omitempty"`
 	Path    string            `json:"path,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+	Query   map[string]string `json:"query,omitempty"`
 	Body    []byte            `json:"body,omitempty"`
 }
 
@@ -97,28 +106,84 @@ func (a *App) handleHTTPRequest(ctx context.Context, req Request) Response {
 		return a.handleCreateOperation(ctx, req)
 	case path == "/api/operations" && req.Method == "DELETE":
 		return a.handleDeleteAllOperations(ctx)
+	case path == "/api/operations/active" && req.Method == "GET":
+		return a.handleListActiveOperations(req)
 	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/start") && req.Method == "POST":
 		return a.handleStartOperation(ctx, req, extractOperationID(path, "/start"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/poll") && req.Method == "POST":
+		return a.handlePollOperation(ctx, extractOperationID(path, "/poll"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/approve") && req.Method == "POST":
+		return a.handleApproveOperation(ctx, req, extractOperationID(path, "/approve"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/confirm") && req.Method == "POST":
+		return a.handleConfirmOperation(ctx, extractOperationID(path, "/confirm"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/cancel") && req.Method == "POST":
+		return a.handleCancelOperation(ctx, extractOperationID(path, "/cancel"))
 	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/resume") && req.Method == "POST":
 		return a.handleResumeOperation(ctx, req, extractOperationID(path, "/resume"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/rollback") && req.Method == "POST":
+		return a.handleRollbackOperation(ctx, extractOperationID(path, "/rollback"))
 	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/pause") && req.Method == "POST":
 		return a.handlePauseOperation(ctx, req, extractOperationID(path, "/pause"))
 	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/reset") && req.Method == "POST":
 		return a.handleResetOperation(ctx, req, extractOperationID(path, "/reset"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/events.json") && req.Method == "GET":
+		return a.handleExportEventsJSON(req, extractOperationID(path, "/events.json"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/events.log") && req.Method == "GET":
+		return a.handleExportEventsLog(req, extractOperationID(path, "/events.log"))
 	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/events") && req.Method == "GET":
 		return a.handleGetEvents(req, extractOperationID(path, "/events"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/plan") && req.Method == "GET":
+		return a.handleGetStepPlan(extractOperationID(path, "/plan"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/verify-end-state") && req.Method == "POST":
+		return a.handleVerifyEndState(ctx, extractOperationID(path, "/verify-end-state"))
+	case strings.HasPrefix(path, "/api/operations/") && strings.HasSuffix(path, "/cost-estimate") && req.Method == "GET":
+		return a.handleGetCostEstimate(ctx, extractOperationID(path, "/cost-estimate"))
 	case strings.HasPrefix(path, "/api/operations/") && req.Method == "PATCH":
 		return a.handleUpdateOperation(ctx, req, strings.TrimPrefix(path, "/api/operations/"))
 	case strings.HasPrefix(path, "/api/operations/") && req.Method == "DELETE":
 		return a.handleDeleteOperation(ctx, strings.TrimPrefix(path, "/api/operations/"))
 	case strings.HasPrefix(path, "/api/operations/") && req.Method == "GET":
 		return a.handleGetOperation(req, strings.TrimPrefix(path, "/api/operations/"))
+	case path == "/api/templates" && req.Method == "GET":
+		return jsonResponse(200, a.ListTemplates())
+	case path == "/api/templates" && req.Method == "POST":
+		return a.handleSaveTemplate(ctx, req)
+	case strings.HasPrefix(path, "/api/templates/") && req.Method == "DELETE":
+		return a.handleDeleteTemplate(ctx, strings.TrimPrefix(path, "/api/templates/"))
+	case path == "/api/batch-operations" && req.Method == "POST":
+		return a.handleCreateBatchOperation(ctx, req)
+	case strings.HasPrefix(path, "/api/batch-operations/") && req.Method == "GET":
+		return a.handleGetBatchOperation(strings.TrimPrefix(path, "/api/batch-operations/"))
+	case path == "/api/stats/durations" && req.Method == "GET":
+		return a.handleGetDurationStats(ctx)
+	case path == "/api/interventions" && req.Method == "GET":
+		return a.handleListInterventions()
+	case path == "/api/snapshots/cleanup" && req.Method == "POST":
+		return a.handleCleanupSnapshots(ctx, req)
+	case path == "/api/maintenance/reconcile" && req.Method == "POST":
+		return a.handleReconcileOrphans(ctx, req)
 	case path == "/api/regions" && req.Method == "GET":
 		return a.handleListRegions(ctx)
 	case strings.HasPrefix(path, "/api/regions/") && strings.HasSuffix(path, "/clusters") && req.Method == "GET":
 		region := strings.TrimPrefix(path, "/api/regions/")
 		region = strings.TrimSuffix(region, "/clusters")
-		return a.handleListClusters(ctx, region)
+		return a.handleListClusters(ctx, req, region)
+	case strings.HasPrefix(path, "/api/clusters/") && strings.HasSuffix(path, "/pending-maintenance") && req.Method == "GET":
+		clusterID := strings.TrimPrefix(path, "/api/clusters/")
+		clusterID = strings.TrimSuffix(clusterID, "/pending-maintenance")
+		return a.handleGetPendingMaintenance(ctx, req, clusterID)
+	case strings.HasPrefix(path, "/api/clusters/") && strings.HasSuffix(path, "/upgrade-prereqs") && req.Method == "GET":
+		clusterID := strings.TrimPrefix(path, "/api/clusters/")
+		clusterID = strings.TrimSuffix(clusterID, "/upgrade-prereqs")
+		return a.handleGetUpgradePrerequisites(ctx, req, clusterID)
+	case strings.HasPrefix(path, "/api/clusters/") && strings.HasSuffix(path, "/upgrade-targets") && req.Method == "GET":
+		clusterID := strings.TrimPrefix(path, "/api/clusters/")
+		clusterID = strings.TrimSuffix(clusterID, "/upgrade-targets")
+		return a.handleGetUpgradeTargetOptions(ctx, req, clusterID)
+	case strings.HasPrefix(path, "/api/clusters/") && strings.HasSuffix(path, "/instance-options") && req.Method == "GET":
+		clusterID := strings.TrimPrefix(path, "/api/clusters/")
+		clusterID = strings.TrimSuffix(clusterID, "/instance-options")
+		return a.handleGetInstanceOptions(ctx, req, clusterID)
 	case path == "/api/cluster" && req.Method == "GET":
 		return a.handleGetClusterInfo(ctx, req)
 	case path == "/api/cluster/blue-green" && req.Method == "GET":
@@ -167,43 +232,298 @@ func (a *App) handlePublicConfig() Response {
 	})
 }
 
-// handleListOperations returns all operations.
+// listOperationsParams are the query parameters of a filtered, paginated
+// operations listing.
+var listOperationsParams = []string{"state", "cluster", "type", "limit", "cursor"}
+
+// handleListOperations returns a page of operation summaries, newest first.
+// The state, cluster and type query parameters filter the listing; limit and
+// cursor page through it. Without any of them the full operations are
+// returned, as older clients expect.
 func (a *App) handleListOperations(req Request) Response {
-	return jsonResponse(200, a.ListOperations())
+	if !slices.ContainsFunc(listOperationsParams, func(p string) bool { return req.Query[p] != "" }) {
+		return jsonResponse(200, a.Engine.Operations())
+	}
+
+	filter := types.OperationFilter{
+		State:     types.OperationState(req.Query["state"]),
+		ClusterID: req.Query["cluster"],
+		Type:      types.OperationType(req.Query["type"]),
+		Limit:     constants.DefaultOperationsPageSize,
+		Cursor:    req.Query["cursor"],
+	}
+	if limit := req.Query["limit"]; limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > constants.MaxOperationsPageSize {
+			return errorResponse(400, "limit must be between 1 and "+strconv.Itoa(constants.MaxOperationsPageSize))
+		}
+		filter.Limit = n
+	}
+
+	page, err := a.ListOperations(filter)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, page)
 }
 
 // handleGetOperation returns a single operation.
 func (a *App) handleGetOperation(req Request, id string) Response {
 	op, err := a.GetOperation(id)
 	if err != nil {
-		return errorResponse(404, err.Error())
+		return errorResponseFor(404, err)
 	}
 	return jsonResponse(200, op)
 }
 
 // handleCreateOperation creates a new operation.
 func (a *App) handleCreateOperation(ctx context.Context, req Request) Response {
+	// A template and cluster named in the query need no body, so a runbook
+	// can create a routine operation with ?template=<name>&cluster=<id>. A
+	// body still sets the other fields and overrides the template's params.
 	var createReq CreateOperationRequest
-	if err := json.Unmarshal(req.Body, &createReq); err != nil {
-		return errorResponse(400, "invalid operation request body")
+	if len(req.Body) > 0 || req.Query["template"] == "" {
+		if err := json.Unmarshal(req.Body, &createReq); err != nil {
+			return errorResponse(400, "invalid operation request body")
+		}
+	}
+	if createReq.TemplateID == "" {
+		createReq.TemplateID = req.Query["template"]
+	}
+	if createReq.ClusterID == "" {
+		createReq.ClusterID = req.Query["cluster"]
+	}
+	if createReq.Region == "" {
+		createReq.Region = req.Query["region"]
+	}
+	if key := req.Headers["idempotency-key"]; key != "" {
+		createReq.ClientToken = key
+	}
+	createReq.CreatedBy = a.callerIdentity(req)
+
+	// Overriding the maintenance window is an admin decision.
+	if createReq.OverrideWindow {
+		if resp := a.checkAdminAuth(req); resp != nil {
+			return *resp
+		}
 	}
 
 	op, err := a.CreateOperation(ctx, createReq)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		var windowErr *machine.OutsideWindowError
+		if errors.As(err, &windowErr) {
+			return windowClosedResponse(windowErr)
+		}
+		if errors.Is(err, internalerrors.ErrClusterNotAvailable) || errors.Is(err, internalerrors.ErrClusterBusy) ||
+			errors.Is(err, internalerrors.ErrConcurrentModification) {
+			return errorResponseFor(409, err)
+		}
+		if errors.Is(err, internalerrors.ErrTemplateNotFound) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
 	}
 
 	return jsonResponse(201, op)
 }
 
+// windowClosedResponse rejects an operation requested outside the maintenance
+// windows, telling the caller when it can retry.
+func windowClosedResponse(windowErr *machine.OutsideWindowError) Response {
+	// The window reopens, so unlike other invalid requests this one is worth
+	// retrying.
+	resp := classifiedErrorResponse(409, windowErr.Error(), internalerrors.ClassRetryable, map[string]any{
+		"next_window_start": windowErr.NextStart.UTC().Format(time.RFC3339),
+	})
+	retryAfter := int(math.Ceil(time.Until(windowErr.NextStart).Seconds()))
+	resp.Headers["Retry-After"] = strconv.Itoa(max(retryAfter, 0))
+	return resp
+}
+
+// handleCreateBatchOperation creates one operation per listed cluster and a
+// batch record that tracks them.
+func (a *App) handleCreateBatchOperation(ctx context.Context, req Request) Response {
+	var batchReq CreateBatchOperationRequest
+	if err := json.Unmarshal(req.Body, &batchReq); err != nil {
+		return errorResponse(400, "invalid batch operation request body")
+	}
+	batchReq.CreatedBy = a.callerIdentity(req)
+
+	batch, err := a.CreateBatchOperation(ctx, batchReq)
+	if err != nil {
+		var windowErr *machine.OutsideWindowError
+		if errors.As(err, &windowErr) {
+			return windowClosedResponse(windowErr)
+		}
+		if errors.Is(err, internalerrors.ErrClusterNotAvailable) || errors.Is(err, internalerrors.ErrClusterBusy) {
+			return errorResponseFor(409, err)
+		}
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+
+	return jsonResponse(201, batch)
+}
+
+// handleGetBatchOperation returns a batch operation with its children's states.
+func (a *App) handleGetBatchOperation(id string) Response {
+	batch, err := a.GetBatchOperation(id)
+	if err != nil {
+		return errorResponseFor(404, err)
+	}
+	return jsonResponse(200, batch)
+}
+
+// handleSaveTemplate saves an operation template, either exported from an
+// existing operation or defined directly by type and params.
+func (a *App) handleSaveTemplate(ctx context.Context, req Request) Response {
+	var spec machine.TemplateSpec
+	if err := json.Unmarshal(req.Body, &spec); err != nil {
+		return errorResponse(400, "invalid template request body")
+	}
+
+	tmpl, err := a.SaveTemplate(ctx, spec)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+
+	return jsonResponse(201, tmpl)
+}
+
+// handleDeleteTemplate removes a saved operation template.
+func (a *App) handleDeleteTemplate(ctx context.Context, id string) Response {
+	if err := a.DeleteTemplate(ctx, id); err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, map[string]string{"status": "deleted"})
+}
+
 // handleStartOperation starts an operation.
 func (a *App) handleStartOperation(ctx context.Context, req Request, id string) Response {
 	if err := a.StartOperation(ctx, id); err != nil {
-		return errorResponse(500, err.Error())
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if isConcurrencyLimit(err) {
+			return errorResponseFor(409, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, map[string]string{"status": "started"})
+}
+
+// handleConfirmOperation confirms a dry-run operation and starts it.
+func (a *App) handleConfirmOperation(ctx context.Context, id string) Response {
+	if err := a.ConfirmOperation(ctx, id); err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if isConcurrencyLimit(err) {
+			return errorResponseFor(409, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidState) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, map[string]string{"status": "started"})
+}
+
+// handlePollOperation advances an operation in poll mode and reports whether
+// it still has to be polled.
+func (a *App) handlePollOperation(ctx context.Context, id string) Response {
+	result, err := a.PollOperation(ctx, id)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		if errors.Is(err, internalerrors.ErrOperationAlreadyRunning) {
+			return errorResponseFor(409, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, result)
+}
+
+// handleApproveOperation records a second person's approval of an operation
+// and starts it. The approver is the authenticated caller, never a name from
+// the body.
+func (a *App) handleApproveOperation(ctx context.Context, req Request, id string) Response {
+	if resp := a.checkAdminAuth(req); resp != nil {
+		return *resp
+	}
+	approver := a.callerIdentity(req)
+	if approver == "" {
+		return errorResponse(401, "approving needs an authenticated identity; set APP_IDENTITY_HEADER behind an authenticating proxy")
+	}
+
+	var body struct {
+		Comment string `json:"comment,omitempty"`
+	}
+	if len(req.Body) > 0 {
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			return errorResponse(400, "invalid approve request body")
+		}
+	}
+
+	if err := a.ApproveOperation(ctx, id, approver, body.Comment); err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrSelfApproval) {
+			return errorResponseFor(403, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidState) || isConcurrencyLimit(err) {
+			return errorResponseFor(409, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, map[string]string{"status": "started"})
 }
 
+// handleCancelOperation cancels a running or paused operation.
+func (a *App) handleCancelOperation(ctx context.Context, id string) Response {
+	if err := a.CancelOperation(ctx, id); err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidState) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, map[string]string{"status": "cancelling"})
+}
+
 // handleResumeOperation resumes a paused operation.
 func (a *App) handleResumeOperation(ctx context.Context, req Request, id string) Response {
 	var response types.InterventionResponse
@@ -212,11 +532,38 @@ func (a *App) handleResumeOperation(ctx context.Context, req Request, id string)
 	}
 
 	if err := a.ResumeOperation(ctx, id, response); err != nil {
-		return errorResponse(500, err.Error())
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrOperationNotPaused) {
+			return errorResponseFor(400, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidState) {
+			allowed, _ := a.ResumeOptions(id)
+			return errorResponseWith(400, err, map[string]any{"allowed_decisions": allowed})
+		}
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, map[string]string{"status": "resumed"})
 }
 
+// handleRollbackOperation rolls back a failed or paused operation.
+func (a *App) handleRollbackOperation(ctx context.Context, id string) Response {
+	if err := a.RollbackOperation(ctx, id); err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if isConcurrencyLimit(err) {
+			return errorResponseFor(409, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidState) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, map[string]string{"status": "rolling_back"})
+}
+
 // handlePauseOperation pauses a running operation.
 func (a *App) handlePauseOperation(ctx context.Context, req Request, id string) Response {
 	var body struct {
@@ -229,7 +576,7 @@ func (a *App) handlePauseOperation(ctx context.Context, req Request, id string)
 	}
 
 	if err := a.PauseOperation(ctx, id, body.Reason); err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, map[string]string{"status": "paused"})
 }
@@ -238,11 +585,168 @@ func (a *App) handlePauseOperation(ctx context.Context, req Request, id string)
 func (a *App) handleGetEvents(req Request, id string) Response {
 	events, err := a.GetEvents(id)
 	if err != nil {
-		return errorResponse(404, err.Error())
+		return errorResponseFor(404, err)
+	}
+	return jsonResponse(200, events)
+}
+
+// handleExportEventsJSON returns an operation's full event log in order.
+// ?since= (RFC 3339) returns only the events after that time; passing the
+// timestamp of the last event seen fetches what has happened since.
+func (a *App) handleExportEventsJSON(req Request, id string) Response {
+	since, resp := parseSince(req)
+	if resp != nil {
+		return *resp
+	}
+	events, err := a.GetEventsSince(id, since)
+	if err != nil {
+		return errorResponseFor(404, err)
 	}
 	return jsonResponse(200, events)
 }
 
+// handleExportEventsLog returns an operation's event log as plain text, one
+// line per event, for reading in a post-incident review.
+func (a *App) handleExportEventsLog(req Request, id string) Response {
+	since, resp := parseSince(req)
+	if resp != nil {
+		return *resp
+	}
+	op, err := a.GetOperation(id)
+	if err != nil {
+		return errorResponseFor(404, err)
+	}
+	events, err := a.GetEventsSince(id, since)
+	if err != nil {
+		return errorResponseFor(404, err)
+	}
+	return Response{
+		StatusCode:  200,
+		ContentType: "text/plain; charset=utf-8",
+		Headers:     map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+		Body:        formatEventLog(op, events),
+	}
+}
+
+// parseSince reads the optional ?since= timestamp of an events export.
+func parseSince(req Request) (time.Time, *Response) {
+	raw := req.Query["since"]
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	since, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		resp := errorResponse(400, "since must be an RFC 3339 timestamp")
+		return time.Time{}, &resp
+	}
+	return since, nil
+}
+
+// formatEventLog renders events as lines of UTC time, severity, the step
+// they belong to and the message, under a header naming the operation.
+func formatEventLog(op *types.Operation, events []types.Event) []byte {
+	stepNames := make(map[string]string, len(op.Steps))
+	for i, step := range op.Steps {
+		stepNames[step.ID] = fmt.Sprintf("step %d: %s", i+1, step.Name)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Operation %s: %s of %s in %s\n", op.ID, op.Type, op.ClusterID, op.Region)
+	for _, event := range events {
+		fmt.Fprintf(&b, "%s %-7s ", event.Timestamp.UTC().Format("2006-01-02 15:04:05.000"), strings.ToUpper(string(event.Severity)))
+		if name, ok := stepNames[event.StepID]; ok {
+			fmt.Fprintf(&b, "[%s] ", name)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", event.Type, event.Message)
+	}
+	return b.Bytes()
+}
+
+// handleGetStepPlan returns an operation's steps with their resolved parameters.
+func (a *App) handleGetStepPlan(id string) Response {
+	plan, err := a.GetStepPlan(id)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, plan)
+}
+
+// handleGetCostEstimate returns an estimate of an operation's extra spend.
+func (a *App) handleGetCostEstimate(ctx context.Context, id string) Response {
+	estimate, err := a.GetCostEstimate(ctx, id)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, estimate)
+}
+
+// handleVerifyEndState compares an operation's cluster with its intended end
+// state.
+func (a *App) handleVerifyEndState(ctx context.Context, id string) Response {
+	report, err := a.VerifyEndState(ctx, id)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			return errorResponseFor(404, err)
+		}
+		if errors.Is(err, internalerrors.ErrInvalidParameter) {
+			return errorResponseFor(400, err)
+		}
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, report)
+}
+
+// handleGetDurationStats returns historical duration statistics per operation type.
+func (a *App) handleGetDurationStats(ctx context.Context) Response {
+	stats, err := a.GetDurationStats(ctx)
+	if err != nil {
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, stats)
+}
+
+// handleListActiveOperations lists the operations holding a cluster, so a
+// caller can check a cluster is free before submitting. The x-cluster-id and
+// x-region headers narrow the list.
+func (a *App) handleListActiveOperations(req Request) Response {
+	clusterID := req.Headers["x-cluster-id"]
+	region := req.Headers["x-region"]
+	active := []types.ActiveOperation{}
+	for _, op := range a.ListActiveOperations() {
+		if (clusterID == "" || op.ClusterID == clusterID) && (region == "" || op.Region == region) {
+			active = append(active, op)
+		}
+	}
+	return jsonResponse(200, map[string]any{
+		"operations":                active,
+		"max_concurrent_operations": a.Engine.MaxConcurrentOperations(),
+	})
+}
+
+// isConcurrencyLimit reports whether err refused an operation because its
+// cluster or the engine is already busy.
+func isConcurrencyLimit(err error) bool {
+	return errors.Is(err, internalerrors.ErrClusterBusy) || errors.Is(err, internalerrors.ErrTooManyOperations)
+}
+
+// handleListInterventions returns every operation waiting on an operator.
+func (a *App) handleListInterventions() Response {
+	pending := a.ListInterventions()
+	if pending == nil {
+		pending = []types.PendingIntervention{}
+	}
+	return jsonResponse(200, pending)
+}
+
 // handleResetOperation resets an operation to a specific step in paused state.
 func (a *App) handleResetOperation(ctx context.Context, req Request, id string) Response {
 	var body struct {
@@ -254,14 +758,14 @@ func (a *App) handleResetOperation(ctx context.Context, req Request, id string)
 
 	if err := a.Engine.ResetOperationToStep(ctx, id, body.StepIndex); err != nil {
 		if internalerrors.IsNotFound(err) {
-			return errorResponse(404, err.Error())
+			return errorResponseFor(404, err)
 		}
-		return errorResponse(400, err.Error())
+		return errorResponseFor(400, err)
 	}
 
 	op, err := a.GetOperation(id)
 	if err != nil {
-		return errorResponse(404, err.Error())
+		return errorResponseFor(404, err)
 	}
 	return jsonResponse(200, op)
 }
@@ -270,6 +774,7 @@ func (a *App) handleResetOperation(ctx context.Context, req Request, id string)
 func (a *App) handleUpdateOperation(ctx context.Context, req Request, id string) Response {
 	var body struct {
 		WaitTimeout      int   `json:"wait_timeout"`
+		MaxDuration      int   `json:"max_duration_seconds"`
 		PauseBeforeSteps []int `json:"pause_before_steps"`
 	}
 	if err := json.Unmarshal(req.Body, &body); err != nil {
@@ -278,7 +783,15 @@ func (a *App) handleUpdateOperation(ctx context.Context, req Request, id string)
 
 	if body.WaitTimeout > 0 {
 		if err := a.UpdateOperationTimeout(ctx, id, body.WaitTimeout); err != nil {
-			return errorResponse(500, err.Error())
+			return errorResponseFor(500, err)
+		}
+	}
+	if body.MaxDuration > 0 {
+		if err := a.Engine.UpdateOperationDeadline(ctx, id, body.MaxDuration); err != nil {
+			if internalerrors.IsNotFound(err) {
+				return errorResponseFor(404, err)
+			}
+			return errorResponseFor(500, err)
 		}
 	}
 
@@ -286,15 +799,15 @@ func (a *App) handleUpdateOperation(ctx context.Context, req Request, id string)
 	if body.PauseBeforeSteps != nil {
 		if err := a.Engine.SetPauseBeforeSteps(ctx, id, body.PauseBeforeSteps); err != nil {
 			if internalerrors.IsNotFound(err) {
-				return errorResponse(404, err.Error())
+				return errorResponseFor(404, err)
 			}
-			return errorResponse(400, err.Error())
+			return errorResponseFor(400, err)
 		}
 	}
 
 	op, err := a.GetOperation(id)
 	if err != nil {
-		return errorResponse(404, err.Error())
+		return errorResponseFor(404, err)
 	}
 	return jsonResponse(200, op)
 }
@@ -304,12 +817,12 @@ func (a *App) handleDeleteOperation(ctx context.Context, id string) Response {
 	if err := a.DeleteOperation(ctx, id); err != nil {
 		// Return 400 for invalid state errors, 404 for not found, 500 for others
 		if internalerrors.IsNotFound(err) {
-			return errorResponse(404, err.Error())
+			return errorResponseFor(404, err)
 		}
 		if internalerrors.IsCannotDelete(err) {
-			return errorResponse(400, err.Error())
+			return errorResponseFor(400, err)
 		}
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, map[string]string{"status": "deleted"})
 }
@@ -327,11 +840,47 @@ func (a *App) handleDeleteAllOperations(ctx context.Context) Response {
 	})
 }
 
+// handleCleanupSnapshots deletes the expired maintenance snapshots in the
+// region given by the x-region header, or the default region.
+func (a *App) handleCleanupSnapshots(ctx context.Context, req Request) Response {
+	if resp := a.checkAdminAuth(req); resp != nil {
+		return *resp
+	}
+	region := req.Headers["x-region"]
+	if region == "" {
+		region = a.Config.AWSRegion
+	}
+
+	result, err := a.CleanupSnapshots(ctx, region)
+	if err != nil {
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, result)
+}
+
+// handleReconcileOrphans deletes the orphaned temp instances in the region
+// given by the x-region header, or the default region.
+func (a *App) handleReconcileOrphans(ctx context.Context, req Request) Response {
+	if resp := a.checkAdminAuth(req); resp != nil {
+		return *resp
+	}
+	region := req.Headers["x-region"]
+	if region == "" {
+		region = a.Config.AWSRegion
+	}
+
+	result, err := a.ReconcileOrphans(ctx, region)
+	if err != nil {
+		return errorResponseFor(500, err)
+	}
+	return jsonResponse(200, result)
+}
+
 // handleListRegions returns available AWS regions.
 func (a *App) handleListRegions(ctx context.Context) Response {
 	regions, err := a.ListRegions(ctx)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, map[string]any{
 		"regions":        regions,
@@ -339,11 +888,13 @@ func (a *App) handleListRegions(ctx context.Context) Response {
 	})
 }
 
-// handleListClusters returns Aurora clusters in a region.
-func (a *App) handleListClusters(ctx context.Context, region string) Response {
-	clusters, err := a.ListClusters(ctx, region)
+// handleListClusters returns Aurora clusters in a region. include=instances
+// adds the writer's instance class at the cost of extra RDS calls.
+func (a *App) handleListClusters(ctx context.Context, req Request, region string) Response {
+	includeInstances := slices.Contains(strings.Split(req.Query["include"], ","), "instances")
+	clusters, err := a.ListClusters(ctx, region, includeInstances)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, clusters)
 }
@@ -362,7 +913,7 @@ func (a *App) handleGetClusterInfo(ctx context.Context, req Request) Response {
 
 	info, err := a.GetClusterInfo(ctx, region, clusterID)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, info)
 }
@@ -381,66 +932,75 @@ func (a *App) handleGetBlueGreenDeployments(ctx context.Context, req Request) Re
 
 	deployments, err := a.GetBlueGreenDeployments(ctx, region, clusterID)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 	return jsonResponse(200, deployments)
 }
 
-// handleGetInstanceTypes returns available instance types for a cluster's engine.
+// handleGetInstanceTypes returns available instance types for the cluster
+// named by the x-cluster-id header.
 func (a *App) handleGetInstanceTypes(ctx context.Context, req Request) Response {
 	clusterID := req.Headers["x-cluster-id"]
-	region := req.Headers["x-region"]
-
 	if clusterID == "" {
 		return errorResponse(400, "missing x-cluster-id header")
 	}
+	return a.handleGetInstanceOptions(ctx, req, clusterID)
+}
+
+// handleGetInstanceOptions returns the instance classes orderable for a
+// cluster's engine version and the writer's current class.
+func (a *App) handleGetInstanceOptions(ctx context.Context, req Request, clusterID string) Response {
+	region := req.Headers["x-region"]
+	if clusterID == "" {
+		return errorResponse(400, "missing cluster id")
+	}
 	if region == "" {
 		region = a.Config.AWSRegion
 	}
 
-	client, err := a.ClientManager.GetClient(ctx, region)
+	options, err := a.GetInstanceOptions(ctx, region, clusterID)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return errorResponseFor(404, err)
+		}
+		return errorResponseFor(500, err)
 	}
+	return jsonResponse(200, options)
+}
 
-	// Get cluster info to determine engine and version
-	clusterInfo, err := client.GetClusterInfo(ctx, clusterID)
-	if err != nil {
-		return errorResponse(500, err.Error())
+// handleGetUpgradeTargets returns valid upgrade targets for the cluster named
+// by the x-cluster-id header.
+func (a *App) handleGetUpgradeTargets(ctx context.Context, req Request) Response {
+	clusterID := req.Headers["x-cluster-id"]
+	if clusterID == "" {
+		return errorResponse(400, "missing x-cluster-id header")
 	}
+	return a.handleGetUpgradeTargetOptions(ctx, req, clusterID)
+}
 
-	// Get available instance types
-	instanceTypes, err := client.GetOrderableInstanceTypes(ctx, clusterInfo.Engine, clusterInfo.EngineVersion)
-	if err != nil {
-		return errorResponse(500, err.Error())
+// handleGetUpgradeTargetOptions returns the valid upgrade targets for a
+// cluster's engine version and its current version.
+func (a *App) handleGetUpgradeTargetOptions(ctx context.Context, req Request, clusterID string) Response {
+	region := req.Headers["x-region"]
+	if clusterID == "" {
+		return errorResponse(400, "missing cluster id")
 	}
-
-	// Get current writer instance type for reference
-	var currentInstanceType string
-	for _, inst := range clusterInfo.Instances {
-		if inst.Role == "writer" {
-			currentInstanceType = inst.InstanceType
-			break
-		}
+	if region == "" {
+		region = a.Config.AWSRegion
 	}
 
-	response := struct {
-		CurrentInstanceType string                      `json:"current_instance_type"`
-		Engine              string                      `json:"engine"`
-		EngineVersion       string                      `json:"engine_version"`
-		InstanceTypes       []rds.OrderableInstanceType `json:"instance_types"`
-	}{
-		CurrentInstanceType: currentInstanceType,
-		Engine:              clusterInfo.Engine,
-		EngineVersion:       clusterInfo.EngineVersion,
-		InstanceTypes:       instanceTypes,
+	options, err := a.GetUpgradeTargetOptions(ctx, region, clusterID)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return errorResponseFor(404, err)
+		}
+		return errorResponseFor(500, err)
 	}
-
-	return jsonResponse(200, response)
+	return jsonResponse(200, options)
 }
 
-// handleGetUpgradeTargets returns valid upgrade targets for a cluster's engine version.
-func (a *App) handleGetUpgradeTargets(ctx context.Context, req Request) Response {
+// handleGetClusterEvents returns recent RDS events for a cluster (for debugging).
+func (a *App) handleGetClusterEvents(ctx context.Context, req Request) Response {
 	clusterID := req.Headers["x-cluster-id"]
 	region := req.Headers["x-region"]
 
@@ -453,40 +1013,47 @@ func (a *App) handleGetUpgradeTargets(ctx context.Context, req Request) Response
 
 	client, err := a.ClientManager.GetClient(ctx, region)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
-	// Get cluster info to determine engine and version
-	clusterInfo, err := client.GetClusterInfo(ctx, clusterID)
+	events, err := client.GetRecentClusterEvents(ctx, clusterID, 50)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
-	// Get valid upgrade targets
-	targets, err := client.GetValidUpgradeTargets(ctx, clusterInfo.Engine, clusterInfo.EngineVersion)
-	if err != nil {
-		return errorResponse(500, err.Error())
-	}
+	return jsonResponse(200, map[string]any{
+		"cluster_id": clusterID,
+		"events":     events,
+	})
+}
 
-	// For Aurora PostgreSQL, Blue-Green deployments are generally supported
-	// Include all targets (the SupportsBlueGreen flag is informational)
-	bgTargets := targets
+// handleGetPendingMaintenance returns the pending maintenance actions for a
+// cluster so operators can review them before applying.
+func (a *App) handleGetPendingMaintenance(ctx context.Context, req Request, clusterID string) Response {
+	region := req.Headers["x-region"]
+	if clusterID == "" {
+		return errorResponse(400, "missing cluster id")
+	}
+	if region == "" {
+		region = a.Config.AWSRegion
+	}
 
-	response := struct {
-		CurrentVersion string              `json:"current_version"`
-		Engine         string              `json:"engine"`
-		UpgradeTargets []rds.UpgradeTarget `json:"upgrade_targets"`
-	}{
-		CurrentVersion: clusterInfo.EngineVersion,
-		Engine:         clusterInfo.Engine,
-		UpgradeTargets: bgTargets,
+	actions, err := a.GetPendingMaintenanceActions(ctx, region, clusterID)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return errorResponseFor(404, err)
+		}
+		return errorResponseFor(500, err)
 	}
 
-	return jsonResponse(200, response)
+	return jsonResponse(200, map[string]any{
+		"cluster_id": clusterID,
+		"actions":    actions,
+	})
 }
 
-// handleGetClusterEvents returns recent RDS events for a cluster (for debugging).
-func (a *App) handleGetClusterEvents(ctx context.Context, req Request) Response {
+// handleGetBlueGreenPrerequisites checks if a cluster meets Blue-Green deployment prerequisites.
+func (a *App) handleGetBlueGreenPrerequisites(ctx context.Context, req Request) Response {
 	clusterID := req.Headers["x-cluster-id"]
 	region := req.Headers["x-region"]
 
@@ -499,27 +1066,27 @@ func (a *App) handleGetClusterEvents(ctx context.Context, req Request) Response
 
 	client, err := a.ClientManager.GetClient(ctx, region)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
-	events, err := client.GetRecentClusterEvents(ctx, clusterID, 50)
+	prereqs, err := client.CheckBlueGreenPrerequisites(ctx, clusterID, "")
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
-	return jsonResponse(200, map[string]any{
-		"cluster_id": clusterID,
-		"events":     events,
-	})
+	return jsonResponse(200, prereqs)
 }
 
-// handleGetBlueGreenPrerequisites checks if a cluster meets Blue-Green deployment prerequisites.
-func (a *App) handleGetBlueGreenPrerequisites(ctx context.Context, req Request) Response {
-	clusterID := req.Headers["x-cluster-id"]
+// handleGetUpgradePrerequisites runs the Blue-Green upgrade prerequisite checks
+// for a cluster against the target version in the "target" query parameter.
+func (a *App) handleGetUpgradePrerequisites(ctx context.Context, req Request, clusterID string) Response {
 	region := req.Headers["x-region"]
-
+	target := req.Query["target"]
 	if clusterID == "" {
-		return errorResponse(400, "missing x-cluster-id header")
+		return errorResponse(400, "missing cluster id")
+	}
+	if target == "" {
+		return errorResponse(400, "missing target query parameter")
 	}
 	if region == "" {
 		region = a.Config.AWSRegion
@@ -527,15 +1094,23 @@ func (a *App) handleGetBlueGreenPrerequisites(ctx context.Context, req Request)
 
 	client, err := a.ClientManager.GetClient(ctx, region)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
-	prereqs, err := client.CheckBlueGreenPrerequisites(ctx, clusterID)
+	prereqs, err := client.CheckBlueGreenPrerequisites(ctx, clusterID, target)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return errorResponseFor(404, err)
+		}
+		return errorResponseFor(500, err)
 	}
 
-	return jsonResponse(200, prereqs)
+	return jsonResponse(200, map[string]any{
+		"cluster_id":     clusterID,
+		"target_version": target,
+		"passed":         len(prereqs.FailedRequired()) == 0,
+		"checks":         prereqs.Checks,
+	})
 }
 
 // handleGetClusterProxies returns RDS Proxies targeting a cluster.
@@ -552,13 +1127,13 @@ func (a *App) handleGetClusterProxies(ctx context.Context, req Request) Response
 
 	client, err := a.ClientManager.GetClient(ctx, region)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
 	// Find proxies targeting this cluster
 	proxies, err := client.FindProxiesForCluster(ctx, clusterID)
 	if err != nil {
-		return errorResponse(500, err.Error())
+		return errorResponseFor(500, err)
 	}
 
 	response := struct {
@@ -760,8 +1335,50 @@ func jsonResponse(status int, data any) Response {
 	}
 }
 
+// errorResponse answers with an error message, classed by the status alone.
+// Prefer errorResponseFor when the error is at hand.
 func errorResponse(status int, message string) Response {
-	body, _ := json.Marshal(map[string]string{"error": message})
+	return classifiedErrorResponse(status, message, statusErrorClass(status), nil)
+}
+
+// errorResponseFor answers with err, classed by the sentinels it wraps so
+// callers such as workflow engines can tell whether retrying can help.
+func errorResponseFor(status int, err error) Response {
+	return errorResponseWith(status, err, nil)
+}
+
+// errorResponseWith answers like errorResponseFor, adding fields to the body
+// that tell the caller what it can do next.
+func errorResponseWith(status int, err error, fields map[string]any) Response {
+	// Whatever a handler makes of it, a refusal to take work while the
+	// server drains is temporary.
+	if errors.Is(err, internalerrors.ErrShuttingDown) {
+		status = 503
+	}
+	class := internalerrors.Classify(err)
+	if class == "" {
+		class = statusErrorClass(status)
+	}
+	return classifiedErrorResponse(status, err.Error(), class, fields)
+}
+
+// statusErrorClass classes an error that has nothing but its status code:
+// conflicts, rate limits and unavailability are retryable, anything else is
+// not.
+func statusErrorClass(status int) internalerrors.Class {
+	switch status {
+	case 408, 409, 425, 429, 502, 503, 504:
+		return internalerrors.ClassRetryable
+	}
+	return internalerrors.ClassTerminal
+}
+
+// classifiedErrorResponse builds every error body: the message, its class
+// and any fields the caller adds.
+func classifiedErrorResponse(status int, message string, class internalerrors.Class, fields map[string]any) Response {
+	data := map[string]any{"error": message, "error_class": string(class)}
+	maps.Copy(data, fields)
+	body, _ := json.Marshal(data)
 	return Response{
 		StatusCode:  status,
 		ContentType: "application/json",
@@ -770,6 +1387,17 @@ func errorResponse(status int, message string) Response {
 	}
 }
 
+// callerIdentity returns the authenticated caller's identity: the value of
+// the APP_IDENTITY_HEADER header, which the authenticating proxy in front of
+// the server sets. It is empty when no identity header is configured or the
+// request carries none.
+func (a *App) callerIdentity(req Request) string {
+	if a.Config.IdentityHeader == "" {
+		return ""
+	}
+	return strings.TrimSpace(req.Headers[strings.ToLower(a.Config.IdentityHeader)])
+}
+
 func (a *App) checkAdminAuth(req Request) *Response {
 	if a.Config.AdminToken == "" {
 		return nil
@@ -2,13 +2,25 @@ package app
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/config"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
 )
 
 // testApp creates a minimal App for testing HTTP routing.
@@ -56,6 +68,30 @@ func TestHandleRequest_HTTPRouting(t *testing.T) {
 			path:       "/api/operations",
 			wantStatus: 200,
 		},
+		{
+			name:       "GET /api/stats/durations returns stats",
+			method:     "GET",
+			path:       "/api/stats/durations",
+			wantStatus: 200,
+		},
+		{
+			name:       "GET cost estimate of unknown operation returns 404",
+			method:     "GET",
+			path:       "/api/operations/missing/cost-estimate",
+			wantStatus: 404,
+		},
+		{
+			name:       "POST verify end state of unknown operation returns 404",
+			method:     "POST",
+			path:       "/api/operations/missing/verify-end-state",
+			wantStatus: 404,
+		},
+		{
+			name:       "GET /api/interventions returns list",
+			method:     "GET",
+			path:       "/api/interventions",
+			wantStatus: 200,
+		},
 		{
 			name:       "GET unknown path returns 404",
 			method:     "GET",
@@ -82,6 +118,18 @@ func TestHandleRequest_HTTPRouting(t *testing.T) {
 			headers:    map[string]string{"authorization": "Bearer test-admin-token"},
 			wantStatus: 200,
 		},
+		{
+			name:       "POST /api/snapshots/cleanup without auth returns 401",
+			method:     "POST",
+			path:       "/api/snapshots/cleanup",
+			wantStatus: 401,
+		},
+		{
+			name:       "POST /api/maintenance/reconcile without auth returns 401",
+			method:     "POST",
+			path:       "/api/maintenance/reconcile",
+			wantStatus: 401,
+		},
 		{
 			name:       "GET /server/config with auth returns 200",
 			method:     "GET",
@@ -102,6 +150,76 @@ func TestHandleRequest_HTTPRouting(t *testing.T) {
 			path:       "/api/operations/nonexistent-id",
 			wantStatus: 404,
 		},
+		{
+			name:       "POST confirm on nonexistent operation returns 404",
+			method:     "POST",
+			path:       "/api/operations/nonexistent-id/confirm",
+			wantStatus: 404,
+		},
+		{
+			name:       "GET plan on nonexistent operation returns 404",
+			method:     "GET",
+			path:       "/api/operations/nonexistent-id/plan",
+			wantStatus: 404,
+		},
+		{
+			name:       "POST cancel on nonexistent operation returns 404",
+			method:     "POST",
+			path:       "/api/operations/nonexistent-id/cancel",
+			wantStatus: 404,
+		},
+		{
+			name:       "POST rollback on nonexistent operation returns 404",
+			method:     "POST",
+			path:       "/api/operations/nonexistent-id/rollback",
+			wantStatus: 404,
+		},
+		{
+			name:       "GET /api/templates returns list",
+			method:     "GET",
+			path:       "/api/templates",
+			wantStatus: 200,
+		},
+		{
+			name:       "POST /api/templates without name returns 400",
+			method:     "POST",
+			path:       "/api/templates",
+			body:       []byte(`{"type":"instance_cycle"}`),
+			wantStatus: 400,
+		},
+		{
+			name:       "POST /api/templates from nonexistent operation returns 404",
+			method:     "POST",
+			path:       "/api/templates",
+			body:       []byte(`{"name":"cycle","operation_id":"nonexistent-id"}`),
+			wantStatus: 404,
+		},
+		{
+			name:       "POST /api/batch-operations without clusters returns 400",
+			method:     "POST",
+			path:       "/api/batch-operations",
+			body:       []byte(`{"type":"instance_cycle","cluster_ids":[]}`),
+			wantStatus: 400,
+		},
+		{
+			name:       "GET nonexistent batch operation returns 404",
+			method:     "GET",
+			path:       "/api/batch-operations/nonexistent-id",
+			wantStatus: 404,
+		},
+		{
+			name:       "DELETE nonexistent template returns 404",
+			method:     "DELETE",
+			path:       "/api/templates/nonexistent-id",
+			wantStatus: 404,
+		},
+		{
+			name:       "POST /api/operations with nonexistent template returns 404",
+			method:     "POST",
+			path:       "/api/operations",
+			body:       []byte(`{"template_id":"nonexistent-id","cluster_id":"demo-multi"}`),
+			wantStatus: 404,
+		},
 		{
 			name:       "GET /static/styles.css returns CSS",
 			method:     "GET",
@@ -194,6 +312,264 @@ func TestHandleRequest_BasePath(t *testing.T) {
 	}
 }
 
+func TestHandleRequest_OutsideMaintenanceWindow(t *testing.T) {
+	opens := time.Now().Add(12 * time.Hour).UTC()
+	closes := opens.Add(time.Hour)
+	spec := fmt.Sprintf("%s:%02d:%02d-%s:%02d:%02d",
+		opens.Weekday().String()[:3], opens.Hour(), opens.Minute(),
+		closes.Weekday().String()[:3], closes.Hour(), closes.Minute())
+	window, err := maintwindow.Parse(spec, "UTC")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	cfg := &config.Config{AWSRegion: "us-east-1", AdminToken: "test-admin-token"}
+	engine := machine.NewEngine(machine.EngineConfig{
+		Store:             &storage.NullStore{},
+		DefaultRegion:     "us-east-1",
+		MaintenanceWindow: window,
+	})
+	app := NewWithEngine(cfg, engine, &notifiers.NullNotifier{})
+	ctx := context.Background()
+
+	resp := app.HandleRequest(ctx, Request{
+		Method: "POST",
+		Path:   "/api/operations",
+		Body:   []byte(`{"type":"instance_cycle","cluster_id":"demo-single"}`),
+	})
+	if resp.StatusCode != 409 {
+		t.Fatalf("got status %d, want 409. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var body map[string]string
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["next_window_start"] != opens.Truncate(time.Minute).Format(time.RFC3339) {
+		t.Errorf("next_window_start = %q, want %s", body["next_window_start"], opens.Truncate(time.Minute).Format(time.RFC3339))
+	}
+	if resp.Headers["Retry-After"] == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// Overriding the window needs the admin token.
+	resp = app.HandleRequest(ctx, Request{
+		Method: "POST",
+		Path:   "/api/operations",
+		Body:   []byte(`{"type":"instance_cycle","cluster_id":"demo-single","override_window":true}`),
+	})
+	if resp.StatusCode != 401 {
+		t.Errorf("override without admin token: got status %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestHandleRequest_PendingMaintenance(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		cluster     string
+		wantStatus  int
+		wantActions int
+	}{
+		{cluster: "demo-multi", wantStatus: 200, wantActions: 2},
+		{cluster: "demo-single", wantStatus: 200, wantActions: 0},
+		{cluster: "missing", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cluster, func(t *testing.T) {
+			resp := app.HandleRequest(ctx, Request{Method: "GET", Path: "/api/clusters/" + tt.cluster + "/pending-maintenance"})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got status %d, want %d. Body: %s", resp.StatusCode, tt.wantStatus, string(resp.Body))
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+			var body struct {
+				Actions []rds.PendingMaintenanceAction `json:"actions"`
+			}
+			if err := json.Unmarshal(resp.Body, &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Actions == nil || len(body.Actions) != tt.wantActions {
+				t.Errorf("actions = %v, want %d (never null)", body.Actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+func TestHandleRequest_ClusterOptions(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	ctx := context.Background()
+
+	get := func(path string) Response {
+		t.Helper()
+		return app.HandleRequest(ctx, Request{Method: "GET", Path: path})
+	}
+
+	resp := get("/api/clusters/demo-upgrade/upgrade-targets")
+	if resp.StatusCode != 200 {
+		t.Fatalf("upgrade-targets: got status %d. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var targets UpgradeTargetOptions
+	if err := json.Unmarshal(resp.Body, &targets); err != nil {
+		t.Fatalf("decode upgrade targets: %v", err)
+	}
+	if targets.ClusterID != "demo-upgrade" || targets.CurrentVersion == "" || len(targets.UpgradeTargets) == 0 {
+		t.Errorf("upgrade targets = %+v, want the current version and some targets", targets)
+	}
+
+	resp = get("/api/clusters/demo-upgrade/instance-options")
+	if resp.StatusCode != 200 {
+		t.Fatalf("instance-options: got status %d. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var options InstanceOptions
+	if err := json.Unmarshal(resp.Body, &options); err != nil {
+		t.Fatalf("decode instance options: %v", err)
+	}
+	if options.CurrentInstanceType == "" || len(options.InstanceTypes) == 0 {
+		t.Fatalf("instance options = %+v, want the writer's class and some options", options)
+	}
+	seen := map[string]bool{}
+	for _, it := range options.InstanceTypes {
+		if seen[it.InstanceClass] {
+			t.Errorf("instance class %s listed twice", it.InstanceClass)
+		}
+		seen[it.InstanceClass] = true
+	}
+
+	if resp := get("/api/clusters/missing/instance-options"); resp.StatusCode != 404 {
+		t.Errorf("missing cluster: got status %d, want 404", resp.StatusCode)
+	}
+
+	// Both are answered from the cache once RDS is gone.
+	server.Close()
+	for _, path := range []string{"/api/clusters/demo-upgrade/upgrade-targets", "/api/clusters/demo-upgrade/instance-options"} {
+		if resp := get(path); resp.StatusCode != 200 {
+			t.Errorf("%s after RDS went away: got status %d, want a cached 200", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandleRequest_UpgradePrerequisites(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		cluster    string
+		target     string
+		wantStatus int
+		wantPassed bool
+	}{
+		{cluster: "demo-upgrade", target: "16.1", wantStatus: 200, wantPassed: true},
+		{cluster: "demo-single", target: "16.1", wantStatus: 200, wantPassed: false},
+		{cluster: "demo-upgrade", target: "", wantStatus: 400},
+		{cluster: "missing", target: "16.1", wantStatus: 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cluster+"/"+tt.target, func(t *testing.T) {
+			resp := app.HandleRequest(ctx, Request{
+				Method: "GET",
+				Path:   "/api/clusters/" + tt.cluster + "/upgrade-prereqs",
+				Query:  map[string]string{"target": tt.target},
+			})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got status %d, want %d. Body: %s", resp.StatusCode, tt.wantStatus, string(resp.Body))
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+			var body struct {
+				Passed bool                    `json:"passed"`
+				Checks []rds.PrerequisiteCheck `json:"checks"`
+			}
+			if err := json.Unmarshal(resp.Body, &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Passed != tt.wantPassed || len(body.Checks) == 0 {
+				t.Errorf("passed = %v with checks %+v, want passed = %v", body.Passed, body.Checks, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestHandleRequest_ListOperations(t *testing.T) {
+	app := testApp(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		query      map[string]string
+		wantStatus int
+	}{
+		{name: "default page", query: map[string]string{"limit": "50"}, wantStatus: 200},
+		{name: "filters", query: map[string]string{"state": "running", "cluster": "demo-multi", "type": "instance_cycle", "limit": "10"}, wantStatus: 200},
+		{name: "zero limit", query: map[string]string{"limit": "0"}, wantStatus: 400},
+		{name: "limit too large", query: map[string]string{"limit": "501"}, wantStatus: 400},
+		{name: "invalid cursor", query: map[string]string{"cursor": "garbage!"}, wantStatus: 400},
+	}
+	t.Run("no parameters returns full operations", func(t *testing.T) {
+		resp := app.HandleRequest(ctx, Request{Method: "GET", Path: "/api/operations"})
+		var ops []types.Operation
+		if err := json.Unmarshal(resp.Body, &ops); err != nil {
+			t.Fatalf("expected a list of operations, got %s: %v", string(resp.Body), err)
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := app.HandleRequest(ctx, Request{Method: "GET", Path: "/api/operations", Query: tt.query})
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("got status %d, want %d. Body: %s", resp.StatusCode, tt.wantStatus, string(resp.Body))
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+			var page types.OperationPage
+			if err := json.Unmarshal(resp.Body, &page); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if page.Operations == nil {
+				t.Errorf("operations = null, want a list")
+			}
+		})
+	}
+}
+
 func TestIsStaticPath(t *testing.T) {
 	tests := []struct {
 		path string
@@ -232,3 +608,487 @@ func stringContains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestHandleRequest_ResumeDecisions(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+	op := &types.Operation{
+		ID:          "op-paused-check",
+		Type:        types.OperationTypeInstanceCycle,
+		State:       types.StatePaused,
+		ClusterID:   "demo-multi",
+		Region:      "us-east-1",
+		PauseReason: "Preflight check failed",
+		Steps: []types.Step{
+			{ID: "s1", Name: "Get cluster info", Action: "get_cluster_info", State: types.StepStateCompleted},
+			{ID: "s2", Name: "Preflight check", Action: "preflight_check", State: types.StepStateWaiting},
+		},
+		CurrentStepIndex: 1,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
+	if err := store.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	engine := machine.NewEngine(machine.EngineConfig{Store: store})
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	app := NewWithEngine(&config.Config{AWSRegion: "us-east-1", DemoMode: true}, engine, &notifiers.NullNotifier{})
+
+	resume := func(id, action string) Response {
+		body, _ := json.Marshal(types.InterventionResponse{Action: action, Operator: "alice"})
+		return app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations/" + id + "/resume", Body: body})
+	}
+
+	resp := resume(op.ID, "mark_complete")
+	if resp.StatusCode != 400 {
+		t.Fatalf("got status %d, want 400. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var body struct {
+		Error            string   `json:"error"`
+		AllowedDecisions []string `json:"allowed_decisions"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if want := []string{"continue", "override", "abort"}; fmt.Sprint(body.AllowedDecisions) != fmt.Sprint(want) {
+		t.Errorf("allowed_decisions = %v, want %v", body.AllowedDecisions, want)
+	}
+
+	if resp := resume("missing", "continue"); resp.StatusCode != 404 {
+		t.Errorf("unknown operation: got status %d, want 404", resp.StatusCode)
+	}
+
+	if resp := resume(op.ID, "abort"); resp.StatusCode != 200 {
+		t.Fatalf("abort: got status %d, want 200. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	events, _ := engine.GetEvents(op.ID)
+	var audited bool
+	for _, event := range events {
+		if event.Type == "operator_decision" && event.Message == "alice chose abort" {
+			audited = true
+		}
+	}
+	if !audited {
+		t.Errorf("expected an operator_decision event for alice, got %+v", events)
+	}
+}
+
+func TestHandleRequest_ExportEvents(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	op := &types.Operation{
+		ID:        "op-export",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateCompleted,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "s1", Name: "Reboot reader", Action: "reboot_instance", State: types.StepStateCompleted},
+		},
+		CreatedAt: start,
+		UpdatedAt: start,
+	}
+	if err := store.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	// The first event predates severities and is classified on load.
+	for i, event := range []types.Event{
+		{Type: "operation_started", Message: "Operation started"},
+		{Type: "warning", StepID: "s1", Severity: types.EventSeverityWarning, Message: "temp instance still deleting"},
+		{Type: "operation_completed", Severity: types.EventSeverityInfo, Message: "Operation completed"},
+	} {
+		event.ID = fmt.Sprintf("e%d", i)
+		event.OperationID = op.ID
+		event.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if err := store.AppendEvent(ctx, event); err != nil {
+			t.Fatalf("AppendEvent failed: %v", err)
+		}
+	}
+	engine := machine.NewEngine(machine.EngineConfig{Store: store})
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	app := NewWithEngine(&config.Config{AWSRegion: "us-east-1", DemoMode: true}, engine, &notifiers.NullNotifier{})
+
+	get := func(path string, query map[string]string) Response {
+		return app.HandleRequest(ctx, Request{Method: "GET", Path: path, Query: query})
+	}
+
+	resp := get("/api/operations/op-export/events.json", nil)
+	if resp.StatusCode != 200 {
+		t.Fatalf("events.json: got status %d. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var events []types.Event
+	if err := json.Unmarshal(resp.Body, &events); err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if len(events) != 3 || events[0].Severity != types.EventSeverityInfo || events[1].StepID != "s1" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	resp = get("/api/operations/op-export/events.json", map[string]string{"since": events[0].Timestamp.Format(time.RFC3339Nano)})
+	var later []types.Event
+	if err := json.Unmarshal(resp.Body, &later); err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if len(later) != 2 || later[0].ID != "e1" {
+		t.Errorf("since the first event: got %+v, want e1 and e2", later)
+	}
+
+	if resp := get("/api/operations/op-export/events.json", map[string]string{"since": "yesterday"}); resp.StatusCode != 400 {
+		t.Errorf("invalid since: got status %d, want 400", resp.StatusCode)
+	}
+	if resp := get("/api/operations/missing/events.log", nil); resp.StatusCode != 404 {
+		t.Errorf("unknown operation: got status %d, want 404", resp.StatusCode)
+	}
+
+	resp = get("/api/operations/op-export/events.log", nil)
+	if resp.StatusCode != 200 || !strings.HasPrefix(resp.ContentType, "text/plain") {
+		t.Fatalf("events.log: got status %d, content type %q", resp.StatusCode, resp.ContentType)
+	}
+	want := "# Operation op-export: instance_cycle of demo-multi in us-east-1\n" +
+		"2026-03-01 02:00:00.000 INFO    operation_started: Operation started\n" +
+		"2026-03-01 02:00:01.000 WARNING [step 1: Reboot reader] warning: temp instance still deleting\n" +
+		"2026-03-01 02:00:02.000 INFO    operation_completed: Operation completed\n"
+	if got := string(resp.Body); got != want {
+		t.Errorf("events.log =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHandleRequest_ListClusters(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	if err := store.SaveOperation(ctx, &types.Operation{
+		ID:        "op-running",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	app := testApp(t)
+	app.Engine = machine.NewEngine(machine.EngineConfig{Store: store, DefaultRegion: "us-east-1"})
+	if _, err := app.Engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+
+	list := func(query map[string]string) map[string]types.ClusterSummary {
+		t.Helper()
+		resp := app.HandleRequest(ctx, Request{Method: "GET", Path: "/api/regions/us-east-1/clusters", Query: query})
+		if resp.StatusCode != 200 {
+			t.Fatalf("got status %d. Body: %s", resp.StatusCode, string(resp.Body))
+		}
+		var clusters []types.ClusterSummary
+		if err := json.Unmarshal(resp.Body, &clusters); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		byID := make(map[string]types.ClusterSummary, len(clusters))
+		for _, c := range clusters {
+			byID[c.ClusterID] = c
+		}
+		return byID
+	}
+
+	clusters := list(nil)
+	if got := clusters["demo-multi"].ActiveOperationID; got != "op-running" {
+		t.Errorf("demo-multi active operation = %q, want op-running", got)
+	}
+	if got := clusters["demo-single"].ActiveOperationID; got != "" {
+		t.Errorf("demo-single active operation = %q, want none", got)
+	}
+	if got := clusters["demo-multi"].WriterInstanceClass; got != "" {
+		t.Errorf("writer class %q returned without include=instances", got)
+	}
+
+	clusters = list(map[string]string{"include": "instances"})
+	if got := clusters["demo-multi"].WriterInstanceClass; got != "db.r6g.large" {
+		t.Errorf("demo-multi writer class = %q, want db.r6g.large", got)
+	}
+}
+
+func TestHandleRequest_ApproveOperation(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	ctx := context.Background()
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	app.Engine = machine.NewEngine(machine.EngineConfig{ClientManager: app.ClientManager, DefaultRegion: "us-east-1"})
+	app.Config.IdentityHeader = "X-Auth-User"
+
+	const body = `{"type":"instance_cycle","cluster_id":"demo-single","region":"us-east-1","requires_approval":true,"created_by":"bob"}`
+	create := func(user string) Response {
+		headers := map[string]string{}
+		if user != "" {
+			headers["x-auth-user"] = user
+		}
+		return app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations", Headers: headers, Body: []byte(body)})
+	}
+	// A self-reported created_by is not an identity.
+	resp := create("")
+	if resp.StatusCode != 400 {
+		t.Errorf("without an authenticated creator: got status %d, want 400. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	resp = create("alice")
+	if resp.StatusCode != 201 {
+		t.Fatalf("got status %d, want 201. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var op types.Operation
+	if err := json.Unmarshal(resp.Body, &op); err != nil {
+		t.Fatalf("decode operation: %v", err)
+	}
+	if op.State != types.StatePendingApproval || op.CreatedBy != "alice" {
+		t.Errorf("state %s created by %q, want pending_approval created by alice", op.State, op.CreatedBy)
+	}
+
+	approve := func(id, user, token string) Response {
+		headers := map[string]string{"authorization": "Bearer " + token}
+		if user != "" {
+			headers["x-auth-user"] = user
+		}
+		return app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations/" + id + "/approve",
+			Headers: headers, Body: []byte(`{"approver":"bob"}`)})
+	}
+	for _, tt := range []struct {
+		name  string
+		id    string
+		user  string
+		token string
+		want  int
+	}{
+		{name: "no admin token", id: op.ID, user: "bob", token: "wrong", want: 401},
+		{name: "no authenticated approver", id: op.ID, user: "", token: "test-admin-token", want: 401},
+		{name: "unknown operation", id: "missing", user: "bob", token: "test-admin-token", want: 404},
+		{name: "self-approval", id: op.ID, user: "alice", token: "test-admin-token", want: 403},
+		{name: "second person", id: op.ID, user: "bob", token: "test-admin-token", want: 200},
+		{name: "already approved", id: op.ID, user: "carol", token: "test-admin-token", want: 409},
+	} {
+		if resp := approve(tt.id, tt.user, tt.token); resp.StatusCode != tt.want {
+			t.Errorf("%s: got status %d, want %d. Body: %s", tt.name, resp.StatusCode, tt.want, string(resp.Body))
+		}
+	}
+	if got, _ := app.GetOperation(op.ID); got.ApprovedBy != "bob" {
+		t.Errorf("approved by %q, want bob", got.ApprovedBy)
+	}
+}
+
+func TestHandleRequest_ErrorClass(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	ctx := context.Background()
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	app.Engine = machine.NewEngine(machine.EngineConfig{ClientManager: app.ClientManager, DefaultRegion: "us-east-1"})
+
+	errorClass := func(resp Response) string {
+		t.Helper()
+		var body struct {
+			ErrorClass string `json:"error_class"`
+		}
+		if err := json.Unmarshal(resp.Body, &body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		return body.ErrorClass
+	}
+	create := func(clusterID, params string) Response {
+		body := `{"type":"instance_type_change","cluster_id":"` + clusterID + `","region":"us-east-1","params":` + params + `}`
+		return app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations", Body: []byte(body)})
+	}
+
+	resp := create("demo-multi", `{"target_instance_type":"db.r6g.24xlarge"}`)
+	if resp.StatusCode != 400 || errorClass(resp) != "terminal" {
+		t.Errorf("unorderable class: got status %d, class %q; want 400, terminal", resp.StatusCode, errorClass(resp))
+	}
+
+	resp = app.HandleRequest(ctx, Request{Method: "GET", Path: "/api/operations/missing"})
+	if resp.StatusCode != 404 || errorClass(resp) != "terminal" {
+		t.Errorf("unknown operation: got status %d, class %q; want 404, terminal", resp.StatusCode, errorClass(resp))
+	}
+
+	state.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeAPIError,
+		Action:      "DescribeDBClusters",
+		ErrorCode:   "Throttling",
+		Probability: 1,
+		Enabled:     true,
+	})
+	resp = create("demo-single", `{"target_instance_type":"db.r6g.xlarge"}`)
+	if resp.StatusCode != 500 || errorClass(resp) != "retryable" {
+		t.Errorf("throttled: got status %d, class %q; want 500, retryable. Body: %s", resp.StatusCode, errorClass(resp), string(resp.Body))
+	}
+
+	state.Faults().ClearAll()
+	if _, err := app.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	resp = create("demo-single", `{"target_instance_type":"db.r6g.xlarge"}`)
+	if resp.StatusCode != 503 || errorClass(resp) != "retryable" {
+		t.Errorf("shutting down: got status %d, class %q; want 503, retryable", resp.StatusCode, errorClass(resp))
+	}
+}
+
+func TestHandleRequest_PollOperation(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	ctx := context.Background()
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	app.Engine = machine.NewEngine(machine.EngineConfig{ClientManager: app.ClientManager, DefaultRegion: "us-east-1"})
+
+	create := func(body string) types.Operation {
+		t.Helper()
+		resp := app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations", Body: []byte(body)})
+		if resp.StatusCode != 201 {
+			t.Fatalf("got status %d, want 201. Body: %s", resp.StatusCode, string(resp.Body))
+		}
+		var op types.Operation
+		if err := json.Unmarshal(resp.Body, &op); err != nil {
+			t.Fatalf("decode operation: %v", err)
+		}
+		return op
+	}
+	poll := func(id string) Response {
+		return app.HandleRequest(ctx, Request{Method: "POST", Path: "/api/operations/" + id + "/poll"})
+	}
+
+	op := create(`{"type":"instance_cycle","cluster_id":"demo-single","region":"us-east-1","poll_mode":true}`)
+	if !op.PollMode {
+		t.Error("operation should be in poll mode")
+	}
+	resp := poll(op.ID)
+	if resp.StatusCode != 200 {
+		t.Fatalf("got status %d, want 200. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var result types.PollResult
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		t.Fatalf("decode poll result: %v", err)
+	}
+	if result.Ready || result.OperationID != op.ID {
+		t.Errorf("result = %+v, want operation %s not ready", result, op.ID)
+	}
+
+	blocking := create(`{"type":"instance_cycle","cluster_id":"demo-multi","region":"us-east-1"}`)
+	if resp := poll(blocking.ID); resp.StatusCode != 400 {
+		t.Errorf("not in poll mode: got status %d, want 400. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	if resp := poll("missing"); resp.StatusCode != 404 {
+		t.Errorf("unknown operation: got status %d, want 404. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+}
+
+func TestHandleRequest_CreateFromConfiguredTemplate(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "templates.json")
+	file := `[{"name":"scale-up-r6g-xlarge","type":"instance_type_change","params":{"target_instance_type":"db.r6g.xlarge"}}]`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := machine.LoadTemplates(path)
+	if err != nil {
+		t.Fatalf("LoadTemplates failed: %v", err)
+	}
+
+	ctx := context.Background()
+	app := testApp(t)
+	app.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    server.URL,
+	})
+	app.Engine = machine.NewEngine(machine.EngineConfig{
+		ClientManager: app.ClientManager,
+		DefaultRegion: "us-east-1",
+		Templates:     templates,
+	})
+
+	resp := app.HandleRequest(ctx, Request{
+		Method: "POST",
+		Path:   "/api/operations",
+		Query:  map[string]string{"template": "scale-up-r6g-xlarge", "cluster": "demo-single"},
+	})
+	if resp.StatusCode != 201 {
+		t.Fatalf("got status %d, want 201. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	var op types.Operation
+	if err := json.Unmarshal(resp.Body, &op); err != nil {
+		t.Fatalf("decode operation: %v", err)
+	}
+	if op.Type != types.OperationTypeInstanceTypeChange || op.ClusterID != "demo-single" {
+		t.Errorf("operation = %s on %s, want instance_type_change on demo-single", op.Type, op.ClusterID)
+	}
+
+	// A body overrides the template's params.
+	resp = app.HandleRequest(ctx, Request{
+		Method: "POST",
+		Path:   "/api/operations",
+		Query:  map[string]string{"template": "scale-up-r6g-xlarge", "cluster": "demo-multi"},
+		Body:   []byte(`{"params":{"target_instance_type":"db.r6g.2xlarge"}}`),
+	})
+	if resp.StatusCode != 201 {
+		t.Fatalf("with overrides: got status %d, want 201. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+	if err := json.Unmarshal(resp.Body, &op); err != nil {
+		t.Fatalf("decode operation: %v", err)
+	}
+	if !strings.Contains(string(op.Parameters), "db.r6g.2xlarge") {
+		t.Errorf("params = %s, want the override", op.Parameters)
+	}
+
+	resp = app.HandleRequest(ctx, Request{Method: "DELETE", Path: "/api/templates/scale-up-r6g-xlarge"})
+	if resp.StatusCode != 400 {
+		t.Errorf("deleting a configured template: got status %d, want 400. Body: %s", resp.StatusCode, string(resp.Body))
+	}
+}
@@ -115,7 +115,9 @@ function renderFaults(faults) {
         api_error: 'API Error',
         delay: 'Extra Delay',
         stuck: 'Stuck in State',
-        partial_fail: 'Partial Fail'
+        partial_fail: 'Partial Fail',
+        intermittent: 'Intermittent',
+        task_fail: 'Blue-Green Task Fail'
     };
     
     container.innerHTML = faults.map(fault => {
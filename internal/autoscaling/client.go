@@ -0,0 +1,148 @@
+// Package autoscaling reads and suspends the Application Auto Scaling of
+// Aurora read replicas for the clusters the machine operates on.
+package autoscaling
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/applicationautoscaling/types"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// serviceNamespace is the Application Auto Scaling namespace of RDS.
+	serviceNamespace = types.ServiceNamespaceRds
+	// readReplicaCount is the scalable dimension of an Aurora cluster's
+	// readers.
+	readReplicaCount = types.ScalableDimensionRDSClusterReadReplicaCount
+)
+
+// ResourceID returns the Application Auto Scaling resource ID of a cluster.
+func ResourceID(clusterID string) string {
+	return "cluster:" + clusterID
+}
+
+// SuspendedState says which kinds of scaling are suspended for a cluster.
+type SuspendedState struct {
+	DynamicScalingIn  bool `json:"dynamic_scaling_in"`
+	DynamicScalingOut bool `json:"dynamic_scaling_out"`
+	ScheduledScaling  bool `json:"scheduled_scaling"`
+}
+
+// FullySuspended suspends every kind of scaling.
+var FullySuspended = SuspendedState{DynamicScalingIn: true, DynamicScalingOut: true, ScheduledScaling: true}
+
+// ClusterScaling is the read replica auto scaling registered for a cluster.
+type ClusterScaling struct {
+	ClusterID   string         `json:"cluster_id"`
+	MinCapacity int32          `json:"min_capacity"`
+	MaxCapacity int32          `json:"max_capacity"`
+	Suspended   SuspendedState `json:"suspended"`
+	// Policies names the scaling policies attached to the target.
+	Policies []string `json:"policies,omitempty"`
+}
+
+// Config contains configuration for a Client.
+type Config struct {
+	// AWSConfig supplies the region, credentials and HTTP client.
+	AWSConfig aws.Config
+	// BaseURL overrides the regional endpoint, e.g. for the mock server.
+	BaseURL string
+	// RetryMode is the SDK retry mode. Empty keeps the AWS config's mode.
+	RetryMode aws.RetryMode
+	// RetryMaxAttempts is the number of attempts per call, the first
+	// included. Zero keeps the AWS config's setting.
+	RetryMaxAttempts int
+}
+
+// Client reads and suspends read replica auto scaling in one region.
+type Client struct {
+	aas *applicationautoscaling.Client
+}
+
+// NewClient creates a new Application Auto Scaling client.
+func NewClient(cfg Config) *Client {
+	var opts []func(*applicationautoscaling.Options)
+	if cfg.BaseURL != "" {
+		opts = append(opts, func(o *applicationautoscaling.Options) {
+			o.BaseEndpoint = aws.String(cfg.BaseURL)
+		})
+	}
+	if cfg.RetryMode != "" {
+		opts = append(opts, func(o *applicationautoscaling.Options) {
+			o.RetryMode = cfg.RetryMode
+		})
+	}
+	if cfg.RetryMaxAttempts > 0 {
+		opts = append(opts, func(o *applicationautoscaling.Options) {
+			o.RetryMaxAttempts = cfg.RetryMaxAttempts
+		})
+	}
+	return &Client{aas: applicationautoscaling.NewFromConfig(cfg.AWSConfig, opts...)}
+}
+
+// GetClusterScaling returns the read replica auto scaling of a cluster with
+// its policies, or nil if the cluster has no scalable target registered.
+func (c *Client) GetClusterScaling(ctx context.Context, clusterID string) (*ClusterScaling, error) {
+	targets, err := c.aas.DescribeScalableTargets(ctx, &applicationautoscaling.DescribeScalableTargetsInput{
+		ServiceNamespace:  serviceNamespace,
+		ResourceIds:       []string{ResourceID(clusterID)},
+		ScalableDimension: readReplicaCount,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describe scalable targets")
+	}
+	if len(targets.ScalableTargets) == 0 {
+		return nil, nil
+	}
+	target := targets.ScalableTargets[0]
+	scaling := &ClusterScaling{
+		ClusterID:   clusterID,
+		MinCapacity: aws.ToInt32(target.MinCapacity),
+		MaxCapacity: aws.ToInt32(target.MaxCapacity),
+	}
+	if s := target.SuspendedState; s != nil {
+		scaling.Suspended = SuspendedState{
+			DynamicScalingIn:  aws.ToBool(s.DynamicScalingInSuspended),
+			DynamicScalingOut: aws.ToBool(s.DynamicScalingOutSuspended),
+			ScheduledScaling:  aws.ToBool(s.ScheduledScalingSuspended),
+		}
+	}
+
+	paginator := applicationautoscaling.NewDescribeScalingPoliciesPaginator(c.aas, &applicationautoscaling.DescribeScalingPoliciesInput{
+		ServiceNamespace:  serviceNamespace,
+		ResourceId:        aws.String(ResourceID(clusterID)),
+		ScalableDimension: readReplicaCount,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe scaling policies")
+		}
+		for _, p := range page.ScalingPolicies {
+			scaling.Policies = append(scaling.Policies, aws.ToString(p.PolicyName))
+		}
+	}
+	return scaling, nil
+}
+
+// SetSuspendedState suspends or resumes the kinds of scaling of a cluster's
+// registered target. Its capacity limits and policies are left as they are.
+func (c *Client) SetSuspendedState(ctx context.Context, clusterID string, state SuspendedState) error {
+	_, err := c.aas.RegisterScalableTarget(ctx, &applicationautoscaling.RegisterScalableTargetInput{
+		ServiceNamespace:  serviceNamespace,
+		ResourceId:        aws.String(ResourceID(clusterID)),
+		ScalableDimension: readReplicaCount,
+		SuspendedState: &types.SuspendedState{
+			DynamicScalingInSuspended:  aws.Bool(state.DynamicScalingIn),
+			DynamicScalingOutSuspended: aws.Bool(state.DynamicScalingOut),
+			ScheduledScalingSuspended:  aws.Bool(state.ScheduledScaling),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "register scalable target")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package autoscaling
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_SuspendAndRestore(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	server := httptest.NewServer(mock.NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	scaling, err := client.GetClusterScaling(ctx, "demo-autoscaled")
+	if err != nil {
+		t.Fatalf("GetClusterScaling failed: %v", err)
+	}
+	if scaling == nil || scaling.MinCapacity != 1 || scaling.MaxCapacity != 4 {
+		t.Fatalf("scaling = %+v, want the seeded 1-4 target", scaling)
+	}
+	if len(scaling.Policies) != 1 || scaling.Policies[0] != "demo-autoscaled-cpu-target" {
+		t.Errorf("policies = %v, want the seeded policy", scaling.Policies)
+	}
+	if scaling.Suspended != (SuspendedState{}) {
+		t.Errorf("suspended = %+v, want nothing suspended", scaling.Suspended)
+	}
+
+	if err := client.SetSuspendedState(ctx, "demo-autoscaled", FullySuspended); err != nil {
+		t.Fatalf("SetSuspendedState failed: %v", err)
+	}
+	target, _ := state.GetScalableTarget("demo-autoscaled")
+	if !target.DynamicScalingInSuspended || !target.DynamicScalingOutSuspended || !target.ScheduledScalingSuspended {
+		t.Errorf("target = %+v, want every kind of scaling suspended", target)
+	}
+	if target.MinCapacity != 1 || target.MaxCapacity != 4 {
+		t.Errorf("capacity changed to %d-%d", target.MinCapacity, target.MaxCapacity)
+	}
+
+	if err := client.SetSuspendedState(ctx, "demo-autoscaled", SuspendedState{}); err != nil {
+		t.Fatalf("SetSuspendedState failed: %v", err)
+	}
+	if target, _ := state.GetScalableTarget("demo-autoscaled"); target.DynamicScalingOutSuspended {
+		t.Errorf("target = %+v, want scaling resumed", target)
+	}
+
+	scaling, err = client.GetClusterScaling(ctx, "demo-upgrade")
+	if err != nil || scaling != nil {
+		t.Errorf("GetClusterScaling(demo-upgrade) = %+v, %v; want no target", scaling, err)
+	}
+}
+
+func TestClient_ErrorsAndSigning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("request not signed: %q", r.Header.Get("Authorization"))
+		}
+		if got := r.Header.Get("X-Amz-Target"); got != "AnyScaleFrontendService.RegisterScalableTarget" {
+			t.Errorf("X-Amz-Target = %q", got)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"__type":"com.amazonaws.autoscaling#AccessDeniedException","Message":"not authorized"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{
+			Region: "us-east-1",
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+			}),
+		},
+		BaseURL: server.URL,
+	})
+	err := client.SetSuspendedState(context.Background(), "c1", FullySuspended)
+	if err == nil || !strings.Contains(err.Error(), "AccessDeniedException: not authorized") {
+		t.Errorf("err = %v, want the access denied error", err)
+	}
+}
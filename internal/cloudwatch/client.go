@@ -0,0 +1,116 @@
+// Package cloudwatch reads CloudWatch alarm states and metrics for the
+// clusters the machine operates on.
+package cloudwatch
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/cockroachdb/errors"
+)
+
+// ClusterDimension is the alarm dimension naming an Aurora cluster.
+const ClusterDimension = "DBClusterIdentifier"
+
+// Alarm states.
+const (
+	StateOK               = string(types.StateValueOk)
+	StateAlarm            = string(types.StateValueAlarm)
+	StateInsufficientData = string(types.StateValueInsufficientData)
+)
+
+// AlarmState is the current state of a metric alarm.
+type AlarmState struct {
+	AlarmName  string    `json:"alarm_name"`
+	MetricName string    `json:"metric_name,omitempty"`
+	State      string    `json:"state"`
+	Reason     string    `json:"reason,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+}
+
+// Firing reports whether the alarm is in the ALARM state.
+func (a AlarmState) Firing() bool {
+	return a.State == StateAlarm
+}
+
+// Config contains configuration for a Client.
+type Config struct {
+	// AWSConfig supplies the region, credentials and HTTP client.
+	AWSConfig aws.Config
+	// BaseURL overrides the regional endpoint, e.g. for the mock server.
+	BaseURL string
+	// RetryMode is the SDK retry mode. Empty keeps the AWS config's mode.
+	RetryMode aws.RetryMode
+	// RetryMaxAttempts is the number of attempts per call, the first
+	// included. Zero keeps the AWS config's setting.
+	RetryMaxAttempts int
+}
+
+// Client reads CloudWatch alarms and metrics in one region.
+type Client struct {
+	cw *cloudwatch.Client
+}
+
+// NewClient creates a new CloudWatch client.
+func NewClient(cfg Config) *Client {
+	var opts []func(*cloudwatch.Options)
+	if cfg.BaseURL != "" {
+		opts = append(opts, func(o *cloudwatch.Options) {
+			o.BaseEndpoint = aws.String(cfg.BaseURL)
+		})
+	}
+	if cfg.RetryMode != "" {
+		opts = append(opts, func(o *cloudwatch.Options) {
+			o.RetryMode = cfg.RetryMode
+		})
+	}
+	if cfg.RetryMaxAttempts > 0 {
+		opts = append(opts, func(o *cloudwatch.Options) {
+			o.RetryMaxAttempts = cfg.RetryMaxAttempts
+		})
+	}
+	return &Client{cw: cloudwatch.NewFromConfig(cfg.AWSConfig, opts...)}
+}
+
+// GetAlarmStates returns the metric alarms that belong to a cluster: those
+// with a DBClusterIdentifier dimension of clusterID, or whose name contains
+// the cluster ID. Every page of DescribeAlarms is read.
+func (c *Client) GetAlarmStates(ctx context.Context, clusterID string) ([]AlarmState, error) {
+	var alarms []AlarmState
+	paginator := cloudwatch.NewDescribeAlarmsPaginator(c.cw, &cloudwatch.DescribeAlarmsInput{
+		AlarmTypes: []types.AlarmType{types.AlarmTypeMetricAlarm},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe alarms")
+		}
+		for _, alarm := range page.MetricAlarms {
+			if !belongsTo(alarm, clusterID) {
+				continue
+			}
+			alarms = append(alarms, AlarmState{
+				AlarmName:  aws.ToString(alarm.AlarmName),
+				MetricName: aws.ToString(alarm.MetricName),
+				State:      string(alarm.StateValue),
+				Reason:     aws.ToString(alarm.StateReason),
+				UpdatedAt:  aws.ToTime(alarm.StateUpdatedTimestamp),
+			})
+		}
+	}
+	return alarms, nil
+}
+
+// belongsTo reports whether an alarm watches clusterID.
+func belongsTo(alarm types.MetricAlarm, clusterID string) bool {
+	for _, d := range alarm.Dimensions {
+		if aws.ToString(d.Name) == ClusterDimension && aws.ToString(d.Value) == clusterID {
+			return true
+		}
+	}
+	return strings.Contains(aws.ToString(alarm.AlarmName), clusterID)
+}
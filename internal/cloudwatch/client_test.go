@@ -0,0 +1,135 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/encoding/cbor"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+// writeCBOR answers a CloudWatch call the way the Smithy RPC v2 CBOR
+// protocol expects.
+func writeCBOR(w http.ResponseWriter, status int, body cbor.Map) {
+	w.Header().Set("Smithy-Protocol", "rpc-v2-cbor")
+	w.Header().Set("Content-Type", "application/cbor")
+	w.WriteHeader(status)
+	w.Write(cbor.Encode(body))
+}
+
+// readCBOR decodes the body of a CloudWatch call.
+func readCBOR(t *testing.T, r *http.Request) cbor.Map {
+	t.Helper()
+	body, _ := io.ReadAll(r.Body)
+	v, err := cbor.Decode(body)
+	if err != nil {
+		t.Errorf("decode request: %v", err)
+	}
+	m, _ := v.(cbor.Map)
+	return m
+}
+
+func TestClient_GetAlarmStates(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	if err := state.SetAlarmState("demo-upgrade-replica-lag", mock.AlarmStateAlarm, "Threshold Crossed: lag 12s"); err != nil {
+		t.Fatalf("SetAlarmState failed: %v", err)
+	}
+	if err := state.PutAlarm(mock.MockAlarm{Name: "other-cluster-cpu", ClusterID: "other", State: mock.AlarmStateAlarm}); err != nil {
+		t.Fatalf("PutAlarm failed: %v", err)
+	}
+	server := httptest.NewServer(mock.NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	alarms, err := client.GetAlarmStates(context.Background(), "demo-upgrade")
+	if err != nil {
+		t.Fatalf("GetAlarmStates failed: %v", err)
+	}
+	if len(alarms) != 3 {
+		t.Fatalf("got %d alarms, want the 3 on demo-upgrade: %+v", len(alarms), alarms)
+	}
+	var firing []string
+	for _, alarm := range alarms {
+		if alarm.Firing() {
+			firing = append(firing, alarm.AlarmName)
+			if !strings.Contains(alarm.Reason, "lag 12s") {
+				t.Errorf("reason = %q, want the state reason", alarm.Reason)
+			}
+		}
+	}
+	if len(firing) != 1 || firing[0] != "demo-upgrade-replica-lag" {
+		t.Errorf("firing alarms = %v, want [demo-upgrade-replica-lag]", firing)
+	}
+}
+
+func TestClient_GetAlarmStatesPaginatesAndSigns(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			t.Errorf("request not signed: %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/service/GraniteServiceVersion20100801/operation/DescribeAlarms" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		token, _ := readCBOR(t, r)["NextToken"].(cbor.String)
+		tokens = append(tokens, string(token))
+
+		alarm := cbor.Map{"AlarmName": cbor.String("c1-cpu"), "StateValue": cbor.String("OK")}
+		out := cbor.Map{"MetricAlarms": cbor.List{alarm}, "NextToken": cbor.String("page-2")}
+		if token == "page-2" {
+			alarm["AlarmName"] = cbor.String("c1-lag")
+			delete(out, "NextToken")
+		}
+		writeCBOR(w, http.StatusOK, out)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{
+			Region: "us-east-1",
+			Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, nil
+			}),
+		},
+		BaseURL: server.URL,
+	})
+	alarms, err := client.GetAlarmStates(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("GetAlarmStates failed: %v", err)
+	}
+	if len(alarms) != 2 || alarms[1].AlarmName != "c1-lag" {
+		t.Errorf("alarms = %+v, want c1-cpu and c1-lag", alarms)
+	}
+	if len(tokens) != 2 || tokens[1] != "page-2" {
+		t.Errorf("next tokens sent = %q, want [\"\" \"page-2\"]", tokens)
+	}
+}
+
+func TestClient_GetAlarmStatesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeCBOR(w, http.StatusForbidden, cbor.Map{
+			"__type":  cbor.String("AccessDenied"),
+			"message": cbor.String("not authorized"),
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	_, err := client.GetAlarmStates(context.Background(), "c1")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected the API error code, got: %v", err)
+	}
+}
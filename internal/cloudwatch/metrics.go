@@ -0,0 +1,51 @@
+package cloudwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// metricPeriod is the granularity of the datapoints read; RDS publishes
+	// its metrics every minute.
+	metricPeriod = time.Minute
+	// metricLookback is how far back to look for the latest datapoint.
+	metricLookback = 5 * time.Minute
+)
+
+// LatestMaximum returns the Maximum statistic of the most recent datapoint of
+// a metric with a single dimension, from the last few minutes. ok is false
+// when the metric has no recent datapoints.
+func (c *Client) LatestMaximum(ctx context.Context, namespace, metricName, dimensionName, dimensionValue string) (value float64, ok bool, err error) {
+	end := time.Now().UTC()
+	out, err := c.cw.GetMetricStatistics(ctx, &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String(namespace),
+		MetricName: aws.String(metricName),
+		Dimensions: []types.Dimension{
+			{Name: aws.String(dimensionName), Value: aws.String(dimensionValue)},
+		},
+		StartTime:  aws.Time(end.Add(-metricLookback)),
+		EndTime:    aws.Time(end),
+		Period:     aws.Int32(int32(metricPeriod.Seconds())),
+		Statistics: []types.Statistic{types.StatisticMaximum},
+	})
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "get %s statistics", metricName)
+	}
+
+	var latest *types.Datapoint
+	for i, dp := range out.Datapoints {
+		if latest == nil || aws.ToTime(dp.Timestamp).After(aws.ToTime(latest.Timestamp)) {
+			latest = &out.Datapoints[i]
+		}
+	}
+	if latest == nil {
+		return 0, false, nil
+	}
+	return aws.ToFloat64(latest.Maximum), true, nil
+}
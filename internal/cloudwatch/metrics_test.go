@@ -0,0 +1,65 @@
+package cloudwatch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go/encoding/cbor"
+)
+
+// datapoint is a GetMetricStatistics datapoint with a Maximum statistic.
+func datapoint(timestamp string, maximum float64) cbor.Map {
+	ts, _ := time.Parse(time.RFC3339, timestamp)
+	return cbor.Map{
+		"Timestamp": &cbor.Tag{ID: 1, Value: cbor.Uint(ts.Unix())},
+		"Maximum":   cbor.Float64(maximum),
+	}
+}
+
+func TestClient_LatestMaximum(t *testing.T) {
+	datapoints := cbor.List{
+		datapoint("2026-01-01T10:03:00Z", 40),
+		datapoint("2026-01-01T10:04:00Z", 1200.5),
+		datapoint("2026-01-01T10:02:00Z", 9000),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := readCBOR(t, r)
+		dimensions, _ := req["Dimensions"].(cbor.List)
+		if r.URL.Path != "/service/GraniteServiceVersion20100801/operation/GetMetricStatistics" ||
+			len(dimensions) != 1 || dimensions[0].(cbor.Map)["Value"] != cbor.String("db-1") {
+			t.Errorf("unexpected request: %s %v", r.URL.Path, req)
+		}
+		points := datapoints
+		if req["MetricName"] != cbor.String("AuroraReplicaLag") {
+			points = cbor.List{}
+		}
+		writeCBOR(w, http.StatusOK, cbor.Map{"Datapoints": points})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	value, ok, err := client.LatestMaximum(ctx, "AWS/RDS", "AuroraReplicaLag", "DBInstanceIdentifier", "db-1")
+	if err != nil {
+		t.Fatalf("LatestMaximum failed: %v", err)
+	}
+	if !ok || value != 1200.5 {
+		t.Errorf("LatestMaximum = %v, %v; want the most recent datapoint 1200.5", value, ok)
+	}
+
+	_, ok, err = client.LatestMaximum(ctx, "AWS/RDS", "CPUUtilization", "DBInstanceIdentifier", "db-1")
+	if err != nil {
+		t.Fatalf("LatestMaximum failed: %v", err)
+	}
+	if ok {
+		t.Error("expected no datapoints")
+	}
+}
@@ -3,13 +3,17 @@ package config
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
 )
 
 // Config holds all configuration for the application.
@@ -22,6 +26,19 @@ type Config struct {
 	AWSRegion  string
 	AWSProfile string
 
+	// AllowedRegions limits the regions operations may target (empty allows any)
+	AllowedRegions []string
+
+	// ClusterAllowlist and ClusterDenylist are glob patterns, e.g. "prod-*",
+	// of the cluster IDs operations may target (empty allowlist allows any)
+	ClusterAllowlist []string
+	ClusterDenylist  []string
+
+	// AlarmNamePatterns selects the CloudWatch alarms checked before a
+	// switchover ({cluster} is replaced by the cluster ID; empty checks all of
+	// the cluster's alarms)
+	AlarmNamePatterns []string
+
 	// RDS endpoint override (for demo/testing with mock server)
 	RDSEndpoint string
 
@@ -30,11 +47,20 @@ type Config struct {
 	SlackToken   string
 	SlackChannel string
 
+	// Webhook configuration
+	WebhookURL    string // receives operations that pause, fail or complete
+	WebhookSecret string // HMAC key for the webhook signature header
+
 	// Admin configuration
-	AdminToken string
+	AdminToken     string
+	IdentityHeader string // header an authenticating proxy sets to the caller's identity
 
 	// Debug settings
 	DebugEnabled bool
+	LogFormat    string // "text" or "json"
+
+	// Metrics settings
+	MetricsEnabled bool // expose Prometheus metrics at /metrics
 
 	// TLS configuration for server
 	TLSEnabled  bool
@@ -42,12 +68,41 @@ type Config struct {
 	TLSKeyPath  string
 
 	// Operation settings
-	DefaultWaitTimeout  int // seconds
-	DefaultPollInterval int // seconds
+	DefaultWaitTimeout  int    // seconds
+	DefaultPollInterval int    // seconds
+	MaxPollInterval     int    // seconds wait polls back off to
+	InitialPollDelay    int    // seconds a wait step holds off before its first poll
+	ModifyVerifyPolls   int    // polls before re-issuing an unapplied modify (negative disables)
+	TempFinalSnapshot   bool   // snapshot the cluster before deleting temp instances
+	SnapshotRetention   int    // days pre-upgrade snapshots are kept (negative keeps them)
+	DefaultStorageType  string // target storage type when a storage change omits it
+	MaxConcurrentOps    int    // operations that may be active at once (0 = unlimited)
+	IdempotencyTTL      int    // seconds an idempotency key returns the operation it created
+	InstanceLimit       int    // instances a cluster may have, checked before adding a temp instance
+	OrphanTTL           int    // seconds before a temp instance of an unknown operation is an orphan
+	ReconcileOnStartup  bool   // delete orphaned temp instances when the app starts
+	PriceTablePath      string // JSON price table for cost estimates (empty uses the built-in one)
+	TemplatesPath       string // JSON file of named operation templates loaded at startup
+	ApproveMajorUpgrade bool   // hold major engine upgrades for a second person's approval
+	MaxOpDuration       int    // seconds an operation may run before it is stopped (0 = unlimited)
+	BlueGreenDuration   int    // seconds an engine upgrade may run, replacing MaxOpDuration
+
+	// Maintenance window settings
+	MaintenanceWindow   string // allowed windows for new operations, e.g. "Sun:03:00-Sun:05:00"
+	MaintenanceTimezone string // IANA time zone the windows are in (default UTC)
+
+	// RDS API settings
+	RDSCallTimeout    int            // seconds a single RDS API call may take (negative disables)
+	RDSActionTimeouts map[string]int // per-action overrides of RDSCallTimeout, in seconds
+	RDSRetryMode      string         // SDK retry mode: "standard" or "adaptive"
+	RDSMaxAttempts    int            // attempts per RDS API call, the first included
+	RDSTagConcurrency int            // tag lookups in flight while describing a cluster
+	RDSTagRate        int            // tag lookups started per second (0 = unlimited)
 
 	// Storage settings
-	DataDir    string // directory for persistent storage
-	AutoResume bool   // automatically resume running operations on startup
+	DataDir       string // directory for persistent storage
+	DynamoDBTable string // DynamoDB table for storage; takes precedence over DataDir
+	AutoResume    bool   // automatically resume running operations on startup
 
 	// Demo mode settings
 	DemoMode     bool
@@ -65,15 +120,44 @@ func NewConfig() (*Config, error) {
 		SlackEnabled:        getEnvBool("APP_SLACK_ENABLED", false),
 		SlackToken:          getEnv("APP_SLACK_TOKEN", ""),
 		SlackChannel:        getEnv("APP_SLACK_CHANNEL", ""),
+		WebhookURL:          getEnv("APP_WEBHOOK_URL", ""),
+		WebhookSecret:       getEnv("APP_WEBHOOK_SECRET", ""),
 		AdminToken:          getEnv("APP_ADMIN_TOKEN", ""),
+		IdentityHeader:      getEnv("APP_IDENTITY_HEADER", ""),
 		DebugEnabled:        getEnvBool("APP_DEBUG_ENABLED", false),
+		LogFormat:           getEnv("APP_LOG_FORMAT", LogFormatText),
+		MetricsEnabled:      getEnvBool("APP_METRICS_ENABLED", false),
 		TLSEnabled:          getEnvBool("APP_TLS_ENABLED", false),
 		TLSCertPath:         getEnv("APP_TLS_CERT_PATH", ""),
 		TLSKeyPath:          getEnv("APP_TLS_KEY_PATH", ""),
 		DefaultWaitTimeout:  getEnvInt("APP_DEFAULT_WAIT_TIMEOUT", 2700), // 45 minutes
 		DefaultPollInterval: getEnvInt("APP_DEFAULT_POLL_INTERVAL", 30),  // 30 seconds
+		MaxPollInterval:     getEnvInt("APP_MAX_POLL_INTERVAL", 120),     // 2 minutes
+		InitialPollDelay:    getEnvInt("APP_INITIAL_POLL_DELAY", 5),
+		ModifyVerifyPolls:   getEnvInt("APP_MODIFY_VERIFY_POLLS", 20),
+		TempFinalSnapshot:   getEnvBool("APP_TEMP_FINAL_SNAPSHOT", false),
+		SnapshotRetention:   getEnvInt("APP_SNAPSHOT_RETENTION_DAYS", 14),
+		DefaultStorageType:  getEnv("APP_DEFAULT_STORAGE_TYPE", ""),
+		MaxConcurrentOps:    getEnvInt("APP_MAX_CONCURRENT_OPERATIONS", 0),
+		IdempotencyTTL:      getEnvInt("APP_IDEMPOTENCY_TTL", 86400), // 24 hours
+		InstanceLimit:       getEnvInt("APP_CLUSTER_INSTANCE_LIMIT", 15),
+		OrphanTTL:           getEnvInt("APP_ORPHAN_TTL", 86400), // 24 hours
+		ReconcileOnStartup:  getEnvBool("APP_RECONCILE_ON_STARTUP", false),
+		PriceTablePath:      getEnv("APP_PRICE_TABLE_PATH", ""),
+		TemplatesPath:       getEnv("APP_TEMPLATES_PATH", ""),
+		ApproveMajorUpgrade: getEnvBool("APP_MAJOR_UPGRADE_APPROVAL", false),
+		MaxOpDuration:       getEnvInt("APP_MAX_OPERATION_DURATION", 0),
+		BlueGreenDuration:   getEnvInt("APP_BLUE_GREEN_MAX_DURATION", 86400), // 24 hours
+		RDSCallTimeout:      getEnvInt("APP_RDS_CALL_TIMEOUT", 30),
+		RDSRetryMode:        getEnv("APP_RDS_RETRY_MODE", "adaptive"),
+		RDSMaxAttempts:      getEnvInt("APP_RDS_MAX_ATTEMPTS", 5),
+		RDSTagConcurrency:   getEnvInt("APP_RDS_TAG_CONCURRENCY", 4),
+		RDSTagRate:          getEnvInt("APP_RDS_TAG_RATE", 10),
+		MaintenanceWindow:   getEnv("APP_MAINTENANCE_WINDOW", ""),
+		MaintenanceTimezone: getEnv("APP_MAINTENANCE_TIMEZONE", "UTC"),
 		DataDir:             getEnv("APP_DATA_DIR", "./data"),
-		AutoResume:          getEnvBool("APP_AUTO_RESUME", true), // default to auto-resume
+		DynamoDBTable:       getEnv("APP_DYNAMODB_TABLE", ""),
+		AutoResume:          getEnvBool("APP_AUTO_RESUME", false), // opt-in; otherwise paused for review
 		DemoMode:            getEnvBool("APP_DEMO_MODE", false),
 		MockEndpoint:        getEnv("APP_MOCK_ENDPOINT", ""),
 	}
@@ -82,9 +166,94 @@ func NewConfig() (*Config, error) {
 		cfg.SlackEnabled = true
 	}
 
+	switch cfg.LogFormat {
+	case LogFormatText, LogFormatJSON:
+	default:
+		return nil, errors.Newf("APP_LOG_FORMAT %q is not a log format (want %q or %q)",
+			cfg.LogFormat, LogFormatText, LogFormatJSON)
+	}
+
+	switch cfg.DefaultStorageType {
+	case "", constants.StorageTypeAurora, constants.StorageTypeAuroraIOOptimized:
+	default:
+		return nil, errors.Newf("APP_DEFAULT_STORAGE_TYPE %q is not an Aurora storage type (want %q or %q)",
+			cfg.DefaultStorageType, constants.StorageTypeAurora, constants.StorageTypeAuroraIOOptimized)
+	}
+
+	if cfg.ApproveMajorUpgrade && cfg.IdentityHeader == "" {
+		return nil, errors.New("APP_MAJOR_UPGRADE_APPROVAL needs APP_IDENTITY_HEADER to tell the creator and approver apart")
+	}
+
+	if _, err := aws.ParseRetryMode(cfg.RDSRetryMode); err != nil {
+		return nil, errors.Wrap(err, "APP_RDS_RETRY_MODE")
+	}
+
+	cfg.AllowedRegions = parseList(getEnv("APP_ALLOWED_REGIONS", ""))
+	cfg.AlarmNamePatterns = parseList(getEnv("APP_ALARM_NAME_PATTERNS", ""))
+	cfg.ClusterAllowlist = parseList(getEnv("APP_CLUSTER_ALLOWLIST", ""))
+	if err := validatePatterns(cfg.ClusterAllowlist); err != nil {
+		return nil, errors.Wrap(err, "APP_CLUSTER_ALLOWLIST")
+	}
+	cfg.ClusterDenylist = parseList(getEnv("APP_CLUSTER_DENYLIST", ""))
+	if err := validatePatterns(cfg.ClusterDenylist); err != nil {
+		return nil, errors.Wrap(err, "APP_CLUSTER_DENYLIST")
+	}
+
+	actionTimeouts, err := parseActionTimeouts(getEnv("APP_RDS_ACTION_TIMEOUTS", ""))
+	if err != nil {
+		return nil, errors.Wrap(err, "APP_RDS_ACTION_TIMEOUTS")
+	}
+	cfg.RDSActionTimeouts = actionTimeouts
+
 	return cfg, nil
 }
 
+// parseList splits a comma-separated list, dropping blank entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validatePatterns checks that each pattern is valid path.Match syntax.
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Newf("pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// parseActionTimeouts parses a comma-separated list of Action=seconds pairs,
+// e.g. "DescribeBlueGreenDeployments=10,CreateDBInstance=60".
+func parseActionTimeouts(value string) (map[string]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	timeouts := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		action, seconds, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(action) == "" {
+			return nil, errors.Newf("%q is not an Action=seconds pair", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(seconds))
+		if err != nil {
+			return nil, errors.Newf("timeout for %s is not a number of seconds: %q", action, seconds)
+		}
+		timeouts[strings.TrimSpace(action)] = n
+	}
+	return timeouts, nil
+}
+
 // LoadAWSConfig loads the AWS SDK configuration.
 func (c *Config) LoadAWSConfig(ctx context.Context) (aws.Config, error) {
 	opts := []func(*awsconfig.LoadOptions) error{
@@ -105,22 +274,62 @@ func (c *Config) Redacted() map[string]any {
 		"base_path":             c.BasePath,
 		"aws_region":            c.AWSRegion,
 		"aws_profile":           c.AWSProfile,
+		"allowed_regions":       c.AllowedRegions,
+		"alarm_name_patterns":   c.AlarmNamePatterns,
+		"cluster_allowlist":     c.ClusterAllowlist,
+		"cluster_denylist":      c.ClusterDenylist,
 		"rds_endpoint":          c.RDSEndpoint,
 		"slack_enabled":         c.SlackEnabled,
 		"slack_token":           redact(c.SlackToken),
 		"slack_channel":         c.SlackChannel,
+		"webhook_url":           redact(c.WebhookURL),
+		"webhook_secret":        redact(c.WebhookSecret),
 		"admin_token":           redact(c.AdminToken),
+		"identity_header":       c.IdentityHeader,
 		"debug_enabled":         c.DebugEnabled,
+		"log_format":            c.LogFormat,
+		"metrics_enabled":       c.MetricsEnabled,
 		"tls_enabled":           c.TLSEnabled,
 		"default_wait_timeout":  c.DefaultWaitTimeout,
 		"default_poll_interval": c.DefaultPollInterval,
+		"max_poll_interval":     c.MaxPollInterval,
+		"initial_poll_delay":    c.InitialPollDelay,
+		"modify_verify_polls":   c.ModifyVerifyPolls,
+		"temp_final_snapshot":   c.TempFinalSnapshot,
+		"snapshot_retention":    c.SnapshotRetention,
+		"default_storage_type":  c.DefaultStorageType,
+		"max_concurrent_ops":    c.MaxConcurrentOps,
+		"idempotency_ttl":       c.IdempotencyTTL,
+		"instance_limit":        c.InstanceLimit,
+		"orphan_ttl":            c.OrphanTTL,
+		"reconcile_on_startup":  c.ReconcileOnStartup,
+		"price_table_path":      c.PriceTablePath,
+		"templates_path":        c.TemplatesPath,
+		"approve_major_upgrade": c.ApproveMajorUpgrade,
+		"max_op_duration":       c.MaxOpDuration,
+		"blue_green_duration":   c.BlueGreenDuration,
+		"rds_call_timeout":      c.RDSCallTimeout,
+		"maintenance_window":    c.MaintenanceWindow,
+		"maintenance_timezone":  c.MaintenanceTimezone,
+		"rds_action_timeouts":   c.RDSActionTimeouts,
+		"rds_retry_mode":        c.RDSRetryMode,
+		"rds_max_attempts":      c.RDSMaxAttempts,
+		"rds_tag_concurrency":   c.RDSTagConcurrency,
+		"rds_tag_rate":          c.RDSTagRate,
 		"data_dir":              c.DataDir,
+		"dynamodb_table":        c.DynamoDBTable,
 		"auto_resume":           c.AutoResume,
 		"demo_mode":             c.DemoMode,
 		"mock_endpoint":         c.MockEndpoint,
 	}
 }
 
+// Log formats selectable with APP_LOG_FORMAT.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 // NewLogger creates a new structured logger.
 func NewLogger() *slog.Logger {
 	level := slog.LevelInfo
@@ -128,9 +337,17 @@ func NewLogger() *slog.Logger {
 		level = slog.LevelDebug
 	}
 
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
-	}))
+	return slog.New(NewLogHandler(os.Stdout, level))
+}
+
+// NewLogHandler returns a handler writing to w in the format APP_LOG_FORMAT
+// selects: JSON lines for log ingestion, or text by default.
+func NewLogHandler(w io.Writer, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if getEnv("APP_LOG_FORMAT", LogFormatText) == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -16,6 +16,45 @@ const (
 
 	// DefaultPollIntervalSeconds is the default poll interval in seconds.
 	DefaultPollIntervalSeconds = 30
+
+	// SnapshotWaitTimeoutSeconds is the wait timeout builders give snapshot steps.
+	SnapshotWaitTimeoutSeconds = 1800
+
+	// DefaultSnapshotRetentionDays is how long pre-upgrade snapshots are kept
+	// before snapshot cleanup deletes them.
+	DefaultSnapshotRetentionDays = 14
+
+	// RebootWaitTimeoutSeconds is the wait timeout builders give post-reboot waits.
+	RebootWaitTimeoutSeconds = 300
+
+	// DefaultModifyVerifyPolls is the number of polls an instance may be available
+	// without its pending modification applied before the modify is re-issued.
+	DefaultModifyVerifyPolls = 20
+
+	// DefaultMaxReplicaLagMs is the replica lag, in milliseconds, above which
+	// a failover target is refused.
+	DefaultMaxReplicaLagMs = 1000
+
+	// DefaultIdempotencyTTL is how long an idempotency key returns the
+	// operation it created.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// DefaultClusterInstanceLimit is the most instances an Aurora cluster
+	// may have, the writer included.
+	DefaultClusterInstanceLimit = 15
+
+	// DefaultOrphanTTL is how old a temp instance of an unknown operation
+	// must be before orphan reconciliation deletes it.
+	DefaultOrphanTTL = 24 * time.Hour
+)
+
+// Aurora cluster storage types
+const (
+	// StorageTypeAurora is Aurora Standard storage.
+	StorageTypeAurora = "aurora"
+
+	// StorageTypeAuroraIOOptimized is Aurora I/O-Optimized storage.
+	StorageTypeAuroraIOOptimized = "aurora-iopt1"
 )
 
 // Default region
@@ -38,6 +77,9 @@ const (
 	// DefaultIdleTimeout is the default HTTP idle timeout.
 	DefaultIdleTimeout = 60 * time.Second
 
+	// ReadinessTimeout bounds the RDS call a readiness probe makes.
+	ReadinessTimeout = 3 * time.Second
+
 	// DefaultShutdownTimeout is the default graceful shutdown timeout.
 	DefaultShutdownTimeout = 30 * time.Second
 
@@ -45,6 +87,17 @@ const (
 	DemoShutdownTimeout = 5 * time.Second
 )
 
+// Progress streaming
+const (
+	// EventStreamBufferSize is how many updates a progress stream subscriber
+	// may fall behind before further updates are dropped for it.
+	EventStreamBufferSize = 64
+
+	// EventStreamHeartbeat is how often an idle progress stream sends a
+	// keep-alive comment so proxies do not close the connection.
+	EventStreamHeartbeat = 15 * time.Second
+)
+
 // Cache durations
 const (
 	// StaticFileCacheDuration is the cache duration for CSS/JS files (1 hour).
@@ -152,3 +205,12 @@ const (
 	// OperationIDSuffixLength is the number of characters to use from the operation ID.
 	OperationIDSuffixLength = 8
 )
+
+// Operation listing page sizes
+const (
+	// DefaultOperationsPageSize is the page size of GET /api/operations when no limit is given.
+	DefaultOperationsPageSize = 50
+
+	// MaxOperationsPageSize is the largest limit GET /api/operations accepts.
+	MaxOperationsPageSize = 500
+)
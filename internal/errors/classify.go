@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/smithy-go"
+)
+
+// Class tells a caller what to do about a failed request.
+type Class string
+
+const (
+	// ClassRetryable failures are transient; the same request may succeed later.
+	ClassRetryable Class = "retryable"
+	// ClassTerminal failures will fail again until the request is changed.
+	ClassTerminal Class = "terminal"
+	// ClassIntervention failures need an operator before anything can proceed.
+	ClassIntervention Class = "intervention"
+)
+
+// retryableAWSCodes are AWS error codes for throttling, service-side faults
+// and resources that are only briefly in the wrong state.
+var retryableAWSCodes = map[string]bool{
+	"Throttling":                 true,
+	"ThrottlingException":        true,
+	"RequestLimitExceeded":       true,
+	"TooManyRequestsException":   true,
+	"RequestThrottled":           true,
+	"InternalFailure":            true,
+	"ServiceUnavailable":         true,
+	"InvalidDBClusterStateFault": true,
+	"InvalidDBInstanceState":     true,
+}
+
+// Classify returns the class of an error from the sentinels it wraps and,
+// for AWS API errors, their error code. It returns "" for errors it does
+// not recognise.
+func Classify(err error) Class {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrInterventionRequired) || errors.Is(err, ErrClusterInstanceLimit) ||
+		errors.Is(err, ErrRollbackFailed) {
+		return ClassIntervention
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && retryableAWSCodes[apiErr.ErrorCode()] {
+		return ClassRetryable
+	}
+	if errors.Is(err, ErrWaitTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrClusterBusy) || errors.Is(err, ErrTooManyOperations) ||
+		errors.Is(err, ErrClusterNotAvailable) || errors.Is(err, ErrConcurrentModification) ||
+		errors.Is(err, ErrOperationAlreadyRunning) || errors.Is(err, ErrShuttingDown) {
+		return ClassRetryable
+	}
+
+	if IsNotFound(err) || errors.Is(err, ErrInvalidParameter) || errors.Is(err, ErrInvalidState) ||
+		errors.Is(err, ErrSelfApproval) || errors.Is(err, ErrCannotDelete) ||
+		errors.Is(err, ErrClusterAlreadyExists) || errors.Is(err, ErrInstanceAlreadyExists) ||
+		errors.Is(err, ErrOutsideMaintenanceWindow) {
+		return ClassTerminal
+	}
+	if apiErr != nil && apiErr.ErrorFault() == smithy.FaultClient {
+		return ClassTerminal
+	}
+	return ""
+}
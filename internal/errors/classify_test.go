@@ -0,0 +1,43 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "wait timeout", err: fmt.Errorf("wait_until_available: %w", ErrWaitTimeout), want: ClassRetryable},
+		{name: "deadline", err: context.DeadlineExceeded, want: ClassRetryable},
+		{name: "cluster busy", err: ErrClusterBusy, want: ClassRetryable},
+		{name: "shutting down", err: fmt.Errorf("create: %w", ErrShuttingDown), want: ClassRetryable},
+		{name: "throttling", err: &smithy.GenericAPIError{Code: "Throttling", Fault: smithy.FaultClient}, want: ClassRetryable},
+		{
+			name: "throttled step",
+			err:  fmt.Errorf("%w: %w", ErrStepFailed, &smithy.GenericAPIError{Code: "ThrottlingException"}),
+			want: ClassRetryable,
+		},
+		{name: "invalid parameter", err: fmt.Errorf("bad class: %w", ErrInvalidParameter), want: ClassTerminal},
+		{name: "instance already exists", err: fmt.Errorf("create: %w", ErrInstanceAlreadyExists), want: ClassTerminal},
+		{name: "cluster not found", err: ErrClusterNotFound, want: ClassTerminal},
+		{name: "aws client fault", err: &smithy.GenericAPIError{Code: "InvalidParameterCombination", Fault: smithy.FaultClient}, want: ClassTerminal},
+		{name: "intervention", err: ErrInterventionRequired, want: ClassIntervention},
+		{name: "unknown", err: errors.New("boom"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -34,6 +34,30 @@ var (
 	ErrBlueGreenDeploymentNotFound = errors.New("blue-green deployment not found")
 	// ErrCannotDelete indicates the resource cannot be deleted in its current state.
 	ErrCannotDelete = errors.New("cannot delete")
+	// ErrClusterNotAvailable indicates the cluster is not idle enough to start an operation.
+	ErrClusterNotAvailable = errors.New("cluster not available")
+	// ErrTemplateNotFound indicates the requested operation template does not exist.
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrBatchNotFound indicates the requested batch operation does not exist.
+	ErrBatchNotFound = errors.New("batch operation not found")
+	// ErrConcurrentModification indicates a stored record changed since it was read.
+	ErrConcurrentModification = errors.New("concurrent modification")
+	// ErrClusterBusy indicates another operation is already active on the cluster.
+	ErrClusterBusy = errors.New("cluster busy")
+	// ErrTooManyOperations indicates the engine is running as many operations as it allows.
+	ErrTooManyOperations = errors.New("too many concurrent operations")
+	// ErrOutsideMaintenanceWindow indicates an operation was requested outside every allowed window.
+	ErrOutsideMaintenanceWindow = errors.New("outside maintenance window")
+	// ErrClusterAlreadyExists indicates a cluster with the requested identifier already exists.
+	ErrClusterAlreadyExists = errors.New("cluster already exists")
+	// ErrInstanceAlreadyExists indicates an instance with the requested identifier already exists.
+	ErrInstanceAlreadyExists = errors.New("instance already exists")
+	// ErrClusterInstanceLimit indicates a cluster cannot take another instance.
+	ErrClusterInstanceLimit = errors.New("cluster instance limit reached")
+	// ErrSelfApproval indicates an operation's creator tried to approve it.
+	ErrSelfApproval = errors.New("operation cannot be approved by its creator")
+	// ErrShuttingDown indicates the server is draining and accepts no new work.
+	ErrShuttingDown = errors.New("server is shutting down")
 )
 
 // IsNotFound returns true if the error is any kind of "not found" error.
@@ -42,7 +66,9 @@ func IsNotFound(err error) bool {
 		errors.Is(err, ErrOperationNotFound) ||
 		errors.Is(err, ErrClusterNotFound) ||
 		errors.Is(err, ErrInstanceNotFound) ||
-		errors.Is(err, ErrBlueGreenDeploymentNotFound)
+		errors.Is(err, ErrBlueGreenDeploymentNotFound) ||
+		errors.Is(err, ErrTemplateNotFound) ||
+		errors.Is(err, ErrBatchNotFound)
 }
 
 // IsCannotDelete returns true if the error indicates a resource cannot be deleted.
@@ -27,6 +27,19 @@ func NewRequestHandler(a *app.App, logger *slog.Logger) *RequestHandler {
 
 // ServeHTTP implements http.Handler interface.
 func (h *RequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if probe, ok := h.probePath(r); ok {
+		h.serveProbe(w, r, probe)
+		return
+	}
+	if h.isMetricsRequest(r) {
+		h.serveMetrics(w)
+		return
+	}
+	if id, ok := h.eventStreamOperationID(r); ok {
+		h.serveEventStream(w, r, id)
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	defer r.Body.Close()
 	if err != nil {
@@ -41,10 +54,18 @@ func (h *RequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	query := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			query[key] = values[0]
+		}
+	}
+
 	req := app.Request{
 		Method:  r.Method,
 		Path:    r.URL.Path,
 		Headers: headers,
+		Query:   query,
 		Body:    body,
 	}
 
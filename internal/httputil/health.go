@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+)
+
+// Orchestrator probe paths. They are served without authentication and
+// before the app router, so a probe never depends on the router's state.
+const (
+	livenessPath  = "/healthz"
+	readinessPath = "/readyz"
+)
+
+// readinessResponse is the body of a readiness probe.
+type readinessResponse struct {
+	Status   string `json:"status"`
+	Region   string `json:"region"`
+	DemoMode bool   `json:"demo_mode"`
+	Error    string `json:"error,omitempty"`
+}
+
+// probePath returns the probe path when r is a GET of /healthz or /readyz.
+func (h *RequestHandler) probePath(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet {
+		return "", false
+	}
+	path := r.URL.Path
+	if h.app.Config != nil && h.app.Config.BasePath != "" {
+		path = strings.TrimPrefix(path, h.app.Config.BasePath)
+	}
+	if path != livenessPath && path != readinessPath {
+		return "", false
+	}
+	return path, true
+}
+
+// serveProbe answers a liveness or readiness probe. Liveness only says the
+// process is serving requests. Readiness also describes clusters in the
+// default region, which in demo mode goes to the mock endpoint, and answers
+// 503 when that fails so no traffic is routed to an instance that cannot
+// reach RDS.
+func (h *RequestHandler) serveProbe(w http.ResponseWriter, r *http.Request, path string) {
+	if path == livenessPath {
+		h.writeProbe(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+
+	resp := readinessResponse{Status: "ready"}
+	if h.app.Config != nil {
+		resp.Region = h.app.Config.AWSRegion
+		resp.DemoMode = h.app.Config.DemoMode
+	}
+	if err := h.checkRDSAccess(r.Context(), resp.Region); err != nil {
+		resp.Status = "not_ready"
+		resp.Error = err.Error()
+		h.writeProbe(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	h.writeProbe(w, http.StatusOK, resp)
+}
+
+// checkRDSAccess describes clusters in region within the readiness timeout.
+func (h *RequestHandler) checkRDSAccess(ctx context.Context, region string) error {
+	if h.app.ClientManager == nil {
+		return errors.New("no rds client configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, constants.ReadinessTimeout)
+	defer cancel()
+
+	client, err := h.app.ClientManager.GetClient(ctx, region)
+	if err != nil {
+		return errors.Wrapf(err, "get rds client for %s", region)
+	}
+	return errors.Wrapf(client.CheckAccess(ctx), "check rds access in %s", region)
+}
+
+func (h *RequestHandler) writeProbe(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil && h.logger != nil {
+		h.logger.Debug("failed to write probe response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/app"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/config"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+)
+
+// probeServer serves the handler for an app whose RDS clients go to rdsURL.
+func probeServer(t *testing.T, rdsURL string) *httptest.Server {
+	t.Helper()
+	a := app.NewWithEngine(&config.Config{AWSRegion: "us-east-1", DemoMode: true},
+		machine.NewEngine(machine.EngineConfig{}), &notifiers.NullNotifier{})
+	a.ClientManager = rds.NewClientManager(rds.ClientManagerConfig{
+		BaseConfig: aws.Config{
+			Region:           "us-east-1",
+			RetryMaxAttempts: 1,
+			Credentials:      aws.AnonymousCredentials{},
+		},
+		DemoMode: true,
+		BaseURL:  rdsURL,
+	})
+	server := httptest.NewServer(NewRequestHandler(a, nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getProbe(t *testing.T, url string) (int, readinessResponse) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	var out readinessResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestProbes(t *testing.T) {
+	mockState := mock.NewState(mock.TimingConfig{FastMode: true})
+	mockState.SeedDemoClusters()
+	rdsServer := httptest.NewServer(mock.NewServer(mockState, slog.New(slog.DiscardHandler), false))
+	defer rdsServer.Close()
+	server := probeServer(t, rdsServer.URL)
+
+	if status, body := getProbe(t, server.URL+"/healthz"); status != http.StatusOK || body.Status != "ok" {
+		t.Errorf("/healthz = %d %+v, want 200 ok", status, body)
+	}
+
+	status, body := getProbe(t, server.URL+"/readyz")
+	if status != http.StatusOK {
+		t.Fatalf("/readyz = %d %+v, want 200", status, body)
+	}
+	if body.Status != "ready" || body.Region != "us-east-1" || !body.DemoMode {
+		t.Errorf("/readyz body = %+v, want ready in us-east-1 in demo mode", body)
+	}
+}
+
+func TestProbes_RDSUnreachable(t *testing.T) {
+	rdsServer := httptest.NewServer(http.NotFoundHandler())
+	rdsServer.Close()
+	server := probeServer(t, rdsServer.URL)
+
+	// Liveness does not depend on RDS.
+	if status, _ := getProbe(t, server.URL+"/healthz"); status != http.StatusOK {
+		t.Errorf("/healthz = %d, want 200", status)
+	}
+
+	status, body := getProbe(t, server.URL+"/readyz")
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz = %d, want 503", status)
+	}
+	if body.Status != "not_ready" || body.Error == "" || body.Region != "us-east-1" {
+		t.Errorf("/readyz body = %+v, want not_ready with the error and region", body)
+	}
+}
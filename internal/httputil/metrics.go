@@ -0,0 +1,33 @@
+package httputil
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// isMetricsRequest reports whether r is a scrape of /metrics and metrics are
+// enabled. When they are disabled the path falls through to the app router,
+// which answers 404.
+func (h *RequestHandler) isMetricsRequest(r *http.Request) bool {
+	if h.app.Metrics == nil || r.Method != http.MethodGet {
+		return false
+	}
+	path := r.URL.Path
+	if h.app.Config != nil && h.app.Config.BasePath != "" {
+		path = strings.TrimPrefix(path, h.app.Config.BasePath)
+	}
+	return path == "/metrics"
+}
+
+// serveMetrics writes the current metrics for a Prometheus scrape.
+func (h *RequestHandler) serveMetrics(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", metricsContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := h.app.Metrics.WriteTo(w); err != nil && h.logger != nil {
+		h.logger.Debug("failed to write metrics", slog.String("error", err.Error()))
+	}
+}
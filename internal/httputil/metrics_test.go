@@ -0,0 +1,62 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/app"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/config"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/metrics"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		basePath   string
+		path       string
+		wantStatus int
+	}{
+		{name: "enabled", enabled: true, path: "/metrics", wantStatus: http.StatusOK},
+		{name: "enabled with base path", enabled: true, basePath: "/rds", path: "/rds/metrics", wantStatus: http.StatusOK},
+		{name: "disabled", enabled: false, path: "/metrics", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := app.NewWithEngine(&config.Config{AWSRegion: "us-east-1", BasePath: tt.basePath},
+				machine.NewEngine(machine.EngineConfig{}), &notifiers.NullNotifier{})
+			if tt.enabled {
+				a.Metrics = metrics.New()
+				a.Metrics.StepFinished("reboot_instance", "completed", 3)
+			}
+			server := httptest.NewServer(NewRequestHandler(a, nil))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if !tt.enabled {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+				t.Errorf("Content-Type = %q, want Prometheus text format", ct)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), `rdsmaint_steps_total{action="reboot_instance",state="completed"} 1`) {
+				t.Errorf("missing step counter:\n%s", body)
+			}
+		})
+	}
+}
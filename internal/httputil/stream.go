@@ -0,0 +1,155 @@
+package httputil
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// eventStreamOperationID returns the operation ID when r asks for the events
+// of an operation as a Server-Sent Events stream. Plain JSON requests to the
+// same path keep going through the app router.
+func (h *RequestHandler) eventStreamOperationID(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return "", false
+	}
+
+	path := r.URL.Path
+	if h.app.Config != nil && h.app.Config.BasePath != "" {
+		path = strings.TrimPrefix(path, h.app.Config.BasePath)
+	}
+	if !strings.HasPrefix(path, "/api/operations/") || !strings.HasSuffix(path, "/events") {
+		return "", false
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(path, "/api/operations/"), "/events")
+	if id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+// serveEventStream streams an operation's events and progress as
+// Server-Sent Events. Events already recorded are replayed first (after
+// Last-Event-ID, if the client sent one). The stream ends when the operation
+// reaches a terminal state or the client goes away.
+func (h *RequestHandler) serveEventStream(w http.ResponseWriter, r *http.Request, id string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between.
+	updates, unsubscribe, err := h.app.SubscribeOperation(id)
+	if err != nil {
+		if internalerrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer unsubscribe()
+
+	backlog, err := h.app.GetEvents(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	progress, err := h.app.GetProgress(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// The server's write timeout is meant for ordinary requests; a progress
+	// stream stays open for as long as the operation runs.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	seen := make(map[string]bool, len(backlog))
+	lastEventID := r.Header.Get("Last-Event-ID")
+	replay := lastEventID == ""
+	for _, event := range backlog {
+		seen[event.ID] = true
+		if replay {
+			if !h.writeSSE(w, event.ID, "event", event) {
+				return
+			}
+		} else if event.ID == lastEventID {
+			replay = true
+		}
+	}
+	if !h.writeSSE(w, "", "progress", progress) {
+		return
+	}
+	flusher.Flush()
+
+	if progress.State.IsTerminal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(constants.EventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case update := <-updates:
+			switch {
+			case update.Event != nil:
+				if seen[update.Event.ID] {
+					continue
+				}
+				if !h.writeSSE(w, update.Event.ID, "event", update.Event) {
+					return
+				}
+			case update.Progress != nil:
+				if !h.writeSSE(w, "", "progress", update.Progress) {
+					return
+				}
+			}
+			flusher.Flush()
+			if update.Progress != nil && update.Progress.State.IsTerminal() {
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Events message. It reports false once the
+// client can no longer be written to.
+func (h *RequestHandler) writeSSE(w http.ResponseWriter, id, event string, payload any) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Warn("failed to marshal stream payload", slog.String("error", err.Error()))
+		}
+		return true
+	}
+
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\ndata: %s\n\n", event, data)
+	_, err = fmt.Fprint(w, b.String())
+	return err == nil
+}
@@ -0,0 +1,122 @@
+package httputil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/app"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/config"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/notifiers"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// testServer serves an app whose engine was loaded with one completed operation.
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ctx := context.Background()
+
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	now := time.Now()
+	op := &types.Operation{
+		ID:          "op-done",
+		Type:        types.OperationTypeInstanceCycle,
+		State:       types.StateCompleted,
+		ClusterID:   "demo-multi",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CompletedAt: &now,
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Reboot reader 1", State: types.StepStateCompleted, Action: "reboot_instance"},
+		},
+	}
+	if err := store.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	for i, typ := range []string{"operation_started", "operation_completed"} {
+		event := types.Event{ID: "evt-" + strconv.Itoa(i+1), OperationID: op.ID, Type: typ, Message: typ, Timestamp: now}
+		if err := store.AppendEvent(ctx, event); err != nil {
+			t.Fatalf("AppendEvent failed: %v", err)
+		}
+	}
+
+	engine := machine.NewEngine(machine.EngineConfig{Store: store, DefaultRegion: "us-east-1"})
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+
+	a := app.NewWithEngine(&config.Config{AWSRegion: "us-east-1"}, engine, &notifiers.NullNotifier{})
+	server := httptest.NewServer(NewRequestHandler(a, nil))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEventStream_ReplaysAndClosesOnTerminalState(t *testing.T) {
+	server := testServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/operations/op-done/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "evt-1")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// The body must end on its own because the operation is already terminal.
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	stream := string(body)
+
+	if strings.Contains(stream, "id: evt-1\n") {
+		t.Errorf("event before Last-Event-ID was replayed:\n%s", stream)
+	}
+	if !strings.Contains(stream, "id: evt-2\nevent: event\n") {
+		t.Errorf("missing replayed event evt-2:\n%s", stream)
+	}
+	if !strings.Contains(stream, "event: progress\n") || !strings.Contains(stream, `"state":"completed"`) {
+		t.Errorf("missing terminal progress message:\n%s", stream)
+	}
+}
+
+func TestEventStream_PlainRequestsUseJSON(t *testing.T) {
+	server := testServer(t)
+
+	resp, err := http.Get(server.URL + "/api/operations/op-done/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/operations/missing/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 for unknown operation", resp.StatusCode)
+	}
+}
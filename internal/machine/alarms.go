@@ -0,0 +1,116 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/cloudwatch"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// clusterPlaceholder is replaced by the cluster ID in alarm name patterns, so
+// one pattern such as "{cluster}-replica-lag" can serve every cluster.
+const clusterPlaceholder = "{cluster}"
+
+// handleCheckAlarms reads the CloudWatch alarms for the cluster and pauses for
+// intervention if any selected alarm is firing. Nothing has changed when it
+// pauses, so the operator can continue once the alarm clears, override it, or
+// abort.
+func (e *Engine) handleCheckAlarms(ctx context.Context, op *types.Operation, step *types.Step) error {
+	var params struct {
+		AlarmNamePatterns []string `json:"alarm_name_patterns,omitempty"`
+	}
+	if len(step.Parameters) > 0 {
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+	patterns := params.AlarmNamePatterns
+	if len(patterns) == 0 {
+		patterns = e.alarmPatterns
+	}
+
+	client, err := e.getAlarmClient(ctx, op)
+	if err != nil {
+		return err
+	}
+	alarms, err := client.GetAlarmStates(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get alarm states")
+	}
+
+	checked, err := matchAlarms(alarms, patterns, op.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	var firing []string
+	names := make([]string, 0, len(checked))
+	for _, alarm := range checked {
+		names = append(names, alarm.AlarmName)
+		if alarm.Firing() {
+			firing = append(firing, fmt.Sprintf("%s (%s)", alarm.AlarmName, alarm.Reason))
+		}
+	}
+
+	result, _ := json.Marshal(map[string]any{
+		"patterns": patterns,
+		"checked":  names,
+		"alarms":   checked,
+		"firing":   len(firing),
+	})
+	step.Result = result
+
+	if len(firing) > 0 {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"%d CloudWatch alarm(s) in ALARM for cluster %s: %s. Continue to re-check once they clear, override to proceed anyway, or abort.",
+			len(firing), op.ClusterID, strings.Join(firing, "; "))
+	}
+
+	if len(checked) == 0 {
+		e.addEvent(op.ID, "warning", fmt.Sprintf("No CloudWatch alarms found for cluster %s; nothing to gate on", op.ClusterID), nil)
+		return nil
+	}
+	e.addEvent(op.ID, "info", fmt.Sprintf("Alarm check passed: %d alarm(s) for cluster %s are not in ALARM", len(checked), op.ClusterID), nil)
+	return nil
+}
+
+// validateAlarmPatterns rejects malformed alarm name patterns up front rather
+// than when the check runs just before switchover.
+func validateAlarmPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter, "alarm name pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchAlarms returns the alarms whose names match any of the patterns, using
+// path.Match glob syntax with the cluster placeholder expanded. No patterns
+// selects every alarm.
+func matchAlarms(alarms []cloudwatch.AlarmState, patterns []string, clusterID string) ([]cloudwatch.AlarmState, error) {
+	if len(patterns) == 0 {
+		return alarms, nil
+	}
+
+	var matched []cloudwatch.AlarmState
+	for _, alarm := range alarms {
+		for _, pattern := range patterns {
+			ok, err := path.Match(strings.ReplaceAll(pattern, clusterPlaceholder, clusterID), alarm.AlarmName)
+			if err != nil {
+				return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "alarm name pattern %q: %v", pattern, err)
+			}
+			if ok {
+				matched = append(matched, alarm)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,103 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/cloudwatch"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestMatchAlarms(t *testing.T) {
+	alarms := []cloudwatch.AlarmState{
+		{AlarmName: "prod-db-replica-lag"},
+		{AlarmName: "prod-db-cpu"},
+		{AlarmName: "team-prod-db-connections"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "no patterns selects all", want: []string{"prod-db-replica-lag", "prod-db-cpu", "team-prod-db-connections"}},
+		{name: "cluster placeholder", patterns: []string{"{cluster}-*"}, want: []string{"prod-db-replica-lag", "prod-db-cpu"}},
+		{name: "exact name", patterns: []string{"prod-db-cpu"}, want: []string{"prod-db-cpu"}},
+		{name: "any pattern matches once", patterns: []string{"*-cpu", "prod-db-*"}, want: []string{"prod-db-replica-lag", "prod-db-cpu"}},
+		{name: "nothing matches", patterns: []string{"other-*"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := matchAlarms(alarms, tt.patterns, "prod-db")
+			if err != nil {
+				t.Fatalf("matchAlarms failed: %v", err)
+			}
+			var got []string
+			for _, alarm := range matched {
+				got = append(got, alarm.AlarmName)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := matchAlarms(alarms, []string{"[bad"}, "prod-db"); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for a malformed pattern, got: %v", err)
+	}
+}
+
+func TestHandleCheckAlarms(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	op := &types.Operation{ID: "op-alarms", ClusterID: "demo-upgrade", Region: "us-east-1"}
+	step := &types.Step{Action: "check_alarms"}
+	if err := engine.handleCheckAlarms(ctx, op, step); err != nil {
+		t.Fatalf("check with all alarms OK failed: %v", err)
+	}
+
+	if err := mockState.SetAlarmState("demo-upgrade-replica-lag", mock.AlarmStateAlarm, "Threshold Crossed: lag 45s"); err != nil {
+		t.Fatalf("SetAlarmState failed: %v", err)
+	}
+
+	err := engine.handleCheckAlarms(ctx, op, step)
+	if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+		t.Fatalf("expected ErrInterventionRequired, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "demo-upgrade-replica-lag") {
+		t.Errorf("error should name the firing alarm: %v", err)
+	}
+	var result struct {
+		Checked []string `json:"checked"`
+		Firing  int      `json:"firing"`
+	}
+	if err := json.Unmarshal(step.Result, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if result.Firing != 1 || len(result.Checked) != 3 {
+		t.Errorf("result = %+v, want 1 firing of 3 checked", result)
+	}
+
+	// Patterns that leave out the firing alarm let the check pass.
+	step.Parameters, _ = json.Marshal(map[string]any{"alarm_name_patterns": []string{"{cluster}-cpu"}})
+	if err := engine.handleCheckAlarms(ctx, op, step); err != nil {
+		t.Errorf("check restricted to the cpu alarm failed: %v", err)
+	}
+
+	// The engine default applies when the step has no patterns.
+	engine.alarmPatterns = []string{"*-replica-lag"}
+	step.Parameters = nil
+	if err := engine.handleCheckAlarms(ctx, op, step); !errors.Is(err, internalerrors.ErrInterventionRequired) {
+		t.Errorf("expected the default patterns to catch the firing alarm, got: %v", err)
+	}
+}
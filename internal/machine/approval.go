@@ -0,0 +1,69 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// ApproveOperation records a second person's approval of an operation held
+// in pending_approval and starts it. The approver must not be the creator;
+// identities are compared without regard to case or surrounding space. Like
+// CreatedBy, the approver must come from an authenticated principal.
+//
+// An approval that cannot start the operation, e.g. because the cluster is
+// busy, still stands: the operation is left created and can be started
+// later without approving it again.
+func (e *Engine) ApproveOperation(ctx context.Context, id, approver, comment string) error {
+	approver = strings.TrimSpace(approver)
+
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return internalerrors.ErrOperationNotFound
+	}
+	if op.ApprovedBy != "" {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState,
+			"operation was already approved by %s", op.ApprovedBy)
+	}
+	if op.State != types.StatePendingApproval {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState,
+			"operation in state %s is not awaiting approval", op.State)
+	}
+	if approver == "" {
+		e.mu.Unlock()
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "approver is required")
+	}
+	if strings.EqualFold(approver, strings.TrimSpace(op.CreatedBy)) {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrSelfApproval,
+			"%s created operation %s and cannot also approve it", approver, id)
+	}
+
+	now := time.Now()
+	op.ApprovedBy = approver
+	op.ApprovedAt = &now
+	op.State = types.StateCreated
+	op.UpdatedAt = now
+	audit, _ := json.Marshal(map[string]string{
+		"created_by":  op.CreatedBy,
+		"approved_by": approver,
+		"comment":     comment,
+	})
+	approved := e.addEventLocked(id, "operation_approved",
+		fmt.Sprintf("Approved by %s (created by %s)", approver, op.CreatedBy), audit)
+	e.mu.Unlock()
+
+	e.persistEvent(approved)
+	e.persistOperation(ctx, op)
+	return e.StartOperation(ctx, id)
+}
@@ -0,0 +1,104 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestApproveOperation(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{RequiresApproval: true})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter without created_by, got: %v", err)
+	}
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{RequiresApproval: true, CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if op.State != types.StatePendingApproval || len(op.Steps) == 0 {
+		t.Fatalf("state = %s with %d steps, want a planned operation pending approval", op.State, len(op.Steps))
+	}
+	if err := engine.StartOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected starting before approval to fail with ErrInvalidState, got: %v", err)
+	}
+
+	if err := engine.ApproveOperation(ctx, op.ID, " ALICE ", ""); !errors.Is(err, internalerrors.ErrSelfApproval) {
+		t.Errorf("expected ErrSelfApproval, got: %v", err)
+	}
+	if err := engine.ApproveOperation(ctx, op.ID, "", ""); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for a missing approver, got: %v", err)
+	}
+	if err := engine.ApproveOperation(ctx, "missing", "bob", ""); !internalerrors.IsNotFound(err) {
+		t.Errorf("expected not found, got: %v", err)
+	}
+
+	if err := engine.ApproveOperation(ctx, op.ID, "bob", "looks good"); err != nil {
+		t.Fatalf("ApproveOperation failed: %v", err)
+	}
+	got, _ := engine.GetOperation(op.ID)
+	if got.CreatedBy != "alice" || got.ApprovedBy != "bob" || got.ApprovedAt == nil {
+		t.Errorf("created_by %q, approved_by %q, approved_at %v; want alice, bob and a time", got.CreatedBy, got.ApprovedBy, got.ApprovedAt)
+	}
+	if got.StartedAt == nil {
+		t.Error("approval should start the operation")
+	}
+
+	err = engine.ApproveOperation(ctx, op.ID, "carol", "")
+	if !errors.Is(err, internalerrors.ErrInvalidState) || !containsString(err.Error(), "already approved by bob") {
+		t.Errorf("expected an already-approved error, got: %v", err)
+	}
+
+	events, _ := engine.GetEvents(op.ID)
+	var audited bool
+	for _, event := range events {
+		if event.Type == "operation_approved" {
+			var audit map[string]string
+			_ = json.Unmarshal(event.Data, &audit)
+			audited = audit["created_by"] == "alice" && audit["approved_by"] == "bob" && audit["comment"] == "looks good"
+		}
+	}
+	if !audited {
+		t.Errorf("expected an operation_approved event naming alice and bob, got %+v", events)
+	}
+}
+
+func TestApproveOperation_MajorUpgrade(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.majorApproval = true
+	ctx := context.Background()
+
+	minor, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: "15.5"})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeEngineUpgrade, "demo-upgrade", "us-east-1",
+		minor, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if op.RequiresApproval || op.State != types.StatePlanned {
+		t.Errorf("a minor upgrade should not need approval, got state %s", op.State)
+	}
+	if err := engine.DeleteOperation(ctx, op.ID); err != nil {
+		t.Fatalf("DeleteOperation failed: %v", err)
+	}
+
+	major, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: "16.1"})
+	op, err = engine.CreateOperation(ctx, types.OperationTypeEngineUpgrade, "demo-upgrade", "us-east-1",
+		major, CreateOptions{CreatedBy: "alice"})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if !op.RequiresApproval || op.State != types.StatePendingApproval {
+		t.Errorf("a major upgrade should wait for approval, got requires_approval=%v in state %s", op.RequiresApproval, op.State)
+	}
+}
@@ -0,0 +1,174 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/autoscaling"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// autoscalingSuspension is the result of a suspend_autoscaling step. Prior is
+// the scaling state found before suspending, which resume_autoscaling restores.
+type autoscalingSuspension struct {
+	Registered bool                        `json:"registered"`
+	Suspended  bool                        `json:"suspended"`
+	Prior      *autoscaling.SuspendedState `json:"prior,omitempty"`
+	Policies   []string                    `json:"policies,omitempty"`
+	Error      string                      `json:"error,omitempty"`
+}
+
+// suspendAutoscalingStep returns the step that suspends read replica auto
+// scaling. Builders place it after the preflight check, so nothing is
+// suspended for an operation that cannot start.
+func suspendAutoscalingStep() types.Step {
+	return types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Suspend autoscaling",
+		Description: "Record the cluster's read replica auto scaling and suspend it so readers are not added or removed mid-operation",
+		State:       types.StepStatePending,
+		Action:      "suspend_autoscaling",
+		MaxRetries:  2,
+	}
+}
+
+// resumeAutoscalingStep returns the step that restores the scaling state
+// recorded by suspend_autoscaling.
+func resumeAutoscalingStep() types.Step {
+	return types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Resume autoscaling",
+		Description: "Restore the read replica auto scaling state recorded before the operation",
+		State:       types.StepStatePending,
+		Action:      "resume_autoscaling",
+		MaxRetries:  2,
+	}
+}
+
+// autoscalingSuspensionOf returns what the operation's suspend_autoscaling
+// step did, or nil if it has not completed.
+func autoscalingSuspensionOf(op *types.Operation) *autoscalingSuspension {
+	for _, step := range op.Steps {
+		if step.Action != "suspend_autoscaling" || step.State != types.StepStateCompleted {
+			continue
+		}
+		var result autoscalingSuspension
+		if err := json.Unmarshal(step.Result, &result); err != nil {
+			return nil
+		}
+		return &result
+	}
+	return nil
+}
+
+// autoscalingResumePending reports whether the operation suspended auto
+// scaling and has not restored it yet.
+func autoscalingResumePending(op *types.Operation) bool {
+	suspension := autoscalingSuspensionOf(op)
+	if suspension == nil || !suspension.Suspended {
+		return false
+	}
+	for _, step := range op.Steps {
+		if step.Action == "resume_autoscaling" && step.State == types.StepStateCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSuspendAutoscaling records the cluster's read replica auto scaling and
+// suspends it. A cluster without a scalable target has nothing to suspend. If
+// the scaling cannot be read or suspended, typically for want of Application
+// Auto Scaling permissions, the operation warns and carries on: scaling only
+// makes the operation noisier, not unsafe.
+func (e *Engine) handleSuspendAutoscaling(ctx context.Context, op *types.Operation, step *types.Step) error {
+	result, err := e.suspendAutoscaling(ctx, op)
+	if err != nil {
+		result.Error = err.Error()
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Could not suspend autoscaling of %s, continuing with it active: %v", op.ClusterID, err), nil)
+	}
+	step.Result, _ = json.Marshal(result)
+	return nil
+}
+
+func (e *Engine) suspendAutoscaling(ctx context.Context, op *types.Operation) (*autoscalingSuspension, error) {
+	result := &autoscalingSuspension{}
+	client, err := e.getAutoScalingClient(ctx, op)
+	if err != nil {
+		return result, errors.Wrap(err, "get autoscaling client")
+	}
+	scaling, err := client.GetClusterScaling(ctx, op.ClusterID)
+	if err != nil {
+		return result, err
+	}
+	if scaling == nil {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Cluster %s has no read replica autoscaling to suspend", op.ClusterID), nil)
+		return result, nil
+	}
+
+	result.Registered = true
+	result.Prior = &scaling.Suspended
+	result.Policies = scaling.Policies
+	if scaling.Suspended == autoscaling.FullySuspended {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Autoscaling of %s is already suspended", op.ClusterID), nil)
+		return result, nil
+	}
+	if err := client.SetSuspendedState(ctx, op.ClusterID, autoscaling.FullySuspended); err != nil {
+		return result, err
+	}
+	result.Suspended = true
+
+	policies := "no policies"
+	if len(scaling.Policies) > 0 {
+		policies = "policies " + strings.Join(scaling.Policies, ", ")
+	}
+	e.addEvent(op.ID, "info", fmt.Sprintf("Suspended autoscaling of %s (%d-%d readers, %s)",
+		op.ClusterID, scaling.MinCapacity, scaling.MaxCapacity, policies), nil)
+	return result, nil
+}
+
+// handleResumeAutoscaling restores the scaling state suspend_autoscaling
+// recorded. It does nothing if that step suspended nothing. A failure pauses
+// the operation, since leaving scaling suspended would go unnoticed.
+func (e *Engine) handleResumeAutoscaling(ctx context.Context, op *types.Operation, step *types.Step) error {
+	e.mu.RLock()
+	suspension := autoscalingSuspensionOf(op)
+	e.mu.RUnlock()
+	if suspension == nil || !suspension.Suspended || suspension.Prior == nil {
+		step.Result = json.RawMessage(`{"resumed":false}`)
+		return nil
+	}
+
+	client, err := e.getAutoScalingClient(ctx, op)
+	if err == nil {
+		err = client.SetSuspendedState(ctx, op.ClusterID, *suspension.Prior)
+	}
+	if err != nil {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"could not restore autoscaling of %s: %v; restore its suspended state by hand or continue to retry", op.ClusterID, err)
+	}
+
+	step.Result, _ = json.Marshal(map[string]any{"resumed": true, "state": suspension.Prior})
+	e.addEvent(op.ID, "info", fmt.Sprintf("Restored autoscaling of %s", op.ClusterID), nil)
+	return nil
+}
+
+// restoreAutoscalingAfterCancel restores auto scaling a cancelled operation
+// suspended. It is best effort: a failure is reported, not retried.
+func (e *Engine) restoreAutoscalingAfterCancel(ctx context.Context, op *types.Operation) {
+	e.mu.RLock()
+	pending := autoscalingResumePending(op)
+	e.mu.RUnlock()
+	if !pending {
+		return
+	}
+	step := resumeAutoscalingStep()
+	if err := e.handleResumeAutoscaling(ctx, op, &step); err != nil {
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Cancelled with autoscaling still suspended: %v", err), nil)
+	}
+}
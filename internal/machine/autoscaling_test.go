@@ -0,0 +1,182 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestBuildInstanceTypeChangeSteps_SuspendAutoscaling(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	for _, suspend := range []bool{false, true} {
+		params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge", SuspendAutoscaling: suspend})
+		op := &types.Operation{
+			ID:         "test-op-suspend-autoscaling",
+			Type:       types.OperationTypeInstanceTypeChange,
+			State:      types.StateCreated,
+			ClusterID:  "demo-autoscaled",
+			Region:     "us-east-1",
+			Parameters: params,
+			CreatedAt:  time.Now(),
+		}
+		if err := engine.buildInstanceTypeChangeSteps(context.Background(), op); err != nil {
+			t.Fatalf("buildInstanceTypeChangeSteps failed: %v", err)
+		}
+
+		suspendIdx, resumeIdx := -1, -1
+		for i, step := range op.Steps {
+			switch step.Action {
+			case "suspend_autoscaling":
+				suspendIdx = i
+			case "resume_autoscaling":
+				resumeIdx = i
+			}
+		}
+		if !suspend {
+			if suspendIdx >= 0 || resumeIdx >= 0 {
+				t.Errorf("autoscaling steps added without suspend_autoscaling")
+			}
+			continue
+		}
+		if suspendIdx < 0 || op.Steps[suspendIdx-1].Action != "preflight_check" {
+			t.Errorf("suspend_autoscaling at %d, want straight after preflight_check", suspendIdx)
+		}
+		if last := len(op.Steps) - 1; resumeIdx != last-1 || op.Steps[last].Action != "verify_end_state" {
+			t.Errorf("resume_autoscaling at %d of %d, want just before verify_end_state", resumeIdx, len(op.Steps))
+		}
+	}
+}
+
+func TestSuspendAndResumeAutoscaling(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	newOp := func(id, clusterID string) *types.Operation {
+		return &types.Operation{
+			ID:        id,
+			ClusterID: clusterID,
+			Region:    "us-east-1",
+			Steps: []types.Step{
+				{Name: "Suspend autoscaling", Action: "suspend_autoscaling"},
+				{Name: "Resume autoscaling", Action: "resume_autoscaling"},
+			},
+		}
+	}
+	suspended := func() bool {
+		target, _ := mockState.GetScalableTarget("demo-autoscaled")
+		return target.DynamicScalingInSuspended && target.DynamicScalingOutSuspended && target.ScheduledScalingSuspended
+	}
+
+	t.Run("suspended and restored", func(t *testing.T) {
+		op := newOp("op-autoscaling", "demo-autoscaled")
+		if err := engine.handleSuspendAutoscaling(ctx, op, &op.Steps[0]); err != nil {
+			t.Fatalf("handleSuspendAutoscaling failed: %v", err)
+		}
+		op.Steps[0].State = types.StepStateCompleted
+		if !suspended() {
+			t.Fatal("autoscaling not suspended")
+		}
+		if !containsAny(string(op.Steps[0].Result), `"policies":["demo-autoscaled-cpu-target"]`) {
+			t.Errorf("result does not record the policies: %s", op.Steps[0].Result)
+		}
+
+		if err := engine.handleResumeAutoscaling(ctx, op, &op.Steps[1]); err != nil {
+			t.Fatalf("handleResumeAutoscaling failed: %v", err)
+		}
+		if target, _ := mockState.GetScalableTarget("demo-autoscaled"); target.DynamicScalingInSuspended || target.ScheduledScalingSuspended {
+			t.Errorf("target = %+v, want the prior state restored", target)
+		}
+	})
+
+	t.Run("no scalable target", func(t *testing.T) {
+		op := newOp("op-autoscaling-none", "demo-upgrade")
+		if err := engine.handleSuspendAutoscaling(ctx, op, &op.Steps[0]); err != nil {
+			t.Fatalf("handleSuspendAutoscaling failed: %v", err)
+		}
+		op.Steps[0].State = types.StepStateCompleted
+		if !containsAny(string(op.Steps[0].Result), `"registered":false`) {
+			t.Errorf("result = %s, want no target registered", op.Steps[0].Result)
+		}
+		if err := engine.handleResumeAutoscaling(ctx, op, &op.Steps[1]); err != nil {
+			t.Fatalf("handleResumeAutoscaling failed: %v", err)
+		}
+		if string(op.Steps[1].Result) != `{"resumed":false}` {
+			t.Errorf("resume result = %s, want nothing resumed", op.Steps[1].Result)
+		}
+	})
+
+	t.Run("no permission warns and continues", func(t *testing.T) {
+		mockState.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeAPIError,
+			Action:      "RegisterScalableTarget",
+			Target:      "demo-autoscaled",
+			Probability: 1.0,
+			ErrorCode:   "AccessDeniedException",
+			ErrorMsg:    "not authorized to perform application-autoscaling:RegisterScalableTarget",
+			Enabled:     true,
+		})
+		defer mockState.Faults().ClearAll()
+
+		op := newOp("op-autoscaling-denied", "demo-autoscaled")
+		if err := engine.handleSuspendAutoscaling(ctx, op, &op.Steps[0]); err != nil {
+			t.Fatalf("handleSuspendAutoscaling should continue, got: %v", err)
+		}
+		if suspended() {
+			t.Error("autoscaling suspended despite the denial")
+		}
+		if !containsAny(string(op.Steps[0].Result), "AccessDeniedException") {
+			t.Errorf("result does not record the error: %s", op.Steps[0].Result)
+		}
+		events, _ := engine.GetEvents(op.ID)
+		if len(events) == 0 || events[len(events)-1].Type != "warning" {
+			t.Errorf("events = %+v, want a warning", events)
+		}
+	})
+
+	t.Run("failed restore pauses", func(t *testing.T) {
+		op := newOp("op-autoscaling-restore-denied", "demo-autoscaled")
+		if err := engine.handleSuspendAutoscaling(ctx, op, &op.Steps[0]); err != nil {
+			t.Fatalf("handleSuspendAutoscaling failed: %v", err)
+		}
+		op.Steps[0].State = types.StepStateCompleted
+
+		mockState.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeAPIError,
+			Action:      "RegisterScalableTarget",
+			Target:      "demo-autoscaled",
+			Probability: 1.0,
+			ErrorCode:   "AccessDeniedException",
+			ErrorMsg:    "not authorized",
+			Enabled:     true,
+		})
+		err := engine.handleResumeAutoscaling(ctx, op, &op.Steps[1])
+		mockState.Faults().ClearAll()
+		if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+			t.Fatalf("err = %v, want an intervention", err)
+		}
+
+		// Rolling back restores the state the operation left suspended.
+		if err := engine.prepareRollbackLocked(op); err != nil {
+			t.Fatalf("prepareRollbackLocked failed: %v", err)
+		}
+		last := op.Steps[len(op.Steps)-1]
+		if last.Action != "resume_autoscaling" || !last.Rollback {
+			t.Fatalf("last step = %s (rollback %v), want a resume_autoscaling rollback step", last.Action, last.Rollback)
+		}
+		if err := engine.handleResumeAutoscaling(ctx, op, &last); err != nil {
+			t.Fatalf("handleResumeAutoscaling failed: %v", err)
+		}
+		if suspended() {
+			t.Error("autoscaling still suspended after rollback")
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// readerBatchSize resolves max_parallel_readers for a cluster. Zero means
+// one reader at a time. Each batch takes its readers out of service
+// together, so a batch may include at most all but one of the cluster's
+// readers, or all but minReaders of them when min_available_readers is set;
+// a larger request is capped to that with a warning, since it would leave
+// the writer without a failover target while the batch runs, or stall on
+// the reader floor.
+func readerBatchSize(requested, minReaders int, info *types.ClusterInfo, writerID string) (int, string, error) {
+	if requested < 0 {
+		return 0, "", errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"max_parallel_readers must not be negative, got %d", requested)
+	}
+	if requested <= 1 {
+		return 1, "", nil
+	}
+
+	readers := 0
+	for _, inst := range info.Instances {
+		if inst.InstanceID != writerID {
+			readers++
+		}
+	}
+	keep := max(minReaders, 1)
+	limit := max(readers-keep, 1)
+	if requested <= limit {
+		return requested, "", nil
+	}
+	if minReaders > 1 {
+		return limit, fmt.Sprintf("max_parallel_readers %d capped to %d: cluster %s has %d readers and min_available_readers keeps %d in service while a batch is modified",
+			requested, limit, info.ClusterID, readers, minReaders), nil
+	}
+	return limit, fmt.Sprintf("max_parallel_readers %d capped to %d: cluster %s has %d readers and at least one must stay in service while a batch is modified",
+		requested, limit, info.ClusterID, readers), nil
+}
+
+// modifyInstanceSteps builds the modify and wait steps for the given
+// instances, in order. Readers are modified batchSize at a time and waited on
+// together with wait_instances_available; the writer is always in a batch of
+// its own. Single-instance batches keep the wait_instance_available step, so
+// a batch size of 1 plans exactly what the builders did before batching.
+// modifyStep builds one instance's modify_instance step.
+func modifyInstanceSteps(instances []types.InstanceInfo, writerID string, batchSize int, waitDescription string,
+	modifyStep func(types.InstanceInfo) (types.Step, error)) ([]types.Step, error) {
+	var steps []types.Step
+	var batch []string
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		waitStep, err := batchWaitStep(batch, waitDescription)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, waitStep)
+		batch = nil
+		return nil
+	}
+
+	for _, instance := range instances {
+		isWriter := instance.InstanceID == writerID
+		if isWriter {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		step, err := modifyStep(instance)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+		batch = append(batch, instance.InstanceID)
+
+		if isWriter || len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// batchWaitStep builds the step that waits for a batch of modified
+// instances. The instance IDs are always explicit so the wait can never
+// fall back to some other instance and let modifications overlap.
+func batchWaitStep(instanceIDs []string, description string) (types.Step, error) {
+	if len(instanceIDs) == 1 {
+		waitParams, err := json.Marshal(map[string]string{
+			"instance_id": instanceIDs[0],
+		})
+		if err != nil {
+			return types.Step{}, errors.Wrapf(err, "marshal wait_instance_available params for %s", instanceIDs[0])
+		}
+		return types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Wait for instance: " + instanceIDs[0],
+			Description: description,
+			State:       types.StepStatePending,
+			Action:      "wait_instance_available",
+			Parameters:  waitParams,
+			MaxRetries:  1,
+		}, nil
+	}
+
+	waitParams, err := json.Marshal(map[string][]string{
+		"instance_ids": instanceIDs,
+	})
+	if err != nil {
+		return types.Step{}, errors.Wrap(err, "marshal wait_instances_available params")
+	}
+	return types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Wait for instances: " + strings.Join(instanceIDs, ", "),
+		Description: description,
+		State:       types.StepStatePending,
+		Action:      "wait_instances_available",
+		Parameters:  waitParams,
+		MaxRetries:  1,
+	}, nil
+}
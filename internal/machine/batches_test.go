@@ -0,0 +1,181 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// addReaders adds readers to a demo cluster so it has enough to batch.
+func addReaders(t *testing.T, mockState *mock.State, clusterID string, n int) {
+	t.Helper()
+	for i := range n {
+		err := mockState.CreateInstance(&mock.MockInstance{
+			ID:           fmt.Sprintf("%s-extra-%d", clusterID, i),
+			ClusterID:    clusterID,
+			InstanceType: "db.r6g.large",
+		})
+		if err != nil {
+			t.Fatalf("CreateInstance failed: %v", err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for i := range n {
+		for {
+			if inst, _ := mockState.GetInstance(fmt.Sprintf("%s-extra-%d", clusterID, i)); inst.Status == "available" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("added readers did not become available")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestBuildInstanceTypeChangeSteps_ParallelReaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxParallel int
+		wantBatch   int
+		wantWarning bool
+	}{
+		{name: "default is serial", maxParallel: 0, wantBatch: 1},
+		{name: "batches of two", maxParallel: 2, wantBatch: 2},
+		{name: "capped below reader count", maxParallel: 10, wantBatch: 3, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, mockState, cleanup := testEngineWithMockState(t)
+			defer cleanup()
+			addReaders(t, mockState, "demo-multi", 2) // 4 readers
+
+			params, _ := json.Marshal(map[string]any{
+				"target_instance_type": "db.r6g.xlarge",
+				"max_parallel_readers": tt.maxParallel,
+			})
+			op, err := engine.CreateOperation(context.Background(), types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1",
+				params, CreateOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("CreateOperation failed: %v", err)
+			}
+
+			var warned bool
+			for _, warning := range op.Warnings {
+				warned = warned || strings.Contains(warning, "max_parallel_readers 10 capped to 3")
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("capped warning = %v, want %v (warnings %v)", warned, tt.wantWarning, op.Warnings)
+			}
+
+			// Every wait must cover exactly the instances modified since the
+			// previous wait, and the writer must be alone in its batch.
+			var pending []string
+			largest := 0
+			for _, step := range op.Steps {
+				switch step.Action {
+				case "modify_instance":
+					var p struct {
+						InstanceID string `json:"instance_id"`
+					}
+					_ = json.Unmarshal(step.Parameters, &p)
+					pending = append(pending, p.InstanceID)
+				case "wait_instance_available", "wait_instances_available":
+					if len(pending) == 0 {
+						continue // temp instance wait
+					}
+					var p struct {
+						InstanceID  string   `json:"instance_id"`
+						InstanceIDs []string `json:"instance_ids"`
+					}
+					_ = json.Unmarshal(step.Parameters, &p)
+					waited := p.InstanceIDs
+					if p.InstanceID != "" {
+						waited = []string{p.InstanceID}
+					}
+					if strings.Join(waited, ",") != strings.Join(pending, ",") {
+						t.Errorf("%s waits on %v, want %v", step.Name, waited, pending)
+					}
+					if len(pending) > 1 && strings.Contains(strings.Join(pending, ","), "demo-multi-writer") {
+						t.Errorf("writer modified together with readers: %v", pending)
+					}
+					if (len(waited) > 1) != (step.Action == "wait_instances_available") {
+						t.Errorf("%s uses %s for %d instances", step.Name, step.Action, len(waited))
+					}
+					largest = max(largest, len(waited))
+					pending = nil
+				}
+			}
+			if len(pending) > 0 {
+				t.Errorf("modified instances never waited on: %v", pending)
+			}
+			if largest != tt.wantBatch {
+				t.Errorf("largest batch = %d, want %d", largest, tt.wantBatch)
+			}
+		})
+	}
+}
+
+func TestBuildStorageTypeChangeSteps_RejectsNegativeParallelReaders(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	_, err := engine.CreateOperation(context.Background(), types.OperationTypeStorageTypeChange, "demo-multi", "us-east-1",
+		json.RawMessage(`{"target_storage_type":"aurora-iopt1","max_parallel_readers":-1}`), CreateOptions{DryRun: true})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+	}
+}
+
+func TestHandleWaitInstancesAvailable(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+	ctx := context.Background()
+
+	t.Run("requires explicit instance IDs", func(t *testing.T) {
+		for _, params := range []string{`{}`, `{"instance_ids":["demo-multi-reader-1",""]}`} {
+			op := &types.Operation{ID: "op-batch-ids", ClusterID: "demo-multi", Region: "us-east-1"}
+			step := &types.Step{Name: "Wait for instances", Action: "wait_instances_available", Parameters: json.RawMessage(params)}
+			if err := engine.handleWaitInstancesAvailable(ctx, op, step); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+				t.Errorf("params %s: expected ErrInvalidParameter, got: %v", params, err)
+			}
+		}
+	})
+
+	t.Run("waits for every instance in the batch", func(t *testing.T) {
+		readers := []string{"demo-multi-reader-1", "demo-multi-reader-2"}
+		op := &types.Operation{ID: "op-batch", ClusterID: "demo-multi", Region: "us-east-1"}
+		for _, id := range readers {
+			params, _ := json.Marshal(map[string]string{"instance_id": id, "instance_type": "db.r6g.xlarge"})
+			op.Steps = append(op.Steps, types.Step{Name: "Modify instance: " + id, Action: "modify_instance", Parameters: params})
+		}
+		waitParams, _ := json.Marshal(map[string][]string{"instance_ids": readers})
+		op.Steps = append(op.Steps, types.Step{Name: "Wait for instances", Action: "wait_instances_available", Parameters: waitParams})
+
+		for i := range readers {
+			op.CurrentStepIndex = i
+			if err := engine.handleModifyInstance(ctx, op, &op.Steps[i]); err != nil {
+				t.Fatalf("handleModifyInstance failed: %v", err)
+			}
+		}
+		op.CurrentStepIndex = len(readers)
+		if err := engine.handleWaitInstancesAvailable(ctx, op, &op.Steps[len(readers)]); err != nil {
+			t.Fatalf("handleWaitInstancesAvailable failed: %v", err)
+		}
+		for _, id := range readers {
+			if inst, _ := mockState.GetInstance(id); inst.Status != "available" || inst.InstanceType != "db.r6g.xlarge" {
+				t.Errorf("%s is %s on %s, want available on db.r6g.xlarge", id, inst.Status, inst.InstanceType)
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// blueGreenInFlight reports whether a deployment in this status is still
+// being provisioned or switched over, and so may belong to a running upgrade.
+func blueGreenInFlight(status string) bool {
+	switch status {
+	case "PROVISIONING", "AVAILABLE", "SWITCHOVER_IN_PROGRESS":
+		return true
+	}
+	return false
+}
+
+// resolveBlueGreenDeployment returns the identifier of the operation's
+// Blue-Green deployment. When the recorded identifier is missing or no longer
+// describes, e.g. after a restart lost the adoption of an existing
+// deployment, the cluster's in-flight deployment is adopted again and
+// recorded on the operation. If there is none to adopt, the recorded
+// identifier is returned as is and callers handle the deployment being gone.
+func (e *Engine) resolveBlueGreenDeployment(ctx context.Context, op *types.Operation, rdsClient *rds.Client) (string, error) {
+	e.mu.RLock()
+	deploymentID := e.findBlueGreenDeploymentID(op)
+	e.mu.RUnlock()
+
+	if deploymentID != "" {
+		_, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
+		if !errors.Is(err, internalerrors.ErrBlueGreenDeploymentNotFound) {
+			// Found, or a describe failure the caller's own describe retries
+			return deploymentID, nil
+		}
+	}
+
+	clusterARN, err := rdsClient.GetClusterARN(ctx, op.ClusterID)
+	if err != nil {
+		return "", errors.Wrap(err, "get cluster ARN")
+	}
+	deployments, err := rdsClient.ListBlueGreenDeploymentsForCluster(ctx, clusterARN)
+	if err != nil {
+		return "", errors.Wrap(err, "list Blue-Green deployments")
+	}
+	var candidates []*rds.BlueGreenDeploymentInfo
+	for _, bg := range deployments {
+		if blueGreenInFlight(bg.Status) {
+			candidates = append(candidates, bg)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0 && deploymentID == "":
+		return "", errors.Wrap(internalerrors.ErrInvalidParameter, "blue-green deployment identifier not found")
+	case len(candidates) == 0:
+		return deploymentID, nil
+	case len(candidates) > 1:
+		ids := make([]string, len(candidates))
+		for i, bg := range candidates {
+			ids[i] = bg.Identifier
+		}
+		return "", errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"Blue-Green deployment %q was not found and cluster %s has several in-flight deployments (%s); delete the ones that do not belong to this operation, then continue",
+			deploymentID, op.ClusterID, strings.Join(ids, ", "))
+	}
+
+	bg := candidates[0]
+	e.mu.Lock()
+	for i := range op.Steps {
+		step := &op.Steps[i]
+		if step.Action == "create_blue_green_deployment" && step.State == types.StepStateCompleted {
+			step.Result, _ = json.Marshal(map[string]any{
+				"deployment_identifier": bg.Identifier,
+				"deployment_name":       bg.Name,
+				"source_arn":            bg.Source,
+				"target_arn":            bg.Target,
+				"status":                bg.Status,
+				"adopted":               true,
+			})
+		}
+	}
+	e.mu.Unlock()
+	e.recordBlueGreen(ctx, op, bg)
+	e.persistOperation(ctx, op)
+
+	if deploymentID == "" {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Re-adopted Blue-Green deployment %s (status: %s); the operation had no deployment recorded", bg.Identifier, bg.Status), nil)
+	} else {
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Blue-Green deployment %s not found; re-adopted %s (status: %s)", deploymentID, bg.Identifier, bg.Status), nil)
+	}
+	return bg.Identifier, nil
+}
+
+// recordBlueGreen stores the deployment's source and target resources on the
+// operation, persisting it when they changed. Once a describe has shown the
+// switchover completed the record stays switched over. It returns the record.
+func (e *Engine) recordBlueGreen(ctx context.Context, op *types.Operation, bg *rds.BlueGreenDeploymentInfo) *types.BlueGreenResources {
+	record := &types.BlueGreenResources{
+		DeploymentIdentifier: bg.Identifier,
+		Status:               bg.Status,
+		SourceARN:            bg.Source,
+		TargetARN:            bg.Target,
+		SwitchedOver:         bg.Status == "SWITCHOVER_COMPLETED",
+		UpdatedAt:            time.Now(),
+	}
+	for _, detail := range bg.SwitchoverDetails {
+		record.Members = append(record.Members, types.BlueGreenMember{
+			SourceMember: detail.SourceMember,
+			TargetMember: detail.TargetMember,
+			Status:       detail.Status,
+		})
+	}
+
+	e.mu.Lock()
+	prev := op.BlueGreen
+	if prev != nil && prev.SwitchedOver && prev.DeploymentIdentifier == record.DeploymentIdentifier && !record.SwitchedOver {
+		// A describe racing the rename may still report the blue resources
+		// under their original names; keep the mapping read after it.
+		e.mu.Unlock()
+		return prev
+	}
+	changed := prev == nil || prev.DeploymentIdentifier != record.DeploymentIdentifier ||
+		prev.Status != record.Status || prev.SourceARN != record.SourceARN || prev.TargetARN != record.TargetARN ||
+		prev.SwitchedOver != record.SwitchedOver || !slices.Equal(prev.Members, record.Members)
+	op.BlueGreen = record
+	if changed {
+		op.UpdatedAt = record.UpdatedAt
+	}
+	e.mu.Unlock()
+
+	if changed {
+		e.persistOperation(ctx, op)
+	}
+	return record
+}
+
+// retiredBlueGreenResources returns the blue cluster and instances a
+// completed switchover retired, named by the recorded source ARNs.
+func retiredBlueGreenResources(record *types.BlueGreenResources) (clusterID string, instanceIDs []string) {
+	for _, member := range record.Members {
+		if _, id, ok := strings.Cut(member.SourceMember, ":db:"); ok {
+			instanceIDs = append(instanceIDs, id)
+		} else if _, id, ok := strings.Cut(member.SourceMember, ":cluster:"); ok {
+			clusterID = id
+		}
+	}
+	if clusterID == "" {
+		if _, id, ok := strings.Cut(record.SourceARN, ":cluster:"); ok {
+			clusterID = id
+		}
+	}
+	return clusterID, instanceIDs
+}
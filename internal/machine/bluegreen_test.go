@@ -0,0 +1,196 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestRecordBlueGreen(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+	op := &types.Operation{ID: "op-record-bg", ClusterID: "c1"}
+
+	const arn = "arn:aws:rds:us-east-1:123456789012:"
+	provisioned := &rds.BlueGreenDeploymentInfo{
+		Identifier: "bgd-1",
+		Status:     "AVAILABLE",
+		Source:     arn + "cluster:c1",
+		Target:     arn + "cluster:c1-green",
+		SwitchoverDetails: []rds.BlueGreenSwitchoverDetail{
+			{SourceMember: arn + "cluster:c1", TargetMember: arn + "cluster:c1-green", Status: "AVAILABLE"},
+			{SourceMember: arn + "db:c1-writer", TargetMember: arn + "db:c1-writer-green", Status: "AVAILABLE"},
+		},
+	}
+	record := engine.recordBlueGreen(ctx, op, provisioned)
+	if op.BlueGreen != record || record.SwitchedOver || len(record.Members) != 2 || record.TargetARN != provisioned.Target {
+		t.Fatalf("record = %+v, want the provisioned mapping", record)
+	}
+
+	switched := &rds.BlueGreenDeploymentInfo{
+		Identifier: "bgd-1",
+		Status:     "SWITCHOVER_COMPLETED",
+		Source:     arn + "cluster:c1-old1",
+		Target:     arn + "cluster:c1",
+		SwitchoverDetails: []rds.BlueGreenSwitchoverDetail{
+			{SourceMember: arn + "cluster:c1-old1", TargetMember: arn + "cluster:c1", Status: "SWITCHOVER_COMPLETED"},
+			{SourceMember: arn + "db:c1-writer-old1", TargetMember: arn + "db:c1-writer", Status: "SWITCHOVER_COMPLETED"},
+		},
+	}
+	record = engine.recordBlueGreen(ctx, op, switched)
+	if !record.SwitchedOver {
+		t.Fatal("record not marked switched over")
+	}
+	clusterID, instances := retiredBlueGreenResources(record)
+	if clusterID != "c1-old1" || !slices.Equal(instances, []string{"c1-writer-old1"}) {
+		t.Errorf("retired = %s %v, want c1-old1 [c1-writer-old1]", clusterID, instances)
+	}
+
+	// A stale describe must not replace the mapping read after the switchover.
+	if stale := engine.recordBlueGreen(ctx, op, provisioned); !stale.SwitchedOver || stale.SourceARN != switched.Source {
+		t.Errorf("record = %+v, want the switched-over mapping kept", stale)
+	}
+}
+
+// TestHandleCleanupBlueGreen_RecordedMapping verifies that cleanup deletes
+// exactly the blue resources the recorded mapping names.
+func TestHandleCleanupBlueGreen_RecordedMapping(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	bg, err := mockState.CreateBlueGreenDeployment("recorded", "arn:aws:rds:us-east-1:123456789012:cluster:demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus := func(status string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if current, _ := mockState.GetBlueGreenDeployment(bg.Identifier); current.Status == status {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("deployment did not reach %s", status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForStatus("AVAILABLE")
+	if err := mockState.SwitchoverBlueGreenDeployment(bg.Identifier); err != nil {
+		t.Fatalf("SwitchoverBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus("SWITCHOVER_COMPLETED")
+
+	params, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: "16.4"})
+	deployment, _ := json.Marshal(map[string]string{"deployment_identifier": bg.Identifier})
+	op := &types.Operation{
+		ID:         "op-cleanup-recorded",
+		ClusterID:  "demo-upgrade",
+		Region:     "us-east-1",
+		Parameters: params,
+		Steps: []types.Step{
+			{Action: "create_blue_green_deployment", State: types.StepStateCompleted, Result: deployment},
+			{Name: "Cleanup", Action: "cleanup_blue_green"},
+		},
+	}
+	if err := engine.handleCleanupBlueGreen(ctx, op, &op.Steps[1]); err != nil {
+		t.Fatalf("handleCleanupBlueGreen failed: %v", err)
+	}
+
+	if op.BlueGreen == nil || !op.BlueGreen.SwitchedOver {
+		t.Fatalf("mapping = %+v, want it recorded after the switchover", op.BlueGreen)
+	}
+	if old, ok := mockState.GetCluster("demo-upgrade-old1"); ok && old.Status == "available" {
+		t.Error("demo-upgrade-old1 was not deleted")
+	}
+	for _, id := range []string{"demo-upgrade-writer-old1", "demo-upgrade-reader-1-old1"} {
+		if inst, ok := mockState.GetInstance(id); ok && inst.PendingStatusChange != "deleting" {
+			t.Errorf("%s was not deleted", id)
+		}
+	}
+	for _, id := range []string{"demo-upgrade-writer", "demo-upgrade-reader-1"} {
+		if inst, ok := mockState.GetInstance(id); !ok || inst.Status != "available" {
+			t.Errorf("live instance %s touched: %+v", id, inst)
+		}
+	}
+}
+
+// TestResolveBlueGreenDeployment verifies that a Blue-Green step whose
+// recorded deployment is missing or stale re-adopts the cluster's in-flight
+// deployment instead of failing.
+func TestResolveBlueGreenDeployment(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+	ctx := context.Background()
+
+	newOp := func(id, clusterID string, created json.RawMessage) *types.Operation {
+		return &types.Operation{
+			ID:        id,
+			ClusterID: clusterID,
+			Region:    "us-east-1",
+			Steps: []types.Step{
+				{Action: "create_blue_green_deployment", State: types.StepStateCompleted, Result: created},
+				{Name: "Wait for Blue-Green", Action: "wait_blue_green_available"},
+			},
+		}
+	}
+
+	t.Run("no deployment to adopt", func(t *testing.T) {
+		op := newOp("op-bg-none", "demo-multi", json.RawMessage(`{}`))
+		err := engine.handleWaitBlueGreenAvailable(ctx, op, &op.Steps[1])
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) || !containsAny(err.Error(), "identifier not found") {
+			t.Errorf("err = %v, want the identifier reported missing", err)
+		}
+	})
+
+	rdsClient, err := engine.getRDSClient(ctx, newOp("op-bg-client", "demo-upgrade", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arn, err := rdsClient.GetClusterARN(ctx, "demo-upgrade")
+	if err != nil {
+		t.Fatalf("GetClusterARN failed: %v", err)
+	}
+	bg, err := mockState.CreateBlueGreenDeployment("adopted", arn, "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+
+	t.Run("dropped identifier", func(t *testing.T) {
+		op := newOp("op-bg-dropped", "demo-upgrade", json.RawMessage(`{}`))
+		if err := engine.handleWaitBlueGreenAvailable(ctx, op, &op.Steps[1]); err != nil {
+			t.Fatalf("handleWaitBlueGreenAvailable failed: %v", err)
+		}
+		if got := engine.findBlueGreenDeploymentID(op); got != bg.Identifier {
+			t.Errorf("recorded deployment = %q, want %s", got, bg.Identifier)
+		}
+		if op.BlueGreen == nil || op.BlueGreen.DeploymentIdentifier != bg.Identifier {
+			t.Errorf("mapping = %+v, want the re-adopted deployment", op.BlueGreen)
+		}
+		events, _ := engine.GetEvents(op.ID)
+		if len(events) == 0 || !containsAny(events[0].Message, "Re-adopted Blue-Green deployment "+bg.Identifier) {
+			t.Errorf("events = %+v, want the re-adoption reported", events)
+		}
+	})
+
+	t.Run("stale identifier", func(t *testing.T) {
+		op := newOp("op-bg-stale", "demo-upgrade", json.RawMessage(`{"deployment_identifier":"bgd-stale"}`))
+		got, err := engine.resolveBlueGreenDeployment(ctx, op, rdsClient)
+		if err != nil || got != bg.Identifier {
+			t.Fatalf("resolveBlueGreenDeployment = %q, %v; want %s", got, err, bg.Identifier)
+		}
+		if !containsAny(string(op.Steps[0].Result), `"adopted":true`) {
+			t.Errorf("create step result = %s, want the adopted deployment", op.Steps[0].Result)
+		}
+	})
+}
@@ -4,13 +4,110 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/cockroachdb/errors"
 	"github.com/google/uuid"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
 	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
 )
 
+// clusterUnavailableReason describes why the cluster is not ready for a new
+// operation, or returns "" if the cluster and every non-autoscaled instance
+// are available. Autoscaled instances are ignored because they come and go
+// independently of the operation.
+func clusterUnavailableReason(info *types.ClusterInfo) string {
+	if !rds.ClusterStatus(info.Status).IsAvailable() {
+		return fmt.Sprintf("cluster %s is %s", info.ClusterID, info.Status)
+	}
+	for _, inst := range info.Instances {
+		if inst.IsAutoScaled {
+			continue
+		}
+		if !rds.InstanceStatus(inst.Status).IsAvailable() {
+			return fmt.Sprintf("instance %s is %s", inst.InstanceID, inst.Status)
+		}
+	}
+	return ""
+}
+
+// precheckClusterAvailable verifies the cluster is idle before an operation is
+// stacked on it. If it is not, the operation is rejected unless waitForAvailable
+// is set, in which case a wait step is returned to run ahead of the operation's
+// own steps.
+func (e *Engine) precheckClusterAvailable(ctx context.Context, op *types.Operation, waitForAvailable bool) (*types.Step, error) {
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return nil, errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get cluster info")
+	}
+
+	reason := clusterUnavailableReason(info)
+	if reason == "" {
+		return nil, nil
+	}
+	if !waitForAvailable {
+		return nil, errors.Wrapf(internalerrors.ErrClusterNotAvailable,
+			"%s; retry once it is available or set wait_for_available", reason)
+	}
+
+	op.Warnings = append(op.Warnings, fmt.Sprintf("Cluster not available at creation (%s); operation will wait for it first", reason))
+	return &types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Wait for cluster to become available",
+		Description: "Wait for in-progress changes to finish before starting: " + reason,
+		State:       types.StepStatePending,
+		Action:      "wait_cluster_available",
+		MaxRetries:  1,
+	}, nil
+}
+
+// preflightCheckStep returns the step that confirms the cluster is in a clean
+// starting state before an operation changes anything. Builders place it
+// straight after the first get_cluster_info step. adoptBlueGreen lets an engine
+// upgrade through when a deployment it can adopt already exists.
+func preflightCheckStep(adoptBlueGreen bool) types.Step {
+	step := types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Preflight check",
+		Description: "Verify the cluster is available with one writer, no failed or deleting instances and no Blue-Green deployment in progress",
+		State:       types.StepStatePending,
+		Action:      "preflight_check",
+		MaxRetries:  2,
+	}
+	if adoptBlueGreen {
+		step.Parameters = json.RawMessage(`{"adopt_blue_green":true}`)
+	}
+	return step
+}
+
+// summarizePlan derives the reviewable decisions from a built step list.
+func summarizePlan(steps []types.Step) *types.PlanSummary {
+	plan := &types.PlanSummary{StepCount: len(steps)}
+	for _, step := range steps {
+		switch step.Action {
+		case "create_temp_instance":
+			plan.CreatesTempInstance = true
+			var params struct {
+				InstanceType string `json:"instance_type"`
+			}
+			if err := json.Unmarshal(step.Parameters, &params); err == nil {
+				plan.TempInstanceType = params.InstanceType
+			}
+		case "failover_to_instance":
+			plan.IncludesFailover = true
+		}
+	}
+	return plan
+}
+
 // validateExcludedInstances checks that excluded instance IDs exist in the cluster
 // and that not all instances are excluded. Returns:
 // - excludeSet: map of excluded instance IDs for quick lookup
@@ -57,6 +154,184 @@ func validateExcludedInstances(excludeIDs []string, instances []types.InstanceIn
 	return excludeSet, nil
 }
 
+// validateStorageTypeSupport checks that every instance the storage type change
+// will modify can use the target storage type. supported maps instance class to
+// the storage types orderable for the cluster's engine and version.
+func validateStorageTypeSupport(target, engine string, instances []types.InstanceInfo, excludeSet map[string]bool, supported map[string][]string) error {
+	engineTypes := make(map[string]bool)
+	for _, storageTypes := range supported {
+		for _, st := range storageTypes {
+			engineTypes[st] = true
+		}
+	}
+	if !engineTypes[target] {
+		available := make([]string, 0, len(engineTypes))
+		for st := range engineTypes {
+			available = append(available, st)
+		}
+		sort.Strings(available)
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"storage type %s is not supported by %s; supported storage types: %v", target, engine, available)
+	}
+
+	var unsupported []string
+	for _, inst := range instances {
+		if inst.IsAutoScaled || excludeSet[inst.InstanceID] {
+			continue
+		}
+		classSupported := false
+		for _, st := range supported[inst.InstanceType] {
+			if st == target {
+				classSupported = true
+				break
+			}
+		}
+		if !classSupported {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", inst.InstanceID, inst.InstanceType))
+		}
+	}
+	if len(unsupported) > 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"storage type %s is not available for instance(s) %v; change their instance class first or exclude them", target, unsupported)
+	}
+
+	return nil
+}
+
+// findWriter returns the cluster's non-autoscaled writer, or nil if there is
+// none. DescribeDBClusters does not list the writer first, so callers must
+// never infer it from position.
+func findWriter(instances []types.InstanceInfo) *types.InstanceInfo {
+	for i := range instances {
+		if instances[i].Role == "writer" && !instances[i].IsAutoScaled {
+			return &instances[i]
+		}
+	}
+	return nil
+}
+
+// buildTempInstanceDeleteSteps builds the steps that remove the temp instance.
+// When a final snapshot is requested it is taken as a cluster snapshot before
+// the delete: Aurora does not support final snapshots of individual instances,
+// and the cluster snapshot captures the same data under an identifiable name.
+// The operation-level override takes precedence over the engine default.
+func (e *Engine) buildTempInstanceDeleteSteps(op *types.Operation, finalSnapshot *bool) ([]types.Step, error) {
+	takeSnapshot := e.tempFinalSnapshot
+	if finalSnapshot != nil {
+		takeSnapshot = *finalSnapshot
+	}
+
+	var steps []types.Step
+	if takeSnapshot {
+		snapshotParams, err := json.Marshal(map[string]string{
+			"snapshot_id": op.ClusterID + "-temp-final-" + op.ID,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal final snapshot params")
+		}
+		steps = append(steps, types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Create final snapshot",
+			Description: "Snapshot the cluster before removing the temporary instance",
+			Rationale:   finalSnapshotRationale(finalSnapshot),
+			State:       types.StepStatePending,
+			Action:      "create_snapshot",
+			Parameters:  snapshotParams,
+			MaxRetries:  2,
+		})
+		steps = append(steps, types.Step{
+			ID:             uuid.New().String(),
+			Name:           "Wait for final snapshot",
+			Description:    "Wait for the final snapshot to become available",
+			State:          types.StepStatePending,
+			Action:         "wait_snapshot_available",
+			Parameters:     snapshotParams,
+			MaxRetries:     1,
+			TimeoutSeconds: constants.SnapshotWaitTimeoutSeconds,
+		})
+	}
+
+	deleteParams, err := json.Marshal(deleteInstanceParams{FinalSnapshot: tempInstanceFinalSnapshot})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal delete_instance params")
+	}
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Delete temp instance",
+		Description: "Remove temporary maintenance instance",
+		State:       types.StepStatePending,
+		Action:      "delete_instance",
+		Parameters:  deleteParams,
+		MaxRetries:  2,
+	})
+
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Wait for temp instance deletion",
+		Description: "Wait for temporary instance to be deleted",
+		State:       types.StepStatePending,
+		Action:      "wait_instance_deleted",
+		MaxRetries:  1,
+	})
+
+	return steps, nil
+}
+
+// preUpgradeSnapshotSteps returns the steps that snapshot the cluster before
+// an upgrade, or none when the snapshot is skipped. The snapshot is tagged
+// with the operation's retention override or the engine default, so snapshot
+// cleanup can delete it once it expires.
+func (e *Engine) preUpgradeSnapshotSteps(mode types.PreUpgradeSnapshotMode, retentionDays int) ([]types.Step, error) {
+	switch mode {
+	case "", types.PreUpgradeSnapshotCreate, types.PreUpgradeSnapshotCopyTags:
+	case types.PreUpgradeSnapshotSkip:
+		return nil, nil
+	default:
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"pre_upgrade_snapshot must be %q, %q or %q, got %q",
+			types.PreUpgradeSnapshotCreate, types.PreUpgradeSnapshotSkip, types.PreUpgradeSnapshotCopyTags, mode)
+	}
+	if retentionDays < 0 {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "snapshot_retention_days must not be negative")
+	}
+	if retentionDays == 0 {
+		retentionDays = e.snapshotRetention
+	}
+
+	snapshotParams, err := json.Marshal(map[string]any{
+		"retention_days":    retentionDays,
+		"copy_cluster_tags": mode == types.PreUpgradeSnapshotCopyTags,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal create_snapshot params")
+	}
+	rationale := fmt.Sprintf("pre_upgrade_snapshot is %s; set it to %s to upgrade without one", mode, types.PreUpgradeSnapshotSkip)
+	if mode == "" {
+		rationale = fmt.Sprintf("pre_upgrade_snapshot is not set and defaults to %s", types.PreUpgradeSnapshotCreate)
+	}
+	return []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Create pre-upgrade snapshot",
+			Description: "Snapshot the cluster before it is upgraded",
+			Rationale:   rationale,
+			State:       types.StepStatePending,
+			Action:      "create_snapshot",
+			Parameters:  snapshotParams,
+			MaxRetries:  2,
+		},
+		{
+			ID:             uuid.New().String(),
+			Name:           "Wait for pre-upgrade snapshot",
+			Description:    "Wait for the pre-upgrade snapshot to become available",
+			State:          types.StepStatePending,
+			Action:         "wait_snapshot_available",
+			MaxRetries:     1,
+			TimeoutSeconds: constants.SnapshotWaitTimeoutSeconds,
+		},
+	}, nil
+}
+
 // buildInstanceTypeChangeSteps builds the steps for an instance type change operation.
 // This performs a zero-downtime instance type change by:
 // 1. Creating a temp reader with the new instance type (unless SkipTempInstance is true)
@@ -75,6 +350,16 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 	if params.TargetInstanceType == "" {
 		return errors.New("missing required parameter: target_instance_type")
 	}
+	if params.TempInstancePromotionTier < 0 || params.TempInstancePromotionTier > 15 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"temp_instance_promotion_tier must be between 0 and 15, got %d", params.TempInstancePromotionTier)
+	}
+	if err := validateMinAvailableReaders(params.MinAvailableReaders); err != nil {
+		return err
+	}
+	if err := rds.ValidateTags(params.Tags); err != nil {
+		return err
+	}
 
 	// Get RDS client for operation's region
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -94,22 +379,86 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 		return err
 	}
 
+	// A class the engine version does not offer would only fail once the
+	// temp instance or the first modify is attempted.
+	orderable := newOrderableCache(rdsClient)
+	if err := orderable.validateInstanceClass(ctx, params.TargetInstanceType, info.Engine, info.EngineVersion, ""); err != nil {
+		return err
+	}
+
 	// Check if the writer is excluded - if so, we don't need failover steps
-	var originalWriter *types.InstanceInfo
-	writerExcluded := false
-	for _, instance := range info.Instances {
-		if instance.Role == "writer" && !instance.IsAutoScaled {
-			originalWriter = &instance
-			if excludeSet[instance.InstanceID] {
-				writerExcluded = true
-			}
-			break
+	originalWriter := findWriter(info.Instances)
+	if originalWriter == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidState, "no writer instance found in cluster %s", op.ClusterID)
+	}
+	writerExcluded := excludeSet[originalWriter.InstanceID]
+
+	targetPG := params.TargetInstanceParameterGroupName
+	if targetPG != "" {
+		alreadyUsed, err := validateTargetInstanceParameterGroup(ctx, rdsClient, targetPG, originalWriter.InstanceID)
+		if err != nil {
+			return err
+		}
+		if alreadyUsed {
+			op.Warnings = append(op.Warnings, fmt.Sprintf(
+				"writer %s already uses instance parameter group %s; instances are not switched or rebooted for it",
+				originalWriter.InstanceID, targetPG))
+			targetPG = ""
+		}
+	}
+
+	// An excluded writer keeps its current class while the readers move to the
+	// target, so any failover afterwards lands on a differently sized instance.
+	if writerExcluded && originalWriter.InstanceType != params.TargetInstanceType {
+		msg := fmt.Sprintf("writer %s is excluded and will stay on %s while readers change to %s, leaving the cluster with mixed instance classes; a failover would change the writer's capacity",
+			originalWriter.InstanceID, originalWriter.InstanceType, params.TargetInstanceType)
+		if params.StrictWriterClass {
+			return errors.Wrap(internalerrors.ErrInvalidParameter, msg)
 		}
+		op.Warnings = append(op.Warnings, msg)
+	}
+
+	batchSize, warning, err := readerBatchSize(params.MaxParallelReaders, params.MinAvailableReaders, info, originalWriter.InstanceID)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		op.Warnings = append(op.Warnings, warning)
 	}
 
 	// Determine if we should create a temp instance
 	// By default, create temp instance for redundancy unless explicitly skipped
 	createTempInstance := !params.SkipTempInstance
+	tempRationale := tempInstanceRationale(writerExcluded)
+
+	// A writer with no readers has nothing to fail over to, so skipping the
+	// temp instance means an outage for the whole modification. That takes
+	// allow_downtime; otherwise the temp instance is created regardless.
+	if params.SkipTempInstance && len(info.Instances) == 1 {
+		if params.AllowDowntime {
+			op.Warnings = append(op.Warnings, fmt.Sprintf(
+				"cluster %s has no readers and allow_downtime is set; writer %s is modified in place and the cluster is unavailable until it is back",
+				op.ClusterID, originalWriter.InstanceID))
+		} else {
+			createTempInstance = true
+			tempRationale = forcedTempInstanceRationale(op.ClusterID)
+			op.Warnings = append(op.Warnings, fmt.Sprintf(
+				"cluster %s has no readers, so skip_temp_instance is overridden and a temp instance takes over writes; set allow_downtime to modify writer %s in place instead",
+				op.ClusterID, originalWriter.InstanceID))
+		}
+	}
+
+	if createTempInstance {
+		if params.TempInstanceAvailabilityZone != "" {
+			if err := orderable.validateInstanceClass(ctx, params.TargetInstanceType, info.Engine, info.EngineVersion,
+				params.TempInstanceAvailabilityZone); err != nil {
+				return err
+			}
+		}
+		if problem := e.tempInstanceRoomProblem(info); problem != "" {
+			op.Warnings = append(op.Warnings, problem)
+		}
+	}
 
 	steps := []types.Step{}
 
@@ -122,12 +471,18 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 		Action:      "get_cluster_info",
 		MaxRetries:  3,
 	})
+	steps = append(steps, preflightCheckStep(false))
+	if params.SuspendAutoscaling {
+		steps = append(steps, suspendAutoscalingStep())
+	}
 
 	// Create temp instance if enabled
 	if createTempInstance {
-		createParams, err := json.Marshal(map[string]string{
-			"instance_type": params.TargetInstanceType,
-			"engine":        info.Engine,
+		createParams, err := json.Marshal(map[string]any{
+			"instance_type":     params.TargetInstanceType,
+			"engine":            info.Engine,
+			"promotion_tier":    params.TempInstancePromotionTier,
+			"availability_zone": params.TempInstanceAvailabilityZone,
 		})
 		if err != nil {
 			return errors.Wrap(err, "marshal create_temp_instance params")
@@ -137,6 +492,7 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 			Name:        "Create temp instance",
 			Description: "Create temporary reader with new instance type: " + params.TargetInstanceType,
 			State:       types.StepStatePending,
+			Rationale:   tempRationale,
 			Action:      "create_temp_instance",
 			Parameters:  createParams,
 			MaxRetries:  1,
@@ -158,6 +514,7 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 			ID:          uuid.New().String(),
 			Name:        "Failover to temp instance",
 			Description: "Promote temporary instance to writer",
+			Rationale:   failoverToTempRationale(originalWriter.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			MaxRetries:  1,
@@ -174,6 +531,7 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 	}
 
 	// Steps: Modify each non-autoscaled instance
+	var modified []types.InstanceInfo
 	for _, instance := range info.Instances {
 		if instance.IsAutoScaled {
 			continue // Skip autoscaled instances - they'll get new type from policy
@@ -181,46 +539,50 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 		if excludeSet[instance.InstanceID] {
 			continue // Skip explicitly excluded instances
 		}
-
-		modifyParams, err := json.Marshal(map[string]string{
-			"instance_id":   instance.InstanceID,
-			"instance_type": params.TargetInstanceType,
-		})
-		if err != nil {
-			return errors.Wrapf(err, "marshal modify_instance params for %s", instance.InstanceID)
-		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Modify instance: " + instance.InstanceID,
-			Description: "Change instance type to " + params.TargetInstanceType,
-			State:       types.StepStatePending,
-			Action:      "modify_instance",
-			Parameters:  modifyParams,
-			MaxRetries:  2,
-		})
-
-		// Wait for instance to be available after modification
-		// CRITICAL: instance_id MUST be set explicitly to ensure we wait for THIS instance,
-		// not the temp instance. Without this, all modify operations could run in parallel.
-		waitParams, err := json.Marshal(map[string]string{
-			"instance_id": instance.InstanceID,
+		modified = append(modified, instance)
+	}
+	modifySteps, err := modifyInstanceSteps(modified, originalWriter.InstanceID, batchSize,
+		"Wait for instance modification to complete",
+		func(instance types.InstanceInfo) (types.Step, error) {
+			// The current class is recorded so a rollback can restore it even if
+			// the get_cluster_info step never ran.
+			modifyParamsMap := map[string]string{
+				"instance_id":            instance.InstanceID,
+				"instance_type":          params.TargetInstanceType,
+				"original_instance_type": instance.InstanceType,
+			}
+			description := "Change instance type to " + params.TargetInstanceType
+			if targetPG != "" {
+				modifyParamsMap["db_parameter_group_name"] = targetPG
+				description += " and parameter group to " + targetPG
+			}
+			modifyParams, err := json.Marshal(modifyParamsMap)
+			if err != nil {
+				return types.Step{}, errors.Wrapf(err, "marshal modify_instance params for %s", instance.InstanceID)
+			}
+			return types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Modify instance: " + instance.InstanceID,
+				Description: description,
+				Rationale:   instanceChangeRationale(instance.InstanceID, originalWriter.InstanceID, createTempInstance, "resized"),
+				State:       types.StepStatePending,
+				Action:      "modify_instance",
+				Parameters:  modifyParams,
+				MaxRetries:  2,
+			}, nil
 		})
-		if err != nil {
-			return errors.Wrapf(err, "marshal wait_instance_available params for %s", instance.InstanceID)
+	if err != nil {
+		return err
+	}
+	if targetPG != "" {
+		if modifySteps, err = withParameterGroupReboots(modifySteps, targetPG); err != nil {
+			return err
 		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Wait for instance: " + instance.InstanceID,
-			Description: "Wait for instance modification to complete",
-			State:       types.StepStatePending,
-			Action:      "wait_instance_available",
-			Parameters:  waitParams,
-			MaxRetries:  1,
-		})
 	}
+	steps = append(steps, modifySteps...)
 
 	// Only add failover-back steps if we did a failover (temp instance + writer not excluded)
-	if createTempInstance && !writerExcluded && originalWriter != nil {
+	if createTempInstance && !writerExcluded {
 		failoverParams, err := json.Marshal(map[string]string{
 			"instance_id": originalWriter.InstanceID,
 		})
@@ -231,6 +593,7 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 			ID:          uuid.New().String(),
 			Name:        "Failover back to original writer",
 			Description: "Restore original writer: " + originalWriter.InstanceID,
+			Rationale:   failbackRationale(originalWriter.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			Parameters:  failoverParams,
@@ -249,34 +612,179 @@ func (e *Engine) buildInstanceTypeChangeSteps(ctx context.Context, op *types.Ope
 
 	// Delete temp instance if we created one
 	if createTempInstance {
-		deleteParams, err := json.Marshal(map[string]bool{
-			"skip_final_snapshot": true,
+		deleteSteps, err := e.buildTempInstanceDeleteSteps(op, params.FinalSnapshot)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, deleteSteps...)
+	}
+	if params.SuspendAutoscaling {
+		steps = append(steps, resumeAutoscalingStep())
+	}
+	steps = append(steps, verifyEndStateStep())
+
+	op.Steps = steps
+	return nil
+}
+
+// validateTargetInstanceParameterGroup checks that the instance parameter
+// group a type change switches to exists and is of the family the writer's
+// current group is, so it suits the cluster's engine version. It reports
+// whether the writer already uses the group.
+func validateTargetInstanceParameterGroup(ctx context.Context, rdsClient *rds.Client, name, writerID string) (bool, error) {
+	exists, err := rdsClient.InstanceParameterGroupExists(ctx, name)
+	if err != nil {
+		return false, errors.Wrap(err, "check instance parameter group exists")
+	}
+	if !exists {
+		return false, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"instance parameter group %s does not exist", name)
+	}
+
+	current, err := rdsClient.GetInstanceParameterGroup(ctx, writerID)
+	if err != nil {
+		return false, errors.Wrapf(err, "get instance parameter group of %s", writerID)
+	}
+	if current.Name == name {
+		return true, nil
+	}
+	target, err := rdsClient.DescribeInstanceParameterGroup(ctx, name)
+	if err != nil {
+		return false, errors.Wrapf(err, "describe instance parameter group %s", name)
+	}
+	if target.Family != current.Family {
+		return false, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"instance parameter group %s is for %s, but the cluster's instances use %s (%s)",
+			name, target.Family, current.Family, current.Name)
+	}
+	return false, nil
+}
+
+// withParameterGroupReboots follows each wait for modified instances with a
+// reboot of each of them in turn, since a new parameter group only takes
+// effect when the instance restarts.
+func withParameterGroupReboots(steps []types.Step, parameterGroup string) ([]types.Step, error) {
+	rationale := fmt.Sprintf("target_instance_parameter_group_name is %s, which applies only once the instance reboots", parameterGroup)
+
+	var out []types.Step
+	var modified []string
+	for _, step := range steps {
+		out = append(out, step)
+		switch step.Action {
+		case "modify_instance":
+			var params struct {
+				InstanceID string `json:"instance_id"`
+			}
+			if err := json.Unmarshal(step.Parameters, &params); err != nil {
+				return nil, errors.Wrap(err, "unmarshal modify_instance params")
+			}
+			modified = append(modified, params.InstanceID)
+		case "wait_instance_available", "wait_instances_available":
+			for _, instanceID := range modified {
+				rebootSteps, err := rebootAndWaitSteps(instanceID, "instance "+instanceID)
+				if err != nil {
+					return nil, err
+				}
+				rebootSteps[0].Rationale = rationale
+				out = append(out, rebootSteps...)
+			}
+			modified = nil
+		}
+	}
+	return out, nil
+}
+
+// buildInstanceTypeRollbackSteps builds the steps that restore every instance
+// a completed modify_instance step resized, newest change first. Original
+// classes come from the get_cluster_info step result, which is captured
+// before anything is modified, falling back to the class recorded in the
+// modify step's parameters when the plan was built. Each restore is followed
+// by a wait on that instance so the rollback, like the forward pass, never
+// has two modifications in flight.
+func buildInstanceTypeRollbackSteps(op *types.Operation) ([]types.Step, error) {
+	originalTypes := make(map[string]string)
+	for _, step := range op.Steps {
+		if step.Action == "get_cluster_info" && step.State == types.StepStateCompleted && len(step.Result) > 0 {
+			var info types.ClusterInfo
+			if err := json.Unmarshal(step.Result, &info); err == nil {
+				for _, inst := range info.Instances {
+					originalTypes[inst.InstanceID] = inst.InstanceType
+				}
+			}
+			break
+		}
+	}
+
+	var steps []types.Step
+	for i := len(op.Steps) - 1; i >= 0; i-- {
+		step := op.Steps[i]
+		if step.Action != "modify_instance" || step.Rollback || step.State != types.StepStateCompleted {
+			continue
+		}
+
+		var params struct {
+			InstanceID           string `json:"instance_id"`
+			InstanceType         string `json:"instance_type"`
+			OriginalInstanceType string `json:"original_instance_type"`
+		}
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal params of step %q", step.Name)
+		}
+		if params.InstanceType == "" {
+			continue
+		}
+
+		original := originalTypes[params.InstanceID]
+		if original == "" {
+			original = params.OriginalInstanceType
+		}
+		if original == "" {
+			return nil, errors.Wrapf(internalerrors.ErrRollbackFailed,
+				"original instance type of %s was not recorded", params.InstanceID)
+		}
+		if original == params.InstanceType {
+			continue
+		}
+
+		modifyParams, err := json.Marshal(map[string]string{
+			"instance_id":   params.InstanceID,
+			"instance_type": original,
 		})
 		if err != nil {
-			return errors.Wrap(err, "marshal delete_instance params")
+			return nil, errors.Wrapf(err, "marshal modify_instance params for %s", params.InstanceID)
 		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Delete temp instance",
-			Description: "Remove temporary maintenance instance",
-			State:       types.StepStatePending,
-			Action:      "delete_instance",
-			Parameters:  deleteParams,
-			MaxRetries:  2,
+		waitParams, err := json.Marshal(map[string]string{
+			"instance_id": params.InstanceID,
 		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal wait_instance_available params for %s", params.InstanceID)
+		}
 
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Wait for temp instance deletion",
-			Description: "Wait for temporary instance to be deleted",
-			State:       types.StepStatePending,
-			Action:      "wait_instance_deleted",
-			MaxRetries:  1,
-		})
+		steps = append(steps,
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Restore instance: " + params.InstanceID,
+				Description: "Change instance type back to " + original,
+				State:       types.StepStatePending,
+				Action:      "modify_instance",
+				Parameters:  modifyParams,
+				MaxRetries:  2,
+				Rollback:    true,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Wait for restored instance: " + params.InstanceID,
+				Description: "Wait for instance to return to " + original,
+				State:       types.StepStatePending,
+				Action:      "wait_instance_available",
+				Parameters:  waitParams,
+				MaxRetries:  1,
+				Rollback:    true,
+			},
+		)
 	}
 
-	op.Steps = steps
-	return nil
+	return steps, nil
 }
 
 // buildStorageTypeChangeSteps builds the steps for a storage type change operation.
@@ -286,9 +794,24 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 	if err := json.Unmarshal(op.Parameters, &params); err != nil {
 		return errors.Wrap(err, "unmarshal params")
 	}
+	if err := validateMinAvailableReaders(params.MinAvailableReaders); err != nil {
+		return err
+	}
+	if err := rds.ValidateTags(params.Tags); err != nil {
+		return err
+	}
 
 	if params.TargetStorageType == "" {
-		return errors.New("missing required parameter: target_storage_type")
+		if e.defaultStorageType == "" {
+			return errors.New("missing required parameter: target_storage_type")
+		}
+		// Record the server default on the operation so it shows what was applied
+		params.TargetStorageType = e.defaultStorageType
+		resolved, err := json.Marshal(params)
+		if err != nil {
+			return errors.Wrap(err, "marshal params")
+		}
+		op.Parameters = resolved
 	}
 
 	// Get RDS client for operation's region
@@ -312,25 +835,45 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 	if err != nil {
 		return err
 	}
+	if err := validateStorageSettings(params.TargetStorageType, params.IOPS, params.StorageThroughput, info.Instances, excludeSet); err != nil {
+		return err
+	}
+
+	// Reject storage types the engine or instance classes cannot use before
+	// anything is created; otherwise the failure only surfaces mid-operation.
+	supported, err := rdsClient.GetSupportedStorageTypes(ctx, info.Engine, info.EngineVersion)
+	if err != nil {
+		return errors.Wrap(err, "get supported storage types")
+	}
+	if err := validateStorageTypeSupport(params.TargetStorageType, info.Engine, info.Instances, excludeSet, supported); err != nil {
+		return err
+	}
 
 	// Check if the writer is excluded - if so, we don't need failover steps
-	var originalWriter *types.InstanceInfo
-	writerExcluded := false
-	for _, instance := range info.Instances {
-		if instance.Role == "writer" && !instance.IsAutoScaled {
-			originalWriter = &instance
-			if excludeSet[instance.InstanceID] {
-				writerExcluded = true
-			}
-			break
-		}
+	originalWriter := findWriter(info.Instances)
+	if originalWriter == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidState, "no writer instance found in cluster %s", op.ClusterID)
+	}
+	writerExcluded := excludeSet[originalWriter.InstanceID]
+
+	batchSize, warning, err := readerBatchSize(params.MaxParallelReaders, params.MinAvailableReaders, info, originalWriter.InstanceID)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		op.Warnings = append(op.Warnings, warning)
 	}
 
 	// Determine if we should create a temp instance
 	// By default, create temp instance for redundancy unless explicitly skipped
 	createTempInstance := !params.SkipTempInstance
-
-	steps := []types.Step{}
+	if createTempInstance {
+		if problem := e.tempInstanceRoomProblem(info); problem != "" {
+			op.Warnings = append(op.Warnings, problem)
+		}
+	}
+
+	steps := []types.Step{}
 
 	// Step 1: Get cluster info
 	steps = append(steps, types.Step{
@@ -341,12 +884,17 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 		Action:      "get_cluster_info",
 		MaxRetries:  3,
 	})
+	steps = append(steps, preflightCheckStep(false))
+	if params.SuspendAutoscaling {
+		steps = append(steps, suspendAutoscalingStep())
+	}
 
 	// Create temp instance if enabled
 	if createTempInstance {
 		createParams, err := json.Marshal(map[string]string{
-			"instance_type": info.Instances[0].InstanceType, // Use same instance type
-			"engine":        info.Engine,
+			"instance_type":     originalWriter.InstanceType, // Match the writer it stands in for
+			"engine":            info.Engine,
+			"availability_zone": params.TempInstanceAvailabilityZone,
 		})
 		if err != nil {
 			return errors.Wrap(err, "marshal create_temp_instance params")
@@ -356,6 +904,7 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 			Name:        "Create temp instance",
 			Description: "Create temporary reader for failover",
 			State:       types.StepStatePending,
+			Rationale:   tempInstanceRationale(writerExcluded),
 			Action:      "create_temp_instance",
 			Parameters:  createParams,
 			MaxRetries:  1,
@@ -377,6 +926,7 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 			ID:          uuid.New().String(),
 			Name:        "Failover to temp instance",
 			Description: "Promote temporary instance to writer",
+			Rationale:   failoverToTempRationale(originalWriter.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			MaxRetries:  1,
@@ -393,6 +943,7 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 	}
 
 	// Steps: Modify storage on each non-autoscaled instance
+	var modified []types.InstanceInfo
 	for _, instance := range info.Instances {
 		if instance.IsAutoScaled {
 			continue
@@ -400,53 +951,43 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 		if excludeSet[instance.InstanceID] {
 			continue // Skip explicitly excluded instances
 		}
-
-		modifyData := map[string]any{
-			"instance_id":  instance.InstanceID,
-			"storage_type": params.TargetStorageType,
-		}
-		if params.IOPS != nil {
-			modifyData["iops"] = *params.IOPS
-		}
-		if params.StorageThroughput != nil {
-			modifyData["storage_throughput"] = *params.StorageThroughput
-		}
-		modifyParams, err := json.Marshal(modifyData)
-		if err != nil {
-			return errors.Wrapf(err, "marshal modify_instance params for %s", instance.InstanceID)
-		}
-
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Modify storage: " + instance.InstanceID,
-			Description: "Change storage type to " + params.TargetStorageType,
-			State:       types.StepStatePending,
-			Action:      "modify_instance",
-			Parameters:  modifyParams,
-			MaxRetries:  2,
-		})
-
-		// CRITICAL: instance_id MUST be set explicitly to ensure we wait for THIS instance,
-		// not the temp instance. Without this, all modify operations could run in parallel.
-		waitParams, err := json.Marshal(map[string]string{
-			"instance_id": instance.InstanceID,
-		})
-		if err != nil {
-			return errors.Wrapf(err, "marshal wait_instance_available params for %s", instance.InstanceID)
-		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Wait for instance: " + instance.InstanceID,
-			Description: "Wait for storage modification to complete",
-			State:       types.StepStatePending,
-			Action:      "wait_instance_available",
-			Parameters:  waitParams,
-			MaxRetries:  1,
+		modified = append(modified, instance)
+	}
+	modifySteps, err := modifyInstanceSteps(modified, originalWriter.InstanceID, batchSize,
+		"Wait for storage modification to complete",
+		func(instance types.InstanceInfo) (types.Step, error) {
+			modifyData := map[string]any{
+				"instance_id":  instance.InstanceID,
+				"storage_type": params.TargetStorageType,
+			}
+			if params.IOPS != nil {
+				modifyData["iops"] = *params.IOPS
+			}
+			if params.StorageThroughput != nil {
+				modifyData["storage_throughput"] = *params.StorageThroughput
+			}
+			modifyParams, err := json.Marshal(modifyData)
+			if err != nil {
+				return types.Step{}, errors.Wrapf(err, "marshal modify_instance params for %s", instance.InstanceID)
+			}
+			return types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Modify storage: " + instance.InstanceID,
+				Description: "Change storage type to " + params.TargetStorageType,
+				Rationale:   instanceChangeRationale(instance.InstanceID, originalWriter.InstanceID, createTempInstance, "modified"),
+				State:       types.StepStatePending,
+				Action:      "modify_instance",
+				Parameters:  modifyParams,
+				MaxRetries:  2,
+			}, nil
 		})
+	if err != nil {
+		return err
 	}
+	steps = append(steps, modifySteps...)
 
 	// Only add failover-back steps if we did a failover (temp instance + writer not excluded)
-	if createTempInstance && !writerExcluded && originalWriter != nil {
+	if createTempInstance && !writerExcluded {
 		failoverParams, err := json.Marshal(map[string]string{
 			"instance_id": originalWriter.InstanceID,
 		})
@@ -457,6 +998,7 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 			ID:          uuid.New().String(),
 			Name:        "Failover back to original writer",
 			Description: "Restore original writer: " + originalWriter.InstanceID,
+			Rationale:   failbackRationale(originalWriter.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			Parameters:  failoverParams,
@@ -475,31 +1017,16 @@ func (e *Engine) buildStorageTypeChangeSteps(ctx context.Context, op *types.Oper
 
 	// Delete temp instance if we created one
 	if createTempInstance {
-		deleteParams, err := json.Marshal(map[string]bool{
-			"skip_final_snapshot": true,
-		})
+		deleteSteps, err := e.buildTempInstanceDeleteSteps(op, params.FinalSnapshot)
 		if err != nil {
-			return errors.Wrap(err, "marshal delete_instance params")
+			return err
 		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Delete temp instance",
-			Description: "Remove temporary maintenance instance",
-			State:       types.StepStatePending,
-			Action:      "delete_instance",
-			Parameters:  deleteParams,
-			MaxRetries:  2,
-		})
-
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Wait for temp instance deletion",
-			Description: "Wait for temporary instance to be deleted",
-			State:       types.StepStatePending,
-			Action:      "wait_instance_deleted",
-			MaxRetries:  1,
-		})
+		steps = append(steps, deleteSteps...)
 	}
+	if params.SuspendAutoscaling {
+		steps = append(steps, resumeAutoscalingStep())
+	}
+	steps = append(steps, verifyEndStateStep())
 
 	op.Steps = steps
 	return nil
@@ -520,10 +1047,67 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 	if params.TargetEngineVersion == "" {
 		return errors.New("missing required parameter: target_engine_version")
 	}
+	if err := rds.ValidateTags(params.Tags); err != nil {
+		return err
+	}
+	if err := validateAlarmPatterns(params.AlarmNamePatterns); err != nil {
+		return err
+	}
+
+	// A Blue-Green deployment can only move forward. Comparing here turns a
+	// downgrade into a clear rejection instead of a failed deployment, and a
+	// request for the current version into a completed no-op.
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return errors.Wrap(err, "get rds client")
+	}
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+	current, err := rds.ParseEngineVersion(info.Engine, info.EngineVersion)
+	if err != nil {
+		return errors.Wrap(err, "parse current engine version")
+	}
+	target, err := rds.ParseEngineVersion(info.Engine, params.TargetEngineVersion)
+	if err != nil {
+		return err
+	}
+	switch target.Compare(current) {
+	case 0:
+		return &alreadyDone{reason: fmt.Sprintf("cluster %s already runs %s %s; there is nothing to upgrade",
+			op.ClusterID, info.Engine, info.EngineVersion)}
+	case -1:
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"target engine version %s is older than the cluster's %s; downgrades are not supported",
+			params.TargetEngineVersion, info.EngineVersion)
+	}
+	if e.majorApproval && target.Major != current.Major {
+		op.RequiresApproval = true
+	}
 
 	skipProxySteps := params.SkipProxyRetarget != nil && *params.SkipProxyRetarget
 	steps := []types.Step{}
 
+	// Step 0: Check Blue-Green prerequisites
+	// Logical replication and the upgrade path are verified before anything is
+	// created, so a misconfigured cluster pauses here rather than failing later.
+	prereqParams, err := json.Marshal(map[string]string{
+		"target_engine_version": params.TargetEngineVersion,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal check_upgrade_prerequisites params")
+	}
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Check upgrade prerequisites",
+		Description: "Verify logical replication settings and that " + params.TargetEngineVersion + " is a valid Blue-Green upgrade target",
+		State:       types.StepStatePending,
+		Action:      "check_upgrade_prerequisites",
+		Parameters:  prereqParams,
+		MaxRetries:  2,
+	})
+
 	// Step 1: Get cluster info and ARN
 	steps = append(steps, types.Step{
 		ID:          uuid.New().String(),
@@ -533,6 +1117,14 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 		Action:      "get_cluster_info",
 		MaxRetries:  3,
 	})
+	steps = append(steps, preflightCheckStep(true))
+
+	// Snapshot the cluster before anything about it changes
+	snapshotSteps, err := e.preUpgradeSnapshotSteps(params.PreUpgradeSnapshot, params.SnapshotRetentionDays)
+	if err != nil {
+		return err
+	}
+	steps = append(steps, snapshotSteps...)
 
 	// Step 2: Prepare parameter groups for the target version
 	// This step creates parameter groups for the green environment with migrated custom settings
@@ -574,12 +1166,20 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 	// This discovers proxies pointing at the cluster and validates they are healthy.
 	// Must run BEFORE Blue-Green deployment creation because we need to deregister proxies first.
 	if !skipProxySteps {
+		proxyHealthParams, err := json.Marshal(map[string]bool{
+			"continue_on_unhealthy": params.ContinueOnProxyHealthWarning,
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshal validate_proxy_health params")
+		}
 		steps = append(steps, types.Step{
 			ID:          uuid.New().String(),
 			Name:        "Validate proxy health",
 			Description: "Discover and validate RDS Proxies targeting this cluster",
+			Rationale:   "skip_proxy_retarget is not set; proxies must be healthy before they are detached",
 			State:       types.StepStatePending,
 			Action:      "validate_proxy_health",
+			Parameters:  proxyHealthParams,
 			MaxRetries:  2,
 		})
 	}
@@ -593,6 +1193,7 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        "Deregister proxy targets",
 			Description: "Deregister cluster from RDS Proxy (required for Blue-Green deployment)",
+			Rationale:   "skip_proxy_retarget is not set, and a Blue-Green deployment cannot be created while the cluster is a proxy target",
 			State:       types.StepStatePending,
 			Action:      "deregister_proxy_targets",
 			MaxRetries:  2,
@@ -628,6 +1229,26 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 		MaxRetries:  1,
 	})
 
+	// Gate the switchover on the cluster's CloudWatch alarms (if not skipped)
+	if !params.SkipAlarmCheck {
+		alarmParams, err := json.Marshal(map[string]any{
+			"alarm_name_patterns": params.AlarmNamePatterns,
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshal check_alarms params")
+		}
+		steps = append(steps, types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Check alarms",
+			Description: "Verify no CloudWatch alarm for the cluster is in ALARM before switchover",
+			Rationale:   "skip_alarm_check is not set",
+			State:       types.StepStatePending,
+			Action:      "check_alarms",
+			Parameters:  alarmParams,
+			MaxRetries:  2,
+		})
+	}
+
 	// Step 8: Switchover Blue-Green deployment
 	switchoverParamsMap := map[string]any{}
 	if params.SwitchoverTimeout > 0 {
@@ -654,13 +1275,26 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        "Register proxy targets",
 			Description: "Register upgraded cluster to RDS Proxy",
+			Rationale:   "skip_proxy_retarget is not set, so proxies deregistered before the deployment are pointed back at the cluster",
 			State:       types.StepStatePending,
 			Action:      "register_proxy_targets",
 			MaxRetries:  3,
 		})
 	}
 
-	// Step 10: Cleanup Blue-Green deployment and old cluster
+	// Step 10: Repoint custom endpoints still naming the retired instances.
+	// This runs before the pause for cleanup so no endpoint routes to the old
+	// cluster while the upgrade is being checked.
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Repoint custom endpoints",
+		Description: "Point custom endpoints that name old instances at the instances that replaced them",
+		State:       types.StepStatePending,
+		Action:      "repoint_custom_endpoints",
+		MaxRetries:  2,
+	})
+
+	// Step 11: Cleanup Blue-Green deployment and old cluster
 	steps = append(steps, types.Step{
 		ID:          uuid.New().String(),
 		Name:        "Cleanup",
@@ -670,7 +1304,7 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 		MaxRetries:  1,
 	})
 
-	// Step 11: Verify final cluster state
+	// Step 12: Verify final cluster state
 	steps = append(steps, types.Step{
 		ID:          uuid.New().String(),
 		Name:        "Verify upgrade",
@@ -695,10 +1329,11 @@ func (e *Engine) buildEngineUpgradeSteps(ctx context.Context, op *types.Operatio
 	}
 
 	// Set auto-pause before switchover step by default (unless explicitly disabled)
-	// PauseBeforeSwitchover defaults to true when nil
+	// PauseBeforeSwitchover defaults to true when nil. The pause lands on the
+	// alarm check when there is one, so alarms are read after approval.
 	if params.PauseBeforeSwitchover == nil || *params.PauseBeforeSwitchover {
 		for i, step := range op.Steps {
-			if step.Action == "switchover_blue_green" {
+			if step.Action == "check_alarms" || step.Action == "switchover_blue_green" {
 				op.PauseBeforeSteps = append(op.PauseBeforeSteps, i)
 				break
 			}
@@ -733,6 +1368,10 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 		}
 	}
 
+	if err := validateMinAvailableReaders(params.MinAvailableReaders); err != nil {
+		return err
+	}
+
 	client, err := e.clientManager.GetClient(ctx, op.Region)
 	if err != nil {
 		return errors.Wrap(err, "get RDS client")
@@ -751,36 +1390,33 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 	}
 
 	// Separate writer and readers, exclude autoscaled instances
-	var writer *types.InstanceInfo
-	var readers []*types.InstanceInfo
-	writerExcluded := false
+	writer := findWriter(info.Instances)
+	if writer == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidState, "no writer instance found in cluster %s", op.ClusterID)
+	}
+	writerExcluded := excludeSet[writer.InstanceID]
 
+	var readers []*types.InstanceInfo
 	for i := range info.Instances {
 		inst := &info.Instances[i]
 		if inst.IsAutoScaled {
 			e.logger.Info("skipping autoscaled instance", "instance", inst.InstanceID)
 			continue
 		}
-		if inst.Role == "writer" {
-			writer = inst
-			if excludeSet[inst.InstanceID] {
-				writerExcluded = true
-			}
-		} else {
-			// Only add readers that are not excluded
-			if !excludeSet[inst.InstanceID] {
-				readers = append(readers, inst)
-			}
+		// Only add readers that are not excluded
+		if inst.InstanceID != writer.InstanceID && !excludeSet[inst.InstanceID] {
+			readers = append(readers, inst)
 		}
 	}
 
-	if writer == nil {
-		return errors.New("no writer instance found in cluster")
-	}
-
 	// Determine if we should create a temp instance
 	// By default, create temp instance for redundancy unless explicitly skipped
 	createTempInstance := !params.SkipTempInstance
+	if createTempInstance {
+		if problem := e.tempInstanceRoomProblem(info); problem != "" {
+			op.Warnings = append(op.Warnings, problem)
+		}
+	}
 
 	var steps []types.Step
 
@@ -793,12 +1429,14 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 		Action:      "get_cluster_info",
 		MaxRetries:  3,
 	})
+	steps = append(steps, preflightCheckStep(false))
 
 	// Create temp instance if enabled
 	if createTempInstance {
 		createParams, err := json.Marshal(map[string]string{
-			"instance_type": writer.InstanceType,
-			"engine":        info.Engine,
+			"instance_type":     writer.InstanceType,
+			"engine":            info.Engine,
+			"availability_zone": params.TempInstanceAvailabilityZone,
 		})
 		if err != nil {
 			return errors.Wrap(err, "marshal create_temp_instance params")
@@ -808,6 +1446,7 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			Name:        "Create temp instance",
 			Description: "Create temporary instance for failover during reboot",
 			State:       types.StepStatePending,
+			Rationale:   tempInstanceRationale(writerExcluded),
 			Action:      "create_temp_instance",
 			Parameters:  createParams,
 			MaxRetries:  1,
@@ -829,6 +1468,7 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        "Failover to temp instance",
 			Description: "Promote temporary instance to writer",
+			Rationale:   failoverToTempRationale(writer.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			MaxRetries:  1,
@@ -856,6 +1496,7 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        "Reboot original writer",
 			Description: fmt.Sprintf("Reboot instance %s (original writer)", writer.InstanceID),
+			Rationale:   instanceChangeRationale(writer.InstanceID, writer.InstanceID, createTempInstance, "rebooted"),
 			State:       types.StepStatePending,
 			Action:      "reboot_instance",
 			Parameters:  writerRebootParams,
@@ -871,13 +1512,14 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			return errors.Wrapf(err, "marshal wait_instance_available params for %s", writer.InstanceID)
 		}
 		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Wait for original writer",
-			Description: fmt.Sprintf("Wait for instance %s to be available", writer.InstanceID),
-			State:       types.StepStatePending,
-			Action:      "wait_instance_available",
-			Parameters:  writerWaitParams,
-			MaxRetries:  1,
+			ID:             uuid.New().String(),
+			Name:           "Wait for original writer",
+			Description:    fmt.Sprintf("Wait for instance %s to be available", writer.InstanceID),
+			State:          types.StepStatePending,
+			Action:         "wait_instance_available",
+			Parameters:     writerWaitParams,
+			MaxRetries:     1,
+			TimeoutSeconds: constants.RebootWaitTimeoutSeconds,
 		})
 	}
 
@@ -894,6 +1536,7 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        fmt.Sprintf("Reboot reader %d", i+1),
 			Description: fmt.Sprintf("Reboot reader instance %s", reader.InstanceID),
+			Rationale:   instanceChangeRationale(reader.InstanceID, writer.InstanceID, createTempInstance, "rebooted"),
 			State:       types.StepStatePending,
 			Action:      "reboot_instance",
 			Parameters:  rebootParams,
@@ -909,13 +1552,14 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			return errors.Wrapf(err, "marshal wait_instance_available params for %s", reader.InstanceID)
 		}
 		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        fmt.Sprintf("Wait for reader %d", i+1),
-			Description: fmt.Sprintf("Wait for instance %s to be available", reader.InstanceID),
-			State:       types.StepStatePending,
-			Action:      "wait_instance_available",
-			Parameters:  waitParams,
-			MaxRetries:  1,
+			ID:             uuid.New().String(),
+			Name:           fmt.Sprintf("Wait for reader %d", i+1),
+			Description:    fmt.Sprintf("Wait for instance %s to be available", reader.InstanceID),
+			State:          types.StepStatePending,
+			Action:         "wait_instance_available",
+			Parameters:     waitParams,
+			MaxRetries:     1,
+			TimeoutSeconds: constants.RebootWaitTimeoutSeconds,
 		})
 	}
 
@@ -931,6 +1575,7 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 			ID:          uuid.New().String(),
 			Name:        "Failover back to original writer",
 			Description: "Restore original writer: " + writer.InstanceID,
+			Rationale:   failbackRationale(writer.InstanceID),
 			State:       types.StepStatePending,
 			Action:      "failover_to_instance",
 			Parameters:  failoverParams,
@@ -949,33 +1594,210 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 
 	// Delete temp instance if we created one
 	if createTempInstance {
-		deleteParams, err := json.Marshal(map[string]bool{
-			"skip_final_snapshot": true,
-		})
+		deleteSteps, err := e.buildTempInstanceDeleteSteps(op, params.FinalSnapshot)
 		if err != nil {
-			return errors.Wrap(err, "marshal delete_instance params")
+			return err
 		}
-		steps = append(steps, types.Step{
-			ID:          uuid.New().String(),
-			Name:        "Delete temp instance",
-			Description: "Remove temporary maintenance instance",
-			State:       types.StepStatePending,
-			Action:      "delete_instance",
-			Parameters:  deleteParams,
-			MaxRetries:  2,
-		})
+		steps = append(steps, deleteSteps...)
+	}
 
-		steps = append(steps, types.Step{
+	// Final step: Verify cluster state
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Verify cluster",
+		Description: "Verify all instances are available",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	})
+
+	op.Steps = steps
+	return nil
+}
+
+// rebootAndWaitSteps returns a reboot_instance step for one instance followed by
+// a wait for it. The reboot is skipped, and the wait with it, when the instance
+// is not available at the time it would be rebooted.
+func rebootAndWaitSteps(instanceID, label string) ([]types.Step, error) {
+	rebootParams, err := json.Marshal(map[string]any{
+		"instance_id":      instanceID,
+		"skip_unavailable": true,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal reboot_instance params for %s", instanceID)
+	}
+	waitParams, err := json.Marshal(map[string]string{
+		"instance_id": instanceID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal wait_instance_available params for %s", instanceID)
+	}
+
+	return []types.Step{
+		{
 			ID:          uuid.New().String(),
-			Name:        "Wait for temp instance deletion",
-			Description: "Wait for temporary instance to be deleted",
+			Name:        "Reboot " + label,
+			Description: fmt.Sprintf("Reboot instance %s", instanceID),
 			State:       types.StepStatePending,
-			Action:      "wait_instance_deleted",
+			Action:      "reboot_instance",
+			Parameters:  rebootParams,
 			MaxRetries:  1,
+		},
+		{
+			ID:             uuid.New().String(),
+			Name:           "Wait for " + label,
+			Description:    fmt.Sprintf("Wait for instance %s to be available", instanceID),
+			State:          types.StepStatePending,
+			Action:         "wait_instance_available",
+			Parameters:     waitParams,
+			MaxRetries:     1,
+			TimeoutSeconds: constants.RebootWaitTimeoutSeconds,
+		},
+	}, nil
+}
+
+// buildRebootClusterSteps builds the steps for a reboot cluster operation.
+// Unlike an instance cycle no temp instance is created:
+// 1. Each non-autoscaled reader is rebooted and waited on, one at a time
+// 2. With reboot_writer, the cluster fails over to the first reader
+// 3. The original writer is rebooted and waited on
+// 4. Unless fail_back is false, the cluster fails back to the original writer
+// Instances that are not available when their turn comes are skipped.
+func (e *Engine) buildRebootClusterSteps(ctx context.Context, op *types.Operation) error {
+	var params types.RebootClusterParams
+	if len(op.Parameters) > 0 {
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+
+	if err := validateMinAvailableReaders(params.MinAvailableReaders); err != nil {
+		return err
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	excludeSet, err := validateExcludedInstances(params.ExcludeInstances, info.Instances)
+	if err != nil {
+		return err
+	}
+
+	writer := findWriter(info.Instances)
+	if writer == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidState, "no writer instance found in cluster %s", op.ClusterID)
+	}
+	rebootWriter := params.RebootWriter && !excludeSet[writer.InstanceID]
+
+	var readers []*types.InstanceInfo
+	for i := range info.Instances {
+		inst := &info.Instances[i]
+		if inst.IsAutoScaled || inst.InstanceID == writer.InstanceID || excludeSet[inst.InstanceID] {
+			continue
+		}
+		readers = append(readers, inst)
+	}
+
+	if len(readers) == 0 {
+		if rebootWriter {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"cluster %s has no reader to fail over to before rebooting the writer", op.ClusterID)
+		}
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster %s has no readers to reboot; set reboot_writer to reboot the writer", op.ClusterID)
+	}
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Get current cluster state before rebooting instances",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	for i, reader := range readers {
+		rebootSteps, err := rebootAndWaitSteps(reader.InstanceID, fmt.Sprintf("reader %d", i+1))
+		if err != nil {
+			return err
+		}
+		rebootSteps[0].Rationale = instanceChangeRationale(reader.InstanceID, writer.InstanceID, false, "rebooted")
+		steps = append(steps, rebootSteps...)
+	}
+
+	if rebootWriter {
+		failoverParams, err := json.Marshal(map[string]string{
+			"instance_id": readers[0].InstanceID,
 		})
+		if err != nil {
+			return errors.Wrapf(err, "marshal failover params for %s", readers[0].InstanceID)
+		}
+		steps = append(steps,
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Failover to reader",
+				Description: fmt.Sprintf("Promote rebooted reader %s to writer", readers[0].InstanceID),
+				Rationale:   fmt.Sprintf("reboot_writer is set, so writes move off %s before it is rebooted", writer.InstanceID),
+				State:       types.StepStatePending,
+				Action:      "failover_to_instance",
+				Parameters:  failoverParams,
+				MaxRetries:  1,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Wait for failover",
+				Description: "Wait for cluster to stabilize after failover",
+				State:       types.StepStatePending,
+				Action:      "wait_cluster_available",
+				MaxRetries:  1,
+			},
+		)
+
+		writerSteps, err := rebootAndWaitSteps(writer.InstanceID, "original writer")
+		if err != nil {
+			return err
+		}
+		writerSteps[0].Rationale = fmt.Sprintf("reboot_writer is set and writer %s is not excluded", writer.InstanceID)
+		steps = append(steps, writerSteps...)
+
+		if params.FailBack == nil || *params.FailBack {
+			failbackParams, err := json.Marshal(map[string]string{
+				"instance_id": writer.InstanceID,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "marshal failover params for %s", writer.InstanceID)
+			}
+			steps = append(steps,
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Failover back to original writer",
+					Description: "Restore original writer: " + writer.InstanceID,
+					Rationale:   fmt.Sprintf("fail_back is not false, so %s is made writer again", writer.InstanceID),
+					State:       types.StepStatePending,
+					Action:      "failover_to_instance",
+					Parameters:  failbackParams,
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for final failover",
+					Description: "Wait for cluster to stabilize",
+					State:       types.StepStatePending,
+					Action:      "wait_cluster_available",
+					MaxRetries:  1,
+				},
+			)
+		}
 	}
 
-	// Final step: Verify cluster state
 	steps = append(steps, types.Step{
 		ID:          uuid.New().String(),
 		Name:        "Verify cluster",
@@ -988,3 +1810,746 @@ func (e *Engine) buildInstanceCycleSteps(ctx context.Context, op *types.Operatio
 	op.Steps = steps
 	return nil
 }
+
+// caRotationSteps returns the rotate, reboot and wait steps for one instance.
+// The certificate change is only requested by rotate_ca_cert; the reboot is what
+// makes the instance serve it, and the wait step verifies the applied value.
+func caRotationSteps(instanceID, label, caIdentifier string) ([]types.Step, error) {
+	rotateParams, err := json.Marshal(map[string]string{
+		"instance_id":               instanceID,
+		"ca_certificate_identifier": caIdentifier,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal rotate_ca_cert params for %s", instanceID)
+	}
+	instanceParams, err := json.Marshal(map[string]string{
+		"instance_id": instanceID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal instance params for %s", instanceID)
+	}
+
+	return []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Rotate CA certificate on " + label,
+			Description: fmt.Sprintf("Set CA certificate of %s to %s", instanceID, caIdentifier),
+			State:       types.StepStatePending,
+			Action:      "rotate_ca_cert",
+			Parameters:  rotateParams,
+			MaxRetries:  2,
+		},
+		{
+			ID:          uuid.New().String(),
+			Name:        "Reboot " + label,
+			Description: fmt.Sprintf("Reboot instance %s to apply the new CA certificate", instanceID),
+			State:       types.StepStatePending,
+			Action:      "reboot_instance",
+			Parameters:  instanceParams,
+			MaxRetries:  1,
+		},
+		{
+			ID:             uuid.New().String(),
+			Name:           "Wait for " + label,
+			Description:    fmt.Sprintf("Wait for instance %s to serve %s", instanceID, caIdentifier),
+			State:          types.StepStatePending,
+			Action:         "wait_instance_available",
+			Parameters:     instanceParams,
+			MaxRetries:     1,
+			TimeoutSeconds: constants.RebootWaitTimeoutSeconds,
+		},
+	}, nil
+}
+
+// buildCACertRotationSteps builds steps to move every instance to a new CA
+// certificate. Readers are rotated first; the writer is then rotated behind a
+// failover to a temporary instance that is created with the target certificate.
+func (e *Engine) buildCACertRotationSteps(ctx context.Context, op *types.Operation) error {
+	var params types.CACertRotationParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	if params.TargetCACertificate == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "missing required parameter: target_ca_certificate")
+	}
+
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return errors.Wrap(err, "get rds client")
+	}
+
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	var writer *types.InstanceInfo
+	var readers []*types.InstanceInfo
+	for i := range info.Instances {
+		inst := &info.Instances[i]
+		if inst.IsAutoScaled {
+			// Autoscaled readers are replaced by the service and pick up the
+			// cluster default certificate, so rotating them here would not stick.
+			op.Warnings = append(op.Warnings, fmt.Sprintf("autoscaled instance %s is not rotated", inst.InstanceID))
+			continue
+		}
+		if inst.CACertificateIdentifier == params.TargetCACertificate {
+			e.logger.Info("instance already uses target CA certificate", "instance", inst.InstanceID)
+			continue
+		}
+		if inst.Role == "writer" {
+			writer = inst
+		} else {
+			readers = append(readers, inst)
+		}
+	}
+
+	if writer == nil && len(readers) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"all instances in cluster %s already use CA certificate %s", op.ClusterID, params.TargetCACertificate)
+	}
+
+	createTempInstance := writer != nil && !params.SkipTempInstance
+	if writer != nil && params.SkipTempInstance {
+		op.Warnings = append(op.Warnings, fmt.Sprintf("writer %s will be rebooted in place; expect a brief write outage", writer.InstanceID))
+	}
+	if createTempInstance {
+		if problem := e.tempInstanceRoomProblem(info); problem != "" {
+			op.Warnings = append(op.Warnings, problem)
+		}
+	}
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Retrieve current cluster state",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	for i, reader := range readers {
+		readerSteps, err := caRotationSteps(reader.InstanceID, fmt.Sprintf("reader %d", i+1), params.TargetCACertificate)
+		if err != nil {
+			return err
+		}
+		readerSteps[0].Rationale = caRotationRationale(reader, params.TargetCACertificate)
+		steps = append(steps, readerSteps...)
+	}
+
+	if writer != nil {
+		if createTempInstance {
+			createParams, err := json.Marshal(map[string]string{
+				"instance_type":             writer.InstanceType,
+				"engine":                    info.Engine,
+				"ca_certificate_identifier": params.TargetCACertificate,
+				"availability_zone":         params.TempInstanceAvailabilityZone,
+			})
+			if err != nil {
+				return errors.Wrap(err, "marshal create_temp_instance params")
+			}
+			steps = append(steps,
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Create temp instance",
+					Description: "Create temporary instance for failover while the writer is rotated",
+					State:       types.StepStatePending,
+					Rationale:   tempInstanceRationale(false),
+					Action:      "create_temp_instance",
+					Parameters:  createParams,
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for temp instance",
+					Description: "Wait for temporary instance to become available",
+					State:       types.StepStatePending,
+					Action:      "wait_instance_available",
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Failover to temp instance",
+					Description: "Promote temporary instance to writer",
+					Rationale:   failoverToTempRationale(writer.InstanceID),
+					State:       types.StepStatePending,
+					Action:      "failover_to_instance",
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for failover",
+					Description: "Wait for cluster to stabilize after failover",
+					State:       types.StepStatePending,
+					Action:      "wait_cluster_available",
+					MaxRetries:  1,
+				},
+			)
+		}
+
+		writerSteps, err := caRotationSteps(writer.InstanceID, "original writer", params.TargetCACertificate)
+		if err != nil {
+			return err
+		}
+		writerSteps[0].Rationale = caRotationRationale(writer, params.TargetCACertificate)
+		writerSteps[1].Rationale = instanceChangeRationale(writer.InstanceID, writer.InstanceID, createTempInstance, "rebooted")
+		steps = append(steps, writerSteps...)
+
+		if createTempInstance {
+			failoverParams, err := json.Marshal(map[string]string{
+				"instance_id": writer.InstanceID,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "marshal failover params for %s", writer.InstanceID)
+			}
+			steps = append(steps,
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Failover back to original writer",
+					Description: "Restore original writer: " + writer.InstanceID,
+					Rationale:   failbackRationale(writer.InstanceID),
+					State:       types.StepStatePending,
+					Action:      "failover_to_instance",
+					Parameters:  failoverParams,
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for final failover",
+					Description: "Wait for cluster to stabilize",
+					State:       types.StepStatePending,
+					Action:      "wait_cluster_available",
+					MaxRetries:  1,
+				},
+			)
+
+			deleteSteps, err := e.buildTempInstanceDeleteSteps(op, nil)
+			if err != nil {
+				return err
+			}
+			steps = append(steps, deleteSteps...)
+		}
+	}
+
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Verify cluster",
+		Description: "Verify all instances are available",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	})
+
+	op.Steps = steps
+	return nil
+}
+
+// buildMinorVersionUpgradeSteps builds steps for an in-place minor version
+// upgrade. Patch-level bumps do not need the Blue-Green machinery, so the
+// cluster is modified directly and then waited on.
+func (e *Engine) buildMinorVersionUpgradeSteps(ctx context.Context, op *types.Operation) error {
+	var params types.MinorVersionUpgradeParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+	if params.TargetEngineVersion == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "target_engine_version required")
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	if params.TargetEngineVersion == info.EngineVersion {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster is already on engine version %s", info.EngineVersion)
+	}
+	if rds.EngineMajorVersion(info.Engine, params.TargetEngineVersion) != rds.EngineMajorVersion(info.Engine, info.EngineVersion) {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s -> %s changes the major version; use an engine_upgrade operation instead",
+			info.EngineVersion, params.TargetEngineVersion)
+	}
+
+	modifyParams, err := json.Marshal(map[string]any{
+		"engine_version":              params.TargetEngineVersion,
+		"allow_major_version_upgrade": false,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal modify_cluster params")
+	}
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Retrieve current cluster state",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	if params.DBClusterParameterGroupName != "" {
+		pgSteps, err := e.applyParameterGroupSteps(ctx, client, op.ClusterID, info, params.DBClusterParameterGroupName)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, pgSteps...)
+	}
+
+	op.Steps = append(steps, []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Upgrade engine version",
+			Description: fmt.Sprintf("Modify cluster engine version from %s to %s", info.EngineVersion, params.TargetEngineVersion),
+			State:       types.StepStatePending,
+			Action:      "modify_cluster",
+			Parameters:  modifyParams,
+			MaxRetries:  1,
+		},
+		{
+			ID:          uuid.New().String(),
+			Name:        "Wait for upgrade",
+			Description: "Wait for cluster and instances to become available",
+			State:       types.StepStatePending,
+			Action:      "wait_cluster_available",
+			MaxRetries:  1,
+		},
+	}...)
+
+	return nil
+}
+
+// applyParameterGroupSteps returns the steps that associate a new cluster
+// parameter group and then reboot each non-autoscaled instance, readers first
+// and the writer last. The reboots are planned up front but only run when the
+// apply_parameter_group step finds static parameters pending reboot. No steps
+// are returned when the cluster already uses the group.
+func (e *Engine) applyParameterGroupSteps(ctx context.Context, client *rds.Client, clusterID string, info *types.ClusterInfo, pgName string) ([]types.Step, error) {
+	current, err := client.GetClusterParameterGroup(ctx, clusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get current parameter group")
+	}
+	if current.Name == pgName {
+		return nil, nil
+	}
+
+	applyParams, err := json.Marshal(map[string]string{
+		"parameter_group_name": pgName,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal apply_parameter_group params")
+	}
+
+	steps := []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Apply parameter group",
+			Description: fmt.Sprintf("Associate cluster parameter group %s (was %s)", pgName, current.Name),
+			State:       types.StepStatePending,
+			Action:      "apply_parameter_group",
+			Parameters:  applyParams,
+			MaxRetries:  1,
+		},
+		{
+			ID:          uuid.New().String(),
+			Name:        "Wait for parameter group",
+			Description: "Wait for cluster to finish applying the parameter group",
+			State:       types.StepStatePending,
+			Action:      "wait_cluster_available",
+			MaxRetries:  1,
+		},
+	}
+
+	var writer *types.InstanceInfo
+	var readers []*types.InstanceInfo
+	for i := range info.Instances {
+		inst := &info.Instances[i]
+		if inst.IsAutoScaled {
+			continue
+		}
+		if inst.Role == "writer" {
+			writer = inst
+		} else {
+			readers = append(readers, inst)
+		}
+	}
+
+	ordered := readers
+	if writer != nil {
+		ordered = append(ordered, writer)
+	}
+	rebootRationale := e.parameterRebootRationale(ctx, client, current.Name, pgName)
+	for i, inst := range ordered {
+		label := fmt.Sprintf("reader %d", i+1)
+		if inst == writer {
+			label = "writer"
+		}
+		rebootSteps, err := rebootAndWaitSteps(inst.InstanceID, label)
+		if err != nil {
+			return nil, err
+		}
+		rebootSteps[0].Description = fmt.Sprintf("Reboot instance %s if static parameters are pending reboot", inst.InstanceID)
+		rebootSteps[0].Rationale = rebootRationale
+		rebootSteps[0].Parameters, err = json.Marshal(map[string]any{
+			"instance_id":            inst.InstanceID,
+			"skip_unavailable":       true,
+			"only_if_pending_reboot": true,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal reboot_instance params for %s", inst.InstanceID)
+		}
+		steps = append(steps, rebootSteps...)
+	}
+
+	return steps, nil
+}
+
+// buildApplyPendingMaintenanceSteps builds the steps for applying the pending
+// maintenance actions discovered on the cluster and its instances. Each action
+// is applied immediately and waited on before the next, so instances that
+// reboot to apply an update do so one at a time.
+func (e *Engine) buildApplyPendingMaintenanceSteps(ctx context.Context, op *types.Operation) error {
+	var params types.ApplyPendingMaintenanceParams
+	if len(op.Parameters) > 0 {
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	pending, err := client.GetPendingMaintenanceActions(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get pending maintenance actions")
+	}
+
+	var actions []rds.PendingMaintenanceAction
+	for _, action := range pending {
+		if len(params.Actions) == 0 || slices.Contains(params.Actions, action.Action) {
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster %s has no pending maintenance actions to apply", op.ClusterID)
+	}
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Retrieve current cluster state",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	for _, action := range actions {
+		applyParams, err := json.Marshal(map[string]string{
+			"resource_arn": action.ResourceARN,
+			"resource_id":  action.ResourceID,
+			"action":       action.Action,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "marshal apply_pending_maintenance params for %s", action.ResourceID)
+		}
+		description := fmt.Sprintf("Apply %s to %s %s immediately", action.Action, action.ResourceType, action.ResourceID)
+		if action.Description != "" {
+			description += ": " + action.Description
+		}
+		rationale := fmt.Sprintf("%s is pending on %s and actions is empty, so every pending action is applied", action.Action, action.ResourceID)
+		if len(params.Actions) > 0 {
+			rationale = fmt.Sprintf("%s is pending on %s and listed in actions", action.Action, action.ResourceID)
+		}
+		steps = append(steps,
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        fmt.Sprintf("Apply %s to %s", action.Action, action.ResourceID),
+				Description: description,
+				Rationale:   rationale,
+				State:       types.StepStatePending,
+				Action:      "apply_pending_maintenance",
+				Parameters:  applyParams,
+				MaxRetries:  1,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Wait for " + action.ResourceID,
+				Description: "Wait for cluster and instances to become available",
+				State:       types.StepStatePending,
+				Action:      "wait_cluster_available",
+				MaxRetries:  1,
+			},
+		)
+	}
+
+	op.Steps = steps
+	return nil
+}
+
+// buildSnapshotRestoreTestSteps builds the steps for proving a cluster
+// snapshot is restorable: the snapshot (a fresh one unless snapshot_id is
+// given) is restored into a temporary cluster, which is waited on, optionally
+// given an instance to run the validation query, and then deleted.
+func (e *Engine) buildSnapshotRestoreTestSteps(ctx context.Context, op *types.Operation) error {
+	var params types.SnapshotRestoreTestParams
+	if len(op.Parameters) > 0 {
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+	if params.ValidationQuery != "" && e.restoreValidator == nil {
+		return errors.Wrap(internalerrors.ErrInvalidParameter,
+			"validation_query given but no restore validator is configured")
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	if params.SnapshotID != "" {
+		if _, err := client.IsSnapshotAvailable(ctx, params.SnapshotID); err != nil {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter, "snapshot %s: %v", params.SnapshotID, err)
+		}
+	}
+
+	restoreClusterID := rds.GenerateRestoreClusterID(op.ClusterID, op.ID)
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Retrieve current cluster state",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	if params.SnapshotID == "" {
+		steps = append(steps,
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Create snapshot",
+				Description: "Create cluster snapshot to restore",
+				State:       types.StepStatePending,
+				Action:      "create_snapshot",
+				MaxRetries:  1,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Wait for snapshot",
+				Description: "Wait for snapshot to become available",
+				State:       types.StepStatePending,
+				Action:      "wait_snapshot_available",
+				MaxRetries:  1,
+			},
+		)
+	} else {
+		waitParams, err := json.Marshal(map[string]string{"snapshot_id": params.SnapshotID})
+		if err != nil {
+			return errors.Wrap(err, "marshal wait_snapshot_available params")
+		}
+		steps = append(steps, types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Wait for snapshot",
+			Description: fmt.Sprintf("Wait for snapshot %s to be available", params.SnapshotID),
+			State:       types.StepStatePending,
+			Action:      "wait_snapshot_available",
+			Parameters:  waitParams,
+			MaxRetries:  1,
+		})
+	}
+
+	restoreParams, err := json.Marshal(map[string]string{
+		"snapshot_id":        params.SnapshotID,
+		"restore_cluster_id": restoreClusterID,
+		"engine":             info.Engine,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal restore_snapshot params")
+	}
+	waitRestoredParams, err := json.Marshal(map[string]bool{"restored_cluster": true})
+	if err != nil {
+		return errors.Wrap(err, "marshal wait_cluster_available params")
+	}
+
+	// Restoring is not retried: a retry after a restore that was accepted
+	// but not acknowledged would start a second cluster.
+	steps = append(steps,
+		types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Restore snapshot",
+			Description: fmt.Sprintf("Restore snapshot into temporary cluster %s", restoreClusterID),
+			State:       types.StepStatePending,
+			Action:      "restore_snapshot",
+			Parameters:  restoreParams,
+		},
+		types.Step{
+			ID:          uuid.New().String(),
+			Name:        "Wait for restored cluster",
+			Description: "Wait for the restored cluster to become available",
+			State:       types.StepStatePending,
+			Action:      "wait_cluster_available",
+			Parameters:  waitRestoredParams,
+			MaxRetries:  1,
+		},
+	)
+
+	if params.ValidationQuery != "" {
+		instanceType := params.InstanceType
+		if instanceType == "" {
+			writer := findWriter(info.Instances)
+			if writer == nil {
+				return errors.Wrap(internalerrors.ErrInvalidParameter,
+					"instance_type required: cluster has no writer to copy the class from")
+			}
+			instanceType = writer.InstanceType
+		}
+		instanceParams, err := json.Marshal(map[string]string{
+			"instance_type": instanceType,
+			"engine":        info.Engine,
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshal create_restore_instance params")
+		}
+		validateParams, err := json.Marshal(map[string]string{"query": params.ValidationQuery})
+		if err != nil {
+			return errors.Wrap(err, "marshal validate_restore params")
+		}
+		steps = append(steps,
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Create restore instance",
+				Description: fmt.Sprintf("Create %s instance in the restored cluster", instanceType),
+				State:       types.StepStatePending,
+				Action:      "create_restore_instance",
+				Parameters:  instanceParams,
+				MaxRetries:  1,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Wait for restore instance",
+				Description: "Wait for the restored cluster and its instance to become available",
+				State:       types.StepStatePending,
+				Action:      "wait_cluster_available",
+				Parameters:  waitRestoredParams,
+				MaxRetries:  1,
+			},
+			types.Step{
+				ID:          uuid.New().String(),
+				Name:        "Validate restore",
+				Description: "Run the validation query against the restored cluster",
+				State:       types.StepStatePending,
+				Action:      "validate_restore",
+				Parameters:  validateParams,
+			},
+		)
+	}
+
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Delete restored cluster",
+		Description: "Delete the restored cluster and its instances",
+		State:       types.StepStatePending,
+		Action:      "delete_restored_cluster",
+		MaxRetries:  3,
+	})
+
+	op.Steps = steps
+	return nil
+}
+
+// buildServerlessScalingSteps builds the steps for changing the Aurora
+// Serverless v2 capacity range. The range is checked against the AWS limits
+// here so a bad request fails before anything is changed.
+func (e *Engine) buildServerlessScalingSteps(ctx context.Context, op *types.Operation) error {
+	var params types.ServerlessScalingParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+	if err := rds.ValidateServerlessV2Capacity(params.MinCapacity, params.MaxCapacity); err != nil {
+		return err
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	current := info.ServerlessV2Scaling
+	if current == nil && !info.IsServerless {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster %s has no Serverless v2 instances or scaling configuration", op.ClusterID)
+	}
+	if current != nil && current.MinCapacity == params.MinCapacity && current.MaxCapacity == params.MaxCapacity {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster already scales between %g and %g ACUs", current.MinCapacity, current.MaxCapacity)
+	}
+
+	description := fmt.Sprintf("Set Serverless v2 capacity to %g-%g ACUs", params.MinCapacity, params.MaxCapacity)
+	if current != nil {
+		description = fmt.Sprintf("Change Serverless v2 capacity from %g-%g to %g-%g ACUs",
+			current.MinCapacity, current.MaxCapacity, params.MinCapacity, params.MaxCapacity)
+	}
+
+	scalingParams, err := json.Marshal(map[string]float64{
+		"min_capacity": params.MinCapacity,
+		"max_capacity": params.MaxCapacity,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal modify_serverless_scaling params")
+	}
+
+	op.Steps = []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Get cluster info",
+			Description: "Retrieve current cluster state",
+			State:       types.StepStatePending,
+			Action:      "get_cluster_info",
+			MaxRetries:  3,
+		},
+		preflightCheckStep(false),
+		{
+			ID:          uuid.New().String(),
+			Name:        "Modify serverless capacity",
+			Description: description,
+			State:       types.StepStatePending,
+			Action:      "modify_serverless_scaling",
+			Parameters:  scalingParams,
+			MaxRetries:  1,
+		},
+		{
+			ID:          uuid.New().String(),
+			Name:        "Wait for cluster",
+			Description: "Wait for cluster and instances to become available",
+			State:       types.StepStatePending,
+			Action:      "wait_cluster_available",
+			MaxRetries:  1,
+		},
+	}
+
+	return nil
+}
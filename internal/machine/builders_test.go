@@ -6,10 +6,15 @@ import (
 	"log/slog"
 	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
@@ -19,6 +24,21 @@ import (
 // testEngineWithMockServer creates a test engine with a mock RDS server.
 func testEngineWithMockServer(t *testing.T) (*Engine, func()) {
 	t.Helper()
+	engine, _, cleanup := testEngineWithMockState(t)
+	return engine, cleanup
+}
+
+// testEngineWithMockState is like testEngineWithMockServer but also returns the
+// mock state so tests can inject faults or inspect resources directly.
+func testEngineWithMockState(t *testing.T) (*Engine, *mock.State, func()) {
+	t.Helper()
+	return testEngineWithCallTimeouts(t, rds.CallTimeouts{})
+}
+
+// testEngineWithCallTimeouts is like testEngineWithMockState but bounds each
+// RDS API call with the given timeouts.
+func testEngineWithCallTimeouts(t *testing.T, timeouts rds.CallTimeouts) (*Engine, *mock.State, func()) {
+	t.Helper()
 
 	timing := mock.TimingConfig{
 		BaseWaitMs:    10,
@@ -40,8 +60,9 @@ func testEngineWithMockServer(t *testing.T) (*Engine, func()) {
 			Region:      "us-east-1",
 			Credentials: aws.AnonymousCredentials{},
 		},
-		DemoMode: true,
-		BaseURL:  server.URL,
+		DemoMode:     true,
+		BaseURL:      server.URL,
+		CallTimeouts: timeouts,
 	})
 
 	engine := &Engine{
@@ -60,7 +81,7 @@ func testEngineWithMockServer(t *testing.T) (*Engine, func()) {
 		mockState.Stop()
 	}
 
-	return engine, cleanup
+	return engine, mockState, cleanup
 }
 
 // TestBuildInstanceTypeChangeSteps_WaitStepsHaveInstanceID verifies that all wait_instance_available
@@ -151,7 +172,7 @@ func TestBuildStorageTypeChangeSteps_WaitStepsHaveInstanceID(t *testing.T) {
 	defer cleanup()
 
 	params, _ := json.Marshal(types.StorageTypeChangeParams{
-		TargetStorageType: "gp3",
+		TargetStorageType: "aurora-iopt1",
 	})
 
 	op := &types.Operation{
@@ -531,7 +552,7 @@ func TestValidateExcludedInstances_StorageTypeChange(t *testing.T) {
 	defer cleanup()
 
 	params, _ := json.Marshal(types.StorageTypeChangeParams{
-		TargetStorageType: "gp3",
+		TargetStorageType: "aurora-iopt1",
 		ExcludeInstances:  []string{"nonexistent-instance"},
 	})
 
@@ -564,3 +585,1154 @@ func stringContainsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+// TestBuildInstanceTypeChangeSteps_WriterExcludedClassMismatch verifies that
+// excluding the writer while resizing the readers is reported as a warning by
+// default and rejected when strict_writer_class is set.
+func TestBuildInstanceTypeChangeSteps_WriterExcludedClassMismatch(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		target      string
+		strict      bool
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "mismatch warns", target: "db.r6g.xlarge", wantWarning: true},
+		{name: "mismatch rejected when strict", target: "db.r6g.xlarge", strict: true, wantErr: true},
+		{name: "same class is silent", target: "db.r6g.large", strict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(types.InstanceTypeChangeParams{
+				TargetInstanceType: tt.target,
+				ExcludeInstances:   []string{"demo-multi-writer"},
+				StrictWriterClass:  tt.strict,
+			})
+			op := &types.Operation{
+				ID:         "test-writer-class",
+				Type:       types.OperationTypeInstanceTypeChange,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+
+			err := engine.buildInstanceTypeChangeSteps(context.Background(), op)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected strict mode to reject the class mismatch")
+				}
+				if !containsString(err.Error(), "demo-multi-writer") {
+					t.Errorf("expected error to name the writer, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(op.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("warnings = %v, want warning: %v", op.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// TestBuildInstanceTypeChangeSteps_TempPromotionTier verifies that the temp
+// instance promotion tier is validated and passed to create_temp_instance.
+func TestBuildInstanceTypeChangeSteps_TempPromotionTier(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name     string
+		tier     int32
+		wantErr  bool
+		wantTier int32
+	}{
+		{name: "default tier", wantTier: 0},
+		{name: "custom tier", tier: 5, wantTier: 5},
+		{name: "maximum tier", tier: 15, wantTier: 15},
+		{name: "negative tier rejected", tier: -1, wantErr: true},
+		{name: "tier above 15 rejected", tier: 16, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(types.InstanceTypeChangeParams{
+				TargetInstanceType:        "db.r6g.xlarge",
+				TempInstancePromotionTier: tt.tier,
+			})
+			op := &types.Operation{
+				ID:         "test-promotion-tier",
+				Type:       types.OperationTypeInstanceTypeChange,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+
+			err := engine.buildInstanceTypeChangeSteps(context.Background(), op)
+			if tt.wantErr {
+				if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+					t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, step := range op.Steps {
+				if step.Action != "create_temp_instance" {
+					continue
+				}
+				var createParams struct {
+					PromotionTier int32 `json:"promotion_tier"`
+				}
+				if err := json.Unmarshal(step.Parameters, &createParams); err != nil {
+					t.Fatalf("unmarshal create params: %v", err)
+				}
+				if createParams.PromotionTier != tt.wantTier {
+					t.Errorf("promotion_tier = %d, want %d", createParams.PromotionTier, tt.wantTier)
+				}
+				return
+			}
+			t.Fatal("no create_temp_instance step")
+		})
+	}
+}
+
+// TestBuildTempInstanceDeleteSteps_FinalSnapshot verifies that temp instance
+// deletion honors the engine default and the per-operation override for
+// taking a final snapshot.
+func TestBuildTempInstanceDeleteSteps_FinalSnapshot(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	yes, no := true, false
+	tests := []struct {
+		name          string
+		engineDefault bool
+		override      *bool
+		wantSnapshot  bool
+	}{
+		{name: "default skips snapshot"},
+		{name: "engine default takes snapshot", engineDefault: true, wantSnapshot: true},
+		{name: "operation enables snapshot", override: &yes, wantSnapshot: true},
+		{name: "operation disables snapshot", engineDefault: true, override: &no},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine.tempFinalSnapshot = tt.engineDefault
+			params, _ := json.Marshal(types.InstanceTypeChangeParams{
+				TargetInstanceType: "db.r6g.xlarge",
+				FinalSnapshot:      tt.override,
+			})
+			op := &types.Operation{
+				ID:         "test-final-snapshot",
+				Type:       types.OperationTypeInstanceTypeChange,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+			if err := engine.buildInstanceTypeChangeSteps(context.Background(), op); err != nil {
+				t.Fatalf("buildInstanceTypeChangeSteps failed: %v", err)
+			}
+
+			var snapshotStep, deleteStep *types.Step
+			for i := range op.Steps {
+				switch op.Steps[i].Action {
+				case "create_snapshot":
+					snapshotStep = &op.Steps[i]
+				case "delete_instance":
+					deleteStep = &op.Steps[i]
+				}
+			}
+			if deleteStep == nil {
+				t.Fatal("expected a delete_instance step")
+			}
+
+			// The snapshot, if any, is of the cluster; the instance itself is
+			// always deleted without one.
+			var deleteParams deleteInstanceParams
+			if err := json.Unmarshal(deleteStep.Parameters, &deleteParams); err != nil {
+				t.Fatalf("unmarshal delete params: %v", err)
+			}
+			if deleteParams.FinalSnapshot != finalSnapshotSkip {
+				t.Errorf("final_snapshot = %q, want %q", deleteParams.FinalSnapshot, finalSnapshotSkip)
+			}
+			if (snapshotStep != nil) != tt.wantSnapshot {
+				t.Fatalf("create_snapshot step present = %v, want %v", snapshotStep != nil, tt.wantSnapshot)
+			}
+			if snapshotStep == nil {
+				return
+			}
+
+			// The snapshot must be created under an identifiable name.
+			if err := engine.handleCreateSnapshot(context.Background(), op, snapshotStep); err != nil {
+				t.Fatalf("handleCreateSnapshot failed: %v", err)
+			}
+			wantID := "demo-multi-temp-final-test-final-snapshot"
+			if _, ok := mockState.GetSnapshot(wantID); !ok {
+				t.Errorf("expected snapshot %s to exist", wantID)
+			}
+			mockState.Reset()
+		})
+	}
+}
+
+// TestBuildEngineUpgradeSteps_PreUpgradeSnapshot verifies that engine upgrades
+// snapshot the cluster unless told to skip it, and that the snapshot carries
+// its retention and, for copy-tags, the cluster's tags.
+func TestBuildEngineUpgradeSteps_PreUpgradeSnapshot(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.snapshotRetention = constants.DefaultSnapshotRetentionDays
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		mode            types.PreUpgradeSnapshotMode
+		retentionDays   int
+		wantSnapshot    bool
+		wantRetention   string
+		wantClusterTags bool
+	}{
+		{name: "default creates snapshot", wantSnapshot: true, wantRetention: "14"},
+		{name: "retention override", mode: types.PreUpgradeSnapshotCreate, retentionDays: 3, wantSnapshot: true, wantRetention: "3"},
+		{name: "copy-tags", mode: types.PreUpgradeSnapshotCopyTags, wantSnapshot: true, wantRetention: "14", wantClusterTags: true},
+		{name: "skip", mode: types.PreUpgradeSnapshotSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Snapshot IDs are timestamped to the second, so start clean.
+			mockState.Reset()
+			mockState.AddResourceTags("arn:aws:rds:us-east-1:123456789012:cluster:demo-upgrade", map[string]string{"Environment": "demo"})
+
+			params, _ := json.Marshal(types.EngineUpgradeParams{
+				TargetEngineVersion:   "16.4",
+				PreUpgradeSnapshot:    tt.mode,
+				SnapshotRetentionDays: tt.retentionDays,
+				Tags:                  map[string]string{"Change": "CHG-1"},
+			})
+			op := &types.Operation{
+				ID:         "test-" + strings.ReplaceAll(tt.name, " ", "-"),
+				Type:       types.OperationTypeEngineUpgrade,
+				ClusterID:  "demo-upgrade",
+				Region:     "us-east-1",
+				Parameters: params,
+			}
+			if err := engine.buildEngineUpgradeSteps(ctx, op); err != nil {
+				t.Fatalf("buildEngineUpgradeSteps failed: %v", err)
+			}
+
+			var snapshotStep *types.Step
+			for i := range op.Steps {
+				if op.Steps[i].Action == "create_snapshot" {
+					snapshotStep = &op.Steps[i]
+				}
+			}
+			if (snapshotStep != nil) != tt.wantSnapshot {
+				t.Fatalf("create_snapshot step present = %v, want %v", snapshotStep != nil, tt.wantSnapshot)
+			}
+			if snapshotStep == nil {
+				return
+			}
+
+			if err := engine.handleCreateSnapshot(ctx, op, snapshotStep); err != nil {
+				t.Fatalf("handleCreateSnapshot failed: %v", err)
+			}
+			var result struct {
+				SnapshotID string `json:"snapshot_id"`
+			}
+			if err := json.Unmarshal(snapshotStep.Result, &result); err != nil {
+				t.Fatalf("unmarshal result: %v", err)
+			}
+			tags := mockState.ResourceTags("arn:aws:rds:us-east-1:123456789012:cluster-snapshot:" + result.SnapshotID)
+			if tags[rds.TagKeyRetentionDays] != tt.wantRetention {
+				t.Errorf("retention tag = %q, want %q", tags[rds.TagKeyRetentionDays], tt.wantRetention)
+			}
+			if tags["Change"] != "CHG-1" {
+				t.Errorf("operator tag missing from snapshot tags %v", tags)
+			}
+			if (tags["Environment"] == "demo") != tt.wantClusterTags {
+				t.Errorf("cluster tag copied = %v, want %v", tags["Environment"] == "demo", tt.wantClusterTags)
+			}
+		})
+	}
+
+	t.Run("unknown mode", func(t *testing.T) {
+		params, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: "16.4", PreUpgradeSnapshot: "sometimes"})
+		op := &types.Operation{ClusterID: "demo-upgrade", Region: "us-east-1", Parameters: params}
+		if err := engine.buildEngineUpgradeSteps(ctx, op); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("err = %v, want ErrInvalidParameter", err)
+		}
+	})
+}
+
+// TestBuildStorageTypeChangeSteps_ValidatesStorageTypeSupport verifies that
+// storage types the engine cannot use are rejected before any steps are built.
+func TestBuildStorageTypeChangeSteps_ValidatesStorageTypeSupport(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		target  string
+		wantErr bool
+	}{
+		{target: "gp3", wantErr: true},
+		{target: "io2", wantErr: true},
+		{target: "aurora-iopt1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			params, _ := json.Marshal(types.StorageTypeChangeParams{
+				TargetStorageType: tt.target,
+			})
+			op := &types.Operation{
+				ID:         "test-storage-support",
+				Type:       types.OperationTypeStorageTypeChange,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+
+			err := engine.buildStorageTypeChangeSteps(context.Background(), op)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected %s to be accepted, got: %v", tt.target, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected %s to be rejected", tt.target)
+			}
+			if !containsString(err.Error(), "supported storage types") {
+				t.Errorf("expected guidance listing supported storage types, got: %v", err)
+			}
+			if len(op.Steps) != 0 {
+				t.Error("expected no steps to be built for a rejected storage type")
+			}
+		})
+	}
+}
+
+// TestValidateStorageTypeSupport_InstanceClass verifies that a storage type
+// the engine supports is still rejected for instance classes that cannot use it.
+func TestValidateStorageTypeSupport_InstanceClass(t *testing.T) {
+	supported := map[string][]string{
+		"db.r6g.large": {"aurora", "aurora-iopt1"},
+		"db.t3.medium": {"aurora"},
+	}
+	instances := []types.InstanceInfo{
+		{InstanceID: "writer", InstanceType: "db.r6g.large", Role: "writer"},
+		{InstanceID: "reader", InstanceType: "db.t3.medium", Role: "reader"},
+	}
+
+	err := validateStorageTypeSupport("aurora-iopt1", "aurora-postgresql", instances, map[string]bool{}, supported)
+	if err == nil || !containsString(err.Error(), "reader (db.t3.medium)") {
+		t.Errorf("expected rejection naming the t3 reader, got: %v", err)
+	}
+
+	err = validateStorageTypeSupport("aurora-iopt1", "aurora-postgresql", instances, map[string]bool{"reader": true}, supported)
+	if err != nil {
+		t.Errorf("expected excluded reader to be ignored, got: %v", err)
+	}
+}
+
+func TestBuildMinorVersionUpgradeSteps(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	newOp := func(target string) *types.Operation {
+		params, _ := json.Marshal(types.MinorVersionUpgradeParams{TargetEngineVersion: target})
+		return &types.Operation{
+			ID:         "test-minor-upgrade",
+			Type:       types.OperationTypeMinorVersionUpgrade,
+			ClusterID:  "demo-multi",
+			Region:     "us-east-1",
+			Parameters: params,
+		}
+	}
+
+	t.Run("minor bump modifies in place", func(t *testing.T) {
+		op := newOp("15.5")
+		if err := engine.buildMinorVersionUpgradeSteps(ctx, op); err != nil {
+			t.Fatalf("buildMinorVersionUpgradeSteps failed: %v", err)
+		}
+
+		var actions []string
+		for _, step := range op.Steps {
+			actions = append(actions, step.Action)
+		}
+		if !slices.Contains(actions, "modify_cluster") || !slices.Contains(actions, "wait_cluster_available") {
+			t.Fatalf("expected modify_cluster and wait_cluster_available, got %v", actions)
+		}
+		for _, unwanted := range []string{"create_blue_green_deployment", "create_snapshot", "migrate_parameter_groups"} {
+			if slices.Contains(actions, unwanted) {
+				t.Errorf("minor upgrade should not include %s", unwanted)
+			}
+		}
+
+		for _, step := range op.Steps {
+			if step.Action != "modify_cluster" {
+				continue
+			}
+			var params map[string]any
+			if err := json.Unmarshal(step.Parameters, &params); err != nil {
+				t.Fatalf("unmarshal modify_cluster params: %v", err)
+			}
+			if params["engine_version"] != "15.5" || params["allow_major_version_upgrade"] != false {
+				t.Errorf("modify_cluster params = %v, want engine_version 15.5 without major upgrade", params)
+			}
+		}
+	})
+
+	t.Run("parameter group plans gated reboots before upgrade", func(t *testing.T) {
+		op := newOp("15.5")
+		op.Parameters, _ = json.Marshal(types.MinorVersionUpgradeParams{
+			TargetEngineVersion:         "15.5",
+			DBClusterParameterGroupName: "demo-multi-tuned-pg",
+		})
+		if err := engine.buildMinorVersionUpgradeSteps(ctx, op); err != nil {
+			t.Fatalf("buildMinorVersionUpgradeSteps failed: %v", err)
+		}
+
+		applyIdx, modifyIdx := -1, -1
+		var rebootIDs []string
+		for i, step := range op.Steps {
+			switch step.Action {
+			case "apply_parameter_group":
+				applyIdx = i
+			case "modify_cluster":
+				modifyIdx = i
+			case "reboot_instance":
+				var params struct {
+					InstanceID          string `json:"instance_id"`
+					OnlyIfPendingReboot bool   `json:"only_if_pending_reboot"`
+				}
+				if err := json.Unmarshal(step.Parameters, &params); err != nil {
+					t.Fatalf("unmarshal reboot_instance params: %v", err)
+				}
+				if !params.OnlyIfPendingReboot {
+					t.Errorf("reboot of %s is not gated on pending reboot", params.InstanceID)
+				}
+				rebootIDs = append(rebootIDs, params.InstanceID)
+			}
+		}
+		if applyIdx < 0 || modifyIdx < applyIdx {
+			t.Fatalf("expected apply_parameter_group before modify_cluster, got indexes %d and %d", applyIdx, modifyIdx)
+		}
+		if len(rebootIDs) != 3 || rebootIDs[len(rebootIDs)-1] != "demo-multi-writer" {
+			t.Errorf("reboots = %v, want 3 instances with the writer last", rebootIDs)
+		}
+	})
+
+	t.Run("current parameter group adds no steps", func(t *testing.T) {
+		op := newOp("15.5")
+		op.Parameters, _ = json.Marshal(types.MinorVersionUpgradeParams{
+			TargetEngineVersion:         "15.5",
+			DBClusterParameterGroupName: "demo-multi-pg",
+		})
+		if err := engine.buildMinorVersionUpgradeSteps(ctx, op); err != nil {
+			t.Fatalf("buildMinorVersionUpgradeSteps failed: %v", err)
+		}
+		for _, step := range op.Steps {
+			if step.Action == "apply_parameter_group" || step.Action == "reboot_instance" {
+				t.Errorf("unexpected %s step when the group is already associated", step.Action)
+			}
+		}
+	})
+
+	t.Run("major target is rejected", func(t *testing.T) {
+		err := engine.buildMinorVersionUpgradeSteps(ctx, newOp("16.1"))
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+		}
+	})
+
+	t.Run("current version is rejected", func(t *testing.T) {
+		err := engine.buildMinorVersionUpgradeSteps(ctx, newOp("15.4"))
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+		}
+	})
+}
+
+func TestBuildStorageTypeChangeSteps_DefaultStorageType(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	// modifyStorageTypes returns the storage_type of every modify_instance step.
+	modifyStorageTypes := func(op *types.Operation) []string {
+		var got []string
+		for _, step := range op.Steps {
+			if step.Action != "modify_instance" {
+				continue
+			}
+			var params struct {
+				StorageType string `json:"storage_type"`
+			}
+			if err := json.Unmarshal(step.Parameters, &params); err != nil {
+				t.Fatalf("unmarshal modify_instance params: %v", err)
+			}
+			got = append(got, params.StorageType)
+		}
+		return got
+	}
+
+	// The demo instances use aurora, so the overridden default is one that
+	// would be a no-op.
+	tests := []struct {
+		name        string
+		defaultType string
+		params      types.StorageTypeChangeParams
+		want        string
+	}{
+		{name: "default applied when omitted", defaultType: "aurora-iopt1", want: "aurora-iopt1"},
+		{name: "request overrides default", defaultType: "aurora", params: types.StorageTypeChangeParams{TargetStorageType: "aurora-iopt1"}, want: "aurora-iopt1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine.defaultStorageType = tt.defaultType
+			params, _ := json.Marshal(tt.params)
+			op := &types.Operation{
+				ID:         "test-op-default-storage",
+				Type:       types.OperationTypeStorageTypeChange,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+
+			if err := engine.buildStorageTypeChangeSteps(context.Background(), op); err != nil {
+				t.Fatalf("buildStorageTypeChangeSteps failed: %v", err)
+			}
+
+			got := modifyStorageTypes(op)
+			if len(got) == 0 {
+				t.Fatal("expected modify_instance steps")
+			}
+			for _, storageType := range got {
+				if storageType != tt.want {
+					t.Errorf("modify_instance storage_type = %q, want %q", storageType, tt.want)
+				}
+			}
+
+			var recorded types.StorageTypeChangeParams
+			if err := json.Unmarshal(op.Parameters, &recorded); err != nil {
+				t.Fatalf("unmarshal operation params: %v", err)
+			}
+			if recorded.TargetStorageType != tt.want {
+				t.Errorf("operation params target = %q, want %q", recorded.TargetStorageType, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInstanceCycleSteps_RebootWaitsUseShortTimeout(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	op := &types.Operation{
+		ID:         "test-op-cycle-timeouts",
+		Type:       types.OperationTypeInstanceCycle,
+		State:      types.StateCreated,
+		ClusterID:  "demo-multi",
+		Region:     "us-east-1",
+		Parameters: json.RawMessage(`{"final_snapshot":true}`),
+		CreatedAt:  time.Now(),
+	}
+	if err := engine.buildInstanceCycleSteps(context.Background(), op); err != nil {
+		t.Fatalf("buildInstanceCycleSteps failed: %v", err)
+	}
+
+	for i, step := range op.Steps {
+		switch {
+		case step.Action == "wait_instance_available" && i > 0 && op.Steps[i-1].Action == "reboot_instance":
+			if step.TimeoutSeconds != 300 {
+				t.Errorf("%s: TimeoutSeconds = %d, want 300", step.Name, step.TimeoutSeconds)
+			}
+		case step.Action == "wait_snapshot_available":
+			if step.TimeoutSeconds != 1800 {
+				t.Errorf("%s: TimeoutSeconds = %d, want 1800", step.Name, step.TimeoutSeconds)
+			}
+		}
+	}
+}
+
+func TestBuildInstanceTypeRollbackSteps(t *testing.T) {
+	info, _ := json.Marshal(types.ClusterInfo{
+		ClusterID: "test-cluster",
+		Instances: []types.InstanceInfo{
+			{InstanceID: "writer", InstanceType: "db.r6g.large", Role: "writer"},
+			{InstanceID: "reader-1", InstanceType: "db.r6g.large", Role: "reader"},
+			{InstanceID: "reader-2", InstanceType: "db.r6g.2xlarge", Role: "reader"},
+		},
+	})
+	modify := func(id, original string, state types.StepState) types.Step {
+		params, _ := json.Marshal(map[string]string{
+			"instance_id":            id,
+			"instance_type":          "db.r6g.xlarge",
+			"original_instance_type": original,
+		})
+		return types.Step{Name: "Modify instance: " + id, Action: "modify_instance", State: state, Parameters: params}
+	}
+
+	op := &types.Operation{
+		Type: types.OperationTypeInstanceTypeChange,
+		Steps: []types.Step{
+			{Name: "Get cluster info", Action: "get_cluster_info", State: types.StepStateCompleted, Result: info},
+			modify("reader-1", "db.r6g.large", types.StepStateCompleted),
+			modify("reader-2", "db.r6g.large", types.StepStateCompleted), // plan-time value is stale
+			modify("writer", "db.r6g.large", types.StepStateFailed),
+		},
+	}
+
+	steps, err := buildInstanceTypeRollbackSteps(op)
+	if err != nil {
+		t.Fatalf("buildInstanceTypeRollbackSteps failed: %v", err)
+	}
+
+	want := []struct{ action, instanceID, instanceType string }{
+		{"modify_instance", "reader-2", "db.r6g.2xlarge"},
+		{"wait_instance_available", "reader-2", ""},
+		{"modify_instance", "reader-1", "db.r6g.large"},
+		{"wait_instance_available", "reader-1", ""},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("got %d rollback steps, want %d", len(steps), len(want))
+	}
+	for i, w := range want {
+		var params struct {
+			InstanceID   string `json:"instance_id"`
+			InstanceType string `json:"instance_type"`
+		}
+		if err := json.Unmarshal(steps[i].Parameters, &params); err != nil {
+			t.Fatalf("step %d: unmarshal params: %v", i, err)
+		}
+		if steps[i].Action != w.action || params.InstanceID != w.instanceID || params.InstanceType != w.instanceType {
+			t.Errorf("step %d = %s %+v, want %s %s %s", i, steps[i].Action, params, w.action, w.instanceID, w.instanceType)
+		}
+		if !steps[i].Rollback {
+			t.Errorf("step %d should be marked as a rollback step", i)
+		}
+	}
+
+	// Without a get_cluster_info result the plan-time class is used.
+	op.Steps[0].State = types.StepStateFailed
+	steps, err = buildInstanceTypeRollbackSteps(op)
+	if err != nil {
+		t.Fatalf("buildInstanceTypeRollbackSteps failed: %v", err)
+	}
+	if !strings.Contains(string(steps[0].Parameters), `"db.r6g.large"`) {
+		t.Errorf("expected fallback to recorded original type, got %s", steps[0].Parameters)
+	}
+}
+
+func TestBuildCACertRotationSteps(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	op := &types.Operation{
+		ID:         "test-op-ca-rotation",
+		Type:       types.OperationTypeCACertRotation,
+		State:      types.StateCreated,
+		ClusterID:  "demo-multi",
+		Region:     "us-east-1",
+		Parameters: json.RawMessage(`{"target_ca_certificate":"rds-ca-rsa2048-g1"}`),
+		CreatedAt:  time.Now(),
+	}
+	if err := engine.buildCACertRotationSteps(context.Background(), op); err != nil {
+		t.Fatalf("buildCACertRotationSteps failed: %v", err)
+	}
+
+	// Readers must be rotated before the temp instance takes over the writer role.
+	var rotated []string
+	createIdx := -1
+	for i, step := range op.Steps {
+		switch step.Action {
+		case "rotate_ca_cert":
+			var p map[string]string
+			if err := json.Unmarshal(step.Parameters, &p); err != nil {
+				t.Fatalf("unmarshal rotate params: %v", err)
+			}
+			if p["ca_certificate_identifier"] != "rds-ca-rsa2048-g1" {
+				t.Errorf("%s: ca_certificate_identifier = %q", step.Name, p["ca_certificate_identifier"])
+			}
+			rotated = append(rotated, p["instance_id"])
+			if op.Steps[i+1].Action != "reboot_instance" || op.Steps[i+2].Action != "wait_instance_available" {
+				t.Errorf("%s: expected reboot and wait to follow", step.Name)
+			}
+		case "create_temp_instance":
+			createIdx = i
+			if !strings.Contains(string(step.Parameters), `"ca_certificate_identifier":"rds-ca-rsa2048-g1"`) {
+				t.Errorf("temp instance should be created with the target CA, params = %s", step.Parameters)
+			}
+		case "wait_instance_available":
+			if step.Name != "Wait for temp instance" && !strings.Contains(string(step.Parameters), "instance_id") {
+				t.Errorf("%s: missing instance_id", step.Name)
+			}
+		}
+	}
+
+	if len(rotated) != 3 || rotated[2] != "demo-multi-writer" {
+		t.Errorf("rotation order = %v, want both readers then demo-multi-writer", rotated)
+	}
+	if createIdx < 0 {
+		t.Fatal("expected a create_temp_instance step")
+	}
+	for i, step := range op.Steps[:createIdx] {
+		if strings.Contains(string(step.Parameters), "demo-multi-writer") {
+			t.Errorf("step %d (%s) touches the writer before the temp instance exists", i, step.Name)
+		}
+	}
+
+	op.Parameters = json.RawMessage(`{"target_ca_certificate":"rds-ca-2019"}`)
+	if err := engine.buildCACertRotationSteps(context.Background(), op); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter when every instance already uses the target, got: %v", err)
+	}
+}
+
+// TestBuilders_WriterListedLast verifies that plans target the writer by role
+// when DescribeDBClusters lists it after its readers.
+func TestBuilders_WriterListedLast(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	const writerID = "demo-writer-last-writer"
+
+	tests := []struct {
+		name     string
+		opType   types.OperationType
+		params   string
+		build    func(context.Context, *types.Operation) error
+		tempType string
+	}{
+		{
+			name:     "instance type change",
+			opType:   types.OperationTypeInstanceTypeChange,
+			params:   `{"target_instance_type":"db.r6g.2xlarge"}`,
+			build:    engine.buildInstanceTypeChangeSteps,
+			tempType: "db.r6g.2xlarge",
+		},
+		{
+			name:     "storage type change",
+			opType:   types.OperationTypeStorageTypeChange,
+			params:   `{"target_storage_type":"aurora-iopt1"}`,
+			build:    engine.buildStorageTypeChangeSteps,
+			tempType: "db.r6g.xlarge",
+		},
+		{
+			name:     "instance cycle",
+			opType:   types.OperationTypeInstanceCycle,
+			params:   `{}`,
+			build:    engine.buildInstanceCycleSteps,
+			tempType: "db.r6g.xlarge",
+		},
+		{
+			name:     "ca cert rotation",
+			opType:   types.OperationTypeCACertRotation,
+			params:   `{"target_ca_certificate":"rds-ca-rsa2048-g1"}`,
+			build:    engine.buildCACertRotationSteps,
+			tempType: "db.r6g.xlarge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &types.Operation{
+				ID:         "test-op-writer-last",
+				Type:       tt.opType,
+				State:      types.StateCreated,
+				ClusterID:  "demo-writer-last",
+				Region:     "us-east-1",
+				Parameters: json.RawMessage(tt.params),
+				CreatedAt:  time.Now(),
+			}
+			if err := tt.build(context.Background(), op); err != nil {
+				t.Fatalf("build failed: %v", err)
+			}
+
+			var failovers []string
+			for _, step := range op.Steps {
+				var p map[string]string
+				if len(step.Parameters) > 0 {
+					_ = json.Unmarshal(step.Parameters, &p)
+				}
+				switch step.Action {
+				case "create_temp_instance":
+					if p["instance_type"] != tt.tempType {
+						t.Errorf("temp instance type = %q, want %q", p["instance_type"], tt.tempType)
+					}
+				case "failover_to_instance":
+					failovers = append(failovers, p["instance_id"])
+				}
+			}
+
+			// The first failover promotes the temp instance; the second must
+			// hand the writer role back to the original writer.
+			if len(failovers) != 2 || failovers[0] != "" || failovers[1] != writerID {
+				t.Errorf("failover targets = %q, want [\"\" %q]", failovers, writerID)
+			}
+		})
+	}
+}
+
+// TestBuildRebootClusterSteps verifies that readers are rebooted first and the
+// writer only when requested, failing over to a rebooted reader beforehand.
+func TestBuildRebootClusterSteps(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	no := false
+	tests := []struct {
+		name      string
+		params    types.RebootClusterParams
+		wantErr   bool
+		wantSteps []string // "action:instance_id" for reboots and failovers
+	}{
+		{
+			name: "readers only by default",
+			wantSteps: []string{
+				"reboot_instance:demo-multi-reader-1",
+				"reboot_instance:demo-multi-reader-2",
+			},
+		},
+		{
+			name:   "writer rebooted after failover and failed back",
+			params: types.RebootClusterParams{RebootWriter: true},
+			wantSteps: []string{
+				"reboot_instance:demo-multi-reader-1",
+				"reboot_instance:demo-multi-reader-2",
+				"failover_to_instance:demo-multi-reader-1",
+				"reboot_instance:demo-multi-writer",
+				"failover_to_instance:demo-multi-writer",
+			},
+		},
+		{
+			name:   "fail back disabled",
+			params: types.RebootClusterParams{RebootWriter: true, FailBack: &no},
+			wantSteps: []string{
+				"reboot_instance:demo-multi-reader-1",
+				"reboot_instance:demo-multi-reader-2",
+				"failover_to_instance:demo-multi-reader-1",
+				"reboot_instance:demo-multi-writer",
+			},
+		},
+		{
+			name:    "no readers left to reboot",
+			params:  types.RebootClusterParams{ExcludeInstances: []string{"demo-multi-reader-1", "demo-multi-reader-2"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(tt.params)
+			op := &types.Operation{
+				ID:         "test-reboot-cluster",
+				Type:       types.OperationTypeRebootCluster,
+				State:      types.StateCreated,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+				CreatedAt:  time.Now(),
+			}
+
+			err := engine.buildRebootClusterSteps(context.Background(), op)
+			if tt.wantErr {
+				if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+					t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for i, step := range op.Steps {
+				if step.Action == "create_temp_instance" {
+					t.Fatal("reboot cluster must not create a temp instance")
+				}
+				if step.Action != "reboot_instance" && step.Action != "failover_to_instance" {
+					continue
+				}
+				var p struct {
+					InstanceID string `json:"instance_id"`
+				}
+				_ = json.Unmarshal(step.Parameters, &p)
+				got = append(got, step.Action+":"+p.InstanceID)
+
+				if step.Action == "reboot_instance" {
+					next := op.Steps[i+1]
+					if next.Action != "wait_instance_available" || !strings.Contains(string(next.Parameters), p.InstanceID) {
+						t.Errorf("reboot of %s not followed by a wait for it", p.InstanceID)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.wantSteps) {
+				t.Errorf("steps = %v, want %v", got, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestBuildEngineUpgradeSteps_AlarmCheck(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	build := func(p types.EngineUpgradeParams) (*types.Operation, error) {
+		p.TargetEngineVersion = "16.4"
+		params, _ := json.Marshal(p)
+		op := &types.Operation{
+			ID:         "test-alarm-check",
+			Type:       types.OperationTypeEngineUpgrade,
+			ClusterID:  "demo-upgrade",
+			Region:     "us-east-1",
+			Parameters: params,
+		}
+		return op, engine.buildEngineUpgradeSteps(ctx, op)
+	}
+
+	op, err := build(types.EngineUpgradeParams{AlarmNamePatterns: []string{"{cluster}-*"}})
+	if err != nil {
+		t.Fatalf("buildEngineUpgradeSteps failed: %v", err)
+	}
+	checkIdx := slices.IndexFunc(op.Steps, func(s types.Step) bool { return s.Action == "check_alarms" })
+	if checkIdx < 0 {
+		t.Fatal("expected a check_alarms step")
+	}
+	if op.Steps[checkIdx+1].Action != "switchover_blue_green" {
+		t.Errorf("check_alarms should run right before switchover, next step is %s", op.Steps[checkIdx+1].Action)
+	}
+	if !slices.Contains(op.PauseBeforeSteps, checkIdx) {
+		t.Errorf("switchover pause should land on the alarm check (%d), got %v", checkIdx, op.PauseBeforeSteps)
+	}
+	if !strings.Contains(string(op.Steps[checkIdx].Parameters), "{cluster}-*") {
+		t.Errorf("check_alarms params should carry the patterns: %s", op.Steps[checkIdx].Parameters)
+	}
+
+	op, err = build(types.EngineUpgradeParams{SkipAlarmCheck: true})
+	if err != nil {
+		t.Fatalf("buildEngineUpgradeSteps failed: %v", err)
+	}
+	if slices.ContainsFunc(op.Steps, func(s types.Step) bool { return s.Action == "check_alarms" }) {
+		t.Error("skip_alarm_check should remove the check_alarms step")
+	}
+	switchoverIdx := slices.IndexFunc(op.Steps, func(s types.Step) bool { return s.Action == "switchover_blue_green" })
+	if !slices.Contains(op.PauseBeforeSteps, switchoverIdx) {
+		t.Errorf("expected pause before switchover (%d), got %v", switchoverIdx, op.PauseBeforeSteps)
+	}
+
+	if _, err := build(types.EngineUpgradeParams{AlarmNamePatterns: []string{"[bad"}}); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for a malformed pattern, got: %v", err)
+	}
+}
+
+// TestBuildInstanceTypeChangeSteps_InstanceParameterGroup verifies that a
+// target instance parameter group is validated, passed to every modify step,
+// and followed by a reboot of each modified instance.
+func TestBuildInstanceTypeChangeSteps_InstanceParameterGroup(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	build := func(pg string) (*types.Operation, error) {
+		params, _ := json.Marshal(types.InstanceTypeChangeParams{
+			TargetInstanceType:               "db.r6g.xlarge",
+			TargetInstanceParameterGroupName: pg,
+			MaxParallelReaders:               2,
+		})
+		op := &types.Operation{
+			ID:         "test-instance-pg",
+			Type:       types.OperationTypeInstanceTypeChange,
+			State:      types.StateCreated,
+			ClusterID:  "demo-multi",
+			Region:     "us-east-1",
+			Parameters: params,
+			CreatedAt:  time.Now(),
+		}
+		return op, engine.buildInstanceTypeChangeSteps(context.Background(), op)
+	}
+
+	for pg, want := range map[string]string{
+		"missing-pg":         "does not exist",
+		"demo-instance-pg16": "is for aurora-postgresql16",
+	} {
+		if _, err := build(pg); !errors.Is(err, internalerrors.ErrInvalidParameter) || !containsString(err.Error(), want) {
+			t.Errorf("%s: expected ErrInvalidParameter mentioning %q, got: %v", pg, want, err)
+		}
+	}
+
+	op, err := build("demo-instance-pg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var modified, rebooted []string
+	for i, step := range op.Steps {
+		var params struct {
+			InstanceID           string `json:"instance_id"`
+			DBParameterGroupName string `json:"db_parameter_group_name"`
+		}
+		_ = json.Unmarshal(step.Parameters, &params)
+		switch step.Action {
+		case "modify_instance":
+			if params.DBParameterGroupName != "demo-instance-pg" {
+				t.Errorf("modify of %s has parameter group %q", params.InstanceID, params.DBParameterGroupName)
+			}
+			modified = append(modified, params.InstanceID)
+		case "reboot_instance":
+			if prev := op.Steps[i-1].Action; !strings.HasPrefix(prev, "wait_instance") {
+				t.Errorf("reboot of %s follows %s, want a wait", params.InstanceID, prev)
+			}
+			rebooted = append(rebooted, params.InstanceID)
+		}
+	}
+	if len(modified) != 3 || !slices.Equal(modified, rebooted) {
+		t.Errorf("modified %v but rebooted %v; want each modified instance rebooted in order", modified, rebooted)
+	}
+
+	// Without a parameter group, nothing is rebooted.
+	op, err = build("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, step := range op.Steps {
+		if step.Action == "reboot_instance" {
+			t.Errorf("unexpected reboot step %q", step.Name)
+		}
+	}
+}
+
+// TestBuildInstanceTypeChangeSteps_SingleInstance verifies that skipping the
+// temp instance on a cluster with no readers takes allow_downtime, and that
+// both outcomes are explained by a warning event.
+func TestBuildInstanceTypeChangeSteps_SingleInstance(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	build := func(allowDowntime bool) (*types.Operation, []types.Event) {
+		t.Helper()
+		params, _ := json.Marshal(types.InstanceTypeChangeParams{
+			TargetInstanceType: "db.r6g.xlarge",
+			SkipTempInstance:   true,
+			AllowDowntime:      allowDowntime,
+		})
+		op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-single", "us-east-1",
+			params, CreateOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		events, err := engine.GetEvents(op.ID)
+		if err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+		return op, events
+	}
+	hasWarning := func(events []types.Event, substr string) bool {
+		for _, event := range events {
+			if event.Type == "warning" && strings.Contains(event.Message, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	stepFor := func(op *types.Operation, action string) *types.Step {
+		for i := range op.Steps {
+			if op.Steps[i].Action == action {
+				return &op.Steps[i]
+			}
+		}
+		return nil
+	}
+
+	// Without allow_downtime the skip is overridden.
+	op, events := build(false)
+	create := stepFor(op, "create_temp_instance")
+	if create == nil || stepFor(op, "failover_to_instance") == nil {
+		t.Fatal("expected a temp instance and a failover despite skip_temp_instance")
+	}
+	if !strings.Contains(create.Rationale, "no readers") {
+		t.Errorf("create rationale = %q, want it to explain the override", create.Rationale)
+	}
+	if !hasWarning(events, "skip_temp_instance is overridden") {
+		t.Errorf("expected a warning event explaining the override, got %+v", events)
+	}
+
+	// With allow_downtime the writer is modified in place.
+	op, events = build(true)
+	if stepFor(op, "create_temp_instance") != nil || stepFor(op, "failover_to_instance") != nil {
+		t.Error("allow_downtime should keep the temp instance skipped")
+	}
+	if stepFor(op, "modify_instance") == nil {
+		t.Fatal("expected the writer to be modified")
+	}
+	if !hasWarning(events, "unavailable until it is back") {
+		t.Errorf("expected a warning event about the downtime, got %+v", events)
+	}
+}
+
+// TestBuildEngineUpgradeSteps_VersionDirection verifies that a Blue-Green
+// upgrade to the current version completes as a no-op and a downgrade is
+// rejected.
+func TestBuildEngineUpgradeSteps_VersionDirection(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	create := func(target string) (*types.Operation, error) {
+		params, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: target})
+		return engine.CreateOperation(ctx, types.OperationTypeEngineUpgrade, "demo-upgrade", "us-east-1",
+			params, CreateOptions{DryRun: true})
+	}
+
+	op, err := create("15.4")
+	if err != nil {
+		t.Fatalf("same version should not be an error: %v", err)
+	}
+	if op.State != types.StateCompleted || len(op.Steps) != 0 || op.CompletedAt == nil {
+		t.Errorf("same version: state %s with %d steps, want a completed no-op", op.State, len(op.Steps))
+	}
+	events, _ := engine.GetEvents(op.ID)
+	found := false
+	for _, event := range events {
+		if event.Type == "operation_completed" && strings.Contains(event.Message, "already runs aurora-postgresql 15.4") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an event explaining the no-op, got %+v", events)
+	}
+
+	_, err = create("15.3")
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) || !strings.Contains(err.Error(), "downgrades are not supported") {
+		t.Errorf("downgrade: expected ErrInvalidParameter, got %v", err)
+	}
+
+	op, err = create("16.1")
+	if err != nil {
+		t.Fatalf("major upgrade failed: %v", err)
+	}
+	if op.State != types.StatePlanned || len(op.Steps) == 0 {
+		t.Errorf("major upgrade: state %s with %d steps, want a plan", op.State, len(op.Steps))
+	}
+}
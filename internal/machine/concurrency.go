@@ -0,0 +1,93 @@
+package machine
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// checkClusterFreeLocked returns ErrClusterBusy if an operation other than
+// exceptID is active on the cluster. Callers hold e.mu.
+//
+// There is no separate lock to release: a cluster is held for as long as an
+// operation on it is active (see types.OperationState.IsActive), so whatever
+// path ends the operation - completion, failure, cancellation or rollback -
+// frees the cluster with it.
+func (e *Engine) checkClusterFreeLocked(clusterID, region, exceptID string) error {
+	for _, other := range e.operations {
+		if other.ID == exceptID || other.ClusterID != clusterID || other.Region != region {
+			continue
+		}
+		if other.State.IsActive() {
+			return errors.Wrapf(internalerrors.ErrClusterBusy,
+				"cluster %s in region %s has operation %s %s", clusterID, region, other.ID, other.State)
+		}
+	}
+	return nil
+}
+
+// admitLocked checks that op may become active: the engine must not be
+// draining, its cluster must be free and the engine below its concurrency
+// cap. An operation that is already active keeps its slot. Callers hold e.mu.
+func (e *Engine) admitLocked(op *types.Operation) error {
+	if e.draining {
+		return errors.Wrapf(internalerrors.ErrShuttingDown, "operation %s not started", op.ID)
+	}
+	if op.State.IsActive() {
+		return nil
+	}
+	if err := e.checkClusterFreeLocked(op.ClusterID, op.Region, op.ID); err != nil {
+		return err
+	}
+	if e.maxConcurrentOps <= 0 {
+		return nil
+	}
+	active := 0
+	for _, other := range e.operations {
+		if other.State.IsActive() {
+			active++
+		}
+	}
+	if active >= e.maxConcurrentOps {
+		return errors.Wrapf(internalerrors.ErrTooManyOperations,
+			"%d of %d operations already active", active, e.maxConcurrentOps)
+	}
+	return nil
+}
+
+// ActiveOperations lists the operations currently holding a cluster, ordered
+// by cluster.
+func (e *Engine) ActiveOperations() []types.ActiveOperation {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	active := []types.ActiveOperation{}
+	for _, op := range e.operations {
+		if !op.State.IsActive() {
+			continue
+		}
+		active = append(active, types.ActiveOperation{
+			OperationID: op.ID,
+			Type:        op.Type,
+			ClusterID:   op.ClusterID,
+			Region:      op.Region,
+			State:       op.State,
+			StartedAt:   op.StartedAt,
+		})
+	}
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Region != active[j].Region {
+			return active[i].Region < active[j].Region
+		}
+		return active[i].ClusterID < active[j].ClusterID
+	})
+	return active
+}
+
+// MaxConcurrentOperations returns the engine-wide cap on active operations,
+// or zero if there is none.
+func (e *Engine) MaxConcurrentOperations() int {
+	return e.maxConcurrentOps
+}
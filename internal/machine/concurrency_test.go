@@ -0,0 +1,87 @@
+package machine
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestStartOperation_ConcurrencyLimits(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+
+	now := time.Now()
+	ops := []*types.Operation{
+		{ID: "op-running", State: types.StateRunning, ClusterID: "cluster-a"},
+		{ID: "op-same-cluster", State: types.StateCreated, ClusterID: "cluster-a"},
+		{ID: "op-paused", State: types.StatePaused, ClusterID: "cluster-b"},
+		{ID: "op-over-cap", State: types.StateCreated, ClusterID: "cluster-c"},
+		{ID: "op-other-region", State: types.StateCreated, ClusterID: "cluster-a", Region: "us-west-2"},
+	}
+	for _, op := range ops {
+		op.Type = types.OperationTypeInstanceCycle
+		if op.Region == "" {
+			op.Region = "us-east-1"
+		}
+		op.CreatedAt = now
+		op.UpdatedAt = now
+		if err := store.SaveOperation(ctx, op); err != nil {
+			t.Fatalf("SaveOperation failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(EngineConfig{
+		Store:                   store,
+		Logger:                  slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		MaxConcurrentOperations: 3,
+	})
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+
+	if got := engine.ActiveOperations(); len(got) != 2 || got[0].OperationID != "op-running" || got[1].OperationID != "op-paused" {
+		t.Fatalf("active operations = %+v, want op-running and op-paused", got)
+	}
+
+	// A second operation on a cluster with an active one is refused.
+	if err := engine.StartOperation(ctx, "op-same-cluster"); !errors.Is(err, internalerrors.ErrClusterBusy) {
+		t.Fatalf("StartOperation on busy cluster: err = %v, want ErrClusterBusy", err)
+	}
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "cluster-a", "us-east-1", nil, CreateOptions{}); !errors.Is(err, internalerrors.ErrClusterBusy) {
+		t.Fatalf("CreateOperation on busy cluster: err = %v, want ErrClusterBusy", err)
+	}
+
+	// The same cluster name in another region is a different cluster. It has
+	// no steps, so it completes and frees its slot straight away.
+	if err := engine.StartOperation(ctx, "op-other-region"); err != nil {
+		t.Fatalf("StartOperation in other region failed: %v", err)
+	}
+	waitForState(t, engine, engine.operations["op-other-region"], types.StateCompleted)
+
+	// Fill the last slot, then the cap refuses a fourth.
+	engine.mu.Lock()
+	engine.operations["op-other-region"].State = types.StatePaused
+	engine.mu.Unlock()
+	if err := engine.StartOperation(ctx, "op-over-cap"); !errors.Is(err, internalerrors.ErrTooManyOperations) {
+		t.Fatalf("StartOperation over the cap: err = %v, want ErrTooManyOperations", err)
+	}
+
+	// Once the blocking operation ends, its cluster and slot are released.
+	engine.mu.Lock()
+	engine.operations["op-running"].State = types.StateCancelled
+	engine.mu.Unlock()
+	if err := engine.StartOperation(ctx, "op-same-cluster"); err != nil {
+		t.Fatalf("StartOperation after the cluster was released: %v", err)
+	}
+	waitForState(t, engine, engine.operations["op-same-cluster"], types.StateCompleted)
+}
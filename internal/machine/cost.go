@@ -0,0 +1,210 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// assumedWaitStepDuration is how long each wait step is assumed to take when
+// no operation of the same type has completed to base the duration on.
+const assumedWaitStepDuration = 10 * time.Minute
+
+// assumedSnapshotRetentionDays is how long a snapshot is assumed to be kept
+// when its step does not say.
+const assumedSnapshotRetentionDays = 30
+
+// CostEstimate estimates what an operation adds to the bill: its temp
+// instance, the snapshots it takes and, for Blue-Green upgrades, the green
+// cluster. Every added resource is assumed to exist for the whole operation,
+// which overstates rather than understates the cost. The estimate is priced
+// from the engine's price table and the cluster as it is now.
+func (e *Engine) CostEstimate(ctx context.Context, id string) (*types.CostEstimate, error) {
+	op, err := e.GetOperation(id)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	steps := make([]types.Step, len(op.Steps))
+	copy(steps, op.Steps)
+	opType := op.Type
+	var actual time.Duration
+	if op.StartedAt != nil && op.CompletedAt != nil {
+		actual = op.CompletedAt.Sub(*op.StartedAt)
+	}
+	e.mu.RUnlock()
+
+	client, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "describe cluster %s", op.ClusterID)
+	}
+
+	estimate := &types.CostEstimate{
+		OperationID: id,
+		Estimate:    true,
+		Currency:    e.prices.Currency,
+		Items:       []types.CostItem{},
+		Assumptions: []string{
+			"prices are from " + e.prices.Source + "; taxes, discounts and reserved instances are ignored",
+		},
+	}
+
+	duration, basis, err := e.expectedDuration(ctx, opType, steps, actual)
+	if err != nil {
+		return nil, err
+	}
+	hours := duration.Hours()
+	estimate.ExpectedDurationHours = roundCost(hours)
+	estimate.Assumptions = append(estimate.Assumptions, fmt.Sprintf("the operation takes %.1f hours, %s", hours, basis))
+
+	var writerClass string
+	var volumeGB int32
+	for _, inst := range info.Instances {
+		if inst.Role == "writer" {
+			writerClass = inst.InstanceType
+		}
+		volumeGB = max(volumeGB, inst.AllocatedStorage)
+	}
+
+	addInstance := func(description, class string) {
+		rate, ok := e.prices.InstancePrice(class)
+		if !ok {
+			estimate.Assumptions = append(estimate.Assumptions,
+				fmt.Sprintf("%s is not priced: no hourly price for %s", description, class))
+			return
+		}
+		estimate.Items = append(estimate.Items, types.CostItem{
+			Description: description,
+			Quantity:    roundCost(hours),
+			Unit:        "instance-hours",
+			Rate:        rate,
+			Cost:        roundCost(hours * rate),
+		})
+	}
+
+	for _, step := range steps {
+		switch step.Action {
+		case "create_temp_instance":
+			var params struct {
+				InstanceType string `json:"instance_type"`
+			}
+			if len(step.Parameters) > 0 {
+				if err := json.Unmarshal(step.Parameters, &params); err != nil {
+					return nil, errors.Wrapf(err, "unmarshal %s params", step.Action)
+				}
+			}
+			class := params.InstanceType
+			if class == "" {
+				class = writerClass
+			}
+			addInstance("temp instance "+class, class)
+			estimate.Assumptions = append(estimate.Assumptions,
+				"the temp instance runs for the whole operation")
+
+		case "create_blue_green_deployment":
+			for _, inst := range info.Instances {
+				addInstance(fmt.Sprintf("green copy of %s (%s)", inst.InstanceID, inst.InstanceType), inst.InstanceType)
+			}
+			estimate.Assumptions = append(estimate.Assumptions,
+				"the green cluster mirrors the current instances and runs for the whole operation, covering its provisioning and the switchover")
+
+		case "create_snapshot":
+			var params struct {
+				RetentionDays int `json:"retention_days"`
+			}
+			if len(step.Parameters) > 0 {
+				if err := json.Unmarshal(step.Parameters, &params); err != nil {
+					return nil, errors.Wrapf(err, "unmarshal %s params", step.Action)
+				}
+			}
+			if volumeGB == 0 || e.prices.SnapshotGBMonth == 0 {
+				estimate.Assumptions = append(estimate.Assumptions,
+					fmt.Sprintf("%q is not priced: the cluster volume size or the snapshot price is unknown", step.Name))
+				continue
+			}
+			days := params.RetentionDays
+			if days <= 0 {
+				days = assumedSnapshotRetentionDays
+				estimate.Assumptions = append(estimate.Assumptions,
+					fmt.Sprintf("%q has no expiry and is assumed to be deleted after %d days", step.Name, days))
+			}
+			months := float64(days) / 30
+			estimate.Items = append(estimate.Items, types.CostItem{
+				Description: fmt.Sprintf("%s (%d GiB for %d days)", strings.ToLower(step.Name), volumeGB, days),
+				Quantity:    roundCost(float64(volumeGB) * months),
+				Unit:        "GiB-months",
+				Rate:        e.prices.SnapshotGBMonth,
+				Cost:        roundCost(float64(volumeGB) * months * e.prices.SnapshotGBMonth),
+			})
+			estimate.Assumptions = append(estimate.Assumptions,
+				"snapshots are billed at the full allocated storage; Aurora charges nothing for snapshot storage within the backup retention period, so this is an upper bound")
+		}
+	}
+
+	var total float64
+	for _, item := range estimate.Items {
+		total += item.Cost
+	}
+	estimate.Total = roundCost(total)
+	estimate.Assumptions = dedupe(estimate.Assumptions)
+	return estimate, nil
+}
+
+// expectedDuration returns how long an operation is expected to take and
+// what that is based on: how long it actually took once it has finished,
+// otherwise the median of completed operations of its type, otherwise a
+// fixed allowance per wait step in its plan.
+func (e *Engine) expectedDuration(ctx context.Context, opType types.OperationType, steps []types.Step, actual time.Duration) (time.Duration, string, error) {
+	if actual > 0 {
+		return actual, "the time it actually took", nil
+	}
+
+	stats, err := e.DurationStats(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	if s, ok := stats[opType]; ok && s.Count > 0 {
+		return time.Duration(s.P50Seconds * float64(time.Second)),
+			fmt.Sprintf("the median of %d completed %s operations", s.Count, opType), nil
+	}
+
+	waits := 0
+	for _, step := range steps {
+		if strings.HasPrefix(step.Action, "wait_") {
+			waits++
+		}
+	}
+	waits = max(waits, 1)
+	return time.Duration(waits) * assumedWaitStepDuration,
+		fmt.Sprintf("%s for each of its %d wait steps since no %s operation has completed yet",
+			assumedWaitStepDuration, waits, opType), nil
+}
+
+// roundCost rounds to a hundredth, which is as precise as an estimate gets.
+func roundCost(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// dedupe removes repeated strings, keeping the first of each.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,165 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/pricing"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// hasAssumption reports whether any assumption mentions substr.
+func hasAssumption(estimate *types.CostEstimate, substr string) bool {
+	for _, a := range estimate.Assumptions {
+		if strings.Contains(a, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCostEstimate_TempInstance(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.prices = pricing.Default()
+	ctx := context.Background()
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge"})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1",
+		params, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	estimate, err := engine.CostEstimate(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("CostEstimate failed: %v", err)
+	}
+	if !estimate.Estimate || estimate.Currency != "USD" {
+		t.Errorf("estimate = %v in %q, want an estimate in USD", estimate.Estimate, estimate.Currency)
+	}
+	if len(estimate.Items) != 1 || estimate.Items[0].Description != "temp instance db.r6g.xlarge" {
+		t.Fatalf("Items = %+v, want only the temp instance", estimate.Items)
+	}
+
+	waits := 0
+	for _, step := range op.Steps {
+		if strings.HasPrefix(step.Action, "wait_") {
+			waits++
+		}
+	}
+	hours := (time.Duration(waits) * assumedWaitStepDuration).Hours()
+	if math.Abs(estimate.ExpectedDurationHours-hours) > 0.01 {
+		t.Errorf("ExpectedDurationHours = %v, want %v", estimate.ExpectedDurationHours, hours)
+	}
+	if want := roundCost(hours * 0.519); estimate.Total != want {
+		t.Errorf("Total = %v, want %v", estimate.Total, want)
+	}
+	if !hasAssumption(estimate, "wait steps") || !hasAssumption(estimate, "built-in") {
+		t.Errorf("assumptions should explain the duration and prices: %v", estimate.Assumptions)
+	}
+
+	// Once an operation of the type has completed, its duration is used.
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	engine.store = store
+	started := time.Now().Add(-3 * time.Hour)
+	completed := started.Add(2 * time.Hour)
+	if err := store.SaveOperation(ctx, &types.Operation{
+		ID:          "done",
+		Type:        types.OperationTypeInstanceTypeChange,
+		State:       types.StateCompleted,
+		ClusterID:   "demo-multi",
+		CreatedAt:   started,
+		StartedAt:   &started,
+		CompletedAt: &completed,
+	}); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	estimate, err = engine.CostEstimate(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("CostEstimate failed: %v", err)
+	}
+	if estimate.ExpectedDurationHours != 2 || estimate.Total != 1.04 {
+		t.Errorf("estimate = %v hours for %v, want 2 hours for 1.04", estimate.ExpectedDurationHours, estimate.Total)
+	}
+	if !hasAssumption(estimate, "median of 1 completed") {
+		t.Errorf("assumptions should cite the history: %v", estimate.Assumptions)
+	}
+}
+
+func TestCostEstimate_BlueGreen(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.prices = pricing.Default()
+	ctx := context.Background()
+
+	params, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: "16.4"})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeEngineUpgrade, "demo-upgrade", "us-east-1",
+		params, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	// Aurora instances report no storage, so the snapshot cannot be priced.
+	estimate, err := engine.CostEstimate(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("CostEstimate failed: %v", err)
+	}
+	cluster, _ := mockState.GetCluster("demo-upgrade")
+	if len(estimate.Items) != len(cluster.Members) {
+		t.Fatalf("Items = %+v, want a green copy of each of %d instances", estimate.Items, len(cluster.Members))
+	}
+	for _, item := range estimate.Items {
+		if !strings.HasPrefix(item.Description, "green copy of demo-upgrade-") {
+			t.Errorf("unexpected item %q", item.Description)
+		}
+	}
+	if !hasAssumption(estimate, "switchover") || !hasAssumption(estimate, "is not priced") {
+		t.Errorf("assumptions should cover the green cluster and the unpriced snapshot: %v", estimate.Assumptions)
+	}
+
+	if err := mockState.CreateInstance(&mock.MockInstance{
+		ID:               "demo-upgrade-sized",
+		ClusterID:        "demo-upgrade",
+		InstanceType:     "db.r6g.large",
+		AllocatedStorage: 100,
+	}); err != nil {
+		t.Fatalf("CreateInstance failed: %v", err)
+	}
+	estimate, err = engine.CostEstimate(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("CostEstimate failed: %v", err)
+	}
+	var snapshot *types.CostItem
+	for i, item := range estimate.Items {
+		if strings.Contains(item.Description, "snapshot") {
+			snapshot = &estimate.Items[i]
+		}
+	}
+	if snapshot == nil {
+		t.Fatalf("Items = %+v, want a snapshot item", estimate.Items)
+	}
+	days := float64(engine.snapshotRetention)
+	if days <= 0 {
+		days = assumedSnapshotRetentionDays
+	}
+	if want := roundCost(100 * days / 30 * 0.021); snapshot.Cost != want {
+		t.Errorf("snapshot cost = %v, want %v", snapshot.Cost, want)
+	}
+}
+
+func TestCostEstimate_UnknownOperation(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+	if _, err := engine.CostEstimate(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+}
@@ -0,0 +1,134 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// deadlineExemptActions are steps the operation deadline does not interrupt:
+// cutting one short would leave the cluster between writers. Once one of them
+// has started, a missed deadline pauses the operation instead of failing it,
+// since the steps after it finish what it began.
+var deadlineExemptActions = map[string]bool{
+	"failover_to_instance":  true,
+	"switchover_blue_green": true,
+	"rotate_ca_cert":        true,
+}
+
+// maxDuration returns how long an operation of the type may run, given the
+// max_duration_seconds it was created with. Zero uses the engine's default for
+// the type, and a negative value or a zero default means no deadline.
+func (e *Engine) maxDuration(opType types.OperationType, requested int) time.Duration {
+	switch {
+	case requested < 0:
+		return 0
+	case requested > 0:
+		return time.Duration(requested) * time.Second
+	case opType == types.OperationTypeEngineUpgrade && e.blueGreenDuration > 0:
+		return e.blueGreenDuration
+	default:
+		return e.maxOpDuration
+	}
+}
+
+// deadlinePassed reports whether the operation has a deadline that is behind
+// now. Callers hold e.mu.
+func deadlinePassed(op *types.Operation, now time.Time) bool {
+	return op.Deadline != nil && !now.Before(*op.Deadline)
+}
+
+// stepContext bounds a step's context by the operation deadline, except for
+// steps that must not be interrupted.
+func stepContext(ctx context.Context, op *types.Operation, step *types.Step) (context.Context, context.CancelFunc) {
+	if op.Deadline == nil || deadlineExemptActions[step.Action] {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, *op.Deadline)
+}
+
+// pastPointOfNoReturnLocked reports whether one of the operation's exempt
+// steps has started, after which stopping would leave its work half done.
+// Callers hold e.mu.
+func pastPointOfNoReturnLocked(op *types.Operation) bool {
+	for i := range op.Steps {
+		if deadlineExemptActions[op.Steps[i].Action] && op.Steps[i].StartedAt != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// stopAtDeadlineLocked stops an operation whose deadline has passed, failing
+// it or, once it is past the point of no return, pausing it without a
+// deadline for an operator to finish or roll back. step is the step the deadline interrupted, if any,
+// and stepErr what it returned. Callers hold e.mu, which is released.
+func (e *Engine) stopAtDeadlineLocked(ctx context.Context, op *types.Operation, step *types.Step, stepErr error) {
+	now := time.Now()
+	reason := fmt.Sprintf("operation passed its deadline of %s (max duration %s)",
+		op.Deadline.Format(time.RFC3339), (time.Duration(op.MaxDuration) * time.Second).String())
+	if step != nil {
+		step.State = types.StepStateFailed
+		step.Error = stepErr.Error()
+		step.WaitProgress = nil
+		step.CompletedAt = &now
+		e.recordStepOutcome(step)
+	}
+	op.UpdatedAt = now
+
+	if pastPointOfNoReturnLocked(op) {
+		op.State = types.StatePaused
+		op.PauseReason = reason + "; a failover or switchover has already started, so resume to finish the remaining steps or roll back"
+		op.PausedAt = &now
+		// Resuming has to be able to finish, so the deadline has done its job.
+		op.Deadline = nil
+		e.mu.Unlock()
+		e.persistOperation(ctx, op)
+		e.addEvent(op.ID, "deadline_exceeded", op.PauseReason, nil)
+		if e.notifier != nil {
+			e.notifier.NotifyOperationPaused(ctx, op, op.PauseReason)
+		}
+		return
+	}
+
+	op.State = types.StateFailed
+	op.Error = reason
+	op.CompletedAt = &now
+	e.mu.Unlock()
+	e.persistOperation(ctx, op)
+	e.addEvent(op.ID, "deadline_exceeded", reason, nil)
+	e.releaseOperationContext(op.ID)
+	if e.notifier != nil {
+		e.notifier.NotifyOperationFailed(ctx, op)
+	}
+}
+
+// UpdateOperationDeadline changes how long an operation may run, in seconds
+// from when it started. An operation that has started gets a new deadline; a
+// paused one that had passed its old deadline can then be resumed.
+func (e *Engine) UpdateOperationDeadline(ctx context.Context, id string, seconds int) error {
+	if seconds <= 0 {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "max duration must be positive")
+	}
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return internalerrors.ErrOperationNotFound
+	}
+	op.MaxDuration = seconds
+	if op.StartedAt != nil {
+		deadline := op.StartedAt.Add(time.Duration(seconds) * time.Second)
+		op.Deadline = &deadline
+	}
+	op.UpdatedAt = time.Now()
+	e.mu.Unlock()
+
+	e.persistOperation(ctx, op)
+	e.addEvent(id, "deadline_updated", "Max duration updated to "+(time.Duration(seconds)*time.Second).String(), nil)
+	return nil
+}
@@ -0,0 +1,180 @@
+package machine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestMaxDuration(t *testing.T) {
+	engine := NewEngine(EngineConfig{MaxOperationDuration: time.Hour, BlueGreenMaxDuration: 24 * time.Hour})
+	tests := []struct {
+		opType    types.OperationType
+		requested int
+		want      time.Duration
+	}{
+		{opType: types.OperationTypeInstanceCycle, want: time.Hour},
+		{opType: types.OperationTypeEngineUpgrade, want: 24 * time.Hour},
+		{opType: types.OperationTypeEngineUpgrade, requested: 600, want: 10 * time.Minute},
+		{opType: types.OperationTypeInstanceCycle, requested: -1, want: 0},
+	}
+	for _, tt := range tests {
+		if got := engine.maxDuration(tt.opType, tt.requested); got != tt.want {
+			t.Errorf("maxDuration(%s, %d) = %v, want %v", tt.opType, tt.requested, got, tt.want)
+		}
+	}
+}
+
+// deadlineOperation returns a running operation with the given steps whose
+// deadline is after from now, registered with the engine.
+func deadlineOperation(engine *Engine, after time.Duration, steps ...types.Step) *types.Operation {
+	now := time.Now()
+	deadline := now.Add(after)
+	op := &types.Operation{
+		ID:          "test-deadline",
+		Type:        types.OperationTypeInstanceCycle,
+		State:       types.StateRunning,
+		ClusterID:   "demo-single",
+		Region:      "us-east-1",
+		Steps:       steps,
+		MaxDuration: 60,
+		StartedAt:   &now,
+		Deadline:    &deadline,
+	}
+	engine.operations[op.ID] = op
+	return op
+}
+
+func hasEvent(engine *Engine, opID, eventType string) bool {
+	for _, ev := range engine.events[opID] {
+		if ev.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExecuteSteps_DeadlineFailsOperation(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+	ran := false
+	engine.handlers["test_noop"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		ran = true
+		return nil
+	}
+
+	op := deadlineOperation(engine, -time.Second, types.Step{Name: "No-op", Action: "test_noop", State: types.StepStatePending})
+	engine.executeSteps(context.Background(), op)
+	if op.State != types.StateFailed || !strings.Contains(op.Error, "deadline") {
+		t.Errorf("state %s, error %q; want failed on the deadline", op.State, op.Error)
+	}
+	if ran {
+		t.Error("a step ran after the deadline")
+	}
+	if !hasEvent(engine, op.ID, "deadline_exceeded") {
+		t.Error("no deadline_exceeded event")
+	}
+}
+
+func TestExecuteSteps_DeadlineInterruptsWait(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+	engine.handlers["test_wait"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	op := deadlineOperation(engine, 50*time.Millisecond,
+		types.Step{Name: "Wait", Action: "test_wait", State: types.StepStatePending, MaxRetries: 3})
+	done := make(chan struct{})
+	go func() {
+		engine.executeSteps(context.Background(), op)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the wait was not interrupted by the deadline")
+	}
+	if op.State != types.StateFailed || op.Steps[0].State != types.StepStateFailed {
+		t.Errorf("operation %s, step %s; want both failed", op.State, op.Steps[0].State)
+	}
+	if op.Steps[0].RetryCount != 0 {
+		t.Errorf("step was retried %d times after the deadline", op.Steps[0].RetryCount)
+	}
+}
+
+func TestExecuteSteps_DeadlineDuringSwitchoverPauses(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+	engine.handlers["switchover_blue_green"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		}
+	}
+	cleanedUp := false
+	engine.handlers["cleanup_blue_green"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		cleanedUp = true
+		return nil
+	}
+
+	op := deadlineOperation(engine, 20*time.Millisecond,
+		types.Step{Name: "Switchover", Action: "switchover_blue_green", State: types.StepStatePending},
+		types.Step{Name: "Cleanup", Action: "cleanup_blue_green", State: types.StepStatePending})
+	engine.executeSteps(context.Background(), op)
+
+	if op.Steps[0].State != types.StepStateCompleted {
+		t.Fatalf("switchover step is %s, want it completed despite the deadline", op.Steps[0].State)
+	}
+	if op.State != types.StatePaused || !strings.Contains(op.PauseReason, "deadline") {
+		t.Errorf("state %s, pause reason %q; want paused on the deadline", op.State, op.PauseReason)
+	}
+	if op.Deadline != nil {
+		t.Error("the deadline should be cleared so the operation can be resumed")
+	}
+	if cleanedUp {
+		t.Error("cleanup ran after the deadline")
+	}
+
+	op.State = types.StateRunning
+	engine.executeSteps(context.Background(), op)
+	if op.State != types.StateCompleted || !cleanedUp {
+		t.Errorf("resumed operation is %s, cleaned up %v; want it to finish", op.State, cleanedUp)
+	}
+}
+
+func TestStartOperation_SetsDeadline(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{MaxDuration: 3600, PollMode: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if op.MaxDuration != 3600 || op.Deadline != nil {
+		t.Fatalf("max duration %d, deadline %v; want 3600 and no deadline before starting", op.MaxDuration, op.Deadline)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	got, _ := engine.GetOperation(op.ID)
+	if got.Deadline == nil || !got.Deadline.Equal(got.StartedAt.Add(time.Hour)) {
+		t.Fatalf("deadline = %v, want an hour after %v", got.Deadline, got.StartedAt)
+	}
+
+	if err := engine.UpdateOperationDeadline(ctx, op.ID, 7200); err != nil {
+		t.Fatalf("UpdateOperationDeadline failed: %v", err)
+	}
+	got, _ = engine.GetOperation(op.ID)
+	if !got.Deadline.Equal(got.StartedAt.Add(2 * time.Hour)) {
+		t.Errorf("deadline = %v, want two hours after %v", got.Deadline, got.StartedAt)
+	}
+	if err := engine.UpdateOperationDeadline(ctx, op.ID, 0); err == nil {
+		t.Error("expected a zero max duration to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// retiredInstanceSuffix is appended to the blue instances a Blue-Green
+// switchover retires; the green instance that replaced one has its old name.
+const retiredInstanceSuffix = "-old1"
+
+// endpointRepoint records the members of a custom endpoint before and after
+// it was repointed, so the original definition can be restored by hand.
+type endpointRepoint struct {
+	Identifier     string   `json:"identifier"`
+	StaticBefore   []string `json:"static_members_before,omitempty"`
+	ExcludedBefore []string `json:"excluded_members_before,omitempty"`
+	StaticAfter    []string `json:"static_members_after,omitempty"`
+	ExcludedAfter  []string `json:"excluded_members_after,omitempty"`
+}
+
+// planEndpointRepoint works out new member lists for a custom endpoint so it
+// names only live instances. A member that is gone is replaced by the live
+// instance that took its name at switchover. A gone static member with no
+// replacement makes the endpoint unsafe to repoint, since dropping it would
+// send its traffic elsewhere; a gone excluded member is simply dropped. It
+// reports whether anything changes and, if the endpoint cannot be repointed,
+// why.
+func planEndpointRepoint(ep types.ClusterEndpoint, live map[string]bool) (static, excluded []string, changed bool, problem string) {
+	replace := func(id string) (string, bool) {
+		if live[id] {
+			return id, true
+		}
+		changed = true
+		if name, ok := strings.CutSuffix(id, retiredInstanceSuffix); ok && live[name] {
+			return name, true
+		}
+		return "", false
+	}
+
+	var missing []string
+	for _, id := range ep.StaticMembers {
+		name, ok := replace(id)
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if !slices.Contains(static, name) {
+			static = append(static, name)
+		}
+	}
+	for _, id := range ep.ExcludedMembers {
+		if name, ok := replace(id); ok && !slices.Contains(excluded, name) {
+			excluded = append(excluded, name)
+		}
+	}
+
+	switch {
+	case len(missing) > 0:
+		return nil, nil, changed, fmt.Sprintf("%s routes to %s, which will be deleted and has no replacement", ep.Identifier, strings.Join(missing, ", "))
+	case changed && ep.Status != "available":
+		return nil, nil, changed, fmt.Sprintf("%s is %s", ep.Identifier, ep.Status)
+	}
+	return static, excluded, changed, ""
+}
+
+// handleRepointCustomEndpoints points custom endpoints of the cluster that
+// name instances outside it, such as the blue instances a switchover retired,
+// at the instances that replaced them, before cleanup deletes the old ones.
+// Endpoints that cannot be repointed safely are left alone and the operation
+// pauses until an operator fixes them.
+func (e *Engine) handleRepointCustomEndpoints(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+	live := make(map[string]bool, len(info.Instances))
+	for _, inst := range info.Instances {
+		if inst.Status != "deleting" {
+			live[inst.InstanceID] = true
+		}
+	}
+
+	endpoints, err := rdsClient.ListClusterEndpoints(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "list cluster endpoints")
+	}
+
+	repointed := []endpointRepoint{}
+	var problems []string
+	for _, ep := range endpoints {
+		if ep.EndpointType != rds.EndpointTypeCustom {
+			continue
+		}
+		static, excluded, changed, problem := planEndpointRepoint(ep, live)
+		if problem != "" {
+			problems = append(problems, problem)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		// An emptied exclusion list has to be sent to be cleared.
+		if static == nil && excluded == nil {
+			excluded = []string{}
+		}
+		if _, err := rdsClient.ModifyClusterEndpoint(ctx, ep.Identifier, static, excluded); err != nil {
+			return errors.Wrapf(err, "repoint custom endpoint %s", ep.Identifier)
+		}
+		repointed = append(repointed, endpointRepoint{
+			Identifier:     ep.Identifier,
+			StaticBefore:   ep.StaticMembers,
+			ExcludedBefore: ep.ExcludedMembers,
+			StaticAfter:    static,
+			ExcludedAfter:  excluded,
+		})
+		e.addEvent(op.ID, "info", fmt.Sprintf("Repointed custom endpoint %s to %s", ep.Identifier, describeEndpointMembers(static, excluded)), nil)
+	}
+
+	result, _ := json.Marshal(map[string]any{"repointed": repointed})
+	step.Result = result
+
+	if len(problems) > 0 {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"custom endpoints cannot be repointed safely (%s); change their members so they name no old instance, then continue",
+			strings.Join(problems, "; "))
+	}
+	if len(repointed) == 0 {
+		e.addEvent(op.ID, "info", "No custom endpoints name instances outside the cluster", nil)
+	}
+	return nil
+}
+
+// describeEndpointMembers renders an endpoint's member lists for an event.
+func describeEndpointMembers(static, excluded []string) string {
+	switch {
+	case len(static) > 0:
+		return strings.Join(static, ", ")
+	case len(excluded) > 0:
+		return "all instances except " + strings.Join(excluded, ", ")
+	}
+	return "all instances"
+}
@@ -0,0 +1,166 @@
+package machine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestPlanEndpointRepoint(t *testing.T) {
+	live := map[string]bool{"writer": true, "reader-1": true, "reader-2": true}
+	tests := []struct {
+		name         string
+		ep           types.ClusterEndpoint
+		wantStatic   []string
+		wantExcluded []string
+		wantChanged  bool
+		wantProblem  bool
+	}{
+		{
+			name:       "live members untouched",
+			ep:         types.ClusterEndpoint{StaticMembers: []string{"reader-1"}, Status: "available"},
+			wantStatic: []string{"reader-1"},
+		},
+		{
+			name:        "retired static member replaced",
+			ep:          types.ClusterEndpoint{StaticMembers: []string{"reader-1-old1", "reader-2"}, Status: "available"},
+			wantStatic:  []string{"reader-1", "reader-2"},
+			wantChanged: true,
+		},
+		{
+			name:        "replacement already listed",
+			ep:          types.ClusterEndpoint{StaticMembers: []string{"reader-1-old1", "reader-1"}, Status: "available"},
+			wantStatic:  []string{"reader-1"},
+			wantChanged: true,
+		},
+		{
+			name:         "retired exclusion replaced",
+			ep:           types.ClusterEndpoint{ExcludedMembers: []string{"writer-old1"}, Status: "available"},
+			wantExcluded: []string{"writer"},
+			wantChanged:  true,
+		},
+		{
+			name:        "exclusion without replacement dropped",
+			ep:          types.ClusterEndpoint{ExcludedMembers: []string{"reader-3-old1"}, Status: "available"},
+			wantChanged: true,
+		},
+		{
+			name:        "static member without replacement",
+			ep:          types.ClusterEndpoint{StaticMembers: []string{"reader-3-old1", "reader-1"}, Status: "available"},
+			wantChanged: true,
+			wantProblem: true,
+		},
+		{
+			name:        "endpoint busy",
+			ep:          types.ClusterEndpoint{StaticMembers: []string{"reader-1-old1"}, Status: "modifying"},
+			wantChanged: true,
+			wantProblem: true,
+		},
+		{
+			name:       "busy endpoint with nothing to change",
+			ep:         types.ClusterEndpoint{StaticMembers: []string{"reader-1"}, Status: "modifying"},
+			wantStatic: []string{"reader-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			static, excluded, changed, problem := planEndpointRepoint(tt.ep, live)
+			if changed != tt.wantChanged || (problem != "") != tt.wantProblem {
+				t.Fatalf("changed = %v, problem = %q; want changed %v, problem %v", changed, problem, tt.wantChanged, tt.wantProblem)
+			}
+			if !slices.Equal(static, tt.wantStatic) || !slices.Equal(excluded, tt.wantExcluded) {
+				t.Errorf("static = %v, excluded = %v; want %v, %v", static, excluded, tt.wantStatic, tt.wantExcluded)
+			}
+		})
+	}
+}
+
+func TestRepointCustomEndpoints_AfterSwitchover(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	bg, err := mockState.CreateBlueGreenDeployment("endpoints", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus := func(status string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if current, _ := mockState.GetBlueGreenDeployment(bg.Identifier); current.Status == status {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("deployment did not reach %s", status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForStatus("AVAILABLE")
+	if err := mockState.SwitchoverBlueGreenDeployment(bg.Identifier); err != nil {
+		t.Fatalf("SwitchoverBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus("SWITCHOVER_COMPLETED")
+
+	members := func() []string {
+		ep, _ := mockState.GetClusterEndpoint("demo-upgrade-analytics")
+		return ep.StaticMembers
+	}
+	if got := members(); !slices.Equal(got, []string{"demo-upgrade-reader-1-old1"}) {
+		t.Fatalf("after switchover the endpoint names %v, want the retired reader", got)
+	}
+
+	newOp := func(id string) (*types.Operation, *types.Step) {
+		op := &types.Operation{
+			ID:        id,
+			ClusterID: "demo-upgrade",
+			Region:    "us-east-1",
+			Steps:     []types.Step{{Name: "Repoint custom endpoints", Action: "repoint_custom_endpoints"}},
+		}
+		return op, &op.Steps[0]
+	}
+
+	t.Run("endpoint busy", func(t *testing.T) {
+		if err := mockState.SetClusterEndpointStatus("demo-upgrade-analytics", "modifying"); err != nil {
+			t.Fatal(err)
+		}
+		defer mockState.SetClusterEndpointStatus("demo-upgrade-analytics", "available")
+
+		op, step := newOp("op-endpoint-busy")
+		err := engine.handleRepointCustomEndpoints(ctx, op, step)
+		if !errors.Is(err, internalerrors.ErrInterventionRequired) || !containsAny(err.Error(), "demo-upgrade-analytics is modifying") {
+			t.Fatalf("err = %v, want an intervention naming the busy endpoint", err)
+		}
+		if got := members(); !slices.Equal(got, []string{"demo-upgrade-reader-1-old1"}) {
+			t.Errorf("busy endpoint changed to %v", got)
+		}
+	})
+
+	t.Run("repointed", func(t *testing.T) {
+		op, step := newOp("op-endpoint-repoint")
+		if err := engine.handleRepointCustomEndpoints(ctx, op, step); err != nil {
+			t.Fatalf("handleRepointCustomEndpoints failed: %v", err)
+		}
+		if got := members(); !slices.Equal(got, []string{"demo-upgrade-reader-1"}) {
+			t.Errorf("endpoint names %v, want the live reader", got)
+		}
+		if !containsAny(string(step.Result), `"static_members_before":["demo-upgrade-reader-1-old1"]`) {
+			t.Errorf("result does not record the old members: %s", step.Result)
+		}
+
+		// A second run finds nothing left to do.
+		op, step = newOp("op-endpoint-again")
+		if err := engine.handleRepointCustomEndpoints(ctx, op, step); err != nil {
+			t.Fatalf("second run failed: %v", err)
+		}
+		if string(step.Result) != `{"repointed":[]}` {
+			t.Errorf("second run result = %s, want nothing repointed", step.Result)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// desiredEndState is what an operation leaves its cluster with. Empty fields
+// are not checked.
+type desiredEndState struct {
+	instanceType           string
+	storageType            string
+	engineVersion          string
+	clusterParameterGroup  string
+	instanceParameterGroup string
+	exclude                []string
+}
+
+// desiredEndStateFor returns the end state an operation asks for, from its
+// parameters. Operation types whose result cannot be read off the cluster's
+// configuration are rejected.
+func desiredEndStateFor(op *types.Operation) (desiredEndState, error) {
+	var want desiredEndState
+	switch op.Type {
+	case types.OperationTypeInstanceTypeChange:
+		var params types.InstanceTypeChangeParams
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return want, errors.Wrap(err, "unmarshal params")
+		}
+		want.instanceType = params.TargetInstanceType
+		want.instanceParameterGroup = params.TargetInstanceParameterGroupName
+		want.exclude = params.ExcludeInstances
+	case types.OperationTypeStorageTypeChange:
+		var params types.StorageTypeChangeParams
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return want, errors.Wrap(err, "unmarshal params")
+		}
+		want.storageType = params.TargetStorageType
+		want.exclude = params.ExcludeInstances
+	case types.OperationTypeEngineUpgrade:
+		var params types.EngineUpgradeParams
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return want, errors.Wrap(err, "unmarshal params")
+		}
+		want.engineVersion = params.TargetEngineVersion
+		want.clusterParameterGroup = params.DBClusterParameterGroupName
+		want.instanceParameterGroup = params.DBInstanceParameterGroupName
+	case types.OperationTypeMinorVersionUpgrade:
+		var params types.MinorVersionUpgradeParams
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return want, errors.Wrap(err, "unmarshal params")
+		}
+		want.engineVersion = params.TargetEngineVersion
+		want.clusterParameterGroup = params.DBClusterParameterGroupName
+	default:
+		return want, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s operations have no end state to verify", op.Type)
+	}
+	return want, nil
+}
+
+// compare checks a described cluster against the desired end state. Instances
+// that are excluded, autoscaled, created by the operation or being deleted
+// are left out: the operation does not change them.
+func (want desiredEndState) compare(info *types.ClusterInfo, createdInstance string) *types.EndStateReport {
+	report := &types.EndStateReport{CheckedAt: time.Now()}
+	mismatch := func(resource, field, expected, actual string) {
+		report.Mismatches = append(report.Mismatches, types.EndStateMismatch{
+			Resource: resource, Field: field, Expected: expected, Actual: actual,
+		})
+	}
+
+	if want.engineVersion != "" {
+		report.Checked = append(report.Checked, "engine_version")
+		if info.EngineVersion != want.engineVersion {
+			mismatch(info.ClusterID, "engine_version", want.engineVersion, info.EngineVersion)
+		}
+	}
+	if want.clusterParameterGroup != "" {
+		report.Checked = append(report.Checked, "db_cluster_parameter_group_name")
+		if info.ParameterGroupName != want.clusterParameterGroup {
+			mismatch(info.ClusterID, "db_cluster_parameter_group_name", want.clusterParameterGroup, info.ParameterGroupName)
+		}
+	}
+
+	instanceFields := []struct {
+		name   string
+		wanted string
+		actual func(types.InstanceInfo) string
+	}{
+		{"instance_type", want.instanceType, func(i types.InstanceInfo) string { return i.InstanceType }},
+		{"storage_type", want.storageType, func(i types.InstanceInfo) string { return i.StorageType }},
+		{"db_parameter_group_name", want.instanceParameterGroup, func(i types.InstanceInfo) string { return i.ParameterGroupName }},
+	}
+	checkInstances := false
+	for _, field := range instanceFields {
+		if field.wanted != "" {
+			report.Checked = append(report.Checked, field.name)
+			checkInstances = true
+		}
+	}
+	if !checkInstances {
+		return report
+	}
+
+	for _, inst := range info.Instances {
+		if inst.IsAutoScaled || inst.InstanceID == createdInstance || slices.Contains(want.exclude, inst.InstanceID) ||
+			rds.InstanceStatus(inst.Status).IsDeleting() {
+			continue
+		}
+		report.Instances = append(report.Instances, inst.InstanceID)
+		for _, field := range instanceFields {
+			if field.wanted != "" && field.actual(inst) != field.wanted {
+				mismatch(inst.InstanceID, field.name, field.wanted, field.actual(inst))
+			}
+		}
+	}
+	return report
+}
+
+// VerifyEndState describes the operation's cluster again and compares it
+// with the end state the operation asked for: the instance class, storage
+// type, engine version and parameter groups it set. The report is recorded
+// on the operation, and any mismatch is added to its warnings, since it
+// means RDS applied a change differently than requested.
+func (e *Engine) VerifyEndState(ctx context.Context, op *types.Operation) (*types.EndStateReport, error) {
+	want, err := desiredEndStateFor(op)
+	if err != nil {
+		return nil, err
+	}
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get cluster info")
+	}
+
+	e.mu.RLock()
+	createdInstance := e.findCreatedInstanceID(op)
+	e.mu.RUnlock()
+	report := want.compare(info, createdInstance)
+
+	e.mu.Lock()
+	op.EndState = report
+	op.UpdatedAt = time.Now()
+	for _, m := range report.Mismatches {
+		if warning := describeMismatch(m); !slices.Contains(op.Warnings, warning) {
+			op.Warnings = append(op.Warnings, warning)
+		}
+	}
+	e.mu.Unlock()
+	e.persistOperation(ctx, op)
+
+	if report.Matches() {
+		e.addEvent(op.ID, "end_state_verified",
+			fmt.Sprintf("Cluster matches the intended end state (%s)", strings.Join(report.Checked, ", ")), nil)
+		return report, nil
+	}
+	data, _ := json.Marshal(report.Mismatches)
+	descriptions := make([]string, 0, len(report.Mismatches))
+	for _, m := range report.Mismatches {
+		descriptions = append(descriptions, describeMismatch(m))
+	}
+	e.addEvent(op.ID, "end_state_mismatch",
+		"Cluster differs from the intended end state: "+strings.Join(descriptions, "; "), data)
+	return report, nil
+}
+
+// describeMismatch renders a mismatch for warnings and events.
+func describeMismatch(m types.EndStateMismatch) string {
+	return fmt.Sprintf("%s %s is %q, expected %q", m.Resource, m.Field, m.Actual, m.Expected)
+}
+
+// handleVerifyEndState runs VerifyEndState as an operation's last step. A
+// mismatch is reported as a warning rather than failing the operation: the
+// changes have been made, and nothing the step could retry would fix them.
+func (e *Engine) handleVerifyEndState(ctx context.Context, op *types.Operation, step *types.Step) error {
+	report, err := e.VerifyEndState(ctx, op)
+	if err != nil {
+		return err
+	}
+	result, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "marshal end state report")
+	}
+	step.Result = result
+	return nil
+}
+
+// verifyEndStateStep is the last step of the type change operations.
+func verifyEndStateStep() types.Step {
+	return types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Verify end state",
+		Description: "Compare the cluster with the intended end state",
+		State:       types.StepStatePending,
+		Action:      "verify_end_state",
+		MaxRetries:  2,
+	}
+}
@@ -0,0 +1,125 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestDesiredEndState_Compare(t *testing.T) {
+	info := &types.ClusterInfo{
+		ClusterID:          "c1",
+		EngineVersion:      "15.4",
+		ParameterGroupName: "c1-pg",
+		Instances: []types.InstanceInfo{
+			{InstanceID: "writer", InstanceType: "db.r6g.xlarge", ParameterGroupName: "pg-16", Status: "available"},
+			{InstanceID: "reader", InstanceType: "db.r6g.large", ParameterGroupName: "pg-15", Status: "available"},
+			{InstanceID: "excluded", InstanceType: "db.r6g.large", Status: "available"},
+			{InstanceID: "asg", InstanceType: "db.r6g.large", IsAutoScaled: true, Status: "available"},
+			{InstanceID: "temp", InstanceType: "db.r6g.large", Status: "available"},
+			{InstanceID: "gone", InstanceType: "db.r6g.large", Status: "deleting"},
+		},
+	}
+
+	want := desiredEndState{
+		instanceType:           "db.r6g.xlarge",
+		instanceParameterGroup: "pg-16",
+		engineVersion:          "16.1",
+		exclude:                []string{"excluded"},
+	}
+	report := want.compare(info, "temp")
+	if !slices.Equal(report.Instances, []string{"writer", "reader"}) {
+		t.Errorf("instances checked = %v, want writer and reader only", report.Instances)
+	}
+	wantMismatches := []types.EndStateMismatch{
+		{Resource: "c1", Field: "engine_version", Expected: "16.1", Actual: "15.4"},
+		{Resource: "reader", Field: "instance_type", Expected: "db.r6g.xlarge", Actual: "db.r6g.large"},
+		{Resource: "reader", Field: "db_parameter_group_name", Expected: "pg-16", Actual: "pg-15"},
+	}
+	if !slices.Equal(report.Mismatches, wantMismatches) {
+		t.Errorf("mismatches = %+v, want %+v", report.Mismatches, wantMismatches)
+	}
+
+	if report := (desiredEndState{clusterParameterGroup: "c1-pg"}).compare(info, ""); !report.Matches() || len(report.Instances) != 0 {
+		t.Errorf("cluster-only check = %+v, want a match without instances", report)
+	}
+}
+
+func TestVerifyEndState(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{
+		TargetInstanceType: "db.r6g.xlarge",
+		ExcludeInstances:   []string{"demo-multi-reader-2"},
+	})
+	op := &types.Operation{
+		ID:         "op-end-state",
+		Type:       types.OperationTypeInstanceTypeChange,
+		ClusterID:  "demo-multi",
+		Region:     "us-east-1",
+		Parameters: params,
+	}
+	engine.operations[op.ID] = op
+
+	report, err := engine.VerifyEndState(ctx, op)
+	if err != nil {
+		t.Fatalf("VerifyEndState failed: %v", err)
+	}
+	if len(report.Mismatches) != 2 || op.EndState != report {
+		t.Errorf("report = %+v, want the writer and reader-1 to differ and the report on the operation", report)
+	}
+	if len(op.Warnings) != 2 {
+		t.Errorf("warnings = %v, want one per mismatch", op.Warnings)
+	}
+
+	// Verifying again records the new report without repeating warnings.
+	if _, err := engine.VerifyEndState(ctx, op); err != nil {
+		t.Fatalf("VerifyEndState failed: %v", err)
+	}
+	if len(op.Warnings) != 2 {
+		t.Errorf("warnings after a second check = %v, want them not repeated", op.Warnings)
+	}
+	events, _ := engine.GetEvents(op.ID)
+	if len(events) == 0 || events[len(events)-1].Type != "end_state_mismatch" ||
+		events[len(events)-1].Severity != types.EventSeverityWarning {
+		t.Errorf("last event = %+v, want an end_state_mismatch warning", events)
+	}
+
+	cycle := &types.Operation{ID: "op-cycle", Type: types.OperationTypeInstanceCycle, ClusterID: "demo-multi", Region: "us-east-1"}
+	if _, err := engine.VerifyEndState(ctx, cycle); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("instance cycle: error = %v, want ErrInvalidParameter", err)
+	}
+}
+
+func TestInstanceTypeChange_VerifiesEndState(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge", SkipTempInstance: true})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if last := op.Steps[len(op.Steps)-1]; last.Action != "verify_end_state" {
+		t.Fatalf("last step = %s, want verify_end_state", last.Action)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	if op.EndState == nil || !op.EndState.Matches() || len(op.EndState.Instances) != 3 {
+		t.Errorf("end state = %+v, want all three instances to match", op.EndState)
+	}
+}
@@ -7,12 +7,21 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/google/uuid"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/autoscaling"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/cloudwatch"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
 	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/metrics"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/pricing"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
@@ -28,11 +37,73 @@ type Engine struct {
 	logger        *slog.Logger
 	handlers      map[string]StepHandler
 	notifier      Notifier
+	metrics       *metrics.Registry
+
+	// subscribers receive live updates per operation; lastProgress is the
+	// last progress snapshot sent for each, used to skip duplicates.
+	subMu        sync.Mutex
+	subscribers  map[string]map[chan OperationUpdate]struct{}
+	lastProgress map[string]types.OperationProgress
+
+	// templates holds saved operation definitions keyed by template ID.
+	templates map[string]*types.Template
+
+	// batches holds the batch operations keyed by batch ID.
+	batches map[string]*types.BatchOperation
+
+	// runContexts holds the execution context of each operation that has
+	// been started, so CancelOperation can interrupt in-flight steps.
+	runContexts map[string]runContext
+
+	// draining is set by Drain; no operation is created or started after it.
+	// executors counts the step executors runSteps has started, so Drain
+	// can wait for them to stop.
+	draining  bool
+	executors sync.WaitGroup
+
+	// pendingKeys holds the idempotency keys of creates that are still
+	// building their plan.
+	pendingKeys map[string]bool
+
+	// polling holds the operations in poll mode a PollOperation call is
+	// currently advancing.
+	polling map[string]bool
+
+	// scheduleTimers start scheduled operations when their window opens.
+	// Created on first use.
+	scheduleTimers map[string]*time.Timer
 
 	// Configuration
 	defaultRegion       string
+	allowedRegions      []string
+	clusterAllowlist    []string
+	clusterDenylist     []string
+	alarmPatterns       []string
+	idempotencyTTL      time.Duration
 	defaultWaitTimeout  time.Duration
 	defaultPollInterval time.Duration
+	initialPollDelay    time.Duration
+	maxPollInterval     time.Duration
+	clock               clock // nil uses the real clock
+	modifyVerifyPolls   int
+	tempFinalSnapshot   bool
+	snapshotRetention   int
+	defaultStorageType  string
+	maintenanceWindow   *maintwindow.Schedule
+	maxConcurrentOps    int
+	restoreValidator    RestoreValidator
+	instanceLimit       int
+	orphanTTL           time.Duration
+	prices              *pricing.Table
+	majorApproval       bool
+	maxOpDuration       time.Duration
+	blueGreenDuration   time.Duration
+}
+
+// runContext is the cancellable context steps of an operation run under.
+type runContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // StepHandler is a function that executes a single step.
@@ -47,6 +118,12 @@ type Notifier interface {
 	NotifyStepCompleted(ctx context.Context, op *types.Operation, step *types.Step) error
 }
 
+// RestoreValidator runs the validation query of a snapshot restore test
+// against the restored cluster. An error fails the test.
+type RestoreValidator interface {
+	ValidateRestore(ctx context.Context, region, clusterID, query string) error
+}
+
 // EngineConfig contains configuration for the engine.
 type EngineConfig struct {
 	ClientManager       *rds.ClientManager
@@ -56,6 +133,95 @@ type EngineConfig struct {
 	DefaultRegion       string
 	DefaultWaitTimeout  time.Duration
 	DefaultPollInterval time.Duration
+
+	// AllowedRegions limits the regions operations may be created in. Empty
+	// allows any region.
+	AllowedRegions []string
+
+	// ClusterAllowlist limits the clusters operations may be created on to
+	// those matching one of its path.Match patterns. Empty allows any.
+	ClusterAllowlist []string
+	// ClusterDenylist rejects operations on clusters matching any of its
+	// patterns, whatever the allowlist says.
+	ClusterDenylist []string
+
+	// IdempotencyTTL is how long an idempotency key returns the operation it
+	// created. Zero uses the default of 24 hours.
+	IdempotencyTTL time.Duration
+
+	// AlarmNamePatterns are the default CloudWatch alarm name patterns the
+	// check_alarms step gates a switchover on. Empty checks every alarm on
+	// the cluster.
+	AlarmNamePatterns []string
+
+	// InitialPollDelay holds off the first poll of a wait step, since RDS can
+	// report the pre-change status for a while after accepting a change.
+	InitialPollDelay time.Duration
+
+	// MaxPollInterval caps the backoff between polls of a wait step, which
+	// start at the poll interval and grow while the condition is unmet. Zero
+	// keeps polling at the poll interval.
+	MaxPollInterval time.Duration
+
+	// ModifyVerifyPolls is how many polls an instance may sit available but not
+	// at its target config before the modify is re-issued. Negative disables it.
+	ModifyVerifyPolls int
+
+	// TempFinalSnapshot takes a cluster snapshot before deleting temp instances.
+	TempFinalSnapshot bool
+
+	// SnapshotRetentionDays is how long pre-upgrade snapshots are kept before
+	// snapshot cleanup deletes them. Zero uses the default; negative keeps
+	// them indefinitely.
+	SnapshotRetentionDays int
+
+	// DefaultStorageType is used when a storage type change omits its target.
+	DefaultStorageType string
+
+	// Metrics records operation and step outcomes. Nil disables recording.
+	Metrics *metrics.Registry
+
+	// MaintenanceWindow restricts when new operations may be created. Nil
+	// allows them at any time. Operations already running are not affected.
+	MaintenanceWindow *maintwindow.Schedule
+
+	// MaxConcurrentOperations caps how many operations may be active at once
+	// across all clusters. Zero is unlimited.
+	MaxConcurrentOperations int
+
+	// RestoreValidator runs snapshot restore test validation queries. Nil
+	// rejects restore tests that ask for one.
+	RestoreValidator RestoreValidator
+
+	// ClusterInstanceLimit is the most instances a cluster may have, used to
+	// check there is room for a temp instance before creating one. Zero uses
+	// the Aurora limit of 15.
+	ClusterInstanceLimit int
+
+	// OrphanTTL is how old a temp instance whose operation the engine does
+	// not know must be before ReconcileOrphans deletes it. Zero uses the
+	// default of 24 hours.
+	OrphanTTL time.Duration
+
+	// PriceTable prices cost estimates. Nil uses the built-in table.
+	PriceTable *pricing.Table
+
+	// MajorUpgradeApproval makes every engine upgrade that changes the major
+	// version require approval, as if it was created with RequiresApproval.
+	MajorUpgradeApproval bool
+
+	// MaxOperationDuration is how long an operation may run before it is
+	// stopped, unless it was created with its own max duration. Zero is
+	// unlimited.
+	MaxOperationDuration time.Duration
+
+	// BlueGreenMaxDuration replaces MaxOperationDuration for engine upgrades,
+	// whose green environment alone can take hours to provision.
+	BlueGreenMaxDuration time.Duration
+
+	// Templates are configured operation templates (see LoadTemplates),
+	// available alongside the saved ones.
+	Templates []*types.Template
 }
 
 // NewEngine creates a new state machine engine.
@@ -68,9 +234,36 @@ func NewEngine(cfg EngineConfig) *Engine {
 		logger:              cfg.Logger,
 		handlers:            make(map[string]StepHandler),
 		notifier:            cfg.Notifier,
+		metrics:             cfg.Metrics,
+		runContexts:         make(map[string]runContext),
+		templates:           make(map[string]*types.Template),
+		batches:             make(map[string]*types.BatchOperation),
 		defaultRegion:       cfg.DefaultRegion,
+		allowedRegions:      cfg.AllowedRegions,
+		clusterAllowlist:    cfg.ClusterAllowlist,
+		clusterDenylist:     cfg.ClusterDenylist,
+		alarmPatterns:       cfg.AlarmNamePatterns,
+		idempotencyTTL:      cfg.IdempotencyTTL,
 		defaultWaitTimeout:  cfg.DefaultWaitTimeout,
 		defaultPollInterval: cfg.DefaultPollInterval,
+		initialPollDelay:    cfg.InitialPollDelay,
+		maxPollInterval:     cfg.MaxPollInterval,
+		modifyVerifyPolls:   cfg.ModifyVerifyPolls,
+		tempFinalSnapshot:   cfg.TempFinalSnapshot,
+		snapshotRetention:   cfg.SnapshotRetentionDays,
+		defaultStorageType:  cfg.DefaultStorageType,
+		maintenanceWindow:   cfg.MaintenanceWindow,
+		maxConcurrentOps:    cfg.MaxConcurrentOperations,
+		restoreValidator:    cfg.RestoreValidator,
+		instanceLimit:       cfg.ClusterInstanceLimit,
+		orphanTTL:           cfg.OrphanTTL,
+		prices:              cfg.PriceTable,
+		majorApproval:       cfg.MajorUpgradeApproval,
+		maxOpDuration:       cfg.MaxOperationDuration,
+		blueGreenDuration:   cfg.BlueGreenMaxDuration,
+	}
+	for _, tmpl := range cfg.Templates {
+		e.templates[tmpl.ID] = tmpl
 	}
 
 	if e.logger == nil {
@@ -82,12 +275,21 @@ func NewEngine(cfg EngineConfig) *Engine {
 	if e.defaultPollInterval == 0 {
 		e.defaultPollInterval = 30 * time.Second
 	}
+	if e.modifyVerifyPolls == 0 {
+		e.modifyVerifyPolls = constants.DefaultModifyVerifyPolls
+	}
+	if e.snapshotRetention == 0 {
+		e.snapshotRetention = constants.DefaultSnapshotRetentionDays
+	}
 	if e.defaultRegion == "" {
 		e.defaultRegion = "us-east-1"
 	}
 	if e.store == nil {
 		e.store = &storage.NullStore{}
 	}
+	if e.prices == nil {
+		e.prices = pricing.Default()
+	}
 
 	// Register default step handlers
 	e.registerHandlers()
@@ -98,8 +300,11 @@ func NewEngine(cfg EngineConfig) *Engine {
 // registerHandlers registers the step handlers.
 func (e *Engine) registerHandlers() {
 	e.handlers["get_cluster_info"] = e.handleGetClusterInfo
+	e.handlers["preflight_check"] = e.handlePreflightCheck
+	e.handlers["check_alarms"] = e.handleCheckAlarms
 	e.handlers["create_temp_instance"] = e.handleCreateTempInstance
 	e.handlers["wait_instance_available"] = e.handleWaitInstanceAvailable
+	e.handlers["wait_instances_available"] = e.handleWaitInstancesAvailable
 	e.handlers["failover_to_instance"] = e.handleFailoverToInstance
 	e.handlers["modify_instance"] = e.handleModifyInstance
 	e.handlers["delete_instance"] = e.handleDeleteInstance
@@ -107,13 +312,21 @@ func (e *Engine) registerHandlers() {
 	e.handlers["create_snapshot"] = e.handleCreateSnapshot
 	e.handlers["wait_snapshot_available"] = e.handleWaitSnapshotAvailable
 	e.handlers["modify_cluster"] = e.handleModifyCluster
+	e.handlers["modify_serverless_scaling"] = e.handleModifyServerlessScaling
+	e.handlers["modify_instance_monitoring"] = e.handleModifyInstanceMonitoring
 	e.handlers["wait_cluster_available"] = e.handleWaitClusterAvailable
+	e.handlers["verify_end_state"] = e.handleVerifyEndState
 	e.handlers["prepare_parameter_group"] = e.handlePrepareParameterGroup
+	e.handlers["apply_parameter_group"] = e.handleApplyParameterGroup
 
 	// Blue-Green deployment handlers
+	e.handlers["check_upgrade_prerequisites"] = e.handleCheckUpgradePrerequisites
 	e.handlers["create_blue_green_deployment"] = e.handleCreateBlueGreenDeployment
 	e.handlers["wait_blue_green_available"] = e.handleWaitBlueGreenAvailable
 	e.handlers["switchover_blue_green"] = e.handleSwitchoverBlueGreen
+	e.handlers["repoint_custom_endpoints"] = e.handleRepointCustomEndpoints
+	e.handlers["suspend_autoscaling"] = e.handleSuspendAutoscaling
+	e.handlers["resume_autoscaling"] = e.handleResumeAutoscaling
 	e.handlers["cleanup_blue_green"] = e.handleCleanupBlueGreen
 
 	// RDS Proxy handlers
@@ -124,38 +337,92 @@ func (e *Engine) registerHandlers() {
 
 	// Instance cycle handlers
 	e.handlers["reboot_instance"] = e.handleRebootInstance
+
+	// CA certificate rotation handlers
+	e.handlers["rotate_ca_cert"] = e.handleRotateCACert
+
+	// Pending maintenance handlers
+	e.handlers["apply_pending_maintenance"] = e.handleApplyPendingMaintenance
+
+	// Snapshot restore test handlers
+	e.handlers["restore_snapshot"] = e.handleRestoreSnapshot
+	e.handlers["create_restore_instance"] = e.handleCreateRestoreInstance
+	e.handlers["validate_restore"] = e.handleValidateRestore
+	e.handlers["delete_restored_cluster"] = e.handleDeleteRestoredCluster
 }
 
 // LoadFromStore loads all operations and events from persistent storage.
-// Returns a list of operation IDs that were in running state and need to be resumed.
+// Returns a list of operation IDs that were in progress (running, rolling
+// back, or cancelling) when the server stopped. Nothing drives these after a restart, so the
+// caller must pass them to ResumeRunningOperations.
 func (e *Engine) LoadFromStore(ctx context.Context) ([]string, error) {
 	operations, events, err := e.store.LoadAll(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "load from store")
 	}
 
+	templates, err := e.store.ListTemplates(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load templates from store")
+	}
+
+	batches, err := e.store.ListBatches(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load batches from store")
+	}
+
+	for _, opEvents := range events {
+		for i := range opEvents {
+			if opEvents[i].Severity == "" {
+				opEvents[i].Severity = eventSeverity(opEvents[i].Type)
+			}
+		}
+	}
+
 	e.mu.Lock()
 	e.operations = operations
 	e.events = events
+	configured := e.templates
+	e.templates = make(map[string]*types.Template, len(templates)+len(configured))
+	for id, tmpl := range configured {
+		if tmpl.Configured {
+			e.templates[id] = tmpl
+		}
+	}
+	for _, tmpl := range templates {
+		e.templates[tmpl.ID] = tmpl
+	}
+	e.batches = make(map[string]*types.BatchOperation, len(batches))
+	for _, batch := range batches {
+		e.batches[batch.ID] = batch
+	}
+	scheduled := e.armLoadedSchedulesLocked()
 	e.mu.Unlock()
 
 	// Find operations that need to be resumed
 	var runningOps []string
 	for id, op := range operations {
-		if op.State == types.StateRunning {
+		if op.State == types.StateRunning || op.State == types.StateRollingBack || op.State == types.StateCancelling {
 			runningOps = append(runningOps, id)
 		}
 	}
 
 	e.logger.Info("loaded state from storage",
 		slog.Int("operations", len(operations)),
+		slog.Int("templates", len(templates)),
+		slog.Int("batches", len(batches)),
+		slog.Int("scheduled", scheduled),
 		slog.Int("running", len(runningOps)))
 
 	return runningOps, nil
 }
 
-// ResumeRunningOperations resumes operations that were running when the server stopped.
-// If autoResume is false, it pauses them instead with a "server restarted" reason.
+// ResumeRunningOperations adopts operations that were in progress when the server stopped.
+// If autoResume is true, execution (or rollback) continues from where it left off.
+// Otherwise the operation is paused with a reason naming the interrupted step, and that
+// step is reset to pending so that a "continue" re-runs it from the beginning.
+// A cancellation that was interrupted is always carried through, since the
+// operator has already asked for the operation to stop.
 func (e *Engine) ResumeRunningOperations(ctx context.Context, operationIDs []string, autoResume bool) {
 	for _, id := range operationIDs {
 		e.mu.Lock()
@@ -165,41 +432,155 @@ func (e *Engine) ResumeRunningOperations(ctx context.Context, operationIDs []str
 			continue
 		}
 
-		if autoResume {
-			e.logger.Info("auto-resuming operation", slog.String("operation_id", id))
+		if op.State == types.StateCancelling {
 			e.mu.Unlock()
-			e.addEvent(id, "operation_resumed", "Operation auto-resumed after server restart", nil)
-			go e.executeSteps(context.Background(), op)
-		} else {
-			e.logger.Info("pausing operation after restart", slog.String("operation_id", id))
-			op.State = types.StatePaused
-			op.PauseReason = "Server restarted - manual resume required"
-			op.UpdatedAt = time.Now()
+			e.addEvent(id, "operation_cancelling", "Finishing cancellation after server restart", nil)
+			go e.finishCancellation(context.Background(), op)
+			continue
+		}
+
+		// Polls carry an operation in poll mode on across a restart without
+		// anyone having to resume it.
+		if op.PollMode && op.State == types.StateRunning {
 			e.mu.Unlock()
-			e.addEvent(id, "operation_paused", "Server restarted - manual resume required", nil)
-			e.persistOperation(ctx, op)
+			continue
+		}
+
+		if autoResume {
+			e.logger.Info("auto-resuming operation",
+				slog.String("operation_id", id),
+				slog.String("state", string(op.State)))
+			rollingBack := op.State == types.StateRollingBack
+			e.mu.Unlock()
+			if rollingBack {
+				e.addEvent(id, "rollback_started", "Rollback auto-resumed after server restart", nil)
+				go e.executeRollback(context.Background(), op)
+			} else {
+				e.addEvent(id, "operation_resumed", "Operation auto-resumed after server restart", nil)
+				e.runSteps(op)
+			}
+			continue
+		}
+
+		reason := "Server restarted - manual resume required"
+		if op.State == types.StateRollingBack {
+			reason = "Server restarted during rollback - manual resume required"
+		} else if op.CurrentStepIndex < len(op.Steps) {
+			step := &op.Steps[op.CurrentStepIndex]
+			reason = fmt.Sprintf("Server restarted during step %d: %s - manual resume required", op.CurrentStepIndex+1, step.Name)
+			if step.State == types.StepStateInProgress || step.State == types.StepStateWaiting {
+				step.State = types.StepStatePending
+				step.WaitCondition = ""
+			}
 		}
+
+		e.logger.Info("pausing operation after restart", slog.String("operation_id", id))
+		op.State = types.StatePaused
+		op.PauseReason = reason
+		pausedAt := time.Now()
+		op.UpdatedAt = pausedAt
+		op.PausedAt = &pausedAt
+		e.mu.Unlock()
+		e.addEvent(id, "operation_paused", reason, nil)
+		e.persistOperation(ctx, op)
 	}
 }
 
+// CreateOptions holds operation-wide settings for CreateOperation.
+type CreateOptions struct {
+	// WaitTimeout overrides the engine's default wait timeout, in seconds.
+	WaitTimeout int
+	// WaitForAvailable queues a wait step instead of rejecting the operation
+	// when the cluster is not available.
+	WaitForAvailable bool
+	// DryRun builds the plan in the planned state; it will not run until
+	// ConfirmOperation is called.
+	DryRun bool
+	// OverrideWindow allows creation outside the configured maintenance
+	// windows. Callers are responsible for checking the requester may do so.
+	OverrideWindow bool
+	// IdempotencyKey makes the create safe to retry: while the key is within
+	// its TTL, a create with the same key returns the operation it created.
+	IdempotencyKey string
+	// RequiresApproval holds the operation in pending_approval until
+	// ApproveOperation is called by someone other than CreatedBy.
+	RequiresApproval bool
+	// CreatedBy identifies the creator. Callers must take it from an
+	// authenticated principal, not from the request. It is required when the
+	// operation needs approval, since the approver must be someone else.
+	CreatedBy string
+	// MaxDuration is how long the operation may run, in seconds. Zero uses
+	// the engine's default for the type; negative sets no deadline.
+	MaxDuration int
+	// PollMode runs the operation only when PollOperation is called, with
+	// wait steps checking their condition once per call.
+	PollMode bool
+	// BatchID records the batch operation the operation belongs to.
+	BatchID string
+	// ScheduleInClusterWindow makes StartOperation hold the operation until
+	// the cluster's preferred maintenance window, and the configured
+	// maintenance windows if any, are open. The configured windows are then
+	// not checked at creation.
+	ScheduleInClusterWindow bool
+}
+
+// alreadyDone is returned by a step builder when the cluster is already in
+// the state the operation asks for. CreateOperation records such an
+// operation as completed, with the reason as its event, instead of failing.
+type alreadyDone struct {
+	reason string
+}
+
+func (d *alreadyDone) Error() string { return d.reason }
+
 // CreateOperation creates a new operation.
-func (e *Engine) CreateOperation(ctx context.Context, opType types.OperationType, clusterID, region string, params json.RawMessage, waitTimeout int) (*types.Operation, error) {
+func (e *Engine) CreateOperation(ctx context.Context, opType types.OperationType, clusterID, region string, params json.RawMessage, opts CreateOptions) (*types.Operation, error) {
 	// Use default region if not specified
 	if region == "" {
 		region = e.defaultRegion
 	}
+	if !e.RegionAllowed(region) {
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"region %s is not in the allowed regions (%s)", region, strings.Join(e.allowedRegions, ", "))
+	}
+	if err := e.checkClusterAllowed(clusterID); err != nil {
+		return nil, err
+	}
 
-	// Check if there's already a running operation for this cluster
-	e.mu.RLock()
-	for _, op := range e.operations {
-		if op.ClusterID == clusterID && op.Region == region && (op.State == types.StateRunning || op.State == types.StatePaused) {
-			e.mu.RUnlock()
-			return nil, errors.Wrapf(internalerrors.ErrOperationAlreadyRunning, "cluster %s in region %s", clusterID, region)
+	if opts.IdempotencyKey != "" {
+		existing, err := e.claimIdempotencyKey(opts.IdempotencyKey, opType, clusterID, region)
+		if err != nil || existing != nil {
+			return existing, err
 		}
+		// Whatever happens next, the key is either recorded on the new
+		// operation or free again for a retry.
+		defer e.releaseIdempotencyKey(opts.IdempotencyKey)
+	}
+
+	// Check if there's already an active operation for this cluster
+	e.mu.RLock()
+	err := e.checkClusterFreeLocked(clusterID, region, "")
+	if e.draining {
+		err = errors.Wrap(internalerrors.ErrShuttingDown, "no new operations are accepted")
 	}
 	e.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ScheduleInClusterWindow && opts.PollMode {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter,
+			"schedule_in_cluster_window cannot be combined with poll_mode")
+	}
 
 	now := time.Now()
+	var windowWarning string
+	if !opts.ScheduleInClusterWindow {
+		if windowWarning, err = e.checkMaintenanceWindow(now, opts.OverrideWindow); err != nil {
+			return nil, err
+		}
+	}
+
 	op := &types.Operation{
 		ID:          uuid.New().String(),
 		Type:        opType,
@@ -207,13 +588,33 @@ func (e *Engine) CreateOperation(ctx context.Context, opType types.OperationType
 		ClusterID:   clusterID,
 		Region:      region,
 		Parameters:  params,
-		WaitTimeout: waitTimeout,
+		WaitTimeout: opts.WaitTimeout,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+
+		IdempotencyKey:   opts.IdempotencyKey,
+		RequiresApproval: opts.RequiresApproval,
+		CreatedBy:        opts.CreatedBy,
+		PollMode:         opts.PollMode,
+		BatchID:          opts.BatchID,
+
+		ScheduleInClusterWindow: opts.ScheduleInClusterWindow,
+	}
+	op.MaxDuration = int(e.maxDuration(opType, opts.MaxDuration) / time.Second)
+	if op.ScheduleInClusterWindow {
+		if op.ClusterMaintenanceWindow, err = e.clusterMaintenanceWindow(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+
+	// Refuse to stack a new operation on a cluster that is already changing
+	// (outside of lock since it makes RDS calls)
+	waitStep, err := e.precheckClusterAvailable(ctx, op, opts.WaitForAvailable)
+	if err != nil {
+		return nil, errors.Wrap(err, "precheck")
 	}
 
-	// Build steps based on operation type (outside of lock since it makes RDS calls)
-	var err error
+	// Build steps based on operation type
 	switch opType {
 	case types.OperationTypeInstanceTypeChange:
 		err = e.buildInstanceTypeChangeSteps(ctx, op)
@@ -223,19 +624,70 @@ func (e *Engine) CreateOperation(ctx context.Context, opType types.OperationType
 		err = e.buildEngineUpgradeSteps(ctx, op)
 	case types.OperationTypeInstanceCycle:
 		err = e.buildInstanceCycleSteps(ctx, op)
+	case types.OperationTypeMinorVersionUpgrade:
+		err = e.buildMinorVersionUpgradeSteps(ctx, op)
+	case types.OperationTypeCACertRotation:
+		err = e.buildCACertRotationSteps(ctx, op)
+	case types.OperationTypeRebootCluster:
+		err = e.buildRebootClusterSteps(ctx, op)
+	case types.OperationTypeApplyPendingMaintenance:
+		err = e.buildApplyPendingMaintenanceSteps(ctx, op)
+	case types.OperationTypeSnapshotRestoreTest:
+		err = e.buildSnapshotRestoreTestSteps(ctx, op)
+	case types.OperationTypeServerlessScaling:
+		err = e.buildServerlessScalingSteps(ctx, op)
+	case types.OperationTypeMonitoringChange:
+		err = e.buildMonitoringChangeSteps(ctx, op)
 	default:
 		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "unknown operation type: %s", opType)
 	}
 
+	var done *alreadyDone
+	if errors.As(err, &done) {
+		err = nil
+		waitStep = nil
+		op.State = types.StateCompleted
+		op.CompletedAt = &now
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "build steps")
 	}
+	if waitStep != nil {
+		op.Steps = append([]types.Step{*waitStep}, op.Steps...)
+	}
+	if windowWarning != "" {
+		op.Warnings = append(op.Warnings, windowWarning)
+	}
+	op.Plan = summarizePlan(op.Steps)
+	if opts.DryRun && done == nil {
+		op.State = types.StatePlanned
+	}
+	if op.RequiresApproval && done == nil {
+		if op.CreatedBy == "" {
+			return nil, errors.Wrap(internalerrors.ErrInvalidParameter,
+				"an authenticated creator is required for an operation that needs approval")
+		}
+		op.State = types.StatePendingApproval
+	}
 
 	// Now acquire lock to store the operation
 	e.mu.Lock()
 	e.operations[op.ID] = op
 	e.events[op.ID] = []types.Event{}
 	e.addEventLocked(op.ID, "operation_created", "Operation created", nil)
+	if op.State == types.StatePlanned {
+		e.addEventLocked(op.ID, "operation_planned", "Dry run: plan built, awaiting confirmation", nil)
+	}
+	if op.State == types.StatePendingApproval {
+		e.addEventLocked(op.ID, "approval_required",
+			fmt.Sprintf("Plan built, awaiting approval by someone other than %s", op.CreatedBy), nil)
+	}
+	for _, warning := range op.Warnings {
+		e.addEventLocked(op.ID, "warning", warning, nil)
+	}
+	if done != nil {
+		e.addEventLocked(op.ID, "operation_completed", "Nothing to do: "+done.reason, nil)
+	}
 	e.mu.Unlock()
 
 	// Persist to storage
@@ -257,17 +709,32 @@ func (e *Engine) GetOperation(id string) (*types.Operation, error) {
 	return op, nil
 }
 
-// ListOperations returns all operations.
-func (e *Engine) ListOperations() []*types.Operation {
+// GetStepPlan returns the operation's steps with their current parameters.
+// Unlike the parameters captured at build time, these include values filled
+// in at runtime by earlier steps.
+func (e *Engine) GetStepPlan(id string) ([]types.PlannedStep, error) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	ops := make([]*types.Operation, 0, len(e.operations))
-	for _, op := range e.operations {
-		ops = append(ops, op)
+	op, ok := e.operations[id]
+	if !ok {
+		return nil, internalerrors.ErrOperationNotFound
 	}
 
-	return ops
+	plan := make([]types.PlannedStep, len(op.Steps))
+	for i, step := range op.Steps {
+		plan[i] = types.PlannedStep{
+			Index:      i,
+			ID:         step.ID,
+			Name:       step.Name,
+			Rationale:  step.Rationale,
+			Action:     step.Action,
+			State:      step.State,
+			Parameters: slices.Clone(step.Parameters),
+		}
+	}
+
+	return plan, nil
 }
 
 // GetEvents returns events for an operation.
@@ -284,7 +751,8 @@ func (e *Engine) GetEvents(operationID string) ([]types.Event, error) {
 }
 
 // DeleteOperation deletes an operation that was created but never started.
-// Only operations in the "created" state can be deleted.
+// Only operations that have not started (created, planned, pending approval
+// or scheduled) can be deleted.
 func (e *Engine) DeleteOperation(ctx context.Context, id string) error {
 	return e.deleteOperation(ctx, id, false)
 }
@@ -306,11 +774,12 @@ func (e *Engine) deleteOperation(ctx context.Context, id string, force bool) err
 	}
 
 	// Only allow deletion of operations that were never started (unless forced)
-	if !force && op.State != types.StateCreated {
+	if !force && op.State != types.StateCreated && op.State != types.StatePlanned && op.State != types.StatePendingApproval && op.State != types.StateScheduled {
 		return errors.Wrapf(internalerrors.ErrInvalidState,
-			"cannot delete operation in state %q; only operations in %q state can be deleted",
-			op.State, types.StateCreated)
+			"cannot delete operation in state %q; only operations in %q, %q, %q or %q state can be deleted",
+			op.State, types.StateCreated, types.StatePlanned, types.StatePendingApproval, types.StateScheduled)
 	}
+	e.disarmScheduleLocked(id)
 
 	// Remove from in-memory maps
 	delete(e.operations, id)
@@ -365,7 +834,9 @@ func (e *Engine) ResetOperationToStep(ctx context.Context, id string, stepIndex
 	op.PauseReason = "Reset to step for retry"
 	op.CurrentStepIndex = stepIndex
 	op.CompletedAt = nil
-	op.UpdatedAt = time.Now()
+	pausedAt := time.Now()
+	op.UpdatedAt = pausedAt
+	op.PausedAt = &pausedAt
 
 	// Reset the target step and all subsequent steps
 	for i := stepIndex; i < len(op.Steps); i++ {
@@ -375,7 +846,11 @@ func (e *Engine) ResetOperationToStep(ctx context.Context, id string, stepIndex
 		op.Steps[i].StartedAt = nil
 		op.Steps[i].CompletedAt = nil
 		op.Steps[i].RetryCount = 0
+		op.Steps[i].Attempts = 0
 		op.Steps[i].WaitCondition = ""
+		op.Steps[i].WaitStartedAt = nil
+		op.Steps[i].Polls = 0
+		op.Steps[i].WaitProgress = nil
 	}
 
 	e.mu.Unlock()
@@ -386,7 +861,9 @@ func (e *Engine) ResetOperationToStep(ctx context.Context, id string, stepIndex
 	return nil
 }
 
-// StartOperation starts executing an operation.
+// StartOperation starts executing an operation. One created to follow its
+// cluster's maintenance window is scheduled instead, unless the window is
+// open now.
 func (e *Engine) StartOperation(ctx context.Context, id string) error {
 	e.mu.Lock()
 	op, ok := e.operations[id]
@@ -401,28 +878,113 @@ func (e *Engine) StartOperation(ctx context.Context, id string) error {
 	}
 
 	now := time.Now()
+	if op.State == types.StateCreated && op.ScheduleInClusterWindow {
+		start, err := e.nextScheduledStart(op.ClusterMaintenanceWindow, now)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+		if start.After(now) {
+			e.scheduleLocked(op, start)
+			e.mu.Unlock()
+
+			e.persistOperation(ctx, op)
+			e.addEvent(id, "operation_scheduled", fmt.Sprintf("Scheduled to start at %s in cluster maintenance window %s",
+				start.UTC().Format(time.RFC3339), op.ClusterMaintenanceWindow), nil)
+			return nil
+		}
+	}
+
+	if err := e.startLocked(op, now); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	e.mu.Unlock()
+
+	e.afterStart(ctx, op)
+	return nil
+}
+
+// startLocked admits the operation and moves it to running. Callers hold e.mu.
+func (e *Engine) startLocked(op *types.Operation, now time.Time) error {
+	if err := e.admitLocked(op); err != nil {
+		return err
+	}
+
 	op.State = types.StateRunning
 	op.UpdatedAt = now
 	if op.StartedAt == nil {
 		op.StartedAt = &now
+		if op.MaxDuration > 0 {
+			deadline := now.Add(time.Duration(op.MaxDuration) * time.Second)
+			op.Deadline = &deadline
+		}
 	}
-	e.mu.Unlock()
+	return nil
+}
 
+// afterStart records the start of an operation startLocked moved to running
+// and runs its steps.
+func (e *Engine) afterStart(ctx context.Context, op *types.Operation) {
 	e.persistOperation(ctx, op)
-	e.addEvent(id, "operation_started", "Operation started", nil)
+	e.addEvent(op.ID, "operation_started", "Operation started", nil)
 
 	if e.notifier != nil {
 		e.notifier.NotifyOperationStarted(ctx, op)
 	}
 
-	// Execute steps in background with a new context that won't be canceled
-	// when the HTTP request completes.
-	go e.executeSteps(context.Background(), op)
+	e.runSteps(op)
+}
 
-	return nil
+// runSteps executes the operation's steps in the background with its own
+// context rather than the request's, which is canceled when the HTTP request
+// completes. An operation in poll mode is left for PollOperation to advance.
+func (e *Engine) runSteps(op *types.Operation) {
+	if op.PollMode {
+		return
+	}
+	// An operation that slips in as the engine starts draining stays
+	// running without an executor; Drain saves it for the next process.
+	e.mu.Lock()
+	if e.draining {
+		e.mu.Unlock()
+		return
+	}
+	e.executors.Add(1)
+	e.mu.Unlock()
+
+	ctx := e.operationContext(op.ID)
+	go func() {
+		defer e.executors.Done()
+		e.executeSteps(ctx, op)
+	}()
+}
+
+// ConfirmOperation accepts a dry-run plan and starts executing it.
+func (e *Engine) ConfirmOperation(ctx context.Context, id string) error {
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return internalerrors.ErrOperationNotFound
+	}
+
+	if op.State != types.StatePlanned {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState, "cannot confirm from state %s", op.State)
+	}
+
+	op.State = types.StateCreated
+	op.UpdatedAt = time.Now()
+	e.mu.Unlock()
+
+	e.addEvent(id, "operation_confirmed", "Dry-run plan confirmed", nil)
+	return e.StartOperation(ctx, id)
 }
 
-// ResumeOperation resumes a paused operation.
+// ResumeOperation applies an operator's decision to a paused operation. The
+// decision must be one ResumeOptions currently offers; it is recorded as an
+// operator_decision event along with who made it.
 func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.InterventionResponse) error {
 	e.mu.Lock()
 	op, ok := e.operations[id]
@@ -435,8 +997,52 @@ func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.
 		e.mu.Unlock()
 		return internalerrors.ErrOperationNotPaused
 	}
+	if e.draining {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrShuttingDown, "operation %s not resumed", id)
+	}
+
+	// Only the decisions that make sense for this pause are accepted, so a
+	// stale UI or script cannot, say, mark an operation complete while it is
+	// still waiting on a prerequisite check.
+	allowed := resumeOptions(op)
+	if !slices.Contains(allowed, response.Action) {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState,
+			"decision %q is not allowed for this pause; allowed decisions: %s", response.Action, strings.Join(allowed, ", "))
+	}
+
+	operator := response.Operator
+	if operator == "" {
+		operator = "unknown operator"
+	}
+	audit, _ := json.Marshal(map[string]string{
+		"operator":     response.Operator,
+		"decision":     response.Action,
+		"comment":      response.Comment,
+		"pause_reason": op.PauseReason,
+	})
+	decision := e.addEventLocked(id, "operator_decision", fmt.Sprintf("%s chose %s", operator, response.Action), audit)
+	defer e.persistEvent(decision)
 
 	switch response.Action {
+	case "override":
+		// Skip the failed check and carry on with the next step. The check's
+		// result stays on the step so the override can be reviewed later.
+		step := &op.Steps[op.CurrentStepIndex]
+		step.State = types.StepStateSkipped
+		step.WaitCondition = ""
+		now := time.Now()
+		step.CompletedAt = &now
+		op.CurrentStepIndex++
+		op.State = types.StateRunning
+		op.PauseReason = ""
+		op.UpdatedAt = now
+		e.mu.Unlock()
+		e.persistOperation(ctx, op)
+		e.addEvent(id, "check_overridden", fmt.Sprintf("Check %q overridden: %s", step.Name, response.Comment), nil)
+		e.runSteps(op)
+
 	case "continue":
 		op.State = types.StateRunning
 		op.PauseReason = ""
@@ -444,11 +1050,13 @@ func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.
 		e.mu.Unlock()
 		e.persistOperation(ctx, op)
 		e.addEvent(id, "operation_resumed", "Operation resumed: "+response.Comment, nil)
-		go e.executeSteps(context.Background(), op)
+		e.runSteps(op)
 
 	case "rollback":
-		op.State = types.StateRollingBack
-		op.UpdatedAt = time.Now()
+		if err := e.prepareRollbackLocked(op); err != nil {
+			e.mu.Unlock()
+			return err
+		}
 		e.mu.Unlock()
 		e.persistOperation(ctx, op)
 		e.addEvent(id, "rollback_started", "Rollback initiated: "+response.Comment, nil)
@@ -463,10 +1071,35 @@ func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.
 		e.mu.Unlock()
 		e.persistOperation(ctx, op)
 		e.addEvent(id, "operation_aborted", "Operation aborted: "+response.Comment, nil)
+		e.releaseOperationContext(id)
 		if e.notifier != nil {
 			e.notifier.NotifyOperationFailed(ctx, op)
 		}
 
+	case "retry_cleanup":
+		// Re-run only the Blue-Green cleanup step. The handler tolerates
+		// already-deleted resources, so a repeat run picks up where the last
+		// one failed without touching the completed switchover.
+		cleanupIdx, err := retryableCleanupStep(op)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+		cleanup := &op.Steps[cleanupIdx]
+		cleanup.State = types.StepStatePending
+		cleanup.Error = ""
+		cleanup.WaitCondition = ""
+		cleanup.RetryCount = 0
+		cleanup.CompletedAt = nil
+		op.CurrentStepIndex = cleanupIdx
+		op.State = types.StateRunning
+		op.PauseReason = ""
+		op.UpdatedAt = time.Now()
+		e.mu.Unlock()
+		e.persistOperation(ctx, op)
+		e.addEvent(id, "cleanup_retried", "Retrying cleanup: "+response.Comment, nil)
+		e.runSteps(op)
+
 	case "mark_complete":
 		// Allow user to manually mark operation as complete despite failures
 		// This is useful when cleanup fails but the main operation succeeded
@@ -478,6 +1111,7 @@ func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.
 		e.mu.Unlock()
 		e.persistOperation(ctx, op)
 		e.addEvent(id, "operation_marked_complete", "Operation manually marked complete: "+response.Comment, nil)
+		e.releaseOperationContext(id)
 		if e.notifier != nil {
 			e.notifier.NotifyOperationCompleted(ctx, op)
 		}
@@ -490,6 +1124,331 @@ func (e *Engine) ResumeOperation(ctx context.Context, id string, response types.
 	return nil
 }
 
+// RollbackOperation undoes a failed or paused operation. For an instance type
+// change, every instance already resized is changed back one at a time; any
+// temp instance is then deleted.
+func (e *Engine) RollbackOperation(ctx context.Context, id string) error {
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return internalerrors.ErrOperationNotFound
+	}
+
+	if op.State != types.StateFailed && op.State != types.StatePaused {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState, "cannot roll back from state %s", op.State)
+	}
+	if err := e.admitLocked(op); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+
+	if err := e.prepareRollbackLocked(op); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	e.mu.Unlock()
+
+	e.persistOperation(ctx, op)
+	e.addEvent(id, "rollback_started", "Rollback initiated", nil)
+	go e.executeRollback(context.Background(), op)
+
+	return nil
+}
+
+// prepareRollbackLocked appends the operation's rollback steps and moves it to
+// rolling_back. The caller must hold e.mu.
+func (e *Engine) prepareRollbackLocked(op *types.Operation) error {
+	if op.CurrentStepIndex < len(op.Steps) && op.Steps[op.CurrentStepIndex].State == types.StepStateInProgress {
+		return errors.Wrapf(internalerrors.ErrInvalidState,
+			"step %d (%s) is still running; roll back after it finishes", op.CurrentStepIndex+1, op.Steps[op.CurrentStepIndex].Name)
+	}
+
+	if op.Type == types.OperationTypeInstanceTypeChange {
+		steps, err := buildInstanceTypeRollbackSteps(op)
+		if err != nil {
+			return err
+		}
+		op.Steps = append(op.Steps, steps...)
+	}
+	if autoscalingResumePending(op) {
+		step := resumeAutoscalingStep()
+		step.Rollback = true
+		op.Steps = append(op.Steps, step)
+	}
+
+	op.State = types.StateRollingBack
+	op.PauseReason = ""
+	op.CompletedAt = nil
+	op.UpdatedAt = time.Now()
+	return nil
+}
+
+// retryableCleanupStep returns the index of the cleanup_blue_green step if the
+// operation is stopped on it with the switchover already completed.
+func retryableCleanupStep(op *types.Operation) (int, error) {
+	cleanupIdx := -1
+	switchoverDone := false
+	for i, step := range op.Steps {
+		switch step.Action {
+		case "switchover_blue_green":
+			switchoverDone = step.State == types.StepStateCompleted
+		case "cleanup_blue_green":
+			cleanupIdx = i
+		}
+	}
+	if cleanupIdx < 0 {
+		return 0, errors.Wrap(internalerrors.ErrInvalidState, "operation has no Blue-Green cleanup step")
+	}
+	if !switchoverDone {
+		return 0, errors.Wrap(internalerrors.ErrInvalidState, "switchover has not completed; retry_cleanup only re-runs cleanup")
+	}
+	if op.CurrentStepIndex != cleanupIdx {
+		return 0, errors.Wrapf(internalerrors.ErrInvalidState, "operation is paused at step %d, not at cleanup", op.CurrentStepIndex+1)
+	}
+	return cleanupIdx, nil
+}
+
+// uncancellableActions are steps that must run to completion once started.
+// Interrupting them would leave the cluster mid-transition, so cancellation
+// is refused until they finish.
+var uncancellableActions = map[string]bool{
+	"failover_to_instance":  true,
+	"switchover_blue_green": true,
+}
+
+// CancelOperation stops a running or paused operation. The operation moves to
+// cancelling, its in-flight step is interrupted through the operation context,
+// and any temp instance it created is deleted before it settles as cancelled.
+// A scheduled operation is cancelled straight away.
+func (e *Engine) CancelOperation(ctx context.Context, id string) error {
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return internalerrors.ErrOperationNotFound
+	}
+
+	if op.State == types.StateScheduled {
+		// Nothing has run yet, so there is nothing to stop or clean up.
+		e.disarmScheduleLocked(id)
+		now := time.Now()
+		op.State = types.StateCancelled
+		op.CompletedAt = &now
+		op.UpdatedAt = now
+		e.mu.Unlock()
+
+		e.persistOperation(ctx, op)
+		e.addEvent(id, "operation_cancelled", "Scheduled operation cancelled before it started", nil)
+		return nil
+	}
+	if op.State != types.StateRunning && op.State != types.StatePaused {
+		e.mu.Unlock()
+		return errors.Wrapf(internalerrors.ErrInvalidState, "cannot cancel from state %s", op.State)
+	}
+
+	// A step is only in progress while a goroutine is executing it; that
+	// goroutine finishes the cancellation once its handler returns. A paused
+	// operation may still have one if it was paused mid-step.
+	stepRunning := false
+	if op.CurrentStepIndex < len(op.Steps) {
+		step := &op.Steps[op.CurrentStepIndex]
+		if step.State == types.StepStateInProgress {
+			if uncancellableActions[step.Action] {
+				e.mu.Unlock()
+				return errors.Wrapf(internalerrors.ErrInvalidState,
+					"step %d (%s) must run to completion; cancel after it finishes", op.CurrentStepIndex+1, step.Name)
+			}
+			stepRunning = true
+		}
+	}
+	driven := op.State == types.StateRunning || stepRunning
+	// Between polls nothing is executing an operation in poll mode.
+	if op.PollMode {
+		driven = e.polling[id]
+	}
+
+	op.State = types.StateCancelling
+	op.PauseReason = ""
+	op.UpdatedAt = time.Now()
+	run, hasRun := e.runContexts[id]
+	e.mu.Unlock()
+
+	e.persistOperation(ctx, op)
+	e.addEvent(id, "operation_cancelling", "Cancellation requested", nil)
+
+	if hasRun {
+		run.cancel()
+	}
+	if !driven {
+		go e.finishCancellation(context.Background(), op)
+	}
+
+	return nil
+}
+
+// finishCancellation settles a cancelling operation: the interrupted step is
+// marked failed, a compensating delete_instance is run for any temp instance
+// still present, and the operation ends cancelled. If the temp instance cannot
+// be removed the operation fails instead, naming the instance left behind.
+func (e *Engine) finishCancellation(ctx context.Context, op *types.Operation) {
+	e.mu.Lock()
+	if op.CurrentStepIndex < len(op.Steps) {
+		step := &op.Steps[op.CurrentStepIndex]
+		if step.State == types.StepStateInProgress || step.State == types.StepStateWaiting {
+			now := time.Now()
+			step.State = types.StepStateFailed
+			step.Error = "cancelled by operator"
+			step.WaitCondition = ""
+			step.CompletedAt = &now
+			e.recordStepOutcome(step)
+		}
+	}
+	tempInstanceID := cancellationCleanupTarget(op)
+	e.mu.Unlock()
+
+	if tempInstanceID != "" {
+		if err := e.deleteTempInstanceAfterCancel(ctx, op, tempInstanceID); err != nil {
+			e.mu.Lock()
+			op.State = types.StateFailed
+			op.Error = fmt.Sprintf("Cancelled, but temp instance %s could not be deleted: %v", tempInstanceID, err)
+			now := time.Now()
+			op.CompletedAt = &now
+			op.UpdatedAt = now
+			e.mu.Unlock()
+			e.persistOperation(ctx, op)
+			e.addEvent(op.ID, "cancel_cleanup_failed", op.Error, nil)
+			e.releaseOperationContext(op.ID)
+			if e.notifier != nil {
+				e.notifier.NotifyOperationFailed(ctx, op)
+			}
+			return
+		}
+	}
+	e.restoreAutoscalingAfterCancel(ctx, op)
+
+	e.mu.Lock()
+	op.State = types.StateCancelled
+	now := time.Now()
+	op.CompletedAt = &now
+	op.UpdatedAt = now
+	e.mu.Unlock()
+
+	e.persistOperation(ctx, op)
+	e.addEvent(op.ID, "operation_cancelled", "Operation cancelled", nil)
+	e.releaseOperationContext(op.ID)
+}
+
+// cancellationCleanupTarget returns the temp instance a cancelled operation
+// may have left behind, or "" if none was created or it was already deleted.
+// The temp instance ID is derived from the operation, so an interrupted
+// create_temp_instance is covered even though it recorded no result.
+func cancellationCleanupTarget(op *types.Operation) string {
+	created := false
+	for _, step := range op.Steps {
+		switch {
+		case step.Action == "delete_instance" && step.State == types.StepStateCompleted:
+			return ""
+		case step.Action == "create_temp_instance" && step.StartedAt != nil:
+			created = true
+		}
+	}
+	if !created {
+		return ""
+	}
+	return rds.GenerateTempInstanceID(op.ClusterID, op.ID)
+}
+
+// deleteTempInstanceAfterCancel appends and runs a delete_instance step for
+// the temp instance, unless it no longer exists or is already being deleted.
+func (e *Engine) deleteTempInstanceAfterCancel(ctx context.Context, op *types.Operation, instanceID string) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	info, err := rdsClient.GetInstanceInfo(ctx, instanceID)
+	if internalerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "check temp instance")
+	}
+	if rds.InstanceStatus(info.Status).IsDeleting() {
+		return nil
+	}
+
+	params, err := json.Marshal(deleteInstanceParams{
+		InstanceID:    instanceID,
+		FinalSnapshot: tempInstanceFinalSnapshot,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal delete_instance params")
+	}
+
+	e.mu.Lock()
+	op.Steps = append(op.Steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Delete temp instance (cancelled)",
+		Description: "Remove temporary instance left by the cancelled operation",
+		State:       types.StepStatePending,
+		Action:      "delete_instance",
+		Parameters:  params,
+	})
+	op.CurrentStepIndex = len(op.Steps) - 1
+	step := &op.Steps[op.CurrentStepIndex]
+	e.mu.Unlock()
+
+	err = e.executeStep(ctx, op, step)
+
+	e.mu.Lock()
+	now := time.Now()
+	step.CompletedAt = &now
+	if err != nil {
+		step.State = types.StepStateFailed
+		step.Error = err.Error()
+	} else {
+		step.State = types.StepStateCompleted
+	}
+	e.recordStepOutcome(step)
+	e.mu.Unlock()
+	e.persistOperation(ctx, op)
+
+	return err
+}
+
+// operationContext returns the context an operation's steps run under,
+// creating it on first use. The same context is reused across resumes so a
+// cancel reaches every goroutine still working on the operation.
+func (e *Engine) operationContext(id string) context.Context {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if run, ok := e.runContexts[id]; ok {
+		return run.ctx
+	}
+	if e.runContexts == nil {
+		e.runContexts = make(map[string]runContext)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.runContexts[id] = runContext{ctx: ctx, cancel: cancel}
+	return ctx
+}
+
+// releaseOperationContext cancels and forgets an operation's context once
+// the operation has reached a terminal state.
+func (e *Engine) releaseOperationContext(id string) {
+	e.mu.Lock()
+	run, ok := e.runContexts[id]
+	delete(e.runContexts, id)
+	e.mu.Unlock()
+
+	if ok {
+		run.cancel()
+	}
+}
+
 // PauseOperation pauses a running operation.
 // Returns an error if the operation is on its last step and that step is in progress,
 // since pausing at that point would have no effect.
@@ -517,7 +1476,9 @@ func (e *Engine) PauseOperation(ctx context.Context, id string, reason string) e
 
 	op.State = types.StatePaused
 	op.PauseReason = reason
-	op.UpdatedAt = time.Now()
+	pausedAt := time.Now()
+	op.UpdatedAt = pausedAt
+	op.PausedAt = &pausedAt
 	e.mu.Unlock()
 
 	e.persistOperation(ctx, op)
@@ -530,15 +1491,44 @@ func (e *Engine) PauseOperation(ctx context.Context, id string, reason string) e
 	return nil
 }
 
-// getWaitTimeout returns the timeout for wait operations.
-// Uses operation-specific timeout if set, otherwise the default.
-func (e *Engine) getWaitTimeout(op *types.Operation) time.Duration {
+// getWaitTimeout returns the timeout for a step's wait. An operation-wide
+// timeout set by the operator takes precedence, then the step's own timeout,
+// then the engine default.
+func (e *Engine) getWaitTimeout(op *types.Operation, step *types.Step) time.Duration {
 	if op.WaitTimeout > 0 {
 		return time.Duration(op.WaitTimeout) * time.Second
 	}
+	if step != nil && step.TimeoutSeconds > 0 {
+		return time.Duration(step.TimeoutSeconds) * time.Second
+	}
 	return e.defaultWaitTimeout
 }
 
+// getPollInterval returns the step's poll interval, or the engine default.
+func (e *Engine) getPollInterval(step *types.Step) time.Duration {
+	if step != nil && step.PollIntervalSeconds > 0 {
+		return time.Duration(step.PollIntervalSeconds) * time.Second
+	}
+	return e.defaultPollInterval
+}
+
+// awaitFirstPoll blocks for the initial poll delay, so a wait that starts
+// right after a modify, reboot, or failover does not mistake the stale
+// pre-transition "available" status for completion. A single-shot wait
+// never blocks; its poller holds the first check back instead.
+func (e *Engine) awaitFirstPoll(ctx context.Context, op *types.Operation, step *types.Step) error {
+	e.recordWaitStart(step)
+	if e.initialPollDelay <= 0 || singleShotWait(op, step) {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(e.initialPollDelay):
+		return nil
+	}
+}
+
 // getRDSClient returns the RDS client for an operation's region.
 func (e *Engine) getRDSClient(ctx context.Context, op *types.Operation) (*rds.Client, error) {
 	region := op.Region
@@ -548,15 +1538,45 @@ func (e *Engine) getRDSClient(ctx context.Context, op *types.Operation) (*rds.Cl
 	return e.clientManager.GetClient(ctx, region)
 }
 
+// getAlarmClient returns the CloudWatch client for the operation's region.
+func (e *Engine) getAlarmClient(ctx context.Context, op *types.Operation) (*cloudwatch.Client, error) {
+	region := op.Region
+	if region == "" {
+		region = e.defaultRegion
+	}
+	return e.clientManager.GetAlarmClient(ctx, region)
+}
+
+// getAutoScalingClient returns the Application Auto Scaling client for the
+// operation's region.
+func (e *Engine) getAutoScalingClient(ctx context.Context, op *types.Operation) (*autoscaling.Client, error) {
+	region := op.Region
+	if region == "" {
+		region = e.defaultRegion
+	}
+	return e.clientManager.GetAutoScalingClient(ctx, region)
+}
+
 // executeSteps executes the steps of an operation.
 func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 	for op.CurrentStepIndex < len(op.Steps) {
 		e.mu.RLock()
-		if op.State != types.StateRunning {
+		if op.State == types.StateCancelling {
+			e.mu.RUnlock()
+			e.finishCancellation(context.WithoutCancel(ctx), op)
+			return
+		}
+		if op.State != types.StateRunning || e.draining || e.supersededLocked(op) {
 			e.mu.RUnlock()
 			return
 		}
 		step := &op.Steps[op.CurrentStepIndex]
+		if deadlinePassed(op, time.Now()) {
+			e.mu.RUnlock()
+			e.mu.Lock()
+			e.stopAtDeadlineLocked(ctx, op, nil, nil)
+			return
+		}
 
 		// Check if we should auto-pause before this step
 		if e.shouldAutoPause(op, op.CurrentStepIndex) {
@@ -564,7 +1584,9 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 			e.mu.Lock()
 			op.State = types.StatePaused
 			op.PauseReason = fmt.Sprintf("Auto-pause before step %d: %s", op.CurrentStepIndex+1, step.Name)
-			op.UpdatedAt = time.Now()
+			pausedAt := time.Now()
+			op.UpdatedAt = pausedAt
+			op.PausedAt = &pausedAt
 			// Remove this step from the auto-pause list since we've now paused
 			op.PauseBeforeSteps = removeFromSlice(op.PauseBeforeSteps, op.CurrentStepIndex)
 			e.mu.Unlock()
@@ -575,19 +1597,64 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 			}
 			return
 		}
+		// A handler that pauses for intervention may set its own pause
+		// reason; one it leaves alone is replaced by the step's error.
+		priorPauseReason := op.PauseReason
 		e.mu.RUnlock()
 
 		// Execute step
-		err := e.executeStep(ctx, op, step)
+		stepCtx, cancel := stepContext(ctx, op, step)
+		err := e.executeStep(stepCtx, op, step)
+		cancel()
 
 		e.mu.Lock()
+		if e.supersededLocked(op) {
+			e.mu.Unlock()
+			return
+		}
 		if err != nil {
+			if op.State == types.StateCancelling {
+				e.mu.Unlock()
+				e.finishCancellation(context.WithoutCancel(ctx), op)
+				return
+			}
+			if e.draining && ctx.Err() != nil {
+				e.interruptStepLocked(op, step)
+				e.mu.Unlock()
+				e.persistOperation(context.WithoutCancel(ctx), op)
+				e.addStepEvent(op.ID, step.ID, "operation_interrupted",
+					"Server shutting down; "+step.Name+" will run again when the operation resumes", nil)
+				return
+			}
+			if deadlinePassed(op, time.Now()) && !deadlineExemptActions[step.Action] {
+				e.stopAtDeadlineLocked(ctx, op, step, err)
+				return
+			}
+
+			// A single-shot wait that is not done leaves the operation
+			// running until the next poll.
+			if errors.Is(err, errPollPending) {
+				step.State = types.StepStateWaiting
+				op.UpdatedAt = time.Now()
+				e.mu.Unlock()
+				e.persistOperation(ctx, op)
+				return
+			}
+			// Whatever runs the step next starts its wait afresh, as a
+			// blocking wait's handler would.
+			step.WaitProgress = nil
+
 			if errors.Is(err, internalerrors.ErrInterventionRequired) {
 				step.State = types.StepStateWaiting
 				step.WaitCondition = "waiting for operator intervention"
+				step.Error = err.Error()
 				op.State = types.StatePaused
-				op.PauseReason = step.Error
-				op.UpdatedAt = time.Now()
+				if op.PauseReason == priorPauseReason {
+					op.PauseReason = step.Error
+				}
+				pausedAt := time.Now()
+				op.UpdatedAt = pausedAt
+				op.PausedAt = &pausedAt
 				e.mu.Unlock()
 				e.persistOperation(ctx, op)
 				e.addEvent(op.ID, "intervention_required", step.Error, nil)
@@ -597,15 +1664,19 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 				return
 			}
 
-			// Check if we can retry
-			if step.RetryCount < step.MaxRetries {
+			// Transient failures are retried with backoff while the step
+			// has retries left; anything else fails it now.
+			if step.RetryCount < step.MaxRetries && retryableStepError(err) {
 				step.RetryCount++
 				step.State = types.StepStatePending
 				step.Error = ""
 				e.mu.Unlock()
 				e.persistOperation(ctx, op)
-				e.addEvent(op.ID, "step_retry", "Retrying step: "+step.Name, nil)
-				time.Sleep(e.defaultPollInterval)
+				delay := e.recordStepRetry(op, step, err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(delay):
+				}
 				continue
 			}
 
@@ -614,13 +1685,17 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 			step.Error = err.Error()
 			now := time.Now()
 			step.CompletedAt = &now
+			e.recordStepOutcome(step)
 			op.State = types.StatePaused
 			op.PauseReason = "Step failed: " + step.Name + " - " + err.Error()
-			op.UpdatedAt = time.Now()
+			pausedAt := time.Now()
+			op.UpdatedAt = pausedAt
+			op.PausedAt = &pausedAt
 			e.mu.Unlock()
 
 			e.persistOperation(ctx, op)
 			e.addEvent(op.ID, "step_failed", step.Error, nil)
+			e.attachRDSEvents(ctx, op, step)
 			if e.notifier != nil {
 				e.notifier.NotifyOperationPaused(ctx, op, op.PauseReason)
 			}
@@ -631,12 +1706,13 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 		step.State = types.StepStateCompleted
 		now := time.Now()
 		step.CompletedAt = &now
+		e.recordStepOutcome(step)
 		op.CurrentStepIndex++
 		op.UpdatedAt = time.Now()
 		e.mu.Unlock()
 
 		e.persistOperation(ctx, op)
-		e.addEvent(op.ID, "step_completed", "Completed: "+step.Name, nil)
+		e.addStepEvent(op.ID, step.ID, "step_completed", "Completed: "+step.Name, nil)
 		if e.notifier != nil {
 			e.notifier.NotifyStepCompleted(ctx, op, step)
 		}
@@ -644,6 +1720,15 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 
 	// All steps completed
 	e.mu.Lock()
+	if e.supersededLocked(op) {
+		e.mu.Unlock()
+		return
+	}
+	if op.State == types.StateCancelling {
+		e.mu.Unlock()
+		e.finishCancellation(context.WithoutCancel(ctx), op)
+		return
+	}
 	op.State = types.StateCompleted
 	now := time.Now()
 	op.CompletedAt = &now
@@ -652,14 +1737,22 @@ func (e *Engine) executeSteps(ctx context.Context, op *types.Operation) {
 
 	e.persistOperation(ctx, op)
 	e.addEvent(op.ID, "operation_completed", "Operation completed successfully", nil)
+	e.releaseOperationContext(op.ID)
 	if e.notifier != nil {
 		e.notifier.NotifyOperationCompleted(ctx, op)
 	}
 }
 
+// errOperationSuperseded is returned by executeStep when saving the step's
+// start showed another process had saved the operation. The handler is not
+// run, since the step may already have been done from the newer copy.
+var errOperationSuperseded = errors.New("operation superseded by a newer stored copy")
+
 // executeStep executes a single step.
 func (e *Engine) executeStep(ctx context.Context, op *types.Operation, step *types.Step) error {
 	e.mu.Lock()
+	// A wait already under way is only being polled again.
+	resumed := singleShotWait(op, step) && step.WaitProgress != nil
 	step.State = types.StepStateInProgress
 	// Only set StartedAt if not already set - preserves original start time across retries
 	// so duration reflects total time spent on this step (including failed attempts)
@@ -667,23 +1760,71 @@ func (e *Engine) executeStep(ctx context.Context, op *types.Operation, step *typ
 		now := time.Now()
 		step.StartedAt = &now
 	}
+	if !resumed {
+		step.Attempts++
+	}
 	e.mu.Unlock()
 
 	e.persistOperation(ctx, op)
-	e.addEvent(op.ID, "step_started", "Starting: "+step.Name, nil)
+	if e.superseded(op) {
+		return errOperationSuperseded
+	}
+	if !resumed {
+		e.addEvent(op.ID, "step_started", "Starting: "+step.Name, nil)
+	}
 
 	handler, ok := e.handlers[step.Action]
 	if !ok {
 		return errors.Wrapf(internalerrors.ErrInvalidParameter, "unknown action: %s", step.Action)
 	}
 
+	// Every line a handler logs through stepLogger carries the operation and
+	// step, so handlers do not repeat them.
+	ctx = contextWithLogger(ctx, e.logger.With(
+		slog.String("operation_id", op.ID),
+		slog.String("step_id", step.ID),
+		slog.String("action", step.Action)))
 	return handler(ctx, op, step)
 }
 
+// loggerKey is the context key of the step logger.
+type loggerKey struct{}
+
+// contextWithLogger returns ctx carrying logger for stepLogger.
+func contextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// stepLogger returns the logger executeStep derived for the running step, or
+// the engine logger outside a step.
+func (e *Engine) stepLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return e.logger
+}
+
 // executeRollback executes rollback for an operation.
 func (e *Engine) executeRollback(ctx context.Context, op *types.Operation) {
 	e.logger.Info("executing rollback", slog.String("operation_id", op.ID))
 
+	if err := e.executeRollbackSteps(ctx, op); err != nil {
+		e.mu.Lock()
+		op.State = types.StateFailed
+		op.Error = err.Error()
+		now := time.Now()
+		op.CompletedAt = &now
+		op.UpdatedAt = now
+		e.mu.Unlock()
+
+		e.persistOperation(ctx, op)
+		e.addEvent(op.ID, "rollback_failed", op.Error, nil)
+		if e.notifier != nil {
+			e.notifier.NotifyOperationFailed(ctx, op)
+		}
+		return
+	}
+
 	rdsClient, err := e.getRDSClient(ctx, op)
 	if err != nil {
 		e.logger.Error("failed to get RDS client for rollback",
@@ -700,7 +1841,7 @@ func (e *Engine) executeRollback(ctx context.Context, op *types.Operation) {
 				}
 				if err := json.Unmarshal(step.Result, &result); err == nil && result.InstanceID != "" {
 					e.logger.Info("deleting temp instance", slog.String("instance_id", result.InstanceID))
-					if err := rdsClient.DeleteInstance(ctx, result.InstanceID, true); err != nil {
+					if err := rdsClient.DeleteInstance(ctx, result.InstanceID, ""); err != nil {
 						e.logger.Error("failed to delete temp instance",
 							slog.String("instance_id", result.InstanceID),
 							slog.String("error", err.Error()))
@@ -721,41 +1862,187 @@ func (e *Engine) executeRollback(ctx context.Context, op *types.Operation) {
 	e.addEvent(op.ID, "rollback_completed", "Rollback completed", nil)
 }
 
+// executeRollbackSteps runs the operation's pending rollback steps in order,
+// retrying transient failures of each up to its MaxRetries. It stops at the
+// first step that fails.
+func (e *Engine) executeRollbackSteps(ctx context.Context, op *types.Operation) error {
+	for i := range op.Steps {
+		step := &op.Steps[i]
+		if !step.Rollback || step.State == types.StepStateCompleted {
+			continue
+		}
+
+		e.mu.Lock()
+		op.CurrentStepIndex = i
+		e.mu.Unlock()
+
+		err := e.executeStep(ctx, op, step)
+		for err != nil && step.RetryCount < step.MaxRetries && retryableStepError(err) {
+			e.mu.Lock()
+			step.RetryCount++
+			e.mu.Unlock()
+			time.Sleep(e.recordStepRetry(op, step, err))
+			err = e.executeStep(ctx, op, step)
+		}
+
+		e.mu.Lock()
+		now := time.Now()
+		step.CompletedAt = &now
+		if err != nil {
+			step.State = types.StepStateFailed
+			step.Error = err.Error()
+		} else {
+			step.State = types.StepStateCompleted
+		}
+		e.recordStepOutcome(step)
+		e.mu.Unlock()
+		e.persistOperation(ctx, op)
+
+		if err != nil {
+			return errors.Wrapf(internalerrors.ErrRollbackFailed, "%s: %v", step.Name, err)
+		}
+		e.addStepEvent(op.ID, step.ID, "step_completed", "Completed: "+step.Name, nil)
+	}
+	return nil
+}
+
 // addEvent adds an event to the operation's event log and persists it.
 func (e *Engine) addEvent(operationID, eventType, message string, data json.RawMessage) {
 	e.mu.Lock()
 	event := e.addEventLocked(operationID, eventType, message, data)
 	e.mu.Unlock()
 
-	// Persist event to storage
+	e.persistEvent(event)
+}
+
+// persistEvent saves an event recorded by addEventLocked to storage.
+func (e *Engine) persistEvent(event types.Event) {
 	if err := e.store.AppendEvent(context.Background(), event); err != nil {
 		e.logger.Error("failed to persist event",
-			slog.String("operation_id", operationID),
-			slog.String("event_type", eventType),
+			slog.String("operation_id", event.OperationID),
+			slog.String("event_type", event.Type),
 			slog.String("error", err.Error()))
 	}
 }
 
+// addEventLocked records an event against the step that is running, if any.
+// Callers hold e.mu.
 func (e *Engine) addEventLocked(operationID, eventType, message string, data json.RawMessage) types.Event {
+	return e.appendEventLocked(operationID, e.eventStepIDLocked(operationID), eventType, message, data)
+}
+
+// addStepEvent is like addEvent but records the event against a given step,
+// for events about a step that is no longer the running one.
+func (e *Engine) addStepEvent(operationID, stepID, eventType, message string, data json.RawMessage) {
+	e.mu.Lock()
+	event := e.appendEventLocked(operationID, stepID, eventType, message, data)
+	e.mu.Unlock()
+
+	e.persistEvent(event)
+}
+
+func (e *Engine) appendEventLocked(operationID, stepID, eventType, message string, data json.RawMessage) types.Event {
 	event := types.Event{
 		ID:          uuid.New().String(),
 		OperationID: operationID,
 		Type:        eventType,
+		Severity:    eventSeverity(eventType),
+		StepID:      stepID,
 		Message:     message,
 		Data:        data,
-		Timestamp:   time.Now(),
+		Timestamp:   e.nextEventTimeLocked(operationID),
 	}
 	e.events[operationID] = append(e.events[operationID], event)
+	e.publishEvent(event)
 	return event
 }
 
-// persistOperation saves an operation to storage.
+// persistOperation saves an operation to storage. A copy superseded after a
+// lost save is no longer written.
 func (e *Engine) persistOperation(ctx context.Context, op *types.Operation) {
+	if e.superseded(op) {
+		return
+	}
 	if err := e.store.SaveOperation(ctx, op); err != nil {
+		if errors.Is(err, internalerrors.ErrConcurrentModification) {
+			e.supersedeOperation(ctx, op, err)
+			return
+		}
 		e.logger.Error("failed to persist operation",
 			slog.String("operation_id", op.ID),
 			slog.String("error", err.Error()))
 	}
+	e.publishProgress(op)
+}
+
+// superseded reports whether op is a stale copy that supersedeOperation has
+// replaced. An operation the engine does not hold is never superseded.
+func (e *Engine) superseded(op *types.Operation) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.supersededLocked(op)
+}
+
+// supersededLocked is superseded for callers holding e.mu.
+func (e *Engine) supersededLocked(op *types.Operation) bool {
+	current, ok := e.operations[op.ID]
+	return ok && current != op
+}
+
+// supersedeOperation handles a save that lost a race with another process:
+// op is stale, so the stored operation replaces it and op's executor stops.
+// Its run context is cancelled, interrupting the in-flight handler, and
+// executeSteps returns once it sees op was replaced. Reading the stored
+// operation also refreshes the store's version of it. If that read fails,
+// op is replaced by a paused copy instead, so nothing runs it until an
+// operator resumes it and its next save tries the store again.
+func (e *Engine) supersedeOperation(ctx context.Context, op *types.Operation, saveErr error) {
+	e.logger.Warn("operation was saved by another process, stopping this copy",
+		slog.String("operation_id", op.ID),
+		slog.String("error", saveErr.Error()))
+
+	stored, err := e.store.GetOperation(context.WithoutCancel(ctx), op.ID)
+	if err == nil && stored == nil {
+		err = internalerrors.ErrOperationNotFound
+	}
+
+	e.mu.Lock()
+	if e.supersededLocked(op) {
+		e.mu.Unlock()
+		return
+	}
+	message := "Operation was saved by another process; reloaded the stored copy"
+	if err != nil {
+		paused := *op
+		paused.Steps = slices.Clone(op.Steps)
+		paused.State = types.StatePaused
+		paused.PauseReason = "Operation was saved by another process and could not be reloaded: " + err.Error()
+		pausedAt := time.Now()
+		paused.UpdatedAt = pausedAt
+		paused.PausedAt = &pausedAt
+		stored = &paused
+		message = paused.PauseReason
+	}
+	e.operations[op.ID] = stored
+	run, ok := e.runContexts[op.ID]
+	delete(e.runContexts, op.ID)
+	e.mu.Unlock()
+
+	if ok {
+		run.cancel()
+	}
+	e.addEvent(op.ID, "operation_superseded", message, nil)
+	e.publishProgress(stored)
+}
+
+// recordStepOutcome counts a step that has reached its final state and
+// observes how long it ran. Callers hold e.mu.
+func (e *Engine) recordStepOutcome(step *types.Step) {
+	seconds := -1.0
+	if step.StartedAt != nil && step.CompletedAt != nil {
+		seconds = step.CompletedAt.Sub(*step.StartedAt).Seconds()
+	}
+	e.metrics.StepFinished(step.Action, string(step.State), seconds)
 }
 
 // ClientManager returns the RDS client manager.
@@ -763,6 +2050,33 @@ func (e *Engine) ClientManager() *rds.ClientManager {
 	return e.clientManager
 }
 
+// RegionAllowed reports whether operations may be created in region.
+func (e *Engine) RegionAllowed(region string) bool {
+	return len(e.allowedRegions) == 0 || slices.Contains(e.allowedRegions, region)
+}
+
+// checkClusterAllowed rejects a cluster that matches a denylist pattern, or
+// no allowlist pattern when there is an allowlist. The error names the
+// pattern that decided.
+func (e *Engine) checkClusterAllowed(clusterID string) error {
+	for _, pattern := range e.clusterDenylist {
+		if ok, _ := path.Match(pattern, clusterID); ok {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"cluster %s matches cluster denylist pattern %q", clusterID, pattern)
+		}
+	}
+	if len(e.clusterAllowlist) == 0 {
+		return nil
+	}
+	for _, pattern := range e.clusterAllowlist {
+		if ok, _ := path.Match(pattern, clusterID); ok {
+			return nil
+		}
+	}
+	return errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"cluster %s matches no cluster allowlist pattern (%s)", clusterID, strings.Join(e.clusterAllowlist, ", "))
+}
+
 // DefaultRegion returns the default AWS region.
 func (e *Engine) DefaultRegion() string {
 	return e.defaultRegion
@@ -0,0 +1,1452 @@
+package machine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/metrics"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// persistInterruptedOperation saves an operation that was mid-step when the
+// server stopped, as the previous process would have left it in the store.
+func persistInterruptedOperation(t *testing.T, store storage.Store) *types.Operation {
+	t.Helper()
+	started := time.Now().Add(-time.Minute)
+	op := &types.Operation{
+		ID:        "test-op-interrupted",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateRunning,
+		ClusterID: "restart-cluster",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "First step", Action: "test_action", State: types.StepStateCompleted},
+			{ID: "step-2", Name: "Second step", Action: "test_action", State: types.StepStateWaiting, WaitCondition: "waiting"},
+		},
+		CurrentStepIndex: 1,
+		CreatedAt:        started,
+		StartedAt:        &started,
+	}
+	if err := store.SaveOperation(context.Background(), op); err != nil {
+		t.Fatalf("SaveOperation failed: %v", err)
+	}
+	return op
+}
+
+func TestResumeRunningOperations_PausesWithoutAutoResume(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	persisted := persistInterruptedOperation(t, store)
+
+	engine := NewEngine(EngineConfig{Store: store})
+	ctx := context.Background()
+
+	runningOps, err := engine.LoadFromStore(ctx)
+	if err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	if len(runningOps) != 1 || runningOps[0] != persisted.ID {
+		t.Fatalf("expected %s to be picked up, got %v", persisted.ID, runningOps)
+	}
+
+	engine.ResumeRunningOperations(ctx, runningOps, false)
+
+	op, err := engine.GetOperation(persisted.ID)
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if op.State != types.StatePaused {
+		t.Errorf("state = %s, want paused", op.State)
+	}
+	if !containsAny(op.PauseReason, "Second step") {
+		t.Errorf("expected pause reason to name the interrupted step, got %q", op.PauseReason)
+	}
+	if op.Steps[1].State != types.StepStatePending {
+		t.Errorf("interrupted step state = %s, want pending", op.Steps[1].State)
+	}
+
+	// The paused state must survive another restart.
+	stored, err := store.GetOperation(ctx, persisted.ID)
+	if err != nil {
+		t.Fatalf("GetOperation from store failed: %v", err)
+	}
+	if stored.State != types.StatePaused {
+		t.Errorf("stored state = %s, want paused", stored.State)
+	}
+}
+
+func TestResumeRunningOperations_AutoResume(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	persisted := persistInterruptedOperation(t, store)
+
+	engine := NewEngine(EngineConfig{Store: store, DefaultPollInterval: 10 * time.Millisecond})
+	executed := make(chan string, 2)
+	engine.handlers["test_action"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		executed <- step.ID
+		return nil
+	}
+	ctx := context.Background()
+
+	runningOps, err := engine.LoadFromStore(ctx)
+	if err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	engine.ResumeRunningOperations(ctx, runningOps, true)
+
+	select {
+	case stepID := <-executed:
+		if stepID != "step-2" {
+			t.Errorf("resumed at %s, want step-2", stepID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("operation was not resumed")
+	}
+
+	// The completion event is the executor's last write to the store, so
+	// waiting for it keeps the temp dir from being removed under it.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		events, _ := store.GetEvents(ctx, persisted.ID)
+		for _, ev := range events {
+			if ev.Type != "operation_completed" {
+				continue
+			}
+			op, _ := engine.GetOperation(persisted.ID)
+			engine.mu.RLock()
+			state := op.State
+			engine.mu.RUnlock()
+			if state != types.StateCompleted {
+				t.Errorf("state = %s, want completed", state)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("expected resumed operation to complete")
+}
+
+// TestExecuteStep_StepLogger verifies that handlers log through a logger that
+// already carries the operation and step.
+func TestExecuteStep_StepLogger(t *testing.T) {
+	var buf bytes.Buffer
+	engine := NewEngine(EngineConfig{Logger: slog.New(slog.NewJSONHandler(&buf, nil))})
+	engine.handlers["test_action"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		engine.stepLogger(ctx).Info("handler line", "instance_id", "db-1")
+		return nil
+	}
+	op := &types.Operation{
+		ID:    "op-logging",
+		Steps: []types.Step{{ID: "step-1", Name: "Test", Action: "test_action"}},
+	}
+
+	if err := engine.executeStep(context.Background(), op, &op.Steps[0]); err != nil {
+		t.Fatalf("executeStep failed: %v", err)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected one JSON log line, got %q: %v", buf.String(), err)
+	}
+	for key, want := range map[string]string{
+		"msg":          "handler line",
+		"operation_id": "op-logging",
+		"step_id":      "step-1",
+		"action":       "test_action",
+		"instance_id":  "db-1",
+	} {
+		if line[key] != want {
+			t.Errorf("%s = %v, want %q", key, line[key], want)
+		}
+	}
+}
+
+func TestCreateOperation_ClusterAvailabilityPrecheck(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	t.Run("available cluster proceeds", func(t *testing.T) {
+		op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		if op.Steps[0].Name == "Wait for cluster to become available" {
+			t.Error("did not expect a leading wait step for an available cluster")
+		}
+	})
+
+	// Hold demo-multi in "modifying" for the remaining subtests.
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-multi",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+	if err := mockState.SetClusterStatus("demo-multi", "modifying"); err != nil {
+		t.Fatalf("SetClusterStatus failed: %v", err)
+	}
+
+	t.Run("modifying cluster blocks creation", func(t *testing.T) {
+		_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, CreateOptions{})
+		if !errors.Is(err, internalerrors.ErrClusterNotAvailable) {
+			t.Fatalf("expected ErrClusterNotAvailable, got: %v", err)
+		}
+		if !containsAny(err.Error(), "modifying") {
+			t.Errorf("expected current status in error, got: %v", err)
+		}
+	})
+
+	t.Run("wait_for_available queues a wait step", func(t *testing.T) {
+		op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, CreateOptions{WaitForAvailable: true})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		if op.Steps[0].Action != "wait_cluster_available" {
+			t.Errorf("first step action = %q, want wait_cluster_available", op.Steps[0].Action)
+		}
+		if len(op.Warnings) == 0 {
+			t.Error("expected a warning recording the unavailable cluster")
+		}
+	})
+}
+
+func TestCreateOperation_MaintenanceWindow(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// windowSpec formats a window from start to end in UTC.
+	windowSpec := func(start, end time.Time) string {
+		start, end = start.UTC(), end.UTC()
+		return fmt.Sprintf("%s:%02d:%02d-%s:%02d:%02d",
+			start.Weekday().String()[:3], start.Hour(), start.Minute(),
+			end.Weekday().String()[:3], end.Hour(), end.Minute())
+	}
+	now := time.Now()
+
+	closed, err := maintwindow.Parse(windowSpec(now.Add(12*time.Hour), now.Add(13*time.Hour)), "UTC")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	engine.maintenanceWindow = closed
+
+	t.Run("outside window is refused", func(t *testing.T) {
+		_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{})
+		if !errors.Is(err, internalerrors.ErrOutsideMaintenanceWindow) {
+			t.Fatalf("expected ErrOutsideMaintenanceWindow, got: %v", err)
+		}
+		var windowErr *OutsideWindowError
+		if !errors.As(err, &windowErr) {
+			t.Fatalf("expected *OutsideWindowError, got %T", err)
+		}
+		if until := time.Until(windowErr.NextStart); until < 11*time.Hour || until > 13*time.Hour {
+			t.Errorf("next window opens in %s, want about 12h", until)
+		}
+	})
+
+	t.Run("override records a warning", func(t *testing.T) {
+		op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{OverrideWindow: true, DryRun: true})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		if len(op.Warnings) == 0 || !containsAny(op.Warnings[0], "outside maintenance window") {
+			t.Errorf("expected an override warning, got %v", op.Warnings)
+		}
+	})
+
+	t.Run("inside window proceeds", func(t *testing.T) {
+		open, err := maintwindow.Parse(windowSpec(now.Add(-time.Hour), now.Add(time.Hour)), "UTC")
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		engine.maintenanceWindow = open
+		op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		if len(op.Warnings) != 0 {
+			t.Errorf("expected no warnings inside the window, got %v", op.Warnings)
+		}
+	})
+}
+
+func TestRebootCluster_SkipsUnavailableInstances(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeRebootCluster, "demo-multi", "us-east-1", nil, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	// Break the reader once the preflight check has passed, as if it failed
+	// partway through the reboot.
+	firstReboot := slices.IndexFunc(op.Steps, func(s types.Step) bool { return s.Action == "reboot_instance" })
+	if err := engine.SetPauseBeforeSteps(ctx, op.ID, []int{firstReboot}); err != nil {
+		t.Fatalf("SetPauseBeforeSteps failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	if err := mockState.SetInstanceStatus("demo-multi-reader-2", "incompatible-parameters"); err != nil {
+		t.Fatalf("SetInstanceStatus failed: %v", err)
+	}
+	if err := engine.ResumeOperation(ctx, op.ID, types.InterventionResponse{Action: "continue"}); err != nil {
+		t.Fatalf("ResumeOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	events, _ := engine.GetEvents(op.ID)
+	var skipped []string
+	for _, event := range events {
+		if event.Type == "instance_skipped" {
+			skipped = append(skipped, event.Message)
+		}
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "demo-multi-reader-2") {
+		t.Errorf("instance_skipped events = %v, want one for demo-multi-reader-2", skipped)
+	}
+
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	for _, step := range op.Steps {
+		if step.Name == "Wait for reader 2" && !strings.Contains(string(step.Result), "skipped") {
+			t.Errorf("wait for skipped reader result = %s, want skipped", step.Result)
+		}
+	}
+}
+
+func TestApplyPendingMaintenance(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	t.Run("nothing pending is rejected", func(t *testing.T) {
+		_, err := engine.CreateOperation(ctx, types.OperationTypeApplyPendingMaintenance, "demo-single", "us-east-1", nil, CreateOptions{})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+		}
+	})
+
+	t.Run("applies each pending action", func(t *testing.T) {
+		op, err := engine.CreateOperation(ctx, types.OperationTypeApplyPendingMaintenance, "demo-multi", "us-east-1", nil, CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		applies := 0
+		for _, step := range op.Steps {
+			if step.Action == "apply_pending_maintenance" {
+				applies++
+			}
+		}
+		if applies != 2 {
+			t.Fatalf("got %d apply steps, want one per seeded reader action", applies)
+		}
+
+		if err := engine.StartOperation(ctx, op.ID); err != nil {
+			t.Fatalf("StartOperation failed: %v", err)
+		}
+		waitForState(t, engine, op, types.StateCompleted)
+
+		for _, id := range []string{"demo-multi-reader-1", "demo-multi-reader-2"} {
+			if pending := mockState.PendingMaintenanceActions(id); len(pending) != 0 {
+				t.Errorf("%s still has pending actions: %+v", id, pending)
+			}
+		}
+	})
+}
+
+func TestServerlessScaling(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name, cluster, params string
+	}{
+		{"provisioned cluster", "demo-single", `{"min_capacity": 1, "max_capacity": 8}`},
+		{"range outside AWS limits", "demo-serverless", `{"min_capacity": 0, "max_capacity": 8}`},
+		{"unchanged range", "demo-serverless", `{"min_capacity": 0.5, "max_capacity": 8}`},
+	} {
+		t.Run(tc.name+" is rejected", func(t *testing.T) {
+			_, err := engine.CreateOperation(ctx, types.OperationTypeServerlessScaling, tc.cluster, "us-east-1", json.RawMessage(tc.params), CreateOptions{})
+			if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+				t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+			}
+		})
+	}
+
+	t.Run("applies the new range", func(t *testing.T) {
+		op, err := engine.CreateOperation(ctx, types.OperationTypeServerlessScaling, "demo-serverless", "us-east-1",
+			json.RawMessage(`{"min_capacity": 2, "max_capacity": 64}`), CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+		if err := engine.StartOperation(ctx, op.ID); err != nil {
+			t.Fatalf("StartOperation failed: %v", err)
+		}
+		waitForState(t, engine, op, types.StateCompleted)
+
+		cluster, _ := mockState.GetCluster("demo-serverless")
+		if cluster.ServerlessV2MinCapacity != 2 || cluster.ServerlessV2MaxCapacity != 64 {
+			t.Errorf("capacity = %g-%g, want 2-64", cluster.ServerlessV2MinCapacity, cluster.ServerlessV2MaxCapacity)
+		}
+	})
+}
+
+// restoreValidatorFunc adapts a function to RestoreValidator.
+type restoreValidatorFunc func(ctx context.Context, region, clusterID, query string) error
+
+func (f restoreValidatorFunc) ValidateRestore(ctx context.Context, region, clusterID, query string) error {
+	return f(ctx, region, clusterID, query)
+}
+
+func TestSnapshotRestoreTest(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+	params := json.RawMessage(`{"validation_query": "SELECT count(*) FROM orders"}`)
+
+	t.Run("validation query needs a validator", func(t *testing.T) {
+		_, err := engine.CreateOperation(ctx, types.OperationTypeSnapshotRestoreTest, "demo-single", "us-east-1", params, CreateOptions{})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+		}
+	})
+
+	t.Run("restores, validates and deletes", func(t *testing.T) {
+		var validatedCluster, validatedQuery string
+		engine.restoreValidator = restoreValidatorFunc(func(_ context.Context, _, clusterID, query string) error {
+			validatedCluster, validatedQuery = clusterID, query
+			return nil
+		})
+
+		op, err := engine.CreateOperation(ctx, types.OperationTypeSnapshotRestoreTest, "demo-single", "us-east-1", params, CreateOptions{})
+		if err != nil {
+			t.Fatalf("CreateOperation failed: %v", err)
+		}
+
+		// Take the planned restore identifier so the restore has to pick another.
+		planned := rds.GenerateRestoreClusterID("demo-single", op.ID)
+		if err := mockState.CreateSnapshot("demo-single", "blocker"); err != nil {
+			t.Fatalf("CreateSnapshot failed: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if snap, _ := mockState.GetSnapshot("blocker"); snap.Status == "available" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("blocker snapshot never became available")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err := mockState.RestoreClusterFromSnapshot("blocker", planned); err != nil {
+			t.Fatalf("RestoreClusterFromSnapshot failed: %v", err)
+		}
+
+		if err := engine.StartOperation(ctx, op.ID); err != nil {
+			t.Fatalf("StartOperation failed: %v", err)
+		}
+		waitForState(t, engine, op, types.StateCompleted)
+
+		if !strings.HasPrefix(validatedCluster, planned+"-") {
+			t.Errorf("validated cluster %q, want %s with a timestamp suffix", validatedCluster, planned)
+		}
+		if validatedQuery != "SELECT count(*) FROM orders" {
+			t.Errorf("validated query %q", validatedQuery)
+		}
+		if _, ok := mockState.GetCluster(planned); !ok {
+			t.Errorf("cluster %s that held the planned identifier was touched", planned)
+		}
+
+		deadline = time.Now().Add(2 * time.Second)
+		for {
+			if _, ok := mockState.GetCluster(validatedCluster); !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("restored cluster %s was not deleted", validatedCluster)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestCreateOperation_DryRunPlansWithoutStarting(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	instancesBefore := len(mockState.ListInstances())
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge"}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	if op.State != types.StatePlanned {
+		t.Errorf("state = %q, want %q", op.State, types.StatePlanned)
+	}
+	if op.Plan == nil || !op.Plan.CreatesTempInstance || !op.Plan.IncludesFailover || op.Plan.TempInstanceType != "db.r6g.xlarge" {
+		t.Errorf("plan = %+v, want temp instance db.r6g.xlarge and failover", op.Plan)
+	}
+	if op.Plan != nil && op.Plan.StepCount != len(op.Steps) {
+		t.Errorf("plan step count = %d, want %d", op.Plan.StepCount, len(op.Steps))
+	}
+	if got := len(mockState.ListInstances()); got != instancesBefore {
+		t.Errorf("dry run created instances: %d before, %d after", instancesBefore, got)
+	}
+
+	if err := engine.StartOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected StartOperation on a plan to fail with ErrInvalidState, got: %v", err)
+	}
+
+	for _, step := range op.Steps {
+		engine.handlers[step.Action] = func(ctx context.Context, op *types.Operation, step *types.Step) error { return nil }
+	}
+	if err := engine.ConfirmOperation(ctx, op.ID); err != nil {
+		t.Fatalf("ConfirmOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	if err := engine.ConfirmOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected second confirm to fail with ErrInvalidState, got: %v", err)
+	}
+}
+
+func TestCreateOperation_DryRunWriterExcludedSkipsFailover(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.large","exclude_instances":["demo-multi-writer"]}`)
+	op, err := engine.CreateOperation(context.Background(), types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if op.Plan == nil || op.Plan.IncludesFailover {
+		t.Errorf("plan = %+v, want no failover when the writer is excluded", op.Plan)
+	}
+}
+
+// waitForState polls until op reaches the wanted state or the test times out.
+func waitForState(t *testing.T, engine *Engine, op *types.Operation, want types.OperationState) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		engine.mu.RLock()
+		state := op.State
+		engine.mu.RUnlock()
+		if state == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("operation state = %q, want %q", state, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResumeOperation_RetryCleanup(t *testing.T) {
+	engine := &Engine{
+		operations:          make(map[string]*types.Operation),
+		events:              make(map[string][]types.Event),
+		logger:              slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		handlers:            make(map[string]StepHandler),
+		store:               &storage.NullStore{},
+		defaultWaitTimeout:  5 * time.Second,
+		defaultPollInterval: 10 * time.Millisecond,
+	}
+
+	var switchovers, cleanups int
+	engine.handlers["switchover_blue_green"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		switchovers++
+		return nil
+	}
+	engine.handlers["cleanup_blue_green"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		cleanups++
+		if cleanups == 1 {
+			return errors.Wrap(internalerrors.ErrInterventionRequired, "cleanup partially failed")
+		}
+		return nil
+	}
+
+	op := &types.Operation{
+		ID:        "test-op-retry-cleanup",
+		Type:      types.OperationTypeEngineUpgrade,
+		State:     types.StateCreated,
+		ClusterID: "test-cluster",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Switchover", Action: "switchover_blue_green", State: types.StepStatePending},
+			{ID: "step-2", Name: "Cleanup", Action: "cleanup_blue_green", State: types.StepStatePending},
+		},
+		CreatedAt: time.Now(),
+	}
+	engine.operations[op.ID] = op
+	ctx := context.Background()
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	if err := engine.ResumeOperation(ctx, op.ID, types.InterventionResponse{Action: "retry_cleanup"}); err != nil {
+		t.Fatalf("retry_cleanup failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	if switchovers != 1 {
+		t.Errorf("switchover ran %d times, want 1", switchovers)
+	}
+	if cleanups != 2 {
+		t.Errorf("cleanup ran %d times, want 2", cleanups)
+	}
+}
+
+// TestExecuteSteps_InterventionPauseReason verifies that a pause for
+// intervention explains itself: with the handler's own reason if it set
+// one, and otherwise with the step's error.
+func TestExecuteSteps_InterventionPauseReason(t *testing.T) {
+	tests := []struct {
+		name          string
+		handlerReason string
+		want          string
+	}{
+		{name: "step error", want: "cluster is full: intervention required"},
+		{name: "handler reason", handlerReason: "Delete the old cluster manually", want: "Delete the old cluster manually"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{
+				operations:          make(map[string]*types.Operation),
+				events:              make(map[string][]types.Event),
+				logger:              slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+				handlers:            make(map[string]StepHandler),
+				store:               &storage.NullStore{},
+				defaultWaitTimeout:  5 * time.Second,
+				defaultPollInterval: 10 * time.Millisecond,
+			}
+			engine.handlers["test_action"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+				if tt.handlerReason != "" {
+					op.PauseReason = tt.handlerReason
+				}
+				return errors.Wrap(internalerrors.ErrInterventionRequired, "cluster is full")
+			}
+
+			op := &types.Operation{
+				ID:        "test-op-pause-reason",
+				State:     types.StateCreated,
+				ClusterID: "test-cluster",
+				Steps:     []types.Step{{ID: "step-1", Name: "Create", Action: "test_action", State: types.StepStatePending}},
+				CreatedAt: time.Now(),
+			}
+			engine.operations[op.ID] = op
+
+			if err := engine.StartOperation(context.Background(), op.ID); err != nil {
+				t.Fatalf("StartOperation failed: %v", err)
+			}
+			waitForState(t, engine, op, types.StatePaused)
+
+			engine.mu.RLock()
+			defer engine.mu.RUnlock()
+			if op.PauseReason != tt.want {
+				t.Errorf("PauseReason = %q, want %q", op.PauseReason, tt.want)
+			}
+			if op.Steps[0].Error != "cluster is full: intervention required" {
+				t.Errorf("step error = %q", op.Steps[0].Error)
+			}
+		})
+	}
+}
+
+func TestResumeOperation_RetryCleanupRejectsBeforeSwitchover(t *testing.T) {
+	engine := &Engine{
+		operations: make(map[string]*types.Operation),
+		events:     make(map[string][]types.Event),
+		store:      &storage.NullStore{},
+	}
+	op := &types.Operation{
+		ID:    "test-op-retry-cleanup-early",
+		State: types.StatePaused,
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Switchover", Action: "switchover_blue_green", State: types.StepStateFailed},
+			{ID: "step-2", Name: "Cleanup", Action: "cleanup_blue_green", State: types.StepStatePending},
+		},
+	}
+	engine.operations[op.ID] = op
+
+	err := engine.ResumeOperation(context.Background(), op.ID, types.InterventionResponse{Action: "retry_cleanup"})
+	if !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got: %v", err)
+	}
+	if op.State != types.StatePaused || op.Steps[0].State != types.StepStateFailed {
+		t.Error("rejected retry_cleanup must leave the operation untouched")
+	}
+}
+
+func TestGetWaitTimeoutAndPollInterval(t *testing.T) {
+	engine := &Engine{
+		defaultWaitTimeout:  45 * time.Minute,
+		defaultPollInterval: 30 * time.Second,
+	}
+
+	tests := []struct {
+		name         string
+		opTimeout    int
+		step         *types.Step
+		wantTimeout  time.Duration
+		wantInterval time.Duration
+	}{
+		{
+			name:         "engine defaults",
+			step:         &types.Step{},
+			wantTimeout:  45 * time.Minute,
+			wantInterval: 30 * time.Second,
+		},
+		{
+			name:         "step overrides defaults",
+			step:         &types.Step{TimeoutSeconds: 300, PollIntervalSeconds: 5},
+			wantTimeout:  5 * time.Minute,
+			wantInterval: 5 * time.Second,
+		},
+		{
+			name:         "operation timeout wins over step",
+			opTimeout:    7200,
+			step:         &types.Step{TimeoutSeconds: 300},
+			wantTimeout:  2 * time.Hour,
+			wantInterval: 30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &types.Operation{WaitTimeout: tt.opTimeout}
+			if got := engine.getWaitTimeout(op, tt.step); got != tt.wantTimeout {
+				t.Errorf("getWaitTimeout = %v, want %v", got, tt.wantTimeout)
+			}
+			if got := engine.getPollInterval(tt.step); got != tt.wantInterval {
+				t.Errorf("getPollInterval = %v, want %v", got, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestCancelOperation_DeletesTempInstance(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge"}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	failoverIdx := -1
+	for i, step := range op.Steps {
+		if step.Action == "failover_to_instance" {
+			failoverIdx = i
+			break
+		}
+	}
+	if failoverIdx < 0 {
+		t.Fatal("expected a failover_to_instance step")
+	}
+	if err := engine.SetPauseBeforeSteps(ctx, op.ID, []int{failoverIdx}); err != nil {
+		t.Fatalf("SetPauseBeforeSteps failed: %v", err)
+	}
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	tempID := engine.findCreatedInstanceID(op)
+	if tempID == "" {
+		t.Fatal("expected temp instance to have been created")
+	}
+
+	if err := engine.CancelOperation(ctx, op.ID); err != nil {
+		t.Fatalf("CancelOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCancelled)
+
+	last := op.Steps[len(op.Steps)-1]
+	if last.Action != "delete_instance" || last.State != types.StepStateCompleted {
+		t.Errorf("last step = %s (%s), want completed compensating delete_instance", last.Action, last.State)
+	}
+	if op.Steps[failoverIdx].State != types.StepStatePending {
+		t.Errorf("failover step state = %q, want it left pending", op.Steps[failoverIdx].State)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		inst, ok := mockState.GetInstance(tempID)
+		if !ok || inst.Status == "deleting" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("temp instance %s still %q after cancellation", tempID, inst.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := engine.CancelOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected cancelling a cancelled operation to fail with ErrInvalidState, got: %v", err)
+	}
+}
+
+func TestCancelOperation_InterruptsRunningStep(t *testing.T) {
+	engine := &Engine{
+		operations:          make(map[string]*types.Operation),
+		events:              make(map[string][]types.Event),
+		handlers:            make(map[string]StepHandler),
+		store:               &storage.NullStore{},
+		logger:              slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		defaultPollInterval: time.Millisecond,
+	}
+
+	blocking := make(chan struct{})
+	engine.handlers["test_wait"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		close(blocking)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	engine.handlers["test_action"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		t.Error("steps after the cancelled one must not run")
+		return nil
+	}
+
+	op := &types.Operation{
+		ID:        "test-op-cancel-running",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateCreated,
+		ClusterID: "test-cluster",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Wait forever", Action: "test_wait", State: types.StepStatePending, MaxRetries: 3},
+			{ID: "step-2", Name: "Never runs", Action: "test_action", State: types.StepStatePending},
+		},
+		CreatedAt: time.Now(),
+	}
+	engine.operations[op.ID] = op
+
+	ctx := context.Background()
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	<-blocking
+
+	if err := engine.CancelOperation(ctx, op.ID); err != nil {
+		t.Fatalf("CancelOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCancelled)
+
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	if op.Steps[0].State != types.StepStateFailed || op.Steps[0].RetryCount != 0 {
+		t.Errorf("interrupted step = %s (retries %d), want failed without retries", op.Steps[0].State, op.Steps[0].RetryCount)
+	}
+	if len(op.Steps) != 2 {
+		t.Errorf("expected no compensating steps without a temp instance, got %d steps", len(op.Steps))
+	}
+	if _, ok := engine.runContexts[op.ID]; ok {
+		t.Error("run context should be released after cancellation")
+	}
+}
+
+func TestCancelOperation_RejectsDuringSwitchover(t *testing.T) {
+	engine := &Engine{
+		operations: make(map[string]*types.Operation),
+		events:     make(map[string][]types.Event),
+		store:      &storage.NullStore{},
+		logger:     slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	op := &types.Operation{
+		ID:        "test-op-cancel-switchover",
+		Type:      types.OperationTypeEngineUpgrade,
+		State:     types.StateRunning,
+		ClusterID: "test-cluster",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Switchover", Action: "switchover_blue_green", State: types.StepStateInProgress},
+		},
+	}
+	engine.operations[op.ID] = op
+
+	ctx := context.Background()
+	if err := engine.CancelOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected ErrInvalidState during switchover, got: %v", err)
+	}
+	if op.State != types.StateRunning {
+		t.Errorf("state = %q, want it left running", op.State)
+	}
+	if err := engine.CancelOperation(ctx, "missing"); !errors.Is(err, internalerrors.ErrOperationNotFound) {
+		t.Errorf("expected ErrOperationNotFound, got: %v", err)
+	}
+}
+
+func TestRollbackOperation_RestoresInstanceTypes(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge","skip_temp_instance":true}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	// Stop before the last instance is modified, leaving the cluster mixed.
+	var modifyIdx []int
+	for i, step := range op.Steps {
+		if step.Action == "modify_instance" {
+			modifyIdx = append(modifyIdx, i)
+		}
+	}
+	if len(modifyIdx) != 3 {
+		t.Fatalf("expected 3 modify steps, got %d", len(modifyIdx))
+	}
+	if err := engine.SetPauseBeforeSteps(ctx, op.ID, []int{modifyIdx[2]}); err != nil {
+		t.Fatalf("SetPauseBeforeSteps failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	if err := engine.RollbackOperation(ctx, op.ID); err != nil {
+		t.Fatalf("RollbackOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateRolledBack)
+
+	for _, id := range []string{"demo-multi-writer", "demo-multi-reader-1", "demo-multi-reader-2"} {
+		inst, ok := mockState.GetInstance(id)
+		if !ok {
+			t.Fatalf("instance %s missing", id)
+		}
+		if inst.InstanceType != "db.r6g.large" {
+			t.Errorf("%s instance type = %s, want db.r6g.large", id, inst.InstanceType)
+		}
+	}
+
+	var rollbackSteps []types.Step
+	for _, step := range op.Steps {
+		if step.Rollback {
+			rollbackSteps = append(rollbackSteps, step)
+		}
+	}
+	if len(rollbackSteps) != 4 {
+		t.Fatalf("expected 4 rollback steps for 2 resized instances, got %d", len(rollbackSteps))
+	}
+	for _, step := range rollbackSteps {
+		if step.State != types.StepStateCompleted {
+			t.Errorf("rollback step %q state = %s, want completed", step.Name, step.State)
+		}
+	}
+
+	if err := engine.RollbackOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected rolling back a rolled-back operation to fail with ErrInvalidState, got: %v", err)
+	}
+}
+
+func TestCACertRotation_AppliesCertificateToAllInstances(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"target_ca_certificate":"rds-ca-rsa2048-g1"}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeCACertRotation, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	for _, id := range []string{"demo-multi-writer", "demo-multi-reader-1", "demo-multi-reader-2"} {
+		inst, ok := mockState.GetInstance(id)
+		if !ok {
+			t.Fatalf("instance %s missing", id)
+		}
+		if got := inst.CACertificate(); got != "rds-ca-rsa2048-g1" {
+			t.Errorf("%s CA certificate = %s, want rds-ca-rsa2048-g1", id, got)
+		}
+	}
+}
+
+func TestInstanceTypeChange_TagsTempResources(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge","final_snapshot":true,"tags":{"CostCenter":"42","Team":"dba"}}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	tempID := rds.GenerateTempInstanceID("demo-multi", op.ID)
+	got := mockState.ResourceTags("arn:aws:rds:us-east-1:123456789012:db:" + tempID)
+	for key, want := range map[string]string{
+		"Environment":         "demo", // inherited from the writer
+		"Team":                "dba",  // operator tag overrides the writer's
+		"CostCenter":          "42",
+		rds.TagKeyMachine:     "temp-instance",
+		rds.TagKeyOperationID: op.ID,
+	} {
+		if got[key] != want {
+			t.Errorf("temp instance tag %s = %q, want %q", key, got[key], want)
+		}
+	}
+
+	snapshotTags := mockState.ResourceTags("arn:aws:rds:us-east-1:123456789012:cluster-snapshot:demo-multi-temp-final-" + op.ID)
+	if snapshotTags["CostCenter"] != "42" || snapshotTags[rds.TagKeyMachine] == "" {
+		t.Errorf("final snapshot tags = %v, want CostCenter and %s", snapshotTags, rds.TagKeyMachine)
+	}
+
+	t.Run("reserved tag keys are rejected", func(t *testing.T) {
+		params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge","tags":{"rds-maint-operation-id":"spoofed"}}`)
+		_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+		}
+	})
+}
+
+func TestMinorVersionUpgrade_ParameterGroupRebootsOnlyForStaticChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      map[string]mock.MockParameter
+		wantReboots bool
+	}{
+		{
+			name:        "static change reboots instances",
+			params:      map[string]mock.MockParameter{"shared_preload_libraries": {Value: "pg_stat_statements,pg_cron", ApplyType: "static"}},
+			wantReboots: true,
+		},
+		{
+			name:        "dynamic change skips reboots",
+			params:      map[string]mock.MockParameter{"work_mem": {Value: "65536", ApplyType: "dynamic"}},
+			wantReboots: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, mockState, cleanup := testEngineWithMockState(t)
+			defer cleanup()
+			engine.registerHandlers()
+			ctx := context.Background()
+
+			// The new group keeps logical replication on, as demo-multi-pg has it.
+			mockState.SetClusterParameters("demo-multi-tuned-pg", map[string]mock.MockParameter{
+				"rds.logical_replication": {Value: "1", ApplyType: "static"},
+			})
+			mockState.SetClusterParameters("demo-multi-tuned-pg", tt.params)
+
+			params := json.RawMessage(`{"target_engine_version":"15.5","db_cluster_parameter_group_name":"demo-multi-tuned-pg"}`)
+			op, err := engine.CreateOperation(ctx, types.OperationTypeMinorVersionUpgrade, "demo-multi", "us-east-1", params, CreateOptions{})
+			if err != nil {
+				t.Fatalf("CreateOperation failed: %v", err)
+			}
+			if err := engine.StartOperation(ctx, op.ID); err != nil {
+				t.Fatalf("StartOperation failed: %v", err)
+			}
+			waitForState(t, engine, op, types.StateCompleted)
+
+			cluster, _ := mockState.GetCluster("demo-multi")
+			if cluster.ParameterGroupName != "demo-multi-tuned-pg" {
+				t.Errorf("cluster parameter group = %s, want demo-multi-tuned-pg", cluster.ParameterGroupName)
+			}
+
+			op, _ = engine.GetOperation(op.ID)
+			var rebooted, skipped int
+			for _, step := range op.Steps {
+				switch step.Action {
+				case "apply_parameter_group":
+					var result struct {
+						PendingReboot  []string `json:"pending_reboot"`
+						RebootRequired bool     `json:"reboot_required"`
+					}
+					if err := json.Unmarshal(step.Result, &result); err != nil {
+						t.Fatalf("unmarshal apply_parameter_group result: %v", err)
+					}
+					if result.RebootRequired != tt.wantReboots {
+						t.Errorf("reboot_required = %v, want %v", result.RebootRequired, tt.wantReboots)
+					}
+					if tt.wantReboots && !slices.Contains(result.PendingReboot, "shared_preload_libraries") {
+						t.Errorf("pending_reboot = %v, want shared_preload_libraries", result.PendingReboot)
+					}
+				case "reboot_instance":
+					if strings.Contains(string(step.Result), `"skipped"`) {
+						skipped++
+					} else {
+						rebooted++
+					}
+				}
+			}
+
+			if tt.wantReboots && (rebooted != 3 || skipped != 0) {
+				t.Errorf("rebooted %d, skipped %d; want all 3 instances rebooted", rebooted, skipped)
+			}
+			if !tt.wantReboots && (rebooted != 0 || skipped != 3) {
+				t.Errorf("rebooted %d, skipped %d; want all 3 reboots skipped", rebooted, skipped)
+			}
+		})
+	}
+}
+
+func TestMetrics_RecordsOperationAndStepOutcomes(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	engine.metrics = metrics.New()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"skip_temp_instance":true,"exclude_instances":["demo-multi-writer"]}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	var b strings.Builder
+	if _, err := engine.metrics.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		`rdsmaint_operations_total{type="instance_cycle",state="created"} 1`,
+		`rdsmaint_operations_total{type="instance_cycle",state="running"} 1`,
+		`rdsmaint_operations_total{type="instance_cycle",state="completed"} 1`,
+		`rdsmaint_steps_total{action="reboot_instance",state="completed"} 2`,
+		`rdsmaint_step_duration_seconds_count{action="reboot_instance"} 2`,
+		`rdsmaint_wait_polls_count{action="wait_instance_available"} 2`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestWaitClusterAvailable_SlowCallTimesOutAndPollContinues(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithCallTimeouts(t, rds.CallTimeouts{
+		PerAction: map[string]time.Duration{"DescribeDBClusters": 150 * time.Millisecond},
+	})
+	defer cleanup()
+	engine.metrics = metrics.New()
+
+	// Every DescribeDBClusters hangs far longer than the call timeout until the
+	// fault is lifted, as a stuck AWS endpoint would.
+	faultID := mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeDelay,
+		Action:      "DescribeDBClusters",
+		Probability: 1,
+		DelayMs:     2000,
+		Enabled:     true,
+	})
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		mockState.Faults().RemoveFault(faultID)
+	}()
+
+	op := &types.Operation{ID: "op-slow", ClusterID: "demo-single", Region: "us-east-1"}
+	step := &types.Step{Name: "Wait for cluster", Action: "wait_cluster_available"}
+
+	start := time.Now()
+	if err := engine.handleWaitClusterAvailable(context.Background(), op, step); err != nil {
+		t.Fatalf("wait failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("wait took %v; a hung call should not block the poll loop", elapsed)
+	}
+
+	var b strings.Builder
+	engine.metrics.WriteTo(&b)
+	if strings.Contains(b.String(), `rdsmaint_wait_polls_bucket{action="wait_cluster_available",le="1"} 1`) {
+		t.Errorf("expected more than one poll after timed-out calls:\n%s", b.String())
+	}
+}
+
+func TestGetRDSClient_SharedPerRegion(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	first := &types.Operation{ID: "op-1", Region: "eu-west-1"}
+	second := &types.Operation{ID: "op-2", Region: "eu-west-1"}
+
+	a, err := engine.getRDSClient(ctx, first)
+	if err != nil {
+		t.Fatalf("getRDSClient failed: %v", err)
+	}
+	b, err := engine.getRDSClient(ctx, second)
+	if err != nil {
+		t.Fatalf("getRDSClient failed: %v", err)
+	}
+	if a != b {
+		t.Error("expected operations in the same region to share one client")
+	}
+}
+
+func TestCreateOperation_AllowedRegions(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+	engine.allowedRegions = []string{"us-east-1", "eu-west-1"}
+
+	_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "ap-south-1", nil, CreateOptions{})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter for a region outside the allow-list, got: %v", err)
+	}
+
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{}); err != nil {
+		t.Fatalf("CreateOperation in an allowed region failed: %v", err)
+	}
+}
+
+func TestCreateOperation_ClusterAllowAndDenyLists(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+	engine.clusterAllowlist = []string{"demo-*", "prod-*"}
+	engine.clusterDenylist = []string{"*-analytics", "demo-single"}
+
+	for _, tc := range []struct {
+		clusterID, want string
+	}{
+		{"staging-app", `matches no cluster allowlist pattern (demo-*, prod-*)`},
+		{"demo-single", `matches cluster denylist pattern "demo-single"`},
+		// Neither cluster exists, so a rejection proves no AWS call was made.
+		{"prod-analytics", `matches cluster denylist pattern "*-analytics"`},
+	} {
+		_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, tc.clusterID, "us-east-1", nil, CreateOptions{DryRun: true})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) || !containsAny(err.Error(), tc.want) {
+			t.Errorf("%s: err = %v, want a rejection naming %s", tc.clusterID, err, tc.want)
+		}
+	}
+
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, CreateOptions{DryRun: true}); err != nil {
+		t.Errorf("CreateOperation on an allowed cluster failed: %v", err)
+	}
+
+	engine.clusterAllowlist = nil
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-upgrade", "us-east-1", nil, CreateOptions{DryRun: true}); err != nil {
+		t.Errorf("CreateOperation without an allowlist failed: %v", err)
+	}
+}
+
+func TestResumeOperation_OverrideFailedCheck(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	engine.defaultPollInterval = 10 * time.Millisecond
+	ctx := context.Background()
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeRebootCluster, "demo-multi", "us-east-1", nil, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	client, err := engine.getRDSClient(ctx, op)
+	if err != nil {
+		t.Fatalf("getRDSClient failed: %v", err)
+	}
+	arn, err := client.GetClusterARN(ctx, op.ClusterID)
+	if err != nil {
+		t.Fatalf("GetClusterARN failed: %v", err)
+	}
+	if _, err := mockState.CreateBlueGreenDeployment("stray", arn, "16.4"); err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	options, err := engine.ResumeOptions(op.ID)
+	if err != nil {
+		t.Fatalf("ResumeOptions failed: %v", err)
+	}
+	if want := []string{"continue", "override", "abort"}; !slices.Equal(options, want) {
+		t.Fatalf("options = %v, want %v", options, want)
+	}
+
+	err = engine.ResumeOperation(ctx, op.ID, types.InterventionResponse{Action: "mark_complete"})
+	if !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState for a decision the pause does not offer, got: %v", err)
+	}
+
+	if err := engine.ResumeOperation(ctx, op.ID, types.InterventionResponse{Action: "override", Operator: "alice", Comment: "stray deployment is unrelated"}); err != nil {
+		t.Fatalf("override failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	engine.mu.RLock()
+	defer engine.mu.RUnlock()
+	if op.Steps[1].Action != "preflight_check" || op.Steps[1].State != types.StepStateSkipped {
+		t.Errorf("preflight step = %s/%s, want preflight_check skipped", op.Steps[1].Action, op.Steps[1].State)
+	}
+	var audit *types.Event
+	for i, event := range engine.events[op.ID] {
+		if event.Type == "operator_decision" {
+			audit = &engine.events[op.ID][i]
+		}
+	}
+	if audit == nil || !strings.Contains(string(audit.Data), `"operator":"alice"`) {
+		t.Errorf("expected an operator_decision event recording alice, got %+v", audit)
+	}
+}
+
+// conflictStore fails every save with ErrConcurrentModification, as
+// DynamoStore does once another process has saved the operation, and
+// returns stored (or getErr) from GetOperation.
+type conflictStore struct {
+	storage.NullStore
+	stored *types.Operation
+	getErr error
+	saves  int
+}
+
+func (s *conflictStore) SaveOperation(ctx context.Context, op *types.Operation) error {
+	s.saves++
+	return errors.Wrapf(internalerrors.ErrConcurrentModification, "operation %s", op.ID)
+}
+
+func (s *conflictStore) GetOperation(ctx context.Context, id string) (*types.Operation, error) {
+	return s.stored, s.getErr
+}
+
+func TestExecuteSteps_LostSaveStopsStaleCopy(t *testing.T) {
+	newOp := func() *types.Operation {
+		return &types.Operation{
+			ID:        "test-op-lost-race",
+			Type:      types.OperationTypeInstanceTypeChange,
+			State:     types.StateRunning,
+			ClusterID: "test-cluster",
+			Region:    "us-east-1",
+			Steps: []types.Step{
+				{ID: "step-1", Name: "Modify", Action: "modify_instance", State: types.StepStatePending},
+				{ID: "step-2", Name: "Wait", Action: "wait_instance_available", State: types.StepStatePending},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		getErr     error
+		wantState  types.OperationState
+		wantStored bool
+	}{
+		// Another process already ran the first step and paused.
+		{name: "reloads the stored copy", wantState: types.StatePaused, wantStored: true},
+		{name: "pauses when the reload fails", getErr: errors.New("throttled"), wantState: types.StatePaused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := newOp()
+			stored.State = types.StatePaused
+			stored.CurrentStepIndex = 1
+			stored.Steps[0].State = types.StepStateCompleted
+			store := &conflictStore{stored: stored, getErr: tt.getErr}
+			if tt.getErr != nil {
+				store.stored = nil
+			}
+
+			engine := &Engine{
+				operations: make(map[string]*types.Operation),
+				events:     make(map[string][]types.Event),
+				logger:     slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})),
+				handlers:   make(map[string]StepHandler),
+				store:      store,
+			}
+			var calls int
+			engine.handlers["modify_instance"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+				calls++
+				return nil
+			}
+			engine.handlers["wait_instance_available"] = engine.handlers["modify_instance"]
+
+			op := newOp()
+			engine.operations[op.ID] = op
+			ctx := engine.operationContext(op.ID)
+			engine.executeSteps(ctx, op)
+
+			if calls != 0 {
+				t.Errorf("handlers ran %d times, want none from the stale copy", calls)
+			}
+			if store.saves != 1 {
+				t.Errorf("saves = %d, want only the one that lost", store.saves)
+			}
+			if ctx.Err() == nil {
+				t.Error("stale copy's run context was not cancelled")
+			}
+
+			current, err := engine.GetOperation(op.ID)
+			if err != nil {
+				t.Fatalf("GetOperation failed: %v", err)
+			}
+			if current == op {
+				t.Fatal("engine still holds the stale copy")
+			}
+			if (current == stored) != tt.wantStored {
+				t.Errorf("engine holds the stored copy = %v, want %v", current == stored, tt.wantStored)
+			}
+			if current.State != tt.wantState {
+				t.Errorf("state = %s, want %s", current.State, tt.wantState)
+			}
+
+			// A later save of the stale copy is dropped.
+			engine.persistOperation(context.Background(), op)
+			if store.saves != 1 {
+				t.Errorf("saves = %d after saving the stale copy again, want 1", store.saves)
+			}
+
+			events, _ := engine.GetEvents(op.ID)
+			if !slices.ContainsFunc(events, func(ev types.Event) bool { return ev.Type == "operation_superseded" }) {
+				t.Errorf("events = %+v, want operation_superseded", events)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package machine
+
+import (
+	"strings"
+	"time"
+
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// warningEvents are the event types, other than "warning" itself, that mean
+// the operation needs a closer look without having failed.
+var warningEvents = map[string]bool{
+	"step_retry":            true,
+	"intervention_required": true,
+	"operation_paused":      true,
+	"instance_skipped":      true,
+	"rollback_started":      true,
+	"check_overridden":      true,
+	"operation_cancelled":   true,
+	"operation_aborted":     true,
+	"pending_modifications": true,
+	"end_state_mismatch":    true,
+	"operation_interrupted": true,
+}
+
+// eventSeverity classifies an event by its type.
+func eventSeverity(eventType string) types.EventSeverity {
+	switch {
+	case eventType == "error" || eventType == "deadline_exceeded" || strings.HasSuffix(eventType, "_failed"):
+		return types.EventSeverityError
+	case eventType == "warning" || warningEvents[eventType]:
+		return types.EventSeverityWarning
+	default:
+		return types.EventSeverityInfo
+	}
+}
+
+// eventStepIDLocked returns the ID of the operation's current step if it has
+// started and not yet completed, or "". Callers hold e.mu.
+func (e *Engine) eventStepIDLocked(operationID string) string {
+	op, ok := e.operations[operationID]
+	if !ok || op.CurrentStepIndex < 0 || op.CurrentStepIndex >= len(op.Steps) {
+		return ""
+	}
+	step := &op.Steps[op.CurrentStepIndex]
+	if step.StartedAt == nil || step.State == types.StepStateCompleted {
+		return ""
+	}
+	return step.ID
+}
+
+// nextEventTimeLocked returns the timestamp for a new event: now, or just
+// after the operation's previous event if the clock has not moved past it.
+// Serialized timestamps lose the monotonic clock reading, and stores order
+// events by timestamp, so ties or a wall clock stepping back would reorder
+// the log. Callers hold e.mu.
+func (e *Engine) nextEventTimeLocked(operationID string) time.Time {
+	now := time.Now()
+	events := e.events[operationID]
+	if len(events) == 0 {
+		return now
+	}
+	if last := events[len(events)-1].Timestamp; !now.After(last) {
+		return last.Add(time.Nanosecond)
+	}
+	return now
+}
+
+// GetEventsSince returns an operation's events that occurred after since, in
+// order. A zero since returns them all.
+func (e *Engine) GetEventsSince(operationID string, since time.Time) ([]types.Event, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	events, ok := e.events[operationID]
+	if !ok {
+		return nil, internalerrors.ErrOperationNotFound
+	}
+
+	out := make([]types.Event, 0, len(events))
+	for _, event := range events {
+		if event.Timestamp.After(since) {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
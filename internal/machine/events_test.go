@@ -0,0 +1,100 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestEventSeverity(t *testing.T) {
+	tests := map[string]types.EventSeverity{
+		"step_started":          types.EventSeverityInfo,
+		"info":                  types.EventSeverityInfo,
+		"warning":               types.EventSeverityWarning,
+		"intervention_required": types.EventSeverityWarning,
+		"step_retry":            types.EventSeverityWarning,
+		"error":                 types.EventSeverityError,
+		"step_failed":           types.EventSeverityError,
+		"cancel_cleanup_failed": types.EventSeverityError,
+		"deadline_exceeded":     types.EventSeverityError,
+	}
+	for eventType, want := range tests {
+		if got := eventSeverity(eventType); got != want {
+			t.Errorf("eventSeverity(%q) = %q, want %q", eventType, got, want)
+		}
+	}
+}
+
+// TestExecuteStep_EventsCarryStepAndOrder verifies that events are attributed
+// to the step that produced them and keep their order even when recorded
+// faster than the clock advances.
+func TestExecuteStep_EventsCarryStepAndOrder(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+	op := &types.Operation{
+		ID:    "op-events",
+		State: types.StateRunning,
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Noisy", Action: "noisy"},
+			{ID: "step-2", Name: "Next", Action: "noisy"},
+		},
+	}
+	engine.operations[op.ID] = op
+	engine.events[op.ID] = []types.Event{}
+	engine.handlers["noisy"] = func(ctx context.Context, op *types.Operation, step *types.Step) error {
+		for range 50 {
+			engine.addEvent(op.ID, "warning", "cleanup left something behind", nil)
+		}
+		return nil
+	}
+
+	engine.addEvent(op.ID, "operation_started", "Started", nil)
+	engine.executeSteps(context.Background(), op)
+
+	events, err := engine.GetEvents(op.ID)
+	if err != nil {
+		t.Fatalf("GetEvents failed: %v", err)
+	}
+	for i, event := range events {
+		if i > 0 && !event.Timestamp.After(events[i-1].Timestamp) {
+			t.Fatalf("event %d (%s) at %v is not after event %d at %v",
+				i, event.Type, event.Timestamp, i-1, events[i-1].Timestamp)
+		}
+	}
+
+	byType := map[string]map[string]int{}
+	for _, event := range events {
+		if byType[event.Type] == nil {
+			byType[event.Type] = map[string]int{}
+		}
+		byType[event.Type][event.StepID]++
+		if event.Type == "warning" && event.Severity != types.EventSeverityWarning {
+			t.Errorf("warning event has severity %q", event.Severity)
+		}
+	}
+	if got := byType["operation_started"][""]; got != 1 {
+		t.Errorf("operation_started should have no step, got %v", byType["operation_started"])
+	}
+	for _, stepID := range []string{"step-1", "step-2"} {
+		if got := byType["warning"][stepID]; got != 50 {
+			t.Errorf("got %d warnings for %s, want 50", got, stepID)
+		}
+		if got := byType["step_completed"][stepID]; got != 1 {
+			t.Errorf("got %d step_completed events for %s, want 1", got, stepID)
+		}
+	}
+
+	since := events[len(events)/2].Timestamp
+	later, err := engine.GetEventsSince(op.ID, since)
+	if err != nil {
+		t.Fatalf("GetEventsSince failed: %v", err)
+	}
+	if want := events[len(events)/2+1:]; len(later) != len(want) || later[0].ID != want[0].ID {
+		t.Errorf("GetEventsSince returned %d events starting at %s, want %d starting at %s",
+			len(later), later[0].ID, len(want), want[0].ID)
+	}
+	if none, _ := engine.GetEventsSince(op.ID, time.Now().Add(time.Hour)); len(none) != 0 {
+		t.Errorf("expected no events after the last one, got %d", len(none))
+	}
+}
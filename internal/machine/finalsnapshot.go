@@ -0,0 +1,73 @@
+package machine
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Final snapshot policies of a delete_instance step.
+const (
+	// finalSnapshotSkip deletes the instance without a final snapshot.
+	finalSnapshotSkip = "skip"
+	// finalSnapshotCreate takes a final snapshot under final_snapshot_id or
+	// a name derived from the instance and operation. RDS takes it as it
+	// deletes an instance outside a cluster; for an Aurora cluster member,
+	// which RDS cannot snapshot on its own, a cluster snapshot of that name
+	// is taken just before the delete.
+	finalSnapshotCreate = "create"
+)
+
+// tempInstanceFinalSnapshot is the policy for deleting temp instances. They
+// hold nothing the cluster does not, so a snapshot of the instance itself is
+// never taken; a final snapshot, when wanted, is of the cluster and taken
+// before the delete (see buildTempInstanceDeleteSteps).
+const tempInstanceFinalSnapshot = finalSnapshotSkip
+
+// deleteInstanceParams are the parameters of a delete_instance step.
+type deleteInstanceParams struct {
+	// InstanceID is the instance to delete; empty deletes the instance the
+	// operation created.
+	InstanceID string `json:"instance_id,omitempty"`
+	// FinalSnapshot is the final snapshot policy, skip or create. Empty
+	// means create when FinalSnapshotID is set and skip otherwise.
+	FinalSnapshot string `json:"final_snapshot,omitempty"`
+	// FinalSnapshotID names the final snapshot.
+	FinalSnapshotID string `json:"final_snapshot_id,omitempty"`
+}
+
+// finalSnapshotID returns the identifier of the final snapshot to take when
+// deleting instanceID, or "" to skip it. Without an explicit ID, the snapshot
+// is named after the instance and operation so a retried delete asks for the
+// same one.
+func (p deleteInstanceParams) finalSnapshotID(op *types.Operation, instanceID string) (string, error) {
+	switch p.FinalSnapshot {
+	case finalSnapshotSkip:
+		if p.FinalSnapshotID != "" {
+			return "", errors.Wrap(internalerrors.ErrInvalidParameter,
+				"final_snapshot_id cannot be combined with final_snapshot=skip")
+		}
+		return "", nil
+	case "":
+		return p.FinalSnapshotID, nil
+	case finalSnapshotCreate:
+		if p.FinalSnapshotID != "" {
+			return p.FinalSnapshotID, nil
+		}
+		return instanceID + "-final-" + shortOperationID(op.ID), nil
+	default:
+		return "", errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"final_snapshot must be %s or %s, not %q", finalSnapshotSkip, finalSnapshotCreate, p.FinalSnapshot)
+	}
+}
+
+// shortOperationID returns the first group of an operation's UUID, enough to
+// tell apart the snapshots of different operations on the same instance.
+func shortOperationID(id string) string {
+	if i := strings.IndexByte(id, '-'); i > 0 {
+		return id[:i]
+	}
+	return id
+}
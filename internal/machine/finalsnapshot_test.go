@@ -0,0 +1,69 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestDeleteInstanceParams_FinalSnapshotID(t *testing.T) {
+	op := &types.Operation{ID: "3f2a9c1e-7b4d-4e8a-9c0f-1d2e3f4a5b6c"}
+	tests := []struct {
+		name    string
+		params  deleteInstanceParams
+		want    string
+		wantErr bool
+	}{
+		{name: "default skips"},
+		{name: "explicit skip", params: deleteInstanceParams{FinalSnapshot: "skip"}},
+		{name: "create names the snapshot", params: deleteInstanceParams{FinalSnapshot: "create"}, want: "db-1-final-3f2a9c1e"},
+		{name: "create with ID", params: deleteInstanceParams{FinalSnapshot: "create", FinalSnapshotID: "keep-me"}, want: "keep-me"},
+		{name: "ID alone creates", params: deleteInstanceParams{FinalSnapshotID: "keep-me"}, want: "keep-me"},
+		{name: "skip with ID", params: deleteInstanceParams{FinalSnapshot: "skip", FinalSnapshotID: "keep-me"}, wantErr: true},
+		{name: "unknown policy", params: deleteInstanceParams{FinalSnapshot: "always"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.params.finalSnapshotID(op, "db-1")
+			if tt.wantErr {
+				if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+					t.Errorf("error = %v, want ErrInvalidParameter", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("finalSnapshotID() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteInstance_FinalSnapshot(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	op := &types.Operation{ID: "op-final-snapshot", ClusterID: "demo-multi", Region: "us-east-1"}
+	params, _ := json.Marshal(deleteInstanceParams{InstanceID: "demo-multi-reader-2", FinalSnapshot: finalSnapshotCreate})
+	step := &types.Step{Action: "delete_instance", Parameters: params}
+	if err := engine.handleDeleteInstance(context.Background(), op, step); err != nil {
+		t.Fatalf("handleDeleteInstance failed: %v", err)
+	}
+	if inst, _ := mockState.GetInstance("demo-multi-reader-2"); inst.PendingStatusChange != "deleting" {
+		t.Errorf("pending status = %q, want the instance to be deleting", inst.PendingStatusChange)
+	}
+	// RDS cannot snapshot a cluster member, so the cluster is snapshotted
+	// under the final snapshot's name.
+	if snap, ok := mockState.GetSnapshot("demo-multi-reader-2-final-op"); !ok || snap.ClusterID != "demo-multi" {
+		t.Errorf("final snapshot = %+v, %v; want a cluster snapshot of demo-multi", snap, ok)
+	}
+
+	params, _ = json.Marshal(deleteInstanceParams{InstanceID: "demo-multi-reader-1", FinalSnapshot: finalSnapshotSkip, FinalSnapshotID: "x"})
+	step = &types.Step{Action: "delete_instance", Parameters: params}
+	if err := engine.handleDeleteInstance(context.Background(), op, step); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("skip with a snapshot ID: error = %v, want ErrInvalidParameter", err)
+	}
+}
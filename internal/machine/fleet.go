@@ -0,0 +1,327 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// defaultMaxParallelClusters is how many clusters of a batch are worked on at
+// once when the request does not say: one, so a bad change stops at the
+// first cluster it breaks.
+const defaultMaxParallelClusters = 1
+
+// BatchSpec describes a batch operation: the same operation type and
+// parameters applied to each of a list of clusters.
+type BatchSpec struct {
+	Type       types.OperationType
+	ClusterIDs []string
+	Region     string
+	Params     json.RawMessage
+	// WaitTimeout, MaxDuration and CreatedBy are passed to every child
+	// operation, as in CreateOptions.
+	WaitTimeout int
+	MaxDuration int
+	CreatedBy   string
+	// MaxParallelClusters is how many child operations may be active at
+	// once. Zero uses defaultMaxParallelClusters.
+	MaxParallelClusters int
+}
+
+// CreateBatchOperation creates an operation on each cluster of the spec and
+// starts them in the background, at most MaxParallelClusters at a time. A
+// cluster whose operation cannot be created is recorded as failed in the
+// batch; the batch itself is only rejected when no operation can be created.
+func (e *Engine) CreateBatchOperation(ctx context.Context, spec BatchSpec) (*types.BatchOperation, error) {
+	if !types.ValidOperationTypes[spec.Type] {
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "unknown operation type: %s", spec.Type)
+	}
+	if len(spec.ClusterIDs) == 0 {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "cluster_ids must list at least one cluster")
+	}
+	seen := make(map[string]bool, len(spec.ClusterIDs))
+	for _, clusterID := range spec.ClusterIDs {
+		if strings.TrimSpace(clusterID) == "" {
+			return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "cluster_ids must not contain empty IDs")
+		}
+		if seen[clusterID] {
+			return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "cluster %s is listed more than once", clusterID)
+		}
+		seen[clusterID] = true
+	}
+	if spec.MaxParallelClusters < 0 {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "max_parallel_clusters must not be negative")
+	}
+	maxParallel := spec.MaxParallelClusters
+	if maxParallel == 0 {
+		maxParallel = defaultMaxParallelClusters
+	}
+	region := spec.Region
+	if region == "" {
+		region = e.defaultRegion
+	}
+	if !e.RegionAllowed(region) {
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"region %s is not in the allowed regions (%s)", region, strings.Join(e.allowedRegions, ", "))
+	}
+
+	now := time.Now()
+	batch := &types.BatchOperation{
+		ID:                  uuid.New().String(),
+		Type:                spec.Type,
+		Region:              region,
+		Parameters:          spec.Params,
+		MaxParallelClusters: maxParallel,
+		State:               types.BatchStateRunning,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	// Every plan is built up front, so a parameter one cluster cannot take is
+	// reported at once rather than when its turn comes.
+	var firstErr error
+	for i, clusterID := range spec.ClusterIDs {
+		child := types.BatchChild{ClusterID: clusterID}
+		op, err := e.CreateOperation(ctx, spec.Type, clusterID, region, spec.Params, CreateOptions{
+			WaitTimeout: spec.WaitTimeout,
+			MaxDuration: spec.MaxDuration,
+			CreatedBy:   spec.CreatedBy,
+			BatchID:     batch.ID,
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			child.State = types.StateFailed
+			child.Error = err.Error()
+		} else {
+			child.OperationID = op.ID
+			child.State = op.State
+			e.addEvent(op.ID, "info", fmt.Sprintf("Created by batch operation %s (cluster %d of %d)",
+				batch.ID, i+1, len(spec.ClusterIDs)), nil)
+		}
+		batch.Children = append(batch.Children, child)
+	}
+	if !slices.ContainsFunc(batch.Children, func(c types.BatchChild) bool { return c.OperationID != "" }) {
+		return nil, errors.Wrapf(firstErr, "no operation could be created for any of the %d clusters", len(spec.ClusterIDs))
+	}
+
+	e.mu.Lock()
+	e.refreshBatchLocked(batch)
+	if e.batches == nil {
+		e.batches = make(map[string]*types.BatchOperation)
+	}
+	e.batches[batch.ID] = batch
+	snapshot := cloneBatch(batch)
+	e.mu.Unlock()
+
+	e.persistBatch(ctx, snapshot)
+	e.logger.Info("created batch operation",
+		"batch_id", batch.ID,
+		"type", batch.Type,
+		"clusters", len(batch.Children),
+		"max_parallel_clusters", maxParallel)
+
+	go e.runBatch(batch.ID)
+	return snapshot, nil
+}
+
+// GetBatchOperation returns a batch operation with its children's current
+// states.
+func (e *Engine) GetBatchOperation(id string) (*types.BatchOperation, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	batch, ok := e.batches[id]
+	if !ok {
+		return nil, errors.Wrapf(internalerrors.ErrBatchNotFound, "batch %s", id)
+	}
+	e.refreshBatchLocked(batch)
+	return cloneBatch(batch), nil
+}
+
+// ResumeBatchOperations carries on scheduling the batches that had children
+// left to run when the server stopped. Call it after LoadFromStore.
+func (e *Engine) ResumeBatchOperations() {
+	e.mu.RLock()
+	var ids []string
+	for id, batch := range e.batches {
+		if batch.CompletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	e.mu.RUnlock()
+
+	for _, id := range ids {
+		go e.runBatch(id)
+	}
+}
+
+// runBatch starts a batch's child operations as slots free up, until every
+// child has finished.
+func (e *Engine) runBatch(id string) {
+	ctx := context.Background()
+	for !e.advanceBatch(ctx, id) {
+		<-time.After(e.defaultPollInterval)
+	}
+}
+
+// advanceBatch refreshes a batch from its children and starts created
+// children while fewer than MaxParallelClusters are active. It reports
+// whether the batch has finished.
+func (e *Engine) advanceBatch(ctx context.Context, id string) bool {
+	e.mu.Lock()
+	batch, ok := e.batches[id]
+	// A draining engine starts nothing; ResumeBatchOperations picks the
+	// batch up in the next process.
+	if !ok || e.draining {
+		e.mu.Unlock()
+		return true
+	}
+	var snapshot *types.BatchOperation
+	if e.refreshBatchLocked(batch) {
+		snapshot = cloneBatch(batch)
+	}
+	finished := batch.CompletedAt != nil
+	active := 0
+	var pending []string
+	for _, child := range batch.Children {
+		switch {
+		case child.Error != "":
+		case child.State.IsActive():
+			active++
+		case child.State == types.StateCreated:
+			pending = append(pending, child.OperationID)
+		}
+	}
+	e.mu.Unlock()
+
+	if snapshot != nil {
+		e.persistBatch(ctx, snapshot)
+	}
+	if finished {
+		if snapshot != nil {
+			e.logger.Info("batch operation finished",
+				"batch_id", id,
+				"state", snapshot.State,
+				"counts", snapshot.Counts)
+		}
+		return true
+	}
+
+	for _, opID := range pending {
+		if active >= batch.MaxParallelClusters {
+			break
+		}
+		err := e.StartOperation(ctx, opID)
+		switch {
+		case err == nil:
+			active++
+		case errors.Is(err, internalerrors.ErrTooManyOperations):
+			// The engine is full; nothing else can start until it drains.
+			return false
+		case errors.Is(err, internalerrors.ErrClusterBusy):
+			// Something else holds this cluster; try it again next round.
+		case errors.Is(err, internalerrors.ErrInvalidState), internalerrors.IsNotFound(err):
+			// Started, cancelled or deleted by hand; the next refresh
+			// picks that up.
+		default:
+			e.logger.Warn("failed to start batch child operation",
+				slog.String("batch_id", id),
+				slog.String("operation_id", opID),
+				slog.String("error", err.Error()))
+		}
+	}
+	return false
+}
+
+// refreshBatchLocked copies the children's operation states into the batch
+// and derives its state and counts. It reports whether anything changed.
+// Callers hold e.mu.
+func (e *Engine) refreshBatchLocked(batch *types.BatchOperation) bool {
+	changed := false
+	for i := range batch.Children {
+		child := &batch.Children[i]
+		if child.OperationID == "" || child.Error != "" {
+			continue
+		}
+		op, ok := e.operations[child.OperationID]
+		if !ok {
+			if !child.State.IsTerminal() {
+				child.Error = "operation was deleted before it finished"
+				changed = true
+			}
+			continue
+		}
+		if op.State != child.State {
+			child.State = op.State
+			changed = true
+		}
+	}
+
+	counts := make(map[types.OperationState]int)
+	completed, done := 0, true
+	for _, child := range batch.Children {
+		counts[child.State]++
+		if !child.Done() {
+			done = false
+		}
+		if child.State == types.StateCompleted && child.Error == "" {
+			completed++
+		}
+	}
+	batch.Counts = counts
+
+	state := types.BatchStateRunning
+	if done {
+		switch completed {
+		case len(batch.Children):
+			state = types.BatchStateCompleted
+		case 0:
+			state = types.BatchStateFailed
+		default:
+			state = types.BatchStatePartiallyCompleted
+		}
+	}
+	now := time.Now()
+	if state != batch.State {
+		batch.State = state
+		changed = true
+	}
+	if done && batch.CompletedAt == nil {
+		batch.CompletedAt = &now
+		changed = true
+	}
+	if changed {
+		batch.UpdatedAt = now
+	}
+	return changed
+}
+
+// persistBatch saves a batch snapshot to storage.
+func (e *Engine) persistBatch(ctx context.Context, batch *types.BatchOperation) {
+	if err := e.store.SaveBatch(ctx, batch); err != nil {
+		e.logger.Error("failed to persist batch operation",
+			slog.String("batch_id", batch.ID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// cloneBatch copies a batch so it can be returned or persisted while the
+// scheduler keeps updating the original. Callers hold e.mu.
+func cloneBatch(batch *types.BatchOperation) *types.BatchOperation {
+	c := *batch
+	c.Children = slices.Clone(batch.Children)
+	c.Counts = maps.Clone(batch.Counts)
+	return &c
+}
@@ -0,0 +1,118 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestCreateBatchOperation_PartialCompletion(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	engine.defaultPollInterval = 10 * time.Millisecond
+
+	params, _ := json.Marshal(types.InstanceCycleParams{SkipTempInstance: true})
+	batch, err := engine.CreateBatchOperation(context.Background(), BatchSpec{
+		Type:       types.OperationTypeInstanceCycle,
+		ClusterIDs: []string{"demo-single", "no-such-cluster", "demo-multi"},
+		Region:     "us-east-1",
+		Params:     params,
+	})
+	if err != nil {
+		t.Fatalf("CreateBatchOperation failed: %v", err)
+	}
+	if batch.MaxParallelClusters != defaultMaxParallelClusters {
+		t.Errorf("max parallel clusters = %d, want the default", batch.MaxParallelClusters)
+	}
+	if child := batch.Children[1]; child.OperationID != "" || child.State != types.StateFailed || child.Error == "" {
+		t.Errorf("missing cluster child = %+v, want a failed child without an operation", child)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		got, err := engine.GetBatchOperation(batch.ID)
+		if err != nil {
+			t.Fatalf("GetBatchOperation failed: %v", err)
+		}
+		active := 0
+		for _, child := range got.Children {
+			if child.State.IsActive() {
+				active++
+			}
+		}
+		if active > 1 {
+			t.Fatalf("%d children active at once, want at most 1", active)
+		}
+		if got.CompletedAt != nil {
+			if got.State != types.BatchStatePartiallyCompleted {
+				t.Errorf("batch state = %s, want %s", got.State, types.BatchStatePartiallyCompleted)
+			}
+			if got.Counts[types.StateCompleted] != 2 || got.Counts[types.StateFailed] != 1 {
+				t.Errorf("counts = %v, want 2 completed and 1 failed", got.Counts)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch did not finish: %+v", got.Children)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	op, err := engine.GetOperation(batch.Children[0].OperationID)
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	if op.BatchID != batch.ID {
+		t.Errorf("child batch ID = %q, want %q", op.BatchID, batch.ID)
+	}
+}
+
+func TestCreateBatchOperation_Rejected(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		spec BatchSpec
+		want error
+	}{
+		{
+			name: "no clusters",
+			spec: BatchSpec{Type: types.OperationTypeInstanceCycle},
+			want: internalerrors.ErrInvalidParameter,
+		},
+		{
+			name: "duplicate cluster",
+			spec: BatchSpec{Type: types.OperationTypeInstanceCycle, ClusterIDs: []string{"demo-single", "demo-single"}},
+			want: internalerrors.ErrInvalidParameter,
+		},
+		{
+			name: "negative parallelism",
+			spec: BatchSpec{Type: types.OperationTypeInstanceCycle, ClusterIDs: []string{"demo-single"}, MaxParallelClusters: -1},
+			want: internalerrors.ErrInvalidParameter,
+		},
+		{
+			name: "no cluster exists",
+			spec: BatchSpec{Type: types.OperationTypeInstanceCycle, ClusterIDs: []string{"missing-a", "missing-b"}},
+			want: internalerrors.ErrClusterNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Region = "us-east-1"
+			if _, err := engine.CreateBatchOperation(ctx, tt.spec); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if len(engine.batches) != 0 {
+		t.Errorf("%d batches recorded for rejected requests", len(engine.batches))
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
 	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
@@ -30,6 +33,127 @@ func (e *Engine) handleGetClusterInfo(ctx context.Context, op *types.Operation,
 	return nil
 }
 
+// handlePreflightCheck refuses to let an operation change anything unless the
+// cluster is in a clean starting state. Any problem pauses the operation for
+// intervention; continuing re-runs the check.
+func (e *Engine) handlePreflightCheck(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		AdoptBlueGreen bool `json:"adopt_blue_green,omitempty"`
+	}
+	if len(step.Parameters) > 0 {
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+	clusterARN, err := rdsClient.GetClusterARN(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster ARN")
+	}
+	deployments, err := rdsClient.ListBlueGreenDeploymentsForCluster(ctx, clusterARN)
+	if err != nil {
+		return errors.Wrap(err, "list Blue-Green deployments")
+	}
+
+	problems := preflightProblems(info, deployments, params.AdoptBlueGreen)
+
+	result, _ := json.Marshal(map[string]any{
+		"cluster_status":                      info.Status,
+		"iam_database_authentication_enabled": info.IAMDatabaseAuthenticationEnabled,
+		"problems":                            problems,
+	})
+	step.Result = result
+
+	if len(problems) > 0 {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"Preflight check failed, nothing has been changed: %s. Resolve this and continue to re-run the check, or abort the operation.",
+			strings.Join(problems, "; "))
+	}
+
+	e.addEvent(op.ID, "info", fmt.Sprintf("Preflight check passed: cluster %s is available with writer and instances healthy", op.ClusterID), nil)
+
+	// rds-db:connect is granted per resource ID. A green cluster gets a new
+	// one at switchover, so policies naming the old ID, or written for
+	// particular instances, lock clients out after the cutover.
+	if info.IAMDatabaseAuthenticationEnabled && addsInstances(op) {
+		e.addEvent(op.ID, "warning", fmt.Sprintf(
+			"Cluster %s uses IAM database authentication: make sure the IAM roles clients connect with can also reach the temp or green instances this operation creates",
+			op.ClusterID), nil)
+	}
+	return nil
+}
+
+// addsInstances reports whether the operation creates instances clients may
+// be failed over to: a temp instance or a Blue-Green green environment.
+func addsInstances(op *types.Operation) bool {
+	for _, step := range op.Steps {
+		switch step.Action {
+		case "create_temp_instance", "create_blue_green_deployment":
+			return true
+		}
+	}
+	return false
+}
+
+// preflightProblems lists why the cluster is not in a clean starting state:
+// it must be available with exactly one writer, no instance failed or being
+// deleted, and no Blue-Green deployment in flight. Autoscaled instances are
+// not checked for deletion since scale-in removes them routinely. With
+// adoptBlueGreen, a provisioning or available deployment is allowed because
+// the engine upgrade will adopt it.
+func preflightProblems(info *types.ClusterInfo, deployments []*rds.BlueGreenDeploymentInfo, adoptBlueGreen bool) []string {
+	var problems []string
+
+	if !rds.ClusterStatus(info.Status).IsAvailable() {
+		problems = append(problems, fmt.Sprintf("cluster %s is %s, not available", info.ClusterID, info.Status))
+	}
+
+	var writers []string
+	for _, inst := range info.Instances {
+		if inst.Role == "writer" {
+			writers = append(writers, inst.InstanceID)
+		}
+		status := rds.InstanceStatus(inst.Status)
+		switch {
+		case status.IsError():
+			problems = append(problems, fmt.Sprintf("instance %s is in error state %s", inst.InstanceID, inst.Status))
+		case status.IsDeleting() && !inst.IsAutoScaled:
+			problems = append(problems, fmt.Sprintf("instance %s is %s", inst.InstanceID, inst.Status))
+		}
+	}
+	switch len(writers) {
+	case 0:
+		problems = append(problems, fmt.Sprintf("cluster %s has no writer", info.ClusterID))
+	case 1:
+	default:
+		problems = append(problems, fmt.Sprintf("cluster %s has %d writers (%s), expected exactly one",
+			info.ClusterID, len(writers), strings.Join(writers, ", ")))
+	}
+
+	for _, bg := range deployments {
+		switch bg.Status {
+		case "SWITCHOVER_COMPLETED":
+			continue
+		case "PROVISIONING", "AVAILABLE":
+			if adoptBlueGreen {
+				continue
+			}
+		}
+		problems = append(problems, fmt.Sprintf("Blue-Green deployment %s is %s", bg.Identifier, bg.Status))
+	}
+
+	return problems
+}
+
 // handleCreateTempInstance creates a temporary instance.
 func (e *Engine) handleCreateTempInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -38,34 +162,155 @@ func (e *Engine) handleCreateTempInstance(ctx context.Context, op *types.Operati
 	}
 
 	var params struct {
-		InstanceType string `json:"instance_type"`
-		Engine       string `json:"engine"`
+		InstanceType            string `json:"instance_type"`
+		Engine                  string `json:"engine"`
+		CACertificateIdentifier string `json:"ca_certificate_identifier,omitempty"`
+		PromotionTier           int32  `json:"promotion_tier,omitempty"` // 0 (highest failover priority) unless set
+
+		// AvailabilityZone places the temp instance; the writer's AZ unless set.
+		AvailabilityZone string `json:"availability_zone,omitempty"`
 	}
 	if err := json.Unmarshal(step.Parameters, &params); err != nil {
 		return errors.Wrap(err, "unmarshal params")
 	}
 
+	// The writer's tags, AZ and network are best-effort; without them the temp
+	// instance is created with only the operator's tags wherever RDS places it.
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	var writer *types.InstanceInfo
+	if err != nil {
+		e.stepLogger(ctx).Warn("failed to describe cluster, temp instance will not follow the writer",
+			"error", err)
+	} else {
+		writer = findWriter(info.Instances)
+		if problem := e.tempInstanceRoomProblem(info); problem != "" {
+			return instanceLimitError(internalerrors.ErrClusterInstanceLimit, problem)
+		}
+	}
+
+	zone, err := e.tempInstanceZone(ctx, rdsClient, op, info, writer, params.InstanceType, params.AvailabilityZone)
+	if err != nil {
+		return err
+	}
+
 	instanceID := rds.GenerateTempInstanceID(op.ClusterID, op.ID)
 
 	createParams := rds.CreateInstanceParams{
-		ClusterID:     op.ClusterID,
-		InstanceID:    instanceID,
-		InstanceType:  params.InstanceType,
-		Engine:        params.Engine,
-		PromotionTier: 0, // Highest priority for failover
-		OperationID:   op.ID,
+		ClusterID:               op.ClusterID,
+		InstanceID:              instanceID,
+		InstanceType:            params.InstanceType,
+		Engine:                  params.Engine,
+		PromotionTier:           params.PromotionTier,
+		AvailabilityZone:        zone,
+		OperationID:             op.ID,
+		CACertificateIdentifier: params.CACertificateIdentifier,
+		Tags:                    e.tempInstanceTags(ctx, rdsClient, op, writer),
+	}
+	if info != nil {
+		createParams.IAMDatabaseAuthentication = info.IAMDatabaseAuthenticationEnabled
+	}
+	// Copy the writer's subnet group and security groups rather than relying
+	// on the account defaults, which need not be the ones the cluster uses.
+	if writer != nil {
+		createParams.DBSubnetGroupName = writer.DBSubnetGroup
+		createParams.VpcSecurityGroupIDs = writer.VpcSecurityGroupIDs
 	}
 
 	_, err = rdsClient.CreateClusterInstance(ctx, createParams)
+	// The ID is derived from the operation, so an instance that already has
+	// it was created by an earlier attempt of this step whose response was
+	// lost; a retry carries on with it.
+	if errors.Is(err, internalerrors.ErrInstanceAlreadyExists) {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Temp instance %s already exists from an earlier attempt; using it", instanceID), nil)
+		err = nil
+	}
+	if errors.Is(err, internalerrors.ErrClusterInstanceLimit) {
+		return instanceLimitError(err, "RDS has no room for the temp instance; retry with skip_temp_instance, or free up cluster or account instance quota")
+	}
 	if err != nil {
 		return err
 	}
 
-	result, _ := json.Marshal(map[string]string{"instance_id": instanceID})
+	result, _ := json.Marshal(map[string]string{"instance_id": instanceID, "availability_zone": zone})
 	step.Result = result
 	return nil
 }
 
+// operationTags returns the operator-supplied tags from the operation's
+// parameters, or nil when it has none.
+func operationTags(op *types.Operation) map[string]string {
+	var params struct {
+		Tags map[string]string `json:"tags"`
+	}
+	if len(op.Parameters) == 0 || json.Unmarshal(op.Parameters, &params) != nil {
+		return nil
+	}
+	return params.Tags
+}
+
+// tempInstanceTags returns the tags for a temp instance: the writer's tags,
+// so the temp node keeps things like Environment and Team, overridden by the
+// operator's tags. The writer's tags are best-effort; failing to read them
+// only drops them.
+func (e *Engine) tempInstanceTags(ctx context.Context, rdsClient *rds.Client, op *types.Operation, writer *types.InstanceInfo) map[string]string {
+	tags := make(map[string]string)
+
+	if writer != nil {
+		writerTags, err := rdsClient.GetInstanceTags(ctx, writer.InstanceID)
+		if err != nil {
+			e.stepLogger(ctx).Warn("failed to read writer tags, temp instance will not inherit them",
+				"error", err)
+		}
+		maps.Copy(tags, writerTags)
+	}
+
+	maps.Copy(tags, operationTags(op))
+	return tags
+}
+
+// tempInstanceZone picks the AZ for a temp instance: the requested one, or
+// else the writer's, so failing over to the temp instance does not move the
+// writer away from clients in its AZ. A requested AZ the instance class
+// cannot be created in is an error; if the writer's AZ cannot take it, any
+// AZ that can is used instead, with a warning. An empty result lets RDS
+// choose.
+func (e *Engine) tempInstanceZone(ctx context.Context, rdsClient *rds.Client, op *types.Operation, info *types.ClusterInfo, writer *types.InstanceInfo, instanceClass, requested string) (string, error) {
+	zone := requested
+	if zone == "" && writer != nil {
+		zone = writer.AvailabilityZone
+	}
+	if zone == "" || info == nil {
+		return zone, nil
+	}
+
+	orderable, err := rdsClient.GetOrderableInstanceTypes(ctx, info.Engine, info.EngineVersion)
+	if err != nil {
+		e.stepLogger(ctx).Warn("failed to check AZ availability, creating temp instance in it anyway",
+			"availability_zone", zone, "error", err)
+		return zone, nil
+	}
+	var zones []string
+	for _, it := range orderable {
+		if it.InstanceClass == instanceClass {
+			zones = it.AvailabilityZones
+			break
+		}
+	}
+	if len(zones) == 0 || slices.Contains(zones, zone) {
+		return zone, nil
+	}
+
+	if requested != "" {
+		return "", errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s cannot be created in %s; available AZs: %s", instanceClass, requested, strings.Join(zones, ", "))
+	}
+	fallback := slices.Min(zones)
+	e.addEvent(op.ID, "warning", fmt.Sprintf(
+		"%s is not available in the writer's AZ %s; creating the temp instance in %s, so failing over to it moves the writer across AZs",
+		instanceClass, zone, fallback), nil)
+	return fallback, nil
+}
+
 // handleWaitInstanceAvailable waits for an instance to become available AND reach desired state.
 func (e *Engine) handleWaitInstanceAvailable(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -92,16 +337,13 @@ func (e *Engine) handleWaitInstanceAvailable(ctx context.Context, op *types.Oper
 		if isTempInstanceWait {
 			params.InstanceID = e.findCreatedInstanceID(op)
 			if params.InstanceID != "" {
-				e.logger.Info("using temp instance ID from previous step",
-					"operation_id", op.ID,
+				e.stepLogger(ctx).Info("using temp instance ID from previous step",
 					"instance_id", params.InstanceID)
 			}
 		} else {
 			// This is NOT a temp instance wait, but instance_id is missing.
 			// This is a critical error that would cause parallel modifications.
-			e.logger.Error("CRITICAL: wait_instance_available step missing instance_id parameter",
-				"operation_id", op.ID,
-				"step_id", step.ID,
+			e.stepLogger(ctx).Error("CRITICAL: wait_instance_available step missing instance_id parameter",
 				"step_name", step.Name,
 				"step_parameters", string(step.Parameters))
 			return errors.Wrapf(internalerrors.ErrInvalidParameter,
@@ -113,126 +355,343 @@ func (e *Engine) handleWaitInstanceAvailable(ctx context.Context, op *types.Oper
 		return errors.Wrap(internalerrors.ErrInvalidParameter, "instance_id required")
 	}
 
-	// Determine what we're waiting for based on the operation type and previous step
-	var targetInstanceType string
-	var targetStorageType string
-
-	// Look for the previous modify step to get the target state
-	for i := op.CurrentStepIndex - 1; i >= 0; i-- {
-		prevStep := &op.Steps[i]
-		if prevStep.Action == "modify_instance" {
-			var modifyParams struct {
-				InstanceID   string `json:"instance_id"`
-				InstanceType string `json:"instance_type,omitempty"`
-				StorageType  string `json:"storage_type,omitempty"`
-			}
-			if err := json.Unmarshal(prevStep.Parameters, &modifyParams); err == nil {
-				if modifyParams.InstanceID == params.InstanceID {
-					targetInstanceType = modifyParams.InstanceType
-					targetStorageType = modifyParams.StorageType
-					break
-				}
-			}
-		}
+	// A reboot that was skipped because the instance was unavailable leaves
+	// nothing to wait for.
+	if rebootSkipped(op, params.InstanceID) {
+		step.Result, _ = json.Marshal(map[string]string{
+			"status":  "skipped",
+			"message": "reboot of " + params.InstanceID + " was skipped",
+		})
+		return nil
 	}
 
-	e.logger.Info("waiting for instance to reach desired state",
-		"operation_id", op.ID,
+	w := newInstanceWaiter(op, params.InstanceID)
+	progress := e.startWait(step, params.InstanceID)
+	w.restore(progress)
+
+	e.stepLogger(ctx).Info("waiting for instance to reach desired state",
 		"instance_id", params.InstanceID,
 		"step_name", step.Name,
-		"target_instance_type", targetInstanceType,
-		"target_storage_type", targetStorageType)
+		"target_instance_type", w.targetInstanceType,
+		"target_storage_type", w.targetStorageType,
+		"target_ca_certificate", w.targetCACert)
 
-	step.WaitCondition = "waiting for instance to become available and reach desired state"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for instance to become available and reach desired state")
 
 	// Poll until instance is available AND has the desired configuration
-	timeout := time.After(e.getWaitTimeout(op))
-	ticker := time.NewTicker(e.defaultPollInterval)
-	defer ticker.Stop()
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
 
 	pollCount := 0
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-timeout:
-			return errors.Wrapf(internalerrors.ErrWaitTimeout, "instance %s did not reach desired state", params.InstanceID)
-		case <-ticker.C:
-			pollCount++
+	defer func() { e.metrics.WaitPolls(step.Action, pollCount) }()
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		pollCount++
+		done, err := e.pollInstance(ctx, rdsClient, op, w, pollCount)
+		w.save(progress)
+		if w.condition != "" {
+			step.WaitCondition = w.condition
+		}
+		return done, err
+	})
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		return errors.Wrapf(err, "instance %s did not reach desired state", params.InstanceID)
+	}
+	return err
+}
+
+// handleWaitInstancesAvailable waits for a batch of instances modified
+// together to all become available and reach their desired state. Like
+// wait_instance_available it only waits on the instances it is given, so a
+// batch can never widen to instances that were not modified with it.
+func (e *Engine) handleWaitInstancesAvailable(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		InstanceIDs []string `json:"instance_ids"`
+	}
+	if len(step.Parameters) > 0 {
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+	if len(params.InstanceIDs) == 0 || slices.Contains(params.InstanceIDs, "") {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"instance_ids required for step %q and must not contain empty IDs", step.Name)
+	}
+
+	// Instances an earlier poll of the wait found ready are not checked
+	// again, as a blocking wait would not check them again either.
+	progress := e.startWait(step, params.InstanceIDs...)
+	pending := make([]*instanceWaiter, 0, len(progress.WaitingOn))
+	for _, instanceID := range progress.WaitingOn {
+		w := newInstanceWaiter(op, instanceID)
+		w.restore(progress)
+		pending = append(pending, w)
+	}
+
+	e.stepLogger(ctx).Info("waiting for instances to reach desired state",
+		"instance_ids", params.InstanceIDs,
+		"step_name", step.Name)
 
-			// Get current instance info
-			instanceInfo, err := rdsClient.GetInstanceInfo(ctx, params.InstanceID)
+	e.markWaiting(step, fmt.Sprintf("waiting for %d instances to become available and reach desired state", len(pending)))
+
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
+
+	pollCount := 0
+	defer func() { e.metrics.WaitPolls(step.Action, pollCount) }()
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		pollCount++
+		// A failed describe keeps that instance pending; the others are still
+		// checked before the wait backs off.
+		var pollErr error
+		var waiting []string
+		remaining := pending[:0]
+		for _, w := range pending {
+			done, err := e.pollInstance(ctx, rdsClient, op, w, pollCount)
+			w.save(progress)
+			var transientErr *transientPollError
+			if err != nil && !errors.As(err, &transientErr) {
+				return false, err
+			}
 			if err != nil {
-				if pollCount%10 == 0 {
-					e.logger.Warn("error getting instance info",
-						"operation_id", op.ID,
-						"instance_id", params.InstanceID,
-						"error", err)
-				}
+				pollErr = err
+			}
+			if done {
 				continue
 			}
+			remaining = append(remaining, w)
+			if w.condition != "" {
+				waiting = append(waiting, fmt.Sprintf("%s (%s)", w.instanceID, w.condition))
+			} else {
+				waiting = append(waiting, w.instanceID)
+			}
+		}
+		pending = remaining
+		progress.WaitingOn = progress.WaitingOn[:0]
+		for _, w := range pending {
+			progress.WaitingOn = append(progress.WaitingOn, w.instanceID)
+		}
+		if len(pending) == 0 {
+			return true, nil
+		}
+		step.WaitCondition = fmt.Sprintf("%d/%d instances ready; waiting on %s",
+			len(params.InstanceIDs)-len(pending), len(params.InstanceIDs), strings.Join(waiting, ", "))
+		return false, pollErr
+	})
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		ids := make([]string, 0, len(pending))
+		for _, w := range pending {
+			ids = append(ids, w.instanceID)
+		}
+		return errors.Wrapf(err, "instances %s did not reach desired state", strings.Join(ids, ", "))
+	}
+	return err
+}
+
+// instanceWaiter tracks one instance's progress toward the state the step
+// that last changed it asked for.
+type instanceWaiter struct {
+	instanceID         string
+	targetInstanceType string
+	targetStorageType  string
+	targetCACert       string
+	modifyParams       *rds.ModifyInstanceParams
+	monitoring         *monitoringStepParams
+
+	// mismatchPolls counts consecutive polls where the instance is available
+	// but not at the target config, and reissued records that the modify was
+	// re-issued because of them.
+	mismatchPolls int
+	reissued      bool
+	// condition describes what the instance was last seen waiting on.
+	condition string
+}
+
+// restore carries on from the counts an earlier poll of the wait saved.
+func (w *instanceWaiter) restore(p *types.WaitProgress) {
+	w.mismatchPolls = p.MismatchPolls[w.instanceID]
+	w.reissued = slices.Contains(p.Reissued, w.instanceID)
+}
+
+// save records the waiter's counts for the next poll of the wait.
+func (w *instanceWaiter) save(p *types.WaitProgress) {
+	if w.mismatchPolls > 0 {
+		if p.MismatchPolls == nil {
+			p.MismatchPolls = make(map[string]int)
+		}
+		p.MismatchPolls[w.instanceID] = w.mismatchPolls
+	} else {
+		delete(p.MismatchPolls, w.instanceID)
+	}
+	if w.reissued && !slices.Contains(p.Reissued, w.instanceID) {
+		p.Reissued = append(p.Reissued, w.instanceID)
+	}
+}
+
+// newInstanceWaiter looks back through the operation's steps for the modify,
+// monitoring change or CA rotation that targeted the instance to learn what
+// it should become.
+func newInstanceWaiter(op *types.Operation, instanceID string) *instanceWaiter {
+	w := &instanceWaiter{instanceID: instanceID}
 
-			// Check if instance is available
-			instanceStatus := rds.InstanceStatus(instanceInfo.Status)
-			if !instanceStatus.IsAvailable() {
-				step.WaitCondition = fmt.Sprintf("instance status: %s", instanceInfo.Status)
-				if pollCount%10 == 0 {
-					e.logger.Info("instance not yet available",
-						"operation_id", op.ID,
-						"instance_id", params.InstanceID,
-						"status", instanceInfo.Status,
-						"poll_count", pollCount)
+	// Look for the previous modify step to get the target state
+	for i := op.CurrentStepIndex - 1; i >= 0; i-- {
+		prevStep := &op.Steps[i]
+		if prevStep.Action == "rotate_ca_cert" && w.targetCACert == "" {
+			var prevParams struct {
+				InstanceID              string `json:"instance_id"`
+				CACertificateIdentifier string `json:"ca_certificate_identifier"`
+			}
+			if err := json.Unmarshal(prevStep.Parameters, &prevParams); err == nil && prevParams.InstanceID == instanceID {
+				w.targetCACert = prevParams.CACertificateIdentifier
+			}
+		}
+		if prevStep.Action == "modify_instance_monitoring" {
+			var prevParams monitoringStepParams
+			if err := json.Unmarshal(prevStep.Parameters, &prevParams); err == nil && prevParams.InstanceID == instanceID {
+				w.monitoring = &prevParams
+				break
+			}
+		}
+		if prevStep.Action == "modify_instance" {
+			var prevParams struct {
+				InstanceID        string `json:"instance_id"`
+				InstanceType      string `json:"instance_type,omitempty"`
+				StorageType       string `json:"storage_type,omitempty"`
+				IOPS              *int32 `json:"iops,omitempty"`
+				StorageThroughput *int32 `json:"storage_throughput,omitempty"`
+			}
+			if err := json.Unmarshal(prevStep.Parameters, &prevParams); err == nil {
+				if prevParams.InstanceID == instanceID {
+					w.targetInstanceType = prevParams.InstanceType
+					w.targetStorageType = prevParams.StorageType
+					w.modifyParams = &rds.ModifyInstanceParams{
+						InstanceID:        prevParams.InstanceID,
+						InstanceType:      prevParams.InstanceType,
+						StorageType:       prevParams.StorageType,
+						IOPS:              prevParams.IOPS,
+						StorageThroughput: prevParams.StorageThroughput,
+						ApplyImmediately:  true,
+					}
+					break
 				}
-				continue
 			}
+		}
+	}
+	return w
+}
+
+// pollInstance checks once whether the instance is available and at its
+// target config, recording what it is waiting on in w.condition. A modify
+// that AWS silently dropped looks like an available instance stuck at the
+// old config, so after the verification budget is spent the modify is
+// re-issued once and then the decision is handed to an operator instead of
+// waiting out the timeout.
+func (e *Engine) pollInstance(ctx context.Context, rdsClient *rds.Client, op *types.Operation, w *instanceWaiter, pollCount int) (bool, error) {
+	// Get current instance info
+	instanceInfo, err := rdsClient.GetInstanceInfo(ctx, w.instanceID)
+	if err != nil {
+		if pollCount%10 == 0 {
+			e.stepLogger(ctx).Warn("error getting instance info",
+				"instance_id", w.instanceID,
+				"error", err)
+		}
+		return false, transient(err)
+	}
+
+	// Check if instance is available
+	instanceStatus := rds.InstanceStatus(instanceInfo.Status)
+	if !instanceStatus.IsAvailable() {
+		w.mismatchPolls = 0
+		w.condition = fmt.Sprintf("instance status: %s", instanceInfo.Status)
+		if pollCount%10 == 0 {
+			e.stepLogger(ctx).Info("instance not yet available",
+				"instance_id", w.instanceID,
+				"status", instanceInfo.Status,
+				"poll_count", pollCount)
+		}
+		return false, nil
+	}
 
-			// Instance is available, now check if it has the desired configuration
-			configMatch := true
-			var mismatchReason string
+	// Instance is available, now check if it has the desired configuration
+	var mismatches []string
+	if w.targetInstanceType != "" && instanceInfo.InstanceType != w.targetInstanceType {
+		mismatches = append(mismatches, fmt.Sprintf("instance type is %s, waiting for %s", instanceInfo.InstanceType, w.targetInstanceType))
+	}
+	if w.targetStorageType != "" && instanceInfo.StorageType != w.targetStorageType {
+		mismatches = append(mismatches, fmt.Sprintf("storage type is %s, waiting for %s", instanceInfo.StorageType, w.targetStorageType))
+	}
+	if w.targetCACert != "" && instanceInfo.CACertificateIdentifier != w.targetCACert {
+		mismatches = append(mismatches, fmt.Sprintf("CA certificate is %s, waiting for %s", instanceInfo.CACertificateIdentifier, w.targetCACert))
+	}
+	if w.monitoring != nil {
+		mismatches = append(mismatches, w.monitoring.mismatches(instanceInfo)...)
+	}
 
-			if targetInstanceType != "" && instanceInfo.InstanceType != targetInstanceType {
-				configMatch = false
-				mismatchReason = fmt.Sprintf("instance type is %s, waiting for %s", instanceInfo.InstanceType, targetInstanceType)
+	if len(mismatches) > 0 {
+		mismatchReason := strings.Join(mismatches, "; ")
+		w.condition = mismatchReason
+		w.mismatchPolls++
+		if e.modifyVerifyPolls > 0 && w.mismatchPolls >= e.modifyVerifyPolls {
+			if w.reissued || (w.modifyParams == nil && w.monitoring == nil) {
+				return false, errors.Wrapf(internalerrors.ErrInterventionRequired,
+					"instance %s is available but the modification was not applied after %d polls (%s); verify the instance in the AWS console, then 'continue' to keep waiting or 'abort'",
+					w.instanceID, w.mismatchPolls, mismatchReason)
 			}
 
-			if targetStorageType != "" && instanceInfo.StorageType != targetStorageType {
-				configMatch = false
-				if mismatchReason != "" {
-					mismatchReason += "; "
-				}
-				mismatchReason += fmt.Sprintf("storage type is %s, waiting for %s", instanceInfo.StorageType, targetStorageType)
-			}
-
-			if !configMatch {
-				step.WaitCondition = mismatchReason
-				if pollCount%10 == 0 {
-					e.logger.Info("instance available but configuration not yet applied",
-						"operation_id", op.ID,
-						"instance_id", params.InstanceID,
-						"current_instance_type", instanceInfo.InstanceType,
-						"target_instance_type", targetInstanceType,
-						"current_storage_type", instanceInfo.StorageType,
-						"target_storage_type", targetStorageType,
-						"poll_count", pollCount)
+			e.stepLogger(ctx).Warn("instance available but modification not applied, re-issuing modify",
+				"instance_id", w.instanceID,
+				"mismatch_polls", w.mismatchPolls,
+				"reason", mismatchReason)
+			e.addEvent(op.ID, "warning",
+				fmt.Sprintf("Modification of %s not applied after %d polls (%s), re-issuing modify", w.instanceID, w.mismatchPolls, mismatchReason), nil)
+
+			reissue := func() error {
+				if w.monitoring != nil {
+					return rdsClient.ModifyInstanceMonitoring(ctx, w.monitoring.modifyParams())
 				}
-				continue
+				return rdsClient.ModifyInstance(ctx, *w.modifyParams)
 			}
-
-			// Instance is available AND has the desired configuration
-			e.logger.Info("instance has reached desired state",
-				"operation_id", op.ID,
-				"instance_id", params.InstanceID,
-				"instance_type", instanceInfo.InstanceType,
-				"storage_type", instanceInfo.StorageType,
+			if err := reissue(); err != nil {
+				return false, errors.Wrapf(internalerrors.ErrInterventionRequired,
+					"instance %s did not apply the modification and re-issuing it failed: %v", w.instanceID, err)
+			}
+			w.reissued = true
+			w.mismatchPolls = 0
+			return false, nil
+		}
+		if pollCount%10 == 0 {
+			e.stepLogger(ctx).Info("instance available but configuration not yet applied",
+				"instance_id", w.instanceID,
+				"current_instance_type", instanceInfo.InstanceType,
+				"target_instance_type", w.targetInstanceType,
+				"current_storage_type", instanceInfo.StorageType,
+				"target_storage_type", w.targetStorageType,
 				"poll_count", pollCount)
-
-			return nil
 		}
+		return false, nil
 	}
+
+	// Instance is available AND has the desired configuration
+	e.stepLogger(ctx).Info("instance has reached desired state",
+		"instance_id", w.instanceID,
+		"instance_type", instanceInfo.InstanceType,
+		"storage_type", instanceInfo.StorageType,
+		"poll_count", pollCount)
+	w.condition = ""
+	return true, nil
 }
 
 // handleFailoverToInstance initiates a failover to a specific instance and verifies it completes.
+// A target lagging the writer by more than the operation's max_replica_lag_ms
+// pauses for intervention before anything changes, and once the target is the
+// writer the cluster must have a single writer and its original reader count.
 func (e *Engine) handleFailoverToInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
 	if err != nil {
@@ -295,19 +754,34 @@ func (e *Engine) handleFailoverToInstance(ctx context.Context, op *types.Operati
 			params.InstanceID, targetInstance.Status, rds.StatusAvailable)
 	}
 
+	lag, err := e.checkFailoverLag(ctx, rdsClient, op, params.InstanceID)
+	if err != nil {
+		return err
+	}
+	// Afterwards every instance but the target should be a reader.
+	expectedReaders := 0
+	for _, inst := range clusterInfo.Instances {
+		if !inst.IsAutoScaled && inst.InstanceID != params.InstanceID {
+			expectedReaders++
+		}
+	}
+
 	// Initiate the failover
 	if err := rdsClient.FailoverCluster(ctx, op.ClusterID, params.InstanceID); err != nil {
 		return err
 	}
 
 	// Poll until the target becomes the writer or we timeout
-	step.WaitCondition = "waiting for failover to complete"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for failover to complete")
 
-	timeout := time.After(e.getWaitTimeout(op))
-	ticker := time.NewTicker(e.defaultPollInterval)
+	timeout := time.After(e.getWaitTimeout(op, step))
+	ticker := time.NewTicker(e.getPollInterval(step))
 	defer ticker.Stop()
 
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -316,6 +790,7 @@ func (e *Engine) handleFailoverToInstance(ctx context.Context, op *types.Operati
 			return errors.Wrapf(internalerrors.ErrWaitTimeout,
 				"failover to %s did not complete in time", params.InstanceID)
 		case <-ticker.C:
+			step.Polls++
 			info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
 			if err != nil {
 				// Transient errors during failover are expected, continue polling
@@ -326,11 +801,7 @@ func (e *Engine) handleFailoverToInstance(ctx context.Context, op *types.Operati
 			for _, inst := range info.Instances {
 				if inst.InstanceID == params.InstanceID {
 					if inst.Role == "writer" {
-						step.Result, _ = json.Marshal(map[string]string{
-							"status":  "completed",
-							"message": "failover completed successfully",
-						})
-						return nil
+						return verifyFailoverTopology(step, info, params.InstanceID, expectedReaders, lag)
 					}
 					step.WaitCondition = "failover in progress, instance role: " + inst.Role
 					break
@@ -340,7 +811,101 @@ func (e *Engine) handleFailoverToInstance(ctx context.Context, op *types.Operati
 	}
 }
 
-// handleModifyInstance modifies an instance.
+// checkFailoverLag refuses a failover target that trails the writer by more
+// than the operation's lag threshold, returning the lag it measured. A target
+// without recent lag datapoints is allowed with a warning, since CloudWatch
+// can take a few minutes to report a new instance.
+func (e *Engine) checkFailoverLag(ctx context.Context, rdsClient *rds.Client, op *types.Operation, instanceID string) (*time.Duration, error) {
+	maxLag := operationMaxReplicaLag(op)
+	if maxLag < 0 {
+		return nil, nil
+	}
+
+	lag, err := rdsClient.GetReplicaLag(ctx, instanceID)
+	if errors.Is(err, internalerrors.ErrNotFound) {
+		e.addEvent(op.ID, "warning", fmt.Sprintf("No replica lag reported for %s; failing over without a lag check", instanceID), nil)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lag > maxLag {
+		return nil, errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"Failover target %s is %dms behind the writer, above the %dms limit. Continue once it has caught up, or abort.",
+			instanceID, lag.Milliseconds(), maxLag.Milliseconds())
+	}
+	return &lag, nil
+}
+
+// verifyFailoverTopology checks the cluster once the failover target is the
+// writer: it must be the only writer and every other instance a reader.
+// Autoscaled instances are ignored as they come and go on their own.
+func verifyFailoverTopology(step *types.Step, info *types.ClusterInfo, instanceID string, expectedReaders int, lag *time.Duration) error {
+	var writers []string
+	for _, inst := range info.Instances {
+		if inst.Role == "writer" {
+			writers = append(writers, inst.InstanceID)
+		}
+	}
+	readers := countReaders(info.Instances)
+
+	result := map[string]any{
+		"status":  "completed",
+		"message": "failover completed successfully",
+		"writers": writers,
+		"readers": readers,
+	}
+	if lag != nil {
+		result["replica_lag_ms"] = lag.Milliseconds()
+	}
+	step.Result, _ = json.Marshal(result)
+
+	if len(writers) != 1 {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"Failover to %s completed but the cluster has %d writers (%s). Verify the cluster topology before continuing.",
+			instanceID, len(writers), strings.Join(writers, ", "))
+	}
+	if readers != expectedReaders {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"Failover to %s completed but the cluster has %d readers, expected %d. Verify the cluster topology before continuing.",
+			instanceID, readers, expectedReaders)
+	}
+	return nil
+}
+
+// countReaders returns the number of readers that are not autoscaled.
+func countReaders(instances []types.InstanceInfo) int {
+	n := 0
+	for _, inst := range instances {
+		if inst.Role == "reader" && !inst.IsAutoScaled {
+			n++
+		}
+	}
+	return n
+}
+
+// operationMaxReplicaLag returns the lag threshold for failover targets from
+// the operation's max_replica_lag_ms parameter, defaulting to
+// DefaultMaxReplicaLagMs. A negative value disables the lag check.
+func operationMaxReplicaLag(op *types.Operation) time.Duration {
+	var params struct {
+		MaxReplicaLagMs int `json:"max_replica_lag_ms"`
+	}
+	if len(op.Parameters) > 0 {
+		_ = json.Unmarshal(op.Parameters, &params)
+	}
+	switch {
+	case params.MaxReplicaLagMs < 0:
+		return -1
+	case params.MaxReplicaLagMs == 0:
+		params.MaxReplicaLagMs = constants.DefaultMaxReplicaLagMs
+	}
+	return time.Duration(params.MaxReplicaLagMs) * time.Millisecond
+}
+
+// handleModifyInstance modifies an instance. Changes already queued on the
+// instance that this modify does not ask for pause the operation first: the
+// modify is applied immediately, which would apply them along with it.
 func (e *Engine) handleModifyInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
 	if err != nil {
@@ -353,6 +918,8 @@ func (e *Engine) handleModifyInstance(ctx context.Context, op *types.Operation,
 		StorageType       string `json:"storage_type,omitempty"`
 		IOPS              *int32 `json:"iops,omitempty"`
 		StorageThroughput *int32 `json:"storage_throughput,omitempty"`
+		// DBParameterGroupName is associated now and applied on the next reboot.
+		DBParameterGroupName string `json:"db_parameter_group_name,omitempty"`
 	}
 	if err := json.Unmarshal(step.Parameters, &params); err != nil {
 		return errors.Wrap(err, "unmarshal params")
@@ -368,8 +935,7 @@ func (e *Engine) handleModifyInstance(ctx context.Context, op *types.Operation,
 		return errors.Wrapf(err, "get instance info for %s", params.InstanceID)
 	}
 
-	e.logger.Info("MODIFY: instance current status",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("MODIFY: instance current status",
 		"instance_id", params.InstanceID,
 		"current_status", instanceInfo.Status,
 		"current_type", instanceInfo.InstanceType,
@@ -377,37 +943,76 @@ func (e *Engine) handleModifyInstance(ctx context.Context, op *types.Operation,
 
 	// If instance is not available, it might already be modifying
 	if instanceInfo.Status != "available" {
-		e.logger.Warn("MODIFY: instance not available, may already be modifying",
-			"operation_id", op.ID,
+		e.stepLogger(ctx).Warn("MODIFY: instance not available, may already be modifying",
 			"instance_id", params.InstanceID,
 			"status", instanceInfo.Status)
 	}
 
-	e.logger.Info("MODIFY: starting instance modification",
-		"operation_id", op.ID,
+	if conflict := conflictingPendingValues(instanceInfo.PendingModifiedValues,
+		params.InstanceType, params.StorageType, params.IOPS, params.StorageThroughput); conflict != nil {
+		data, _ := json.Marshal(conflict)
+		e.addEvent(op.ID, "pending_modifications",
+			fmt.Sprintf("Instance %s already has pending changes: %s", params.InstanceID, conflict), data)
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"instance %s already has pending changes (%s), which this modify would apply immediately; let them apply or cancel them, then 'continue' to check again, or 'abort'",
+			params.InstanceID, conflict)
+	}
+
+	if err := e.awaitReaderFloor(ctx, op, step, rdsClient, params.InstanceID); err != nil {
+		return err
+	}
+
+	e.stepLogger(ctx).Info("MODIFY: starting instance modification",
 		"instance_id", params.InstanceID,
 		"instance_type", params.InstanceType,
-		"storage_type", params.StorageType)
+		"storage_type", params.StorageType,
+		"parameter_group", params.DBParameterGroupName)
 
 	modifyParams := rds.ModifyInstanceParams{
-		InstanceID:        params.InstanceID,
-		InstanceType:      params.InstanceType,
-		StorageType:       params.StorageType,
-		IOPS:              params.IOPS,
-		StorageThroughput: params.StorageThroughput,
-		ApplyImmediately:  true,
+		InstanceID:           params.InstanceID,
+		InstanceType:         params.InstanceType,
+		StorageType:          params.StorageType,
+		IOPS:                 params.IOPS,
+		StorageThroughput:    params.StorageThroughput,
+		DBParameterGroupName: params.DBParameterGroupName,
+		ApplyImmediately:     true,
 	}
 
 	err = rdsClient.ModifyInstance(ctx, modifyParams)
 
-	e.logger.Info("MODIFY: completed instance modification call",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("MODIFY: completed instance modification call",
 		"instance_id", params.InstanceID,
 		"error", err)
 
 	return err
 }
 
+// conflictingPendingValues returns the pending changes that differ from what
+// a modify asks for, or nil when there are none. A pending value equal to the
+// target is usually an earlier attempt of the same modify.
+func conflictingPendingValues(pending *types.PendingModifiedValues, instanceType, storageType string, iops, throughput *int32) *types.PendingModifiedValues {
+	if pending == nil {
+		return nil
+	}
+	conflict := *pending
+	if conflict.InstanceClass == instanceType {
+		conflict.InstanceClass = ""
+	}
+	if conflict.StorageType == storageType {
+		conflict.StorageType = ""
+	}
+	if conflict.IOPS != nil && iops != nil && *conflict.IOPS == *iops {
+		conflict.IOPS = nil
+	}
+	if conflict.StorageThroughput != nil && throughput != nil && *conflict.StorageThroughput == *throughput {
+		conflict.StorageThroughput = nil
+	}
+	if conflict.IsEmpty() {
+		return nil
+	}
+	return &conflict
+}
+
 // handleDeleteInstance deletes an instance.
 func (e *Engine) handleDeleteInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -415,10 +1020,7 @@ func (e *Engine) handleDeleteInstance(ctx context.Context, op *types.Operation,
 		return err
 	}
 
-	var params struct {
-		InstanceID        string `json:"instance_id"`
-		SkipFinalSnapshot bool   `json:"skip_final_snapshot"`
-	}
+	var params deleteInstanceParams
 	if len(step.Parameters) > 0 {
 		if err := json.Unmarshal(step.Parameters, &params); err != nil {
 			return errors.Wrap(err, "unmarshal params")
@@ -433,6 +1035,10 @@ func (e *Engine) handleDeleteInstance(ctx context.Context, op *types.Operation,
 	if params.InstanceID == "" {
 		return errors.Wrap(internalerrors.ErrInvalidParameter, "instance_id required")
 	}
+	snapshotID, err := params.finalSnapshotID(op, params.InstanceID)
+	if err != nil {
+		return err
+	}
 
 	// Safety check: never delete the current writer instance.
 	// This prevents data loss if a failover didn't complete as expected.
@@ -440,16 +1046,67 @@ func (e *Engine) handleDeleteInstance(ctx context.Context, op *types.Operation,
 	if err != nil {
 		return errors.Wrap(err, "get cluster info for safety check")
 	}
+	if err := refuseWriterDelete(clusterInfo, params.InstanceID); err != nil {
+		return err
+	}
 
-	for _, inst := range clusterInfo.Instances {
-		if inst.InstanceID == params.InstanceID && inst.Role == "writer" {
+	// RDS rejects a final snapshot of an Aurora cluster member, whose data
+	// lives in the cluster volume, so the cluster is snapshotted instead.
+	if snapshotID != "" && isClusterMember(clusterInfo, params.InstanceID) {
+		if err := e.createFinalClusterSnapshot(ctx, rdsClient, op, snapshotID); err != nil {
+			return err
+		}
+		e.addEvent(op.ID, "info", fmt.Sprintf("Deleting %s after final cluster snapshot %s", params.InstanceID, snapshotID), nil)
+		return rdsClient.DeleteInstance(ctx, params.InstanceID, "")
+	}
+
+	if snapshotID != "" {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Deleting %s with final snapshot %s", params.InstanceID, snapshotID), nil)
+	}
+	return rdsClient.DeleteInstance(ctx, params.InstanceID, snapshotID)
+}
+
+// isClusterMember reports whether instanceID is one of the cluster's
+// instances.
+func isClusterMember(info *types.ClusterInfo, instanceID string) bool {
+	for _, inst := range info.Instances {
+		if inst.InstanceID == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+// createFinalClusterSnapshot takes the cluster snapshot standing in for a
+// member's final snapshot. A snapshot of that name this operation already
+// started, on an earlier attempt of the step, is reused. The snapshot has no
+// retention tag, so snapshot cleanup never removes it.
+func (e *Engine) createFinalClusterSnapshot(ctx context.Context, rdsClient *rds.Client, op *types.Operation, snapshotID string) error {
+	existing, err := rdsClient.FindSnapshotByOperationID(ctx, op.ClusterID, op.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.SnapshotID == snapshotID {
+		return nil
+	}
+	if err := rdsClient.CreateClusterSnapshot(ctx, op.ClusterID, snapshotID, op.ID, 0, operationTags(op)); err != nil {
+		return errors.Wrap(err, "create final cluster snapshot")
+	}
+	return nil
+}
+
+// refuseWriterDelete returns ErrInvalidState if instanceID is the cluster's
+// current writer, which a temp instance is after a failover to it that was
+// never failed back.
+func refuseWriterDelete(info *types.ClusterInfo, instanceID string) error {
+	for _, inst := range info.Instances {
+		if inst.InstanceID == instanceID && inst.Role == "writer" {
 			return errors.Wrapf(internalerrors.ErrInvalidState,
 				"refusing to delete instance %s because it is the current writer; "+
-					"failover may not have completed successfully", params.InstanceID)
+					"failover may not have completed successfully", instanceID)
 		}
 	}
-
-	return rdsClient.DeleteInstance(ctx, params.InstanceID, params.SkipFinalSnapshot)
+	return nil
 }
 
 // handleWaitInstanceDeleted waits for an instance to be deleted.
@@ -476,18 +1133,30 @@ func (e *Engine) handleWaitInstanceDeleted(ctx context.Context, op *types.Operat
 		return errors.Wrap(internalerrors.ErrInvalidParameter, "instance_id required")
 	}
 
-	step.WaitCondition = "waiting for instance to be deleted"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for instance to be deleted")
+	e.startWait(step, params.InstanceID)
 
-	err = rdsClient.WaitForInstanceDeleted(ctx, params.InstanceID, e.getWaitTimeout(op))
-	if err != nil {
-		return errors.Wrapf(internalerrors.ErrWaitTimeout, "instance %s: %v", params.InstanceID, err)
+	var lastErr error
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		deleted, err := rdsClient.IsInstanceDeleted(ctx, params.InstanceID)
+		if err != nil {
+			lastErr = err
+			return false, transient(err)
+		}
+		return deleted, nil
+	})
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		if lastErr != nil {
+			return errors.Wrapf(internalerrors.ErrWaitTimeout, "instance %s: %v", params.InstanceID, lastErr)
+		}
+		return errors.Wrapf(internalerrors.ErrWaitTimeout, "instance %s was not deleted", params.InstanceID)
 	}
-
-	return nil
+	return err
 }
 
-// handleCreateSnapshot creates a cluster snapshot.
+// handleCreateSnapshot creates a cluster snapshot. If an earlier attempt of
+// the step already started a snapshot for this operation, that snapshot is
+// reused rather than taking a second one.
 func (e *Engine) handleCreateSnapshot(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
 	if err != nil {
@@ -495,7 +1164,9 @@ func (e *Engine) handleCreateSnapshot(ctx context.Context, op *types.Operation,
 	}
 
 	var params struct {
-		SnapshotID string `json:"snapshot_id"`
+		SnapshotID      string `json:"snapshot_id"`
+		RetentionDays   int    `json:"retention_days"`
+		CopyClusterTags bool   `json:"copy_cluster_tags"`
 	}
 	if len(step.Parameters) > 0 {
 		if err := json.Unmarshal(step.Parameters, &params); err != nil {
@@ -503,11 +1174,37 @@ func (e *Engine) handleCreateSnapshot(ctx context.Context, op *types.Operation,
 		}
 	}
 
+	// Check for this operation's snapshot first (idempotency check). A step
+	// naming its snapshot only reuses that one.
+	existing, err := rdsClient.FindSnapshotByOperationID(ctx, op.ClusterID, op.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && (params.SnapshotID == "" || existing.SnapshotID == params.SnapshotID) {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Snapshot %s already exists (%s), reusing", existing.SnapshotID, existing.Status), nil)
+		result, _ := json.Marshal(map[string]string{"snapshot_id": existing.SnapshotID})
+		step.Result = result
+		return nil
+	}
+
 	if params.SnapshotID == "" {
 		params.SnapshotID = op.ClusterID + "-pre-upgrade-" + time.Now().Format("20060102-150405")
 	}
 
-	err = rdsClient.CreateClusterSnapshot(ctx, op.ClusterID, params.SnapshotID)
+	// The cluster's tags are best-effort like a temp instance's: failing to
+	// read them only leaves them off the snapshot.
+	tags := make(map[string]string)
+	if params.CopyClusterTags {
+		clusterTags, err := rdsClient.GetClusterTags(ctx, op.ClusterID)
+		if err != nil {
+			e.stepLogger(ctx).Warn("failed to read cluster tags, snapshot will not inherit them",
+				"error", err)
+		}
+		maps.Copy(tags, clusterTags)
+	}
+	maps.Copy(tags, operationTags(op))
+
+	err = rdsClient.CreateClusterSnapshot(ctx, op.ClusterID, params.SnapshotID, op.ID, params.RetentionDays, tags)
 	if err != nil {
 		return err
 	}
@@ -541,15 +1238,43 @@ func (e *Engine) handleWaitSnapshotAvailable(ctx context.Context, op *types.Oper
 		return errors.Wrap(internalerrors.ErrInvalidParameter, "snapshot_id required")
 	}
 
-	step.WaitCondition = "waiting for snapshot to become available"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for snapshot to become available")
+	e.startWait(step, params.SnapshotID)
+
+	var lastErr error
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		available, err := rdsClient.IsSnapshotAvailable(ctx, params.SnapshotID)
+		if err != nil {
+			lastErr = err
+			return false, transient(err)
+		}
+		return available, nil
+	})
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		if lastErr != nil {
+			return errors.Wrapf(internalerrors.ErrWaitTimeout, "snapshot %s: %v", params.SnapshotID, lastErr)
+		}
+		return errors.Wrapf(internalerrors.ErrWaitTimeout, "snapshot %s", params.SnapshotID)
+	}
+	return err
+}
 
-	err = rdsClient.WaitForSnapshotAvailable(ctx, params.SnapshotID, e.getWaitTimeout(op))
+// handleModifyServerlessScaling sets the cluster's Serverless v2 capacity range.
+func (e *Engine) handleModifyServerlessScaling(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
 	if err != nil {
-		return errors.Wrapf(internalerrors.ErrWaitTimeout, "snapshot %s: %v", params.SnapshotID, err)
+		return err
 	}
 
-	return nil
+	var params struct {
+		MinCapacity float64 `json:"min_capacity"`
+		MaxCapacity float64 `json:"max_capacity"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	return rdsClient.ModifyServerlessScaling(ctx, op.ClusterID, params.MinCapacity, params.MaxCapacity)
 }
 
 // handleModifyCluster modifies cluster settings.
@@ -569,16 +1294,137 @@ func (e *Engine) handleModifyCluster(ctx context.Context, op *types.Operation, s
 		return errors.Wrap(err, "unmarshal params")
 	}
 
-	modifyParams := rds.ModifyClusterParams{
-		ClusterID:                    op.ClusterID,
-		EngineVersion:                params.EngineVersion,
-		AllowMajorVersionUpgrade:     params.AllowMajorVersionUpgrade,
-		DBClusterParameterGroupName:  params.DBClusterParameterGroupName,
-		DBInstanceParameterGroupName: params.DBInstanceParameterGroupName,
-		ApplyImmediately:             true,
-	}
+	// Never jump a major version in place unless the step explicitly allows it
+	if params.EngineVersion != "" && !params.AllowMajorVersionUpgrade {
+		info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+		if err != nil {
+			return errors.Wrap(err, "get cluster info")
+		}
+		if rds.EngineMajorVersion(info.Engine, params.EngineVersion) != rds.EngineMajorVersion(info.Engine, info.EngineVersion) {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s -> %s is a major version upgrade but allow_major_version_upgrade is not set",
+				info.EngineVersion, params.EngineVersion)
+		}
+	}
+
+	modifyParams := rds.ModifyClusterParams{
+		ClusterID:                    op.ClusterID,
+		EngineVersion:                params.EngineVersion,
+		AllowMajorVersionUpgrade:     params.AllowMajorVersionUpgrade,
+		DBClusterParameterGroupName:  params.DBClusterParameterGroupName,
+		DBInstanceParameterGroupName: params.DBInstanceParameterGroupName,
+		ApplyImmediately:             true,
+	}
+
+	return rdsClient.ModifyCluster(ctx, modifyParams)
+}
+
+// applyParameterGroupResult is the result of an apply_parameter_group step.
+// Later reboot_instance steps read RebootRequired to decide whether to run.
+type applyParameterGroupResult struct {
+	ParameterGroupName         string                `json:"parameter_group_name"`
+	PreviousParameterGroupName string                `json:"previous_parameter_group_name"`
+	ChangedParameters          []rds.ParameterChange `json:"changed_parameters"`
+	PendingReboot              []string              `json:"pending_reboot"`
+	RebootRequired             bool                  `json:"reboot_required"`
+}
+
+// handleApplyParameterGroup associates a new cluster parameter group with the
+// cluster. The custom parameters of the current and new groups are compared
+// so the result records which changed parameters are static and will only
+// take effect once the instances are rebooted.
+func (e *Engine) handleApplyParameterGroup(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		ParameterGroupName string `json:"parameter_group_name"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+	if params.ParameterGroupName == "" {
+		return errors.New("parameter_group_name is required")
+	}
+
+	current, err := rdsClient.GetClusterParameterGroup(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get current parameter group")
+	}
+
+	// A retry after the group was already associated must keep the original
+	// comparison, otherwise the pending reboots would be forgotten.
+	if current.Name == params.ParameterGroupName && len(step.Result) > 0 {
+		return nil
+	}
+
+	fromParams, err := rdsClient.GetClusterParameterGroupCustomParameters(ctx, current.Name)
+	if err != nil {
+		return errors.Wrapf(err, "get parameters of %s", current.Name)
+	}
+	toParams, err := rdsClient.GetClusterParameterGroupCustomParameters(ctx, params.ParameterGroupName)
+	if err != nil {
+		return errors.Wrapf(err, "get parameters of %s", params.ParameterGroupName)
+	}
+
+	result := applyParameterGroupResult{
+		ParameterGroupName:         params.ParameterGroupName,
+		PreviousParameterGroupName: current.Name,
+		ChangedParameters:          rds.ChangedParameters(fromParams, toParams),
+		PendingReboot:              []string{},
+	}
+	for _, change := range result.ChangedParameters {
+		if change.PendingReboot() {
+			result.PendingReboot = append(result.PendingReboot, change.Name)
+		}
+	}
+	result.RebootRequired = len(result.PendingReboot) > 0
+
+	if current.Name != params.ParameterGroupName {
+		e.stepLogger(ctx).Info("applying cluster parameter group",
+			"from", current.Name,
+			"to", params.ParameterGroupName)
+
+		if err := rdsClient.ModifyCluster(ctx, rds.ModifyClusterParams{
+			ClusterID:                   op.ClusterID,
+			DBClusterParameterGroupName: params.ParameterGroupName,
+			ApplyImmediately:            true,
+		}); err != nil {
+			return errors.Wrap(err, "modify cluster parameter group")
+		}
+	}
+
+	if result.RebootRequired {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Parameter group %s applied; static parameters pending reboot: %s",
+			params.ParameterGroupName, strings.Join(result.PendingReboot, ", ")), nil)
+	} else {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Parameter group %s applied; %d changed parameter(s) are all dynamic, skipping reboots",
+			params.ParameterGroupName, len(result.ChangedParameters)), nil)
+	}
+
+	step.Result, _ = json.Marshal(result)
+	return nil
+}
 
-	return rdsClient.ModifyCluster(ctx, modifyParams)
+// parameterGroupRebootRequired reports whether the most recent
+// apply_parameter_group step before the current step left static parameters
+// pending reboot. It returns true when there is no such step, so a reboot
+// gated on it is never skipped by mistake.
+func parameterGroupRebootRequired(op *types.Operation) bool {
+	for i := op.CurrentStepIndex - 1; i >= 0; i-- {
+		prevStep := &op.Steps[i]
+		if prevStep.Action != "apply_parameter_group" || len(prevStep.Result) == 0 {
+			continue
+		}
+		var result applyParameterGroupResult
+		if err := json.Unmarshal(prevStep.Result, &result); err != nil {
+			return true
+		}
+		return result.RebootRequired
+	}
+	return true
 }
 
 // handleWaitClusterAvailable waits for the cluster to become available.
@@ -588,116 +1434,119 @@ func (e *Engine) handleWaitClusterAvailable(ctx context.Context, op *types.Opera
 		return err
 	}
 
-	step.WaitCondition = "waiting for cluster to become available"
-	step.State = types.StepStateWaiting
+	var params struct {
+		RestoredCluster bool `json:"restored_cluster,omitempty"` // wait for the snapshot restore test's cluster
+	}
+	if len(step.Parameters) > 0 {
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
 
-	e.logger.Info("starting wait for cluster available",
-		"operation_id", op.ID,
-		"cluster_id", op.ClusterID,
+	clusterID := op.ClusterID
+	if params.RestoredCluster {
+		clusterID = e.findRestoredClusterID(op)
+		if clusterID == "" {
+			return errors.Wrap(internalerrors.ErrInvalidParameter, "no restored cluster found from a previous step")
+		}
+	}
+
+	e.markWaiting(step, "waiting for cluster to become available")
+	e.startWait(step, clusterID)
+
+	e.stepLogger(ctx).Info("starting wait for cluster available",
+		"cluster_id", clusterID,
 		"step_name", step.Name)
 
 	// Poll until cluster and all instances are available
-	timeout := time.After(e.getWaitTimeout(op))
-	ticker := time.NewTicker(e.defaultPollInterval)
-	defer ticker.Stop()
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
 
 	pollCount := 0
-	for {
-		select {
-		case <-ctx.Done():
-			e.logger.Warn("context cancelled while waiting for cluster",
-				"operation_id", op.ID,
-				"cluster_id", op.ClusterID)
-			return ctx.Err()
-		case <-timeout:
-			e.logger.Error("timeout waiting for cluster available",
-				"operation_id", op.ID,
-				"cluster_id", op.ClusterID,
-				"last_condition", step.WaitCondition)
-			return errors.Wrapf(internalerrors.ErrWaitTimeout, "cluster %s", op.ClusterID)
-		case <-ticker.C:
-			pollCount++
-			info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
-			if err != nil {
-				e.logger.Warn("transient error getting cluster info",
-					"operation_id", op.ID,
-					"cluster_id", op.ClusterID,
-					"error", err,
+	defer func() { e.metrics.WaitPolls(step.Action, pollCount) }()
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		pollCount++
+		info, err := rdsClient.GetClusterInfo(ctx, clusterID)
+		if err != nil {
+			e.stepLogger(ctx).Warn("transient error getting cluster info",
+				"cluster_id", clusterID,
+				"error", err,
+				"poll_count", pollCount)
+			return false, transient(err)
+		}
+
+		// Check cluster status using the status helper
+		clusterStatus := rds.ClusterStatus(info.Status)
+		if !clusterStatus.IsAvailable() {
+			// Update wait condition to show current cluster status
+			if clusterStatus.IsTransitional() {
+				step.WaitCondition = "cluster status: " + info.Status
+			}
+			if pollCount%10 == 0 {
+				e.stepLogger(ctx).Info("waiting for cluster",
+					"cluster_id", clusterID,
+					"cluster_status", info.Status,
 					"poll_count", pollCount)
-				continue
 			}
+			return false, nil
+		}
 
-			// Check cluster status using the status helper
-			clusterStatus := rds.ClusterStatus(info.Status)
-			if !clusterStatus.IsAvailable() {
-				// Update wait condition to show current cluster status
-				if clusterStatus.IsTransitional() {
-					step.WaitCondition = "cluster status: " + info.Status
-				}
-				if pollCount%10 == 0 {
-					e.logger.Info("waiting for cluster",
-						"operation_id", op.ID,
-						"cluster_id", op.ClusterID,
-						"cluster_status", info.Status,
-						"poll_count", pollCount)
+		// Check all instance statuses
+		for _, instance := range info.Instances {
+			instanceStatus := rds.InstanceStatus(instance.Status)
+
+			// Skip stopped or deleting instances - they don't block cluster availability
+			if instanceStatus.IsStopped() || instanceStatus.IsDeleting() {
+				if pollCount == 1 {
+					e.stepLogger(ctx).Info("skipping stopped/deleting instance in availability check",
+						"instance_id", instance.InstanceID,
+						"instance_status", instance.Status)
 				}
 				continue
 			}
 
-			// Check all instance statuses
-			allAvailable := true
-			var blockingInstance string
-			var blockingStatus string
-			for _, instance := range info.Instances {
-				instanceStatus := rds.InstanceStatus(instance.Status)
-
-				// Skip stopped or deleting instances - they don't block cluster availability
-				if instanceStatus.IsStopped() || instanceStatus.IsDeleting() {
-					if pollCount == 1 {
-						e.logger.Info("skipping stopped/deleting instance in availability check",
-							"operation_id", op.ID,
-							"instance_id", instance.InstanceID,
-							"instance_status", instance.Status)
-					}
-					continue
-				}
-
-				if !instanceStatus.IsAvailable() {
-					allAvailable = false
-					blockingInstance = instance.InstanceID
-					blockingStatus = instance.Status
-
-					if instanceStatus.IsError() {
-						e.logger.Error("instance in error state",
-							"operation_id", op.ID,
-							"instance_id", instance.InstanceID,
-							"instance_status", instance.Status)
-						return errors.Wrapf(internalerrors.ErrWaitTimeout,
-							"instance %s is in error state: %s", instance.InstanceID, instance.Status)
-					}
-					break
-				}
+			if instanceStatus.IsAvailable() {
+				continue
 			}
 
-			if !allAvailable {
-				step.WaitCondition = "instance " + blockingInstance + " status: " + blockingStatus
-				if pollCount%10 == 0 {
-					e.logger.Info("waiting for instance",
-						"operation_id", op.ID,
-						"instance_id", blockingInstance,
-						"instance_status", blockingStatus,
-						"poll_count", pollCount)
-				}
+			if instanceStatus.IsError() {
+				e.stepLogger(ctx).Error("instance in error state",
+					"instance_id", instance.InstanceID,
+					"instance_status", instance.Status)
+				return false, errors.Wrapf(internalerrors.ErrWaitTimeout,
+					"instance %s is in error state: %s", instance.InstanceID, instance.Status)
 			}
 
-			if allAvailable {
-				e.logger.Info("cluster and all instances available",
-					"operation_id", op.ID,
-					"cluster_id", op.ClusterID,
+			step.WaitCondition = "instance " + instance.InstanceID + " status: " + instance.Status
+			if pollCount%10 == 0 {
+				e.stepLogger(ctx).Info("waiting for instance",
+					"instance_id", instance.InstanceID,
+					"instance_status", instance.Status,
 					"poll_count", pollCount)
-				return nil
 			}
+			return false, nil
 		}
+
+		e.stepLogger(ctx).Info("cluster and all instances available",
+			"cluster_id", clusterID,
+			"poll_count", pollCount)
+		return true, nil
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		e.stepLogger(ctx).Warn("context cancelled while waiting for cluster",
+			"cluster_id", clusterID)
+		return err
+	case err == internalerrors.ErrWaitTimeout: // the poll timed out, not an instance error state
+		e.stepLogger(ctx).Error("timeout waiting for cluster available",
+			"cluster_id", clusterID,
+			"last_condition", step.WaitCondition)
+		return errors.Wrapf(err, "cluster %s", clusterID)
+	default:
+		return err
 	}
 }
 
@@ -731,6 +1580,48 @@ func (e *Engine) findCreatedSnapshotID(op *types.Operation) string {
 	return ""
 }
 
+// handleCheckUpgradePrerequisites runs the Blue-Green prerequisite checks for
+// the target version. Failed advisory checks are recorded as warnings; a failed
+// required check pauses the operation with the remediation for each failure.
+func (e *Engine) handleCheckUpgradePrerequisites(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		TargetEngineVersion string `json:"target_engine_version"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	prereqs, err := rdsClient.CheckBlueGreenPrerequisites(ctx, op.ClusterID, params.TargetEngineVersion)
+	if err != nil {
+		return errors.Wrap(err, "check upgrade prerequisites")
+	}
+	step.Result, _ = json.Marshal(prereqs)
+
+	for _, check := range prereqs.Checks {
+		if !check.Passed && !check.Required {
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Upgrade prerequisite %s: %s", check.Name, check.Message), nil)
+		}
+	}
+
+	failed := prereqs.FailedRequired()
+	if len(failed) > 0 {
+		problems := make([]string, 0, len(failed))
+		for _, check := range failed {
+			problems = append(problems, fmt.Sprintf("%s (%s)", check.Message, check.Remediation))
+		}
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"upgrade prerequisites not met: %s", strings.Join(problems, "; "))
+	}
+
+	e.addEvent(op.ID, "info", fmt.Sprintf("Upgrade prerequisites met for %s", params.TargetEngineVersion), nil)
+	return nil
+}
+
 // handlePrepareParameterGroup prepares parameter groups for engine upgrade.
 // If the cluster or instances use custom parameter groups, this creates new parameter groups
 // for the target engine version and migrates the custom settings.
@@ -767,7 +1658,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 	var targetClusterPGName string
 	var clusterPGAction string
 	var clusterMigratedCount int
-	var clusterSkippedParams []string
+	var clusterSkippedParams []types.SkippedParameter
 
 	isDefaultClusterPG := strings.HasPrefix(currentClusterPG.Name, "default.")
 	if isDefaultClusterPG {
@@ -788,7 +1679,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 
 		targetClusterPGName = params.TargetParameterGroupName
 		if targetClusterPGName == "" {
-			targetClusterPGName = fmt.Sprintf("%s-%s-upgraded", op.ClusterID, strings.ReplaceAll(params.TargetEngineVersion, ".", "-"))
+			targetClusterPGName = fmt.Sprintf("%s-%s-upgraded", op.ClusterID, rds.VersionNameSuffix(params.TargetEngineVersion))
 		}
 
 		exists, err := rdsClient.ParameterGroupExists(ctx, targetClusterPGName)
@@ -798,7 +1689,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 
 		if !exists {
 			description := fmt.Sprintf("Migrated from %s for engine upgrade to %s", currentClusterPG.Name, params.TargetEngineVersion)
-			if err := rdsClient.CreateClusterParameterGroup(ctx, targetClusterPGName, targetFamily, description); err != nil {
+			if err := rdsClient.CreateClusterParameterGroup(ctx, targetClusterPGName, targetFamily, description, operationTags(op)); err != nil {
 				return errors.Wrap(err, "create cluster parameter group")
 			}
 			e.addEvent(op.ID, "info", fmt.Sprintf("Created cluster parameter group: %s (family: %s)", targetClusterPGName, targetFamily), nil)
@@ -817,7 +1708,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 	var targetInstancePGName string
 	var instancePGAction string
 	var instanceMigratedCount int
-	var instanceSkippedParams []string
+	var instanceSkippedParams []types.SkippedParameter
 	var sourceInstancePGName string
 
 	if len(clusterInfo.Instances) > 0 {
@@ -858,7 +1749,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 			e.addEvent(op.ID, "info", fmt.Sprintf("Found %d custom instance parameter(s) to migrate", len(customParams)), nil)
 
 			// Generate instance PG name based on cluster PG name pattern
-			targetInstancePGName = fmt.Sprintf("%s-%s-instance-upgraded", op.ClusterID, strings.ReplaceAll(params.TargetEngineVersion, ".", "-"))
+			targetInstancePGName = fmt.Sprintf("%s-%s-instance-upgraded", op.ClusterID, rds.VersionNameSuffix(params.TargetEngineVersion))
 
 			exists, err := rdsClient.InstanceParameterGroupExists(ctx, targetInstancePGName)
 			if err != nil {
@@ -867,7 +1758,7 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 
 			if !exists {
 				description := fmt.Sprintf("Migrated from %s for engine upgrade to %s", currentInstancePG.Name, params.TargetEngineVersion)
-				if err := rdsClient.CreateInstanceParameterGroup(ctx, targetInstancePGName, targetFamily, description); err != nil {
+				if err := rdsClient.CreateInstanceParameterGroup(ctx, targetInstancePGName, targetFamily, description, operationTags(op)); err != nil {
 					return errors.Wrap(err, "create instance parameter group")
 				}
 				e.addEvent(op.ID, "info", fmt.Sprintf("Created instance parameter group: %s (family: %s)", targetInstancePGName, targetFamily), nil)
@@ -912,61 +1803,142 @@ func (e *Engine) handlePrepareParameterGroup(ctx context.Context, op *types.Oper
 }
 
 // applyParametersToClusterPG applies custom parameters to a cluster parameter group.
-func (e *Engine) applyParametersToClusterPG(ctx context.Context, rdsClient *rds.Client, op *types.Operation, pgName string, customParams []rds.ParameterInfo) []string {
-	var skippedParams []string
-	if len(customParams) == 0 {
+// Parameters that cannot be applied are returned with the reason they were skipped.
+func (e *Engine) applyParametersToClusterPG(ctx context.Context, rdsClient *rds.Client, op *types.Operation, pgName string, customParams []rds.ParameterInfo) []types.SkippedParameter {
+	var skippedParams []types.SkippedParameter
+	var applicable []rds.ParameterInfo
+	for _, p := range customParams {
+		if !p.IsModifiable {
+			skippedParams = append(skippedParams, types.SkippedParameter{Name: p.Name, Value: p.Value, Reason: types.SkipReasonNotModifiable})
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped non-modifiable cluster parameter: %s=%s", p.Name, p.Value), nil)
+			continue
+		}
+		applicable = append(applicable, p)
+	}
+	if len(applicable) == 0 {
 		return skippedParams
 	}
 
-	if err := rdsClient.ModifyClusterParameterGroupParams(ctx, pgName, customParams); err != nil {
+	err := rdsClient.ModifyClusterParameterGroupParams(ctx, pgName, applicable)
+	var invalid *rds.ParameterValidationError
+	if errors.As(err, &invalid) {
+		// Validation rejected the whole set before anything was applied;
+		// drop the invalid values and apply the rest together.
+		for _, skipped := range invalid.Invalid {
+			skippedParams = append(skippedParams, skipped)
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped cluster parameter (%s): %s", skipped.Reason, skipped.Error), nil)
+		}
+		applicable = withoutSkippedParameters(applicable, invalid.Invalid)
+		err = rdsClient.ModifyClusterParameterGroupParams(ctx, pgName, applicable)
+	}
+	if err != nil {
 		e.addEvent(op.ID, "warning", fmt.Sprintf("Some cluster parameters could not be applied: %v", err), nil)
 		// Try applying parameters one by one
-		for _, p := range customParams {
+		for _, p := range applicable {
 			if err := rdsClient.ModifyClusterParameterGroupParams(ctx, pgName, []rds.ParameterInfo{p}); err != nil {
-				skippedParams = append(skippedParams, p.Name)
-				e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped incompatible cluster parameter: %s=%s (%v)", p.Name, p.Value, err), nil)
+				skipped := types.SkippedParameter{Name: p.Name, Value: p.Value, Reason: classifyParameterError(err), Error: err.Error()}
+				skippedParams = append(skippedParams, skipped)
+				e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped cluster parameter (%s): %s=%s (%v)", skipped.Reason, p.Name, p.Value, err), nil)
 			} else {
 				e.addEvent(op.ID, "info", fmt.Sprintf("Applied cluster parameter: %s=%s", p.Name, p.Value), nil)
 			}
 		}
-	} else {
-		e.addEvent(op.ID, "info", fmt.Sprintf("Applied %d custom cluster parameter(s) to %s", len(customParams), pgName), nil)
+	} else if len(applicable) > 0 {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Applied %d custom cluster parameter(s) to %s", len(applicable), pgName), nil)
 	}
 
 	if len(skippedParams) > 0 {
-		e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped %d incompatible cluster parameter(s): %v", len(skippedParams), skippedParams), nil)
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped %d cluster parameter(s): %v", len(skippedParams), skippedParameterNames(skippedParams)), nil)
 	}
 	return skippedParams
 }
 
 // applyParametersToInstancePG applies custom parameters to an instance parameter group.
-func (e *Engine) applyParametersToInstancePG(ctx context.Context, rdsClient *rds.Client, op *types.Operation, pgName string, customParams []rds.ParameterInfo) []string {
-	var skippedParams []string
-	if len(customParams) == 0 {
+// Parameters that cannot be applied are returned with the reason they were skipped.
+func (e *Engine) applyParametersToInstancePG(ctx context.Context, rdsClient *rds.Client, op *types.Operation, pgName string, customParams []rds.ParameterInfo) []types.SkippedParameter {
+	var skippedParams []types.SkippedParameter
+	var applicable []rds.ParameterInfo
+	for _, p := range customParams {
+		if !p.IsModifiable {
+			skippedParams = append(skippedParams, types.SkippedParameter{Name: p.Name, Value: p.Value, Reason: types.SkipReasonNotModifiable})
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped non-modifiable instance parameter: %s=%s", p.Name, p.Value), nil)
+			continue
+		}
+		applicable = append(applicable, p)
+	}
+	if len(applicable) == 0 {
 		return skippedParams
 	}
 
-	if err := rdsClient.ModifyInstanceParameterGroupParams(ctx, pgName, customParams); err != nil {
+	err := rdsClient.ModifyInstanceParameterGroupParams(ctx, pgName, applicable)
+	var invalid *rds.ParameterValidationError
+	if errors.As(err, &invalid) {
+		// Validation rejected the whole set before anything was applied;
+		// drop the invalid values and apply the rest together.
+		for _, skipped := range invalid.Invalid {
+			skippedParams = append(skippedParams, skipped)
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped instance parameter (%s): %s", skipped.Reason, skipped.Error), nil)
+		}
+		applicable = withoutSkippedParameters(applicable, invalid.Invalid)
+		err = rdsClient.ModifyInstanceParameterGroupParams(ctx, pgName, applicable)
+	}
+	if err != nil {
 		e.addEvent(op.ID, "warning", fmt.Sprintf("Some instance parameters could not be applied: %v", err), nil)
 		// Try applying parameters one by one
-		for _, p := range customParams {
+		for _, p := range applicable {
 			if err := rdsClient.ModifyInstanceParameterGroupParams(ctx, pgName, []rds.ParameterInfo{p}); err != nil {
-				skippedParams = append(skippedParams, p.Name)
-				e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped incompatible instance parameter: %s=%s (%v)", p.Name, p.Value, err), nil)
+				skipped := types.SkippedParameter{Name: p.Name, Value: p.Value, Reason: classifyParameterError(err), Error: err.Error()}
+				skippedParams = append(skippedParams, skipped)
+				e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped instance parameter (%s): %s=%s (%v)", skipped.Reason, p.Name, p.Value, err), nil)
 			} else {
 				e.addEvent(op.ID, "info", fmt.Sprintf("Applied instance parameter: %s=%s", p.Name, p.Value), nil)
 			}
 		}
-	} else {
-		e.addEvent(op.ID, "info", fmt.Sprintf("Applied %d custom instance parameter(s) to %s", len(customParams), pgName), nil)
+	} else if len(applicable) > 0 {
+		e.addEvent(op.ID, "info", fmt.Sprintf("Applied %d custom instance parameter(s) to %s", len(applicable), pgName), nil)
 	}
 
 	if len(skippedParams) > 0 {
-		e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped %d incompatible instance parameter(s): %v", len(skippedParams), skippedParams), nil)
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Skipped %d instance parameter(s): %v", len(skippedParams), skippedParameterNames(skippedParams)), nil)
 	}
 	return skippedParams
 }
 
+// withoutSkippedParameters returns params less the skipped ones.
+func withoutSkippedParameters(params []rds.ParameterInfo, skipped []types.SkippedParameter) []rds.ParameterInfo {
+	var kept []rds.ParameterInfo
+	for _, p := range params {
+		if !slices.ContainsFunc(skipped, func(s types.SkippedParameter) bool { return s.Name == p.Name }) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// classifyParameterError maps a ModifyDB*ParameterGroup error for a single
+// parameter to a skip reason. RDS reports all three cases as
+// InvalidParameterValue, so the message is the only distinguishing signal.
+func classifyParameterError(err error) types.SkipReason {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "could not find parameter"), strings.Contains(msg, "unknown parameter"):
+		return types.SkipReasonUnknownParameter
+	case strings.Contains(msg, "cannot be modified"), strings.Contains(msg, "not modifiable"):
+		return types.SkipReasonNotModifiable
+	default:
+		return types.SkipReasonIncompatibleValue
+	}
+}
+
+// skippedParameterNames returns the names of the skipped parameters.
+func skippedParameterNames(skipped []types.SkippedParameter) []string {
+	names := make([]string, len(skipped))
+	for i, p := range skipped {
+		names[i] = p.Name
+	}
+	return names
+}
+
 // updateModifyClusterStepWithPGs updates the modify_cluster step to use the specified parameter groups.
 func (e *Engine) updateModifyClusterStepWithPGs(op *types.Operation, clusterPGName, instancePGName string) {
 	for i := range op.Steps {
@@ -1033,6 +2005,24 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 		return errors.Wrap(err, "get cluster ARN")
 	}
 
+	// Blue-Green is not supported on a secondary of a global database, and a
+	// primary's upgrade leaves its secondaries behind
+	globalCluster, err := rdsClient.GetGlobalClusterForCluster(ctx, op.ClusterID)
+	if err != nil {
+		e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to check global database membership: %v", err), nil)
+	} else if globalCluster != nil {
+		if !globalCluster.IsPrimary {
+			return errors.Wrapf(internalerrors.ErrInvalidState,
+				"cluster %s is a secondary of global database %s; Blue-Green deployments are only supported on the primary cluster, upgrade the global database from its primary instead",
+				op.ClusterID, globalCluster.GlobalClusterID)
+		}
+		if secondaries := globalCluster.Secondaries(); len(secondaries) > 0 {
+			e.addEvent(op.ID, "warning", fmt.Sprintf(
+				"Cluster %s is the primary of global database %s; its secondary clusters (%s) are not part of this Blue-Green deployment and must be upgraded separately",
+				op.ClusterID, globalCluster.GlobalClusterID, strings.Join(secondaries, ", ")), nil)
+		}
+	}
+
 	// Check for existing Blue-Green deployments that can be adopted
 	existingDeployments, err := rdsClient.ListBlueGreenDeploymentsForCluster(ctx, clusterARN)
 	if err != nil {
@@ -1045,6 +2035,7 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 			switch bgInfo.Status {
 			case "PROVISIONING", "AVAILABLE":
 				e.addEvent(op.ID, "info", fmt.Sprintf("Adopting existing Blue-Green deployment: %s (status: %s)", bgInfo.Identifier, bgInfo.Status), nil)
+				e.recordBlueGreen(ctx, op, bgInfo)
 
 				result, _ := json.Marshal(map[string]any{
 					"deployment_identifier": bgInfo.Identifier,
@@ -1060,7 +2051,17 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 		}
 	}
 
-	// No existing deployment found, create a new one
+	// No existing deployment found, create a new one. A typo in the target
+	// version would otherwise only surface as an AWS error once the green
+	// cluster is being provisioned.
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+	if err := validateBlueGreenTarget(ctx, rdsClient, info.Engine, info.EngineVersion, params.TargetEngineVersion); err != nil {
+		return err
+	}
+
 	// Use parameter group names from step params (set by prepare_parameter_group step)
 	// or fall back to finding them from the prepare step result
 	clusterPGName := params.TargetClusterParameterGroupName
@@ -1076,7 +2077,7 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 	}
 
 	// Generate deployment name (max 60 chars per AWS limit)
-	versionSuffix := "-upgrade-" + strings.ReplaceAll(params.TargetEngineVersion, ".", "-")
+	versionSuffix := "-upgrade-" + rds.VersionNameSuffix(params.TargetEngineVersion)
 	maxClusterLen := 60 - len(versionSuffix)
 	clusterPrefix := op.ClusterID
 	if len(clusterPrefix) > maxClusterLen {
@@ -1100,6 +2101,7 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 	}
 
 	e.addEvent(op.ID, "info", fmt.Sprintf("Blue-Green deployment created: %s (status: %s)", bgInfo.Identifier, bgInfo.Status), nil)
+	e.recordBlueGreen(ctx, op, bgInfo)
 
 	result, _ := json.Marshal(map[string]any{
 		"deployment_identifier": bgInfo.Identifier,
@@ -1112,6 +2114,41 @@ func (e *Engine) handleCreateBlueGreenDeployment(ctx context.Context, op *types.
 	return nil
 }
 
+// validateBlueGreenTarget checks that target is one of the valid upgrade
+// targets of the engine version and that a Blue-Green deployment can reach
+// it, listing the valid targets when it is not.
+func validateBlueGreenTarget(ctx context.Context, client *rds.Client, engine, version, target string) error {
+	targets, err := client.GetValidUpgradeTargets(ctx, engine, version)
+	if err != nil {
+		return errors.Wrap(err, "get valid upgrade targets")
+	}
+	valid := make([]string, 0, len(targets))
+	for _, t := range targets {
+		if t.EngineVersion != target {
+			if t.SupportsBlueGreen {
+				valid = append(valid, t.EngineVersion)
+			}
+			continue
+		}
+		if t.SupportsBlueGreen {
+			return nil
+		}
+		if t.IsMajorVersionUpgrade {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s %s cannot be reached from %s with a Blue-Green deployment", engine, target, version)
+		}
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s %s cannot be reached from %s with a Blue-Green deployment; upgrade in place with a minor_version_upgrade operation instead",
+			engine, target, version)
+	}
+	if len(valid) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s is not a valid upgrade target from %s %s, which has no Blue-Green upgrade targets", target, engine, version)
+	}
+	return errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"%s is not a valid upgrade target from %s %s; valid targets: %s", target, engine, version, strings.Join(valid, ", "))
+}
+
 // handleWaitBlueGreenAvailable waits for the Blue-Green deployment to be available.
 func (e *Engine) handleWaitBlueGreenAvailable(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -1119,75 +2156,72 @@ func (e *Engine) handleWaitBlueGreenAvailable(ctx context.Context, op *types.Ope
 		return err
 	}
 
-	// Get deployment identifier from previous step
-	deploymentID := e.findBlueGreenDeploymentID(op)
-	if deploymentID == "" {
-		return errors.Wrap(internalerrors.ErrInvalidParameter, "blue-green deployment identifier not found")
+	deploymentID, err := e.resolveBlueGreenDeployment(ctx, op, rdsClient)
+	if err != nil {
+		return err
 	}
 
-	step.WaitCondition = "waiting for Blue-Green deployment to be available"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for Blue-Green deployment to be available")
+	e.startWait(step, deploymentID)
 
-	timeout := time.After(e.getWaitTimeout(op))
-	ticker := time.NewTicker(e.defaultPollInterval)
-	defer ticker.Stop()
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-timeout:
-			return errors.Wrapf(internalerrors.ErrWaitTimeout, "Blue-Green deployment %s", deploymentID)
-		case <-ticker.C:
-			bgInfo, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
-			if err != nil {
-				// Transient errors are expected, continue polling
-				continue
+	pollCount := 0
+	defer func() { e.metrics.WaitPolls(step.Action, pollCount) }()
+	err = e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+		pollCount++
+		bgInfo, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
+		if err != nil {
+			// Transient errors are expected, continue polling
+			return false, transient(err)
+		}
+		e.metrics.BlueGreenStatus(deploymentID, bgInfo.Status)
+		e.recordBlueGreen(ctx, op, bgInfo)
+
+		// Update wait condition with current status
+		step.WaitCondition = fmt.Sprintf("Blue-Green status: %s", bgInfo.Status)
+
+		// Log task progress; a failed task fails the deployment whatever
+		// its overall status says
+		for _, task := range bgInfo.Tasks {
+			switch task.Status {
+			case "IN_PROGRESS":
+				step.WaitCondition = fmt.Sprintf("Blue-Green: %s (%s)", task.Name, task.Status)
+			case "FAILED":
+				return false, errors.Errorf("Blue-Green deployment task %s failed with status: %s (%s)", task.Name, bgInfo.Status, bgInfo.StatusDetails)
 			}
+		}
 
-			// Update wait condition with current status
-			step.WaitCondition = fmt.Sprintf("Blue-Green status: %s", bgInfo.Status)
-
-			// Log task progress
+		switch bgInfo.Status {
+		case "AVAILABLE":
+			// Check that all tasks are complete before allowing switchover
+			// Even when status is AVAILABLE, tasks might still be IN_PROGRESS
 			for _, task := range bgInfo.Tasks {
-				if task.Status == "IN_PROGRESS" {
-					step.WaitCondition = fmt.Sprintf("Blue-Green: %s (%s)", task.Name, task.Status)
+				if task.Status == "IN_PROGRESS" || task.Status == "PENDING" {
+					step.WaitCondition = fmt.Sprintf("Blue-Green: waiting for task %s (%s)", task.Name, task.Status)
+					return false, nil // Keep polling until all tasks complete
 				}
 			}
 
-			switch bgInfo.Status {
-			case "AVAILABLE":
-				// Check that all tasks are complete before allowing switchover
-				// Even when status is AVAILABLE, tasks might still be IN_PROGRESS
-				allTasksComplete := true
-				for _, task := range bgInfo.Tasks {
-					if task.Status == "IN_PROGRESS" || task.Status == "PENDING" {
-						allTasksComplete = false
-						step.WaitCondition = fmt.Sprintf("Blue-Green: waiting for task %s (%s)", task.Name, task.Status)
-						break
-					}
-					if task.Status == "FAILED" {
-						return errors.Errorf("Blue-Green deployment task %s failed", task.Name)
-					}
-				}
-
-				if !allTasksComplete {
-					continue // Keep polling until all tasks complete
-				}
-
-				e.addEvent(op.ID, "info", "Blue-Green deployment is available and all tasks complete, ready for switchover", nil)
-				result, _ := json.Marshal(map[string]any{
-					"deployment_identifier": bgInfo.Identifier,
-					"status":                bgInfo.Status,
-					"target_arn":            bgInfo.Target,
-				})
-				step.Result = result
-				return nil
-			case "INVALID_CONFIGURATION", "PROVISIONING_FAILED":
-				return errors.Errorf("Blue-Green deployment failed with status: %s (%s)", bgInfo.Status, bgInfo.StatusDetails)
-			}
+			e.addEvent(op.ID, "info", "Blue-Green deployment is available and all tasks complete, ready for switchover", nil)
+			result, _ := json.Marshal(map[string]any{
+				"deployment_identifier": bgInfo.Identifier,
+				"status":                bgInfo.Status,
+				"target_arn":            bgInfo.Target,
+			})
+			step.Result = result
+			return true, nil
+		case "INVALID_CONFIGURATION", "PROVISIONING_FAILED":
+			return false, errors.Errorf("Blue-Green deployment failed with status: %s (%s)", bgInfo.Status, bgInfo.StatusDetails)
 		}
+		return false, nil
+	})
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		return errors.Wrapf(err, "Blue-Green deployment %s", deploymentID)
 	}
+	return err
 }
 
 // handleSwitchoverBlueGreen performs the Blue-Green switchover.
@@ -1211,16 +2245,17 @@ func (e *Engine) handleSwitchoverBlueGreen(ctx context.Context, op *types.Operat
 		params.SwitchoverTimeout = 300 // 5 minutes
 	}
 
-	// Get deployment identifier from previous step
-	deploymentID := e.findBlueGreenDeploymentID(op)
-	if deploymentID == "" {
-		return errors.Wrap(internalerrors.ErrInvalidParameter, "blue-green deployment identifier not found")
+	deploymentID, err := e.resolveBlueGreenDeployment(ctx, op, rdsClient)
+	if err != nil {
+		return err
 	}
 
 	// Check if switchover has already completed (idempotency check)
 	// This handles cases where the step is retried after switchover already succeeded
 	bgInfo, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
 	if err == nil {
+		e.recordBlueGreen(ctx, op, bgInfo)
+
 		// Check switchover_details first - AWS may keep top-level status as AVAILABLE
 		// but set switchover failure/success in the details
 		for _, detail := range bgInfo.SwitchoverDetails {
@@ -1258,26 +2293,43 @@ func (e *Engine) handleSwitchoverBlueGreen(ctx context.Context, op *types.Operat
 waitForCompletion:
 
 	// Wait for switchover to complete
-	step.WaitCondition = "waiting for switchover to complete"
-	step.State = types.StepStateWaiting
+	e.markWaiting(step, "waiting for switchover to complete")
 
-	timeout := time.After(e.getWaitTimeout(op))
-	ticker := time.NewTicker(e.defaultPollInterval)
+	timeout := time.After(e.getWaitTimeout(op, step))
+	ticker := time.NewTicker(e.getPollInterval(step))
 	defer ticker.Stop()
 
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
+
+	pollCount := 0
+	defer func() { e.metrics.WaitPolls(step.Action, pollCount) }()
+	var progress switchoverProgress
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-timeout:
-			return errors.Wrapf(internalerrors.ErrWaitTimeout, "switchover for deployment %s", deploymentID)
+			if progress.total == 0 {
+				return errors.Wrapf(internalerrors.ErrWaitTimeout, "switchover for deployment %s", deploymentID)
+			}
+			return errors.Wrapf(internalerrors.ErrWaitTimeout, "switchover for deployment %s: %s", deploymentID, progress.stalled())
 		case <-ticker.C:
+			pollCount++
+			step.Polls++
 			bgInfo, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
 			if err != nil {
 				continue
 			}
+			e.metrics.BlueGreenStatus(deploymentID, bgInfo.Status)
+			e.recordBlueGreen(ctx, op, bgInfo)
 
+			progress = newSwitchoverProgress(bgInfo.SwitchoverDetails)
 			step.WaitCondition = fmt.Sprintf("switchover status: %s", bgInfo.Status)
+			if progress.total > 0 {
+				step.WaitCondition += ", " + progress.String()
+			}
 
 			// Check switchover_details for failure status
 			// AWS may keep top-level status as AVAILABLE but set switchover failure in details
@@ -1294,6 +2346,7 @@ waitForCompletion:
 					"deployment_identifier": bgInfo.Identifier,
 					"status":                bgInfo.Status,
 					"switchover_details":    bgInfo.SwitchoverDetails,
+					"member_status_counts":  progress.counts,
 				})
 				step.Result = result
 				return nil
@@ -1304,6 +2357,65 @@ waitForCompletion:
 	}
 }
 
+// switchoverProgress tallies the per-member statuses of a Blue-Green
+// switchover. AWS switches members over individually, so a member whose
+// status stops advancing is what holds a long switchover up.
+type switchoverProgress struct {
+	total    int
+	switched int
+	counts   map[string]int
+	// pending maps each member not yet switched over to its status.
+	pending map[string]string
+}
+
+func newSwitchoverProgress(details []rds.BlueGreenSwitchoverDetail) switchoverProgress {
+	p := switchoverProgress{
+		total:   len(details),
+		counts:  map[string]int{},
+		pending: map[string]string{},
+	}
+	for _, detail := range details {
+		p.counts[detail.Status]++
+		if detail.Status == "SWITCHOVER_COMPLETED" {
+			p.switched++
+		} else {
+			p.pending[detail.SourceMember[strings.LastIndex(detail.SourceMember, ":")+1:]] = detail.Status
+		}
+	}
+	return p
+}
+
+// String summarizes the progress, e.g. "3/4 members switched over
+// (1 SWITCHING_OVER)".
+func (p switchoverProgress) String() string {
+	msg := fmt.Sprintf("%d/%d members switched over", p.switched, p.total)
+	var others []string
+	for status, count := range p.counts {
+		if status != "SWITCHOVER_COMPLETED" {
+			others = append(others, fmt.Sprintf("%d %s", count, status))
+		}
+	}
+	if len(others) > 0 {
+		slices.Sort(others)
+		msg += " (" + strings.Join(others, ", ") + ")"
+	}
+	return msg
+}
+
+// stalled names the members that had not switched over when the wait ended.
+func (p switchoverProgress) stalled() string {
+	if len(p.pending) == 0 {
+		return p.String()
+	}
+	members := make([]string, 0, len(p.pending))
+	for member, status := range p.pending {
+		members = append(members, fmt.Sprintf("%s (%s)", member, status))
+	}
+	slices.Sort(members)
+	return fmt.Sprintf("%d/%d members switched over, still waiting on %s",
+		p.switched, p.total, strings.Join(members, ", "))
+}
+
 // handleCleanupBlueGreen cleans up the Blue-Green deployment and old cluster resources.
 func (e *Engine) handleCleanupBlueGreen(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -1311,46 +2423,71 @@ func (e *Engine) handleCleanupBlueGreen(ctx context.Context, op *types.Operation
 		return err
 	}
 
-	// Get deployment identifier
+	// A deployment that no longer describes is expected here, since cleanup
+	// may already have deleted it; only a missing identifier is re-discovered
 	deploymentID := e.findBlueGreenDeploymentID(op)
 	if deploymentID == "" {
-		return errors.Wrap(internalerrors.ErrInvalidParameter, "blue-green deployment identifier not found")
+		if deploymentID, err = e.resolveBlueGreenDeployment(ctx, op, rdsClient); err != nil {
+			return err
+		}
 	}
 
-	// Get the switchover details from the switchover step result (most reliable source)
-	oldInstances, oldClusterID := e.findSwitchoverDetails(op)
+	// The mapping recorded after the switchover names the retired blue
+	// resources exactly; without one, describe the deployment to read it
+	e.mu.RLock()
+	recorded := op.BlueGreen
+	e.mu.RUnlock()
 
 	// Track if deployment still exists (needed for cleanup decisions)
 	deploymentExists := true
-
-	// If we couldn't get switchover details from the step, try describing the deployment
-	if len(oldInstances) == 0 || oldClusterID == "" {
+	if recorded == nil || !recorded.SwitchedOver {
 		bgInfo, err := rdsClient.DescribeBlueGreenDeployment(ctx, deploymentID)
+		switch {
+		case errors.Is(err, internalerrors.ErrBlueGreenDeploymentNotFound):
+			deploymentExists = false
+		case err != nil:
+			return errors.Wrap(err, "describe blue-green deployment for cleanup")
+		default:
+			recorded = e.recordBlueGreen(ctx, op, bgInfo)
+		}
+	}
+
+	var oldInstances []string
+	var oldClusterID string
+	if recorded != nil && recorded.SwitchedOver {
+		oldClusterID, oldInstances = retiredBlueGreenResources(recorded)
+	} else {
+		// Nothing was recorded after the switchover, e.g. for an operation
+		// started by an earlier version: fall back to the switchover step
+		// result, or to the original cluster ID, and the -old1 names AWS
+		// gives the blue resources
+		oldInstances, oldClusterID = e.findSwitchoverDetails(op)
+		if oldClusterID == "" {
+			e.addEvent(op.ID, "warning", "Blue-Green switchover details not found in operation state", nil)
+			oldClusterID = op.ClusterID
+		}
+		oldClusterID = withOldSuffix(oldClusterID)
+		for i, id := range oldInstances {
+			oldInstances[i] = withOldSuffix(id)
+		}
+	}
+
+	// Never delete a cluster without confirming it really is the retired
+	// blue cluster
+	if oldClusterID != "" {
+		exists, problem, err := e.verifyOldBlueGreenCluster(ctx, rdsClient, op, oldClusterID)
 		if err != nil {
-			if errors.Is(err, internalerrors.ErrBlueGreenDeploymentNotFound) {
-				deploymentExists = false
-				// Deployment is gone but we don't have switchover details - we can't identify old resources
-				// Try to infer from the original cluster ID (old resources get -old1 suffix)
-				e.addEvent(op.ID, "warning", "Blue-Green deployment already deleted but switchover details not found in operation state", nil)
-				oldClusterID = op.ClusterID // The original cluster becomes the "old" one after switchover
-			} else {
-				return errors.Wrap(err, "describe blue-green deployment for cleanup")
-			}
-		} else {
-			// Extract switchover details from the deployment info
-			for _, detail := range bgInfo.SwitchoverDetails {
-				if strings.Contains(detail.SourceMember, ":db:") {
-					parts := strings.Split(detail.SourceMember, ":db:")
-					if len(parts) == 2 {
-						oldInstances = append(oldInstances, parts[1])
-					}
-				} else if strings.Contains(detail.SourceMember, ":cluster:") {
-					parts := strings.Split(detail.SourceMember, ":cluster:")
-					if len(parts) == 2 {
-						oldClusterID = parts[1]
-					}
-				}
-			}
+			return errors.Wrap(err, "verify old cluster")
+		}
+		if problem != "" {
+			e.addEvent(op.ID, "warning", fmt.Sprintf("Old cluster %s could not be verified: %s", oldClusterID, problem), nil)
+			op.State = types.StatePaused
+			op.PauseReason = fmt.Sprintf("Cleanup stopped: could not confirm that %s is the old Blue-Green cluster (%s). Nothing has been deleted. Delete the old resources manually and select 'mark_complete', select 'retry_cleanup' once resolved, or 'abort' to stop.", oldClusterID, problem)
+			return errors.Wrapf(internalerrors.ErrInterventionRequired, "old cluster %s not verified: %s", oldClusterID, problem)
+		}
+		if !exists {
+			e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s does not exist, nothing to delete", oldClusterID), nil)
+			oldClusterID = ""
 		}
 	}
 
@@ -1365,7 +2502,7 @@ func (e *Engine) handleCleanupBlueGreen(ctx context.Context, op *types.Operation
 				// If cleanup fails, we should pause and let user decide
 				e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to delete Blue-Green deployment: %v", err), nil)
 				op.State = types.StatePaused
-				op.PauseReason = fmt.Sprintf("Cleanup failed: could not delete Blue-Green deployment %s: %v. The upgrade was successful but old resources may still exist. Select 'mark_complete' to complete the operation anyway, or 'abort' to stop.", deploymentID, err)
+				op.PauseReason = fmt.Sprintf("Cleanup failed: could not delete Blue-Green deployment %s: %v. The upgrade was successful but old resources may still exist. Select 'retry_cleanup' to try the cleanup again, 'mark_complete' to complete the operation anyway, or 'abort' to stop.", deploymentID, err)
 				return errors.Wrap(internalerrors.ErrInterventionRequired, "cleanup failed")
 			}
 			e.addEvent(op.ID, "info", "Blue-Green deployment record already deleted", nil)
@@ -1376,20 +2513,14 @@ func (e *Engine) handleCleanupBlueGreen(ctx context.Context, op *types.Operation
 		e.addEvent(op.ID, "info", "Blue-Green deployment record already deleted", nil)
 	}
 
-	// Step 2: Delete old instances (they have -old1 suffix after switchover)
+	// Step 2: Delete old instances
 	var deletedInstances []string
 	var failedDeletes []string
 
-	for _, instID := range oldInstances {
-		// The old instances are renamed with -old1 suffix after switchover
-		// Check if already has the suffix (in case we got it from post-switchover data)
-		oldInstID := instID
-		if !strings.HasSuffix(instID, "-old1") {
-			oldInstID = instID + "-old1"
-		}
+	for _, oldInstID := range oldInstances {
 		e.addEvent(op.ID, "info", fmt.Sprintf("Deleting old instance: %s", oldInstID), nil)
 
-		if err := rdsClient.DeleteInstance(ctx, oldInstID, true); err != nil {
+		if err := rdsClient.DeleteInstance(ctx, oldInstID, ""); err != nil {
 			errStr := err.Error()
 			// Treat "not found" or "already being deleted" as success
 			if strings.Contains(errStr, "NotFound") || strings.Contains(errStr, "not found") {
@@ -1407,87 +2538,153 @@ func (e *Engine) handleCleanupBlueGreen(ctx context.Context, op *types.Operation
 		}
 	}
 
-	// Step 3: Delete old cluster (it has -old1 suffix after switchover)
+	// Step 3: Delete old cluster
 	if oldClusterID != "" {
-		// Check if already has the suffix (in case we got it from post-switchover data)
-		oldClusterIDRenamed := oldClusterID
-		if !strings.HasSuffix(oldClusterID, "-old1") {
-			oldClusterIDRenamed = oldClusterID + "-old1"
-		}
-		e.addEvent(op.ID, "info", fmt.Sprintf("Deleting old cluster: %s", oldClusterIDRenamed), nil)
+		e.addEvent(op.ID, "info", fmt.Sprintf("Deleting old cluster: %s", oldClusterID), nil)
 
 		// Wait a bit for instances to start deleting before trying to delete cluster
 		time.Sleep(2 * time.Second)
 
-		if err := rdsClient.DeleteCluster(ctx, oldClusterIDRenamed, true); err != nil {
+		if err := rdsClient.DeleteCluster(ctx, oldClusterID, true); err != nil {
 			errStr := err.Error()
 			// Treat "not found" as success - resource is already gone
 			if strings.Contains(errStr, "NotFound") || strings.Contains(errStr, "not found") {
-				e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s already deleted or does not exist", oldClusterIDRenamed), nil)
+				e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s already deleted or does not exist", oldClusterID), nil)
 			} else if strings.Contains(errStr, "deletion protection") || strings.Contains(errStr, "Cannot delete protected") {
 				// Disable deletion protection and retry
-				e.addEvent(op.ID, "info", fmt.Sprintf("Disabling deletion protection on old cluster %s", oldClusterIDRenamed), nil)
+				e.addEvent(op.ID, "info", fmt.Sprintf("Disabling deletion protection on old cluster %s", oldClusterID), nil)
 				falseVal := false
 				if modErr := rdsClient.ModifyCluster(ctx, rds.ModifyClusterParams{
-					ClusterID:          oldClusterIDRenamed,
+					ClusterID:          oldClusterID,
 					ApplyImmediately:   true,
 					DeletionProtection: &falseVal,
 				}); modErr != nil {
-					e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to disable deletion protection on %s: %v", oldClusterIDRenamed, modErr), nil)
-					failedDeletes = append(failedDeletes, oldClusterIDRenamed)
+					e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to disable deletion protection on %s: %v", oldClusterID, modErr), nil)
+					failedDeletes = append(failedDeletes, oldClusterID)
 				} else {
 					// Retry delete after disabling protection
 					time.Sleep(2 * time.Second)
-					if retryErr := rdsClient.DeleteCluster(ctx, oldClusterIDRenamed, true); retryErr != nil {
+					if retryErr := rdsClient.DeleteCluster(ctx, oldClusterID, true); retryErr != nil {
 						if !strings.Contains(retryErr.Error(), "NotFound") && !strings.Contains(retryErr.Error(), "not found") {
-							e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to delete old cluster %s after disabling protection: %v", oldClusterIDRenamed, retryErr), nil)
-							failedDeletes = append(failedDeletes, oldClusterIDRenamed)
+							e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to delete old cluster %s after disabling protection: %v", oldClusterID, retryErr), nil)
+							failedDeletes = append(failedDeletes, oldClusterID)
 						} else {
-							e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s already deleted", oldClusterIDRenamed), nil)
+							e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s already deleted", oldClusterID), nil)
 						}
 					} else {
-						e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s deletion initiated", oldClusterIDRenamed), nil)
+						e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s deletion initiated", oldClusterID), nil)
 					}
 				}
 			} else {
-				e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to delete old cluster %s: %v", oldClusterIDRenamed, err), nil)
-				failedDeletes = append(failedDeletes, oldClusterIDRenamed)
+				e.addEvent(op.ID, "warning", fmt.Sprintf("Failed to delete old cluster %s: %v", oldClusterID, err), nil)
+				failedDeletes = append(failedDeletes, oldClusterID)
 			}
 		} else {
-			e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s deletion initiated", oldClusterIDRenamed), nil)
+			e.addEvent(op.ID, "info", fmt.Sprintf("Old cluster %s deletion initiated", oldClusterID), nil)
 		}
 	}
 
 	// If any deletes failed (excluding "not found" errors), pause for intervention
 	if len(failedDeletes) > 0 {
 		op.State = types.StatePaused
-		op.PauseReason = fmt.Sprintf("Cleanup partially failed: could not delete %v. The upgrade was successful but these resources may still exist and incur charges. Select 'mark_complete' to complete the operation anyway, or 'abort' to stop.", failedDeletes)
+		op.PauseReason = fmt.Sprintf("Cleanup partially failed: could not delete %v. The upgrade was successful but these resources may still exist and incur charges. Select 'retry_cleanup' to try the cleanup again, 'mark_complete' to complete the operation anyway, or 'abort' to stop.", failedDeletes)
 		return errors.Wrap(internalerrors.ErrInterventionRequired, "cleanup partially failed")
 	}
 
-	result, _ := json.Marshal(map[string]any{
-		"deleted_deployment": deploymentID,
-		"deleted_instances":  deletedInstances,
-		"old_cluster":        oldClusterID,
-	})
-	step.Result = result
+	result, _ := json.Marshal(map[string]any{
+		"deleted_deployment": deploymentID,
+		"deleted_instances":  deletedInstances,
+		"old_cluster":        oldClusterID,
+	})
+	step.Result = result
+
+	e.addEvent(op.ID, "info", "Blue-Green cleanup completed successfully", nil)
+	return nil
+}
+
+// verifyOldBlueGreenCluster checks that candidate is the blue cluster a
+// switchover retired: it must not be the live cluster, the live cluster must
+// be on the target engine version, and the candidate must not be. When the
+// operation recorded the cluster's original version the candidate must still
+// be on it. It reports whether the candidate exists and, if it cannot be
+// confirmed, why.
+func (e *Engine) verifyOldBlueGreenCluster(ctx context.Context, rdsClient *rds.Client, op *types.Operation, candidate string) (bool, string, error) {
+	if candidate == op.ClusterID {
+		return true, "it is the live cluster", nil
+	}
+
+	var params types.EngineUpgradeParams
+	if len(op.Parameters) > 0 {
+		if err := json.Unmarshal(op.Parameters, &params); err != nil {
+			return false, "", errors.Wrap(err, "unmarshal params")
+		}
+	}
+	if params.TargetEngineVersion == "" {
+		return false, "the operation has no target engine version to check against", nil
+	}
+
+	old, err := rdsClient.GetClusterInfo(ctx, candidate)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return false, "", nil
+		}
+		return false, "", errors.Wrapf(err, "get cluster info for %s", candidate)
+	}
+
+	live, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		if errors.Is(err, internalerrors.ErrClusterNotFound) {
+			return true, fmt.Sprintf("live cluster %s was not found", op.ClusterID), nil
+		}
+		return false, "", errors.Wrapf(err, "get cluster info for %s", op.ClusterID)
+	}
+
+	switch {
+	case live.EngineVersion != params.TargetEngineVersion:
+		return true, fmt.Sprintf("live cluster %s is on %s, not the target %s, so the switchover may not have happened",
+			op.ClusterID, live.EngineVersion, params.TargetEngineVersion), nil
+	case old.EngineVersion == params.TargetEngineVersion:
+		return true, fmt.Sprintf("%s is already on the target version %s and may be serving traffic",
+			candidate, params.TargetEngineVersion), nil
+	}
 
-	e.addEvent(op.ID, "info", "Blue-Green cleanup completed successfully", nil)
-	return nil
+	if original := originalEngineVersion(op); original != "" && old.EngineVersion != original {
+		return true, fmt.Sprintf("%s is on %s, but the cluster was on %s before the upgrade",
+			candidate, old.EngineVersion, original), nil
+	}
+	return true, "", nil
+}
+
+// originalEngineVersion returns the engine version recorded by the
+// operation's get_cluster_info step, or "" if there is none.
+func originalEngineVersion(op *types.Operation) string {
+	for _, step := range op.Steps {
+		if step.Action == "get_cluster_info" && step.State == types.StepStateCompleted && len(step.Result) > 0 {
+			var info types.ClusterInfo
+			if err := json.Unmarshal(step.Result, &info); err == nil {
+				return info.EngineVersion
+			}
+		}
+	}
+	return ""
 }
 
-// findBlueGreenDeploymentID finds the Blue-Green deployment identifier from previous steps.
+// findBlueGreenDeploymentID finds the Blue-Green deployment identifier from
+// previous steps, falling back to the operation's recorded deployment.
 func (e *Engine) findBlueGreenDeploymentID(op *types.Operation) string {
 	for _, step := range op.Steps {
 		if step.Action == "create_blue_green_deployment" && step.State == types.StepStateCompleted {
 			var result struct {
 				DeploymentIdentifier string `json:"deployment_identifier"`
 			}
-			if err := json.Unmarshal(step.Result, &result); err == nil {
+			if err := json.Unmarshal(step.Result, &result); err == nil && result.DeploymentIdentifier != "" {
 				return result.DeploymentIdentifier
 			}
 		}
 	}
+	if op.BlueGreen != nil {
+		return op.BlueGreen.DeploymentIdentifier
+	}
 	return ""
 }
 
@@ -1511,6 +2708,14 @@ func (e *Engine) findPreparedParameterGroupNames(op *types.Operation) (clusterPG
 	return "", ""
 }
 
+// withOldSuffix returns the name AWS gives a blue resource on switchover.
+func withOldSuffix(id string) string {
+	if strings.HasSuffix(id, "-old1") {
+		return id
+	}
+	return id + "-old1"
+}
+
 // findSwitchoverDetails extracts old instance IDs and cluster ID from the switchover step result.
 func (e *Engine) findSwitchoverDetails(op *types.Operation) (oldInstances []string, oldClusterID string) {
 	for _, step := range op.Steps {
@@ -1540,6 +2745,26 @@ func (e *Engine) findSwitchoverDetails(op *types.Operation) (oldInstances []stri
 	return oldInstances, oldClusterID
 }
 
+// rebootSkipped reports whether the most recent reboot_instance step for the
+// instance before the current step was skipped.
+func rebootSkipped(op *types.Operation, instanceID string) bool {
+	for i := op.CurrentStepIndex - 1; i >= 0; i-- {
+		prevStep := &op.Steps[i]
+		if prevStep.Action != "reboot_instance" || len(prevStep.Result) == 0 {
+			continue
+		}
+		var result struct {
+			InstanceID string `json:"instance_id"`
+			Status     string `json:"status"`
+		}
+		if err := json.Unmarshal(prevStep.Result, &result); err != nil || result.InstanceID != instanceID {
+			continue
+		}
+		return result.Status == "skipped"
+	}
+	return false
+}
+
 // handleRebootInstance reboots an RDS instance.
 func (e *Engine) handleRebootInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
 	rdsClient, err := e.getRDSClient(ctx, op)
@@ -1549,6 +2774,12 @@ func (e *Engine) handleRebootInstance(ctx context.Context, op *types.Operation,
 
 	var params struct {
 		InstanceID string `json:"instance_id"`
+		// SkipUnavailable completes the step without rebooting when the
+		// instance is not available, instead of letting the reboot fail.
+		SkipUnavailable bool `json:"skip_unavailable,omitempty"`
+		// OnlyIfPendingReboot completes the step without rebooting when the
+		// preceding apply_parameter_group step changed no static parameters.
+		OnlyIfPendingReboot bool `json:"only_if_pending_reboot,omitempty"`
 	}
 	if err := json.Unmarshal(step.Parameters, &params); err != nil {
 		return errors.Wrap(err, "unmarshal params")
@@ -1558,8 +2789,39 @@ func (e *Engine) handleRebootInstance(ctx context.Context, op *types.Operation,
 		return errors.New("instance_id is required")
 	}
 
-	e.logger.Info("rebooting instance",
-		"operation_id", op.ID,
+	if params.OnlyIfPendingReboot && !parameterGroupRebootRequired(op) {
+		msg := fmt.Sprintf("Skipped reboot of %s: no static parameters pending reboot", params.InstanceID)
+		e.addEvent(op.ID, "instance_skipped", msg, nil)
+		step.Result, _ = json.Marshal(map[string]string{
+			"instance_id": params.InstanceID,
+			"status":      "skipped",
+			"message":     msg,
+		})
+		return nil
+	}
+
+	if params.SkipUnavailable {
+		info, err := rdsClient.GetInstanceInfo(ctx, params.InstanceID)
+		if err != nil {
+			return errors.Wrap(err, "get instance info")
+		}
+		if !rds.InstanceStatus(info.Status).IsAvailable() {
+			msg := fmt.Sprintf("Skipped reboot of %s: instance is %s", params.InstanceID, info.Status)
+			e.addEvent(op.ID, "instance_skipped", msg, nil)
+			step.Result, _ = json.Marshal(map[string]string{
+				"instance_id": params.InstanceID,
+				"status":      "skipped",
+				"message":     msg,
+			})
+			return nil
+		}
+	}
+
+	if err := e.awaitReaderFloor(ctx, op, step, rdsClient, params.InstanceID); err != nil {
+		return err
+	}
+
+	e.stepLogger(ctx).Info("rebooting instance",
 		"instance_id", params.InstanceID)
 
 	err = rdsClient.RebootInstance(ctx, params.InstanceID)
@@ -1572,6 +2834,109 @@ func (e *Engine) handleRebootInstance(ctx context.Context, op *types.Operation,
 	return nil
 }
 
+// ==================== CA Certificate Handlers ====================
+
+// handleRotateCACert requests a CA certificate change on an instance. The new
+// certificate only takes effect after a reboot, so the step completes once RDS
+// reports the target as pending (or already applied); the following
+// wait_instance_available step verifies the applied certificate.
+func (e *Engine) handleRotateCACert(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		InstanceID              string `json:"instance_id"`
+		CACertificateIdentifier string `json:"ca_certificate_identifier"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	if params.InstanceID == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "instance_id required")
+	}
+	if params.CACertificateIdentifier == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "ca_certificate_identifier required")
+	}
+
+	instanceInfo, err := rdsClient.GetInstanceInfo(ctx, params.InstanceID)
+	if err != nil {
+		return errors.Wrapf(err, "get instance info for %s", params.InstanceID)
+	}
+
+	previous := instanceInfo.CACertificateIdentifier
+	if previous == params.CACertificateIdentifier {
+		e.stepLogger(ctx).Info("instance already uses target CA certificate",
+			"instance_id", params.InstanceID,
+			"ca_certificate", previous)
+		result, _ := json.Marshal(map[string]any{
+			"instance_id":          params.InstanceID,
+			"ca_certificate":       previous,
+			"previous_certificate": previous,
+			"already_applied":      true,
+		})
+		step.Result = result
+		return nil
+	}
+
+	e.stepLogger(ctx).Info("rotating instance CA certificate",
+		"instance_id", params.InstanceID,
+		"current_ca_certificate", previous,
+		"target_ca_certificate", params.CACertificateIdentifier)
+
+	if instanceInfo.PendingCACertificateIdentifier != params.CACertificateIdentifier {
+		if err := rdsClient.ModifyInstanceCACert(ctx, params.InstanceID, params.CACertificateIdentifier); err != nil {
+			return errors.Wrapf(err, "rotate CA certificate for %s", params.InstanceID)
+		}
+	}
+
+	e.markWaiting(step, "waiting for CA certificate change to be accepted")
+
+	timeout := time.After(e.getWaitTimeout(op, step))
+	ticker := time.NewTicker(e.getPollInterval(step))
+	defer ticker.Stop()
+
+	if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return errors.Wrapf(internalerrors.ErrWaitTimeout,
+				"instance %s did not accept CA certificate %s", params.InstanceID, params.CACertificateIdentifier)
+		case <-ticker.C:
+			step.Polls++
+			info, err := rdsClient.GetInstanceInfo(ctx, params.InstanceID)
+			if err != nil {
+				e.stepLogger(ctx).Warn("error getting instance info",
+					"instance_id", params.InstanceID,
+					"error", err)
+				continue
+			}
+
+			applied := info.CACertificateIdentifier == params.CACertificateIdentifier
+			if !applied && info.PendingCACertificateIdentifier != params.CACertificateIdentifier {
+				step.WaitCondition = fmt.Sprintf("CA certificate is %s, waiting for %s to be pending", info.CACertificateIdentifier, params.CACertificateIdentifier)
+				continue
+			}
+
+			result, _ := json.Marshal(map[string]any{
+				"instance_id":          params.InstanceID,
+				"ca_certificate":       params.CACertificateIdentifier,
+				"previous_certificate": previous,
+				"pending_reboot":       !applied,
+			})
+			step.Result = result
+			return nil
+		}
+	}
+}
+
 // ==================== RDS Proxy Handlers ====================
 
 // handleValidateProxyHealth discovers and validates RDS Proxies targeting this cluster.
@@ -1584,8 +2949,16 @@ func (e *Engine) handleValidateProxyHealth(ctx context.Context, op *types.Operat
 		return err
 	}
 
-	e.logger.Info("discovering RDS Proxies for cluster",
-		"operation_id", op.ID,
+	var params struct {
+		ContinueOnUnhealthy bool `json:"continue_on_unhealthy"`
+	}
+	if len(step.Parameters) > 0 {
+		if err := json.Unmarshal(step.Parameters, &params); err != nil {
+			return errors.Wrap(err, "unmarshal params")
+		}
+	}
+
+	e.stepLogger(ctx).Info("discovering RDS Proxies for cluster",
 		"cluster_id", op.ClusterID)
 
 	// Discover proxies pointing at this cluster
@@ -1595,8 +2968,7 @@ func (e *Engine) handleValidateProxyHealth(ctx context.Context, op *types.Operat
 	}
 
 	if len(proxies) == 0 {
-		e.logger.Info("no RDS Proxies found targeting this cluster",
-			"operation_id", op.ID,
+		e.stepLogger(ctx).Info("no RDS Proxies found targeting this cluster",
 			"cluster_id", op.ClusterID)
 		e.addEvent(op.ID, "info", "No RDS Proxies found targeting this cluster", nil)
 
@@ -1609,31 +2981,39 @@ func (e *Engine) handleValidateProxyHealth(ctx context.Context, op *types.Operat
 		return nil
 	}
 
-	e.logger.Info("found RDS Proxies targeting cluster",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("found RDS Proxies targeting cluster",
 		"cluster_id", op.ClusterID,
 		"proxy_count", len(proxies))
 
-	// Validate health of each proxy
-	var healthyProxies []rds.ProxyWithTargets
+	// Validate health of each proxy. When the operation opted to continue on
+	// warnings, unhealthy proxies are still recorded so the later deregister and
+	// register steps handle them; Blue-Green creation fails while any proxy
+	// still targets the cluster.
+	var discovered []rds.ProxyWithTargets
+	var unhealthy []string
 	for _, proxy := range proxies {
 		if err := rdsClient.ValidateProxyHealth(ctx, proxy); err != nil {
-			e.addEvent(op.ID, "error", fmt.Sprintf("RDS Proxy %s is unhealthy: %v", proxy.Proxy.ProxyName, err), nil)
-			return errors.Wrapf(err, "proxy %s health validation failed", proxy.Proxy.ProxyName)
+			if !params.ContinueOnUnhealthy {
+				e.addEvent(op.ID, "error", fmt.Sprintf("RDS Proxy %s is unhealthy: %v", proxy.Proxy.ProxyName, err), nil)
+				return errors.Wrapf(err, "proxy %s health validation failed", proxy.Proxy.ProxyName)
+			}
+			e.addEvent(op.ID, "warning", fmt.Sprintf("RDS Proxy %s is unhealthy, continuing as configured: %v", proxy.Proxy.ProxyName, err), nil)
+			unhealthy = append(unhealthy, proxy.Proxy.ProxyName)
+		} else {
+			e.stepLogger(ctx).Info("RDS Proxy is healthy",
+				"proxy_name", proxy.Proxy.ProxyName,
+				"status", proxy.Proxy.Status)
 		}
-		healthyProxies = append(healthyProxies, proxy)
-		e.logger.Info("RDS Proxy is healthy",
-			"operation_id", op.ID,
-			"proxy_name", proxy.Proxy.ProxyName,
-			"status", proxy.Proxy.Status)
+		discovered = append(discovered, proxy)
 	}
 
-	e.addEvent(op.ID, "info", fmt.Sprintf("Validated %d RDS Proxy(ies) as healthy", len(healthyProxies)), nil)
+	e.addEvent(op.ID, "info", fmt.Sprintf("Validated %d RDS Proxy(ies) as healthy", len(discovered)-len(unhealthy)), nil)
 
 	// Store proxy info for use by retarget step
 	result, _ := json.Marshal(map[string]any{
-		"proxies_found": len(healthyProxies),
-		"proxies":       healthyProxies,
+		"proxies_found":     len(discovered),
+		"proxies":           discovered,
+		"unhealthy_proxies": unhealthy,
 	})
 	step.Result = result
 	return nil
@@ -1653,8 +3033,7 @@ func (e *Engine) handleRetargetProxies(ctx context.Context, op *types.Operation,
 	proxies := e.findDiscoveredProxies(op)
 
 	if len(proxies) == 0 {
-		e.logger.Info("no proxies to retarget",
-			"operation_id", op.ID)
+		e.stepLogger(ctx).Info("no proxies to retarget")
 		e.addEvent(op.ID, "info", "No RDS Proxies to retarget", nil)
 
 		result, _ := json.Marshal(map[string]any{
@@ -1675,8 +3054,7 @@ func (e *Engine) handleRetargetProxies(ctx context.Context, op *types.Operation,
 		for _, tg := range proxy.TargetGroups {
 			targets, err := rdsClient.GetProxyTargets(ctx, proxy.Proxy.ProxyName, tg.TargetGroupName)
 			if err != nil {
-				e.logger.Info("failed to get proxy targets for idempotency check",
-					"operation_id", op.ID,
+				e.stepLogger(ctx).Info("failed to get proxy targets for idempotency check",
 					"proxy_name", proxy.Proxy.ProxyName,
 					"error", err)
 				allAlreadyAvailable = false
@@ -1733,16 +3111,14 @@ func (e *Engine) handleRetargetProxies(ctx context.Context, op *types.Operation,
 		return nil
 	}
 
-	e.logger.Info("retargeting RDS Proxies to new cluster",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("retargeting RDS Proxies to new cluster",
 		"new_cluster_id", newClusterID,
 		"proxy_count", len(proxies))
 
 	var retargetedProxies []string
 	for _, proxy := range proxies {
 		for _, tg := range proxy.TargetGroups {
-			e.logger.Info("retargeting proxy target group",
-				"operation_id", op.ID,
+			e.stepLogger(ctx).Info("retargeting proxy target group",
 				"proxy_name", proxy.Proxy.ProxyName,
 				"target_group", tg.TargetGroupName,
 				"new_cluster_id", newClusterID)
@@ -1764,10 +3140,9 @@ func (e *Engine) handleRetargetProxies(ctx context.Context, op *types.Operation,
 	}
 
 	// Wait for all proxy targets to become available
-	e.logger.Info("waiting for proxy targets to become available",
-		"operation_id", op.ID)
-	step.WaitCondition = "waiting for proxy targets to become available"
-	step.State = types.StepStateWaiting
+	e.stepLogger(ctx).Info("waiting for proxy targets to become available")
+	e.markWaiting(step, "waiting for proxy targets to become available")
+	e.recordWaitStart(step)
 
 	for _, proxy := range proxies {
 		for _, tg := range proxy.TargetGroups {
@@ -1818,8 +3193,7 @@ func (e *Engine) handleDeregisterProxyTargets(ctx context.Context, op *types.Ope
 	proxies := e.findDiscoveredProxies(op)
 
 	if len(proxies) == 0 {
-		e.logger.Info("no proxies to deregister",
-			"operation_id", op.ID)
+		e.stepLogger(ctx).Info("no proxies to deregister")
 		e.addEvent(op.ID, "info", "No RDS Proxies to deregister", nil)
 
 		result, _ := json.Marshal(map[string]any{
@@ -1829,8 +3203,7 @@ func (e *Engine) handleDeregisterProxyTargets(ctx context.Context, op *types.Ope
 		return nil
 	}
 
-	e.logger.Info("deregistering cluster from RDS Proxies",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("deregistering cluster from RDS Proxies",
 		"cluster_id", op.ClusterID,
 		"proxy_count", len(proxies))
 
@@ -1839,8 +3212,7 @@ func (e *Engine) handleDeregisterProxyTargets(ctx context.Context, op *types.Ope
 	var deregisteredProxies []string
 	for _, proxy := range proxies {
 		for _, tg := range proxy.TargetGroups {
-			e.logger.Info("deregistering cluster from proxy target group",
-				"operation_id", op.ID,
+			e.stepLogger(ctx).Info("deregistering cluster from proxy target group",
 				"proxy_name", proxy.Proxy.ProxyName,
 				"target_group", tg.TargetGroupName,
 				"cluster_id", op.ClusterID)
@@ -1880,8 +3252,7 @@ func (e *Engine) handleRegisterProxyTargets(ctx context.Context, op *types.Opera
 	proxies := e.findDiscoveredProxies(op)
 
 	if len(proxies) == 0 {
-		e.logger.Info("no proxies to register",
-			"operation_id", op.ID)
+		e.stepLogger(ctx).Info("no proxies to register")
 		e.addEvent(op.ID, "info", "No RDS Proxies to register", nil)
 
 		result, _ := json.Marshal(map[string]any{
@@ -1955,16 +3326,14 @@ func (e *Engine) handleRegisterProxyTargets(ctx context.Context, op *types.Opera
 		return nil
 	}
 
-	e.logger.Info("registering cluster to RDS Proxies",
-		"operation_id", op.ID,
+	e.stepLogger(ctx).Info("registering cluster to RDS Proxies",
 		"cluster_id", clusterID,
 		"proxy_count", len(proxies))
 
 	var registeredProxies []string
 	for _, proxy := range proxies {
 		for _, tg := range proxy.TargetGroups {
-			e.logger.Info("registering cluster to proxy target group",
-				"operation_id", op.ID,
+			e.stepLogger(ctx).Info("registering cluster to proxy target group",
 				"proxy_name", proxy.Proxy.ProxyName,
 				"target_group", tg.TargetGroupName,
 				"cluster_id", clusterID)
@@ -1986,10 +3355,9 @@ func (e *Engine) handleRegisterProxyTargets(ctx context.Context, op *types.Opera
 	}
 
 	// Wait for all proxy targets to become available
-	e.logger.Info("waiting for proxy targets to become available",
-		"operation_id", op.ID)
-	step.WaitCondition = "waiting for proxy targets to become available"
-	step.State = types.StepStateWaiting
+	e.stepLogger(ctx).Info("waiting for proxy targets to become available")
+	e.markWaiting(step, "waiting for proxy targets to become available")
+	e.recordWaitStart(step)
 
 	for _, proxy := range proxies {
 		for _, tg := range proxy.TargetGroups {
@@ -2010,3 +3378,250 @@ func (e *Engine) handleRegisterProxyTargets(ctx context.Context, op *types.Opera
 	step.Result = result
 	return nil
 }
+
+// ==================== Pending Maintenance Handlers ====================
+
+// handleApplyPendingMaintenance applies one pending maintenance action
+// immediately. The following wait step tracks the resulting reboot or upgrade.
+func (e *Engine) handleApplyPendingMaintenance(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		ResourceARN string `json:"resource_arn"`
+		ResourceID  string `json:"resource_id"`
+		Action      string `json:"action"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+	if params.ResourceARN == "" || params.Action == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "resource_arn and action required")
+	}
+
+	e.stepLogger(ctx).Info("applying pending maintenance action",
+		"resource_id", params.ResourceID,
+		"action", params.Action)
+
+	if err := rdsClient.ApplyPendingMaintenanceAction(ctx, params.ResourceARN, params.Action); err != nil {
+		return err
+	}
+
+	step.Result, _ = json.Marshal(map[string]string{
+		"resource_id": params.ResourceID,
+		"action":      params.Action,
+	})
+	return nil
+}
+
+// handleRestoreSnapshot restores a snapshot into the temporary cluster of a
+// snapshot restore test. If the planned identifier is already taken, a
+// timestamp is appended to it, as pre-upgrade snapshot IDs are named, and the
+// restore is tried again.
+func (e *Engine) handleRestoreSnapshot(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		SnapshotID       string `json:"snapshot_id"`
+		RestoreClusterID string `json:"restore_cluster_id"`
+		Engine           string `json:"engine"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	if params.SnapshotID == "" {
+		params.SnapshotID = e.findCreatedSnapshotID(op)
+	}
+	if params.SnapshotID == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "snapshot_id required: no snapshot created by a previous step")
+	}
+	if params.RestoreClusterID == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "restore_cluster_id required")
+	}
+
+	restore := func(clusterID string) error {
+		return rdsClient.RestoreClusterFromSnapshot(ctx, rds.RestoreClusterParams{
+			SnapshotID:      params.SnapshotID,
+			ClusterID:       clusterID,
+			Engine:          params.Engine,
+			OperationID:     op.ID,
+			SourceClusterID: op.ClusterID,
+		})
+	}
+
+	clusterID := params.RestoreClusterID
+	err = restore(clusterID)
+	if errors.Is(err, internalerrors.ErrClusterAlreadyExists) {
+		clusterID = rds.TimestampedClusterID(params.RestoreClusterID, time.Now())
+		e.addEvent(op.ID, "warning",
+			fmt.Sprintf("Cluster %s already exists, restoring into %s instead", params.RestoreClusterID, clusterID), nil)
+		err = restore(clusterID)
+	}
+	if err != nil {
+		return err
+	}
+
+	e.addEvent(op.ID, "info", fmt.Sprintf("Restoring snapshot %s into cluster %s", params.SnapshotID, clusterID), nil)
+
+	step.Result, _ = json.Marshal(map[string]string{
+		"cluster_id":  clusterID,
+		"snapshot_id": params.SnapshotID,
+	})
+	return nil
+}
+
+// findRestoredClusterID finds the cluster a previous restore_snapshot step
+// restored into.
+func (e *Engine) findRestoredClusterID(op *types.Operation) string {
+	for _, step := range op.Steps {
+		if step.Action == "restore_snapshot" && step.State == types.StepStateCompleted {
+			var result struct {
+				ClusterID string `json:"cluster_id"`
+			}
+			if err := json.Unmarshal(step.Result, &result); err == nil {
+				return result.ClusterID
+			}
+		}
+	}
+	return ""
+}
+
+// handleCreateRestoreInstance creates an instance in the restored cluster so
+// the validation query has something to connect to.
+func (e *Engine) handleCreateRestoreInstance(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		InstanceType string `json:"instance_type"`
+		Engine       string `json:"engine"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	clusterID := e.findRestoredClusterID(op)
+	if clusterID == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "no restored cluster found from a previous step")
+	}
+
+	// Instance and cluster identifiers are separate namespaces, so the only
+	// instance of the restored cluster shares its name.
+	_, err = rdsClient.CreateClusterInstance(ctx, rds.CreateInstanceParams{
+		ClusterID:    clusterID,
+		InstanceID:   clusterID,
+		InstanceType: params.InstanceType,
+		Engine:       params.Engine,
+		OperationID:  op.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	step.Result, _ = json.Marshal(map[string]string{"instance_id": clusterID})
+	return nil
+}
+
+// handleValidateRestore runs the validation query against the restored
+// cluster through the configured restore validator.
+func (e *Engine) handleValidateRestore(ctx context.Context, op *types.Operation, step *types.Step) error {
+	var params struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	if e.restoreValidator == nil {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "no restore validator is configured")
+	}
+
+	clusterID := e.findRestoredClusterID(op)
+	if clusterID == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter, "no restored cluster found from a previous step")
+	}
+
+	if err := e.restoreValidator.ValidateRestore(ctx, op.Region, clusterID, params.Query); err != nil {
+		return errors.Wrapf(err, "validate restored cluster %s", clusterID)
+	}
+
+	e.addEvent(op.ID, "info", "Validation query succeeded against restored cluster "+clusterID, nil)
+	return nil
+}
+
+// handleDeleteRestoredCluster deletes the restored cluster. Its instances are
+// deleted and waited on first, since RDS refuses to delete a cluster that
+// still has instances. A cluster that is already gone is not an error.
+func (e *Engine) handleDeleteRestoredCluster(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	clusterID := e.findRestoredClusterID(op)
+	if clusterID == "" {
+		e.addEvent(op.ID, "info", "No restored cluster to delete", nil)
+		return nil
+	}
+
+	info, err := rdsClient.GetClusterInfo(ctx, clusterID)
+	if errors.Is(err, internalerrors.ErrClusterNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range info.Instances {
+		if rds.InstanceStatus(inst.Status).IsDeleting() {
+			continue
+		}
+		if err := rdsClient.DeleteInstance(ctx, inst.InstanceID, ""); err != nil {
+			return errors.Wrapf(err, "delete restored instance %s", inst.InstanceID)
+		}
+	}
+
+	if len(info.Instances) > 0 {
+		e.markWaiting(step, "waiting for restored instances to be deleted")
+
+		if err := e.awaitFirstPoll(ctx, op, step); err != nil {
+			return err
+		}
+		err := e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), func(ctx context.Context) (bool, error) {
+			for _, inst := range info.Instances {
+				deleted, err := rdsClient.IsInstanceDeleted(ctx, inst.InstanceID)
+				if err != nil {
+					return false, transient(err)
+				}
+				if !deleted {
+					step.WaitCondition = "waiting for instance " + inst.InstanceID + " to be deleted"
+					return false, nil
+				}
+			}
+			return true, nil
+		})
+		if err == internalerrors.ErrWaitTimeout {
+			return errors.Wrapf(err, "instances of restored cluster %s were not deleted", clusterID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if rds.ClusterStatus(info.Status) != rds.ClusterStatusDeleting {
+		if err := rdsClient.DeleteCluster(ctx, clusterID, true); err != nil {
+			return err
+		}
+	}
+
+	e.addEvent(op.ID, "info", "Deleting restored cluster "+clusterID, nil)
+	return nil
+}
@@ -3,13 +3,19 @@ package machine
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http/httptest"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
 	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
@@ -322,6 +328,1512 @@ func TestStepTimingPreservedAcrossRetries(t *testing.T) {
 		t.Errorf("Duration should reflect total time including retries, got only %v", elapsed)
 	}
 
+	if step.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", step.Attempts)
+	}
+
 	t.Logf("Step timing correctly preserved: StartedAt=%v, elapsed=%v (includes %d retry attempts)",
 		finalStartedAt, elapsed, failCount-1)
 }
+
+// lostModifyOperation returns an operation whose modify_instance step has
+// "completed" but whose modification never reached the instance, positioned
+// on the wait step that verifies it.
+func lostModifyOperation(instanceID, targetType string) *types.Operation {
+	modifyParams, _ := json.Marshal(map[string]string{
+		"instance_id":   instanceID,
+		"instance_type": targetType,
+	})
+	waitParams, _ := json.Marshal(map[string]string{
+		"instance_id": instanceID,
+	})
+	return &types.Operation{
+		ID:        "test-op-lost-modify",
+		Type:      types.OperationTypeInstanceTypeChange,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{
+				ID:         "step-1",
+				Name:       "Modify instance: " + instanceID,
+				Action:     "modify_instance",
+				State:      types.StepStateCompleted,
+				Parameters: modifyParams,
+			},
+			{
+				ID:         "step-2",
+				Name:       "Wait for instance: " + instanceID,
+				Action:     "wait_instance_available",
+				State:      types.StepStatePending,
+				Parameters: waitParams,
+			},
+		},
+		CurrentStepIndex: 1,
+		CreatedAt:        time.Now(),
+	}
+}
+
+// TestHandleWaitInstanceAvailable_ReissuesLostModify verifies that a modify
+// which never registered is re-issued once the verification budget is spent,
+// instead of waiting out the full timeout.
+func TestHandleWaitInstanceAvailable_ReissuesLostModify(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+	engine.modifyVerifyPolls = 10
+
+	op := lostModifyOperation("demo-multi-reader-1", "db.r6g.xlarge")
+	err := engine.handleWaitInstanceAvailable(context.Background(), op, &op.Steps[1])
+	if err != nil {
+		t.Fatalf("expected re-issued modify to be applied, got: %v", err)
+	}
+
+	reissued := false
+	for _, event := range engine.events[op.ID] {
+		if event.Type == "warning" && containsAny(event.Message, "re-issuing modify") {
+			reissued = true
+		}
+	}
+	if !reissued {
+		t.Error("expected a warning event recording the re-issued modify")
+	}
+}
+
+// TestHandleWaitInstanceAvailable_PausesWhenReissueDoesNotApply verifies that
+// the handler asks for intervention when even the re-issued modify is not
+// applied, rather than polling until the wait timeout.
+func TestHandleWaitInstanceAvailable_PausesWhenReissueDoesNotApply(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+	engine.modifyVerifyPolls = 10
+
+	// Freeze the instance so neither modify ever takes effect.
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-multi-reader-1",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+
+	op := lostModifyOperation("demo-multi-reader-1", "db.r6g.xlarge")
+	start := time.Now()
+	err := engine.handleWaitInstanceAvailable(context.Background(), op, &op.Steps[1])
+	if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+		t.Fatalf("expected ErrInterventionRequired, got: %v", err)
+	}
+	if !containsAny(err.Error(), "modification was not applied") {
+		t.Errorf("expected diagnosis in error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= engine.defaultWaitTimeout {
+		t.Errorf("expected pause before wait timeout, took %v", elapsed)
+	}
+}
+
+// TestHandleValidateProxyHealth_ContinueOnUnhealthy verifies that an unhealthy
+// proxy fails the step by default and is downgraded to a warning when the
+// operation opts in, while still being recorded for deregistration.
+func TestHandleValidateProxyHealth_ContinueOnUnhealthy(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	// Hold the proxy in "modifying" so it does not recover mid-test.
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-proxy",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+	if err := mockState.SetProxyStatus("demo-proxy", "modifying"); err != nil {
+		t.Fatalf("SetProxyStatus failed: %v", err)
+	}
+
+	newOp := func(continueOnUnhealthy bool) *types.Operation {
+		params, _ := json.Marshal(map[string]bool{"continue_on_unhealthy": continueOnUnhealthy})
+		return &types.Operation{
+			ID:        "test-op-proxy-health",
+			Type:      types.OperationTypeEngineUpgrade,
+			State:     types.StateRunning,
+			ClusterID: "demo-proxy-cluster",
+			Region:    "us-east-1",
+			Steps: []types.Step{{
+				ID:         "step-1",
+				Name:       "Validate proxy health",
+				Action:     "validate_proxy_health",
+				State:      types.StepStatePending,
+				Parameters: params,
+			}},
+			CreatedAt: time.Now(),
+		}
+	}
+
+	t.Run("hard-fail by default", func(t *testing.T) {
+		op := newOp(false)
+		if err := engine.handleValidateProxyHealth(context.Background(), op, &op.Steps[0]); err == nil {
+			t.Fatal("expected unhealthy proxy to fail the step")
+		}
+	})
+
+	t.Run("continue with warning when enabled", func(t *testing.T) {
+		op := newOp(true)
+		if err := engine.handleValidateProxyHealth(context.Background(), op, &op.Steps[0]); err != nil {
+			t.Fatalf("expected step to continue, got: %v", err)
+		}
+
+		var result struct {
+			ProxiesFound     int      `json:"proxies_found"`
+			UnhealthyProxies []string `json:"unhealthy_proxies"`
+		}
+		if err := json.Unmarshal(op.Steps[0].Result, &result); err != nil {
+			t.Fatalf("unmarshal result: %v", err)
+		}
+		if result.ProxiesFound != 1 || len(result.UnhealthyProxies) != 1 || result.UnhealthyProxies[0] != "demo-proxy" {
+			t.Errorf("result = %+v, want demo-proxy recorded as unhealthy", result)
+		}
+		op.Steps[0].State = types.StepStateCompleted
+		if proxies := engine.findDiscoveredProxies(op); len(proxies) != 1 {
+			t.Errorf("expected unhealthy proxy to remain available for deregistration, got %d", len(proxies))
+		}
+
+		warned := false
+		for _, event := range engine.events[op.ID] {
+			if event.Type == "warning" && containsAny(event.Message, "demo-proxy") {
+				warned = true
+			}
+		}
+		if !warned {
+			t.Error("expected a warning event naming the unhealthy proxy")
+		}
+	})
+}
+
+// TestApplyParametersToClusterPG_SkippedParameterReasons verifies that each
+// parameter that cannot be migrated is recorded with its value, a specific
+// reason, and the underlying error, while the rest are still applied.
+func TestApplyParametersToClusterPG_SkippedParameterReasons(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	for name, msg := range map[string]string{
+		"removed_param": "Could not find parameter with name: removed_param",
+		"work_mem":      "Invalid parameter value: 1TB for: work_mem allowed values are: 64-2147483647",
+	} {
+		mockState.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeAPIError,
+			Action:      "ModifyDBClusterParameterGroup",
+			Target:      name,
+			Probability: 1.0,
+			ErrorCode:   "InvalidParameterValue",
+			ErrorMsg:    msg,
+			Enabled:     true,
+		})
+	}
+
+	rdsClient, err := engine.clientManager.GetClient(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+
+	op := &types.Operation{ID: "test-op-skipped-params"}
+	skipped := engine.applyParametersToClusterPG(context.Background(), rdsClient, op, "target-pg", []rds.ParameterInfo{
+		{Name: "rds.logical_replication", Value: "1", IsModifiable: true},
+		{Name: "removed_param", Value: "on", IsModifiable: true},
+		{Name: "work_mem", Value: "1TB", IsModifiable: true},
+		{Name: "rds.extensions", Value: "pg_stat_statements", IsModifiable: false},
+	})
+
+	want := map[string]types.SkipReason{
+		"removed_param":  types.SkipReasonUnknownParameter,
+		"work_mem":       types.SkipReasonIncompatibleValue,
+		"rds.extensions": types.SkipReasonNotModifiable,
+	}
+	if len(skipped) != len(want) {
+		t.Fatalf("got %d skipped parameters, want %d: %+v", len(skipped), len(want), skipped)
+	}
+	for _, p := range skipped {
+		reason, ok := want[p.Name]
+		if !ok {
+			t.Errorf("unexpected skipped parameter %q", p.Name)
+			continue
+		}
+		if p.Reason != reason {
+			t.Errorf("%s: reason = %q, want %q", p.Name, p.Reason, reason)
+		}
+		if p.Value == "" {
+			t.Errorf("%s: expected value to be recorded", p.Name)
+		}
+		if reason != types.SkipReasonNotModifiable && p.Error == "" {
+			t.Errorf("%s: expected underlying error to be recorded", p.Name)
+		}
+	}
+}
+
+// TestApplyParametersToClusterPG_ValidatesValues verifies that values the
+// target group's parameter definitions reject are skipped up front and the
+// rest applied in one call, without falling back to one parameter at a time.
+func TestApplyParametersToClusterPG_ValidatesValues(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	rdsClient, err := engine.clientManager.GetClient(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+
+	op := &types.Operation{ID: "test-op-validated-params"}
+	skipped := engine.applyParametersToClusterPG(context.Background(), rdsClient, op, "target-pg", []rds.ParameterInfo{
+		{Name: "max_connections", Value: "500", ApplyType: "static", IsModifiable: true},
+		{Name: "work_mem", Value: "1TB", ApplyType: "dynamic", IsModifiable: true},
+		{Name: "removed_param", Value: "on", ApplyType: "dynamic", IsModifiable: true},
+	})
+
+	reasons := map[string]types.SkipReason{}
+	for _, p := range skipped {
+		reasons[p.Name] = p.Reason
+		if !containsAny(p.Error, p.Name+"="+p.Value) {
+			t.Errorf("%s: error %q does not name the parameter and value", p.Name, p.Error)
+		}
+	}
+	want := map[string]types.SkipReason{
+		"work_mem":      types.SkipReasonIncompatibleValue,
+		"removed_param": types.SkipReasonUnknownParameter,
+	}
+	if !maps.Equal(reasons, want) {
+		t.Errorf("skipped = %v, want %v", reasons, want)
+	}
+
+	applied := mockState.ClusterParameters("target-pg")
+	if len(applied) != 1 || applied["max_connections"].Value != "500" {
+		t.Errorf("applied parameters = %v, want only max_connections", applied)
+	}
+	for _, event := range engine.events[op.ID] {
+		if containsAny(event.Message, "could not be applied") {
+			t.Errorf("unexpected fallback to one parameter at a time: %s", event.Message)
+		}
+	}
+}
+
+// TestHandleValidateProxyHealth_FailsOnCreatingProxy verifies that a proxy
+// still being created fails validation with its current status.
+func TestHandleValidateProxyHealth_FailsOnCreatingProxy(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-multi-proxy",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+	if err := mockState.SeedProxy("demo-multi-proxy", "demo-multi", "creating"); err != nil {
+		t.Fatalf("SeedProxy failed: %v", err)
+	}
+
+	op := &types.Operation{
+		ID:        "test-op-proxy-creating",
+		Type:      types.OperationTypeEngineUpgrade,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{{
+			ID:     "step-1",
+			Name:   "Validate proxy health",
+			Action: "validate_proxy_health",
+			State:  types.StepStatePending,
+		}},
+		CreatedAt: time.Now(),
+	}
+
+	err := engine.handleValidateProxyHealth(context.Background(), op, &op.Steps[0])
+	if err == nil {
+		t.Fatal("expected a creating proxy to fail validation")
+	}
+	if !containsAny(err.Error(), "creating") {
+		t.Errorf("expected proxy status in error, got: %v", err)
+	}
+}
+
+// TestHandleModifyCluster_RejectsImplicitMajorUpgrade verifies that a
+// modify_cluster step cannot jump major versions unless it explicitly allows it.
+func TestHandleModifyCluster_RejectsImplicitMajorUpgrade(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	params, _ := json.Marshal(map[string]any{"engine_version": "16.1"})
+	op := &types.Operation{
+		ID:        "test-op-modify-major",
+		Type:      types.OperationTypeMinorVersionUpgrade,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{{
+			ID:         "step-1",
+			Name:       "Upgrade engine version",
+			Action:     "modify_cluster",
+			State:      types.StepStatePending,
+			Parameters: params,
+		}},
+	}
+
+	err := engine.handleModifyCluster(context.Background(), op, &op.Steps[0])
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+	}
+}
+
+// TestHandleWaitInstanceAvailable_UsesStepTimeout verifies that a step's own
+// timeout cuts the wait short of the engine default.
+func TestHandleWaitInstanceAvailable_UsesStepTimeout(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultWaitTimeout = 30 * time.Second
+
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-multi-reader-1",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+	if err := mockState.SetInstanceStatus("demo-multi-reader-1", "rebooting"); err != nil {
+		t.Fatalf("SetInstanceStatus failed: %v", err)
+	}
+
+	waitParams, _ := json.Marshal(map[string]string{"instance_id": "demo-multi-reader-1"})
+	op := &types.Operation{
+		ID:        "test-op-step-timeout",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{{
+			ID:             "step-1",
+			Name:           "Wait for reader 1",
+			Action:         "wait_instance_available",
+			State:          types.StepStatePending,
+			Parameters:     waitParams,
+			TimeoutSeconds: 1,
+		}},
+	}
+
+	start := time.Now()
+	err := engine.handleWaitInstanceAvailable(context.Background(), op, &op.Steps[0])
+	if !errors.Is(err, internalerrors.ErrWaitTimeout) {
+		t.Fatalf("expected ErrWaitTimeout, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("wait took %v, expected the 1s step timeout to apply", elapsed)
+	}
+}
+
+// TestGetStepPlan_ReflectsPreparedParameterGroups verifies that parameter
+// group names resolved by prepare_parameter_group show up in the plan view
+// of the later steps that will use them.
+func TestGetStepPlan_ReflectsPreparedParameterGroups(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	prepareParams, _ := json.Marshal(map[string]string{"target_engine_version": "16.4"})
+	modifyParams, _ := json.Marshal(map[string]string{"engine_version": "16.4"})
+	bgParams, _ := json.Marshal(map[string]string{"target_engine_version": "16.4"})
+	op := &types.Operation{
+		ID:        "test-op-step-plan",
+		Type:      types.OperationTypeEngineUpgrade,
+		State:     types.StateRunning,
+		ClusterID: "demo-upgrade",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Prepare parameter groups", Action: "prepare_parameter_group", State: types.StepStateInProgress, Parameters: prepareParams},
+			{ID: "step-2", Name: "Modify cluster", Action: "modify_cluster", State: types.StepStatePending, Parameters: modifyParams},
+			{ID: "step-3", Name: "Create Blue-Green deployment", Action: "create_blue_green_deployment", State: types.StepStatePending, Parameters: bgParams},
+		},
+		CreatedAt: time.Now(),
+	}
+	engine.operations[op.ID] = op
+
+	if err := engine.handlePrepareParameterGroup(context.Background(), op, &op.Steps[0]); err != nil {
+		t.Fatalf("handlePrepareParameterGroup failed: %v", err)
+	}
+
+	plan, err := engine.GetStepPlan(op.ID)
+	if err != nil {
+		t.Fatalf("GetStepPlan failed: %v", err)
+	}
+	if len(plan) != 3 {
+		t.Fatalf("expected 3 planned steps, got %d", len(plan))
+	}
+
+	var modify map[string]any
+	if err := json.Unmarshal(plan[1].Parameters, &modify); err != nil {
+		t.Fatalf("unmarshal modify_cluster params: %v", err)
+	}
+	if modify["engine_version"] != "16.4" {
+		t.Errorf("engine_version = %v, want 16.4", modify["engine_version"])
+	}
+	clusterPG, _ := modify["db_cluster_parameter_group_name"].(string)
+	if clusterPG == "" || clusterPG == "demo-upgrade-pg" {
+		t.Errorf("expected modify_cluster to use the prepared cluster parameter group, got %q", clusterPG)
+	}
+
+	var bg map[string]any
+	if err := json.Unmarshal(plan[2].Parameters, &bg); err != nil {
+		t.Fatalf("unmarshal create_blue_green_deployment params: %v", err)
+	}
+	if bg["target_cluster_parameter_group_name"] != clusterPG {
+		t.Errorf("target_cluster_parameter_group_name = %v, want %q", bg["target_cluster_parameter_group_name"], clusterPG)
+	}
+
+	// The plan is a snapshot; mutating it must not affect the operation.
+	plan[1].Parameters[0] = 'x'
+	if op.Steps[1].Parameters[0] == 'x' {
+		t.Error("GetStepPlan should return a copy of step parameters")
+	}
+
+	if _, err := engine.GetStepPlan("missing"); !errors.Is(err, internalerrors.ErrOperationNotFound) {
+		t.Errorf("expected ErrOperationNotFound, got %v", err)
+	}
+}
+
+// TestHandleWaitInstanceAvailable_InitialPollDelaySkipsStaleStatus verifies
+// that a wait started right after a reboot does not complete on the instance's
+// pre-reboot "available" status, which the mock keeps for a short while.
+func TestHandleWaitInstanceAvailable_InitialPollDelaySkipsStaleStatus(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 5 * time.Millisecond
+	engine.initialPollDelay = 300 * time.Millisecond
+
+	waitParams, _ := json.Marshal(map[string]string{"instance_id": "demo-multi-reader-1"})
+	op := &types.Operation{
+		ID:        "test-op-initial-poll-delay",
+		Type:      types.OperationTypeInstanceCycle,
+		State:     types.StateRunning,
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+		Steps: []types.Step{{
+			ID:         "step-1",
+			Name:       "Wait for reader 1",
+			Action:     "wait_instance_available",
+			State:      types.StepStatePending,
+			Parameters: waitParams,
+		}},
+	}
+
+	if err := mockState.RebootInstance("demo-multi-reader-1"); err != nil {
+		t.Fatalf("RebootInstance failed: %v", err)
+	}
+	inst, _ := mockState.GetInstance("demo-multi-reader-1")
+	if inst.Status != "available" || inst.PendingStatusChange != "rebooting" {
+		t.Fatalf("expected reboot to be pending behind a stale available status, got status=%q pending=%q",
+			inst.Status, inst.PendingStatusChange)
+	}
+
+	start := time.Now()
+	if err := engine.handleWaitInstanceAvailable(context.Background(), op, &op.Steps[0]); err != nil {
+		t.Fatalf("handleWaitInstanceAvailable failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < engine.initialPollDelay {
+		t.Errorf("wait returned after %v, before the %v initial poll delay", elapsed, engine.initialPollDelay)
+	}
+
+	inst, _ = mockState.GetInstance("demo-multi-reader-1")
+	if inst.PendingStatusChange != "" {
+		t.Errorf("wait completed while the reboot was still pending (status=%q pending=%q)",
+			inst.Status, inst.PendingStatusChange)
+	}
+}
+
+// TestHandleCreateTempInstance_PromotionTier verifies that the temp instance is
+// created with the promotion tier from its step parameters.
+func TestHandleCreateTempInstance_PromotionTier(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	op := &types.Operation{
+		ID:        "test-temp-tier",
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+	}
+	step := &types.Step{
+		Action:     "create_temp_instance",
+		Parameters: json.RawMessage(`{"instance_type":"db.r6g.xlarge","engine":"aurora-postgresql","promotion_tier":7}`),
+	}
+
+	if err := engine.handleCreateTempInstance(context.Background(), op, step); err != nil {
+		t.Fatalf("handleCreateTempInstance failed: %v", err)
+	}
+
+	inst, ok := mockState.GetInstance(rds.GenerateTempInstanceID(op.ClusterID, op.ID))
+	if !ok {
+		t.Fatal("temp instance was not created")
+	}
+	if inst.PromotionTier != 7 {
+		t.Errorf("PromotionTier = %d, want 7", inst.PromotionTier)
+	}
+}
+
+func TestHandleCreateTempInstance_WriterNetwork(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	op := &types.Operation{
+		ID:        "test-temp-network",
+		ClusterID: "demo-multi",
+		Region:    "us-east-1",
+	}
+	step := &types.Step{
+		Action:     "create_temp_instance",
+		Parameters: json.RawMessage(`{"instance_type":"db.r6g.xlarge","engine":"aurora-postgresql"}`),
+	}
+	if err := engine.handleCreateTempInstance(context.Background(), op, step); err != nil {
+		t.Fatalf("handleCreateTempInstance failed: %v", err)
+	}
+
+	// Left to the defaults, the mock would put it in the account's default VPC.
+	inst, ok := mockState.GetInstance(rds.GenerateTempInstanceID(op.ClusterID, op.ID))
+	if !ok {
+		t.Fatal("temp instance was not created")
+	}
+	if inst.SubnetGroupName != "demo-multi-subnets" {
+		t.Errorf("SubnetGroupName = %q, want the writer's demo-multi-subnets", inst.SubnetGroupName)
+	}
+	if !slices.Equal(inst.SecurityGroupIDs, []string{"sg-demo-multi"}) {
+		t.Errorf("SecurityGroupIDs = %v, want the writer's [sg-demo-multi]", inst.SecurityGroupIDs)
+	}
+}
+
+func TestHandleCreateTempInstance_AvailabilityZone(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	writer, ok := mockState.GetInstance("demo-multi-writer")
+	if !ok || writer.AvailabilityZone != "us-east-1a" {
+		t.Fatalf("expected the demo-multi writer in us-east-1a, got %+v", writer)
+	}
+
+	tests := []struct {
+		name        string
+		class       string
+		zone        string
+		wantZone    string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{name: "follows the writer", class: "db.r6g.xlarge", wantZone: "us-east-1a"},
+		{name: "override", class: "db.r6g.xlarge", zone: "us-east-1c", wantZone: "us-east-1c"},
+		// The mock does not offer db.r5.24xlarge in us-east-1a.
+		{name: "writer AZ unavailable", class: "db.r5.24xlarge", wantZone: "us-east-1b", wantWarning: true},
+		{name: "override unavailable", class: "db.r5.24xlarge", zone: "us-east-1a", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &types.Operation{
+				ID:        fmt.Sprintf("test-temp-az-%d", i),
+				ClusterID: "demo-multi",
+				Region:    "us-east-1",
+			}
+			params, _ := json.Marshal(map[string]string{
+				"instance_type":     tt.class,
+				"engine":            "aurora-postgresql",
+				"availability_zone": tt.zone,
+			})
+			step := &types.Step{Action: "create_temp_instance", Parameters: params}
+
+			err := engine.handleCreateTempInstance(context.Background(), op, step)
+			if tt.wantErr {
+				if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+					t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("handleCreateTempInstance failed: %v", err)
+			}
+
+			inst, ok := mockState.GetInstance(rds.GenerateTempInstanceID(op.ClusterID, op.ID))
+			if !ok {
+				t.Fatal("temp instance was not created")
+			}
+			if inst.AvailabilityZone != tt.wantZone {
+				t.Errorf("AvailabilityZone = %q, want %q", inst.AvailabilityZone, tt.wantZone)
+			}
+
+			var warned bool
+			for _, event := range engine.events[op.ID] {
+				warned = warned || event.Type == "warning"
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("warning event = %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestHandleCreateBlueGreenDeployment_GlobalDatabase(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, gc := range []mock.MockGlobalCluster{
+		{
+			ID: "global-upgrade", Engine: "aurora-postgresql", EngineVersion: "15.4",
+			Members: []mock.MockGlobalClusterMember{
+				{ClusterID: "demo-upgrade", IsWriter: true},
+				{ClusterID: "demo-upgrade-dr", Region: "us-west-2"},
+			},
+		},
+		{
+			ID: "global-multi", Engine: "aurora-postgresql", EngineVersion: "15.4",
+			Members: []mock.MockGlobalClusterMember{
+				{ClusterID: "prod-primary", Region: "us-west-2", IsWriter: true},
+				{ClusterID: "demo-multi"},
+			},
+		},
+	} {
+		if err := mockState.AddGlobalCluster(gc); err != nil {
+			t.Fatalf("AddGlobalCluster failed: %v", err)
+		}
+	}
+
+	newStep := func() *types.Step {
+		return &types.Step{
+			Action:     "create_blue_green",
+			Parameters: json.RawMessage(`{"target_engine_version":"16.1"}`),
+		}
+	}
+
+	t.Run("secondary is refused", func(t *testing.T) {
+		op := &types.Operation{ID: "test-bg-secondary", ClusterID: "demo-multi", Region: "us-east-1"}
+		err := engine.handleCreateBlueGreenDeployment(ctx, op, newStep())
+		if !errors.Is(err, internalerrors.ErrInvalidState) {
+			t.Fatalf("err = %v, want ErrInvalidState for a global secondary", err)
+		}
+		if len(mockState.ListBlueGreenDeployments()) != 0 {
+			t.Error("a Blue-Green deployment was created for a global secondary")
+		}
+	})
+
+	t.Run("primary warns about secondaries", func(t *testing.T) {
+		op := &types.Operation{ID: "test-bg-primary", ClusterID: "demo-upgrade", Region: "us-east-1"}
+		if err := engine.handleCreateBlueGreenDeployment(ctx, op, newStep()); err != nil {
+			t.Fatalf("handleCreateBlueGreenDeployment failed: %v", err)
+		}
+		found := false
+		for _, event := range engine.events[op.ID] {
+			if event.Type == "warning" && strings.Contains(event.Message, "global database global-upgrade") &&
+				strings.Contains(event.Message, "arn:aws:rds:us-west-2:123456789012:cluster:demo-upgrade-dr") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no warning naming the secondary cluster in events %+v", engine.events[op.ID])
+		}
+	})
+}
+
+func TestHandleCreateBlueGreenDeployment_TargetVersion(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	mockState.SetUpgradeTargets("aurora-postgresql", "15.4", []mock.MockUpgradeTarget{
+		{Version: "15.5"},
+		{Version: "15.6", EngineModes: []string{"serverless"}},
+		{Version: "16.1", IsMajor: true},
+	})
+	create := func(target string) error {
+		op := &types.Operation{ID: "test-bg-target-" + target, ClusterID: "demo-upgrade", Region: "us-east-1"}
+		return engine.handleCreateBlueGreenDeployment(ctx, op, &types.Step{
+			Action:     "create_blue_green_deployment",
+			Parameters: json.RawMessage(`{"target_engine_version":"` + target + `"}`),
+		})
+	}
+
+	err := create("16.10")
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) || !strings.Contains(err.Error(), "valid targets: 15.5, 16.1") {
+		t.Errorf("expected the valid targets to be listed, got: %v", err)
+	}
+	err = create("15.6")
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) || !strings.Contains(err.Error(), "minor_version_upgrade") {
+		t.Errorf("expected the in-place path to be recommended, got: %v", err)
+	}
+	if n := len(mockState.ListBlueGreenDeployments()); n != 0 {
+		t.Fatalf("%d Blue-Green deployments created for invalid targets", n)
+	}
+
+	if err := create("16.1"); err != nil {
+		t.Fatalf("handleCreateBlueGreenDeployment failed: %v", err)
+	}
+	if n := len(mockState.ListBlueGreenDeployments()); n != 1 {
+		t.Errorf("%d Blue-Green deployments, want 1", n)
+	}
+}
+
+// TestHandleWaitClusterAvailable_SurvivesIntermittentFailures verifies that a
+// cluster wait rides out a flaky DescribeDBClusters that is slow and fails
+// every other call, rather than failing the step on the first error.
+func TestHandleWaitClusterAvailable_SurvivesIntermittentFailures(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+
+	if err := mockState.SetClusterStatus("demo-single", "modifying"); err != nil {
+		t.Fatalf("SetClusterStatus failed: %v", err)
+	}
+	faultID := mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeIntermittent,
+		Action:      "DescribeDBClusters",
+		Probability: 1,
+		FailEveryN:  2,
+		ErrorCode:   "InternalFailure",
+		DelayMs:     10,
+		Enabled:     true,
+	})
+
+	op := &types.Operation{ID: "op-flaky", ClusterID: "demo-single", Region: "us-east-1"}
+	step := &types.Step{Name: "Wait for cluster", Action: "wait_cluster_available"}
+	if err := engine.handleWaitClusterAvailable(context.Background(), op, step); err != nil {
+		t.Fatalf("handleWaitClusterAvailable failed: %v", err)
+	}
+
+	for _, f := range mockState.Faults().ListFaults() {
+		if f.ID == faultID && f.CallCount < 2 {
+			t.Errorf("fault matched %d calls, want at least one injected failure", f.CallCount)
+		}
+	}
+}
+
+// TestHandleWaitBlueGreenAvailable_TaskProgression verifies that the wait
+// returns once every task completes and fails with the task name and status
+// details when a task fails.
+func TestHandleWaitBlueGreenAvailable_TaskProgression(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+
+	waitForDeployment := func(name string) (*mock.MockBlueGreenDeployment, error) {
+		bg, err := mockState.CreateBlueGreenDeployment(name, "demo-upgrade", "16.4")
+		if err != nil {
+			t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+		}
+		result, _ := json.Marshal(map[string]string{"deployment_identifier": bg.Identifier})
+		op := &types.Operation{
+			ID:        "op-" + name,
+			ClusterID: "demo-upgrade",
+			Region:    "us-east-1",
+			Steps: []types.Step{
+				{Action: "create_blue_green_deployment", State: types.StepStateCompleted, Result: result},
+				{Name: "Wait for Blue-Green", Action: "wait_blue_green_available"},
+			},
+		}
+		err = engine.handleWaitBlueGreenAvailable(context.Background(), op, &op.Steps[1])
+		bg, _ = mockState.GetBlueGreenDeployment(bg.Identifier)
+		return bg, err
+	}
+
+	t.Run("all tasks complete", func(t *testing.T) {
+		bg, err := waitForDeployment("upgrade-ok")
+		if err != nil {
+			t.Fatalf("handleWaitBlueGreenAvailable failed: %v", err)
+		}
+		for _, task := range bg.Tasks {
+			if task.Status != "COMPLETED" {
+				t.Errorf("task %s = %s, want COMPLETED", task.Name, task.Status)
+			}
+		}
+	})
+
+	t.Run("failed task", func(t *testing.T) {
+		mockState.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeTaskFail,
+			Target:      "upgrade-bad",
+			Task:        "DB_ENGINE_VERSION_UPGRADE",
+			Probability: 1,
+			ErrorMsg:    "incompatible extension",
+			Enabled:     true,
+		})
+		_, err := waitForDeployment("upgrade-bad")
+		if err == nil {
+			t.Fatal("expected the wait to fail")
+		}
+		if !containsAny(err.Error(), "DB_ENGINE_VERSION_UPGRADE") || !containsAny(err.Error(), "incompatible extension") {
+			t.Errorf("expected task name and details in error, got: %v", err)
+		}
+	})
+}
+
+// TestHandleSwitchoverBlueGreen_MemberProgress verifies that a member stuck
+// mid-switchover is named when the wait times out, and that a completed
+// switchover records each member's status.
+func TestHandleSwitchoverBlueGreen_MemberProgress(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+
+	bg, err := mockState.CreateBlueGreenDeployment("switch", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if current, _ := mockState.GetBlueGreenDeployment(bg.Identifier); current.Status == "AVAILABLE" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("deployment did not become available")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	result, _ := json.Marshal(map[string]string{"deployment_identifier": bg.Identifier})
+	op := &types.Operation{
+		ID:          "op-switch",
+		ClusterID:   "demo-upgrade",
+		Region:      "us-east-1",
+		WaitTimeout: 1,
+		Steps: []types.Step{
+			{Action: "create_blue_green_deployment", State: types.StepStateCompleted, Result: result},
+			{Name: "Switchover", Action: "switchover_blue_green"},
+		},
+	}
+	step := &op.Steps[1]
+
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-upgrade-reader-1",
+		Probability: 1,
+		Enabled:     true,
+	})
+	err = engine.handleSwitchoverBlueGreen(context.Background(), op, step)
+	if !errors.Is(err, internalerrors.ErrWaitTimeout) {
+		t.Fatalf("expected ErrWaitTimeout, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "2/3 members switched over, still waiting on demo-upgrade-reader-1 (SWITCHING_OVER)") {
+		t.Errorf("error should name the stuck member, got: %v", err)
+	}
+	if !strings.Contains(step.WaitCondition, "2/3 members switched over (1 SWITCHING_OVER)") {
+		t.Errorf("WaitCondition = %q", step.WaitCondition)
+	}
+
+	// Retrying picks up the switchover already in progress.
+	mockState.Faults().ClearAll()
+	if err := engine.handleSwitchoverBlueGreen(context.Background(), op, step); err != nil {
+		t.Fatalf("handleSwitchoverBlueGreen failed: %v", err)
+	}
+	var got struct {
+		SwitchoverDetails  []rds.BlueGreenSwitchoverDetail `json:"switchover_details"`
+		MemberStatusCounts map[string]int                  `json:"member_status_counts"`
+	}
+	if err := json.Unmarshal(step.Result, &got); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if len(got.SwitchoverDetails) != 3 || got.MemberStatusCounts["SWITCHOVER_COMPLETED"] != 3 {
+		t.Errorf("result = %s, want all 3 members SWITCHOVER_COMPLETED", step.Result)
+	}
+}
+
+// TestHandleCheckUpgradePrerequisites verifies that a failed required
+// prerequisite pauses the upgrade with its remediation, and that a cluster
+// meeting every prerequisite passes.
+func TestHandleCheckUpgradePrerequisites(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	newStep := func(target string) *types.Step {
+		params, _ := json.Marshal(map[string]string{"target_engine_version": target})
+		return &types.Step{Name: "Check upgrade prerequisites", Action: "check_upgrade_prerequisites", Parameters: params}
+	}
+
+	t.Run("logical replication disabled", func(t *testing.T) {
+		op := &types.Operation{ID: "op-prereq-fail", ClusterID: "demo-single", Region: "us-east-1"}
+		err := engine.handleCheckUpgradePrerequisites(ctx, op, newStep("16.1"))
+		if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+			t.Fatalf("err = %v, want ErrInterventionRequired", err)
+		}
+		if !containsAny(err.Error(), "rds.logical_replication") {
+			t.Errorf("expected the missing parameter in error, got: %v", err)
+		}
+	})
+
+	t.Run("prerequisites met", func(t *testing.T) {
+		op := &types.Operation{ID: "op-prereq-ok", ClusterID: "demo-upgrade", Region: "us-east-1"}
+		step := newStep("16.1")
+		if err := engine.handleCheckUpgradePrerequisites(ctx, op, step); err != nil {
+			t.Fatalf("handleCheckUpgradePrerequisites failed: %v", err)
+		}
+		if !containsAny(string(step.Result), "upgrade_target") {
+			t.Errorf("expected checks in step result, got %s", step.Result)
+		}
+	})
+}
+
+// TestHandleCleanupBlueGreen_InferredOldCluster verifies that when the
+// switchover details are gone, cleanup only deletes the guessed -old1 cluster
+// after confirming its engine version, and otherwise pauses without deleting.
+func TestHandleCleanupBlueGreen_InferredOldCluster(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	bg, err := mockState.CreateBlueGreenDeployment("cleanup", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus := func(status string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if current, _ := mockState.GetBlueGreenDeployment(bg.Identifier); current.Status == status {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("deployment did not reach %s", status)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForStatus("AVAILABLE")
+	if err := mockState.SwitchoverBlueGreenDeployment(bg.Identifier); err != nil {
+		t.Fatalf("SwitchoverBlueGreenDeployment failed: %v", err)
+	}
+	waitForStatus("SWITCHOVER_COMPLETED")
+
+	// The recorded deployment no longer exists and no switchover step ran,
+	// so the handler has to fall back to the -old1 naming guess.
+	newOp := func(id, target, original string) *types.Operation {
+		params, _ := json.Marshal(types.EngineUpgradeParams{TargetEngineVersion: target})
+		info, _ := json.Marshal(types.ClusterInfo{ClusterID: "demo-upgrade", EngineVersion: original})
+		deployment, _ := json.Marshal(map[string]string{"deployment_identifier": "bgd-gone"})
+		return &types.Operation{
+			ID:         id,
+			ClusterID:  "demo-upgrade",
+			Region:     "us-east-1",
+			Parameters: params,
+			Steps: []types.Step{
+				{Action: "get_cluster_info", State: types.StepStateCompleted, Result: info},
+				{Action: "create_blue_green_deployment", State: types.StepStateCompleted, Result: deployment},
+				{Name: "Cleanup", Action: "cleanup_blue_green"},
+			},
+		}
+	}
+	oldClusterIntact := func() bool {
+		old, ok := mockState.GetCluster("demo-upgrade-old1")
+		return ok && old.Status == "available"
+	}
+
+	for _, tc := range []struct {
+		name, target, original, want string
+	}{
+		{"live cluster not on target", "17.1", "15.4", "not the target 17.1"},
+		{"recorded version mismatch", "16.4", "14.9", "was on 14.9 before the upgrade"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			op := newOp("op-"+strings.ReplaceAll(tc.name, " ", "-"), tc.target, tc.original)
+			err := engine.handleCleanupBlueGreen(ctx, op, &op.Steps[2])
+			if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+				t.Fatalf("err = %v, want ErrInterventionRequired", err)
+			}
+			if !containsAny(err.Error(), tc.want) {
+				t.Errorf("expected %q in error, got: %v", tc.want, err)
+			}
+			if op.State != types.StatePaused || !containsAny(op.PauseReason, "Nothing has been deleted") {
+				t.Errorf("state = %s, pause reason = %q", op.State, op.PauseReason)
+			}
+			if !oldClusterIntact() {
+				t.Error("demo-upgrade-old1 was deleted without confirmation")
+			}
+		})
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		op := newOp("op-confirmed", "16.4", "15.4")
+		if err := engine.handleCleanupBlueGreen(ctx, op, &op.Steps[2]); err != nil {
+			t.Fatalf("handleCleanupBlueGreen failed: %v", err)
+		}
+		if oldClusterIntact() {
+			t.Error("demo-upgrade-old1 was not deleted")
+		}
+		if live, ok := mockState.GetCluster("demo-upgrade"); !ok || live.Status != "available" {
+			t.Errorf("live cluster touched: %+v", live)
+		}
+	})
+}
+
+func TestPreflightProblems(t *testing.T) {
+	healthy := func() *types.ClusterInfo {
+		return &types.ClusterInfo{
+			ClusterID: "c1",
+			Status:    "available",
+			Instances: []types.InstanceInfo{
+				{InstanceID: "w", Role: "writer", Status: "available"},
+				{InstanceID: "r1", Role: "reader", Status: "available"},
+				{InstanceID: "as", Role: "reader", Status: "deleting", IsAutoScaled: true},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		mutate      func(*types.ClusterInfo)
+		deployments []*rds.BlueGreenDeploymentInfo
+		adopt       bool
+		want        []string
+	}{
+		{name: "clean cluster"},
+		{
+			name:   "failing over",
+			mutate: func(info *types.ClusterInfo) { info.Status = "failing-over" },
+			want:   []string{"cluster c1 is failing-over"},
+		},
+		{
+			name:   "no writer",
+			mutate: func(info *types.ClusterInfo) { info.Instances[0].Role = "reader" },
+			want:   []string{"has no writer"},
+		},
+		{
+			name:   "two writers",
+			mutate: func(info *types.ClusterInfo) { info.Instances[1].Role = "writer" },
+			want:   []string{"has 2 writers (w, r1)"},
+		},
+		{
+			name:   "instance in error",
+			mutate: func(info *types.ClusterInfo) { info.Instances[1].Status = "storage-full" },
+			want:   []string{"instance r1 is in error state storage-full"},
+		},
+		{
+			name:   "instance deleting",
+			mutate: func(info *types.ClusterInfo) { info.Instances[1].Status = "deleting" },
+			want:   []string{"instance r1 is deleting"},
+		},
+		{
+			name:        "blue-green in progress",
+			deployments: []*rds.BlueGreenDeploymentInfo{{Identifier: "bgd-1", Status: "AVAILABLE"}},
+			want:        []string{"Blue-Green deployment bgd-1 is AVAILABLE"},
+		},
+		{
+			name:        "blue-green adoptable",
+			deployments: []*rds.BlueGreenDeploymentInfo{{Identifier: "bgd-1", Status: "PROVISIONING"}},
+			adopt:       true,
+		},
+		{
+			name:        "switchover in progress blocks adoption",
+			deployments: []*rds.BlueGreenDeploymentInfo{{Identifier: "bgd-1", Status: "SWITCHOVER_IN_PROGRESS"}},
+			adopt:       true,
+			want:        []string{"Blue-Green deployment bgd-1 is SWITCHOVER_IN_PROGRESS"},
+		},
+		{
+			name:        "completed deployment ignored",
+			deployments: []*rds.BlueGreenDeploymentInfo{{Identifier: "bgd-1", Status: "SWITCHOVER_COMPLETED"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := healthy()
+			if tt.mutate != nil {
+				tt.mutate(info)
+			}
+			got := preflightProblems(info, tt.deployments, tt.adopt)
+			if len(got) != len(tt.want) {
+				t.Fatalf("problems = %q, want %d matching %q", got, len(tt.want), tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePreflightCheck_BlueGreenInProgress(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	op := &types.Operation{ID: "op-preflight", ClusterID: "demo-upgrade", Region: "us-east-1"}
+	step := preflightCheckStep(false)
+	if err := engine.handlePreflightCheck(ctx, op, &step); err != nil {
+		t.Fatalf("preflight on a clean cluster failed: %v", err)
+	}
+
+	client, err := engine.getRDSClient(ctx, op)
+	if err != nil {
+		t.Fatalf("getRDSClient failed: %v", err)
+	}
+	arn, err := client.GetClusterARN(ctx, op.ClusterID)
+	if err != nil {
+		t.Fatalf("GetClusterARN failed: %v", err)
+	}
+	bg, err := mockState.CreateBlueGreenDeployment("preflight", arn, "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+
+	step = preflightCheckStep(false)
+	err = engine.handlePreflightCheck(ctx, op, &step)
+	if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+		t.Fatalf("expected ErrInterventionRequired, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), bg.Identifier) {
+		t.Errorf("error should name the deployment %s: %v", bg.Identifier, err)
+	}
+
+	step = preflightCheckStep(true)
+	if err := engine.handlePreflightCheck(ctx, op, &step); err != nil {
+		t.Errorf("engine upgrade preflight should allow an adoptable deployment: %v", err)
+	}
+}
+
+func TestHandlePreflightCheck_IAMDatabaseAuthentication(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	iamWarnings := func(op *types.Operation) int {
+		t.Helper()
+		step := preflightCheckStep(false)
+		if err := engine.handlePreflightCheck(ctx, op, &step); err != nil {
+			t.Fatalf("handlePreflightCheck failed: %v", err)
+		}
+		if op.ClusterID == "demo-proxy-cluster" && !strings.Contains(string(step.Result), `"iam_database_authentication_enabled":true`) {
+			t.Errorf("result does not report IAM database authentication: %s", step.Result)
+		}
+		events, _ := engine.GetEvents(op.ID)
+		n := 0
+		for _, event := range events {
+			if event.Type == "warning" && strings.Contains(event.Message, "IAM database authentication") {
+				n++
+			}
+		}
+		return n
+	}
+	withTempInstance := []types.Step{{Action: "preflight_check"}, {Action: "create_temp_instance"}}
+
+	if n := iamWarnings(&types.Operation{ID: "op-iam-temp", ClusterID: "demo-proxy-cluster", Region: "us-east-1", Steps: withTempInstance}); n != 1 {
+		t.Errorf("got %d IAM warnings for a temp instance on an IAM cluster, want 1", n)
+	}
+	if n := iamWarnings(&types.Operation{ID: "op-iam-reboot", ClusterID: "demo-proxy-cluster", Region: "us-east-1"}); n != 0 {
+		t.Errorf("got %d IAM warnings for an operation that adds no instances, want 0", n)
+	}
+	if n := iamWarnings(&types.Operation{ID: "op-no-iam", ClusterID: "demo-multi", Region: "us-east-1", Steps: withTempInstance}); n != 0 {
+		t.Errorf("got %d IAM warnings for a cluster without IAM auth, want 0", n)
+	}
+}
+
+func TestHandleCreateTempInstance_IAMDatabaseAuthentication(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Aurora refuses an instance-level setting, so the temp instance must be
+	// created without one and still end up with the cluster's.
+	op := &types.Operation{ID: "test-temp-iam", ClusterID: "demo-proxy-cluster", Region: "us-east-1"}
+	step := &types.Step{
+		Action:     "create_temp_instance",
+		Parameters: json.RawMessage(`{"instance_type":"db.r6g.large","engine":"aurora-postgresql"}`),
+	}
+	if err := engine.handleCreateTempInstance(ctx, op, step); err != nil {
+		t.Fatalf("handleCreateTempInstance failed: %v", err)
+	}
+
+	client, err := engine.getRDSClient(ctx, op)
+	if err != nil {
+		t.Fatalf("getRDSClient failed: %v", err)
+	}
+	inst, err := client.GetInstanceInfo(ctx, rds.GenerateTempInstanceID(op.ClusterID, op.ID))
+	if err != nil {
+		t.Fatalf("GetInstanceInfo failed: %v", err)
+	}
+	if !inst.IAMDatabaseAuthenticationEnabled {
+		t.Error("temp instance does not have IAM database authentication like its cluster")
+	}
+}
+
+func TestHandleFailoverToInstance_ReplicaLag(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if err := mockState.SetReplicaLag("demo-multi-reader-1", 2500); err != nil {
+		t.Fatalf("SetReplicaLag failed: %v", err)
+	}
+
+	op := &types.Operation{ID: "op-lag", ClusterID: "demo-multi", Region: "us-east-1"}
+	step := &types.Step{Action: "failover_to_instance", Parameters: json.RawMessage(`{"instance_id":"demo-multi-reader-1"}`)}
+	err := engine.handleFailoverToInstance(ctx, op, step)
+	if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+		t.Fatalf("expected ErrInterventionRequired for a lagging target, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "2500ms") {
+		t.Errorf("error should report the lag: %v", err)
+	}
+	if inst, _ := mockState.GetInstance("demo-multi-writer"); !inst.IsWriter {
+		t.Fatal("a lagging target must not be failed over to")
+	}
+
+	// A higher threshold lets the failover through, and the topology is verified.
+	op.Parameters = json.RawMessage(`{"max_replica_lag_ms":5000}`)
+	if err := engine.handleFailoverToInstance(ctx, op, step); err != nil {
+		t.Fatalf("failover failed: %v", err)
+	}
+	var result struct {
+		Writers      []string `json:"writers"`
+		Readers      int      `json:"readers"`
+		ReplicaLagMs int64    `json:"replica_lag_ms"`
+	}
+	if err := json.Unmarshal(step.Result, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if len(result.Writers) != 1 || result.Writers[0] != "demo-multi-reader-1" || result.Readers != 2 || result.ReplicaLagMs != 2500 {
+		t.Errorf("result = %+v, want reader-1 as sole writer, 2 readers and 2500ms lag", result)
+	}
+}
+
+func TestVerifyFailoverTopology(t *testing.T) {
+	instances := func(roles ...string) *types.ClusterInfo {
+		info := &types.ClusterInfo{}
+		for i, role := range roles {
+			info.Instances = append(info.Instances, types.InstanceInfo{InstanceID: fmt.Sprintf("db-%d", i), Role: role})
+		}
+		return info
+	}
+
+	tests := []struct {
+		name    string
+		info    *types.ClusterInfo
+		wantErr string
+	}{
+		{name: "healthy", info: instances("writer", "reader", "reader")},
+		{name: "two writers", info: instances("writer", "writer", "reader"), wantErr: "2 writers"},
+		{name: "reader missing", info: instances("writer", "reader"), wantErr: "1 readers, expected 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyFailoverTopology(&types.Step{}, tt.info, "db-0", 2, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, internalerrors.ErrInterventionRequired) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected intervention mentioning %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandleModifyInstance_ParameterGroup(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	op := &types.Operation{ID: "test-modify-pg", ClusterID: "demo-multi", Region: "us-east-1"}
+	params, _ := json.Marshal(map[string]string{
+		"instance_id":             "demo-multi-reader-1",
+		"instance_type":           "db.r6g.xlarge",
+		"db_parameter_group_name": "demo-instance-pg",
+	})
+	step := &types.Step{Action: "modify_instance", Parameters: params}
+
+	if err := engine.handleModifyInstance(context.Background(), op, step); err != nil {
+		t.Fatalf("handleModifyInstance failed: %v", err)
+	}
+	inst, _ := mockState.GetInstance("demo-multi-reader-1")
+	if inst.ParameterGroupName != "demo-instance-pg" {
+		t.Errorf("parameter group = %q, want demo-instance-pg", inst.ParameterGroupName)
+	}
+}
+
+func TestHandleModifyInstance_PendingModifications(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	op := &types.Operation{ID: "test-modify-pending", ClusterID: "demo-multi", Region: "us-east-1"}
+	engine.operations[op.ID] = op
+	params, _ := json.Marshal(map[string]string{
+		"instance_id":   "demo-multi-reader-1",
+		"instance_type": "db.r6g.xlarge",
+	})
+	step := &types.Step{Action: "modify_instance", Parameters: params}
+
+	// A change queued for the maintenance window would be applied with ours.
+	if err := mockState.QueueInstanceModification("demo-multi-reader-1", "", "aurora-iopt1", nil); err != nil {
+		t.Fatalf("QueueInstanceModification failed: %v", err)
+	}
+	err := engine.handleModifyInstance(ctx, op, step)
+	if !errors.Is(err, internalerrors.ErrInterventionRequired) || !strings.Contains(err.Error(), "storage type aurora-iopt1") {
+		t.Fatalf("expected an intervention naming the pending storage type, got: %v", err)
+	}
+	if inst, _ := mockState.GetInstance("demo-multi-reader-1"); inst.Status != "available" || inst.PendingStatusChange != "" {
+		t.Fatalf("instance was modified despite the conflict: status %s, pending %q", inst.Status, inst.PendingStatusChange)
+	}
+	events, _ := engine.GetEvents(op.ID)
+	if len(events) != 1 || events[0].Type != "pending_modifications" ||
+		!strings.Contains(string(events[0].Data), `"storage_type":"aurora-iopt1"`) {
+		t.Errorf("events = %+v, want a pending_modifications event with the pending values", events)
+	}
+
+	// A pending value that matches the target is an earlier attempt of the
+	// same modify and does not block it.
+	if err := mockState.QueueInstanceModification("demo-multi-reader-2", "db.r6g.xlarge", "", nil); err != nil {
+		t.Fatalf("QueueInstanceModification failed: %v", err)
+	}
+	params, _ = json.Marshal(map[string]string{
+		"instance_id":   "demo-multi-reader-2",
+		"instance_type": "db.r6g.xlarge",
+	})
+	if err := engine.handleModifyInstance(ctx, op, &types.Step{Action: "modify_instance", Parameters: params}); err != nil {
+		t.Errorf("handleModifyInstance with a matching pending value failed: %v", err)
+	}
+}
+
+func TestConflictingPendingValues(t *testing.T) {
+	iops, otherIOPS := int32(3000), int32(6000)
+	tests := []struct {
+		name    string
+		pending *types.PendingModifiedValues
+		want    string
+	}{
+		{name: "nothing pending"},
+		{name: "same target", pending: &types.PendingModifiedValues{InstanceClass: "db.r6g.xlarge", IOPS: &iops}},
+		{
+			name:    "different class",
+			pending: &types.PendingModifiedValues{InstanceClass: "db.r6g.2xlarge"},
+			want:    "instance class db.r6g.2xlarge",
+		},
+		{
+			name:    "unrelated change",
+			pending: &types.PendingModifiedValues{InstanceClass: "db.r6g.xlarge", EngineVersion: "15.5"},
+			want:    "engine version 15.5",
+		},
+		{
+			name:    "different IOPS",
+			pending: &types.PendingModifiedValues{IOPS: &otherIOPS},
+			want:    "IOPS 6000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conflictingPendingValues(tt.pending, "db.r6g.xlarge", "", &iops, nil)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("conflict = %s, want none", got)
+			case tt.want != "" && (got == nil || got.String() != tt.want):
+				t.Errorf("conflict = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandleCreateSnapshot_ReusesOperationSnapshot verifies that a retried
+// snapshot step picks up the snapshot its first attempt started instead of
+// taking another one.
+func TestHandleCreateSnapshot_ReusesOperationSnapshot(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	op := &types.Operation{
+		ID:        "op-snapshot-retry",
+		ClusterID: "demo-single",
+		Region:    "us-east-1",
+		Steps:     []types.Step{{Name: "Create snapshot", Action: "create_snapshot"}},
+	}
+	var ids []string
+	for attempt := range 2 {
+		if err := engine.handleCreateSnapshot(ctx, op, &op.Steps[0]); err != nil {
+			t.Fatalf("attempt %d: handleCreateSnapshot failed: %v", attempt+1, err)
+		}
+		var result struct {
+			SnapshotID string `json:"snapshot_id"`
+		}
+		if err := json.Unmarshal(op.Steps[0].Result, &result); err != nil {
+			t.Fatalf("attempt %d: unmarshal result: %v", attempt+1, err)
+		}
+		ids = append(ids, result.SnapshotID)
+		// Timestamped IDs differ a second apart.
+		time.Sleep(1100 * time.Millisecond)
+	}
+
+	var snapshots []string
+	for _, snap := range mockState.ListSnapshots() {
+		if snap.ClusterID == "demo-single" {
+			snapshots = append(snapshots, snap.ID)
+		}
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("snapshots = %v, want exactly one", snapshots)
+	}
+	if ids[0] != snapshots[0] || ids[1] != snapshots[0] {
+		t.Errorf("step results = %v, want both to name %s", ids, snapshots[0])
+	}
+}
+
+// TestHandlePrepareParameterGroup_AuroraMySQLMajorUpgrade verifies that an
+// Aurora MySQL 5.7 -> 8.0 upgrade migrates custom parameters into groups of
+// the aurora-mysql8.0 family, with names RDS accepts, and skips parameters
+// MySQL 8.0 no longer has.
+func TestHandlePrepareParameterGroup_AuroraMySQLMajorUpgrade(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	const target = "8.0.mysql_aurora.3.04.0"
+	prepareParams, _ := json.Marshal(map[string]string{"target_engine_version": target})
+	modifyParams, _ := json.Marshal(map[string]any{"engine_version": target, "allow_major_version_upgrade": true})
+	op := &types.Operation{
+		ID:        "test-op-mysql-upgrade",
+		Type:      types.OperationTypeEngineUpgrade,
+		State:     types.StateRunning,
+		ClusterID: "demo-mysql57",
+		Region:    "us-east-1",
+		Steps: []types.Step{
+			{ID: "step-1", Name: "Prepare parameter groups", Action: "prepare_parameter_group", State: types.StepStateInProgress, Parameters: prepareParams},
+			{ID: "step-2", Name: "Modify cluster", Action: "modify_cluster", State: types.StepStatePending, Parameters: modifyParams},
+		},
+		CreatedAt: time.Now(),
+	}
+	engine.operations[op.ID] = op
+
+	if err := engine.handlePrepareParameterGroup(context.Background(), op, &op.Steps[0]); err != nil {
+		t.Fatalf("handlePrepareParameterGroup failed: %v", err)
+	}
+
+	var result struct {
+		Cluster struct {
+			Name          string                   `json:"name"`
+			Family        string                   `json:"family"`
+			SkippedParams []types.SkippedParameter `json:"skipped_params"`
+		} `json:"cluster_parameter_group"`
+		Instance struct {
+			Name   string `json:"name"`
+			Family string `json:"family"`
+		} `json:"instance_parameter_group"`
+	}
+	if err := json.Unmarshal(op.Steps[0].Result, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if result.Cluster.Family != "aurora-mysql8.0" || result.Instance.Family != "aurora-mysql8.0" {
+		t.Errorf("families = %s and %s, want aurora-mysql8.0", result.Cluster.Family, result.Instance.Family)
+	}
+	for _, name := range []string{result.Cluster.Name, result.Instance.Name} {
+		if strings.ContainsAny(name, "._") {
+			t.Errorf("parameter group name %q has characters RDS rejects", name)
+		}
+	}
+	if family, _ := mockState.ClusterParameterGroupFamily(result.Cluster.Name); family != "aurora-mysql8.0" {
+		t.Errorf("cluster parameter group created with family %q", family)
+	}
+
+	if len(result.Cluster.SkippedParams) != 1 || result.Cluster.SkippedParams[0].Name != "tx_isolation" ||
+		result.Cluster.SkippedParams[0].Reason != types.SkipReasonUnknownParameter {
+		t.Errorf("skipped = %+v, want only tx_isolation as unknown", result.Cluster.SkippedParams)
+	}
+	migrated := mockState.ClusterParameters(result.Cluster.Name)
+	if migrated["innodb_print_all_deadlocks"].Value != "1" || migrated["binlog_format"].Value != "ROW" {
+		t.Errorf("migrated parameters = %+v", migrated)
+	}
+}
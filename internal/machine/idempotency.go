@@ -0,0 +1,71 @@
+package machine
+
+import (
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// claimIdempotencyKey looks up the operation a key created. If it was created
+// within the TTL it is returned, provided it is for the same kind of change;
+// otherwise the key is reserved for the caller's create, which must release
+// it once the new operation is stored or has failed.
+//
+// The key lives on the operation record, so it survives restarts through the
+// store and is forgotten with the operation. Only a create still building its
+// plan is tracked separately, so a concurrent duplicate is refused rather than
+// creating a second operation.
+func (e *Engine) claimIdempotencyKey(key string, opType types.OperationType, clusterID, region string) (*types.Operation, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if op := e.findByIdempotencyKeyLocked(key); op != nil {
+		if op.Type != opType || op.ClusterID != clusterID || op.Region != region {
+			return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"idempotency key %q was used for %s operation %s on %s in %s",
+				key, op.Type, op.ID, op.ClusterID, op.Region)
+		}
+		return op, nil
+	}
+
+	if e.pendingKeys[key] {
+		return nil, errors.Wrapf(internalerrors.ErrConcurrentModification,
+			"an operation with idempotency key %q is already being created", key)
+	}
+	if e.pendingKeys == nil {
+		e.pendingKeys = make(map[string]bool)
+	}
+	e.pendingKeys[key] = true
+	return nil, nil
+}
+
+// releaseIdempotencyKey ends a reservation made by claimIdempotencyKey.
+func (e *Engine) releaseIdempotencyKey(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.pendingKeys, key)
+}
+
+// findByIdempotencyKeyLocked returns the most recent operation created with
+// key within the TTL, or nil. Callers hold e.mu.
+func (e *Engine) findByIdempotencyKeyLocked(key string) *types.Operation {
+	ttl := e.idempotencyTTL
+	if ttl <= 0 {
+		ttl = constants.DefaultIdempotencyTTL
+	}
+	cutoff := time.Now().Add(-ttl)
+
+	var found *types.Operation
+	for _, op := range e.operations {
+		if op.IdempotencyKey != key || op.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if found == nil || op.CreatedAt.After(found.CreatedAt) {
+			found = op
+		}
+	}
+	return found
+}
@@ -0,0 +1,106 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestCreateOperation_IdempotencyKey(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+	opts := CreateOptions{IdempotencyKey: "exec-1234"}
+
+	first, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, opts)
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if first.IdempotencyKey != "exec-1234" {
+		t.Errorf("IdempotencyKey = %q, want it recorded on the operation", first.IdempotencyKey)
+	}
+
+	// A retry returns the same operation rather than tripping over the
+	// cluster being busy with it.
+	second, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, opts)
+	if err != nil {
+		t.Fatalf("retried CreateOperation failed: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("retry created operation %s, want the original %s", second.ID, first.ID)
+	}
+	if n := len(engine.operations); n != 1 {
+		t.Errorf("got %d operations, want 1", n)
+	}
+
+	// The same key for a different change is a caller bug.
+	_, err = engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, opts)
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for a reused key, got: %v", err)
+	}
+
+	// Once the TTL has passed the key creates a new operation.
+	if err := engine.DeleteOperation(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteOperation failed: %v", err)
+	}
+	stale, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{IdempotencyKey: "exec-5678"})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	stale.CreatedAt = time.Now().Add(-25 * time.Hour)
+	stale.State = types.StateCompleted
+	fresh, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{IdempotencyKey: "exec-5678"})
+	if err != nil {
+		t.Fatalf("CreateOperation after the TTL failed: %v", err)
+	}
+	if fresh.ID == stale.ID {
+		t.Error("an expired key should create a new operation")
+	}
+}
+
+func TestCreateOperation_IdempotencyKeyNotBurnedByFailure(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+	opts := CreateOptions{IdempotencyKey: "exec-retry"}
+
+	_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-single", "us-east-1", json.RawMessage(`{}`), opts)
+	if err == nil {
+		t.Fatal("expected the create without a target instance type to fail")
+	}
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge"}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-single", "us-east-1", params, opts)
+	if err != nil {
+		t.Fatalf("create with a corrected request should reuse the key: %v", err)
+	}
+	if op.IdempotencyKey != opts.IdempotencyKey {
+		t.Errorf("IdempotencyKey = %q, want %q", op.IdempotencyKey, opts.IdempotencyKey)
+	}
+}
+
+func TestCreateOperation_IdempotencyKeyInFlight(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Another create holding the key is still building its plan.
+	if existing, err := engine.claimIdempotencyKey("exec-busy", types.OperationTypeInstanceCycle, "demo-single", "us-east-1"); err != nil || existing != nil {
+		t.Fatalf("claimIdempotencyKey = %v, %v", existing, err)
+	}
+
+	_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{IdempotencyKey: "exec-busy"})
+	if !errors.Is(err, internalerrors.ErrConcurrentModification) {
+		t.Fatalf("expected ErrConcurrentModification, got: %v", err)
+	}
+
+	engine.releaseIdempotencyKey("exec-busy")
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{IdempotencyKey: "exec-busy"}); err != nil {
+		t.Errorf("CreateOperation after release failed: %v", err)
+	}
+}
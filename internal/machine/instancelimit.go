@@ -0,0 +1,48 @@
+package machine
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// clusterInstanceLimit returns the most instances a cluster may have.
+func (e *Engine) clusterInstanceLimit() int {
+	if e.instanceLimit > 0 {
+		return e.instanceLimit
+	}
+	return constants.DefaultClusterInstanceLimit
+}
+
+// tempInstanceRoomProblem describes why the cluster has no room for a temp
+// instance, or returns "" if it has. Autoscaled readers count against the
+// limit like any other member, so the advice names them when there are some.
+func (e *Engine) tempInstanceRoomProblem(info *types.ClusterInfo) string {
+	limit := e.clusterInstanceLimit()
+	if len(info.Instances) < limit {
+		return ""
+	}
+
+	var autoscaled int
+	for _, inst := range info.Instances {
+		if inst.IsAutoScaled {
+			autoscaled++
+		}
+	}
+	msg := fmt.Sprintf("cluster %s has %d of its %d allowed instances, leaving no room for a temp instance; retry with skip_temp_instance",
+		info.ClusterID, len(info.Instances), limit)
+	if autoscaled > 0 {
+		msg += fmt.Sprintf(", or lower the autoscaling minimum to remove some of its %d autoscaled readers first", autoscaled)
+	}
+	return msg
+}
+
+// instanceLimitError pauses the operation for an operator when a temp
+// instance cannot be added. The error matches both ErrClusterInstanceLimit
+// and ErrInterventionRequired.
+func instanceLimitError(err error, advice string) error {
+	return errors.Mark(errors.Wrap(err, advice), internalerrors.ErrInterventionRequired)
+}
@@ -0,0 +1,123 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestHandleCreateTempInstance_ClusterAtCapacity(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup leaves the cluster without room for the temp instance.
+		setup func(t *testing.T, engine *Engine, mockState *mock.State)
+	}{
+		{
+			name: "configured limit",
+			setup: func(t *testing.T, engine *Engine, mockState *mock.State) {
+				cluster, _ := mockState.GetCluster("demo-multi")
+				engine.instanceLimit = len(cluster.Members)
+			},
+		},
+		{
+			// The engine allows more than RDS does, so only the create fails.
+			name: "cluster full in RDS",
+			setup: func(t *testing.T, engine *Engine, mockState *mock.State) {
+				engine.instanceLimit = 100
+				cluster, _ := mockState.GetCluster("demo-multi")
+				for i := len(cluster.Members); i < mock.MaxClusterInstances; i++ {
+					err := mockState.CreateInstance(&mock.MockInstance{
+						ID:           fmt.Sprintf("demo-multi-filler-%d", i),
+						ClusterID:    "demo-multi",
+						InstanceType: "db.r6g.large",
+						IsAutoScaled: true,
+					})
+					if err != nil {
+						t.Fatalf("CreateInstance failed: %v", err)
+					}
+				}
+			},
+		},
+		{
+			name: "account instance quota",
+			setup: func(t *testing.T, engine *Engine, mockState *mock.State) {
+				mockState.Faults().AddFault(mock.Fault{
+					Type:        mock.FaultTypeAPIError,
+					Action:      "CreateDBInstance",
+					Probability: 1.0,
+					ErrorCode:   "InstanceQuotaExceeded",
+					ErrorMsg:    "Cannot create more than 40 DB instances",
+					Enabled:     true,
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, mockState, cleanup := testEngineWithMockState(t)
+			defer cleanup()
+			tt.setup(t, engine, mockState)
+
+			op := &types.Operation{ID: "test-temp-full", ClusterID: "demo-multi", Region: "us-east-1"}
+			params, _ := json.Marshal(map[string]string{
+				"instance_type": "db.r6g.large",
+				"engine":        "aurora-postgresql",
+			})
+			step := &types.Step{Action: "create_temp_instance", Parameters: params}
+
+			err := engine.handleCreateTempInstance(context.Background(), op, step)
+			if !errors.Is(err, internalerrors.ErrClusterInstanceLimit) {
+				t.Fatalf("expected ErrClusterInstanceLimit, got: %v", err)
+			}
+			if !errors.Is(err, internalerrors.ErrInterventionRequired) {
+				t.Errorf("expected the limit to pause for intervention, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), "skip_temp_instance") {
+				t.Errorf("error %q should suggest skip_temp_instance", err)
+			}
+			if _, ok := mockState.GetInstance(rds.GenerateTempInstanceID(op.ClusterID, op.ID)); ok {
+				t.Error("temp instance should not have been created")
+			}
+		})
+	}
+}
+
+func TestBuildInstanceCycleSteps_WarnsWhenClusterFull(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	cluster, _ := mockState.GetCluster("demo-multi")
+	engine.instanceLimit = len(cluster.Members)
+
+	op, err := engine.CreateOperation(context.Background(), types.OperationTypeInstanceCycle, "demo-multi", "us-east-1",
+		nil, CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	var warned bool
+	for _, warning := range op.Warnings {
+		warned = warned || strings.Contains(warning, "no room for a temp instance")
+	}
+	if !warned {
+		t.Errorf("expected a capacity warning, got %v", op.Warnings)
+	}
+
+	skip, err := engine.CreateOperation(context.Background(), types.OperationTypeInstanceCycle, "demo-single", "us-east-1",
+		json.RawMessage(`{"skip_temp_instance":true}`), CreateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	for _, warning := range skip.Warnings {
+		if strings.Contains(warning, "no room") {
+			t.Errorf("unexpected capacity warning without a temp instance: %s", warning)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package machine
+
+import (
+	"sort"
+	"time"
+
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// PendingInterventions lists every paused operation with the actions an
+// operator can take on it, longest-waiting first. Operations persisted in the
+// store are loaded into the engine at startup, so this covers them as well.
+func (e *Engine) PendingInterventions() []types.PendingIntervention {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	var pending []types.PendingIntervention
+	for _, op := range e.operations {
+		if op.State != types.StatePaused {
+			continue
+		}
+
+		// Operations paused before PausedAt was recorded fall back to their
+		// last update, which is when they were paused unless edited since.
+		pausedAt := op.UpdatedAt
+		if op.PausedAt != nil {
+			pausedAt = *op.PausedAt
+		}
+
+		item := types.PendingIntervention{
+			OperationID:    op.ID,
+			Type:           op.Type,
+			ClusterID:      op.ClusterID,
+			Region:         op.Region,
+			PauseReason:    op.PauseReason,
+			StepIndex:      op.CurrentStepIndex,
+			Options:        resumeOptions(op),
+			PausedAt:       pausedAt,
+			WaitingSeconds: now.Sub(pausedAt).Seconds(),
+		}
+		if op.CurrentStepIndex < len(op.Steps) {
+			item.StepName = op.Steps[op.CurrentStepIndex].Name
+		}
+		pending = append(pending, item)
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].PausedAt.Before(pending[j].PausedAt)
+	})
+	return pending
+}
+
+// checkActions are the steps that only inspect the cluster. When one pauses
+// the operation nothing has changed yet, so the operator either fixes the
+// problem and re-runs it, overrides it, or abandons the operation.
+var checkActions = map[string]bool{
+	"check_upgrade_prerequisites": true,
+	"preflight_check":             true,
+	"check_alarms":                true,
+}
+
+// pausedOnCheck reports whether the operation is waiting on a failed check step.
+func pausedOnCheck(op *types.Operation) bool {
+	if op.CurrentStepIndex >= len(op.Steps) {
+		return false
+	}
+	step := op.Steps[op.CurrentStepIndex]
+	return checkActions[step.Action] && step.State == types.StepStateWaiting
+}
+
+// resumeOptions returns the ResumeOperation actions a paused operation accepts.
+// Callers hold e.mu.
+func resumeOptions(op *types.Operation) []string {
+	if pausedOnCheck(op) {
+		return []string{"continue", "override", "abort"}
+	}
+	options := []string{"continue"}
+	if op.CurrentStepIndex >= len(op.Steps) || op.Steps[op.CurrentStepIndex].State != types.StepStateInProgress {
+		options = append(options, "rollback")
+	}
+	if _, err := retryableCleanupStep(op); err == nil {
+		options = append(options, "retry_cleanup")
+	}
+	return append(options, "mark_complete", "abort")
+}
+
+// ResumeOptions returns the decisions a paused operation accepts right now.
+func (e *Engine) ResumeOptions(id string) ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	op, ok := e.operations[id]
+	if !ok {
+		return nil, internalerrors.ErrOperationNotFound
+	}
+	if op.State != types.StatePaused {
+		return nil, internalerrors.ErrOperationNotPaused
+	}
+	return resumeOptions(op), nil
+}
@@ -0,0 +1,142 @@
+package machine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestPendingInterventions(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+
+	ops := []*types.Operation{
+		{
+			ID:          "op-step-failed",
+			Type:        types.OperationTypeInstanceTypeChange,
+			State:       types.StatePaused,
+			ClusterID:   "cluster-a",
+			Region:      "us-east-1",
+			PauseReason: "Step failed: Modify reader - boom",
+			Steps: []types.Step{
+				{ID: "s1", Name: "Modify writer", Action: "modify_instance", State: types.StepStateCompleted},
+				{ID: "s2", Name: "Modify reader", Action: "modify_instance", State: types.StepStateFailed},
+			},
+			CurrentStepIndex: 1,
+			PausedAt:         at(10 * time.Minute),
+			UpdatedAt:        now,
+		},
+		{
+			ID:          "op-cleanup",
+			Type:        types.OperationTypeEngineUpgrade,
+			State:       types.StatePaused,
+			ClusterID:   "cluster-b",
+			Region:      "us-west-2",
+			PauseReason: "Cleanup failed",
+			Steps: []types.Step{
+				{ID: "s1", Name: "Switchover", Action: "switchover_blue_green", State: types.StepStateCompleted},
+				{ID: "s2", Name: "Cleanup", Action: "cleanup_blue_green", State: types.StepStateWaiting},
+			},
+			CurrentStepIndex: 1,
+			PausedAt:         at(2 * time.Hour),
+			UpdatedAt:        now,
+		},
+		{
+			// Persisted before PausedAt existed; its last update is the pause.
+			ID:          "op-legacy",
+			Type:        types.OperationTypeInstanceCycle,
+			State:       types.StatePaused,
+			ClusterID:   "cluster-c",
+			PauseReason: "Paused by operator",
+			Steps:       []types.Step{{ID: "s1", Name: "Reboot reader", Action: "reboot_instance", State: types.StepStatePending}},
+			UpdatedAt:   now.Add(-30 * time.Minute),
+		},
+		{ID: "op-done", Type: types.OperationTypeInstanceCycle, State: types.StateCompleted, ClusterID: "cluster-d", UpdatedAt: now},
+		{ID: "op-created", Type: types.OperationTypeInstanceCycle, State: types.StateCreated, ClusterID: "cluster-e", UpdatedAt: now},
+	}
+	for _, op := range ops {
+		op.CreatedAt = now.Add(-3 * time.Hour)
+		if err := store.SaveOperation(ctx, op); err != nil {
+			t.Fatalf("SaveOperation failed: %v", err)
+		}
+	}
+
+	engine := NewEngine(EngineConfig{Store: store})
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+
+	pending := engine.PendingInterventions()
+	var ids []string
+	for _, p := range pending {
+		ids = append(ids, p.OperationID)
+	}
+	if want := []string{"op-cleanup", "op-legacy", "op-step-failed"}; !slices.Equal(ids, want) {
+		t.Fatalf("interventions = %v, want %v (longest waiting first)", ids, want)
+	}
+
+	tests := []struct {
+		index       int
+		cluster     string
+		stepName    string
+		reason      string
+		waiting     time.Duration
+		options     []string
+		withoutOpts []string
+	}{
+		{0, "cluster-b", "Cleanup", "Cleanup failed", 2 * time.Hour, []string{"continue", "rollback", "retry_cleanup", "mark_complete", "abort"}, nil},
+		{1, "cluster-c", "Reboot reader", "Paused by operator", 30 * time.Minute, []string{"continue", "rollback", "mark_complete", "abort"}, []string{"retry_cleanup"}},
+		{2, "cluster-a", "Modify reader", "Step failed: Modify reader - boom", 10 * time.Minute, []string{"continue", "rollback", "mark_complete", "abort"}, []string{"retry_cleanup"}},
+	}
+	for _, tt := range tests {
+		p := pending[tt.index]
+		t.Run(p.OperationID, func(t *testing.T) {
+			if p.ClusterID != tt.cluster || p.StepName != tt.stepName || p.PauseReason != tt.reason {
+				t.Errorf("got cluster=%q step=%q reason=%q", p.ClusterID, p.StepName, p.PauseReason)
+			}
+			if !slices.Equal(p.Options, tt.options) {
+				t.Errorf("options = %v, want %v", p.Options, tt.options)
+			}
+			// Allow for the time the test itself takes.
+			waiting := time.Duration(p.WaitingSeconds * float64(time.Second))
+			if waiting < tt.waiting || waiting > tt.waiting+time.Minute {
+				t.Errorf("waiting = %v, want about %v", waiting, tt.waiting)
+			}
+		})
+	}
+}
+
+func TestPendingInterventions_RecordsPauseTime(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", nil, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.ResetOperationToStep(ctx, op.ID, 0); err != nil {
+		t.Fatalf("ResetOperationToStep failed: %v", err)
+	}
+
+	pending := engine.PendingInterventions()
+	if len(pending) != 1 || pending[0].OperationID != op.ID {
+		t.Fatalf("interventions = %+v, want the reset operation", pending)
+	}
+	if op.PausedAt == nil || !pending[0].PausedAt.Equal(*op.PausedAt) {
+		t.Errorf("PausedAt = %v, want the time recorded on the operation (%v)", pending[0].PausedAt, op.PausedAt)
+	}
+}
@@ -0,0 +1,126 @@
+package machine
+
+import (
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Operations returns every operation with its steps, in no particular order.
+func (e *Engine) Operations() []*types.Operation {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ops := make([]*types.Operation, 0, len(e.operations))
+	for _, op := range e.operations {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// ListOperations returns summaries of the operations matching the filter,
+// newest first. With a limit, the page's NextCursor is passed back in the
+// filter to fetch the following page.
+func (e *Engine) ListOperations(filter types.OperationFilter) (*types.OperationPage, error) {
+	if filter.Limit < 0 {
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "limit must not be negative, got %d", filter.Limit)
+	}
+	var after *operationCursor
+	if filter.Cursor != "" {
+		c, err := decodeOperationCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &c
+	}
+
+	e.mu.RLock()
+	summaries := make([]types.OperationSummary, 0, len(e.operations))
+	for _, op := range e.operations {
+		if filter.State != "" && op.State != filter.State {
+			continue
+		}
+		if filter.ClusterID != "" && op.ClusterID != filter.ClusterID {
+			continue
+		}
+		if filter.Type != "" && op.Type != filter.Type {
+			continue
+		}
+		summaries = append(summaries, op.Summary())
+	}
+	e.mu.RUnlock()
+
+	// Newest first; the ID breaks ties so the order, and so the cursor, is stable
+	slices.SortFunc(summaries, func(a, b types.OperationSummary) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(b.ID, a.ID)
+	})
+
+	if after != nil {
+		start := len(summaries)
+		for i, s := range summaries {
+			if after.before(s) {
+				start = i
+				break
+			}
+		}
+		summaries = summaries[start:]
+	}
+
+	page := &types.OperationPage{Operations: summaries}
+	if filter.Limit > 0 && len(summaries) > filter.Limit {
+		page.Operations = summaries[:filter.Limit]
+		last := page.Operations[filter.Limit-1]
+		page.NextCursor = operationCursor{CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+	}
+	return page, nil
+}
+
+// operationCursor marks the last operation of a listing page. Listings are
+// ordered by creation time and then ID, both descending, so the next page
+// starts at the first operation ordered after the cursor. Operations created
+// or deleted between pages do not shift it.
+type operationCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// encode returns the cursor as an opaque URL-safe string.
+func (c operationCursor) encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// before reports whether the cursor's operation is listed before s.
+func (c operationCursor) before(s types.OperationSummary) bool {
+	if !s.CreatedAt.Equal(c.CreatedAt) {
+		return s.CreatedAt.Before(c.CreatedAt)
+	}
+	return s.ID < c.ID
+}
+
+func decodeOperationCursor(cursor string) (operationCursor, error) {
+	invalid := errors.Wrapf(internalerrors.ErrInvalidParameter, "invalid cursor %q", cursor)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return operationCursor{}, invalid
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return operationCursor{}, invalid
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return operationCursor{}, invalid
+	}
+	return operationCursor{CreatedAt: time.Unix(0, n), ID: id}, nil
+}
@@ -0,0 +1,119 @@
+package machine
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestListOperations(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+
+	// Seven operations a minute apart; op-3 and op-4 share a creation time.
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		created := base.Add(time.Duration(i) * time.Minute)
+		if i == 4 {
+			created = base.Add(3 * time.Minute)
+		}
+		op := &types.Operation{
+			ID:        fmt.Sprintf("op-%d", i),
+			Type:      types.OperationTypeInstanceCycle,
+			State:     types.StateCompleted,
+			ClusterID: "cluster-a",
+			Steps:     make([]types.Step, 3),
+			CreatedAt: created,
+		}
+		if i%2 == 1 {
+			op.Type = types.OperationTypeEngineUpgrade
+			op.ClusterID = "cluster-b"
+		}
+		if i == 6 {
+			op.State = types.StateRunning
+		}
+		engine.operations[op.ID] = op
+	}
+
+	ids := func(page *types.OperationPage) []string {
+		var got []string
+		for _, s := range page.Operations {
+			got = append(got, s.ID)
+		}
+		return got
+	}
+
+	t.Run("newest first with summaries", func(t *testing.T) {
+		page, err := engine.ListOperations(types.OperationFilter{})
+		if err != nil {
+			t.Fatalf("ListOperations failed: %v", err)
+		}
+		want := []string{"op-6", "op-5", "op-4", "op-3", "op-2", "op-1", "op-0"}
+		if fmt.Sprint(ids(page)) != fmt.Sprint(want) {
+			t.Errorf("got %v, want %v", ids(page), want)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("unexpected next cursor %q without a limit", page.NextCursor)
+		}
+		if page.Operations[0].StepCount != 3 {
+			t.Errorf("step count = %d, want 3", page.Operations[0].StepCount)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		tests := []struct {
+			filter types.OperationFilter
+			want   []string
+		}{
+			{types.OperationFilter{ClusterID: "cluster-b"}, []string{"op-5", "op-3", "op-1"}},
+			{types.OperationFilter{Type: types.OperationTypeInstanceCycle}, []string{"op-6", "op-4", "op-2", "op-0"}},
+			{types.OperationFilter{State: types.StateRunning}, []string{"op-6"}},
+			{types.OperationFilter{ClusterID: "cluster-a", State: types.StateCompleted}, []string{"op-4", "op-2", "op-0"}},
+			{types.OperationFilter{ClusterID: "missing"}, nil},
+		}
+		for _, tt := range tests {
+			page, err := engine.ListOperations(tt.filter)
+			if err != nil {
+				t.Fatalf("ListOperations(%+v) failed: %v", tt.filter, err)
+			}
+			if fmt.Sprint(ids(page)) != fmt.Sprint(tt.want) {
+				t.Errorf("ListOperations(%+v) = %v, want %v", tt.filter, ids(page), tt.want)
+			}
+		}
+	})
+
+	t.Run("cursor pages through every operation once", func(t *testing.T) {
+		var got []string
+		filter := types.OperationFilter{Limit: 2}
+		for pages := 0; ; pages++ {
+			if pages > 4 {
+				t.Fatal("pagination did not terminate")
+			}
+			page, err := engine.ListOperations(filter)
+			if err != nil {
+				t.Fatalf("ListOperations failed: %v", err)
+			}
+			got = append(got, ids(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		want := []string{"op-6", "op-5", "op-4", "op-3", "op-2", "op-1", "op-0"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("paged through %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid cursor is rejected", func(t *testing.T) {
+		for _, cursor := range []string{"not base64!", "bm8tY29sb24"} {
+			_, err := engine.ListOperations(types.OperationFilter{Cursor: cursor})
+			if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+				t.Errorf("cursor %q: expected ErrInvalidParameter, got %v", cursor, err)
+			}
+		}
+	})
+}
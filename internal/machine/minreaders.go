@@ -0,0 +1,91 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// validateMinAvailableReaders checks an operation's min_available_readers.
+func validateMinAvailableReaders(minReaders int) error {
+	if minReaders < 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"min_available_readers must not be negative, got %d", minReaders)
+	}
+	return nil
+}
+
+// operationMinAvailableReaders returns how many other readers must be
+// available before the operation takes a reader offline. Zero disables the
+// guard.
+func operationMinAvailableReaders(op *types.Operation) int {
+	var params struct {
+		MinAvailableReaders int `json:"min_available_readers"`
+	}
+	if len(op.Parameters) > 0 {
+		_ = json.Unmarshal(op.Parameters, &params)
+	}
+	return max(params.MinAvailableReaders, 0)
+}
+
+// availableReaders returns the cluster's available readers other than
+// instanceID, and whether instanceID is currently a reader. An instance not
+// in the cluster counts as a reader: only the writer is exempt.
+func availableReaders(info *types.ClusterInfo, instanceID string) ([]string, bool) {
+	var available []string
+	isReader := true
+	for _, inst := range info.Instances {
+		if inst.InstanceID == instanceID {
+			isReader = inst.Role != "writer"
+			continue
+		}
+		if inst.Role == "reader" && inst.Status == "available" {
+			available = append(available, inst.InstanceID)
+		}
+	}
+	return available, isReader
+}
+
+// awaitReaderFloor holds back a step that is about to take the reader
+// instanceID offline until at least min_available_readers other readers are
+// available, e.g. while a reader modified by an earlier step is still
+// coming back. The writer is not held back; failing over is how it is taken
+// offline. If the floor is not met within the step's wait timeout the
+// operation pauses, since carrying on would leave the cluster with less read
+// capacity than asked for.
+func (e *Engine) awaitReaderFloor(ctx context.Context, op *types.Operation, step *types.Step, rdsClient *rds.Client, instanceID string) error {
+	minReaders := operationMinAvailableReaders(op)
+	if minReaders == 0 {
+		return nil
+	}
+
+	var available []string
+	check := func(ctx context.Context) (bool, error) {
+		info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+		if err != nil {
+			return false, transient(errors.Wrap(err, "get cluster info"))
+		}
+		var isReader bool
+		available, isReader = availableReaders(info, instanceID)
+		return !isReader || len(available) >= minReaders, nil
+	}
+	if ok, err := check(ctx); err == nil && ok {
+		return nil
+	}
+
+	e.addEvent(op.ID, "info", fmt.Sprintf("Waiting for %d readers besides %s to be available (%d now: %s)",
+		minReaders, instanceID, len(available), strings.Join(available, ", ")), nil)
+	err := e.newPoller(op, step).pollUntil(ctx, e.getWaitTimeout(op, step), check)
+	if errors.Is(err, internalerrors.ErrWaitTimeout) {
+		return errors.Wrapf(internalerrors.ErrInterventionRequired,
+			"only %d readers besides %s are available, below min_available_readers %d; restore reader capacity or lower the minimum, then continue",
+			len(available), instanceID, minReaders)
+	}
+	return err
+}
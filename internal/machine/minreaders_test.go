@@ -0,0 +1,119 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestReaderBatchSize_MinAvailableReaders(t *testing.T) {
+	info := &types.ClusterInfo{ClusterID: "c1", Instances: []types.InstanceInfo{
+		{InstanceID: "w", Role: "writer"},
+		{InstanceID: "r1", Role: "reader"},
+		{InstanceID: "r2", Role: "reader"},
+		{InstanceID: "r3", Role: "reader"},
+		{InstanceID: "r4", Role: "reader"},
+	}}
+
+	tests := []struct {
+		requested, minReaders, want int
+		wantWarning                 bool
+	}{
+		{requested: 3, minReaders: 0, want: 3},
+		{requested: 3, minReaders: 1, want: 3},
+		{requested: 3, minReaders: 2, want: 2, wantWarning: true},
+		{requested: 3, minReaders: 4, want: 1, wantWarning: true},
+		{requested: 0, minReaders: 4, want: 1},
+	}
+	for _, tt := range tests {
+		got, warning, err := readerBatchSize(tt.requested, tt.minReaders, info, "w")
+		if err != nil {
+			t.Fatalf("readerBatchSize(%d, %d) failed: %v", tt.requested, tt.minReaders, err)
+		}
+		if got != tt.want || (warning != "") != tt.wantWarning {
+			t.Errorf("readerBatchSize(%d, %d) = %d, %q; want %d (warning %v)", tt.requested, tt.minReaders, got, warning, tt.want, tt.wantWarning)
+		}
+		if tt.wantWarning && !containsAny(warning, "min_available_readers") {
+			t.Errorf("warning %q does not mention min_available_readers", warning)
+		}
+	}
+}
+
+func TestCreateOperation_NegativeMinAvailableReaders(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	for _, opType := range []types.OperationType{types.OperationTypeInstanceCycle, types.OperationTypeRebootCluster} {
+		_, err := engine.CreateOperation(context.Background(), opType, "demo-multi", "us-east-1",
+			json.RawMessage(`{"min_available_readers":-1}`), CreateOptions{DryRun: true})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("%s: expected ErrInvalidParameter, got: %v", opType, err)
+		}
+	}
+}
+
+func TestAwaitReaderFloor(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.defaultPollInterval = 20 * time.Millisecond
+	ctx := context.Background()
+
+	newOp := func(id string, minReaders int) *types.Operation {
+		params, _ := json.Marshal(types.RebootClusterParams{MinAvailableReaders: minReaders})
+		return &types.Operation{ID: id, ClusterID: "demo-multi", Region: "us-east-1", Parameters: params}
+	}
+	rebootStep := func(instanceID string) *types.Step {
+		params, _ := json.Marshal(map[string]string{"instance_id": instanceID})
+		return &types.Step{Name: "Reboot " + instanceID, Action: "reboot_instance", Parameters: params, TimeoutSeconds: 1}
+	}
+
+	if err := mockState.SetInstanceStatus("demo-multi-reader-2", "storage-full"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("pauses when the floor is not met", func(t *testing.T) {
+		op := newOp("op-floor-pause", 1)
+		err := engine.handleRebootInstance(ctx, op, rebootStep("demo-multi-reader-1"))
+		if !errors.Is(err, internalerrors.ErrInterventionRequired) || !containsAny(err.Error(), "min_available_readers 1") {
+			t.Fatalf("err = %v, want an intervention naming the minimum", err)
+		}
+		if inst, _ := mockState.GetInstance("demo-multi-reader-1"); inst.Status != "available" {
+			t.Errorf("reader rebooted despite the floor: %s", inst.Status)
+		}
+	})
+
+	t.Run("writer and disabled guard are not held", func(t *testing.T) {
+		rdsClient, err := engine.getRDSClient(ctx, newOp("op-floor-client", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.awaitReaderFloor(ctx, newOp("op-floor-writer", 1), rebootStep("demo-multi-writer"),
+			rdsClient, "demo-multi-writer"); err != nil {
+			t.Errorf("writer held back: %v", err)
+		}
+		if err := engine.awaitReaderFloor(ctx, newOp("op-floor-off", 0), rebootStep("demo-multi-reader-1"),
+			rdsClient, "demo-multi-reader-1"); err != nil {
+			t.Errorf("disabled guard held back: %v", err)
+		}
+	})
+
+	t.Run("waits for a reader to come back", func(t *testing.T) {
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_ = mockState.SetInstanceStatus("demo-multi-reader-2", "available")
+		}()
+		op := newOp("op-floor-wait", 1)
+		if err := engine.handleRebootInstance(ctx, op, rebootStep("demo-multi-reader-1")); err != nil {
+			t.Fatalf("handleRebootInstance failed: %v", err)
+		}
+		events, _ := engine.GetEvents(op.ID)
+		if len(events) == 0 || !containsAny(events[0].Message, "Waiting for 1 readers besides demo-multi-reader-1") {
+			t.Errorf("events = %+v, want the wait reported", events)
+		}
+	})
+}
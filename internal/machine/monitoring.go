@@ -0,0 +1,355 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// monitoringStepParams are the parameters of a modify_instance_monitoring
+// step. The wait step after it reads them back to know what to wait for.
+type monitoringStepParams struct {
+	InstanceID                         string `json:"instance_id"`
+	EnablePerformanceInsights          *bool  `json:"enable_performance_insights,omitempty"`
+	PerformanceInsightsRetentionPeriod *int32 `json:"performance_insights_retention_period,omitempty"`
+	MonitoringInterval                 *int32 `json:"monitoring_interval,omitempty"`
+	MonitoringRoleArn                  string `json:"monitoring_role_arn,omitempty"`
+}
+
+func (p monitoringStepParams) modifyParams() rds.ModifyInstanceMonitoringParams {
+	return rds.ModifyInstanceMonitoringParams{
+		InstanceID:                         p.InstanceID,
+		EnablePerformanceInsights:          p.EnablePerformanceInsights,
+		PerformanceInsightsRetentionPeriod: p.PerformanceInsightsRetentionPeriod,
+		MonitoringInterval:                 p.MonitoringInterval,
+		MonitoringRoleArn:                  p.MonitoringRoleArn,
+	}
+}
+
+// mismatches describes how the instance differs from the settings the step
+// asked for, or returns nil once all of them are in place.
+func (p monitoringStepParams) mismatches(inst *types.InstanceInfo) []string {
+	var out []string
+	if p.EnablePerformanceInsights != nil && inst.PerformanceInsightsEnabled != *p.EnablePerformanceInsights {
+		out = append(out, fmt.Sprintf("performance insights enabled is %t, waiting for %t",
+			inst.PerformanceInsightsEnabled, *p.EnablePerformanceInsights))
+	}
+	if p.PerformanceInsightsRetentionPeriod != nil && inst.PerformanceInsightsRetentionPeriod != *p.PerformanceInsightsRetentionPeriod {
+		out = append(out, fmt.Sprintf("performance insights retention is %d days, waiting for %d",
+			inst.PerformanceInsightsRetentionPeriod, *p.PerformanceInsightsRetentionPeriod))
+	}
+	if p.MonitoringInterval != nil && inst.MonitoringInterval != *p.MonitoringInterval {
+		out = append(out, fmt.Sprintf("monitoring interval is %ds, waiting for %ds",
+			inst.MonitoringInterval, *p.MonitoringInterval))
+	}
+	if p.MonitoringRoleArn != "" && inst.MonitoringRoleArn != p.MonitoringRoleArn {
+		out = append(out, fmt.Sprintf("monitoring role is %q, waiting for %q", inst.MonitoringRoleArn, p.MonitoringRoleArn))
+	}
+	return out
+}
+
+// validateMonitoringChange checks the requested settings on their own and
+// against each instance they would be applied to. Performance Insights
+// retention only exists while it is on, and Enhanced Monitoring cannot be
+// turned on without a role to publish with.
+func validateMonitoringChange(params types.MonitoringChangeParams, instances []*types.InstanceInfo) error {
+	if params.EnablePerformanceInsights == nil && params.PerformanceInsightsRetentionPeriod == nil &&
+		params.MonitoringInterval == nil && params.MonitoringRoleArn == "" {
+		return errors.Wrap(internalerrors.ErrInvalidParameter,
+			"set at least one of enable_performance_insights, performance_insights_retention_period, monitoring_interval or monitoring_role_arn")
+	}
+	if params.PerformanceInsightsRetentionPeriod != nil {
+		if err := rds.ValidatePerformanceInsightsRetention(*params.PerformanceInsightsRetentionPeriod); err != nil {
+			return err
+		}
+		if params.EnablePerformanceInsights != nil && !*params.EnablePerformanceInsights {
+			return errors.Wrap(internalerrors.ErrInvalidParameter,
+				"performance_insights_retention_period cannot be set while turning Performance Insights off")
+		}
+	}
+	if params.MonitoringInterval != nil {
+		if err := rds.ValidateMonitoringInterval(*params.MonitoringInterval); err != nil {
+			return err
+		}
+	}
+
+	var noPI, noRole []string
+	for _, inst := range instances {
+		if params.PerformanceInsightsRetentionPeriod != nil && params.EnablePerformanceInsights == nil && !inst.PerformanceInsightsEnabled {
+			noPI = append(noPI, inst.InstanceID)
+		}
+		if params.MonitoringInterval != nil && *params.MonitoringInterval > 0 && params.MonitoringRoleArn == "" && inst.MonitoringRoleArn == "" {
+			noRole = append(noRole, inst.InstanceID)
+		}
+	}
+	if len(noPI) > 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"performance insights is off on %s; set enable_performance_insights to change its retention", strings.Join(noPI, ", "))
+	}
+	if len(noRole) > 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s have no enhanced monitoring role; set monitoring_role_arn to turn it on", strings.Join(noRole, ", "))
+	}
+	return nil
+}
+
+// monitoringSteps returns the modify and wait steps for one instance.
+func monitoringSteps(params types.MonitoringChangeParams, instanceID, label string) ([]types.Step, error) {
+	modifyParams, err := json.Marshal(monitoringStepParams{
+		InstanceID:                         instanceID,
+		EnablePerformanceInsights:          params.EnablePerformanceInsights,
+		PerformanceInsightsRetentionPeriod: params.PerformanceInsightsRetentionPeriod,
+		MonitoringInterval:                 params.MonitoringInterval,
+		MonitoringRoleArn:                  params.MonitoringRoleArn,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal modify_instance_monitoring params for %s", instanceID)
+	}
+	waitParams, err := json.Marshal(map[string]string{
+		"instance_id": instanceID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "marshal wait_instance_available params for %s", instanceID)
+	}
+
+	return []types.Step{
+		{
+			ID:          uuid.New().String(),
+			Name:        "Modify monitoring on " + label,
+			Description: fmt.Sprintf("Set %s on %s", describeMonitoringChange(params), instanceID),
+			State:       types.StepStatePending,
+			Action:      "modify_instance_monitoring",
+			Parameters:  modifyParams,
+			MaxRetries:  2,
+		},
+		{
+			ID:          uuid.New().String(),
+			Name:        "Wait for " + label,
+			Description: fmt.Sprintf("Wait for instance %s to apply the monitoring settings", instanceID),
+			State:       types.StepStatePending,
+			Action:      "wait_instance_available",
+			Parameters:  waitParams,
+			MaxRetries:  1,
+		},
+	}, nil
+}
+
+// describeMonitoringChange summarises the requested settings for step
+// descriptions.
+func describeMonitoringChange(params types.MonitoringChangeParams) string {
+	var parts []string
+	if params.EnablePerformanceInsights != nil {
+		if *params.EnablePerformanceInsights {
+			parts = append(parts, "Performance Insights on")
+		} else {
+			parts = append(parts, "Performance Insights off")
+		}
+	}
+	if params.PerformanceInsightsRetentionPeriod != nil {
+		parts = append(parts, fmt.Sprintf("%d days retention", *params.PerformanceInsightsRetentionPeriod))
+	}
+	if params.MonitoringInterval != nil {
+		parts = append(parts, fmt.Sprintf("Enhanced Monitoring every %ds", *params.MonitoringInterval))
+	}
+	if params.MonitoringRoleArn != "" {
+		parts = append(parts, "monitoring role "+params.MonitoringRoleArn)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// buildMonitoringChangeSteps builds the steps for changing Performance
+// Insights and Enhanced Monitoring settings. The change does not restart
+// instances, but it briefly puts each one through modifying and a
+// configuring status, so instances are changed one at a time, readers
+// first. With failover_writer set, writes move to a reader while the writer
+// is changed and then move back. Instances that already have the settings
+// are left alone.
+func (e *Engine) buildMonitoringChangeSteps(ctx context.Context, op *types.Operation) error {
+	var params types.MonitoringChangeParams
+	if err := json.Unmarshal(op.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	client, err := e.clientManager.GetClient(ctx, op.Region)
+	if err != nil {
+		return errors.Wrap(err, "get RDS client")
+	}
+
+	info, err := client.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "get cluster info")
+	}
+
+	excludeSet, err := validateExcludedInstances(params.ExcludeInstances, info.Instances)
+	if err != nil {
+		return err
+	}
+
+	writer := findWriter(info.Instances)
+	if writer == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidState, "no writer instance found in cluster %s", op.ClusterID)
+	}
+
+	stepParams := monitoringStepParams{
+		EnablePerformanceInsights:          params.EnablePerformanceInsights,
+		PerformanceInsightsRetentionPeriod: params.PerformanceInsightsRetentionPeriod,
+		MonitoringInterval:                 params.MonitoringInterval,
+		MonitoringRoleArn:                  params.MonitoringRoleArn,
+	}
+	var readers, changedReaders, targets []*types.InstanceInfo
+	var unchanged []string
+	for i := range info.Instances {
+		inst := &info.Instances[i]
+		if inst.IsAutoScaled || excludeSet[inst.InstanceID] {
+			continue
+		}
+		if inst.InstanceID != writer.InstanceID {
+			readers = append(readers, inst)
+		}
+		if len(stepParams.mismatches(inst)) == 0 {
+			unchanged = append(unchanged, inst.InstanceID)
+			continue
+		}
+		targets = append(targets, inst)
+		if inst.InstanceID != writer.InstanceID {
+			changedReaders = append(changedReaders, inst)
+		}
+	}
+
+	if err := validateMonitoringChange(params, targets); err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"every instance in cluster %s already has the requested monitoring settings", op.ClusterID)
+	}
+	if len(unchanged) > 0 {
+		op.Warnings = append(op.Warnings, fmt.Sprintf(
+			"%s already have the requested monitoring settings and are not modified", strings.Join(unchanged, ", ")))
+	}
+	changeWriter := len(changedReaders) < len(targets)
+	if changeWriter && params.FailoverWriter && len(readers) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster %s has no reader to fail over to before changing the writer; unset failover_writer to change it in place", op.ClusterID)
+	}
+
+	steps := []types.Step{{
+		ID:          uuid.New().String(),
+		Name:        "Get cluster info",
+		Description: "Get current cluster state before changing monitoring settings",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	}}
+	steps = append(steps, preflightCheckStep(false))
+
+	for i, reader := range changedReaders {
+		readerSteps, err := monitoringSteps(params, reader.InstanceID, fmt.Sprintf("reader %d", i+1))
+		if err != nil {
+			return err
+		}
+		readerSteps[0].Rationale = instanceChangeRationale(reader.InstanceID, writer.InstanceID, false, "modified")
+		steps = append(steps, readerSteps...)
+	}
+
+	if changeWriter {
+		writerSteps, err := monitoringSteps(params, writer.InstanceID, "writer")
+		if err != nil {
+			return err
+		}
+		writerSteps[0].Rationale = fmt.Sprintf("Writer %s is not excluded; the change does not restart it, so it is modified in place", writer.InstanceID)
+		if !params.FailoverWriter {
+			steps = append(steps, writerSteps...)
+		} else {
+			writerSteps[0].Rationale = fmt.Sprintf("Writer %s is not excluded and failover_writer is set, so it is modified while %s serves writes",
+				writer.InstanceID, readers[0].InstanceID)
+			failoverParams, err := json.Marshal(map[string]string{
+				"instance_id": readers[0].InstanceID,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "marshal failover params for %s", readers[0].InstanceID)
+			}
+			failbackParams, err := json.Marshal(map[string]string{
+				"instance_id": writer.InstanceID,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "marshal failover params for %s", writer.InstanceID)
+			}
+			steps = append(steps,
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Failover to reader",
+					Description: fmt.Sprintf("Promote reader %s to writer", readers[0].InstanceID),
+					Rationale:   fmt.Sprintf("failover_writer is set, so writes move off %s before it is modified", writer.InstanceID),
+					State:       types.StepStatePending,
+					Action:      "failover_to_instance",
+					Parameters:  failoverParams,
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for failover",
+					Description: "Wait for cluster to stabilize after failover",
+					State:       types.StepStatePending,
+					Action:      "wait_cluster_available",
+					MaxRetries:  1,
+				},
+			)
+			steps = append(steps, writerSteps...)
+			steps = append(steps,
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Failover back to original writer",
+					Description: "Restore original writer: " + writer.InstanceID,
+					State:       types.StepStatePending,
+					Action:      "failover_to_instance",
+					Parameters:  failbackParams,
+					MaxRetries:  1,
+				},
+				types.Step{
+					ID:          uuid.New().String(),
+					Name:        "Wait for final failover",
+					Description: "Wait for cluster to stabilize",
+					State:       types.StepStatePending,
+					Action:      "wait_cluster_available",
+					MaxRetries:  1,
+				},
+			)
+		}
+	}
+
+	steps = append(steps, types.Step{
+		ID:          uuid.New().String(),
+		Name:        "Verify cluster",
+		Description: "Verify all instances are available",
+		State:       types.StepStatePending,
+		Action:      "get_cluster_info",
+		MaxRetries:  3,
+	})
+
+	op.Steps = steps
+	return nil
+}
+
+// handleModifyInstanceMonitoring changes one instance's monitoring settings.
+// The wait step that follows checks they were applied.
+func (e *Engine) handleModifyInstanceMonitoring(ctx context.Context, op *types.Operation, step *types.Step) error {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	var params monitoringStepParams
+	if err := json.Unmarshal(step.Parameters, &params); err != nil {
+		return errors.Wrap(err, "unmarshal params")
+	}
+
+	return rdsClient.ModifyInstanceMonitoring(ctx, params.modifyParams())
+}
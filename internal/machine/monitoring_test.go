@@ -0,0 +1,174 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestBuildMonitoringChangeSteps(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	on := true
+	retention, badRetention := int32(93), int32(90)
+	interval := int32(60)
+	tests := []struct {
+		name      string
+		params    types.MonitoringChangeParams
+		wantErr   string
+		wantSteps []string // "action:instance_id" for modifies and failovers
+	}{
+		{
+			name:   "writer modified in place after readers",
+			params: types.MonitoringChangeParams{PerformanceInsightsRetentionPeriod: &retention},
+			wantSteps: []string{
+				"modify_instance_monitoring:demo-multi-reader-1",
+				"modify_instance_monitoring:demo-multi-reader-2",
+				"modify_instance_monitoring:demo-multi-writer",
+			},
+		},
+		{
+			name:   "writer modified behind a failover",
+			params: types.MonitoringChangeParams{PerformanceInsightsRetentionPeriod: &retention, FailoverWriter: true},
+			wantSteps: []string{
+				"modify_instance_monitoring:demo-multi-reader-1",
+				"modify_instance_monitoring:demo-multi-reader-2",
+				"failover_to_instance:demo-multi-reader-1",
+				"modify_instance_monitoring:demo-multi-writer",
+				"failover_to_instance:demo-multi-writer",
+			},
+		},
+		{
+			name: "excluded reader left alone",
+			params: types.MonitoringChangeParams{
+				PerformanceInsightsRetentionPeriod: &retention,
+				ExcludeInstances:                   []string{"demo-multi-reader-2"},
+			},
+			wantSteps: []string{
+				"modify_instance_monitoring:demo-multi-reader-1",
+				"modify_instance_monitoring:demo-multi-writer",
+			},
+		},
+		{
+			name:    "nothing requested",
+			wantErr: "set at least one",
+		},
+		{
+			name:    "retention not allowed",
+			params:  types.MonitoringChangeParams{PerformanceInsightsRetentionPeriod: &badRetention},
+			wantErr: "multiple of 31",
+		},
+		{
+			name:    "enhanced monitoring without a role",
+			params:  types.MonitoringChangeParams{MonitoringInterval: &interval},
+			wantErr: "set monitoring_role_arn",
+		},
+		{
+			name:    "already at the requested settings",
+			params:  types.MonitoringChangeParams{EnablePerformanceInsights: &on},
+			wantErr: "already has the requested monitoring settings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(tt.params)
+			op := &types.Operation{
+				ID:         "test-monitoring-change",
+				Type:       types.OperationTypeMonitoringChange,
+				ClusterID:  "demo-multi",
+				Region:     "us-east-1",
+				Parameters: params,
+			}
+
+			err := engine.buildMonitoringChangeSteps(context.Background(), op)
+			if tt.wantErr != "" {
+				if !errors.Is(err, internalerrors.ErrInvalidParameter) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected ErrInvalidParameter containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for i, step := range op.Steps {
+				if step.Action != "modify_instance_monitoring" && step.Action != "failover_to_instance" {
+					continue
+				}
+				var p struct {
+					InstanceID string `json:"instance_id"`
+				}
+				_ = json.Unmarshal(step.Parameters, &p)
+				got = append(got, step.Action+":"+p.InstanceID)
+
+				if step.Action == "modify_instance_monitoring" {
+					next := op.Steps[i+1]
+					if next.Action != "wait_instance_available" || !strings.Contains(string(next.Parameters), p.InstanceID) {
+						t.Errorf("modify of %s not followed by a wait for it", p.InstanceID)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.wantSteps) {
+				t.Errorf("steps = %v, want %v", got, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestMonitoringChange_WaitsForSettings(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	off := false
+	if err := mockState.ModifyInstanceMonitoring("demo-multi-reader-2", mock.MonitoringSettings{EnablePerformanceInsights: &off}); err != nil {
+		t.Fatalf("ModifyInstanceMonitoring failed: %v", err)
+	}
+
+	on := true
+	retention, interval := int32(31), int32(15)
+	role := "arn:aws:iam::123456789012:role/rds-monitoring"
+	params, _ := json.Marshal(types.MonitoringChangeParams{
+		EnablePerformanceInsights:          &on,
+		PerformanceInsightsRetentionPeriod: &retention,
+		MonitoringInterval:                 &interval,
+		MonitoringRoleArn:                  role,
+	})
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if inst, _ := mockState.GetInstance("demo-multi-reader-2"); inst.Status == "available" && !inst.PerformanceInsightsEnabled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("demo-multi-reader-2 did not turn Performance Insights off")
+		}
+	}
+	op, err := engine.CreateOperation(ctx, types.OperationTypeMonitoringChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StateCompleted)
+
+	for _, id := range []string{"demo-multi-writer", "demo-multi-reader-1", "demo-multi-reader-2"} {
+		inst, _ := mockState.GetInstance(id)
+		if !inst.PerformanceInsightsEnabled || inst.PerformanceInsightsRetentionDays() != 31 ||
+			inst.MonitoringInterval != 15 || inst.MonitoringRoleArn != role {
+			t.Errorf("%s: PI %v for %d days, monitoring every %ds with %q; want every setting applied",
+				id, inst.PerformanceInsightsEnabled, inst.PerformanceInsightsRetentionDays(), inst.MonitoringInterval, inst.MonitoringRoleArn)
+		}
+	}
+}
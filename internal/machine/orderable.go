@@ -0,0 +1,131 @@
+package machine
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+)
+
+// maxSuggestedClasses is how many alternatives an unorderable instance class
+// error lists.
+const maxSuggestedClasses = 5
+
+// orderableCache remembers the orderable instance classes per engine and
+// version while one plan is built, so validating several things against the
+// same version describes the options once.
+type orderableCache struct {
+	client *rds.Client
+	lists  map[string][]rds.OrderableInstanceType
+}
+
+func newOrderableCache(client *rds.Client) *orderableCache {
+	return &orderableCache{client: client, lists: make(map[string][]rds.OrderableInstanceType)}
+}
+
+// instanceTypes returns the instance classes orderable for an engine version.
+func (c *orderableCache) instanceTypes(ctx context.Context, engine, version string) ([]rds.OrderableInstanceType, error) {
+	key := engine + "/" + version
+	if list, ok := c.lists[key]; ok {
+		return list, nil
+	}
+	list, err := c.client.GetOrderableInstanceTypes(ctx, engine, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get orderable instance types for %s %s", engine, version)
+	}
+	c.lists[key] = list
+	return list, nil
+}
+
+// validateInstanceClass checks that an instance class can be ordered for an
+// engine version, and in a specific AZ when zone is set. An unorderable class
+// is rejected with the nearest classes that can be ordered.
+func (c *orderableCache) validateInstanceClass(ctx context.Context, class, engine, version, zone string) error {
+	orderable, err := c.instanceTypes(ctx, engine, version)
+	if err != nil {
+		return err
+	}
+
+	for _, it := range orderable {
+		if it.InstanceClass != class {
+			continue
+		}
+		if zone != "" && len(it.AvailabilityZones) > 0 && !slices.Contains(it.AvailabilityZones, zone) {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s cannot be created in %s; available AZs: %s", class, zone, strings.Join(it.AvailabilityZones, ", "))
+		}
+		return nil
+	}
+
+	classes := make([]string, 0, len(orderable))
+	for _, it := range orderable {
+		classes = append(classes, it.InstanceClass)
+	}
+	if len(classes) == 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s is not orderable for %s %s, which offers no provisioned instance classes", class, engine, version)
+	}
+	return errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"%s is not orderable for %s %s; nearest available classes: %s",
+		class, engine, version, strings.Join(nearestClasses(class, classes, maxSuggestedClasses), ", "))
+}
+
+// nearestClasses returns up to n of the candidates closest to class: the
+// same family first, then the same kind of family (db.t4g for db.t3), each
+// ordered by how far the size is from the requested one.
+func nearestClasses(class string, candidates []string, n int) []string {
+	family, size := splitInstanceClass(class)
+	distance := func(candidate string) float64 {
+		f, s := splitInstanceClass(candidate)
+		d := math.Abs(math.Log2(s) - math.Log2(size))
+		switch {
+		case f == family:
+		case f != "" && family != "" && f[0] == family[0]:
+			d += 10
+		default:
+			d += 100
+		}
+		return d
+	}
+
+	sorted := slices.Clone(candidates)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		if c := cmp.Compare(distance(a), distance(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return sorted[:min(n, len(sorted))]
+}
+
+// splitInstanceClass splits an instance class like db.r6g.2xlarge into its
+// family (r6g) and its size in xlarge units (2). Unknown sizes count as 1.
+func splitInstanceClass(class string) (string, float64) {
+	parts := strings.Split(class, ".")
+	if len(parts) != 3 {
+		return "", 1
+	}
+	family, size := parts[1], parts[2]
+	switch size {
+	case "micro":
+		return family, 1.0 / 16
+	case "small":
+		return family, 1.0 / 8
+	case "medium":
+		return family, 1.0 / 4
+	case "large":
+		return family, 1.0 / 2
+	case "xlarge":
+		return family, 1
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge")); err == nil && n > 0 {
+		return family, float64(n)
+	}
+	return family, 1
+}
@@ -0,0 +1,84 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestNearestClasses(t *testing.T) {
+	candidates := []string{
+		"db.r6g.large", "db.r6g.xlarge", "db.r6g.2xlarge", "db.r6g.16xlarge",
+		"db.r5.large", "db.t4g.medium", "db.t4g.large", "db.t4g.micro",
+	}
+	tests := []struct {
+		class string
+		want  []string
+	}{
+		{class: "db.t3.medium", want: []string{"db.t4g.medium", "db.t4g.large", "db.t4g.micro"}},
+		{class: "db.r6g.24xlarge", want: []string{"db.r6g.16xlarge", "db.r6g.2xlarge", "db.r6g.xlarge"}},
+		{class: "db.r6i.large", want: []string{"db.r5.large", "db.r6g.large", "db.r6g.xlarge"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			if got := nearestClasses(tt.class, candidates, 3); !slices.Equal(got, tt.want) {
+				t.Errorf("nearestClasses(%s) = %v, want %v", tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInstanceTypeChangeSteps_UnorderableClass(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.24xlarge"})
+	_, err := engine.CreateOperation(context.Background(), types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1",
+		params, CreateOptions{DryRun: true})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+	}
+	if want := "nearest available classes: db.r6g.16xlarge, db.r6g.12xlarge, db.r6g.8xlarge"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q should contain %q", err, want)
+	}
+
+	// The temp instance AZ is checked against the same options.
+	params, _ = json.Marshal(types.InstanceTypeChangeParams{
+		TargetInstanceType:           "db.r5.24xlarge",
+		TempInstanceAvailabilityZone: "us-east-1a",
+	})
+	_, err = engine.CreateOperation(context.Background(), types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1",
+		params, CreateOptions{DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot be created in us-east-1a") {
+		t.Errorf("expected the AZ to be rejected, got: %v", err)
+	}
+}
+
+func TestOrderableCache_ValidateAgainstVersion(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	client, err := engine.clientManager.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	cache := newOrderableCache(client)
+
+	if err := cache.validateInstanceClass(ctx, "db.t3.medium", "aurora-postgresql", "15.4", ""); err != nil {
+		t.Errorf("db.t3.medium should be orderable for 15.4: %v", err)
+	}
+	err = cache.validateInstanceClass(ctx, "db.t3.medium", "aurora-postgresql", "16.1", "")
+	if err == nil || !strings.Contains(err.Error(), "nearest available classes: db.t4g.medium") {
+		t.Errorf("expected db.t3.medium to be rejected for 16.1 in favour of db.t4g.medium, got: %v", err)
+	}
+	if len(cache.lists) != 2 {
+		t.Errorf("cache holds %d lists, want one per version", len(cache.lists))
+	}
+}
@@ -0,0 +1,123 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// ReconcileOrphans deletes the temp instances in a region left behind by
+// operations that will never clean them up: operations that have finished,
+// and, once the instance is older than the orphan TTL, operations this engine
+// does not know about, such as ones lost in a crash before they were stored.
+// Instances of unfinished operations are never touched, and neither is an
+// instance that is its cluster's writer. An instance that fails to delete is
+// reported and the sweep carries on.
+func (e *Engine) ReconcileOrphans(ctx context.Context, region string) (*types.OrphanReconcileResult, error) {
+	if region == "" {
+		region = e.defaultRegion
+	}
+	client, err := e.clientManager.GetClient(ctx, region)
+	if err != nil {
+		return nil, errors.Wrap(err, "get RDS client")
+	}
+
+	instances, err := client.ListTempInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if e.clock != nil {
+		now = e.clock.Now()
+	}
+
+	result := &types.OrphanReconcileResult{Region: region, Deleted: []string{}}
+	retain := func(instanceID, reason string) {
+		if result.Retained == nil {
+			result.Retained = make(map[string]string)
+		}
+		result.Retained[instanceID] = reason
+	}
+	clusters := make(map[string]*types.ClusterInfo)
+
+	for _, inst := range instances {
+		if reason := e.orphanRetainReason(inst, now); reason != "" {
+			retain(inst.InstanceID, reason)
+			continue
+		}
+
+		info, ok := clusters[inst.ClusterID]
+		if !ok {
+			info, err = client.GetClusterInfo(ctx, inst.ClusterID)
+			if err != nil {
+				retain(inst.InstanceID, fmt.Sprintf("could not check whether it is the writer of %s: %v", inst.ClusterID, err))
+				continue
+			}
+			clusters[inst.ClusterID] = info
+		}
+		if err := refuseWriterDelete(info, inst.InstanceID); err != nil {
+			e.logger.Warn("orphaned temp instance is its cluster's writer",
+				"instance_id", inst.InstanceID,
+				"cluster_id", inst.ClusterID,
+				"operation_id", inst.OperationID)
+			retain(inst.InstanceID, "it is the writer of "+inst.ClusterID+"; fail over to another instance before deleting it")
+			continue
+		}
+
+		if err := client.DeleteInstance(ctx, inst.InstanceID, ""); err != nil {
+			e.logger.Warn("failed to delete orphaned temp instance",
+				"instance_id", inst.InstanceID,
+				"region", region,
+				"error", err)
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[inst.InstanceID] = err.Error()
+			continue
+		}
+		e.logger.Info("deleted orphaned temp instance",
+			"instance_id", inst.InstanceID,
+			"cluster_id", inst.ClusterID,
+			"operation_id", inst.OperationID)
+		result.Deleted = append(result.Deleted, inst.InstanceID)
+	}
+	return result, nil
+}
+
+// orphanRetainReason returns why a temp instance must be kept, or "" if it
+// is an orphan.
+func (e *Engine) orphanRetainReason(inst rds.TempInstanceInfo, now time.Time) string {
+	if inst.Status == "deleting" {
+		return "already being deleted"
+	}
+
+	e.mu.RLock()
+	op, known := e.operations[inst.OperationID]
+	var state types.OperationState
+	if known {
+		state = op.State
+	}
+	e.mu.RUnlock()
+
+	if known {
+		if !state.IsTerminal() {
+			return fmt.Sprintf("operation %s is %s", inst.OperationID, state)
+		}
+		return ""
+	}
+
+	ttl := e.orphanTTL
+	if ttl <= 0 {
+		ttl = constants.DefaultOrphanTTL
+	}
+	if inst.CreatedAt.IsZero() || now.Sub(inst.CreatedAt) < ttl {
+		return fmt.Sprintf("operation %q is unknown and the instance is younger than %s", inst.OperationID, ttl)
+	}
+	return ""
+}
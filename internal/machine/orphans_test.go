@@ -0,0 +1,109 @@
+package machine
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// TestReconcileOrphans verifies that only temp instances no operation will
+// clean up are deleted, and never one that is its cluster's writer.
+func TestReconcileOrphans(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	client, err := engine.clientManager.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+
+	engine.operations["op-done"] = &types.Operation{ID: "op-done", State: types.StateCompleted}
+	engine.operations["op-running"] = &types.Operation{ID: "op-running", State: types.StateRunning}
+	engine.operations["op-failed-over"] = &types.Operation{ID: "op-failed-over", State: types.StateFailed}
+
+	temps := []struct{ instanceID, clusterID, operationID string }{
+		{"tmp-done", "demo-multi", "op-done"},
+		{"tmp-running", "demo-multi", "op-running"},
+		{"tmp-unknown", "demo-multi", "op-lost-in-crash"},
+		{"tmp-writer", "demo-single", "op-failed-over"},
+	}
+	for _, tmp := range temps {
+		_, err := client.CreateClusterInstance(ctx, rds.CreateInstanceParams{
+			ClusterID:    tmp.clusterID,
+			InstanceID:   tmp.instanceID,
+			InstanceType: "db.r6g.large",
+			Engine:       "aurora-postgresql",
+			OperationID:  tmp.operationID,
+		})
+		if err != nil {
+			t.Fatalf("CreateClusterInstance(%s) failed: %v", tmp.instanceID, err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, tmp := range temps {
+		for {
+			if inst, _ := mockState.GetInstance(tmp.instanceID); inst.Status == "available" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("instance %s did not become available", tmp.instanceID)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if err := mockState.FailoverCluster("demo-single", "tmp-writer"); err != nil {
+		t.Fatalf("FailoverCluster failed: %v", err)
+	}
+
+	result, err := engine.ReconcileOrphans(ctx, "")
+	if err != nil {
+		t.Fatalf("ReconcileOrphans failed: %v", err)
+	}
+	if !slices.Equal(result.Deleted, []string{"tmp-done"}) {
+		t.Errorf("Deleted = %v, want [tmp-done]", result.Deleted)
+	}
+	for instanceID, want := range map[string]string{
+		"tmp-running": "is running",
+		"tmp-unknown": "younger than",
+		"tmp-writer":  "writer of demo-single",
+	} {
+		if reason := result.Retained[instanceID]; !strings.Contains(reason, want) {
+			t.Errorf("Retained[%s] = %q, want it to mention %q", instanceID, reason, want)
+		}
+	}
+	if _, ok := result.Retained["demo-multi-reader-1"]; ok {
+		t.Error("instances without the temp instance tag should not be considered")
+	}
+
+	for {
+		if inst, ok := mockState.GetInstance("tmp-done"); !ok || inst.Status == "deleting" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tmp-done was not deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A day later the unknown operation's instance is an orphan too.
+	clk := newFakeClock()
+	clk.now = time.Now().Add(48 * time.Hour)
+	engine.clock = clk
+
+	result, err = engine.ReconcileOrphans(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("ReconcileOrphans failed: %v", err)
+	}
+	if !slices.Equal(result.Deleted, []string{"tmp-unknown"}) {
+		t.Errorf("Deleted = %v, want [tmp-unknown]", result.Deleted)
+	}
+	if _, ok := mockState.GetInstance("tmp-writer"); !ok {
+		t.Error("the writer must never be deleted")
+	}
+}
@@ -0,0 +1,228 @@
+package machine
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+const (
+	// pollBackoffFactor grows the delay after a poll that found the
+	// condition not yet met.
+	pollBackoffFactor = 1.5
+	// pollErrorBackoffFactor grows the delay after a poll that failed, so a
+	// throttled or failing API is given room to recover.
+	pollErrorBackoffFactor = 3.0
+	// pollJitter spreads each delay by up to this fraction either way so
+	// operations started together do not poll in lockstep.
+	pollJitter = 0.2
+)
+
+// clock is the time source pollUntil sleeps on; tests substitute a fake.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// pollFunc checks a wait condition once. It returns true when the condition
+// is met. A returned error ends the wait unless it is marked with
+// transientPollError, in which case the wait backs off and polls again.
+type pollFunc func(ctx context.Context) (bool, error)
+
+// transientPollError marks a poll failure as worth retrying.
+type transientPollError struct{ err error }
+
+func (e *transientPollError) Error() string { return e.err.Error() }
+func (e *transientPollError) Unwrap() error { return e.err }
+
+// transient marks err as a failed poll to be retried after a longer backoff.
+func transient(err error) error {
+	return &transientPollError{err: err}
+}
+
+// errPollPending is returned by a single-shot wait whose condition is not
+// met yet. executeSteps leaves the step waiting for the next poll.
+var errPollPending = errors.New("wait condition not met yet")
+
+// poller runs a poll function with exponential backoff and jitter.
+type poller struct {
+	interval    time.Duration // delay before the first poll
+	maxInterval time.Duration // cap on the delay between polls
+	clock       clock
+	jitter      func() float64 // returns a value in [0, 1)
+
+	// progress, when set, counts the polls of the step's wait.
+	progress *types.WaitProgress
+	// polls, when set, counts every poll the step makes across attempts.
+	polls *int
+
+	// singleShot checks the condition once instead of sleeping between
+	// polls. The timeout and initialDelay then count from startedAt, when
+	// the wait began, rather than from the call.
+	singleShot   bool
+	startedAt    time.Time
+	initialDelay time.Duration
+}
+
+// newPoller returns a poller for the step: polls start at the step's poll
+// interval and back off up to the engine's maximum. Wait steps of an
+// operation in poll mode get a single-shot poller.
+func (e *Engine) newPoller(op *types.Operation, step *types.Step) poller {
+	p := poller{
+		interval:    e.getPollInterval(step),
+		maxInterval: e.maxPollInterval,
+		clock:       e.clock,
+		jitter:      rand.Float64,
+		progress:    step.WaitProgress,
+		polls:       &step.Polls,
+	}
+	if p.clock == nil {
+		p.clock = realClock{}
+	}
+	e.recordWaitStart(step)
+	if singleShotWait(op, step) {
+		p.singleShot = true
+		p.initialDelay = e.initialPollDelay
+		p.startedAt = p.clock.Now()
+		if p.progress != nil {
+			p.startedAt = p.progress.StartedAt
+		}
+	}
+	return p
+}
+
+// recordWaitStart notes when the step first began waiting, if it has not
+// waited before.
+func (e *Engine) recordWaitStart(step *types.Step) {
+	if step.WaitStartedAt != nil {
+		return
+	}
+	now := time.Now()
+	if e.clock != nil {
+		now = e.clock.Now()
+	}
+	step.WaitStartedAt = &now
+}
+
+// startWait returns the progress of the step's wait, starting it with the
+// resources waited on when the step has none. executeSteps clears it when
+// the handler returns, other than to wait for the next poll.
+func (e *Engine) startWait(step *types.Step, waitingOn ...string) *types.WaitProgress {
+	if step.WaitProgress == nil {
+		now := time.Now()
+		if e.clock != nil {
+			now = e.clock.Now()
+		}
+		step.WaitProgress = &types.WaitProgress{StartedAt: now, WaitingOn: waitingOn}
+	}
+	return step.WaitProgress
+}
+
+// markWaiting moves a step to waiting on condition. Drain and API readers
+// look at the step's state under e.mu while its handler runs, so the
+// transition is made under the lock too.
+func (e *Engine) markWaiting(step *types.Step, condition string) {
+	e.mu.Lock()
+	step.WaitCondition = condition
+	step.State = types.StepStateWaiting
+	e.mu.Unlock()
+}
+
+// singleShotWait reports whether the step is a wait that checks its
+// condition once per poll of an operation in poll mode.
+func singleShotWait(op *types.Operation, step *types.Step) bool {
+	return op.PollMode && strings.HasPrefix(step.Action, "wait_")
+}
+
+// pollUntil calls fn until it reports done, returns a permanent error, the
+// context ends, or timeout elapses. It returns internalerrors.ErrWaitTimeout
+// on timeout; callers wrap it with what they were waiting for. A single-shot
+// poller calls fn at most once and returns errPollPending if it is not done.
+func (p poller) pollUntil(ctx context.Context, timeout time.Duration, fn pollFunc) error {
+	if p.singleShot {
+		return p.pollOnce(ctx, timeout, fn)
+	}
+	deadline := p.clock.Now().Add(timeout)
+	delay := p.interval
+	maxDelay := max(p.maxInterval, p.interval)
+
+	for {
+		remaining := deadline.Sub(p.clock.Now())
+		if remaining <= 0 {
+			return internalerrors.ErrWaitTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.clock.After(min(p.withJitter(delay), remaining)):
+		}
+		if !p.clock.Now().Before(deadline) {
+			return internalerrors.ErrWaitTimeout
+		}
+
+		done, err := p.poll(ctx, fn)
+		if done {
+			return nil
+		}
+		factor := pollBackoffFactor
+		if err != nil {
+			var transientErr *transientPollError
+			if !errors.As(err, &transientErr) {
+				return err
+			}
+			factor = pollErrorBackoffFactor
+		}
+		delay = min(time.Duration(float64(delay)*factor), maxDelay)
+	}
+}
+
+// pollOnce checks the condition of a wait that began at startedAt. The
+// first check is held back until the initial poll delay has passed, as a
+// blocking wait would sleep through it.
+func (p poller) pollOnce(ctx context.Context, timeout time.Duration, fn pollFunc) error {
+	elapsed := p.clock.Now().Sub(p.startedAt)
+	if elapsed < p.initialDelay {
+		return errPollPending
+	}
+	done, err := p.poll(ctx, fn)
+	if done {
+		return nil
+	}
+	var transientErr *transientPollError
+	if err != nil && !errors.As(err, &transientErr) {
+		return err
+	}
+	if elapsed >= timeout {
+		return internalerrors.ErrWaitTimeout
+	}
+	return errPollPending
+}
+
+// poll calls fn once, counting the poll in the wait's progress.
+func (p poller) poll(ctx context.Context, fn pollFunc) (bool, error) {
+	if p.progress != nil {
+		p.progress.Polls++
+	}
+	if p.polls != nil {
+		*p.polls++
+	}
+	return fn(ctx)
+}
+
+// withJitter spreads d by up to pollJitter in either direction.
+func (p poller) withJitter(d time.Duration) time.Duration {
+	if p.jitter == nil {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + pollJitter*(2*p.jitter()-1)))
+}
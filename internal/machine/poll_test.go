@@ -0,0 +1,173 @@
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// fakeClock advances instantly on After and records every delay requested.
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// noJitter places every delay exactly at its nominal value.
+func noJitter() float64 { return 0.5 }
+
+func TestPollUntil_BacksOffToMax(t *testing.T) {
+	clk := newFakeClock()
+	p := poller{interval: 10 * time.Second, maxInterval: 40 * time.Second, clock: clk, jitter: noJitter}
+
+	polls := 0
+	err := p.pollUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+		polls++
+		return polls == 6, nil
+	})
+	if err != nil {
+		t.Fatalf("pollUntil failed: %v", err)
+	}
+
+	want := []time.Duration{10 * time.Second, 15 * time.Second, 22500 * time.Millisecond, 33750 * time.Millisecond, 40 * time.Second, 40 * time.Second}
+	if len(clk.delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", clk.delays, want)
+	}
+	for i := range want {
+		if clk.delays[i] != want[i] {
+			t.Errorf("delay %d = %v, want %v", i, clk.delays[i], want[i])
+		}
+	}
+}
+
+func TestPollUntil_ZeroMaxKeepsInterval(t *testing.T) {
+	clk := newFakeClock()
+	p := poller{interval: 10 * time.Second, clock: clk, jitter: noJitter}
+
+	polls := 0
+	if err := p.pollUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+		polls++
+		return polls == 3, nil
+	}); err != nil {
+		t.Fatalf("pollUntil failed: %v", err)
+	}
+	for i, d := range clk.delays {
+		if d != 10*time.Second {
+			t.Errorf("delay %d = %v, want 10s", i, d)
+		}
+	}
+}
+
+func TestPollUntil_Jitter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		jitter float64
+		want   time.Duration
+	}{
+		{"low", 0, 8 * time.Second},
+		{"high", 1, 12 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := newFakeClock()
+			p := poller{interval: 10 * time.Second, clock: clk, jitter: func() float64 { return tc.jitter }}
+			if err := p.pollUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+				return true, nil
+			}); err != nil {
+				t.Fatalf("pollUntil failed: %v", err)
+			}
+			if clk.delays[0] != tc.want {
+				t.Errorf("delay = %v, want %v", clk.delays[0], tc.want)
+			}
+		})
+	}
+}
+
+func TestPollUntil_TransientErrorsBackOffHarder(t *testing.T) {
+	clk := newFakeClock()
+	p := poller{interval: time.Second, maxInterval: time.Minute, clock: clk, jitter: noJitter}
+
+	polls := 0
+	err := p.pollUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+		polls++
+		if polls < 3 {
+			return false, transient(errors.New("Throttling: Rate exceeded"))
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("pollUntil failed: %v", err)
+	}
+
+	want := []time.Duration{time.Second, 3 * time.Second, 9 * time.Second}
+	for i := range want {
+		if clk.delays[i] != want[i] {
+			t.Errorf("delay %d = %v, want %v", i, clk.delays[i], want[i])
+		}
+	}
+}
+
+func TestPollUntil_Timeout(t *testing.T) {
+	clk := newFakeClock()
+	p := poller{interval: 10 * time.Second, maxInterval: time.Minute, clock: clk, jitter: noJitter}
+	start := clk.Now()
+
+	err := p.pollUntil(context.Background(), 100*time.Second, func(context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, internalerrors.ErrWaitTimeout) {
+		t.Fatalf("err = %v, want ErrWaitTimeout", err)
+	}
+	// The last sleep is cut short so the timeout is honored exactly.
+	if elapsed := clk.Now().Sub(start); elapsed != 100*time.Second {
+		t.Errorf("elapsed = %v, want 100s", elapsed)
+	}
+}
+
+func TestPollUntil_PermanentError(t *testing.T) {
+	clk := newFakeClock()
+	p := poller{interval: time.Second, clock: clk, jitter: noJitter}
+	permanent := errors.New("instance deleted")
+
+	polls := 0
+	err := p.pollUntil(context.Background(), time.Hour, func(context.Context) (bool, error) {
+		polls++
+		return false, permanent
+	})
+	if !errors.Is(err, permanent) {
+		t.Fatalf("err = %v, want %v", err, permanent)
+	}
+	if polls != 1 {
+		t.Errorf("polls = %d, want 1", polls)
+	}
+}
+
+func TestPollUntil_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A real clock with a long interval: only the cancellation can end the wait.
+	p := poller{interval: time.Hour, clock: realClock{}}
+	err := p.pollUntil(ctx, 2*time.Hour, func(context.Context) (bool, error) {
+		t.Fatal("poll ran after cancellation")
+		return false, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
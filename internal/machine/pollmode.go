@@ -0,0 +1,74 @@
+package machine
+
+import (
+	"context"
+	"slices"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// PollOperation advances an operation created in poll mode. It runs steps
+// until a wait step finds its condition not met yet or the operation stops
+// running, checking the wait once rather than blocking on it, so a serverless
+// orchestrator can wait between calls instead of inside one.
+//
+// The result is not ready while the operation still has to be polled or is
+// waiting to be started, confirmed or approved. Everything a wait needs to
+// carry on is kept on the operation, so any instance of the app can serve
+// the next poll.
+func (e *Engine) PollOperation(ctx context.Context, id string) (*types.PollResult, error) {
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok {
+		e.mu.Unlock()
+		return nil, internalerrors.ErrOperationNotFound
+	}
+	if !op.PollMode {
+		e.mu.Unlock()
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "operation %s was not created in poll mode", id)
+	}
+	if e.polling[id] {
+		e.mu.Unlock()
+		return nil, errors.Wrapf(internalerrors.ErrOperationAlreadyRunning, "operation %s is already being polled", id)
+	}
+	advance := op.State == types.StateRunning
+	if advance {
+		if e.polling == nil {
+			e.polling = make(map[string]bool)
+		}
+		e.polling[id] = true
+	}
+	e.mu.Unlock()
+
+	if advance {
+		e.executeSteps(e.operationContext(id), op)
+		e.mu.Lock()
+		delete(e.polling, id)
+		e.mu.Unlock()
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	result := &types.PollResult{
+		OperationID:      op.ID,
+		State:            op.State,
+		CurrentStepIndex: op.CurrentStepIndex,
+		Deadline:         op.Deadline,
+	}
+	switch op.State {
+	case types.StateRunning, types.StateCancelling, types.StateCreated, types.StatePlanned, types.StatePendingApproval:
+	default:
+		result.Ready = true
+	}
+	if op.CurrentStepIndex < len(op.Steps) {
+		step := op.Steps[op.CurrentStepIndex]
+		result.Step = step.Name
+		result.WaitCondition = step.WaitCondition
+		if step.WaitProgress != nil {
+			result.WaitingOn = slices.Clone(step.WaitProgress.WaitingOn)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package machine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestPollOperation(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	// A single-shot wait checks as soon as it is polled, so hold it off
+	// until the mock has moved a rebooted instance out of available.
+	engine.initialPollDelay = 300 * time.Millisecond
+	ctx := context.Background()
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{PollMode: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	result, err := engine.PollOperation(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("PollOperation failed: %v", err)
+	}
+	if result.Ready || result.State != types.StateCreated {
+		t.Errorf("result = %+v, want a created operation that is not ready", result)
+	}
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := engine.GetOperation(op.ID); got.CurrentStepIndex != 0 {
+		t.Fatalf("operation advanced to step %d without being polled", got.CurrentStepIndex)
+	}
+
+	sawWaiting := false
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		result, err = engine.PollOperation(ctx, op.ID)
+		if err != nil {
+			t.Fatalf("PollOperation failed: %v", err)
+		}
+		if result.Ready {
+			break
+		}
+		if len(result.WaitingOn) > 0 {
+			sawWaiting = true
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("operation did not finish: %+v", result)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if result.State != types.StateCompleted {
+		t.Fatalf("state = %s, want completed", result.State)
+	}
+	if !sawWaiting {
+		t.Error("no poll reported what it was waiting on")
+	}
+
+	got, _ := engine.GetOperation(op.ID)
+	events, _ := engine.GetEvents(op.ID)
+	started := 0
+	for _, ev := range events {
+		if ev.Type == "step_started" {
+			started++
+		}
+	}
+	if started != len(got.Steps) {
+		t.Errorf("%d step_started events for %d steps, want one per step however often it was polled", started, len(got.Steps))
+	}
+	for _, step := range got.Steps {
+		if strings.HasPrefix(step.Action, "wait_") && step.WaitProgress == nil {
+			t.Errorf("step %s lost its wait progress", step.Name)
+		}
+		if step.Attempts != 1 {
+			t.Errorf("step %s made %d attempts, want 1 however often it was polled", step.Name, step.Attempts)
+		}
+		if strings.HasPrefix(step.Action, "wait_") && (step.WaitStartedAt == nil || step.Polls == 0) {
+			t.Errorf("step %s recorded wait start %v and %d polls", step.Name, step.WaitStartedAt, step.Polls)
+		}
+	}
+}
+
+func TestPollOperation_Errors(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := engine.PollOperation(ctx, "missing"); !internalerrors.IsNotFound(err) {
+		t.Errorf("expected not found, got: %v", err)
+	}
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if _, err := engine.PollOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for an operation not in poll mode, got: %v", err)
+	}
+}
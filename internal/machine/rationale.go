@@ -0,0 +1,101 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// The rationale helpers below phrase the decisions shared by the builders
+// that stand a temporary instance in for the writer. Each names the
+// parameter or cluster state that put the step in the plan, so an operator
+// reviewing a dry run can spot a misconfiguration before confirming it.
+
+// tempInstanceRationale explains why a temp instance is created.
+func tempInstanceRationale(writerExcluded bool) string {
+	if writerExcluded {
+		return "skip_temp_instance is not set; the temp instance keeps an extra reader in service but is not promoted, because the writer is excluded"
+	}
+	return "skip_temp_instance is not set, so a temp instance takes over writes while the original writer is changed"
+}
+
+// forcedTempInstanceRationale explains why a temp instance is created even
+// though skip_temp_instance is set.
+func forcedTempInstanceRationale(clusterID string) string {
+	return fmt.Sprintf("skip_temp_instance is set, but %s has no readers to take over writes and allow_downtime is not set, so a temp instance is created anyway", clusterID)
+}
+
+// failoverToTempRationale explains why writes move to the temp instance.
+func failoverToTempRationale(writerID string) string {
+	return fmt.Sprintf("Writer %s is not excluded, so writes move to the temp instance before it is changed", writerID)
+}
+
+// failbackRationale explains why the original writer is promoted again.
+func failbackRationale(writerID string) string {
+	return fmt.Sprintf("The cluster failed over to the temp instance; %s is made writer again before the temp instance is removed", writerID)
+}
+
+// instanceChangeRationale explains why an instance is changed, where verb
+// says how (e.g. "modified", "rebooted").
+func instanceChangeRationale(instanceID, writerID string, createTemp bool, verb string) string {
+	switch {
+	case instanceID != writerID:
+		return fmt.Sprintf("Reader %s is neither excluded nor autoscaled", instanceID)
+	case createTemp:
+		return fmt.Sprintf("Writer %s is not excluded; it is %s while the temp instance serves writes", writerID, verb)
+	default:
+		return fmt.Sprintf("Writer %s is not excluded and skip_temp_instance is set, so it is %s in place and writes pause until it is back", writerID, verb)
+	}
+}
+
+// finalSnapshotRationale explains why the temp instance's final snapshot is taken.
+func finalSnapshotRationale(requested *bool) string {
+	if requested != nil {
+		return "final_snapshot is set on the operation"
+	}
+	return "APP_TEMP_FINAL_SNAPSHOT is enabled and the operation does not set final_snapshot"
+}
+
+// parameterRebootRationale explains why instances are rebooted after moving
+// the cluster from one parameter group to another. The groups are compared
+// at plan time so the rationale can say which static parameters will be
+// pending; the apply step repeats the comparison and skips the reboots when
+// none are. A failed comparison only costs the detail.
+func (e *Engine) parameterRebootRationale(ctx context.Context, client *rds.Client, from, to string) string {
+	const fallback = "Runs only if static parameters are pending reboot after the parameter group is applied"
+
+	fromParams, err := client.GetClusterParameterGroupCustomParameters(ctx, from)
+	if err != nil {
+		e.logger.Warn("compare parameter groups for plan", "group", from, "error", err)
+		return fallback
+	}
+	toParams, err := client.GetClusterParameterGroupCustomParameters(ctx, to)
+	if err != nil {
+		e.logger.Warn("compare parameter groups for plan", "group", to, "error", err)
+		return fallback
+	}
+
+	var static []string
+	for _, change := range rds.ChangedParameters(fromParams, toParams) {
+		if change.PendingReboot() {
+			static = append(static, change.Name)
+		}
+	}
+	if len(static) == 0 {
+		return fmt.Sprintf("Every parameter that differs between %s and %s is dynamic, so this reboot is expected to be skipped", from, to)
+	}
+	return fmt.Sprintf("%d static parameter(s) will be pending reboot after moving to %s: %s",
+		len(static), to, strings.Join(static, ", "))
+}
+
+// caRotationRationale explains why an instance's CA certificate is rotated.
+func caRotationRationale(inst *types.InstanceInfo, target string) string {
+	current := inst.CACertificateIdentifier
+	if current == "" {
+		current = "an unknown certificate"
+	}
+	return fmt.Sprintf("%s uses %s, not %s", inst.InstanceID, current, target)
+}
@@ -0,0 +1,143 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// stepsByAction groups an operation's step rationales by action.
+func stepsByAction(steps []types.Step) map[string][]string {
+	out := map[string][]string{}
+	for _, step := range steps {
+		out[step.Action] = append(out[step.Action], step.Rationale)
+	}
+	return out
+}
+
+func TestBuildInstanceTypeChangeSteps_Rationale(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     string
+		wantTemp   bool
+		wantWriter string
+	}{
+		{
+			name:       "temp instance",
+			params:     `{"target_instance_type":"db.r6g.xlarge"}`,
+			wantTemp:   true,
+			wantWriter: "while the temp instance serves writes",
+		},
+		{
+			name:       "skip temp instance",
+			params:     `{"target_instance_type":"db.r6g.xlarge","skip_temp_instance":true}`,
+			wantWriter: "skip_temp_instance is set, so it is resized in place",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _, cleanup := testEngineWithMockState(t)
+			defer cleanup()
+			ctx := context.Background()
+
+			op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1",
+				json.RawMessage(tt.params), CreateOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("CreateOperation failed: %v", err)
+			}
+
+			byAction := stepsByAction(op.Steps)
+			if got := byAction["create_temp_instance"]; tt.wantTemp != (len(got) == 1) {
+				t.Fatalf("create_temp_instance steps = %v, want temp instance %v", got, tt.wantTemp)
+			}
+			if tt.wantTemp {
+				if !strings.Contains(byAction["create_temp_instance"][0], "skip_temp_instance is not set") {
+					t.Errorf("create_temp_instance rationale = %q", byAction["create_temp_instance"][0])
+				}
+				for _, rationale := range byAction["failover_to_instance"] {
+					if rationale == "" {
+						t.Error("failover step has no rationale")
+					}
+				}
+			}
+
+			var writerSteps int
+			for _, rationale := range byAction["modify_instance"] {
+				if strings.HasPrefix(rationale, "Writer ") {
+					writerSteps++
+					if !strings.Contains(rationale, tt.wantWriter) {
+						t.Errorf("writer modify rationale = %q, want it to mention %q", rationale, tt.wantWriter)
+					}
+				} else if !strings.Contains(rationale, "neither excluded nor autoscaled") {
+					t.Errorf("reader modify rationale = %q", rationale)
+				}
+			}
+			if writerSteps != 1 {
+				t.Errorf("got %d writer modify steps, want 1", writerSteps)
+			}
+
+			plan, err := engine.GetStepPlan(op.ID)
+			if err != nil {
+				t.Fatalf("GetStepPlan failed: %v", err)
+			}
+			for i, step := range plan {
+				if step.Rationale != op.Steps[i].Rationale {
+					t.Errorf("plan step %d rationale = %q, want %q", i, step.Rationale, op.Steps[i].Rationale)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyParameterGroupSteps_RebootRationale(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]mock.MockParameter
+		want   string
+	}{
+		{
+			name:   "static change",
+			params: map[string]mock.MockParameter{"shared_preload_libraries": {Value: "pg_stat_statements,pg_cron", ApplyType: "static"}},
+			want:   "1 static parameter(s) will be pending reboot after moving to demo-multi-tuned-pg: shared_preload_libraries",
+		},
+		{
+			name:   "dynamic change",
+			params: map[string]mock.MockParameter{"work_mem": {Value: "65536", ApplyType: "dynamic"}},
+			want:   "is dynamic, so this reboot is expected to be skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, mockState, cleanup := testEngineWithMockState(t)
+			defer cleanup()
+
+			mockState.SetClusterParameters("demo-multi-tuned-pg", map[string]mock.MockParameter{
+				"rds.logical_replication": {Value: "1", ApplyType: "static"},
+			})
+			mockState.SetClusterParameters("demo-multi-tuned-pg", tt.params)
+
+			params := json.RawMessage(`{"target_engine_version":"15.5","db_cluster_parameter_group_name":"demo-multi-tuned-pg"}`)
+			op, err := engine.CreateOperation(context.Background(), types.OperationTypeMinorVersionUpgrade, "demo-multi", "us-east-1",
+				params, CreateOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("CreateOperation failed: %v", err)
+			}
+
+			reboots := stepsByAction(op.Steps)["reboot_instance"]
+			if len(reboots) == 0 {
+				t.Fatal("expected reboot steps")
+			}
+			for _, rationale := range reboots {
+				if !strings.Contains(rationale, tt.want) {
+					t.Errorf("reboot rationale = %q, want it to mention %q", rationale, tt.want)
+				}
+			}
+		})
+	}
+}
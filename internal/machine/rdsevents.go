@@ -0,0 +1,85 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/rds"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// failureEventLookback is how far back RDS events are fetched when a step
+// fails. RDS records a rejected change within moments of the call, so a few
+// minutes is enough even for a step that retried.
+const failureEventLookback = 5 * time.Minute
+
+// maxFailureEvents bounds the RDS events attached to one failure; the most
+// recent are kept.
+const maxFailureEvents = 10
+
+// attachRDSEvents adds the recent RDS events of the resources a failed step
+// worked on to the operation's event log. AWS often explains a rejected call
+// only in its event stream, so this is where the actionable part of a failure
+// like "InvalidParameterCombination" usually is. Lookup errors are logged and
+// otherwise ignored: the failure has already been recorded.
+func (e *Engine) attachRDSEvents(ctx context.Context, op *types.Operation, step *types.Step) {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		e.logger.Warn("failed to get RDS client for failure events",
+			slog.String("operation_id", op.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	since := time.Now().Add(-failureEventLookback)
+	var events []rds.RDSEvent
+	for _, resourceID := range failedStepResources(op, step) {
+		found, err := rdsClient.GetRecentResourceEvents(ctx, resourceID, since)
+		if err != nil {
+			e.logger.Warn("failed to fetch RDS events for failed step",
+				slog.String("operation_id", op.ID),
+				slog.String("resource_id", resourceID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		events = append(events, found...)
+	}
+	if len(events) == 0 {
+		return
+	}
+	slices.SortStableFunc(events, func(a, b rds.RDSEvent) int { return a.Date.Compare(b.Date) })
+	if len(events) > maxFailureEvents {
+		events = events[len(events)-maxFailureEvents:]
+	}
+
+	lines := make([]string, 0, len(events))
+	for _, ev := range events {
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", ev.Date.UTC().Format(time.TimeOnly), ev.SourceID, ev.Message))
+	}
+	data, _ := json.Marshal(events)
+	e.addStepEvent(op.ID, step.ID, "rds_events",
+		fmt.Sprintf("RDS events before %s failed: %s", step.Name, strings.Join(lines, "; ")), data)
+}
+
+// failedStepResources returns the cluster and any instances a step names in
+// its parameters.
+func failedStepResources(op *types.Operation, step *types.Step) []string {
+	resources := []string{op.ClusterID}
+	var params struct {
+		InstanceID  string   `json:"instance_id"`
+		InstanceIDs []string `json:"instance_ids"`
+	}
+	if len(step.Parameters) > 0 && json.Unmarshal(step.Parameters, &params) == nil {
+		for _, id := range append([]string{params.InstanceID}, params.InstanceIDs...) {
+			if id != "" && !slices.Contains(resources, id) {
+				resources = append(resources, id)
+			}
+		}
+	}
+	return resources
+}
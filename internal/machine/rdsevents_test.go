@@ -0,0 +1,78 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestStepFailure_AttachesRDSEvents(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeAPIError,
+		Action:      "ModifyDBInstance",
+		Target:      "demo-multi-reader-1",
+		ErrorCode:   "InvalidParameterCombination",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge", SkipTempInstance: true})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	waitForState(t, engine, op, types.StatePaused)
+
+	// The events are looked up after the operation has paused.
+	var events []types.Event
+	i := -1
+	for deadline := time.Now().Add(5 * time.Second); i < 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("no rds_events event in %+v", events)
+		}
+		time.Sleep(10 * time.Millisecond)
+		events, _ = engine.GetEvents(op.ID)
+		i = slices.IndexFunc(events, func(ev types.Event) bool { return ev.Type == "rds_events" })
+	}
+	if !strings.Contains(events[i].Message, "not supported for this DB engine version or instance class") {
+		t.Errorf("message = %q, want RDS's explanation of the rejected modify", events[i].Message)
+	}
+	if events[i].StepID == "" || events[i-1].Type != "step_failed" {
+		t.Errorf("event = %+v, want it tied to the failed step and logged after step_failed", events[i])
+	}
+}
+
+func TestFailedStepResources(t *testing.T) {
+	op := &types.Operation{ClusterID: "c1"}
+	tests := []struct {
+		name   string
+		params string
+		want   []string
+	}{
+		{"no parameters", "", []string{"c1"}},
+		{"one instance", `{"instance_id":"i1"}`, []string{"c1", "i1"}},
+		{"instance list", `{"instance_ids":["i1","i2","i1"]}`, []string{"c1", "i1", "i2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := &types.Step{Parameters: json.RawMessage(tt.params)}
+			if got := failedStepResources(op, step); !slices.Equal(got, tt.want) {
+				t.Errorf("resources = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+const (
+	// stepRetryBackoffFactor grows the delay before each further retry of a
+	// failed step.
+	stepRetryBackoffFactor = 2.0
+	// maxStepRetryDelay caps the delay between retries when the engine has
+	// no maximum poll interval.
+	maxStepRetryDelay = 5 * time.Minute
+)
+
+// retryableStepError reports whether a failed step is worth running again:
+// its error is classed retryable, or is unclassified and not an answer from
+// the RDS API, which covers network trouble the taxonomy has no sentinel for.
+// An API error with an unfamiliar code is a rejection of the request itself
+// and would come back the same way.
+func retryableStepError(err error) bool {
+	switch internalerrors.Classify(err) {
+	case internalerrors.ClassRetryable:
+		return true
+	case "":
+		var apiErr smithy.APIError
+		return !errors.As(err, &apiErr)
+	}
+	return false
+}
+
+// stepRetryDelay returns how long to wait before retry n (from 1) of a step:
+// the engine's poll interval, doubled for each earlier retry up to the
+// maximum poll interval, spread by pollJitter.
+func (e *Engine) stepRetryDelay(n int) time.Duration {
+	return retryDelay(e.defaultPollInterval, e.maxPollInterval, n, rand.Float64())
+}
+
+// retryDelay is stepRetryDelay with its inputs explicit. jitter is in [0, 1).
+func retryDelay(base, maxDelay time.Duration, n int, jitter float64) time.Duration {
+	if maxDelay <= 0 {
+		maxDelay = maxStepRetryDelay
+	}
+	maxDelay = max(maxDelay, base)
+	delay := float64(base)
+	for i := 1; i < n && delay < float64(maxDelay); i++ {
+		delay *= stepRetryBackoffFactor
+	}
+	delay = min(delay, float64(maxDelay))
+	return time.Duration(delay * (1 + pollJitter*(2*jitter-1)))
+}
+
+// stepRetryData is the data of a step_retry event.
+type stepRetryData struct {
+	Attempt    int                  `json:"attempt"`
+	MaxRetries int                  `json:"max_retries"`
+	DelayMs    int64                `json:"delay_ms"`
+	Error      string               `json:"error"`
+	ErrorClass internalerrors.Class `json:"error_class,omitempty"`
+}
+
+// newStepRetryData describes the retry of step about to be made after delay.
+func newStepRetryData(step *types.Step, err error, delay time.Duration) stepRetryData {
+	return stepRetryData{
+		Attempt:    step.RetryCount,
+		MaxRetries: step.MaxRetries,
+		DelayMs:    delay.Milliseconds(),
+		Error:      err.Error(),
+		ErrorClass: internalerrors.Classify(err),
+	}
+}
+
+// recordStepRetry logs a step_retry event for a step whose RetryCount has
+// just been raised, and returns how long to wait before running it again.
+func (e *Engine) recordStepRetry(op *types.Operation, step *types.Step, err error) time.Duration {
+	delay := e.stepRetryDelay(step.RetryCount)
+	data, _ := json.Marshal(newStepRetryData(step, err, delay))
+	e.addEvent(op.ID, "step_retry", fmt.Sprintf("Retrying step: %s (attempt %d of %d in %s): %v",
+		step.Name, step.RetryCount, step.MaxRetries, delay.Round(time.Millisecond), err), data)
+	return delay
+}
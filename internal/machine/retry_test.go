@@ -0,0 +1,170 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestRetryableStepError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throttled", &smithy.GenericAPIError{Code: "Throttling"}, true},
+		{"cluster busy", errors.Wrap(internalerrors.ErrClusterBusy, "modify"), true},
+		{"network failure", errors.New("dial tcp: connection refused"), true},
+		{"unfamiliar API rejection", &smithy.GenericAPIError{Code: "InvalidParameterCombination"}, false},
+		{"invalid parameter", errors.Wrap(internalerrors.ErrInvalidParameter, "modify"), false},
+		{"needs intervention", internalerrors.ErrClusterInstanceLimit, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryableStepError(tt.err); got != tt.want {
+				t.Errorf("retryableStepError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	tests := []struct {
+		name     string
+		base     time.Duration
+		maxDelay time.Duration
+		n        int
+		jitter   float64
+		want     time.Duration
+	}{
+		{"first retry waits the base", base, time.Second, 1, 0.5, base},
+		{"doubles per retry", base, time.Second, 3, 0.5, 400 * time.Millisecond},
+		{"capped at the maximum", base, time.Second, 10, 0.5, time.Second},
+		{"default cap without a maximum", time.Minute, 0, 10, 0.5, maxStepRetryDelay},
+		{"never below the base", time.Second, base, 2, 0.5, time.Second},
+		{"low jitter", base, time.Second, 1, 0, time.Duration(float64(base) * (1 - pollJitter))},
+		{"high jitter", base, time.Second, 1, 1, time.Duration(float64(base) * (1 + pollJitter))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.base, tt.maxDelay, tt.n, tt.jitter); got != tt.want {
+				t.Errorf("retryDelay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// startTypeChange starts an in-place instance type change on demo-multi.
+func startTypeChange(t *testing.T, engine *Engine) *types.Operation {
+	t.Helper()
+	ctx := context.Background()
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge", SkipTempInstance: true})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	return op
+}
+
+func TestStepRetry_RecoversFromIntermittentFailures(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+
+	// Every second modify fails with an error the SDK leaves to the engine.
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeIntermittent,
+		Action:      "ModifyDBInstance",
+		ErrorCode:   "InvalidDBInstanceState",
+		FailEveryN:  2,
+		Probability: 1.0,
+		Enabled:     true,
+	})
+
+	op := startTypeChange(t, engine)
+	waitForState(t, engine, op, types.StateCompleted)
+
+	engine.mu.RLock()
+	var retried int
+	for _, step := range op.Steps {
+		if step.RetryCount > step.MaxRetries {
+			t.Errorf("step %s retried %d times, budget %d", step.Name, step.RetryCount, step.MaxRetries)
+		}
+		retried += step.RetryCount
+	}
+	engine.mu.RUnlock()
+
+	events, _ := engine.GetEvents(op.ID)
+	var retryEvents int
+	for _, event := range events {
+		if event.Type != "step_retry" {
+			continue
+		}
+		retryEvents++
+		var data stepRetryData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			t.Fatalf("step_retry data: %v", err)
+		}
+		if data.Attempt < 1 || data.Attempt > data.MaxRetries || data.ErrorClass != "retryable" {
+			t.Errorf("step_retry data = %+v", data)
+		}
+	}
+	if retried == 0 || retryEvents != retried {
+		t.Errorf("%d retries with %d step_retry events, want at least one event per retry", retried, retryEvents)
+	}
+}
+
+func TestStepRetry_SkipsTerminalErrors(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeAPIError,
+		Action:      "ModifyDBInstance",
+		ErrorCode:   "InvalidParameterCombination",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+
+	op := startTypeChange(t, engine)
+	// Handlers write step results without the engine lock, so read only the
+	// fields under test rather than copying the step.
+	var action, name string
+	var state types.StepState
+	var retries int
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		engine.mu.RLock()
+		step := &op.Steps[op.CurrentStepIndex]
+		action, name, state, retries = step.Action, step.Name, step.State, step.RetryCount
+		engine.mu.RUnlock()
+		if action == "modify_instance" && state == types.StepStateFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("modify step did not fail; at %s (%s)", name, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if retries != 0 {
+		t.Errorf("terminal failure retried %d times, want none", retries)
+	}
+
+	events, _ := engine.GetEvents(op.ID)
+	for _, event := range events {
+		if event.Type == "step_retry" {
+			t.Errorf("unexpected step_retry event: %s", event.Message)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// scheduleRetryDelay is how long a scheduled operation that could not be
+// admitted when its window opened waits before trying again.
+const scheduleRetryDelay = time.Minute
+
+// clusterMaintenanceWindow reads the cluster's preferred maintenance window
+// and checks that it overlaps the configured maintenance windows, if any, so
+// an operation scheduled into it can ever start.
+func (e *Engine) clusterMaintenanceWindow(ctx context.Context, op *types.Operation) (string, error) {
+	rdsClient, err := e.getRDSClient(ctx, op)
+	if err != nil {
+		return "", errors.Wrap(err, "get rds client")
+	}
+	info, err := rdsClient.GetClusterInfo(ctx, op.ClusterID)
+	if err != nil {
+		return "", errors.Wrap(err, "get cluster info")
+	}
+	if info.PreferredMaintenanceWindow == "" {
+		return "", errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster %s has no preferred maintenance window to schedule into", op.ClusterID)
+	}
+	if _, err := e.nextScheduledStart(info.PreferredMaintenanceWindow, time.Now()); err != nil {
+		return "", err
+	}
+	return info.PreferredMaintenanceWindow, nil
+}
+
+// nextScheduledStart returns the earliest time from now on that is inside
+// the cluster's window and, when maintenance windows are configured, one of
+// those too. RDS windows are in UTC.
+func (e *Engine) nextScheduledStart(clusterWindow string, now time.Time) (time.Time, error) {
+	window, err := maintwindow.Parse(clusterWindow, "")
+	if err != nil {
+		return time.Time{}, errors.Wrapf(internalerrors.ErrInvalidParameter, "cluster maintenance window: %v", err)
+	}
+	start, ok := maintwindow.NextCommon(now, window, e.maintenanceWindow)
+	if !ok {
+		return time.Time{}, errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"cluster maintenance window %s never overlaps maintenance window %s", clusterWindow, e.maintenanceWindow)
+	}
+	return start, nil
+}
+
+// scheduleLocked holds the operation in the scheduled state until start.
+// Callers hold e.mu.
+func (e *Engine) scheduleLocked(op *types.Operation, start time.Time) {
+	op.State = types.StateScheduled
+	op.ScheduledStart = &start
+	op.UpdatedAt = time.Now()
+	e.armScheduleLocked(op)
+}
+
+// armScheduleLocked sets the timer that starts a scheduled operation,
+// replacing any it had. A start already past fires straight away. Callers
+// hold e.mu.
+func (e *Engine) armScheduleLocked(op *types.Operation) {
+	e.disarmScheduleLocked(op.ID)
+	if e.scheduleTimers == nil {
+		e.scheduleTimers = make(map[string]*time.Timer)
+	}
+	id := op.ID
+	e.scheduleTimers[id] = time.AfterFunc(time.Until(*op.ScheduledStart), func() {
+		e.startScheduled(context.Background(), id)
+	})
+}
+
+// disarmScheduleLocked stops the operation's schedule timer, if it has one.
+// Callers hold e.mu.
+func (e *Engine) disarmScheduleLocked(id string) {
+	if timer, ok := e.scheduleTimers[id]; ok {
+		timer.Stop()
+		delete(e.scheduleTimers, id)
+	}
+}
+
+// armLoadedSchedulesLocked sets the timers of scheduled operations loaded
+// from the store. Callers hold e.mu.
+func (e *Engine) armLoadedSchedulesLocked() int {
+	armed := 0
+	for _, op := range e.operations {
+		if op.State == types.StateScheduled && op.ScheduledStart != nil {
+			e.armScheduleLocked(op)
+			armed++
+		}
+	}
+	return armed
+}
+
+// startScheduled starts a scheduled operation when its timer fires. If the
+// window was missed, e.g. while the server was down, or the operation cannot
+// be admitted yet, it is scheduled again: a minute later while the window is
+// still open, otherwise at the next window.
+func (e *Engine) startScheduled(ctx context.Context, id string) {
+	e.mu.Lock()
+	op, ok := e.operations[id]
+	if !ok || op.State != types.StateScheduled || e.draining {
+		// A draining engine leaves the operation scheduled for the next
+		// process to pick up.
+		e.mu.Unlock()
+		return
+	}
+	delete(e.scheduleTimers, id)
+	now := time.Now()
+	start, err := e.nextScheduledStart(op.ClusterMaintenanceWindow, now)
+	if err == nil && !start.After(now) {
+		err = e.startLocked(op, now)
+		if err == nil {
+			e.mu.Unlock()
+			e.afterStart(ctx, op)
+			return
+		}
+		start, _ = e.nextScheduledStart(op.ClusterMaintenanceWindow, now.Add(scheduleRetryDelay))
+	}
+	if start.IsZero() {
+		// The configured windows changed across a restart and no longer
+		// overlap the cluster's; the operation stays scheduled until an
+		// operator cancels or deletes it.
+		e.mu.Unlock()
+		e.logger.Error("cannot reschedule operation", slog.String("operation_id", id), slog.String("error", err.Error()))
+		e.addEvent(id, "warning", fmt.Sprintf("Scheduled start not possible: %v", err), nil)
+		return
+	}
+	e.scheduleLocked(op, start)
+	e.mu.Unlock()
+
+	e.persistOperation(ctx, op)
+	message := "Window missed; scheduled for " + start.UTC().Format(time.RFC3339)
+	if err != nil {
+		message = fmt.Sprintf("Could not start (%v); scheduled for %s", err, start.UTC().Format(time.RFC3339))
+	}
+	e.addEvent(id, "operation_scheduled", message, nil)
+}
@@ -0,0 +1,148 @@
+package machine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/maintwindow"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// weekdayWindow returns a maintenance window in RDS form from 05:00 to 05:30
+// UTC on the day the given number of days from now.
+func weekdayWindow(days int) string {
+	day := strings.ToLower(time.Now().UTC().AddDate(0, 0, days).Weekday().String()[:3])
+	return day + ":05:00-" + day + ":05:30"
+}
+
+func TestScheduleInClusterWindow(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	window := weekdayWindow(2)
+	if err := mockState.SetClusterMaintenanceWindow("demo-single", window); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{ScheduleInClusterWindow: true, PollMode: true}); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected poll mode to be refused, got: %v", err)
+	}
+
+	// A configured window the cluster's never meets makes scheduling impossible.
+	engine.maintenanceWindow, _ = maintwindow.Parse(weekdayWindow(3), "")
+	_, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{ScheduleInClusterWindow: true})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) || !containsAny(err.Error(), "never overlaps") {
+		t.Fatalf("expected the windows to be rejected, got: %v", err)
+	}
+	engine.maintenanceWindow = nil
+
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{ScheduleInClusterWindow: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if op.ClusterMaintenanceWindow != window {
+		t.Errorf("cluster window = %q, want %q", op.ClusterMaintenanceWindow, window)
+	}
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+	got, _ := engine.GetOperation(op.ID)
+	if got.State != types.StateScheduled || got.StartedAt != nil {
+		t.Fatalf("state = %s, started %v; want scheduled and not started", got.State, got.StartedAt)
+	}
+	start := got.ScheduledStart.UTC()
+	if !start.After(time.Now()) || start.Hour() != 5 || start.Minute() != 0 || !strings.HasPrefix(window, strings.ToLower(start.Weekday().String()[:3])) {
+		t.Errorf("scheduled start = %s, want the next %s", start, window)
+	}
+	if summary := got.Summary(); summary.ScheduledStart == nil {
+		t.Error("summary does not carry the scheduled start")
+	}
+	if err := engine.StartOperation(ctx, op.ID); !errors.Is(err, internalerrors.ErrInvalidState) {
+		t.Errorf("expected starting a scheduled operation to fail, got: %v", err)
+	}
+
+	if err := engine.CancelOperation(ctx, op.ID); err != nil {
+		t.Fatalf("CancelOperation failed: %v", err)
+	}
+	if got.State != types.StateCancelled || len(engine.scheduleTimers) != 0 {
+		t.Errorf("state = %s with %d timers, want cancelled with none", got.State, len(engine.scheduleTimers))
+	}
+}
+
+func TestStartScheduled(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	// The window spans yesterday to tomorrow, so it is open now.
+	yesterday, tomorrow := weekdayWindow(-1), weekdayWindow(1)
+	window := yesterday[:3] + ":00:00-" + tomorrow[:3] + ":23:00"
+	if err := mockState.SetClusterMaintenanceWindow("demo-single", window); err != nil {
+		t.Fatal(err)
+	}
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil,
+		CreateOptions{ScheduleInClusterWindow: true})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	// The operation was scheduled before a restart and its start has come.
+	blocker := &types.Operation{ID: "blocker", ClusterID: "demo-single", Region: "us-east-1", State: types.StatePaused}
+	engine.mu.Lock()
+	engine.operations[blocker.ID] = blocker
+	engine.scheduleLocked(op, time.Now().Add(-time.Minute))
+	engine.disarmScheduleLocked(op.ID)
+	engine.mu.Unlock()
+
+	engine.mu.Lock()
+	if armed := engine.armLoadedSchedulesLocked(); armed != 1 {
+		t.Errorf("armed %d schedules, want 1", armed)
+	}
+	engine.mu.Unlock()
+
+	// The cluster is busy, so the start is deferred while the window is open.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, _ := engine.GetEvents(op.ID)
+		if last := events[len(events)-1]; last.Type == "operation_scheduled" {
+			if !containsAny(last.Message, "Could not start") {
+				t.Errorf("event = %q, want the reason it could not start", last.Message)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled start was not deferred")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	engine.mu.Lock()
+	state, start := op.State, *op.ScheduledStart
+	engine.mu.Unlock()
+	if state != types.StateScheduled || time.Until(start) < 30*time.Second {
+		t.Errorf("state = %s starting %s, want rescheduled about a minute out", state, start)
+	}
+
+	engine.mu.Lock()
+	blocker.State = types.StateCompleted
+	engine.mu.Unlock()
+	engine.startScheduled(ctx, op.ID)
+
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	if op.StartedAt == nil || op.State == types.StateScheduled {
+		t.Errorf("state = %s, want the operation started", op.State)
+	}
+	if _, armed := engine.scheduleTimers[op.ID]; armed {
+		t.Error("timer still armed after the start")
+	}
+}
@@ -0,0 +1,94 @@
+package machine
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// DrainSummary reports what Drain did with the operations in flight.
+type DrainSummary struct {
+	// Operations is how many active operations were saved.
+	Operations int `json:"operations"`
+	// Interrupted is how many of them had a step under way, which runs
+	// again from the start when the operation resumes.
+	Interrupted int `json:"interrupted"`
+	// TimedOut is set when step executors were still running at the
+	// deadline. Their operations are saved as they stood.
+	TimedOut bool `json:"timed_out"`
+}
+
+// Drain prepares the engine for the process to exit. It stops accepting new
+// operations, cancels the steps in flight, waits until ctx is done for their
+// executors to stop, and saves every active operation. An interrupted step is
+// reset to pending with the operation left running, the same state a crash
+// leaves behind, so the next process resumes it as configured by
+// APP_AUTO_RESUME. Rollbacks and cancellations are not interrupted: they are
+// saved as they stand and carried on after the restart.
+func (e *Engine) Drain(ctx context.Context) (DrainSummary, error) {
+	var summary DrainSummary
+
+	e.mu.Lock()
+	if e.draining {
+		e.mu.Unlock()
+		return summary, errors.New("engine is already draining")
+	}
+	e.draining = true
+	var active []*types.Operation
+	for _, op := range e.operations {
+		if !op.State.IsActive() {
+			continue
+		}
+		active = append(active, op)
+		if op.State == types.StateRunning && op.CurrentStepIndex < len(op.Steps) {
+			if state := op.Steps[op.CurrentStepIndex].State; state == types.StepStateInProgress || state == types.StepStateWaiting {
+				summary.Interrupted++
+			}
+		}
+	}
+	for _, run := range e.runContexts {
+		run.cancel()
+	}
+	e.mu.Unlock()
+
+	e.logger.Info("draining operations", slog.Int("active", len(active)))
+
+	stopped := make(chan struct{})
+	go func() {
+		e.executors.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		summary.TimedOut = true
+	}
+
+	// Saving is worth finishing even past the deadline: an operation that
+	// is not saved is one the next process cannot resume.
+	saveCtx := context.WithoutCancel(ctx)
+	for _, op := range active {
+		e.persistOperation(saveCtx, op)
+		summary.Operations++
+	}
+
+	e.logger.Info("drained operations",
+		slog.Int("operations", summary.Operations),
+		slog.Int("interrupted", summary.Interrupted),
+		slog.Bool("timed_out", summary.TimedOut))
+	return summary, nil
+}
+
+// interruptStepLocked puts a step cancelled by Drain back to pending so it
+// runs again from the start, as after a crash. A wait starts afresh rather
+// than from saved progress, since the instance may have moved on while the
+// process was down. Callers hold e.mu.
+func (e *Engine) interruptStepLocked(op *types.Operation, step *types.Step) {
+	step.State = types.StepStatePending
+	step.WaitCondition = ""
+	step.WaitProgress = nil
+	op.UpdatedAt = time.Now()
+}
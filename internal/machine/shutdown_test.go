@@ -0,0 +1,80 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestDrain_SavesWaitingOperationResumable(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	engine.defaultWaitTimeout = time.Minute
+	ctx := context.Background()
+
+	// Keep the writer modifying so the operation sits in its wait step.
+	mockState.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeStuck,
+		Target:      "demo-multi-writer",
+		Probability: 1.0,
+		Enabled:     true,
+	})
+
+	params, _ := json.Marshal(types.InstanceTypeChangeParams{TargetInstanceType: "db.r6g.xlarge", SkipTempInstance: true})
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+
+	// Handlers write step results without the engine lock, so read only the
+	// fields under test rather than copying the step.
+	var waitIndex int
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		engine.mu.RLock()
+		step := &op.Steps[op.CurrentStepIndex]
+		action, name, state := step.Action, step.Name, step.State
+		waitIndex = op.CurrentStepIndex
+		engine.mu.RUnlock()
+		if action == "wait_instance_available" && state == types.StepStateWaiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("operation did not reach its wait step; at %s (%s)", name, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	drainCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	summary, err := engine.Drain(drainCtx)
+	if err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if summary != (DrainSummary{Operations: 1, Interrupted: 1}) {
+		t.Errorf("summary = %+v, want one interrupted operation saved in time", summary)
+	}
+
+	engine.mu.RLock()
+	state, index, stepState := op.State, op.CurrentStepIndex, op.Steps[op.CurrentStepIndex].State
+	engine.mu.RUnlock()
+	if state != types.StateRunning || index != waitIndex || stepState != types.StepStatePending {
+		t.Errorf("operation %s at step %d (%s), want running at step %d reset to pending", state, index, stepState, waitIndex)
+	}
+
+	if _, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-single", "us-east-1", nil, CreateOptions{}); !errors.Is(err, internalerrors.ErrShuttingDown) {
+		t.Errorf("create while draining: error = %v, want ErrShuttingDown", err)
+	}
+	if _, err := engine.Drain(drainCtx); err == nil {
+		t.Error("second Drain succeeded, want an error")
+	}
+}
@@ -0,0 +1,60 @@
+package machine
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// CleanupExpiredSnapshots deletes the machine's cluster snapshots in a region
+// whose retention has passed. Snapshots without a retention tag and snapshots
+// still being created are kept. A snapshot that fails to delete is reported
+// and the sweep carries on.
+func (e *Engine) CleanupExpiredSnapshots(ctx context.Context, region string) (*types.SnapshotCleanupResult, error) {
+	if region == "" {
+		region = e.defaultRegion
+	}
+	client, err := e.clientManager.GetClient(ctx, region)
+	if err != nil {
+		return nil, errors.Wrap(err, "get RDS client")
+	}
+
+	snapshots, err := client.ListClusterSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if e.clock != nil {
+		now = e.clock.Now()
+	}
+
+	result := &types.SnapshotCleanupResult{Region: region, Deleted: []string{}}
+	for _, snap := range snapshots {
+		expiresAt, ok := snap.ExpiresAt()
+		if !ok || now.Before(expiresAt) || snap.Status != "available" {
+			result.Retained++
+			continue
+		}
+		if err := client.DeleteClusterSnapshot(ctx, snap.SnapshotID); err != nil && !errors.Is(err, internalerrors.ErrNotFound) {
+			e.logger.Warn("failed to delete expired snapshot",
+				"snapshot_id", snap.SnapshotID,
+				"region", region,
+				"error", err)
+			if result.Failed == nil {
+				result.Failed = make(map[string]string)
+			}
+			result.Failed[snap.SnapshotID] = err.Error()
+			continue
+		}
+		e.logger.Info("deleted expired snapshot",
+			"snapshot_id", snap.SnapshotID,
+			"cluster_id", snap.ClusterID,
+			"expired_at", expiresAt)
+		result.Deleted = append(result.Deleted, snap.SnapshotID)
+	}
+	return result, nil
+}
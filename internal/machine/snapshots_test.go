@@ -0,0 +1,62 @@
+package machine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestCleanupExpiredSnapshots verifies that only the machine's snapshots whose
+// retention has passed are deleted.
+func TestCleanupExpiredSnapshots(t *testing.T) {
+	engine, mockState, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	client, err := engine.clientManager.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	for id, days := range map[string]int{"demo-single-expired": 1, "demo-single-current": 30, "demo-single-forever": 0} {
+		if err := client.CreateClusterSnapshot(ctx, "demo-single", id, "", days, nil); err != nil {
+			t.Fatalf("CreateClusterSnapshot(%s) failed: %v", id, err)
+		}
+	}
+	if err := mockState.CreateSnapshot("demo-single", "demo-single-by-hand"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, snap := range mockState.ListSnapshots() {
+		for {
+			if current, _ := mockState.GetSnapshot(snap.ID); current.Status == "available" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("snapshot %s did not become available", snap.ID)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	clk := newFakeClock()
+	clk.now = time.Now().Add(48 * time.Hour)
+	engine.clock = clk
+
+	result, err := engine.CleanupExpiredSnapshots(ctx, "")
+	if err != nil {
+		t.Fatalf("CleanupExpiredSnapshots failed: %v", err)
+	}
+	if !slices.Equal(result.Deleted, []string{"demo-single-expired"}) {
+		t.Errorf("Deleted = %v, want [demo-single-expired]", result.Deleted)
+	}
+	if result.Retained != 2 || len(result.Failed) != 0 {
+		t.Errorf("Retained = %d, Failed = %v; want 2 retained and no failures", result.Retained, result.Failed)
+	}
+	for _, id := range []string{"demo-single-current", "demo-single-forever", "demo-single-by-hand"} {
+		if _, ok := mockState.GetSnapshot(id); !ok {
+			t.Errorf("snapshot %s was deleted", id)
+		}
+	}
+}
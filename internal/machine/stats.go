@@ -0,0 +1,53 @@
+package machine
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// DurationStats computes per-operation-type duration statistics from the
+// completed operations in the store. Operations that never started or are
+// missing a completion time are ignored.
+func (e *Engine) DurationStats(ctx context.Context) (map[types.OperationType]types.DurationStats, error) {
+	ops, err := e.store.ListOperations(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list operations")
+	}
+
+	durations := make(map[types.OperationType][]float64)
+	for _, op := range ops {
+		if op.State != types.StateCompleted || op.StartedAt == nil || op.CompletedAt == nil {
+			continue
+		}
+		d := op.CompletedAt.Sub(*op.StartedAt).Seconds()
+		durations[op.Type] = append(durations[op.Type], d)
+	}
+
+	stats := make(map[types.OperationType]types.DurationStats, len(durations))
+	for opType, values := range durations {
+		sort.Float64s(values)
+		stats[opType] = types.DurationStats{
+			Count:      len(values),
+			P50Seconds: percentile(values, 50),
+			P95Seconds: percentile(values, 95),
+			MaxSeconds: values[len(values)-1],
+		}
+	}
+	return stats, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
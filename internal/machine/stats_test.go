@@ -0,0 +1,76 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestDurationStats(t *testing.T) {
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	engine := NewEngine(EngineConfig{Store: store})
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	save := func(id string, opType types.OperationType, state types.OperationState, minutes int) {
+		t.Helper()
+		started := base
+		completed := base.Add(time.Duration(minutes) * time.Minute)
+		op := &types.Operation{
+			ID:          id,
+			Type:        opType,
+			State:       state,
+			ClusterID:   "stats-cluster",
+			CreatedAt:   base,
+			StartedAt:   &started,
+			CompletedAt: &completed,
+		}
+		if err := store.SaveOperation(ctx, op); err != nil {
+			t.Fatalf("SaveOperation failed: %v", err)
+		}
+	}
+
+	// Ten instance type changes taking 1..10 minutes.
+	for i := 1; i <= 10; i++ {
+		save(fmt.Sprintf("itc-%d", i), types.OperationTypeInstanceTypeChange, types.StateCompleted, i)
+	}
+	save("upgrade-1", types.OperationTypeEngineUpgrade, types.StateCompleted, 30)
+	// Non-completed operations must not skew the statistics.
+	save("itc-failed", types.OperationTypeInstanceTypeChange, types.StateFailed, 500)
+	save("upgrade-paused", types.OperationTypeEngineUpgrade, types.StatePaused, 500)
+
+	stats, err := engine.DurationStats(ctx)
+	if err != nil {
+		t.Fatalf("DurationStats failed: %v", err)
+	}
+
+	itc := stats[types.OperationTypeInstanceTypeChange]
+	if itc.Count != 10 {
+		t.Errorf("instance_type_change count = %d, want 10", itc.Count)
+	}
+	if itc.P50Seconds != 300 {
+		t.Errorf("instance_type_change p50 = %v, want 300", itc.P50Seconds)
+	}
+	if itc.P95Seconds != 600 {
+		t.Errorf("instance_type_change p95 = %v, want 600", itc.P95Seconds)
+	}
+	if itc.MaxSeconds != 600 {
+		t.Errorf("instance_type_change max = %v, want 600", itc.MaxSeconds)
+	}
+
+	upgrade := stats[types.OperationTypeEngineUpgrade]
+	if upgrade.Count != 1 || upgrade.P50Seconds != 1800 || upgrade.MaxSeconds != 1800 {
+		t.Errorf("engine_upgrade stats = %+v, want single 1800s sample", upgrade)
+	}
+
+	if _, ok := stats[types.OperationTypeInstanceCycle]; ok {
+		t.Error("expected no stats for operation types without completed operations")
+	}
+}
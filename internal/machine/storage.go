@@ -0,0 +1,175 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Provisioned storage limits RDS applies to the MySQL and PostgreSQL engines.
+const (
+	// gp3 volumes below gp3BaselineGiB have a fixed baseline of 3000 IOPS and
+	// 125 MiBps; only larger volumes take provisioned values.
+	gp3BaselineGiB   = 400
+	gp3MinIOPS       = 12000
+	gp3MaxIOPS       = 64000
+	gp3MinThroughput = 500
+	gp3MaxThroughput = 4000
+	// gp3MaxThroughputPerIOPS caps throughput (MiBps) relative to IOPS.
+	gp3MaxThroughputPerIOPS = 0.25
+
+	provisionedMinGiB  = 100
+	provisionedMinIOPS = 1000
+	provisionedMaxIOPS = 256000
+)
+
+// iopsPerGiB is the range of provisioned IOPS per GiB of allocated storage
+// each io volume type allows.
+var iopsPerGiB = map[string][2]float64{
+	"io1": {1, 50},
+	"io2": {0.5, 1000},
+}
+
+// validateStorageSettings checks that the requested storage type, IOPS and
+// throughput make a valid combination for every instance the change will
+// modify, given each instance's current storage type and allocated storage.
+// Instances with no reported allocated storage are only range-checked.
+func validateStorageSettings(target string, iops, throughput *int32, instances []types.InstanceInfo, excludeSet map[string]bool) error {
+	var modified []types.InstanceInfo
+	for _, inst := range instances {
+		if !inst.IsAutoScaled && !excludeSet[inst.InstanceID] {
+			modified = append(modified, inst)
+		}
+	}
+
+	if err := validateAuroraStorage(target, iops, throughput, modified); err != nil {
+		return err
+	}
+
+	unchanged := true
+	for _, inst := range modified {
+		if inst.StorageType != target {
+			unchanged = false
+		}
+	}
+	if unchanged && iops == nil && throughput == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"every instance already uses storage type %s; nothing to change", target)
+	}
+
+	switch target {
+	case "standard", "gp2":
+		if iops != nil || throughput != nil {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s storage does not take iops or storage_throughput; its performance scales with allocated storage", target)
+		}
+	case "gp3":
+		return validateGP3(iops, throughput, modified)
+	case "io1", "io2":
+		return validateProvisionedIOPS(target, iops, throughput, modified)
+	}
+	return nil
+}
+
+// validateAuroraStorage rejects changes that do not apply to Aurora managed
+// storage, where the cluster provisions IOPS and throughput itself and the
+// only choice is between Standard and I/O-Optimized.
+func validateAuroraStorage(target string, iops, throughput *int32, modified []types.InstanceInfo) error {
+	targetAurora := isAuroraStorage(target)
+	for _, inst := range modified {
+		if isAuroraStorage(inst.StorageType) == targetAurora {
+			continue
+		}
+		if targetAurora {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"storage type %s only applies to Aurora managed storage, but instance %s uses %s", target, inst.InstanceID, inst.StorageType)
+		}
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"instance %s uses Aurora managed storage (%s), so %s does not apply; supported storage types: %s, %s",
+			inst.InstanceID, inst.StorageType, target, constants.StorageTypeAurora, constants.StorageTypeAuroraIOOptimized)
+	}
+	if targetAurora && (iops != nil || throughput != nil) {
+		return errors.Wrap(internalerrors.ErrInvalidParameter,
+			"Aurora manages IOPS and throughput itself; remove iops and storage_throughput")
+	}
+	return nil
+}
+
+// isAuroraStorage reports whether a storage type is Aurora managed storage.
+func isAuroraStorage(storageType string) bool {
+	return storageType == constants.StorageTypeAurora || storageType == constants.StorageTypeAuroraIOOptimized
+}
+
+// validateGP3 checks provisioned gp3 IOPS and throughput.
+func validateGP3(iops, throughput *int32, modified []types.InstanceInfo) error {
+	if iops == nil && throughput == nil {
+		return nil
+	}
+
+	var small []string
+	for _, inst := range modified {
+		if inst.AllocatedStorage > 0 && inst.AllocatedStorage < gp3BaselineGiB {
+			small = append(small, fmt.Sprintf("%s (%d GiB)", inst.InstanceID, inst.AllocatedStorage))
+		}
+	}
+	if len(small) > 0 {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"gp3 volumes under %d GiB have a fixed baseline of 3000 IOPS and 125 MiBps and cannot take iops or storage_throughput: %s",
+			gp3BaselineGiB, strings.Join(small, ", "))
+	}
+
+	if iops != nil && (*iops < gp3MinIOPS || *iops > gp3MaxIOPS) {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"gp3 iops must be between %d and %d, got %d", gp3MinIOPS, gp3MaxIOPS, *iops)
+	}
+	if throughput != nil && (*throughput < gp3MinThroughput || *throughput > gp3MaxThroughput) {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"gp3 storage_throughput must be between %d and %d MiBps, got %d", gp3MinThroughput, gp3MaxThroughput, *throughput)
+	}
+	if iops != nil && throughput != nil && float64(*throughput) > float64(*iops)*gp3MaxThroughputPerIOPS {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"gp3 storage_throughput of %d MiBps needs at least %d iops (at most %.2f MiBps per IOPS)",
+			*throughput, int(float64(*throughput)/gp3MaxThroughputPerIOPS), gp3MaxThroughputPerIOPS)
+	}
+	return nil
+}
+
+// validateProvisionedIOPS checks io1 and io2 IOPS against each instance's
+// allocated storage.
+func validateProvisionedIOPS(target string, iops, throughput *int32, modified []types.InstanceInfo) error {
+	if throughput != nil {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s storage does not take storage_throughput; it scales with iops", target)
+	}
+	if iops == nil {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter, "%s storage requires iops", target)
+	}
+	if *iops < provisionedMinIOPS || *iops > provisionedMaxIOPS {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"%s iops must be between %d and %d, got %d", target, provisionedMinIOPS, provisionedMaxIOPS, *iops)
+	}
+
+	ratio := iopsPerGiB[target]
+	for _, inst := range modified {
+		storage := inst.AllocatedStorage
+		if storage == 0 {
+			continue
+		}
+		if storage < provisionedMinGiB {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s storage needs at least %d GiB allocated; instance %s has %d GiB", target, provisionedMinGiB, inst.InstanceID, storage)
+		}
+		minIOPS := int(ratio[0] * float64(storage))
+		maxIOPS := int(ratio[1] * float64(storage))
+		if int(*iops) < minIOPS || int(*iops) > maxIOPS {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s allows %g-%g IOPS per GiB; instance %s has %d GiB, so iops must be between %d and %d, got %d",
+				target, ratio[0], ratio[1], inst.InstanceID, storage, minIOPS, maxIOPS, *iops)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestValidateStorageSettings(t *testing.T) {
+	instances := func(storageType string, gib int32) []types.InstanceInfo {
+		return []types.InstanceInfo{
+			{InstanceID: "db-writer", Role: "writer", StorageType: storageType, AllocatedStorage: gib},
+			{InstanceID: "db-reader", Role: "reader", StorageType: storageType, AllocatedStorage: gib},
+			{InstanceID: "db-auto", Role: "reader", StorageType: storageType, AllocatedStorage: 20, IsAutoScaled: true},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		iops       *int32
+		throughput *int32
+		instances  []types.InstanceInfo
+		exclude    map[string]bool
+		wantErr    string
+	}{
+		// gp2 -> gp3
+		{name: "gp2 to gp3 baseline", target: "gp3", instances: instances("gp2", 200)},
+		{name: "gp2 to gp3 provisioned", target: "gp3", iops: aws.Int32(12000), throughput: aws.Int32(500), instances: instances("gp2", 500)},
+		{name: "gp2 to gp3 provisioned below baseline size", target: "gp3", iops: aws.Int32(12000), instances: instances("gp2", 200), wantErr: "fixed baseline"},
+		{name: "gp2 to gp3 iops out of range", target: "gp3", iops: aws.Int32(8000), instances: instances("gp2", 500), wantErr: "between 12000 and 64000"},
+		{name: "gp2 to gp3 throughput too high for iops", target: "gp3", iops: aws.Int32(12000), throughput: aws.Int32(4000), instances: instances("gp2", 500), wantErr: "needs at least 16000 iops"},
+		{name: "autoscaled reader size is ignored", target: "gp3", iops: aws.Int32(12000), instances: instances("gp2", 500)},
+
+		// gp3 -> io1
+		{name: "gp3 to io1", target: "io1", iops: aws.Int32(10000), instances: instances("gp3", 500)},
+		{name: "gp3 to io1 without iops", target: "io1", instances: instances("gp3", 500), wantErr: "requires iops"},
+		{name: "gp3 to io1 over 50 iops per GiB", target: "io1", iops: aws.Int32(30000), instances: instances("gp3", 500), wantErr: "between 500 and 25000"},
+		{name: "gp3 to io1 with throughput", target: "io1", iops: aws.Int32(10000), throughput: aws.Int32(500), instances: instances("gp3", 500), wantErr: "storage_throughput"},
+		{name: "gp3 to io1 too small", target: "io1", iops: aws.Int32(1000), instances: instances("gp3", 50), wantErr: "at least 100 GiB"},
+		{name: "io1 iops change keeps type", target: "io1", iops: aws.Int32(20000), instances: instances("io1", 500)},
+
+		{name: "gp2 takes no iops", target: "gp2", iops: aws.Int32(3000), instances: instances("gp3", 500), wantErr: "does not take iops"},
+		{name: "excluded instance is not checked", target: "io1", iops: aws.Int32(10000), instances: append(instances("gp3", 500),
+			types.InstanceInfo{InstanceID: "db-small", StorageType: "gp3", AllocatedStorage: 50}), exclude: map[string]bool{"db-small": true}},
+
+		// Aurora
+		{name: "aurora no-op", target: "aurora", instances: instances("aurora", 1), wantErr: "nothing to change"},
+		{name: "aurora to io-optimized", target: "aurora-iopt1", instances: instances("aurora", 1)},
+		{name: "aurora to gp3", target: "gp3", instances: instances("aurora", 1), wantErr: "Aurora managed storage"},
+		{name: "aurora with iops", target: "aurora-iopt1", iops: aws.Int32(3000), instances: instances("aurora", 1), wantErr: "Aurora manages IOPS"},
+		{name: "aurora type on ebs", target: "aurora-iopt1", instances: instances("gp3", 500), wantErr: "only applies to Aurora"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStorageSettings(tt.target, tt.iops, tt.throughput, tt.instances, tt.exclude)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+				t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBuildStorageTypeChangeSteps_AuroraNoOp verifies that asking an Aurora
+// cluster for the storage type it already has builds nothing.
+func TestBuildStorageTypeChangeSteps_AuroraNoOp(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	params, _ := json.Marshal(types.StorageTypeChangeParams{TargetStorageType: "aurora"})
+	op := &types.Operation{
+		ID:         "test-storage-noop",
+		Type:       types.OperationTypeStorageTypeChange,
+		State:      types.StateCreated,
+		ClusterID:  "demo-multi",
+		Region:     "us-east-1",
+		Parameters: params,
+		CreatedAt:  time.Now(),
+	}
+
+	err := engine.buildStorageTypeChangeSteps(context.Background(), op)
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter, got: %v", err)
+	}
+	if len(op.Steps) != 0 {
+		t.Errorf("expected no steps, got %d", len(op.Steps))
+	}
+}
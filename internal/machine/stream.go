@@ -0,0 +1,119 @@
+package machine
+
+import (
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// OperationUpdate is one message delivered to a progress subscriber. Exactly
+// one of Event or Progress is set.
+type OperationUpdate struct {
+	Event    *types.Event
+	Progress *types.OperationProgress
+}
+
+// Subscribe registers for live updates of an operation: each event as it is
+// appended and each change of operation or step state. The channel is
+// buffered; a subscriber that falls behind misses updates rather than
+// stalling the engine. Call the returned function to unsubscribe.
+func (e *Engine) Subscribe(operationID string) (<-chan OperationUpdate, func(), error) {
+	if _, err := e.GetOperation(operationID); err != nil {
+		return nil, nil, err
+	}
+
+	ch := make(chan OperationUpdate, constants.EventStreamBufferSize)
+
+	e.subMu.Lock()
+	if e.subscribers == nil {
+		e.subscribers = make(map[string]map[chan OperationUpdate]struct{})
+	}
+	if e.subscribers[operationID] == nil {
+		e.subscribers[operationID] = make(map[chan OperationUpdate]struct{})
+	}
+	e.subscribers[operationID][ch] = struct{}{}
+	e.subMu.Unlock()
+
+	unsubscribe := func() {
+		e.subMu.Lock()
+		defer e.subMu.Unlock()
+		if subs, ok := e.subscribers[operationID]; ok {
+			delete(subs, ch)
+			if len(subs) == 0 {
+				delete(e.subscribers, operationID)
+			}
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// Progress returns a snapshot of the operation's current state.
+func (e *Engine) Progress(operationID string) (types.OperationProgress, error) {
+	op, err := e.GetOperation(operationID)
+	if err != nil {
+		return types.OperationProgress{}, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return progressOf(op), nil
+}
+
+// progressOf builds a progress snapshot. Callers must not let op change underneath it.
+func progressOf(op *types.Operation) types.OperationProgress {
+	p := types.OperationProgress{
+		OperationID:      op.ID,
+		State:            op.State,
+		CurrentStepIndex: op.CurrentStepIndex,
+	}
+	if op.CurrentStepIndex >= 0 && op.CurrentStepIndex < len(op.Steps) {
+		step := op.Steps[op.CurrentStepIndex]
+		p.StepName = step.Name
+		p.StepState = step.State
+		p.WaitCondition = step.WaitCondition
+	}
+	return p
+}
+
+// publishEvent fans an event out to the operation's subscribers.
+func (e *Engine) publishEvent(event types.Event) {
+	e.publish(event.OperationID, OperationUpdate{Event: &event})
+}
+
+// publishProgress sends a progress snapshot to the operation's subscribers
+// if it differs from the last one sent, so repeated saves of an unchanged
+// operation do not flood the stream.
+func (e *Engine) publishProgress(op *types.Operation) {
+	p := progressOf(op)
+
+	e.subMu.Lock()
+	if e.lastProgress == nil {
+		e.lastProgress = make(map[string]types.OperationProgress)
+	}
+	last, seen := e.lastProgress[op.ID]
+	if seen && last == p {
+		e.subMu.Unlock()
+		return
+	}
+	e.lastProgress[op.ID] = p
+	e.subMu.Unlock()
+
+	if !seen || last.State != p.State {
+		e.metrics.OperationState(string(op.Type), string(p.State))
+	}
+
+	e.publish(op.ID, OperationUpdate{Progress: &p})
+}
+
+// publish delivers an update without blocking; full subscriber buffers drop it.
+func (e *Engine) publish(operationID string, update OperationUpdate) {
+	e.subMu.Lock()
+	defer e.subMu.Unlock()
+
+	for ch := range e.subscribers[operationID] {
+		select {
+		case ch <- update:
+		default:
+			e.logger.Debug("dropping update for slow progress subscriber", "operation_id", operationID)
+		}
+	}
+}
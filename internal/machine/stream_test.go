@@ -0,0 +1,103 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/constants"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestSubscribe_StreamsEventsAndStepTransitions(t *testing.T) {
+	engine, _, cleanup := testEngineWithMockState(t)
+	defer cleanup()
+	engine.registerHandlers()
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"skip_temp_instance":true,"exclude_instances":["demo-multi-writer"]}`)
+	op, err := engine.CreateOperation(ctx, types.OperationTypeInstanceCycle, "demo-multi", "us-east-1", params, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	updates, unsubscribe, err := engine.Subscribe(op.ID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer unsubscribe()
+
+	// A subscriber that never reads must not hold up the operation.
+	_, unsubscribeIdle, err := engine.Subscribe(op.ID)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	defer unsubscribeIdle()
+
+	if err := engine.StartOperation(ctx, op.ID); err != nil {
+		t.Fatalf("StartOperation failed: %v", err)
+	}
+
+	sawStepStarted := false
+	sawInProgress := false
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case <-deadline:
+			t.Fatalf("operation did not stream a terminal state (saw step_started=%v, in_progress=%v)", sawStepStarted, sawInProgress)
+		case update := <-updates:
+			if update.Event != nil && update.Event.Type == "step_started" {
+				sawStepStarted = true
+			}
+			if p := update.Progress; p != nil {
+				if p.StepState == types.StepStateInProgress {
+					sawInProgress = true
+				}
+				if p.State.IsTerminal() {
+					if p.State != types.StateCompleted {
+						t.Fatalf("terminal state = %s, want completed", p.State)
+					}
+					if !sawStepStarted || !sawInProgress {
+						t.Errorf("missing updates: step_started=%v in_progress=%v", sawStepStarted, sawInProgress)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestPublish_DropsWhenSubscriberBufferFull(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+
+	engine.operations["op-1"] = &types.Operation{ID: "op-1", State: types.StateRunning}
+	updates, unsubscribe, err := engine.Subscribe("op-1")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < constants.EventStreamBufferSize*2; i++ {
+			engine.addEvent("op-1", "info", "tick", nil)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("addEvent blocked on a full subscriber")
+	}
+	if got := len(updates); got != constants.EventStreamBufferSize {
+		t.Errorf("buffered updates = %d, want %d", got, constants.EventStreamBufferSize)
+	}
+
+	unsubscribe()
+	engine.addEvent("op-1", "info", "after unsubscribe", nil)
+	if got := len(updates); got != constants.EventStreamBufferSize {
+		t.Errorf("unsubscribed channel received updates: %d", got)
+	}
+}
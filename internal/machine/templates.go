@@ -0,0 +1,291 @@
+package machine
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cockroachdb/errors"
+	"github.com/google/uuid"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// TemplateSpec describes a template to save. When OperationID is set the
+// type, parameters and wait timeout are taken from that operation; otherwise
+// Type and Params are used as given.
+type TemplateSpec struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operation_id,omitempty"`
+	Type        types.OperationType `json:"type,omitempty"`
+	Params      json.RawMessage     `json:"params,omitempty"`
+	WaitTimeout int                 `json:"wait_timeout,omitempty"`
+}
+
+// SaveTemplate stores a reusable operation definition. Parameters that name
+// resources of a specific cluster are stripped so the template can be applied
+// to any cluster.
+func (e *Engine) SaveTemplate(ctx context.Context, spec TemplateSpec) (*types.Template, error) {
+	if strings.TrimSpace(spec.Name) == "" {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "template name is required")
+	}
+
+	tmpl := &types.Template{
+		ID:          uuid.New().String(),
+		Name:        spec.Name,
+		Description: spec.Description,
+		Type:        spec.Type,
+		WaitTimeout: spec.WaitTimeout,
+		CreatedAt:   time.Now(),
+	}
+	params := spec.Params
+
+	if spec.OperationID != "" {
+		op, err := e.GetOperation(spec.OperationID)
+		if err != nil {
+			return nil, err
+		}
+		e.mu.RLock()
+		tmpl.Type = op.Type
+		tmpl.WaitTimeout = op.WaitTimeout
+		params = slices.Clone(op.Parameters)
+		e.mu.RUnlock()
+		tmpl.SourceOperationID = op.ID
+	}
+
+	if !types.ValidOperationTypes[tmpl.Type] {
+		return nil, errors.Wrapf(internalerrors.ErrInvalidParameter, "unknown operation type: %s", tmpl.Type)
+	}
+
+	stripped, err := stripClusterSpecificParams(params)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Parameters = stripped
+	if err := tmpl.ValidateParams(); err != nil {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, err.Error())
+	}
+
+	if err := e.store.SaveTemplate(ctx, tmpl); err != nil {
+		return nil, errors.Wrap(err, "save template")
+	}
+
+	e.mu.Lock()
+	if e.templates == nil {
+		e.templates = make(map[string]*types.Template)
+	}
+	e.templates[tmpl.ID] = tmpl
+	e.mu.Unlock()
+
+	e.logger.Info("saved operation template",
+		"template_id", tmpl.ID,
+		"name", tmpl.Name,
+		"type", tmpl.Type,
+		"source_operation_id", tmpl.SourceOperationID)
+
+	return tmpl, nil
+}
+
+// GetTemplate returns a template by ID or, failing that, by name. A name
+// shared by several saved templates does not pick one of them.
+func (e *Engine) GetTemplate(id string) (*types.Template, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if tmpl, ok := e.templates[id]; ok {
+		return tmpl, nil
+	}
+	var named []*types.Template
+	for _, tmpl := range e.templates {
+		if tmpl.Name == id {
+			named = append(named, tmpl)
+		}
+	}
+	switch len(named) {
+	case 0:
+		return nil, errors.Wrapf(internalerrors.ErrTemplateNotFound, "template %s", id)
+	case 1:
+		return named[0], nil
+	}
+	return nil, errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"%d templates are named %s; use a template ID", len(named), id)
+}
+
+// ListTemplates returns all templates, oldest first, and by name among
+// templates created together.
+func (e *Engine) ListTemplates() []*types.Template {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	templates := make([]*types.Template, 0, len(e.templates))
+	for _, tmpl := range e.templates {
+		templates = append(templates, tmpl)
+	}
+	slices.SortFunc(templates, func(a, b *types.Template) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.Name, b.Name))
+	})
+	return templates
+}
+
+// DeleteTemplate removes a template. Operations created from it are unaffected.
+// Configured templates can only be removed from the templates file.
+func (e *Engine) DeleteTemplate(ctx context.Context, id string) error {
+	tmpl, err := e.GetTemplate(id)
+	if err != nil {
+		return err
+	}
+	if tmpl.Configured {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"template %s is defined in the templates file and cannot be deleted", tmpl.Name)
+	}
+	if err := e.store.DeleteTemplate(ctx, tmpl.ID); err != nil {
+		return errors.Wrap(err, "delete template")
+	}
+
+	e.mu.Lock()
+	delete(e.templates, tmpl.ID)
+	e.mu.Unlock()
+	return nil
+}
+
+// CreateOperationFromTemplate creates an operation for clusterID from a saved
+// template. Top-level keys in overrides replace the template's parameters,
+// which is how cluster-specific values such as exclude_instances are supplied.
+func (e *Engine) CreateOperationFromTemplate(ctx context.Context, templateID, clusterID, region string, overrides json.RawMessage, opts CreateOptions) (*types.Operation, error) {
+	tmpl, err := e.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if clusterID == "" {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "cluster_id is required when creating from a template")
+	}
+
+	params, err := mergeParams(tmpl.Parameters, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if opts.WaitTimeout == 0 {
+		opts.WaitTimeout = tmpl.WaitTimeout
+	}
+
+	op, err := e.CreateOperation(ctx, tmpl.Type, clusterID, region, params, opts)
+	if err != nil {
+		return nil, err
+	}
+	e.addEvent(op.ID, "info", fmt.Sprintf("Created from template %s (%s)", tmpl.Name, tmpl.ID), nil)
+	return op, nil
+}
+
+// templateFileEntry is one template in a templates file.
+type templateFileEntry struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Type        types.OperationType `json:"type"`
+	Params      json.RawMessage     `json:"params,omitempty"`
+	WaitTimeout int                 `json:"wait_timeout,omitempty"`
+}
+
+// LoadTemplates reads the named templates defined in a JSON file, an array of
+// objects with name, description, type, params and wait_timeout. A name is
+// used as the template's ID, so it must be unique and free of whitespace.
+// Every template is checked against its operation type's parameters, so a
+// mistake fails at startup rather than when the template is first used. An
+// empty path loads nothing.
+func LoadTemplates(path string) ([]*types.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read templates %s", path)
+	}
+	var entries []templateFileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Wrapf(err, "parse templates %s", path)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(entries))
+	templates := make([]*types.Template, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Name == "" || strings.ContainsFunc(entry.Name, unicode.IsSpace) {
+			return nil, errors.Newf("templates %s: entry %d: name %q must be non-empty with no whitespace", path, i, entry.Name)
+		}
+		if seen[entry.Name] {
+			return nil, errors.Newf("templates %s: %s is defined more than once", path, entry.Name)
+		}
+		seen[entry.Name] = true
+
+		tmpl := &types.Template{
+			ID:          entry.Name,
+			Name:        entry.Name,
+			Description: entry.Description,
+			Type:        entry.Type,
+			Parameters:  entry.Params,
+			WaitTimeout: entry.WaitTimeout,
+			Configured:  true,
+			CreatedAt:   now,
+		}
+		if err := tmpl.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "templates %s: %s", path, entry.Name)
+		}
+		if err := tmpl.ValidateParams(); err != nil {
+			return nil, errors.Wrapf(err, "templates %s: %s", path, entry.Name)
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+// stripClusterSpecificParams removes parameters listed in
+// types.ClusterSpecificParams from a parameter object.
+func stripClusterSpecificParams(params json.RawMessage) (json.RawMessage, error) {
+	if len(params) == 0 || string(params) == "null" {
+		return nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "params must be a JSON object")
+	}
+	for _, key := range types.ClusterSpecificParams {
+		delete(fields, key)
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal template params")
+	}
+	return out, nil
+}
+
+// mergeParams returns base with the top-level keys of overrides applied.
+func mergeParams(base, overrides json.RawMessage) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	for _, raw := range []json.RawMessage{base, overrides} {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var layer map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &layer); err != nil {
+			return nil, errors.Wrap(internalerrors.ErrInvalidParameter, "params must be a JSON object")
+		}
+		for k, v := range layer {
+			fields[k] = v
+		}
+	}
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal merged params")
+	}
+	return out, nil
+}
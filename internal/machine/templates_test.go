@@ -0,0 +1,211 @@
+package machine
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/storage"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestSaveTemplate_InstantiatesOnDifferentCluster(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	engine.store = store
+
+	params := json.RawMessage(`{"target_instance_type":"db.r6g.xlarge","exclude_instances":["demo-multi-reader-2"],"skip_temp_instance":true}`)
+	source, err := engine.CreateOperation(ctx, types.OperationTypeInstanceTypeChange, "demo-multi", "us-east-1", params, CreateOptions{WaitTimeout: 900})
+	if err != nil {
+		t.Fatalf("CreateOperation failed: %v", err)
+	}
+
+	tmpl, err := engine.SaveTemplate(ctx, TemplateSpec{Name: "resize to xlarge", OperationID: source.ID})
+	if err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if tmpl.Type != types.OperationTypeInstanceTypeChange || tmpl.WaitTimeout != 900 || tmpl.SourceOperationID != source.ID {
+		t.Errorf("unexpected template: %+v", tmpl)
+	}
+	if strings.Contains(string(tmpl.Parameters), "exclude_instances") {
+		t.Errorf("template kept cluster-specific params: %s", tmpl.Parameters)
+	}
+
+	// Templates survive a restart.
+	engine.templates = nil
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	if got := engine.ListTemplates(); len(got) != 1 || got[0].ID != tmpl.ID {
+		t.Fatalf("templates after reload = %v, want [%s]", got, tmpl.ID)
+	}
+
+	op, err := engine.CreateOperationFromTemplate(ctx, tmpl.ID, "demo-upgrade", "", nil, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperationFromTemplate failed: %v", err)
+	}
+	if op.ClusterID != "demo-upgrade" || op.Type != types.OperationTypeInstanceTypeChange || op.WaitTimeout != 900 {
+		t.Errorf("unexpected operation: cluster=%s type=%s wait_timeout=%d", op.ClusterID, op.Type, op.WaitTimeout)
+	}
+
+	var modified []string
+	for _, step := range op.Steps {
+		if step.Action != "modify_instance" {
+			continue
+		}
+		var p map[string]any
+		if err := json.Unmarshal(step.Parameters, &p); err != nil {
+			t.Fatalf("unmarshal modify params: %v", err)
+		}
+		if p["instance_type"] != "db.r6g.xlarge" {
+			t.Errorf("%s: instance_type = %v, want db.r6g.xlarge", step.Name, p["instance_type"])
+		}
+		modified = append(modified, p["instance_id"].(string))
+	}
+	if len(modified) != 2 {
+		t.Errorf("modified instances = %v, want both demo-upgrade instances", modified)
+	}
+	for _, id := range modified {
+		if !strings.HasPrefix(id, "demo-upgrade-") {
+			t.Errorf("operation from template modifies %s, outside demo-upgrade", id)
+		}
+	}
+
+	if _, err := engine.CreateOperationFromTemplate(ctx, "missing", "demo-single", "", nil, CreateOptions{}); !errors.Is(err, internalerrors.ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got: %v", err)
+	}
+}
+
+func TestMergeParams_OverridesTopLevelKeys(t *testing.T) {
+	merged, err := mergeParams(
+		json.RawMessage(`{"target_instance_type":"db.r6g.large","skip_temp_instance":true}`),
+		json.RawMessage(`{"target_instance_type":"db.r6g.xlarge","exclude_instances":["a"]}`),
+	)
+	if err != nil {
+		t.Fatalf("mergeParams failed: %v", err)
+	}
+
+	var got types.InstanceTypeChangeParams
+	if err := json.Unmarshal(merged, &got); err != nil {
+		t.Fatalf("unmarshal merged params: %v", err)
+	}
+	if got.TargetInstanceType != "db.r6g.xlarge" || !got.SkipTempInstance || len(got.ExcludeInstances) != 1 {
+		t.Errorf("unexpected merged params: %s", merged)
+	}
+
+	if _, err := mergeParams(json.RawMessage(`[1]`), nil); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("expected ErrInvalidParameter for non-object params, got: %v", err)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	load := func(t *testing.T, file string) ([]*types.Template, error) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "templates.json")
+		if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return LoadTemplates(path)
+	}
+
+	templates, err := load(t, `[
+		{"name":"scale-up-r6g-xlarge","type":"instance_type_change","params":{"target_instance_type":"db.r6g.xlarge","tags":{"Team":"dba"}}},
+		{"name":"migrate-to-io-optimized","type":"storage_type_change","params":{"target_storage_type":"aurora-iopt1"},"wait_timeout":3600}
+	]`)
+	if err != nil {
+		t.Fatalf("LoadTemplates failed: %v", err)
+	}
+	if len(templates) != 2 || templates[0].ID != "scale-up-r6g-xlarge" || !templates[0].Configured || templates[1].WaitTimeout != 3600 {
+		t.Errorf("unexpected templates: %+v", templates)
+	}
+
+	if templates, err := LoadTemplates(""); err != nil || templates != nil {
+		t.Errorf("empty path: got %v, %v; want nothing", templates, err)
+	}
+
+	for name, file := range map[string]string{
+		"unknown param":   `[{"name":"a","type":"instance_type_change","params":{"target_instance_typ":"db.r6g.xlarge"}}]`,
+		"wrong type":      `[{"name":"a","type":"storage_type_change","params":{"iops":"12000"}}]`,
+		"unknown op type": `[{"name":"a","type":"resize"}]`,
+		"blank name":      `[{"name":"scale up","type":"instance_cycle"}]`,
+		"duplicate name":  `[{"name":"a","type":"instance_cycle"},{"name":"a","type":"reboot_cluster"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := load(t, file); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfiguredTemplates(t *testing.T) {
+	engine, cleanup := testEngineWithMockServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore failed: %v", err)
+	}
+	engine.store = store
+	configured := &types.Template{
+		ID:         "cycle-keep-writer",
+		Name:       "cycle-keep-writer",
+		Type:       types.OperationTypeInstanceCycle,
+		Parameters: json.RawMessage(`{"skip_temp_instance":true}`),
+		Configured: true,
+	}
+	engine.templates = map[string]*types.Template{configured.ID: configured}
+
+	saved, err := engine.SaveTemplate(ctx, TemplateSpec{Name: "resize", Type: types.OperationTypeInstanceTypeChange,
+		Params: json.RawMessage(`{"target_instance_type":"db.r6g.xlarge"}`)})
+	if err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+
+	// Configured templates are not stored but survive a reload.
+	if _, err := engine.LoadFromStore(ctx); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+	if got := engine.ListTemplates(); len(got) != 2 {
+		t.Fatalf("templates after reload = %d, want 2", len(got))
+	}
+
+	if got, err := engine.GetTemplate("resize"); err != nil || got.ID != saved.ID {
+		t.Errorf("GetTemplate by name = %v, %v; want %s", got, err, saved.ID)
+	}
+	if _, err := engine.SaveTemplate(ctx, TemplateSpec{Name: "resize", Type: types.OperationTypeInstanceTypeChange}); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if _, err := engine.GetTemplate("resize"); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("ambiguous name: expected ErrInvalidParameter, got: %v", err)
+	}
+
+	if err := engine.DeleteTemplate(ctx, configured.ID); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("deleting a configured template: expected ErrInvalidParameter, got: %v", err)
+	}
+
+	op, err := engine.CreateOperationFromTemplate(ctx, "cycle-keep-writer", "demo-single", "", nil, CreateOptions{})
+	if err != nil {
+		t.Fatalf("CreateOperationFromTemplate failed: %v", err)
+	}
+	if op.Type != types.OperationTypeInstanceCycle {
+		t.Errorf("operation type = %s, want instance_cycle", op.Type)
+	}
+
+	if _, err := engine.SaveTemplate(ctx, TemplateSpec{Name: "typo", Type: types.OperationTypeInstanceCycle,
+		Params: json.RawMessage(`{"skip_temp_instances":true}`)}); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Errorf("params unknown to the type: expected ErrInvalidParameter, got: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package machine
+
+import (
+	"fmt"
+	"time"
+
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// OutsideWindowError rejects an operation requested outside every configured
+// maintenance window. It matches internalerrors.ErrOutsideMaintenanceWindow.
+type OutsideWindowError struct {
+	// Windows describes the configured windows and their time zone.
+	Windows string
+	// NextStart is when the next window opens.
+	NextStart time.Time
+}
+
+func (e *OutsideWindowError) Error() string {
+	return fmt.Sprintf("outside maintenance window %s; next window opens %s",
+		e.Windows, e.NextStart.UTC().Format(time.RFC3339))
+}
+
+func (e *OutsideWindowError) Unwrap() error {
+	return internalerrors.ErrOutsideMaintenanceWindow
+}
+
+// checkMaintenanceWindow returns an OutsideWindowError if windows are
+// configured and now is outside all of them. With override set the operation
+// is allowed and a warning describing the override is returned instead.
+func (e *Engine) checkMaintenanceWindow(now time.Time, override bool) (string, error) {
+	if e.maintenanceWindow == nil || e.maintenanceWindow.Contains(now) {
+		return "", nil
+	}
+	if override {
+		return fmt.Sprintf("Created outside maintenance window %s by override", e.maintenanceWindow), nil
+	}
+	return "", &OutsideWindowError{
+		Windows:   e.maintenanceWindow.String(),
+		NextStart: e.maintenanceWindow.NextStart(now),
+	}
+}
@@ -0,0 +1,215 @@
+// Package maintwindow parses weekly maintenance windows and answers whether a
+// time falls inside one.
+package maintwindow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a weekly recurring period, in the same ddd:hh24:mi-ddd:hh24:mi
+// form RDS uses for preferred maintenance windows. A window whose end is
+// earlier in the week than its start wraps past Saturday midnight.
+type Window struct {
+	start int // minute of the week, Sunday 00:00 = 0
+	end   int
+}
+
+// String returns the window in ddd:hh24:mi-ddd:hh24:mi form.
+func (w Window) String() string {
+	return formatMinute(w.start) + "-" + formatMinute(w.end)
+}
+
+// contains reports whether a minute of the week falls inside the window.
+// The end minute is exclusive.
+func (w Window) contains(minute int) bool {
+	if w.start <= w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// Schedule is a set of windows evaluated in one time zone.
+type Schedule struct {
+	windows  []Window
+	location *time.Location
+}
+
+// Parse parses a comma-separated list of windows such as
+// "Sun:03:00-Sun:05:00,Wed:22:00-Thu:01:00". Times are in the named IANA time
+// zone; an empty zone means UTC.
+func Parse(spec, zone string) (*Schedule, error) {
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, errors.Wrapf(err, "load time zone %q", zone)
+		}
+	}
+
+	s := &Schedule{location: loc}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := parseWindow(part)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+	if len(s.windows) == 0 {
+		return nil, errors.Newf("no maintenance windows in %q", spec)
+	}
+	return s, nil
+}
+
+func parseWindow(spec string) (Window, error) {
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return Window{}, errors.Newf("window %q must be ddd:hh:mm-ddd:hh:mm", spec)
+	}
+	start, err := parseMinute(from)
+	if err != nil {
+		return Window{}, errors.Wrapf(err, "window %q start", spec)
+	}
+	end, err := parseMinute(to)
+	if err != nil {
+		return Window{}, errors.Wrapf(err, "window %q end", spec)
+	}
+	if start == end {
+		return Window{}, errors.Newf("window %q is empty", spec)
+	}
+	return Window{start: start, end: end}, nil
+}
+
+// parseMinute converts ddd:hh:mm to a minute of the week.
+func parseMinute(s string) (int, error) {
+	fields := strings.Split(strings.TrimSpace(s), ":")
+	if len(fields) != 3 {
+		return 0, errors.Newf("%q must be ddd:hh:mm", s)
+	}
+	day, ok := weekdays[strings.ToLower(fields[0])]
+	if !ok {
+		return 0, errors.Newf("unknown day %q", fields[0])
+	}
+	hour, err := strconv.Atoi(fields[1])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, errors.Newf("invalid hour %q", fields[1])
+	}
+	minute, err := strconv.Atoi(fields[2])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, errors.Newf("invalid minute %q", fields[2])
+	}
+	return int(day)*24*60 + hour*60 + minute, nil
+}
+
+func formatMinute(m int) string {
+	day := time.Weekday(m / (24 * 60)).String()[:3]
+	return fmt.Sprintf("%s:%02d:%02d", day, m%(24*60)/60, m%60)
+}
+
+// Windows returns the parsed windows.
+func (s *Schedule) Windows() []Window {
+	return s.windows
+}
+
+// Location returns the time zone the windows are evaluated in.
+func (s *Schedule) Location() *time.Location {
+	return s.location
+}
+
+// String returns the windows and their time zone.
+func (s *Schedule) String() string {
+	parts := make([]string, len(s.windows))
+	for i, w := range s.windows {
+		parts[i] = w.String()
+	}
+	return strings.Join(parts, ",") + " " + s.location.String()
+}
+
+// Contains reports whether t falls inside any window.
+func (s *Schedule) Contains(t time.Time) bool {
+	local := t.In(s.location)
+	minute := int(local.Weekday())*24*60 + local.Hour()*60 + local.Minute()
+	for _, w := range s.windows {
+		if w.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStart returns the earliest window start after t.
+func (s *Schedule) NextStart(t time.Time) time.Time {
+	local := t.In(s.location)
+	var next time.Time
+	for _, w := range s.windows {
+		day := w.start / (24 * 60)
+		hour := w.start % (24 * 60) / 60
+		minute := w.start % 60
+
+		// Try this week's occurrence, then next week's; building the date
+		// in the zone keeps the wall-clock time right across DST changes.
+		offset := (day - int(local.Weekday()) + 7) % 7
+		for _, extra := range []int{0, 7} {
+			candidate := time.Date(local.Year(), local.Month(), local.Day()+offset+extra, hour, minute, 0, 0, s.location)
+			if candidate.After(t) {
+				if next.IsZero() || candidate.Before(next) {
+					next = candidate
+				}
+				break
+			}
+		}
+	}
+	return next
+}
+
+// Next returns t if it falls inside a window, or else the next window start.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.Contains(t) {
+		return t
+	}
+	return s.NextStart(t)
+}
+
+// NextCommon returns the earliest time from t on that falls inside a window of
+// every schedule, or false if their windows never overlap. Nil schedules
+// place no constraint.
+func NextCommon(t time.Time, schedules ...*Schedule) (time.Time, bool) {
+	// Each pass moves t to the next time one schedule allows, so it settles
+	// on the start of the first overlap. Windows repeat weekly; a week and a
+	// day without one means there is none.
+	limit := t.Add(8 * 24 * time.Hour)
+	for !t.After(limit) {
+		moved := false
+		for _, s := range schedules {
+			if s == nil {
+				continue
+			}
+			if next := s.Next(t); !next.Equal(t) {
+				t = next
+				moved = true
+			}
+		}
+		if !moved {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
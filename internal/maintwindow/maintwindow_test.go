@@ -0,0 +1,140 @@
+package maintwindow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "Sun:03:00-Sun:05:00", want: "Sun:03:00-Sun:05:00 UTC"},
+		{spec: "sun:03:00-sun:05:00, wed:22:30-thu:01:00", want: "Sun:03:00-Sun:05:00,Wed:22:30-Thu:01:00 UTC"},
+		{spec: "Sat:23:00-Sun:01:00", zone: "America/New_York", want: "Sat:23:00-Sun:01:00 America/New_York"},
+		{spec: "", wantErr: true},
+		{spec: "Sun:03:00", wantErr: true},
+		{spec: "Xyz:03:00-Sun:05:00", wantErr: true},
+		{spec: "Sun:24:00-Sun:05:00", wantErr: true},
+		{spec: "Sun:03:60-Sun:05:00", wantErr: true},
+		{spec: "Sun:03:00-Sun:03:00", wantErr: true},
+		{spec: "Sun:03:00-Sun:05:00", zone: "Not/AZone", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec+" "+tt.zone, func(t *testing.T) {
+			s, err := Parse(tt.spec, tt.zone)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", s)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if got := s.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_ContainsAndNextStart(t *testing.T) {
+	// 2026-01-04 is a Sunday.
+	sunday := func(hour, minute int) time.Time {
+		return time.Date(2026, 1, 4, hour, minute, 0, 0, time.UTC)
+	}
+
+	s, err := Parse("Sun:03:00-Sun:05:00,Sat:23:00-Sun:01:00", "")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		contains bool
+		next     time.Time
+	}{
+		{"inside", sunday(4, 0), true, sunday(3, 0).AddDate(0, 0, 6).Add(20 * time.Hour)},
+		{"start is inclusive", sunday(3, 0), true, sunday(23, 0).AddDate(0, 0, 6)},
+		{"end is exclusive", sunday(5, 0), false, sunday(23, 0).AddDate(0, 0, 6)},
+		{"between windows", sunday(2, 0), false, sunday(3, 0)},
+		{"wrapped window after midnight", sunday(0, 30), true, sunday(3, 0)},
+		{"wrapped window before midnight", sunday(23, 30).AddDate(0, 0, -1), true, sunday(3, 0)},
+		{"midweek", sunday(12, 0).AddDate(0, 0, 3), false, sunday(23, 0).AddDate(0, 0, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Contains(tt.at); got != tt.contains {
+				t.Errorf("Contains(%s) = %v, want %v", tt.at, got, tt.contains)
+			}
+			if got := s.NextStart(tt.at); !got.Equal(tt.next) {
+				t.Errorf("NextStart(%s) = %s, want %s", tt.at, got, tt.next)
+			}
+		})
+	}
+}
+
+func TestSchedule_TimeZone(t *testing.T) {
+	s, err := Parse("Sun:03:00-Sun:05:00", "America/New_York")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// 03:30 in New York on 2026-01-04 (EST, UTC-5) is 08:30 UTC.
+	inside := time.Date(2026, 1, 4, 8, 30, 0, 0, time.UTC)
+	if !s.Contains(inside) {
+		t.Errorf("expected %s to be inside the New York window", inside)
+	}
+	if s.Contains(time.Date(2026, 1, 4, 3, 30, 0, 0, time.UTC)) {
+		t.Error("03:30 UTC is 22:30 Saturday in New York and should be outside")
+	}
+
+	// After DST starts (2026-03-08) the window opens at 07:00 UTC.
+	next := s.NextStart(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("NextStart = %s, want %s", next.UTC(), want)
+	}
+}
+
+func TestNextCommon(t *testing.T) {
+	// 2026-01-04 is a Sunday.
+	sunday := func(hour, minute int) time.Time {
+		return time.Date(2026, 1, 4, hour, minute, 0, 0, time.UTC)
+	}
+	mustParse := func(spec string) *Schedule {
+		s, err := Parse(spec, "")
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", spec, err)
+		}
+		return s
+	}
+	cluster := mustParse("sun:05:00-sun:05:30")
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		global *Schedule
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "inside the window", from: sunday(5, 10), want: sunday(5, 10), wantOK: true},
+		{name: "before the window", from: sunday(1, 0), want: sunday(5, 0), wantOK: true},
+		{name: "after the window", from: sunday(6, 0), want: sunday(5, 0).AddDate(0, 0, 7), wantOK: true},
+		{name: "global window opens later", from: sunday(1, 0), global: mustParse("Sun:05:15-Sun:08:00"), want: sunday(5, 15), wantOK: true},
+		{name: "global window covers", from: sunday(1, 0), global: mustParse("Sun:00:00-Mon:00:00"), want: sunday(5, 0), wantOK: true},
+		{name: "no overlap", from: sunday(1, 0), global: mustParse("Wed:01:00-Wed:03:00")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NextCommon(tt.from, cluster, tt.global)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("NextCommon = %s, %v; want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
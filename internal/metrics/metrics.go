@@ -0,0 +1,230 @@
+// Package metrics records operation and step outcomes and renders them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric names exposed by the registry.
+const (
+	metricOperationsTotal     = "rdsmaint_operations_total"
+	metricStepsTotal          = "rdsmaint_steps_total"
+	metricStepDurationSeconds = "rdsmaint_step_duration_seconds"
+	metricWaitPolls           = "rdsmaint_wait_polls"
+	metricBlueGreenStatus     = "rdsmaint_blue_green_status"
+)
+
+// stepDurationBuckets spans quick API calls up to multi-hour Blue-Green waits.
+var stepDurationBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+
+// waitPollBuckets covers waits that finish on the first poll up to long waits
+// at the default 30s interval.
+var waitPollBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500}
+
+// Registry holds the application's metrics. A nil *Registry is valid and
+// records nothing, so callers do not need to check whether metrics are enabled.
+type Registry struct {
+	mu         sync.Mutex
+	counters   map[string]map[string]float64 // name -> label set -> value
+	histograms map[string]map[string]*histogram
+	gauges     map[string]map[string]float64
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64 // cumulative counts are computed on output
+	sum     float64
+	count   uint64
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		counters:   make(map[string]map[string]float64),
+		histograms: make(map[string]map[string]*histogram),
+		gauges:     make(map[string]map[string]float64),
+	}
+}
+
+// OperationState counts an operation entering a state.
+func (r *Registry) OperationState(opType, state string) {
+	r.incCounter(metricOperationsTotal, labels("type", opType, "state", state))
+}
+
+// StepFinished counts a step reaching a final state and records how long it ran.
+func (r *Registry) StepFinished(action, state string, seconds float64) {
+	r.incCounter(metricStepsTotal, labels("action", action, "state", state))
+	if seconds >= 0 {
+		r.observe(metricStepDurationSeconds, labels("action", action), stepDurationBuckets, seconds)
+	}
+}
+
+// WaitPolls records how many polls a wait step made before returning.
+func (r *Registry) WaitPolls(action string, polls int) {
+	r.observe(metricWaitPolls, labels("action", action), waitPollBuckets, float64(polls))
+}
+
+// BlueGreenStatus sets the current status of a Blue-Green deployment. The
+// series for the current status is 1; earlier statuses of the same
+// deployment are removed.
+func (r *Registry) BlueGreenStatus(deploymentID, status string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series := r.gauges[metricBlueGreenStatus]
+	if series == nil {
+		series = make(map[string]float64)
+		r.gauges[metricBlueGreenStatus] = series
+	}
+	prefix := labels("deployment_id", deploymentID)
+	for key := range series {
+		if strings.HasPrefix(key, strings.TrimSuffix(prefix, "}")+",") {
+			delete(series, key)
+		}
+	}
+	series[labels("deployment_id", deploymentID, "status", status)] = 1
+}
+
+func (r *Registry) incCounter(name, labelSet string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series := r.counters[name]
+	if series == nil {
+		series = make(map[string]float64)
+		r.counters[name] = series
+	}
+	series[labelSet]++
+}
+
+func (r *Registry) observe(name, labelSet string, buckets []float64, value float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series := r.histograms[name]
+	if series == nil {
+		series = make(map[string]*histogram)
+		r.histograms[name] = series
+	}
+	h := series[labelSet]
+	if h == nil {
+		h = &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+		series[labelSet] = h
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// help text for each metric, used in the exposition output.
+var help = map[string]string{
+	metricOperationsTotal:     "Operations that entered each state, by operation type.",
+	metricStepsTotal:          "Steps that finished, by action and final state.",
+	metricStepDurationSeconds: "Time from a step starting to it finishing, including retries.",
+	metricWaitPolls:           "Polls made by wait steps before they returned.",
+	metricBlueGreenStatus:     "Current status of Blue-Green deployments being waited on.",
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	if r != nil {
+		r.mu.Lock()
+		for _, name := range sortedKeys(r.counters) {
+			writeHeader(&b, name, "counter")
+			series := r.counters[name]
+			for _, labelSet := range sortedKeys(series) {
+				fmt.Fprintf(&b, "%s%s %s\n", name, labelSet, formatFloat(series[labelSet]))
+			}
+		}
+		for _, name := range sortedKeys(r.histograms) {
+			writeHeader(&b, name, "histogram")
+			series := r.histograms[name]
+			for _, labelSet := range sortedKeys(series) {
+				h := series[labelSet]
+				var cumulative uint64
+				for i, upper := range h.buckets {
+					cumulative += h.counts[i]
+					fmt.Fprintf(&b, "%s_bucket%s %d\n", name, withLabel(labelSet, "le", formatFloat(upper)), cumulative)
+				}
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, withLabel(labelSet, "le", "+Inf"), h.count)
+				fmt.Fprintf(&b, "%s_sum%s %s\n", name, labelSet, formatFloat(h.sum))
+				fmt.Fprintf(&b, "%s_count%s %d\n", name, labelSet, h.count)
+			}
+		}
+		for _, name := range sortedKeys(r.gauges) {
+			writeHeader(&b, name, "gauge")
+			series := r.gauges[name]
+			for _, labelSet := range sortedKeys(series) {
+				fmt.Fprintf(&b, "%s%s %s\n", name, labelSet, formatFloat(series[labelSet]))
+			}
+		}
+		r.mu.Unlock()
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHeader(b *strings.Builder, name, kind string) {
+	if text, ok := help[name]; ok {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, text)
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+}
+
+// labels renders alternating key/value pairs as a Prometheus label set.
+func labels(kv ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%s", kv[i], strconv.Quote(kv[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// withLabel appends one label to a rendered label set.
+func withLabel(labelSet, key, value string) string {
+	extra := fmt.Sprintf("%s=%s", key, strconv.Quote(value))
+	if labelSet == "{}" {
+		return "{" + extra + "}"
+	}
+	return strings.TrimSuffix(labelSet, "}") + "," + extra + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := New()
+	r.OperationState("instance_type_change", "running")
+	r.OperationState("instance_type_change", "completed")
+	r.StepFinished("modify_instance", "completed", 12)
+	r.StepFinished("modify_instance", "completed", 40)
+	r.StepFinished("modify_instance", "failed", 2)
+	r.WaitPolls("wait_instance_available", 3)
+	r.BlueGreenStatus("bgd-1", "PROVISIONING")
+	r.BlueGreenStatus("bgd-1", "AVAILABLE")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := b.String()
+
+	want := []string{
+		"# TYPE rdsmaint_operations_total counter",
+		`rdsmaint_operations_total{type="instance_type_change",state="completed"} 1`,
+		`rdsmaint_steps_total{action="modify_instance",state="completed"} 2`,
+		`rdsmaint_steps_total{action="modify_instance",state="failed"} 1`,
+		"# TYPE rdsmaint_step_duration_seconds histogram",
+		`rdsmaint_step_duration_seconds_bucket{action="modify_instance",le="5"} 1`,
+		`rdsmaint_step_duration_seconds_bucket{action="modify_instance",le="15"} 2`,
+		`rdsmaint_step_duration_seconds_bucket{action="modify_instance",le="+Inf"} 3`,
+		`rdsmaint_step_duration_seconds_sum{action="modify_instance"} 54`,
+		`rdsmaint_step_duration_seconds_count{action="modify_instance"} 3`,
+		`rdsmaint_wait_polls_bucket{action="wait_instance_available",le="5"} 1`,
+		`rdsmaint_blue_green_status{deployment_id="bgd-1",status="AVAILABLE"} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("output missing %q\n%s", line, out)
+		}
+	}
+	if strings.Contains(out, "PROVISIONING") {
+		t.Errorf("previous Blue-Green status should be replaced\n%s", out)
+	}
+}
+
+func TestRegistry_NilIsNoop(t *testing.T) {
+	var r *Registry
+	r.OperationState("instance_type_change", "running")
+	r.StepFinished("modify_instance", "completed", 1)
+	r.WaitPolls("wait_instance_available", 1)
+	r.BlueGreenStatus("bgd-1", "AVAILABLE")
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected no output, got %q", b.String())
+	}
+}
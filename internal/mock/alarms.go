@@ -0,0 +1,162 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go/encoding/cbor"
+)
+
+// CloudWatch alarm states.
+const (
+	AlarmStateOK               = "OK"
+	AlarmStateAlarm            = "ALARM"
+	AlarmStateInsufficientData = "INSUFFICIENT_DATA"
+)
+
+// MockAlarm represents a CloudWatch metric alarm on a cluster.
+type MockAlarm struct {
+	Name       string    `json:"name"`
+	ClusterID  string    `json:"cluster_id"`  // DBClusterIdentifier dimension
+	MetricName string    `json:"metric_name"` // e.g. AuroraReplicaLag, CPUUtilization
+	State      string    `json:"state"`       // OK, ALARM or INSUFFICIENT_DATA
+	Reason     string    `json:"reason,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PutAlarm creates or replaces an alarm. An empty state defaults to OK.
+func (s *State) PutAlarm(alarm MockAlarm) error {
+	if alarm.Name == "" {
+		return fmt.Errorf("alarm name is required")
+	}
+	if alarm.State == "" {
+		alarm.State = AlarmStateOK
+	}
+	switch alarm.State {
+	case AlarmStateOK, AlarmStateAlarm, AlarmStateInsufficientData:
+	default:
+		return fmt.Errorf("invalid alarm state: %s", alarm.State)
+	}
+	if alarm.UpdatedAt.IsZero() {
+		alarm.UpdatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alarms[alarm.Name] = &alarm
+	return nil
+}
+
+// SetAlarmState moves an existing alarm to a new state.
+func (s *State) SetAlarmState(name, state, reason string) error {
+	s.mu.RLock()
+	alarm, ok := s.alarms[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("alarm not found: %s", name)
+	}
+	updated := *alarm
+	updated.State = state
+	updated.Reason = reason
+	updated.UpdatedAt = time.Now()
+	return s.PutAlarm(updated)
+}
+
+// ListAlarms returns every alarm, sorted by name.
+func (s *State) ListAlarms() []*MockAlarm {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alarms := make([]*MockAlarm, 0, len(s.alarms))
+	for _, alarm := range s.alarms {
+		copied := *alarm
+		alarms = append(alarms, &copied)
+	}
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].Name < alarms[j].Name })
+	return alarms
+}
+
+// seedDemoAlarmsLocked gives demo-upgrade the alarms a switchover is gated
+// on, all healthy. MUST be called with s.mu held.
+func (s *State) seedDemoAlarmsLocked(now time.Time) {
+	for _, alarm := range []MockAlarm{
+		{Name: "demo-upgrade-replica-lag", MetricName: "AuroraReplicaLag"},
+		{Name: "demo-upgrade-cpu", MetricName: "CPUUtilization"},
+		{Name: "demo-upgrade-connections", MetricName: "DatabaseConnections"},
+	} {
+		alarm.ClusterID = "demo-upgrade"
+		alarm.State = AlarmStateOK
+		alarm.UpdatedAt = now
+		s.alarms[alarm.Name] = &alarm
+	}
+}
+
+// handleDescribeAlarms serves the CloudWatch DescribeAlarms call. It honours
+// AlarmNamePrefix, AlarmNames and StateValue and returns a single page.
+func (s *Server) handleDescribeAlarms(w http.ResponseWriter, req cbor.Map) {
+	prefix := cborString(req["AlarmNamePrefix"])
+	stateValue := cborString(req["StateValue"])
+	names := make(map[string]bool)
+	if list, ok := req["AlarmNames"].(cbor.List); ok {
+		for _, name := range list {
+			names[cborString(name)] = true
+		}
+	}
+
+	alarms := cbor.List{}
+	for _, alarm := range s.state.ListAlarms() {
+		if prefix != "" && !strings.HasPrefix(alarm.Name, prefix) {
+			continue
+		}
+		if len(names) > 0 && !names[alarm.Name] {
+			continue
+		}
+		if stateValue != "" && alarm.State != stateValue {
+			continue
+		}
+		alarms = append(alarms, cbor.Map{
+			"AlarmName":  cbor.String(alarm.Name),
+			"AlarmArn":   cbor.String("arn:aws:cloudwatch:us-east-1:123456789012:alarm:" + alarm.Name),
+			"Namespace":  cbor.String("AWS/RDS"),
+			"MetricName": cbor.String(alarm.MetricName),
+			"Dimensions": cbor.List{cbor.Map{
+				"Name":  cbor.String("DBClusterIdentifier"),
+				"Value": cbor.String(alarm.ClusterID),
+			}},
+			"StateValue":            cbor.String(alarm.State),
+			"StateReason":           cbor.String(alarm.Reason),
+			"StateUpdatedTimestamp": cborTime(alarm.UpdatedAt),
+		})
+	}
+
+	s.sendCBOR(w, cbor.Map{"MetricAlarms": alarms})
+}
+
+// handleMockAlarms lists alarms (GET) or creates or updates one (POST), so
+// the demo and verify suite can simulate an alarming cluster.
+func (s *Server) handleMockAlarms(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.state.ListAlarms())
+
+	case http.MethodPost:
+		var alarm MockAlarm
+		if err := json.NewDecoder(r.Body).Decode(&alarm); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := s.state.PutAlarm(alarm); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
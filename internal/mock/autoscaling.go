@@ -0,0 +1,160 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// MockScalableTarget is the Application Auto Scaling of a cluster's read
+// replicas.
+type MockScalableTarget struct {
+	ClusterID   string   `json:"cluster_id"`
+	MinCapacity int32    `json:"min_capacity"`
+	MaxCapacity int32    `json:"max_capacity"`
+	Policies    []string `json:"policies"`
+
+	DynamicScalingInSuspended  bool `json:"dynamic_scaling_in_suspended"`
+	DynamicScalingOutSuspended bool `json:"dynamic_scaling_out_suspended"`
+	ScheduledScalingSuspended  bool `json:"scheduled_scaling_suspended"`
+}
+
+// PutScalableTarget registers read replica auto scaling for a cluster,
+// replacing any registered before.
+func (s *State) PutScalableTarget(target MockScalableTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clusters[target.ClusterID]; !ok {
+		return fmt.Errorf("cluster not found: %s", target.ClusterID)
+	}
+	target.Policies = append([]string(nil), target.Policies...)
+	s.scalableTargets[target.ClusterID] = &target
+	return nil
+}
+
+// GetScalableTarget returns the read replica auto scaling of a cluster.
+func (s *State) GetScalableTarget(clusterID string) (MockScalableTarget, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	target, ok := s.scalableTargets[clusterID]
+	if !ok {
+		return MockScalableTarget{}, false
+	}
+	return *target, true
+}
+
+// autoScalingResourceID is the Application Auto Scaling resource ID prefix of
+// Aurora clusters.
+const autoScalingResourceID = "cluster:"
+
+type (
+	autoScalingRequest struct {
+		ResourceID     string   `json:"ResourceId"`
+		ResourceIDs    []string `json:"ResourceIds"`
+		SuspendedState *struct {
+			DynamicScalingInSuspended  bool
+			DynamicScalingOutSuspended bool
+			ScheduledScalingSuspended  bool
+		} `json:"SuspendedState"`
+	}
+
+	scalableTargetData struct {
+		ServiceNamespace  string         `json:"ServiceNamespace"`
+		ResourceID        string         `json:"ResourceId"`
+		ScalableDimension string         `json:"ScalableDimension"`
+		MinCapacity       int32          `json:"MinCapacity"`
+		MaxCapacity       int32          `json:"MaxCapacity"`
+		SuspendedState    map[string]any `json:"SuspendedState"`
+	}
+)
+
+// handleAutoScalingAction serves the Application Auto Scaling actions the
+// machine uses, which arrive as JSON with the action in X-Amz-Target.
+func (s *Server) handleAutoScalingAction(w http.ResponseWriter, action string, body []byte) {
+	var req autoScalingRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.sendJSONError(w, "ValidationException", "failed to parse request body", 400)
+		return
+	}
+	resourceID := req.ResourceID
+	if resourceID == "" && len(req.ResourceIDs) > 0 {
+		resourceID = req.ResourceIDs[0]
+	}
+	clusterID := strings.TrimPrefix(resourceID, autoScalingResourceID)
+
+	if fault := s.state.Faults().Check(action, clusterID); fault.ShouldFail {
+		s.sendJSONError(w, fault.ErrorCode, fault.ErrorMsg, 400)
+		return
+	}
+
+	switch action {
+	case "DescribeScalableTargets":
+		targets := []scalableTargetData{}
+		if target, ok := s.state.GetScalableTarget(clusterID); ok {
+			targets = append(targets, scalableTargetData{
+				ServiceNamespace:  "rds",
+				ResourceID:        resourceID,
+				ScalableDimension: "rds:cluster:ReadReplicaCount",
+				MinCapacity:       target.MinCapacity,
+				MaxCapacity:       target.MaxCapacity,
+				SuspendedState: map[string]any{
+					"DynamicScalingInSuspended":  target.DynamicScalingInSuspended,
+					"DynamicScalingOutSuspended": target.DynamicScalingOutSuspended,
+					"ScheduledScalingSuspended":  target.ScheduledScalingSuspended,
+				},
+			})
+		}
+		s.sendJSON(w, map[string]any{"ScalableTargets": targets})
+
+	case "DescribeScalingPolicies":
+		policies := []map[string]string{}
+		if target, ok := s.state.GetScalableTarget(clusterID); ok {
+			for _, name := range target.Policies {
+				policies = append(policies, map[string]string{
+					"PolicyName": name,
+					"PolicyType": "TargetTrackingScaling",
+					"ResourceId": resourceID,
+				})
+			}
+		}
+		s.sendJSON(w, map[string]any{"ScalingPolicies": policies})
+
+	case "RegisterScalableTarget":
+		target, ok := s.state.GetScalableTarget(clusterID)
+		if !ok {
+			// Registering a new target needs capacity limits the machine never sends.
+			s.sendJSONError(w, "ValidationException",
+				fmt.Sprintf("No scalable target registered for %s; MinCapacity and MaxCapacity are required", resourceID), 400)
+			return
+		}
+		if state := req.SuspendedState; state != nil {
+			target.DynamicScalingInSuspended = state.DynamicScalingInSuspended
+			target.DynamicScalingOutSuspended = state.DynamicScalingOutSuspended
+			target.ScheduledScalingSuspended = state.ScheduledScalingSuspended
+		}
+		if err := s.state.PutScalableTarget(target); err != nil {
+			s.sendJSONError(w, "ObjectNotFoundException", err.Error(), 400)
+			return
+		}
+		s.sendJSON(w, map[string]any{})
+
+	default:
+		s.sendJSONError(w, "UnknownOperationException", fmt.Sprintf("unknown action %s", action), 400)
+	}
+}
+
+func (s *Server) sendJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (s *Server) sendJSONError(w http.ResponseWriter, code, message string, status int) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message}); err != nil {
+		s.logger.Error("failed to encode error response", "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/smithy-go/encoding/cbor"
+)
+
+// cloudWatchOperationPath prefixes the CloudWatch operations. The SDK sends
+// them as Smithy RPC v2 CBOR, naming the operation in the path.
+const cloudWatchOperationPath = "/service/GraniteServiceVersion20100801/operation/"
+
+// handleCloudWatchAction serves the CloudWatch actions the machine uses.
+func (s *Server) handleCloudWatchAction(w http.ResponseWriter, action string, body []byte) {
+	req := cbor.Map{}
+	if len(body) > 0 {
+		v, err := cbor.Decode(body)
+		m, ok := v.(cbor.Map)
+		if err != nil || !ok {
+			s.sendCBORError(w, "SerializationException", "failed to parse request body", 400)
+			return
+		}
+		req = m
+	}
+
+	fault := s.state.Faults().Check(action, "")
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+	if fault.ShouldFail {
+		s.sendCBORError(w, fault.ErrorCode, fault.ErrorMsg, 400)
+		return
+	}
+
+	switch action {
+	case "DescribeAlarms":
+		s.handleDescribeAlarms(w, req)
+	case "GetMetricStatistics":
+		s.handleGetMetricStatistics(w, req)
+	default:
+		s.sendCBORError(w, "UnknownOperationException", fmt.Sprintf("unknown action %s", action), 400)
+	}
+}
+
+func (s *Server) sendCBOR(w http.ResponseWriter, v cbor.Map) {
+	w.Header().Set("Smithy-Protocol", "rpc-v2-cbor")
+	w.Header().Set("Content-Type", "application/cbor")
+	if _, err := w.Write(cbor.Encode(v)); err != nil {
+		s.logger.Error("failed to write response", "error", err)
+	}
+}
+
+func (s *Server) sendCBORError(w http.ResponseWriter, code, message string, status int) {
+	w.Header().Set("Smithy-Protocol", "rpc-v2-cbor")
+	w.Header().Set("Content-Type", "application/cbor")
+	w.Header().Set("X-Amzn-Query-Error", code+";Sender")
+	w.WriteHeader(status)
+	body := cbor.Map{"__type": cbor.String(code), "message": cbor.String(message)}
+	if _, err := w.Write(cbor.Encode(body)); err != nil {
+		s.logger.Error("failed to write error response", "error", err)
+	}
+}
+
+// cborString returns a string member of a request, or "" when it is absent.
+func cborString(v cbor.Value) string {
+	s, _ := v.(cbor.String)
+	return string(s)
+}
+
+// cborTime encodes t as an epoch-seconds timestamp.
+func cborTime(t time.Time) cbor.Value {
+	return &cbor.Tag{ID: 1, Value: cbor.Float64(float64(t.UnixMilli()) / 1000)}
+}
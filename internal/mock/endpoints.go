@@ -0,0 +1,266 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+)
+
+// MockClusterEndpoint represents an Aurora custom endpoint. Members are
+// instance IDs; an endpoint with static members routes only to them, one
+// without routes to every instance of its type except the excluded ones.
+type MockClusterEndpoint struct {
+	ID              string
+	ClusterID       string
+	Type            string // READER or ANY
+	Status          string
+	StaticMembers   []string
+	ExcludedMembers []string
+}
+
+// clusterEndpointData is a cluster endpoint as rendered in API responses.
+type clusterEndpointData struct {
+	Identifier         string
+	ClusterID          string
+	Endpoint           string
+	Status             string
+	EndpointType       string
+	CustomEndpointType string
+	StaticMembers      []string
+	ExcludedMembers    []string
+}
+
+func (ep *MockClusterEndpoint) data() clusterEndpointData {
+	return clusterEndpointData{
+		Identifier:         ep.ID,
+		ClusterID:          ep.ClusterID,
+		Endpoint:           fmt.Sprintf("%s.cluster-custom-abc123.us-east-1.rds.amazonaws.com", ep.ID),
+		Status:             ep.Status,
+		EndpointType:       "CUSTOM",
+		CustomEndpointType: ep.Type,
+		StaticMembers:      ep.StaticMembers,
+		ExcludedMembers:    ep.ExcludedMembers,
+	}
+}
+
+// CreateClusterEndpoint adds an available custom endpoint to a cluster. At
+// most one of static and excluded may be set, and both may only name
+// instances of the cluster.
+func (s *State) CreateClusterEndpoint(clusterID, id, endpointType string, static, excluded []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clusters[clusterID]; !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+	if _, exists := s.clusterEndpoints[id]; exists {
+		return fmt.Errorf("cluster endpoint already exists: %s", id)
+	}
+	if endpointType != "READER" && endpointType != "ANY" {
+		return fmt.Errorf("endpoint type must be READER or ANY, got %q", endpointType)
+	}
+	if err := s.checkEndpointMembersLocked(clusterID, static, excluded); err != nil {
+		return err
+	}
+	s.clusterEndpoints[id] = &MockClusterEndpoint{
+		ID:              id,
+		ClusterID:       clusterID,
+		Type:            endpointType,
+		Status:          "available",
+		StaticMembers:   slices.Clone(static),
+		ExcludedMembers: slices.Clone(excluded),
+	}
+	return nil
+}
+
+// GetClusterEndpoint returns a custom endpoint by identifier.
+func (s *State) GetClusterEndpoint(id string) (*MockClusterEndpoint, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ep, ok := s.clusterEndpoints[id]
+	if !ok {
+		return nil, false
+	}
+	return ep.copy(), true
+}
+
+// SetClusterEndpointStatus sets a custom endpoint's status (e.g., to
+// simulate one still being modified).
+func (s *State) SetClusterEndpointStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep, ok := s.clusterEndpoints[id]
+	if !ok {
+		return fmt.Errorf("cluster endpoint not found: %s", id)
+	}
+	ep.Status = status
+	return nil
+}
+
+// ModifyClusterEndpoint replaces a custom endpoint's members. Setting one
+// list clears the other, as in RDS. Changes apply at once.
+func (s *State) ModifyClusterEndpoint(id string, static, excluded []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ep, ok := s.clusterEndpoints[id]
+	if !ok {
+		return fmt.Errorf("cluster endpoint not found: %s", id)
+	}
+	if ep.Status != "available" {
+		return fmt.Errorf("cluster endpoint %s is %s", id, ep.Status)
+	}
+	if err := s.checkEndpointMembersLocked(ep.ClusterID, static, excluded); err != nil {
+		return err
+	}
+	switch {
+	case static != nil:
+		ep.StaticMembers, ep.ExcludedMembers = slices.Clone(static), nil
+	case excluded != nil:
+		ep.StaticMembers, ep.ExcludedMembers = nil, slices.Clone(excluded)
+	}
+	return nil
+}
+
+// checkEndpointMembersLocked rejects member lists RDS would refuse.
+// Callers hold s.mu.
+func (s *State) checkEndpointMembersLocked(clusterID string, static, excluded []string) error {
+	if len(static) > 0 && len(excluded) > 0 {
+		return fmt.Errorf("static and excluded members cannot both be set")
+	}
+	for _, id := range append(slices.Clone(static), excluded...) {
+		if inst, ok := s.instances[id]; !ok || inst.ClusterID != clusterID {
+			return fmt.Errorf("instance %s is not a member of cluster %s", id, clusterID)
+		}
+	}
+	return nil
+}
+
+// listClusterEndpoints returns the custom endpoints of a cluster by identifier.
+func (s *State) listClusterEndpoints(clusterID string) []*MockClusterEndpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var endpoints []*MockClusterEndpoint
+	for _, ep := range s.clusterEndpoints {
+		if ep.ClusterID == clusterID {
+			endpoints = append(endpoints, ep.copy())
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].ID < endpoints[j].ID })
+	return endpoints
+}
+
+// retireEndpointMembersLocked points the custom endpoints of a cluster at the
+// -old1 instances a Blue-Green switchover renamed its members to. Endpoints
+// keep following the instances they were defined with, so after a switchover
+// they name the retired blue instances. Callers hold s.mu.
+func (s *State) retireEndpointMembersLocked(clusterID string, renamed map[string]string) {
+	rename := func(ids []string) []string {
+		for i, id := range ids {
+			if old, ok := renamed[id]; ok {
+				ids[i] = old
+			}
+		}
+		return ids
+	}
+	for _, ep := range s.clusterEndpoints {
+		if ep.ClusterID == clusterID {
+			ep.StaticMembers = rename(ep.StaticMembers)
+			ep.ExcludedMembers = rename(ep.ExcludedMembers)
+		}
+	}
+}
+
+// dropEndpointMemberLocked removes a deleted instance from every custom
+// endpoint. Callers hold s.mu.
+func (s *State) dropEndpointMemberLocked(instanceID string) {
+	for _, ep := range s.clusterEndpoints {
+		ep.StaticMembers = slices.DeleteFunc(ep.StaticMembers, func(id string) bool { return id == instanceID })
+		ep.ExcludedMembers = slices.DeleteFunc(ep.ExcludedMembers, func(id string) bool { return id == instanceID })
+	}
+}
+
+func (ep *MockClusterEndpoint) copy() *MockClusterEndpoint {
+	epCopy := *ep
+	epCopy.StaticMembers = slices.Clone(ep.StaticMembers)
+	epCopy.ExcludedMembers = slices.Clone(ep.ExcludedMembers)
+	return &epCopy
+}
+
+// memberList reads a StringList query parameter, or nil when it was not
+// sent. An empty list arrives as the bare name with no value.
+func memberList(values url.Values, name string) []string {
+	if !values.Has(name) && values.Get(name+".member.1") == "" {
+		return nil
+	}
+	members := []string{}
+	for i := 1; ; i++ {
+		v := values.Get(fmt.Sprintf("%s.member.%d", name, i))
+		if v == "" {
+			return members
+		}
+		members = append(members, v)
+	}
+}
+
+func (s *Server) handleDescribeDBClusterEndpoints(w http.ResponseWriter, values url.Values) {
+	clusterID := values.Get("DBClusterIdentifier")
+	endpointID := values.Get("DBClusterEndpointIdentifier")
+
+	var endpoints []clusterEndpointData
+	switch {
+	case endpointID != "":
+		if ep, ok := s.state.GetClusterEndpoint(endpointID); ok && (clusterID == "" || ep.ClusterID == clusterID) {
+			endpoints = append(endpoints, ep.data())
+		}
+	case clusterID != "":
+		if !s.state.hasCluster(clusterID) {
+			s.sendErrorResponse(w, "DBClusterNotFoundFault", fmt.Sprintf("DBCluster %s not found.", clusterID), 404)
+			return
+		}
+		// The built-in endpoints come first, as RDS lists them.
+		for _, builtIn := range []struct{ kind, host string }{{"WRITER", "cluster"}, {"READER", "cluster-ro"}} {
+			endpoints = append(endpoints, clusterEndpointData{
+				ClusterID:    clusterID,
+				Endpoint:     fmt.Sprintf("%s.%s-abc123.us-east-1.rds.amazonaws.com", clusterID, builtIn.host),
+				Status:       "available",
+				EndpointType: builtIn.kind,
+			})
+		}
+		for _, ep := range s.state.listClusterEndpoints(clusterID) {
+			endpoints = append(endpoints, ep.data())
+		}
+	}
+
+	s.executeTemplate(w, "describe_db_cluster_endpoints.xml", struct{ Endpoints []clusterEndpointData }{endpoints})
+}
+
+func (s *Server) handleModifyDBClusterEndpoint(w http.ResponseWriter, values url.Values) {
+	endpointID := values.Get("DBClusterEndpointIdentifier")
+	if s.injectFault(w, "ModifyDBClusterEndpoint", endpointID) {
+		return
+	}
+	ep, ok := s.state.GetClusterEndpoint(endpointID)
+	if !ok {
+		s.sendErrorResponse(w, "DBClusterEndpointNotFoundFault",
+			fmt.Sprintf("DBClusterEndpoint %s not found.", endpointID), 404)
+		return
+	}
+	if ep.Status != "available" {
+		s.sendErrorResponse(w, "InvalidDBClusterEndpointStateFault",
+			fmt.Sprintf("DBClusterEndpoint %s is %s.", endpointID, ep.Status), 400)
+		return
+	}
+
+	static := memberList(values, "StaticMembers")
+	excluded := memberList(values, "ExcludedMembers")
+	if err := s.state.ModifyClusterEndpoint(endpointID, static, excluded); err != nil {
+		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
+		return
+	}
+
+	ep, _ = s.state.GetClusterEndpoint(endpointID)
+	s.executeTemplate(w, "modify_db_cluster_endpoint.xml", ep.data())
+}
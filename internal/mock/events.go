@@ -0,0 +1,188 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock/templates"
+)
+
+// RDS event source types served by DescribeEvents.
+const (
+	EventSourceCluster  = "db-cluster"
+	EventSourceInstance = "db-instance"
+)
+
+// maxMockEvents bounds the event log; the oldest events are dropped first.
+const maxMockEvents = 500
+
+// MockEvent is an entry in the simulated RDS event stream.
+type MockEvent struct {
+	SourceID   string
+	SourceType string // EventSourceCluster or EventSourceInstance
+	Message    string
+	Categories []string
+	Date       time.Time
+}
+
+type (
+	eventData struct {
+		SourceID   string
+		SourceType string
+		SourceARN  string
+		Message    string
+		Categories []string
+		Date       string
+	}
+
+	describeEventsData struct {
+		Events []eventData
+	}
+)
+
+// faultEventMessages are the explanations RDS gives in its event stream for
+// the error codes faults are usually injected with.
+var faultEventMessages = map[string]string{
+	"InvalidParameterCombination":    "The requested combination of settings is not supported for this DB engine version or instance class",
+	"InsufficientDBInstanceCapacity": "Unable to complete the request because there is not enough capacity for the requested instance class in the Availability Zone",
+	"StorageQuotaExceeded":           "Unable to complete the request because the account has reached its allocated storage quota",
+	"InvalidDBInstanceState":         "The DB instance is not in a state that allows the requested change",
+	"InvalidDBClusterStateFault":     "The DB cluster is not in a state that allows the requested change",
+}
+
+// RecordEvent appends an event to the mock RDS event stream. A zero date is
+// set to now.
+func (s *State) RecordEvent(event MockEvent) {
+	if event.Date.IsZero() {
+		event.Date = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	if len(s.events) > maxMockEvents {
+		s.events = s.events[len(s.events)-maxMockEvents:]
+	}
+}
+
+// ListEvents returns the events of a source, of any source when sourceID is
+// empty, recorded at or after since, oldest first.
+func (s *State) ListEvents(sourceID, sourceType string, since time.Time) []MockEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []MockEvent
+	for _, event := range s.events {
+		if sourceID != "" && event.SourceID != sourceID {
+			continue
+		}
+		if sourceType != "" && event.SourceType != sourceType {
+			continue
+		}
+		if event.Date.Before(since) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// recordFaultEvent adds the event RDS would have emitted for a request an
+// injected fault rejected, so a failure can be explained from DescribeEvents
+// as it would be against AWS. Requests that name no cluster or instance are
+// not recorded.
+func (s *Server) recordFaultEvent(action, resourceID, code, message string) {
+	if resourceID == "" {
+		return
+	}
+	var sourceType string
+	switch {
+	// A failed create leaves nothing behind to look the type up from.
+	case s.state.hasInstance(resourceID), action == "CreateDBInstance":
+		sourceType = EventSourceInstance
+	case s.state.hasCluster(resourceID), action == "RestoreDBClusterFromSnapshot":
+		sourceType = EventSourceCluster
+	default:
+		return
+	}
+
+	explanation, ok := faultEventMessages[code]
+	if !ok {
+		explanation = message
+	}
+	s.state.RecordEvent(MockEvent{
+		SourceID:   resourceID,
+		SourceType: sourceType,
+		Message:    fmt.Sprintf("%s failed (%s): %s", action, code, explanation),
+		Categories: []string{"failure"},
+	})
+}
+
+// hasInstance reports whether the instance exists.
+func (s *State) hasInstance(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.instances[id]
+	return ok
+}
+
+// hasCluster reports whether the cluster exists.
+func (s *State) hasCluster(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.clusters[id]
+	return ok
+}
+
+// handleDescribeEvents serves DescribeEvents. It honours SourceIdentifier,
+// SourceType, StartTime and Duration (minutes, default 60 as in RDS) and
+// returns a single page.
+func (s *Server) handleDescribeEvents(w http.ResponseWriter, values url.Values) {
+	since := time.Now().Add(-time.Hour)
+	if minutes := values.Get("Duration"); minutes != "" {
+		n, err := strconv.Atoi(minutes)
+		if err != nil || n < 0 {
+			s.sendErrorResponse(w, "InvalidParameterValue", "Duration must be a number of minutes", 400)
+			return
+		}
+		since = time.Now().Add(-time.Duration(n) * time.Minute)
+	}
+	if start := values.Get("StartTime"); start != "" {
+		t, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			s.sendErrorResponse(w, "InvalidParameterValue", "StartTime is not a valid timestamp", 400)
+			return
+		}
+		since = t
+	}
+	sourceID := values.Get("SourceIdentifier")
+	sourceType := values.Get("SourceType")
+	if sourceID != "" && sourceType == "" {
+		s.sendErrorResponse(w, "InvalidParameterCombination",
+			"SourceType must be specified along with SourceIdentifier", 400)
+		return
+	}
+
+	var data describeEventsData
+	for _, event := range s.state.ListEvents(sourceID, sourceType, since) {
+		kind := "db"
+		if event.SourceType == EventSourceCluster {
+			kind = "cluster"
+		}
+		data.Events = append(data.Events, eventData{
+			SourceID:   event.SourceID,
+			SourceType: event.SourceType,
+			SourceARN:  fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:%s:%s", kind, event.SourceID),
+			Message:    event.Message,
+			Categories: event.Categories,
+			Date:       event.Date.UTC().Format(time.RFC3339),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	if err := templates.Execute(w, "describe_events.xml", data); err != nil {
+		s.logger.Error("failed to execute template", "error", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,23 +21,67 @@ const (
 	FaultTypeStuck FaultType = "stuck"
 	// FaultTypePartialFail fails after N successful calls.
 	FaultTypePartialFail FaultType = "partial_fail"
+	// FaultTypeIntermittent fails a fraction of calls, either every Nth call or
+	// at random, and lets the rest through.
+	FaultTypeIntermittent FaultType = "intermittent"
+	// FaultTypeTaskFail fails a Blue-Green deployment task instead of
+	// completing it, which fails the deployment's provisioning.
+	FaultTypeTaskFail FaultType = "task_fail"
 )
 
+// validFaultTypes lists the fault types accepted by the control API.
+var validFaultTypes = map[FaultType]bool{
+	FaultTypeAPIError:     true,
+	FaultTypeDelay:        true,
+	FaultTypeStuck:        true,
+	FaultTypePartialFail:  true,
+	FaultTypeIntermittent: true,
+	FaultTypeTaskFail:     true,
+}
+
 // Fault represents a fault injection rule.
+//
+// Untargeted faults are checked once per request by the API dispatcher.
+// Targeted faults are checked by the action handler that knows the resource
+// ID, so a call is never counted twice against the same fault.
 type Fault struct {
-	ID          string    `json:"id"`
-	Type        FaultType `json:"type"`
-	Action      string    `json:"action"`        // RDS action to target (e.g., "CreateDBInstance")
-	Target      string    `json:"target"`        // Optional: specific resource ID to target
-	Probability float64   `json:"probability"`   // 0.0-1.0, chance the fault triggers
-	ErrorCode   string    `json:"error_code"`    // For api_error type
-	ErrorMsg    string    `json:"error_message"` // For api_error type
-	DelayMs     int       `json:"delay_ms"`      // For delay type
-	FailAfterN  int       `json:"fail_after_n"`  // For partial_fail type
-	Enabled     bool      `json:"enabled"`
-
-	// Internal counter for partial_fail
-	callCount int
+	ID              string    `json:"id"`
+	Type            FaultType `json:"type"`
+	Action          string    `json:"action"`             // RDS action to target (e.g., "CreateDBInstance")
+	Target          string    `json:"target"`             // Optional: specific resource ID to target
+	Probability     float64   `json:"probability"`        // 0.0-1.0, chance the fault triggers
+	ErrorCode       string    `json:"error_code"`         // For failing types
+	ErrorMsg        string    `json:"error_message"`      // For failing types
+	DelayMs         int       `json:"delay_ms"`           // Latency added before responding, for any type
+	FailAfterNCalls int       `json:"fail_after_n_calls"` // For partial_fail type
+	FailEveryN      int       `json:"fail_every_n"`       // For intermittent type: fail 1 in N calls
+	FailureRate     float64   `json:"failure_rate"`       // For intermittent type: 0.0-1.0, used when fail_every_n is 0
+	Task            string    `json:"task"`               // For task_fail type: Blue-Green task name, empty for any
+	Enabled         bool      `json:"enabled"`
+
+	// CallCount is the number of calls the fault has matched. It is reported
+	// by the control API and ignored on input.
+	CallCount int `json:"call_count"`
+}
+
+// Validate checks that the fault's settings are in range.
+func (f Fault) Validate() error {
+	if !validFaultTypes[f.Type] {
+		return fmt.Errorf("unknown fault type %q", f.Type)
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("probability must be between 0 and 1, got %v", f.Probability)
+	}
+	if f.FailureRate < 0 || f.FailureRate > 1 {
+		return fmt.Errorf("failure_rate must be between 0 and 1, got %v", f.FailureRate)
+	}
+	if f.DelayMs < 0 || f.FailAfterNCalls < 0 || f.FailEveryN < 0 {
+		return fmt.Errorf("delay_ms, fail_after_n_calls and fail_every_n must not be negative")
+	}
+	if f.Type == FaultTypeIntermittent && f.FailEveryN == 0 && f.FailureRate == 0 {
+		return fmt.Errorf("intermittent faults need fail_every_n or failure_rate")
+	}
+	return nil
 }
 
 // FaultInjector manages fault injection rules.
@@ -60,7 +105,7 @@ func (fi *FaultInjector) AddFault(f Fault) string {
 	if f.ID == "" {
 		f.ID = uuid.New().String()[:8]
 	}
-	f.callCount = 0
+	f.CallCount = 0
 	fi.faults[f.ID] = &f
 	return f.ID
 }
@@ -113,11 +158,27 @@ type FaultCheckResult struct {
 	ShouldFail  bool
 	ErrorCode   string
 	ErrorMsg    string
-	ExtraDelay  int  // milliseconds
-	ShouldStick bool // for stuck type - don't transition state
+	FaultID     string        // ID of the fault that caused the failure
+	Delay       time.Duration // latency to add before responding, success or not
+	ShouldStick bool          // for stuck type - don't transition state
+}
+
+// fail marks the result as failed by f, filling in default error details.
+func (r *FaultCheckResult) fail(f *Fault, defaultCode, defaultMsg string) {
+	r.ShouldFail = true
+	r.FaultID = f.ID
+	r.ErrorCode = f.ErrorCode
+	if r.ErrorCode == "" {
+		r.ErrorCode = defaultCode
+	}
+	r.ErrorMsg = f.ErrorMsg
+	if r.ErrorMsg == "" {
+		r.ErrorMsg = defaultMsg
+	}
 }
 
 // Check checks if a fault should be triggered for the given action and target.
+// An empty target matches only untargeted faults.
 func (fi *FaultInjector) Check(action, target string) FaultCheckResult {
 	fi.mu.Lock()
 	defer fi.mu.Unlock()
@@ -133,7 +194,7 @@ func (fi *FaultInjector) Check(action, target string) FaultCheckResult {
 		if f.Action != "" && f.Action != action {
 			continue
 		}
-		if f.Target != "" && f.Target != target {
+		if f.Target != target {
 			continue
 		}
 
@@ -142,37 +203,31 @@ func (fi *FaultInjector) Check(action, target string) FaultCheckResult {
 			continue
 		}
 
+		f.CallCount++
+		result.Delay += time.Duration(f.DelayMs) * time.Millisecond
+
 		// Apply the fault
 		switch f.Type {
 		case FaultTypeAPIError:
-			result.ShouldFail = true
-			result.ErrorCode = f.ErrorCode
-			if result.ErrorCode == "" {
-				result.ErrorCode = "InternalFailure"
-			}
-			result.ErrorMsg = f.ErrorMsg
-			if result.ErrorMsg == "" {
-				result.ErrorMsg = fmt.Sprintf("Injected fault for action %s", action)
-			}
-
-		case FaultTypeDelay:
-			result.ExtraDelay += f.DelayMs
+			result.fail(f, "InternalFailure", fmt.Sprintf("Injected fault for action %s", action))
 
 		case FaultTypeStuck:
 			result.ShouldStick = true
 
 		case FaultTypePartialFail:
-			f.callCount++
-			if f.callCount > f.FailAfterN {
-				result.ShouldFail = true
-				result.ErrorCode = f.ErrorCode
-				if result.ErrorCode == "" {
-					result.ErrorCode = "InternalFailure"
-				}
-				result.ErrorMsg = f.ErrorMsg
-				if result.ErrorMsg == "" {
-					result.ErrorMsg = fmt.Sprintf("Partial fail triggered after %d calls", f.FailAfterN)
-				}
+			if f.CallCount > f.FailAfterNCalls {
+				result.fail(f, "InternalFailure", fmt.Sprintf("Partial fail triggered after %d calls", f.FailAfterNCalls))
+			}
+
+		case FaultTypeIntermittent:
+			var trip bool
+			if f.FailEveryN > 0 {
+				trip = f.CallCount%f.FailEveryN == 0
+			} else {
+				trip = rand.Float64() < f.FailureRate
+			}
+			if trip {
+				result.fail(f, "Throttling", "Rate exceeded")
 			}
 		}
 	}
@@ -180,6 +235,35 @@ func (fi *FaultInjector) Check(action, target string) FaultCheckResult {
 	return result
 }
 
+// CheckTaskFailure checks if a Blue-Green deployment task should fail rather
+// than complete. The target matches the deployment identifier or name. It
+// returns the status details to report for the failure.
+func (fi *FaultInjector) CheckTaskFailure(identifier, name, task string) (string, bool) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	for _, f := range fi.faults {
+		if !f.Enabled || f.Type != FaultTypeTaskFail {
+			continue
+		}
+		if f.Target != "" && f.Target != identifier && f.Target != name {
+			continue
+		}
+		if f.Task != "" && f.Task != task {
+			continue
+		}
+		if f.Probability < 1.0 && rand.Float64() > f.Probability {
+			continue
+		}
+		f.CallCount++
+		if f.ErrorMsg != "" {
+			return f.ErrorMsg, true
+		}
+		return fmt.Sprintf("Task %s failed", task), true
+	}
+	return "", false
+}
+
 // CheckStateTransition checks if a state transition should be blocked (for stuck faults).
 func (fi *FaultInjector) CheckStateTransition(resourceID string) bool {
 	fi.mu.RLock()
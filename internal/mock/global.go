@@ -0,0 +1,110 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// MockGlobalCluster represents an Aurora Global Database.
+type MockGlobalCluster struct {
+	ID            string
+	Engine        string
+	EngineVersion string
+	Members       []MockGlobalClusterMember
+}
+
+// MockGlobalClusterMember is one regional cluster of a global database. The
+// cluster need not exist in the mock state, so secondaries in other regions
+// can be represented.
+type MockGlobalClusterMember struct {
+	ClusterID string
+	Region    string // defaults to us-east-1
+	IsWriter  bool
+}
+
+// ARN returns the member cluster's ARN.
+func (m MockGlobalClusterMember) ARN() string {
+	region := m.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return fmt.Sprintf("arn:aws:rds:%s:123456789012:cluster:%s", region, m.ClusterID)
+}
+
+type (
+	globalClusterMemberData struct {
+		ClusterARN string
+		IsWriter   bool
+	}
+
+	globalClusterData struct {
+		ID            string
+		Engine        string
+		EngineVersion string
+		Members       []globalClusterMemberData
+	}
+
+	globalClustersData struct {
+		GlobalClusters []globalClusterData
+	}
+)
+
+// AddGlobalCluster adds a global database.
+func (s *State) AddGlobalCluster(gc MockGlobalCluster) error {
+	if gc.ID == "" {
+		return fmt.Errorf("global cluster ID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.globalClusters[gc.ID]; ok {
+		return fmt.Errorf("global cluster already exists: %s", gc.ID)
+	}
+	gc.Members = append([]MockGlobalClusterMember(nil), gc.Members...)
+	s.globalClusters[gc.ID] = &gc
+	return nil
+}
+
+// GlobalClusterOf returns the ID of the global database the cluster is a
+// member of in us-east-1, or "" if it is not part of one.
+func (s *State) GlobalClusterOf(clusterID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, gc := range s.globalClusters {
+		for _, m := range gc.Members {
+			if m.ClusterID == clusterID && (m.Region == "" || m.Region == "us-east-1") {
+				return gc.ID
+			}
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleDescribeGlobalClusters(w http.ResponseWriter, values url.Values) {
+	wantID := values.Get("GlobalClusterIdentifier")
+
+	s.state.mu.RLock()
+	var data globalClustersData
+	for _, gc := range s.state.globalClusters {
+		if wantID != "" && gc.ID != wantID {
+			continue
+		}
+		item := globalClusterData{ID: gc.ID, Engine: gc.Engine, EngineVersion: gc.EngineVersion}
+		for _, m := range gc.Members {
+			item.Members = append(item.Members, globalClusterMemberData{ClusterARN: m.ARN(), IsWriter: m.IsWriter})
+		}
+		data.GlobalClusters = append(data.GlobalClusters, item)
+	}
+	s.state.mu.RUnlock()
+
+	if wantID != "" && len(data.GlobalClusters) == 0 {
+		s.sendErrorResponse(w, "GlobalClusterNotFoundFault", fmt.Sprintf("global cluster %s not found", wantID), 404)
+		return
+	}
+	sort.Slice(data.GlobalClusters, func(i, j int) bool {
+		return data.GlobalClusters[i].ID < data.GlobalClusters[j].ID
+	})
+
+	s.executeTemplate(w, "describe_global_clusters.xml", data)
+}
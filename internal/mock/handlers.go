@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +33,18 @@ type (
 		Status         string
 		ParameterGroup string
 		Members        []clusterMemberData
+
+		// Serverless v2 capacity range; both zero omits the configuration.
+		ServerlessMinCapacity float64
+		ServerlessMaxCapacity float64
+
+		IAMDatabaseAuth bool
+
+		PreferredMaintenanceWindow string
+
+		// MultiAZ is set when the members run in more than one AZ.
+		MultiAZ         bool
+		GlobalClusterID string
 	}
 
 	clustersData struct {
@@ -46,6 +60,29 @@ type (
 		ClusterID      string
 		ParameterGroup string
 		IOPS           *int32
+		Storage        int32 // allocated storage in GiB; omitted when zero
+		Zone           string
+
+		SubnetGroup    string
+		SecurityGroups []string
+
+		CACertificate        string
+		PendingCACertificate string
+		PendingInstanceType  string
+		PendingStorageType   string
+		PendingIOPS          *int32
+
+		PerformanceInsights bool
+		PIRetention         int32
+		MonitoringInterval  int32
+		MonitoringRole      string
+
+		// IAMDatabaseAuth follows the instance's cluster, as in Aurora.
+		IAMDatabaseAuth bool
+
+		// CreateTime and Tags are only rendered by DescribeDBInstances.
+		CreateTime string
+		Tags       []tagData
 	}
 
 	instancesData struct {
@@ -65,6 +102,7 @@ type (
 		Name        string
 		Family      string
 		Description string
+		ARN         string
 	}
 
 	parameterGroupsData struct {
@@ -73,10 +111,13 @@ type (
 
 	snapshotData struct {
 		ID            string
+		ARN           string
 		ClusterID     string
 		Status        string
 		Engine        string
 		EngineVersion string
+		CreateTime    string
+		Tags          []tagData
 	}
 
 	snapshotsData struct {
@@ -121,10 +162,12 @@ type (
 		Version     string
 		Description string
 		IsMajor     bool
+		EngineModes []string
 	}
 
 	orderableInstanceData struct {
 		InstanceClass     string
+		StorageType       string
 		AvailabilityZones []string
 	}
 
@@ -234,7 +277,11 @@ func (s *Server) handleDescribeDBClusters(w http.ResponseWriter, values url.Valu
 		clusterARN := fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:cluster:%s", cluster.ID)
 		pgName := cluster.ParameterGroupName
 		if pgName == "" {
-			pgName = "default.aurora-postgresql15"
+			pgName = "default." + parameterGroupFamily(cluster.Engine, cluster.EngineVersion)
+		}
+		window := cluster.PreferredMaintenanceWindow
+		if window == "" {
+			window = defaultMaintenanceWindow
 		}
 		cd := clusterData{
 			ID:             cluster.ID,
@@ -244,9 +291,20 @@ func (s *Server) handleDescribeDBClusters(w http.ResponseWriter, values url.Valu
 			Status:         cluster.Status,
 			ParameterGroup: pgName,
 			Members:        make([]clusterMemberData, 0),
+
+			ServerlessMinCapacity: cluster.ServerlessV2MinCapacity,
+			ServerlessMaxCapacity: cluster.ServerlessV2MaxCapacity,
+
+			IAMDatabaseAuth: cluster.IAMDatabaseAuthEnabled,
+
+			PreferredMaintenanceWindow: window,
+
+			GlobalClusterID: s.state.GlobalClusterOf(cluster.ID),
 		}
+		zones := make(map[string]bool)
 		for _, memberID := range cluster.Members {
 			if inst, ok := s.state.GetInstance(memberID); ok {
+				zones[inst.AvailabilityZone] = true
 				isWriter := "false"
 				if inst.IsWriter {
 					isWriter = "true"
@@ -258,21 +316,31 @@ func (s *Server) handleDescribeDBClusters(w http.ResponseWriter, values url.Valu
 				})
 			}
 		}
+		cd.MultiAZ = len(zones) > 1
 		data.Clusters = append(data.Clusters, cd)
 	}
 	s.executeTemplate(w, "describe_db_clusters.xml", data)
 }
 
+// instanceParameterGroup returns the DB parameter group an instance reports.
+func instanceParameterGroup(inst *MockInstance) string {
+	if inst.ParameterGroupName == "" {
+		return "default.aurora-postgresql15"
+	}
+	return inst.ParameterGroupName
+}
+
 func (s *Server) handleDescribeDBInstances(w http.ResponseWriter, values url.Values) {
 	instanceID := values.Get("DBInstanceIdentifier")
 
-	// Check for db-cluster-id filter (used by optimized GetClusterInfo)
-	filterClusterID := ""
-	for i := 1; i <= 10; i++ {
-		filterName := values.Get(fmt.Sprintf("Filters.Filter.%d.Name", i))
-		if filterName == "db-cluster-id" {
-			filterClusterID = values.Get(fmt.Sprintf("Filters.Filter.%d.Values.Value.1", i))
-			break
+	// Check for db-cluster-id filter (used by optimized GetClusterInfo, and
+	// with several clusters at once by ListClusters)
+	filterClusterIDs := filterValues(values, "db-cluster-id")
+	for _, clusterID := range filterClusterIDs {
+		// A fault on any of the clusters fails the whole call, as a missing
+		// permission on one of them does on RDS.
+		if s.injectFault(w, "DescribeDBInstances", clusterID) {
+			return
 		}
 	}
 
@@ -284,13 +352,10 @@ func (s *Server) handleDescribeDBInstances(w http.ResponseWriter, values url.Val
 			return
 		}
 		instances = []*MockInstance{inst}
-	} else if filterClusterID != "" {
-		// Filter by cluster ID
-		allInstances := s.state.ListInstances()
-		for _, inst := range allInstances {
-			if inst.ClusterID == filterClusterID {
-				instances = append(instances, inst)
-			}
+	} else if len(filterClusterIDs) > 0 {
+		// Filter by cluster ID, in member order so results are stable
+		for _, clusterID := range filterClusterIDs {
+			instances = append(instances, s.state.GetClusterInstances(clusterID)...)
 		}
 	} else {
 		instances = s.state.ListInstances()
@@ -305,8 +370,29 @@ func (s *Server) handleDescribeDBInstances(w http.ResponseWriter, values url.Val
 			ARN:            inst.ARN,
 			StorageType:    inst.StorageType,
 			ClusterID:      inst.ClusterID,
-			ParameterGroup: "default.aurora-postgresql15",
+			ParameterGroup: instanceParameterGroup(inst),
 			IOPS:           inst.IOPS,
+			Storage:        inst.AllocatedStorage,
+			Zone:           inst.AvailabilityZone,
+
+			SubnetGroup:    inst.SubnetGroupName,
+			SecurityGroups: inst.SecurityGroupIDs,
+
+			CACertificate:        inst.CACertificate(),
+			PendingCACertificate: inst.PendingCACertificateIdentifier,
+			PendingInstanceType:  inst.PendingInstanceType,
+			PendingStorageType:   inst.PendingStorageType,
+			PendingIOPS:          inst.PendingIOPS,
+
+			PerformanceInsights: inst.PerformanceInsightsEnabled,
+			PIRetention:         inst.PerformanceInsightsRetentionDays(),
+			MonitoringInterval:  inst.MonitoringInterval,
+			MonitoringRole:      inst.MonitoringRoleArn,
+
+			IAMDatabaseAuth: s.state.iamDatabaseAuthEnabled(inst.ClusterID),
+
+			CreateTime: inst.CreatedAt.UTC().Format(time.RFC3339),
+			Tags:       sortedTagData(s.state.ResourceTags(inst.ARN)),
 		})
 	}
 	s.executeTemplate(w, "describe_db_instances.xml", data)
@@ -315,7 +401,7 @@ func (s *Server) handleDescribeDBInstances(w http.ResponseWriter, values url.Val
 func (s *Server) handleListTagsForResource(w http.ResponseWriter, values url.Values) {
 	resourceName := values.Get("ResourceName")
 
-	data := tagsData{Tags: make([]tagData, 0)}
+	data := tagsData{Tags: sortedTagData(s.state.ResourceTags(resourceName))}
 	if strings.Contains(resourceName, ":db:") {
 		parts := strings.Split(resourceName, ":db:")
 		if len(parts) == 2 {
@@ -331,6 +417,19 @@ func (s *Server) handleListTagsForResource(w http.ResponseWriter, values url.Val
 	s.executeTemplate(w, "list_tags_for_resource.xml", data)
 }
 
+// listValues returns the values of a query list parameter such as
+// VpcSecurityGroupIds.VpcSecurityGroupId.N, in order.
+func listValues(values url.Values, prefix string) []string {
+	var result []string
+	for i := 1; ; i++ {
+		v := values.Get(fmt.Sprintf("%s.%d", prefix, i))
+		if v == "" {
+			return result
+		}
+		result = append(result, v)
+	}
+}
+
 func (s *Server) handleCreateDBInstance(w http.ResponseWriter, values url.Values) {
 	instanceID := values.Get("DBInstanceIdentifier")
 	clusterID := values.Get("DBClusterIdentifier")
@@ -341,9 +440,7 @@ func (s *Server) handleCreateDBInstance(w http.ResponseWriter, values url.Values
 		return
 	}
 
-	faultResult := s.state.Faults().Check("CreateDBInstance", instanceID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "CreateDBInstance", instanceID) {
 		return
 	}
 
@@ -354,25 +451,62 @@ func (s *Server) handleCreateDBInstance(w http.ResponseWriter, values url.Values
 		}
 	}
 
-	inst := &MockInstance{
-		ID:            instanceID,
-		ClusterID:     clusterID,
-		InstanceType:  instanceType,
-		StorageType:   "aurora",
-		IsWriter:      false,
-		IsAutoScaled:  false,
-		PromotionTier: promotionTier,
+	caCert := values.Get("CACertificateIdentifier")
+	if caCert != "" && !IsValidCACertificate(caCert) {
+		s.sendErrorResponse(w, "CertificateNotFound", fmt.Sprintf("CA certificate %s not found", caCert), 404)
+		return
+	}
+
+	// Aurora instances take IAM database authentication from the cluster and
+	// refuse an instance-level setting.
+	if values.Has("EnableIAMDatabaseAuthentication") {
+		s.sendErrorResponse(w, "InvalidParameterCombination",
+			"The requested DB Instance will be a member of a DB Cluster. Set IAM database authentication for the DB Cluster.", 400)
+		return
 	}
 
+	if cluster, ok := s.state.GetCluster(clusterID); ok && len(cluster.Members) >= MaxClusterInstances {
+		s.sendErrorResponse(w, "DBClusterQuotaExceeded",
+			fmt.Sprintf("DB cluster %s already has the maximum of %d instances", clusterID, MaxClusterInstances), 400)
+		return
+	}
+
+	az := values.Get("AvailabilityZone")
+	if az != "" && !slices.Contains(orderableZones(instanceType), az) {
+		s.sendErrorResponse(w, "InsufficientDBInstanceCapacity",
+			fmt.Sprintf("%s is not available in %s", instanceType, az), 400)
+		return
+	}
+
+	inst := &MockInstance{
+		ID:                      instanceID,
+		ClusterID:               clusterID,
+		InstanceType:            instanceType,
+		StorageType:             "aurora",
+		IsWriter:                false,
+		IsAutoScaled:            false,
+		PromotionTier:           promotionTier,
+		CACertificateIdentifier: caCert,
+		AvailabilityZone:        az,
+		SubnetGroupName:         values.Get("DBSubnetGroupName"),
+		SecurityGroupIDs:        listValues(values, "VpcSecurityGroupIds.VpcSecurityGroupId"),
+	}
+
+	if s.state.hasInstance(instanceID) {
+		s.sendErrorResponse(w, "DBInstanceAlreadyExists",
+			fmt.Sprintf("DB instance already exists: %s", instanceID), 400)
+		return
+	}
 	if err := s.state.CreateInstance(inst); err != nil {
 		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
 		return
 	}
+	s.state.AddResourceTags(mockARN("db", instanceID), parseTags(values))
 
 	data := instanceData{
 		ID:           instanceID,
 		InstanceType: instanceType,
-		ARN:          fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:db:%s", instanceID),
+		ARN:          mockARN("db", instanceID),
 		ClusterID:    clusterID,
 	}
 	s.executeTemplate(w, "create_db_instance.xml", data)
@@ -385,9 +519,7 @@ func (s *Server) handleModifyDBInstance(w http.ResponseWriter, values url.Values
 		return
 	}
 
-	faultResult := s.state.Faults().Check("ModifyDBInstance", instanceID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "ModifyDBInstance", instanceID) {
 		return
 	}
 
@@ -401,10 +533,60 @@ func (s *Server) handleModifyDBInstance(w http.ResponseWriter, values url.Values
 		}
 	}
 
-	if err := s.state.ModifyInstance(instanceID, instanceType, storageType, iops); err != nil {
-		s.sendErrorResponse(w, "DBInstanceNotFound", err.Error(), 404)
+	// A CA certificate change is held until the next reboot and does not put
+	// the instance into modifying on its own.
+	if caCert := values.Get("CACertificateIdentifier"); caCert != "" {
+		if !IsValidCACertificate(caCert) {
+			s.sendErrorResponse(w, "CertificateNotFound", fmt.Sprintf("CA certificate %s not found", caCert), 404)
+			return
+		}
+		if err := s.state.ModifyInstanceCACert(instanceID, caCert); err != nil {
+			s.sendErrorResponse(w, "DBInstanceNotFound", err.Error(), 404)
+			return
+		}
+	}
+
+	if pgName := values.Get("DBParameterGroupName"); pgName != "" {
+		if _, ok := s.state.InstanceParameterGroupFamily(pgName); !ok {
+			s.sendErrorResponse(w, "DBParameterGroupNotFound", fmt.Sprintf("DBParameterGroup %s not found", pgName), 404)
+			return
+		}
+		if err := s.state.SetInstanceParameterGroup(instanceID, pgName); err != nil {
+			s.sendErrorResponse(w, "DBInstanceNotFound", err.Error(), 404)
+			return
+		}
+	}
+
+	monitoring, err := parseMonitoringSettings(values)
+	if err != nil {
+		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
 		return
 	}
+	if monitoring != nil {
+		if err := s.state.ModifyInstanceMonitoring(instanceID, *monitoring); err != nil {
+			code, status := "InvalidParameterCombination", 400
+			if _, ok := s.state.GetInstance(instanceID); !ok {
+				code, status = "DBInstanceNotFound", 404
+			}
+			s.sendErrorResponse(w, code, err.Error(), status)
+			return
+		}
+	}
+
+	// Without ApplyImmediately the change waits for the maintenance window,
+	// which the mock never reaches; it shows in PendingModifiedValues and is
+	// applied by the next immediate modify.
+	if values.Get("ApplyImmediately") == "false" && (instanceType != "" || storageType != "" || iops != nil) {
+		if err := s.state.QueueInstanceModification(instanceID, instanceType, storageType, iops); err != nil {
+			s.sendErrorResponse(w, "DBInstanceNotFound", err.Error(), 404)
+			return
+		}
+	} else if instanceType != "" || storageType != "" || iops != nil || (values.Get("CACertificateIdentifier") == "" && monitoring == nil) {
+		if err := s.state.ModifyInstance(instanceID, instanceType, storageType, iops); err != nil {
+			s.sendErrorResponse(w, "DBInstanceNotFound", err.Error(), 404)
+			return
+		}
+	}
 
 	inst, ok := s.state.GetInstance(instanceID)
 	if !ok {
@@ -420,6 +602,45 @@ func (s *Server) handleModifyDBInstance(w http.ResponseWriter, values url.Values
 	s.executeTemplate(w, "modify_db_instance.xml", data)
 }
 
+// parseMonitoringSettings reads the Performance Insights and Enhanced
+// Monitoring parameters of a ModifyDBInstance call, or returns nil when it
+// has none. Values RDS would reject are errors.
+func parseMonitoringSettings(values url.Values) (*MonitoringSettings, error) {
+	var settings MonitoringSettings
+	found := false
+	if v := values.Get("EnablePerformanceInsights"); v != "" {
+		enable := v == "true"
+		settings.EnablePerformanceInsights = &enable
+		found = true
+	}
+	if v := values.Get("PerformanceInsightsRetentionPeriod"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || !(days == 7 || days == 731 || (days > 0 && days%31 == 0 && days <= 713)) {
+			return nil, fmt.Errorf("invalid PerformanceInsightsRetentionPeriod: %s", v)
+		}
+		d := int32(days)
+		settings.PerformanceInsightsRetention = &d
+		found = true
+	}
+	if v := values.Get("MonitoringInterval"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || !slices.Contains([]int{0, 1, 5, 10, 15, 30, 60}, seconds) {
+			return nil, fmt.Errorf("invalid MonitoringInterval: %s", v)
+		}
+		interval := int32(seconds)
+		settings.MonitoringInterval = &interval
+		found = true
+	}
+	if v := values.Get("MonitoringRoleArn"); v != "" {
+		settings.MonitoringRoleArn = v
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return &settings, nil
+}
+
 func (s *Server) handleDeleteDBInstance(w http.ResponseWriter, values url.Values) {
 	instanceID := values.Get("DBInstanceIdentifier")
 	if instanceID == "" {
@@ -427,9 +648,12 @@ func (s *Server) handleDeleteDBInstance(w http.ResponseWriter, values url.Values
 		return
 	}
 
-	faultResult := s.state.Faults().Check("DeleteDBInstance", instanceID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "DeleteDBInstance", instanceID) {
+		return
+	}
+	if values.Get("SkipFinalSnapshot") != "true" && values.Get("FinalDBSnapshotIdentifier") == "" {
+		s.sendErrorResponse(w, "InvalidParameterCombination",
+			"FinalDBSnapshotIdentifier is required unless SkipFinalSnapshot is specified", 400)
 		return
 	}
 
@@ -438,6 +662,11 @@ func (s *Server) handleDeleteDBInstance(w http.ResponseWriter, values url.Values
 		s.sendErrorResponse(w, "DBInstanceNotFound", fmt.Sprintf("DBInstance %s not found", instanceID), 404)
 		return
 	}
+	if inst.ClusterID != "" && values.Get("FinalDBSnapshotIdentifier") != "" {
+		s.sendErrorResponse(w, "InvalidParameterCombination",
+			"FinalDBSnapshotIdentifier can not be specified when deleting a cluster instance", 400)
+		return
+	}
 
 	if err := s.state.DeleteInstance(instanceID); err != nil {
 		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
@@ -457,9 +686,7 @@ func (s *Server) handleFailoverDBCluster(w http.ResponseWriter, values url.Value
 		return
 	}
 
-	faultResult := s.state.Faults().Check("FailoverDBCluster", clusterID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "FailoverDBCluster", clusterID) {
 		return
 	}
 
@@ -484,12 +711,17 @@ func (s *Server) handleModifyDBCluster(w http.ResponseWriter, values url.Values)
 		return
 	}
 
-	faultResult := s.state.Faults().Check("ModifyDBCluster", clusterID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "ModifyDBCluster", clusterID) {
 		return
 	}
 
+	if pgName := values.Get("DBClusterParameterGroupName"); pgName != "" {
+		if err := s.state.SetClusterParameterGroup(clusterID, pgName); err != nil {
+			s.sendErrorResponse(w, "DBClusterNotFound", err.Error(), 404)
+			return
+		}
+	}
+
 	engineVersion := values.Get("EngineVersion")
 	if engineVersion != "" {
 		if err := s.state.ModifyCluster(clusterID, engineVersion); err != nil {
@@ -498,6 +730,21 @@ func (s *Server) handleModifyDBCluster(w http.ResponseWriter, values url.Values)
 		}
 	}
 
+	minACU := values.Get("ServerlessV2ScalingConfiguration.MinCapacity")
+	maxACU := values.Get("ServerlessV2ScalingConfiguration.MaxCapacity")
+	if minACU != "" || maxACU != "" {
+		minCapacity, minErr := strconv.ParseFloat(minACU, 64)
+		maxCapacity, maxErr := strconv.ParseFloat(maxACU, 64)
+		if minErr != nil || maxErr != nil {
+			s.sendErrorResponse(w, "InvalidParameterValue", "MinCapacity and MaxCapacity must both be numbers", 400)
+			return
+		}
+		if err := s.state.ModifyServerlessScaling(clusterID, minCapacity, maxCapacity); err != nil {
+			s.sendErrorResponse(w, "InvalidParameterCombination", err.Error(), 400)
+			return
+		}
+	}
+
 	cluster, ok := s.state.GetCluster(clusterID)
 	if !ok {
 		s.sendErrorResponse(w, "DBClusterNotFound", fmt.Sprintf("DBCluster %s not found after modification", clusterID), 404)
@@ -521,9 +768,7 @@ func (s *Server) handleCreateDBClusterSnapshot(w http.ResponseWriter, values url
 		return
 	}
 
-	faultResult := s.state.Faults().Check("CreateDBClusterSnapshot", snapshotID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "CreateDBClusterSnapshot", snapshotID) {
 		return
 	}
 
@@ -531,20 +776,14 @@ func (s *Server) handleCreateDBClusterSnapshot(w http.ResponseWriter, values url
 		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
 		return
 	}
+	s.state.AddResourceTags(mockARN("cluster-snapshot", snapshotID), parseTags(values))
 
 	snap, ok := s.state.GetSnapshot(snapshotID)
 	if !ok {
 		s.sendErrorResponse(w, "DBClusterSnapshotNotFound", fmt.Sprintf("Snapshot %s not found after creation", snapshotID), 404)
 		return
 	}
-	data := snapshotData{
-		ID:            snap.ID,
-		ClusterID:     snap.ClusterID,
-		Status:        snap.Status,
-		Engine:        snap.Engine,
-		EngineVersion: snap.EngineVersion,
-	}
-	s.executeTemplate(w, "create_db_cluster_snapshot.xml", data)
+	s.executeTemplate(w, "create_db_cluster_snapshot.xml", s.snapshotData(snap))
 }
 
 func (s *Server) handleDescribeDBClusterSnapshots(w http.ResponseWriter, values url.Values) {
@@ -559,22 +798,61 @@ func (s *Server) handleDescribeDBClusterSnapshots(w http.ResponseWriter, values
 		}
 		snapshots = []*MockSnapshot{snap}
 	} else {
-		snapshots = s.state.ListSnapshots()
+		clusterID := values.Get("DBClusterIdentifier")
+		for _, snap := range s.state.ListSnapshots() {
+			if clusterID == "" || snap.ClusterID == clusterID {
+				snapshots = append(snapshots, snap)
+			}
+		}
 	}
 
 	data := snapshotsData{Snapshots: make([]snapshotData, 0, len(snapshots))}
 	for _, snap := range snapshots {
-		data.Snapshots = append(data.Snapshots, snapshotData{
-			ID:            snap.ID,
-			ClusterID:     snap.ClusterID,
-			Status:        snap.Status,
-			Engine:        snap.Engine,
-			EngineVersion: snap.EngineVersion,
-		})
+		data.Snapshots = append(data.Snapshots, s.snapshotData(snap))
 	}
 	s.executeTemplate(w, "describe_db_cluster_snapshots.xml", data)
 }
 
+func (s *Server) handleDeleteDBClusterSnapshot(w http.ResponseWriter, values url.Values) {
+	snapshotID := values.Get("DBClusterSnapshotIdentifier")
+	if snapshotID == "" {
+		s.sendErrorResponse(w, "InvalidParameterValue", "DBClusterSnapshotIdentifier is required", 400)
+		return
+	}
+
+	if s.injectFault(w, "DeleteDBClusterSnapshot", snapshotID) {
+		return
+	}
+
+	snap, ok := s.state.GetSnapshot(snapshotID)
+	if !ok {
+		s.sendErrorResponse(w, "DBClusterSnapshotNotFound", fmt.Sprintf("Snapshot %s not found", snapshotID), 404)
+		return
+	}
+	data := s.snapshotData(snap)
+	data.Status = "deleted"
+	if err := s.state.DeleteSnapshot(snapshotID); err != nil {
+		s.sendErrorResponse(w, "InvalidDBClusterSnapshotStateFault", err.Error(), 400)
+		return
+	}
+	s.executeTemplate(w, "delete_db_cluster_snapshot.xml", data)
+}
+
+// snapshotData builds the template data of a snapshot, tags included.
+func (s *Server) snapshotData(snap *MockSnapshot) snapshotData {
+	arn := mockARN("cluster-snapshot", snap.ID)
+	return snapshotData{
+		ID:            snap.ID,
+		ARN:           arn,
+		ClusterID:     snap.ClusterID,
+		Status:        snap.Status,
+		Engine:        snap.Engine,
+		EngineVersion: snap.EngineVersion,
+		CreateTime:    snap.CreatedAt.UTC().Format(time.RFC3339),
+		Tags:          sortedTagData(s.state.ResourceTags(arn)),
+	}
+}
+
 // ==================== Parameter Group Handlers ====================
 
 func (s *Server) handleDescribeDBClusterParameterGroups(w http.ResponseWriter, values url.Values) {
@@ -586,13 +864,20 @@ func (s *Server) handleDescribeDBClusterParameterGroups(w http.ResponseWriter, v
 			family := strings.TrimPrefix(pgName, "default.")
 			data.ParameterGroups = append(data.ParameterGroups, parameterGroupData{
 				Name:        pgName,
+				ARN:         mockARN("cluster-pg", pgName),
 				Family:      family,
 				Description: fmt.Sprintf("Default cluster parameter group for %s", family),
 			})
 		} else {
+			family, ok := s.state.ClusterParameterGroupFamily(pgName)
+			if !ok {
+				s.sendErrorResponse(w, "DBParameterGroupNotFound", fmt.Sprintf("DBClusterParameterGroup %s not found", pgName), 404)
+				return
+			}
 			data.ParameterGroups = append(data.ParameterGroups, parameterGroupData{
 				Name:        pgName,
-				Family:      "aurora-postgresql15",
+				ARN:         mockARN("cluster-pg", pgName),
+				Family:      family,
 				Description: "Custom cluster parameter group",
 			})
 		}
@@ -602,23 +887,57 @@ func (s *Server) handleDescribeDBClusterParameterGroups(w http.ResponseWriter, v
 
 func (s *Server) handleDescribeDBClusterParameters(w http.ResponseWriter, values url.Values) {
 	pgName := values.Get("DBClusterParameterGroupName")
-
-	// Determine logical replication value by finding the cluster that owns this parameter group
-	logicalReplication := "0"
-	if pgName != "" {
-		for _, cluster := range s.state.ListClusters() {
-			if cluster.ParameterGroupName == pgName && cluster.LogicalReplicationEnabled {
-				logicalReplication = "1"
-				break
+	userOnly := values.Get("Source") == "user" // omits parameters left at their default
+
+	family, _ := s.state.ClusterParameterGroupFamily(pgName)
+	definitions := clusterParameterDefinitionsFor(family)
+	params := make(map[string]parameterData, len(definitions))
+	for name, def := range definitions {
+		params[name] = parameterData{
+			Name:          name,
+			Source:        "engine-default",
+			ApplyType:     def.ApplyType,
+			DataType:      def.DataType,
+			AllowedValues: def.AllowedValues,
+			IsModifiable:  def.IsModifiable,
+		}
+	}
+	for name, param := range s.state.ClusterParameters(pgName) {
+		p, ok := params[name]
+		if !ok {
+			p = parameterData{Name: name, ApplyType: param.ApplyType, DataType: "string", IsModifiable: true}
+		}
+		p.Value, p.Source = param.Value, "user"
+		params[name] = p
+	}
+
+	// Logical replication follows the cluster that owns this parameter group
+	// unless the group sets it, and is user-set only when enabled. Aurora
+	// MySQL has no such parameter.
+	if logical, ok := params["rds.logical_replication"]; ok {
+		if logical.Value == "" {
+			logical.Value = "0"
+			for _, cluster := range s.state.ListClusters() {
+				if pgName != "" && cluster.ParameterGroupName == pgName && cluster.LogicalReplicationEnabled {
+					logical.Value = "1"
+					break
+				}
 			}
 		}
+		logical.Source = "engine-default"
+		if logical.Value == "1" {
+			logical.Source = "user"
+		}
+		params[logical.Name] = logical
 	}
 
-	data := struct {
-		LogicalReplication string
-	}{
-		LogicalReplication: logicalReplication,
+	data := struct{ Parameters []parameterData }{}
+	for _, p := range params {
+		if !userOnly || p.Source == "user" {
+			data.Parameters = append(data.Parameters, p)
+		}
 	}
+	sort.Slice(data.Parameters, func(i, j int) bool { return data.Parameters[i].Name < data.Parameters[j].Name })
 	s.executeTemplate(w, "describe_db_cluster_parameters.xml", data)
 }
 
@@ -632,7 +951,10 @@ func (s *Server) handleCreateDBClusterParameterGroup(w http.ResponseWriter, valu
 		return
 	}
 
-	data := parameterGroupData{Name: pgName, Family: family, Description: description}
+	s.state.AddClusterParameterGroup(pgName, family)
+	s.state.AddResourceTags(mockARN("cluster-pg", pgName), parseTags(values))
+
+	data := parameterGroupData{Name: pgName, Family: family, Description: description, ARN: mockARN("cluster-pg", pgName)}
 	s.executeTemplate(w, "create_db_cluster_parameter_group.xml", data)
 }
 
@@ -643,10 +965,30 @@ func (s *Server) handleModifyDBClusterParameterGroup(w http.ResponseWriter, valu
 		return
 	}
 
+	if s.rejectParameters(w, "ModifyDBClusterParameterGroup", values) {
+		return
+	}
+	s.state.SetClusterParameters(pgName, parseParameters(values))
+
 	data := parameterGroupData{Name: pgName}
 	s.executeTemplate(w, "modify_db_cluster_parameter_group.xml", data)
 }
 
+// rejectParameters checks faults for each parameter in a Modify*ParameterGroup
+// request, targeting faults by parameter name so individual parameters can be
+// rejected. It returns true if an error response was sent.
+func (s *Server) rejectParameters(w http.ResponseWriter, action string, values url.Values) bool {
+	for i := 1; ; i++ {
+		name := values.Get(fmt.Sprintf("Parameters.Parameter.%d.ParameterName", i))
+		if name == "" {
+			return false
+		}
+		if s.injectFault(w, action, name) {
+			return true
+		}
+	}
+}
+
 // DB Instance Parameter Group Handlers
 
 func (s *Server) handleDescribeDBParameterGroups(w http.ResponseWriter, values url.Values) {
@@ -654,20 +996,21 @@ func (s *Server) handleDescribeDBParameterGroups(w http.ResponseWriter, values u
 
 	data := parameterGroupsData{ParameterGroups: make([]parameterGroupData, 0)}
 	if pgName != "" {
+		family, ok := s.state.InstanceParameterGroupFamily(pgName)
+		if !ok {
+			s.sendErrorResponse(w, "DBParameterGroupNotFound", fmt.Sprintf("DBParameterGroup %s not found", pgName), 404)
+			return
+		}
+		description := "Custom instance parameter group"
 		if strings.HasPrefix(pgName, "default.") {
-			family := strings.TrimPrefix(pgName, "default.")
-			data.ParameterGroups = append(data.ParameterGroups, parameterGroupData{
-				Name:        pgName,
-				Family:      family,
-				Description: fmt.Sprintf("Default parameter group for %s", family),
-			})
-		} else {
-			data.ParameterGroups = append(data.ParameterGroups, parameterGroupData{
-				Name:        pgName,
-				Family:      "aurora-postgresql15",
-				Description: "Custom instance parameter group",
-			})
+			description = fmt.Sprintf("Default parameter group for %s", family)
 		}
+		data.ParameterGroups = append(data.ParameterGroups, parameterGroupData{
+			Name:        pgName,
+			ARN:         mockARN("pg", pgName),
+			Family:      family,
+			Description: description,
+		})
 	}
 	s.executeTemplate(w, "describe_db_parameter_groups.xml", data)
 }
@@ -686,7 +1029,10 @@ func (s *Server) handleCreateDBParameterGroup(w http.ResponseWriter, values url.
 		return
 	}
 
-	data := parameterGroupData{Name: pgName, Family: family, Description: description}
+	s.state.AddInstanceParameterGroup(pgName, family)
+	s.state.AddResourceTags(mockARN("pg", pgName), parseTags(values))
+
+	data := parameterGroupData{Name: pgName, Family: family, Description: description, ARN: mockARN("pg", pgName)}
 	s.executeTemplate(w, "create_db_parameter_group.xml", data)
 }
 
@@ -697,6 +1043,10 @@ func (s *Server) handleModifyDBParameterGroup(w http.ResponseWriter, values url.
 		return
 	}
 
+	if s.rejectParameters(w, "ModifyDBParameterGroup", values) {
+		return
+	}
+
 	data := parameterGroupData{Name: pgName}
 	s.executeTemplate(w, "modify_db_parameter_group.xml", data)
 }
@@ -713,9 +1063,7 @@ func (s *Server) handleCreateBlueGreenDeployment(w http.ResponseWriter, values u
 		return
 	}
 
-	faultResult := s.state.Faults().Check("CreateBlueGreenDeployment", deploymentName)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "CreateBlueGreenDeployment", deploymentName) {
 		return
 	}
 
@@ -758,9 +1106,7 @@ func (s *Server) handleSwitchoverBlueGreenDeployment(w http.ResponseWriter, valu
 		return
 	}
 
-	faultResult := s.state.Faults().Check("SwitchoverBlueGreenDeployment", identifier)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "SwitchoverBlueGreenDeployment", identifier) {
 		return
 	}
 
@@ -785,9 +1131,7 @@ func (s *Server) handleDeleteBlueGreenDeployment(w http.ResponseWriter, values u
 		return
 	}
 
-	faultResult := s.state.Faults().Check("DeleteBlueGreenDeployment", identifier)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "DeleteBlueGreenDeployment", identifier) {
 		return
 	}
 
@@ -838,9 +1182,7 @@ func (s *Server) handleDeleteDBCluster(w http.ResponseWriter, values url.Values)
 		return
 	}
 
-	faultResult := s.state.Faults().Check("DeleteDBCluster", clusterID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "DeleteDBCluster", clusterID) {
 		return
 	}
 
@@ -868,46 +1210,26 @@ func (s *Server) handleDescribeDBEngineVersions(w http.ResponseWriter, values ur
 		return
 	}
 
-	// Generate mock upgrade targets based on the current version
 	var upgradeTargets []upgradeTargetData
 	if engineVersion != "" {
-		parts := strings.Split(engineVersion, ".")
-		if len(parts) >= 2 {
-			major, _ := strconv.Atoi(parts[0])
-			minor, _ := strconv.Atoi(parts[1])
-
-			// Add minor upgrades (next 2-3 patch versions)
-			for i := 1; i <= 3; i++ {
-				upgradeTargets = append(upgradeTargets, upgradeTargetData{
-					Engine:      engine,
-					Version:     fmt.Sprintf("%d.%d", major, minor+i),
-					Description: fmt.Sprintf("PostgreSQL %d.%d", major, minor+i),
-					IsMajor:     false,
-				})
+		for _, target := range s.state.UpgradeTargets(engine, engineVersion) {
+			modes := target.EngineModes
+			if len(modes) == 0 {
+				modes = []string{"provisioned"}
 			}
-
-			// Add major upgrades (next major version)
 			upgradeTargets = append(upgradeTargets, upgradeTargetData{
 				Engine:      engine,
-				Version:     fmt.Sprintf("%d.1", major+1),
-				Description: fmt.Sprintf("PostgreSQL %d.1", major+1),
-				IsMajor:     true,
-			})
-			upgradeTargets = append(upgradeTargets, upgradeTargetData{
-				Engine:      engine,
-				Version:     fmt.Sprintf("%d.2", major+1),
-				Description: fmt.Sprintf("PostgreSQL %d.2", major+1),
-				IsMajor:     true,
+				Version:     target.Version,
+				Description: engineDisplayName(engine) + " " + target.Version,
+				IsMajor:     target.IsMajor,
+				EngineModes: modes,
 			})
 		}
 	}
 
 	paramGroupFamily := "aurora-postgresql15"
 	if engineVersion != "" {
-		parts := strings.Split(engineVersion, ".")
-		if len(parts) > 0 {
-			paramGroupFamily = fmt.Sprintf("aurora-postgresql%s", parts[0])
-		}
+		paramGroupFamily = parameterGroupFamily(engine, engineVersion)
 	}
 
 	data := engineVersionData{
@@ -926,9 +1248,7 @@ func (s *Server) handleRebootDBInstance(w http.ResponseWriter, values url.Values
 		return
 	}
 
-	faultResult := s.state.Faults().Check("RebootDBInstance", instanceID)
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	if s.injectFault(w, "RebootDBInstance", instanceID) {
 		return
 	}
 
@@ -960,9 +1280,40 @@ func (s *Server) handleDescribeOrderableDBInstanceOptions(w http.ResponseWriter,
 		return
 	}
 
-	// Generate mock instance types for Aurora PostgreSQL
-	// Grouped by family in a logical order
-	instanceTypes := []orderableInstanceData{
+	// AWS returns one option per instance class and storage type. Aurora offers
+	// standard ("aurora") and I/O-Optimized ("aurora-iopt1") storage; the mock
+	// withholds I/O-Optimized from the t3 family so demos can exercise rejection.
+	// Like newer Aurora PostgreSQL releases, major version 16 and later drop
+	// the t3 family altogether.
+	instanceTypes := orderableInstanceTypes()
+	dropT3 := engineMajorVersion(engineVersion) >= 16
+	options := make([]orderableInstanceData, 0, len(instanceTypes)*2)
+	for _, it := range instanceTypes {
+		if dropT3 && strings.HasPrefix(it.InstanceClass, "db.t3.") {
+			continue
+		}
+		it.StorageType = "aurora"
+		options = append(options, it)
+		if !strings.HasPrefix(it.InstanceClass, "db.t3.") {
+			it.StorageType = "aurora-iopt1"
+			options = append(options, it)
+		}
+	}
+
+	data := orderableInstanceOptionsData{
+		Engine:        engine,
+		EngineVersion: engineVersion,
+		InstanceTypes: options,
+	}
+	s.executeTemplate(w, "describe_orderable_db_instance_options.xml", data)
+}
+
+// orderableInstanceTypes returns the mock's orderable instance classes,
+// grouped by family in a logical order. db.r5.24xlarge is not offered in the
+// first AZ, where demo writers run, so demos can exercise temp instance
+// placement falling back to another AZ.
+func orderableInstanceTypes() []orderableInstanceData {
+	return []orderableInstanceData{
 		// r6g family (Graviton2 - recommended)
 		{InstanceClass: "db.r6g.large", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 		{InstanceClass: "db.r6g.xlarge", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
@@ -979,7 +1330,7 @@ func (s *Server) handleDescribeOrderableDBInstanceOptions(w http.ResponseWriter,
 		{InstanceClass: "db.r5.8xlarge", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 		{InstanceClass: "db.r5.12xlarge", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 		{InstanceClass: "db.r5.16xlarge", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
-		{InstanceClass: "db.r5.24xlarge", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
+		{InstanceClass: "db.r5.24xlarge", AvailabilityZones: []string{"us-east-1b", "us-east-1c"}},
 		// t4g family (burstable, Graviton)
 		{InstanceClass: "db.t4g.micro", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 		{InstanceClass: "db.t4g.small", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
@@ -991,13 +1342,24 @@ func (s *Server) handleDescribeOrderableDBInstanceOptions(w http.ResponseWriter,
 		{InstanceClass: "db.t3.medium", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 		{InstanceClass: "db.t3.large", AvailabilityZones: []string{"us-east-1a", "us-east-1b", "us-east-1c"}},
 	}
+}
 
-	data := orderableInstanceOptionsData{
-		Engine:        engine,
-		EngineVersion: engineVersion,
-		InstanceTypes: instanceTypes,
+// engineMajorVersion returns the major version of an engine version such as
+// 15.4, or 0 when there is none.
+func engineMajorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// orderableZones returns the AZs an instance class can be created in.
+func orderableZones(instanceClass string) []string {
+	for _, it := range orderableInstanceTypes() {
+		if it.InstanceClass == instanceClass {
+			return it.AvailabilityZones
+		}
 	}
-	s.executeTemplate(w, "describe_orderable_db_instance_options.xml", data)
+	return nil
 }
 
 // ==================== RDS Proxy Handlers ====================
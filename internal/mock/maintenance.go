@@ -0,0 +1,150 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MockPendingMaintenanceAction represents a maintenance action scheduled for
+// a cluster or instance.
+type MockPendingMaintenanceAction struct {
+	Action               string // system-update, db-upgrade, os-upgrade, ...
+	Description          string
+	AutoAppliedAfterDate time.Time
+}
+
+type (
+	pendingMaintenanceActionData struct {
+		Action               string
+		Description          string
+		AutoAppliedAfterDate string
+	}
+
+	resourcePendingMaintenanceData struct {
+		ResourceARN string
+		Actions     []pendingMaintenanceActionData
+	}
+
+	pendingMaintenanceData struct {
+		Resources []resourcePendingMaintenanceData
+	}
+)
+
+// AddPendingMaintenanceAction schedules a maintenance action for a cluster or
+// instance.
+func (s *State) AddPendingMaintenanceAction(resourceID string, action MockPendingMaintenanceAction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clusters[resourceID]; !ok {
+		if _, ok := s.instances[resourceID]; !ok {
+			return fmt.Errorf("resource not found: %s", resourceID)
+		}
+	}
+	s.pendingMaintenance[resourceID] = append(s.pendingMaintenance[resourceID], action)
+	return nil
+}
+
+// PendingMaintenanceActions returns the actions scheduled for a cluster or instance.
+func (s *State) PendingMaintenanceActions(resourceID string) []MockPendingMaintenanceAction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]MockPendingMaintenanceAction(nil), s.pendingMaintenance[resourceID]...)
+}
+
+// ApplyPendingMaintenanceAction applies a scheduled action immediately. An
+// instance reboots to apply it; cluster actions are applied at once.
+func (s *State) ApplyPendingMaintenanceAction(resourceID, action string) error {
+	s.mu.Lock()
+	actions := s.pendingMaintenance[resourceID]
+	idx := -1
+	for i, a := range actions {
+		if a.Action == action {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		s.mu.Unlock()
+		return fmt.Errorf("no pending %s action for %s", action, resourceID)
+	}
+	s.pendingMaintenance[resourceID] = append(actions[:idx:idx], actions[idx+1:]...)
+	_, isInstance := s.instances[resourceID]
+	s.mu.Unlock()
+
+	if isInstance {
+		return s.RebootInstance(resourceID)
+	}
+	return nil
+}
+
+// filterValues returns the values of the named request filter, as sent by
+// the Describe* actions that accept Filters.
+func filterValues(values url.Values, name string) []string {
+	for i := 1; ; i++ {
+		filterName := values.Get(fmt.Sprintf("Filters.Filter.%d.Name", i))
+		if filterName == "" {
+			return nil
+		}
+		if filterName != name {
+			continue
+		}
+		var result []string
+		for j := 1; ; j++ {
+			v := values.Get(fmt.Sprintf("Filters.Filter.%d.Values.Value.%d", i, j))
+			if v == "" {
+				return result
+			}
+			result = append(result, v)
+		}
+	}
+}
+
+func (s *Server) handleDescribePendingMaintenanceActions(w http.ResponseWriter, values url.Values) {
+	var resources []resourcePendingMaintenanceData
+	add := func(id, kind string) {
+		actions := s.state.PendingMaintenanceActions(id)
+		if len(actions) == 0 {
+			return
+		}
+		resource := resourcePendingMaintenanceData{
+			ResourceARN: fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:%s:%s", kind, id),
+		}
+		for _, a := range actions {
+			resource.Actions = append(resource.Actions, pendingMaintenanceActionData{
+				Action:               a.Action,
+				Description:          a.Description,
+				AutoAppliedAfterDate: a.AutoAppliedAfterDate.UTC().Format(time.RFC3339),
+			})
+		}
+		resources = append(resources, resource)
+	}
+
+	for _, id := range filterValues(values, "db-cluster-id") {
+		add(id, "cluster")
+	}
+	for _, id := range filterValues(values, "db-instance-id") {
+		add(id, "db")
+	}
+
+	s.executeTemplate(w, "describe_pending_maintenance_actions.xml", pendingMaintenanceData{Resources: resources})
+}
+
+func (s *Server) handleApplyPendingMaintenanceAction(w http.ResponseWriter, values url.Values) {
+	arn := values.Get("ResourceIdentifier")
+	action := values.Get("ApplyAction")
+	if arn == "" || action == "" {
+		s.sendErrorResponse(w, "MissingParameter", "ResourceIdentifier and ApplyAction are required", 400)
+		return
+	}
+	resourceID := arn[strings.LastIndex(arn, ":")+1:]
+
+	if err := s.state.ApplyPendingMaintenanceAction(resourceID, action); err != nil {
+		s.sendErrorResponse(w, "InvalidParameterValue", err.Error(), 400)
+		return
+	}
+
+	s.executeTemplate(w, "apply_pending_maintenance_action.xml", resourcePendingMaintenanceData{ResourceARN: arn})
+}
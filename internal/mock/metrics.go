@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/smithy-go/encoding/cbor"
+)
+
+// SetReplicaLag sets the AuroraReplicaLag reported for an instance.
+func (s *State) SetReplicaLag(instanceID string, lagMs float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[instanceID]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", instanceID)
+	}
+	inst.ReplicaLagMs = lagMs
+	return nil
+}
+
+// handleGetMetricStatistics serves the CloudWatch GetMetricStatistics call
+// for AuroraReplicaLag on a DBInstanceIdentifier dimension. Available readers
+// report one datapoint with their modelled lag; writers, like in AWS, report
+// none. Any other metric has no datapoints.
+func (s *Server) handleGetMetricStatistics(w http.ResponseWriter, req cbor.Map) {
+	metricName := cborString(req["MetricName"])
+
+	var instanceID string
+	if dimensions, ok := req["Dimensions"].(cbor.List); ok {
+		for _, d := range dimensions {
+			dimension, _ := d.(cbor.Map)
+			if cborString(dimension["Name"]) == "DBInstanceIdentifier" {
+				instanceID = cborString(dimension["Value"])
+			}
+		}
+	}
+
+	datapoints := cbor.List{}
+	if metricName == "AuroraReplicaLag" && instanceID != "" {
+		if inst, ok := s.state.GetInstance(instanceID); ok && !inst.IsWriter && inst.Status == "available" {
+			datapoints = append(datapoints, cbor.Map{
+				"Timestamp": cborTime(time.Now().UTC().Truncate(time.Minute)),
+				"Maximum":   cbor.Float64(inst.ReplicaLagMs),
+				"Unit":      cbor.String("Milliseconds"),
+			})
+		}
+	}
+
+	s.sendCBOR(w, cbor.Map{"Label": cbor.String(metricName), "Datapoints": datapoints})
+}
+
+// handleMockReplicaLag sets an instance's replica lag (POST), so the demo and
+// tests can simulate a lagging failover target.
+func (s *Server) handleMockReplicaLag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		InstanceID string  `json:"instance_id"`
+		LagMs      float64 `json:"lag_ms"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.state.SetReplicaLag(body.InstanceID, body.LagMs); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+}
@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"fmt"
+)
+
+// MonitoringSettings is a change to an instance's Performance Insights and
+// Enhanced Monitoring settings. Nil fields and an empty role are unchanged.
+type MonitoringSettings struct {
+	EnablePerformanceInsights    *bool
+	PerformanceInsightsRetention *int32
+	MonitoringInterval           *int32
+	MonitoringRoleArn            string
+}
+
+// ModifyInstanceMonitoring records a monitoring change the way RDS applies
+// one: the instance goes through modifying, where retention, interval and
+// role take effect, and then through configuring-performance-insights or
+// configuring-enhanced-monitoring when either is being turned on.
+// Performance Insights reports enabled only once its configuring status
+// finishes.
+func (s *State) ModifyInstanceMonitoring(id string, settings MonitoringSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+
+	enablingPI := settings.EnablePerformanceInsights != nil && *settings.EnablePerformanceInsights && !inst.PerformanceInsightsEnabled
+	enablingEM := settings.MonitoringInterval != nil && *settings.MonitoringInterval > 0 && inst.MonitoringInterval == 0
+	if enablingEM && settings.MonitoringRoleArn == "" && inst.MonitoringRoleArn == "" {
+		return fmt.Errorf("a MonitoringRoleARN value is required if you specify a MonitoringInterval value other than 0")
+	}
+
+	inst.PendingMonitoring = &settings
+	switch {
+	case enablingPI:
+		inst.TransitionalStatus = "configuring-performance-insights"
+	case enablingEM:
+		inst.TransitionalStatus = "configuring-enhanced-monitoring"
+	}
+
+	s.beginModifyingLocked(inst)
+	return nil
+}
+
+// applyPendingMonitoring applies a monitoring change as the instance leaves
+// modifying. Turning Performance Insights on is left to the end of
+// configuring-performance-insights.
+func applyPendingMonitoring(inst *MockInstance) {
+	p := inst.PendingMonitoring
+	if p == nil {
+		return
+	}
+	inst.PendingMonitoring = nil
+	if p.EnablePerformanceInsights != nil && !*p.EnablePerformanceInsights {
+		inst.PerformanceInsightsEnabled = false
+	}
+	if p.PerformanceInsightsRetention != nil {
+		inst.PerformanceInsightsRetention = *p.PerformanceInsightsRetention
+	}
+	if p.MonitoringInterval != nil {
+		inst.MonitoringInterval = *p.MonitoringInterval
+	}
+	if p.MonitoringRoleArn != "" {
+		inst.MonitoringRoleArn = p.MonitoringRoleArn
+	}
+}
+
+// PerformanceInsightsRetentionDays returns the instance's Performance
+// Insights retention, which defaults to the free 7 days.
+func (i *MockInstance) PerformanceInsightsRetentionDays() int32 {
+	if i.PerformanceInsightsRetention == 0 {
+		return 7
+	}
+	return i.PerformanceInsightsRetention
+}
@@ -0,0 +1,171 @@
+package mock
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MockParameter is a user-set parameter in a mock cluster parameter group.
+type MockParameter struct {
+	Value     string
+	ApplyType string // static or dynamic
+}
+
+// parameterDefinition describes an engine parameter as DescribeDB*Parameters
+// reports it.
+type parameterDefinition struct {
+	ApplyType     string // static or dynamic
+	DataType      string
+	AllowedValues string
+	IsModifiable  bool
+}
+
+// clusterParameterDefinitions are the parameters every mock cluster parameter
+// group defines. A listing without a Source filter returns all of them, so
+// values can be validated against their data type and allowed values.
+var clusterParameterDefinitions = map[string]parameterDefinition{
+	"log_min_duration_statement": {ApplyType: "dynamic", DataType: "integer", AllowedValues: "-1-2147483647", IsModifiable: true},
+	"max_connections":            {ApplyType: "static", DataType: "integer", AllowedValues: "6-8388607", IsModifiable: true},
+	"random_page_cost":           {ApplyType: "dynamic", DataType: "real", AllowedValues: "0-2147483647", IsModifiable: true},
+	"rds.extensions":             {ApplyType: "static", DataType: "list", IsModifiable: false},
+	"rds.force_ssl":              {ApplyType: "dynamic", DataType: "boolean", AllowedValues: "0,1", IsModifiable: true},
+	"rds.logical_replication":    {ApplyType: "static", DataType: "boolean", AllowedValues: "0,1", IsModifiable: true},
+	"shared_preload_libraries":   {ApplyType: "static", DataType: "list", AllowedValues: "auto_explain,pg_cron,pg_hint_plan,pg_stat_statements,pg_tle,pglogical", IsModifiable: true},
+	"timezone":                   {ApplyType: "dynamic", DataType: "string", IsModifiable: true},
+	"work_mem":                   {ApplyType: "dynamic", DataType: "integer", AllowedValues: "64-2147483647", IsModifiable: true},
+}
+
+// auroraMySQLParameterDefinitions are the parameters every mock Aurora MySQL
+// cluster parameter group defines. Some exist in one major version only.
+var auroraMySQLParameterDefinitions = map[string]parameterDefinition{
+	"binlog_format":              {ApplyType: "static", DataType: "string", AllowedValues: "ROW,STATEMENT,MIXED,OFF", IsModifiable: true},
+	"character_set_server":       {ApplyType: "dynamic", DataType: "string", IsModifiable: true},
+	"innodb_print_all_deadlocks": {ApplyType: "dynamic", DataType: "boolean", AllowedValues: "0,1", IsModifiable: true},
+	"long_query_time":            {ApplyType: "dynamic", DataType: "float", AllowedValues: "0-31536000", IsModifiable: true},
+	"max_connections":            {ApplyType: "dynamic", DataType: "integer", AllowedValues: "1-16000", IsModifiable: true},
+	"slow_query_log":             {ApplyType: "dynamic", DataType: "boolean", AllowedValues: "0,1", IsModifiable: true},
+	"time_zone":                  {ApplyType: "dynamic", DataType: "string", IsModifiable: true},
+}
+
+// auroraMySQLVersionParameters are the parameters of one Aurora MySQL major
+// version: MySQL 8.0 renamed tx_isolation and dropped the query cache.
+var auroraMySQLVersionParameters = map[string]map[string]parameterDefinition{
+	"aurora-mysql5.7": {
+		"query_cache_type": {ApplyType: "static", DataType: "string", AllowedValues: "0,1,2", IsModifiable: true},
+		"tx_isolation":     {ApplyType: "dynamic", DataType: "string", AllowedValues: "READ-UNCOMMITTED,READ-COMMITTED,REPEATABLE-READ,SERIALIZABLE", IsModifiable: true},
+	},
+	"aurora-mysql8.0": {
+		"transaction_isolation": {ApplyType: "dynamic", DataType: "string", AllowedValues: "READ-UNCOMMITTED,READ-COMMITTED,REPEATABLE-READ,SERIALIZABLE", IsModifiable: true},
+	},
+}
+
+// clusterParameterDefinitionsFor returns the parameters a cluster parameter
+// group of the family defines.
+func clusterParameterDefinitionsFor(family string) map[string]parameterDefinition {
+	if !strings.HasPrefix(family, "aurora-mysql") {
+		return clusterParameterDefinitions
+	}
+	defs := maps.Clone(auroraMySQLParameterDefinitions)
+	maps.Copy(defs, auroraMySQLVersionParameters[family])
+	return defs
+}
+
+type parameterData struct {
+	Name          string
+	Value         string
+	Source        string
+	ApplyType     string
+	DataType      string
+	AllowedValues string
+	IsModifiable  bool
+}
+
+// SetClusterParameters stores user-set parameters on a cluster parameter
+// group, replacing any earlier value for the same parameter.
+func (s *State) SetClusterParameters(parameterGroupName string, params map[string]MockParameter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group, ok := s.clusterParameters[parameterGroupName]
+	if !ok {
+		group = make(map[string]MockParameter)
+		s.clusterParameters[parameterGroupName] = group
+	}
+	for name, param := range params {
+		group[name] = param
+	}
+}
+
+// ClusterParameters returns the user-set parameters of a cluster parameter group.
+func (s *State) ClusterParameters(parameterGroupName string) map[string]MockParameter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]MockParameter, len(s.clusterParameters[parameterGroupName]))
+	for name, param := range s.clusterParameters[parameterGroupName] {
+		result[name] = param
+	}
+	return result
+}
+
+// AddClusterParameterGroup records a custom cluster parameter group.
+func (s *State) AddClusterParameterGroup(name, family string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clusterParamGroups[name] = family
+}
+
+// ClusterParameterGroupFamily returns the family of a cluster parameter
+// group: the one it was created with, else that of the cluster using it.
+// Default groups (default.<family>) always exist.
+func (s *State) ClusterParameterGroupFamily(name string) (string, bool) {
+	if family, ok := strings.CutPrefix(name, "default."); ok {
+		return family, true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if family, ok := s.clusterParamGroups[name]; ok {
+		return family, true
+	}
+	for _, cluster := range s.clusters {
+		if cluster.ParameterGroupName == name {
+			return parameterGroupFamily(cluster.Engine, cluster.EngineVersion), true
+		}
+	}
+	return "", false
+}
+
+// SetClusterParameterGroup associates a cluster parameter group with a
+// cluster, which briefly puts the cluster into modifying.
+func (s *State) SetClusterParameterGroup(clusterID, parameterGroupName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.clusters[clusterID]
+	if !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+	cluster.ParameterGroupName = parameterGroupName
+	cluster.Status = "modifying"
+	cluster.StatusChangedAt = time.Now()
+	return nil
+}
+
+// parseParameters reads the Parameters.Parameter.N list of a
+// Modify*ParameterGroup request. Parameters applied on reboot are static.
+func parseParameters(values url.Values) map[string]MockParameter {
+	params := make(map[string]MockParameter)
+	for i := 1; ; i++ {
+		prefix := fmt.Sprintf("Parameters.Parameter.%d.", i)
+		name := values.Get(prefix + "ParameterName")
+		if name == "" {
+			return params
+		}
+		applyType := "dynamic"
+		if values.Get(prefix+"ApplyMethod") == "pending-reboot" {
+			applyType = "static"
+		}
+		params[name] = MockParameter{Value: values.Get(prefix + "ParameterValue"), ApplyType: applyType}
+	}
+}
@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RestoreClusterFromSnapshot creates a cluster without instances from an
+// available snapshot. The cluster starts "creating" and becomes available
+// once the restore completes. It keeps the source cluster's parameter group
+// if that cluster still exists.
+func (s *State) RestoreClusterFromSnapshot(snapshotID, clusterID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, ok := s.snapshots[snapshotID]
+	if !ok {
+		return fmt.Errorf("snapshot not found: %s", snapshotID)
+	}
+	if snap.Status != "available" {
+		return fmt.Errorf("snapshot %s is not available: %s", snapshotID, snap.Status)
+	}
+	if _, exists := s.clusters[clusterID]; exists {
+		return fmt.Errorf("cluster already exists: %s", clusterID)
+	}
+
+	cluster := &MockCluster{
+		ID:              clusterID,
+		Engine:          snap.Engine,
+		EngineVersion:   snap.EngineVersion,
+		Status:          "creating",
+		StatusChangedAt: time.Now(),
+	}
+	if source, ok := s.clusters[snap.ClusterID]; ok {
+		cluster.ParameterGroupName = source.ParameterGroupName
+		cluster.LogicalReplicationEnabled = source.LogicalReplicationEnabled
+	}
+	s.clusters[clusterID] = cluster
+
+	return nil
+}
+
+func (s *Server) handleRestoreDBClusterFromSnapshot(w http.ResponseWriter, values url.Values) {
+	clusterID := values.Get("DBClusterIdentifier")
+	snapshotID := values.Get("SnapshotIdentifier")
+	if clusterID == "" || snapshotID == "" || values.Get("Engine") == "" {
+		s.sendErrorResponse(w, "InvalidParameterValue", "DBClusterIdentifier, SnapshotIdentifier and Engine are required", 400)
+		return
+	}
+
+	if s.injectFault(w, "RestoreDBClusterFromSnapshot", clusterID) {
+		return
+	}
+
+	if _, exists := s.state.GetCluster(clusterID); exists {
+		s.sendErrorResponse(w, "DBClusterAlreadyExistsFault", fmt.Sprintf("DB Cluster %s already exists", clusterID), 400)
+		return
+	}
+	if _, ok := s.state.GetSnapshot(snapshotID); !ok {
+		s.sendErrorResponse(w, "DBClusterSnapshotNotFoundFault", fmt.Sprintf("DBClusterSnapshot %s not found", snapshotID), 404)
+		return
+	}
+
+	if err := s.state.RestoreClusterFromSnapshot(snapshotID, clusterID); err != nil {
+		s.sendErrorResponse(w, "InvalidDBClusterSnapshotStateFault", err.Error(), 400)
+		return
+	}
+
+	cluster, _ := s.state.GetCluster(clusterID)
+	s.executeTemplate(w, "restore_db_cluster_from_snapshot.xml", clusterData{
+		ID:            cluster.ID,
+		ARN:           fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:cluster:%s", cluster.ID),
+		Engine:        cluster.Engine,
+		EngineVersion: cluster.EngineVersion,
+		Status:        cluster.Status,
+	})
+}
@@ -0,0 +1,164 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatusScriptEntry is one status in a scripted sequence and how long the
+// instance holds it.
+type StatusScriptEntry struct {
+	Status     string `json:"status"`
+	DurationMs int    `json:"duration_ms"`
+}
+
+// statusScript is a sequence of statuses an instance plays back. While it
+// plays, it owns the instance's status: the transition loop does not move the
+// instance on its own, and a status change requested by an API call is held
+// until the script ends.
+type statusScript struct {
+	remaining []StatusScriptEntry
+	until     time.Time // when the current status ends
+}
+
+// ScriptInstanceStatus makes an instance play back a sequence of statuses,
+// holding each for its duration. With onModify the script is armed instead
+// and starts on the instance's next ModifyDBInstance, in place of the usual
+// delayed move to modifying. Starting a script drops any pending status
+// change. After the last entry the instance carries on from that status as
+// usual.
+func (s *State) ScriptInstanceStatus(id string, entries []StatusScriptEntry, onModify bool) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("status script for %s is empty", id)
+	}
+	for i, e := range entries {
+		if e.Status == "" || e.DurationMs < 0 {
+			return fmt.Errorf("status script entry %d needs a status and a non-negative duration", i+1)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+	if onModify {
+		inst.ArmedStatusScript = append([]StatusScriptEntry(nil), entries...)
+		return nil
+	}
+	startStatusScript(inst, entries, time.Now())
+	return nil
+}
+
+// ClearInstanceStatusScript stops an instance's script, playing or armed,
+// leaving it at its current status.
+func (s *State) ClearInstanceStatusScript(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+	inst.statusScript = nil
+	inst.ArmedStatusScript = nil
+	inst.StatusChangedAt = time.Now()
+	return nil
+}
+
+// Scripted reports whether the instance is playing a status script.
+func (i *MockInstance) Scripted() bool {
+	return i.statusScript != nil
+}
+
+func startStatusScript(inst *MockInstance, entries []StatusScriptEntry, now time.Time) {
+	inst.ArmedStatusScript = nil
+	inst.PendingStatusChange = ""
+	inst.PendingStatusChangeAt = time.Time{}
+	inst.Status = entries[0].Status
+	inst.StatusChangedAt = now
+	inst.statusScript = &statusScript{
+		remaining: append([]StatusScriptEntry(nil), entries[1:]...),
+		until:     now.Add(time.Duration(entries[0].DurationMs) * time.Millisecond),
+	}
+}
+
+// advanceStatusScript moves a scripted instance to its next status once the
+// current one has run its course. Leaving a scripted modifying applies the
+// pending modifications, as the unscripted transition does, including those
+// of a modify made while the script played, which then needs no modifying of
+// its own.
+func advanceStatusScript(inst *MockInstance, now time.Time) {
+	script := inst.statusScript
+	if now.Before(script.until) {
+		return
+	}
+	switch inst.Status {
+	case "modifying":
+		applyPendingMonitoring(inst)
+		applyPendingInstanceChanges(inst)
+		if inst.PendingStatusChange == "modifying" {
+			inst.PendingStatusChange = ""
+			inst.PendingStatusChangeAt = time.Time{}
+		}
+	case "configuring-performance-insights":
+		inst.PerformanceInsightsEnabled = true
+	}
+	inst.StatusChangedAt = now
+	if len(script.remaining) == 0 {
+		// The script stood in for any configuring status a modify queued.
+		inst.TransitionalStatus = ""
+		inst.statusScript = nil
+		return
+	}
+	next := script.remaining[0]
+	script.remaining = script.remaining[1:]
+	script.until = now.Add(time.Duration(next.DurationMs) * time.Millisecond)
+	inst.Status = next.Status
+}
+
+// handleMockInstanceScript sets (POST) or clears (DELETE) the status script
+// of the instance in /mock/instances/{id}/script.
+func (s *Server) handleMockInstanceScript(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/mock/instances/"), "/script")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var body struct {
+			Statuses []StatusScriptEntry `json:"statuses"`
+			OnModify bool                `json:"on_modify"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if _, ok := s.state.GetInstance(id); !ok {
+			http.Error(w, fmt.Sprintf("instance not found: %s", id), http.StatusNotFound)
+			return
+		}
+		if err := s.state.ScriptInstanceStatus(id, body.Statuses, body.OnModify); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "scripted"})
+	case http.MethodDelete:
+		if err := s.state.ClearInstanceStatusScript(id); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "cleared"})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -44,6 +44,9 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/mock/timing", s.handleMockTiming)
 	s.mux.HandleFunc("/mock/faults", s.handleMockFaults)
 	s.mux.HandleFunc("/mock/faults/", s.handleMockFaultByID)
+	s.mux.HandleFunc("/mock/alarms", s.handleMockAlarms)
+	s.mux.HandleFunc("/mock/replica-lag", s.handleMockReplicaLag)
+	s.mux.HandleFunc("/mock/instances/", s.handleMockInstanceScript)
 }
 
 // ServeHTTP implements http.Handler.
@@ -84,6 +87,19 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	// CloudWatch, served from the same endpoint in demo mode, speaks CBOR and
+	// names the action in the path
+	if action, ok := strings.CutPrefix(r.URL.Path, cloudWatchOperationPath); ok {
+		s.handleCloudWatchAction(w, action, body)
+		return
+	}
+
+	// Application Auto Scaling speaks JSON and names the action in a header
+	if target := r.Header.Get("X-Amz-Target"); strings.HasPrefix(target, "AnyScaleFrontendService.") {
+		s.handleAutoScalingAction(w, strings.TrimPrefix(target, "AnyScaleFrontendService."), body)
+		return
+	}
+
 	values, err := url.ParseQuery(string(body))
 	if err != nil {
 		s.sendErrorResponse(w, "InvalidParameterValue", "failed to parse request body", 400)
@@ -100,14 +116,13 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 		s.logger.Debug("handling RDS API call", slog.String("action", action))
 	}
 
-	// Check for fault injection
-	faultResult := s.state.Faults().Check(action, "")
-	if faultResult.ShouldFail {
-		s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
-		return
+	// Check for untargeted faults; handlers check targeted ones
+	resourceID := values.Get("DBInstanceIdentifier")
+	if resourceID == "" {
+		resourceID = values.Get("DBClusterIdentifier")
 	}
-	if faultResult.ExtraDelay > 0 {
-		time.Sleep(time.Duration(faultResult.ExtraDelay) * time.Millisecond)
+	if s.injectFaultOn(w, action, "", resourceID) {
+		return
 	}
 
 	// Route to appropriate handler
@@ -120,6 +135,8 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 		s.handleDescribeDBInstances(w, values)
 	case "ListTagsForResource":
 		s.handleListTagsForResource(w, values)
+	case "AddTagsToResource":
+		s.handleAddTagsToResource(w, values)
 	case "CreateDBInstance":
 		s.handleCreateDBInstance(w, values)
 	case "ModifyDBInstance":
@@ -134,6 +151,8 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 		s.handleCreateDBClusterSnapshot(w, values)
 	case "DescribeDBClusterSnapshots":
 		s.handleDescribeDBClusterSnapshots(w, values)
+	case "DeleteDBClusterSnapshot":
+		s.handleDeleteDBClusterSnapshot(w, values)
 	// Cluster Parameter Group actions
 	case "DescribeDBClusterParameterGroups":
 		s.handleDescribeDBClusterParameterGroups(w, values)
@@ -164,6 +183,9 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 	// Cluster deletion (for cleanup)
 	case "DeleteDBCluster":
 		s.handleDeleteDBCluster(w, values)
+	// Snapshot restore (for restore tests)
+	case "RestoreDBClusterFromSnapshot":
+		s.handleRestoreDBClusterFromSnapshot(w, values)
 	// Engine version info
 	case "DescribeDBEngineVersions":
 		s.handleDescribeDBEngineVersions(w, values)
@@ -184,6 +206,19 @@ func (s *Server) handleRDSAction(w http.ResponseWriter, r *http.Request) {
 		s.handleRegisterDBProxyTargets(w, values)
 	case "DeregisterDBProxyTargets":
 		s.handleDeregisterDBProxyTargets(w, values)
+	// Pending maintenance actions
+	case "DescribePendingMaintenanceActions":
+		s.handleDescribePendingMaintenanceActions(w, values)
+	case "ApplyPendingMaintenanceAction":
+		s.handleApplyPendingMaintenanceAction(w, values)
+	case "DescribeGlobalClusters":
+		s.handleDescribeGlobalClusters(w, values)
+	case "DescribeEvents":
+		s.handleDescribeEvents(w, values)
+	case "DescribeDBClusterEndpoints":
+		s.handleDescribeDBClusterEndpoints(w, values)
+	case "ModifyDBClusterEndpoint":
+		s.handleModifyDBClusterEndpoint(w, values)
 	default:
 		s.sendErrorResponse(w, "InvalidAction", fmt.Sprintf("unsupported action: %s", action), 400)
 	}
@@ -202,6 +237,33 @@ func (s *Server) sendErrorResponse(w http.ResponseWriter, code, message string,
 	}
 }
 
+// injectFault applies any fault matching the action and target, sleeping for
+// the injected latency first. It returns true if an error response was sent.
+func (s *Server) injectFault(w http.ResponseWriter, action, target string) bool {
+	return s.injectFaultOn(w, action, target, target)
+}
+
+// injectFaultOn is injectFault for a request on resourceID, which a failure
+// is recorded against in the RDS event stream.
+func (s *Server) injectFaultOn(w http.ResponseWriter, action, target, resourceID string) bool {
+	faultResult := s.state.Faults().Check(action, target)
+	if faultResult.Delay > 0 {
+		time.Sleep(faultResult.Delay)
+	}
+	if !faultResult.ShouldFail {
+		return false
+	}
+	if s.verbose {
+		s.logger.Debug("injecting fault",
+			slog.String("action", action),
+			slog.String("fault_id", faultResult.FaultID),
+			slog.String("code", faultResult.ErrorCode))
+	}
+	s.recordFaultEvent(action, resourceID, faultResult.ErrorCode, faultResult.ErrorMsg)
+	s.sendErrorResponse(w, faultResult.ErrorCode, faultResult.ErrorMsg, 400)
+	return true
+}
+
 // Mock management API handlers
 
 func (s *Server) handleMockState(w http.ResponseWriter, r *http.Request) {
@@ -218,6 +280,7 @@ func (s *Server) handleMockState(w http.ResponseWriter, r *http.Request) {
 		Proxies              []*MockDBProxy             `json:"proxies"`
 		Timing               TimingConfig               `json:"timing"`
 		Faults               []Fault                    `json:"faults"`
+		Alarms               []*MockAlarm               `json:"alarms"`
 	}{
 		Clusters:             s.state.ListClusters(),
 		Instances:            s.state.ListInstances(),
@@ -226,6 +289,7 @@ func (s *Server) handleMockState(w http.ResponseWriter, r *http.Request) {
 		Proxies:              s.state.ListProxies(),
 		Timing:               s.state.GetTiming(),
 		Faults:               s.state.Faults().ListFaults(),
+		Alarms:               s.state.ListAlarms(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -251,11 +315,38 @@ func (s *Server) handleMockTiming(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(s.state.GetTiming())
 
 	case http.MethodPost:
-		var timing TimingConfig
-		if err := json.NewDecoder(r.Body).Decode(&timing); err != nil {
+		// Fields are optional so callers can flip one setting (e.g. fast_mode)
+		// without resending the rest. The PascalCase keys are what the demo UI
+		// sends and are kept for compatibility.
+		var body struct {
+			BaseWaitMs    *int  `json:"base_wait_ms"`
+			RandomRangeMs *int  `json:"random_range_ms"`
+			FastMode      *bool `json:"fast_mode"`
+
+			LegacyBaseWaitMs    *int  `json:"BaseWaitMs"`
+			LegacyRandomRangeMs *int  `json:"RandomRangeMs"`
+			LegacyFastMode      *bool `json:"FastMode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			http.Error(w, "invalid request body", http.StatusBadRequest)
 			return
 		}
+
+		timing := s.state.GetTiming()
+		if v := firstNonNil(body.BaseWaitMs, body.LegacyBaseWaitMs); v != nil {
+			timing.BaseWaitMs = *v
+		}
+		if v := firstNonNil(body.RandomRangeMs, body.LegacyRandomRangeMs); v != nil {
+			timing.RandomRangeMs = *v
+		}
+		if v := firstNonNil(body.FastMode, body.LegacyFastMode); v != nil {
+			timing.FastMode = *v
+		}
+		if timing.BaseWaitMs < 0 || timing.RandomRangeMs < 0 {
+			http.Error(w, "base_wait_ms and random_range_ms must not be negative", http.StatusBadRequest)
+			return
+		}
+
 		s.state.SetTiming(timing)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(timing)
@@ -265,6 +356,16 @@ func (s *Server) handleMockTiming(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// firstNonNil returns the first non-nil pointer, or nil if all are nil.
+func firstNonNil[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
 func (s *Server) handleMockFaults(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -277,6 +378,10 @@ func (s *Server) handleMockFaults(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid request body", http.StatusBadRequest)
 			return
 		}
+		if err := fault.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		id := s.state.Faults().AddFault(fault)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"id": id})
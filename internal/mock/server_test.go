@@ -0,0 +1,266 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockTiming_PostAppliesLive(t *testing.T) {
+	// Start with timing slow enough that no transition completes during the test.
+	state := NewState(TimingConfig{BaseWaitMs: 60000})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/mock/timing", "application/json",
+		bytes.NewBufferString(`{"fast_mode": true, "random_range_ms": 0}`))
+	if err != nil {
+		t.Fatalf("POST /mock/timing failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var got TimingConfig
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !got.FastMode || got.BaseWaitMs != 60000 {
+		t.Errorf("timing = %+v, want fast mode with base wait preserved", got)
+	}
+
+	if err := state.ModifyInstance("demo-single-writer", "db.r6g.xlarge", "", nil); err != nil {
+		t.Fatalf("ModifyInstance failed: %v", err)
+	}
+	if !waitForStatus(state, "demo-single-writer", "modifying", time.Second) {
+		t.Fatal("expected instance to start modifying")
+	}
+	if !waitForStatus(state, "demo-single-writer", "available", time.Second) {
+		t.Fatal("expected near-instant transition back to available in fast mode")
+	}
+}
+
+func TestMockTiming_PostRejectsNegativeValues(t *testing.T) {
+	state := NewState(DefaultTimingConfig())
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/mock/timing", "application/json",
+		bytes.NewBufferString(`{"base_wait_ms": -1}`))
+	if err != nil {
+		t.Fatalf("POST /mock/timing failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+	if state.GetTiming() != DefaultTimingConfig() {
+		t.Errorf("timing changed despite rejected request: %+v", state.GetTiming())
+	}
+}
+
+func TestMockFaults_IntermittentFailures(t *testing.T) {
+	state := NewState(DefaultTimingConfig())
+	state.SeedDemoClusters()
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/mock/faults", "application/json", bytes.NewBufferString(
+		`{"type": "intermittent", "action": "DescribeDBClusters", "probability": 1, "fail_every_n": 3, "delay_ms": 20, "enabled": true}`))
+	if err != nil {
+		t.Fatalf("POST /mock/faults failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var failed []int
+	for i := 1; i <= 6; i++ {
+		start := time.Now()
+		resp, err := http.PostForm(server.URL, url.Values{"Action": {"DescribeDBClusters"}})
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("call %d took %v, want injected latency", i, elapsed)
+		}
+		if resp.StatusCode != http.StatusOK {
+			if !strings.Contains(string(body), "Throttling") {
+				t.Errorf("call %d: expected Throttling error, got %s", i, body)
+			}
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) != 2 || failed[0] != 3 || failed[1] != 6 {
+		t.Errorf("failed calls = %v, want [3 6]", failed)
+	}
+
+	faults := state.Faults().ListFaults()
+	if len(faults) != 1 || faults[0].CallCount != 6 {
+		t.Errorf("faults = %+v, want one fault with 6 calls", faults)
+	}
+}
+
+func TestMockFaults_TargetedFaultCountedOnce(t *testing.T) {
+	state := NewState(DefaultTimingConfig())
+	state.SeedDemoClusters()
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	state.Faults().AddFault(Fault{
+		ID:              "reboot",
+		Type:            FaultTypePartialFail,
+		Action:          "RebootDBInstance",
+		Target:          "demo-single-writer",
+		Probability:     1,
+		FailAfterNCalls: 1,
+		Enabled:         true,
+	})
+
+	reboot := func() int {
+		resp, err := http.PostForm(server.URL, url.Values{
+			"Action":               {"RebootDBInstance"},
+			"DBInstanceIdentifier": {"demo-single-writer"},
+		})
+		if err != nil {
+			t.Fatalf("RebootDBInstance failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := reboot(); status != http.StatusOK {
+		t.Fatalf("first call status = %d, want 200", status)
+	}
+	if status := reboot(); status != http.StatusBadRequest {
+		t.Fatalf("second call status = %d, want 400", status)
+	}
+	if got := state.Faults().ListFaults()[0].CallCount; got != 2 {
+		t.Errorf("call count = %d, want 2", got)
+	}
+}
+
+func TestMockFaults_PostRejectsInvalidFault(t *testing.T) {
+	state := NewState(DefaultTimingConfig())
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+
+	for _, body := range []string{
+		`{"type": "intermittent", "probability": 1}`,
+		`{"type": "intermittent", "failure_rate": 1.5}`,
+		`{"type": "delay", "delay_ms": -10}`,
+		`{"type": "unknown"}`,
+	} {
+		resp, err := http.Post(server.URL+"/mock/faults", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST /mock/faults failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+	if n := len(state.Faults().ListFaults()); n != 0 {
+		t.Errorf("got %d faults, want none", n)
+	}
+}
+
+func TestMockInstanceScript_PlaysOnModify(t *testing.T) {
+	// Unscripted transitions would take a minute, so only the script moves the instance.
+	state := NewState(TimingConfig{BaseWaitMs: 60000, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	server := httptest.NewServer(NewServer(state, slog.New(slog.NewTextHandler(io.Discard, nil)), false))
+	defer server.Close()
+	scriptURL := server.URL + "/mock/instances/demo-multi-reader-1/script"
+
+	resp, err := http.Post(scriptURL, "application/json", bytes.NewBufferString(`{"on_modify": true, "statuses": [
+		{"status": "modifying", "duration_ms": 200},
+		{"status": "storage-optimization", "duration_ms": 200},
+		{"status": "available"}]}`))
+	if err != nil {
+		t.Fatalf("POST script failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); inst.Status != "available" || inst.Scripted() {
+		t.Fatalf("armed script should wait for a modify, instance is %s", inst.Status)
+	}
+
+	if err := state.ModifyInstance("demo-multi-reader-1", "db.r6g.xlarge", "", nil); err != nil {
+		t.Fatalf("ModifyInstance failed: %v", err)
+	}
+	var seen []string
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		inst, _ := state.GetInstance("demo-multi-reader-1")
+		if inst.PendingStatusChange != "" {
+			t.Fatalf("pending status change %q set while the script plays", inst.PendingStatusChange)
+		}
+		if len(seen) == 0 || seen[len(seen)-1] != inst.Status {
+			seen = append(seen, inst.Status)
+			if inst.Status == "storage-optimization" && inst.InstanceType != "db.r6g.xlarge" {
+				t.Errorf("instance type %s after modifying, want db.r6g.xlarge", inst.InstanceType)
+			}
+		}
+		if inst.Status == "available" && !inst.Scripted() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if want := []string{"modifying", "storage-optimization", "available"}; strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Errorf("statuses = %v, want %v", seen, want)
+	}
+
+	for body, want := range map[string]int{
+		`{"statuses": []}`:                        http.StatusBadRequest,
+		`{"statuses": [{"duration_ms": 10}]}`:     http.StatusBadRequest,
+		`{"statuses": [{"status": "rebooting"}]}`: http.StatusOK,
+	} {
+		resp, err := http.Post(scriptURL, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("POST script failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status = %d, want %d", body, resp.StatusCode, want)
+		}
+	}
+	resp, err = http.Post(server.URL+"/mock/instances/missing/script", "application/json",
+		bytes.NewBufferString(`{"statuses": [{"status": "modifying"}]}`))
+	if err != nil {
+		t.Fatalf("POST script failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown instance: status = %d, want 404", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, scriptURL, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE script failed: %v", err)
+	}
+	resp.Body.Close()
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); resp.StatusCode != http.StatusOK || inst.Scripted() {
+		t.Errorf("DELETE: status = %d, scripted = %v; want the script cleared", resp.StatusCode, inst.Scripted())
+	}
+}
@@ -33,7 +33,18 @@ type State struct {
 	snapshots            map[string]*MockSnapshot
 	blueGreenDeployments map[string]*MockBlueGreenDeployment
 	proxies              map[string]*MockDBProxy
-	proxyTargetGroups    map[string]*MockDBProxyTargetGroup // key: proxyName/targetGroupName
+	proxyTargetGroups    map[string]*MockDBProxyTargetGroup        // key: proxyName/targetGroupName
+	pendingMaintenance   map[string][]MockPendingMaintenanceAction // key: cluster or instance ID
+	globalClusters       map[string]*MockGlobalCluster
+	clusterParameters    map[string]map[string]MockParameter // key: cluster parameter group name
+	clusterParamGroups   map[string]string                   // cluster parameter group name -> family
+	instanceParamGroups  map[string]string                   // instance parameter group name -> family
+	resourceTags         map[string]map[string]string        // key: resource ARN
+	alarms               map[string]*MockAlarm               // key: alarm name
+	upgradeTargets       map[string][]MockUpgradeTarget      // key: engine/version
+	events               []MockEvent                         // RDS event stream, oldest first
+	clusterEndpoints     map[string]*MockClusterEndpoint     // key: endpoint identifier
+	scalableTargets      map[string]*MockScalableTarget      // key: cluster ID
 
 	// Timing configuration
 	timing TimingConfig
@@ -47,14 +58,21 @@ type State struct {
 
 // MockCluster represents a simulated RDS cluster.
 type MockCluster struct {
-	ID                        string
-	Engine                    string
-	EngineVersion             string
-	Status                    string   // See rds.ClusterStatus for all possible values
-	Members                   []string // Instance IDs (order matters: first is typically writer)
-	StatusChangedAt           time.Time
-	ParameterGroupName        string // Cluster parameter group name (for PG correlation in mock)
-	LogicalReplicationEnabled bool   // Whether rds.logical_replication is enabled (for Blue-Green prereqs)
+	ID                         string
+	Engine                     string
+	EngineVersion              string
+	Status                     string   // See rds.ClusterStatus for all possible values
+	Members                    []string // Instance IDs in DescribeDBClusters order; the writer is flagged by IsWriter, not position
+	StatusChangedAt            time.Time
+	ParameterGroupName         string // Cluster parameter group name (for PG correlation in mock)
+	LogicalReplicationEnabled  bool   // Whether rds.logical_replication is enabled (for Blue-Green prereqs)
+	IAMDatabaseAuthEnabled     bool   // Whether IAM database authentication is on; members report the same
+	PreferredMaintenanceWindow string // ddd:hh24:mi-ddd:hh24:mi in UTC; empty reports defaultMaintenanceWindow
+
+	// Serverless v2 capacity range in ACUs; zero when the cluster has no
+	// scaling configuration.
+	ServerlessV2MinCapacity float64
+	ServerlessV2MaxCapacity float64
 }
 
 // MockInstance represents a simulated RDS instance.
@@ -74,6 +92,16 @@ type MockInstance struct {
 
 	// PerformanceInsightsEnabled indicates if Performance Insights is enabled on the instance.
 	PerformanceInsightsEnabled bool
+	// PerformanceInsightsRetention is the Performance Insights retention in
+	// days. Zero means the default of 7.
+	PerformanceInsightsRetention int32
+	// MonitoringInterval and MonitoringRoleArn are the Enhanced Monitoring
+	// settings; an interval of zero means it is off.
+	MonitoringInterval int32
+	MonitoringRoleArn  string
+	// PendingMonitoring is a monitoring change applied when the instance
+	// leaves modifying.
+	PendingMonitoring *MonitoringSettings
 
 	// Pending modifications (applied when status becomes available)
 	PendingInstanceType string
@@ -89,6 +117,81 @@ type MockInstance struct {
 	// If set, the instance will transition to this status at PendingStatusChangeAt time.
 	PendingStatusChange   string
 	PendingStatusChangeAt time.Time
+
+	// ArmedStatusScript is a status script that starts on the next modify.
+	// statusScript is the one playing, if any.
+	ArmedStatusScript []StatusScriptEntry
+	statusScript      *statusScript
+
+	// CACertificateIdentifier is the CA certificate the instance serves.
+	// Empty means DefaultCACertificate.
+	CACertificateIdentifier string
+	// PendingCACertificateIdentifier takes effect when the instance next reboots.
+	PendingCACertificateIdentifier string
+
+	// AllocatedStorage is the allocated storage in GiB. Aurora manages its
+	// own storage, so demo instances leave it unset.
+	AllocatedStorage int32
+
+	// ReplicaLagMs is the AuroraReplicaLag CloudWatch reports while the
+	// instance is a reader.
+	ReplicaLagMs float64
+
+	// AvailabilityZone is the AZ the instance runs in.
+	AvailabilityZone string
+
+	// ParameterGroupName is the instance's DB parameter group. Empty means
+	// the default group for aurora-postgresql15.
+	ParameterGroupName string
+
+	// SubnetGroupName and SecurityGroupIDs are the instance's network. An
+	// instance created without them gets DefaultSubnetGroup and
+	// DefaultSecurityGroup, not those of the rest of its cluster.
+	SubnetGroupName  string
+	SecurityGroupIDs []string
+}
+
+// AvailabilityZones are the AZs of the mock region. Demo cluster members are
+// spread across them in member order.
+var AvailabilityZones = []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+
+// MaxClusterInstances is the most instances a mock cluster accepts, matching
+// the Aurora limit.
+const MaxClusterInstances = 15
+
+// DefaultSubnetGroup and DefaultSecurityGroup stand in for the account's
+// default VPC, which demo clusters do not run in.
+const (
+	DefaultSubnetGroup   = "default"
+	DefaultSecurityGroup = "sg-default"
+)
+
+// defaultMaintenanceWindow is the preferred maintenance window reported for
+// clusters that were not given one; RDS assigns every cluster a window.
+const defaultMaintenanceWindow = "sun:05:00-sun:05:30"
+
+// DefaultCACertificate is the CA certificate instances serve until rotated.
+const DefaultCACertificate = "rds-ca-2019"
+
+// validCACertificates are the CA certificate identifiers RDS offers.
+var validCACertificates = map[string]bool{
+	"rds-ca-2019":       true,
+	"rds-ca-rsa2048-g1": true,
+	"rds-ca-rsa4096-g1": true,
+	"rds-ca-ecc384-g1":  true,
+}
+
+// IsValidCACertificate reports whether id names a CA certificate RDS offers.
+func IsValidCACertificate(id string) bool {
+	return validCACertificates[id]
+}
+
+// CACertificate returns the CA certificate the instance serves.
+func (i *MockInstance) CACertificate() string {
+	if i.CACertificateIdentifier == "" {
+		return DefaultCACertificate
+	}
+	return i.CACertificateIdentifier
 }
 
 // MockSnapshot represents a simulated RDS cluster snapshot.
@@ -109,7 +212,7 @@ type MockBlueGreenDeployment struct {
 	SourceClusterARN    string
 	TargetClusterARN    string
 	TargetEngineVersion string
-	Status              string // PROVISIONING, AVAILABLE, SWITCHOVER_IN_PROGRESS, SWITCHOVER_COMPLETED, DELETING
+	Status              string // PROVISIONING, PROVISIONING_FAILED, AVAILABLE, SWITCHOVER_IN_PROGRESS, SWITCHOVER_COMPLETED, DELETING
 	StatusDetails       string
 	Tasks               []MockBlueGreenTask
 	SwitchoverDetails   []MockBlueGreenSwitchoverDetail
@@ -138,6 +241,8 @@ type MockDBProxy struct {
 	EngineFamily string // POSTGRESQL, MYSQL
 	Endpoint     string
 	VpcID        string
+
+	StatusChangedAt time.Time
 }
 
 // MockDBProxyTargetGroup represents a target group for an RDS Proxy.
@@ -202,6 +307,16 @@ func NewState(timing TimingConfig) *State {
 		blueGreenDeployments: make(map[string]*MockBlueGreenDeployment),
 		proxies:              make(map[string]*MockDBProxy),
 		proxyTargetGroups:    make(map[string]*MockDBProxyTargetGroup),
+		pendingMaintenance:   make(map[string][]MockPendingMaintenanceAction),
+		globalClusters:       make(map[string]*MockGlobalCluster),
+		clusterParameters:    make(map[string]map[string]MockParameter),
+		clusterParamGroups:   make(map[string]string),
+		instanceParamGroups:  make(map[string]string),
+		resourceTags:         make(map[string]map[string]string),
+		alarms:               make(map[string]*MockAlarm),
+		upgradeTargets:       make(map[string][]MockUpgradeTarget),
+		clusterEndpoints:     make(map[string]*MockClusterEndpoint),
+		scalableTargets:      make(map[string]*MockScalableTarget),
 		timing:               timing,
 		faults:               NewFaultInjector(),
 		stopCh:               make(chan struct{}),
@@ -315,6 +430,10 @@ func (s *State) seedDemoClustersLocked() {
 		StatusChangedAt:            now,
 		CreatedAt:                  now.Add(-48 * time.Hour),
 	}
+	s.resourceTags["arn:aws:rds:us-east-1:123456789012:db:demo-multi-writer"] = map[string]string{
+		"Environment": "demo",
+		"Team":        "platform",
+	}
 	s.instances["demo-multi-reader-1"] = &MockInstance{
 		ID:                         "demo-multi-reader-1",
 		ClusterID:                  "demo-multi",
@@ -412,6 +531,14 @@ func (s *State) seedDemoClustersLocked() {
 		CreatedAt:                  now.Add(-30 * time.Minute),
 	}
 
+	// The autoscaled readers come from a read replica scaling policy
+	s.scalableTargets["demo-autoscaled"] = &MockScalableTarget{
+		ClusterID:   "demo-autoscaled",
+		MinCapacity: 1,
+		MaxCapacity: 4,
+		Policies:    []string{"demo-autoscaled-cpu-target"},
+	}
+
 	// Demo 4: Cluster ready for engine upgrade (no proxy)
 	s.clusters["demo-upgrade"] = &MockCluster{
 		ID:                        "demo-upgrade",
@@ -452,6 +579,15 @@ func (s *State) seedDemoClustersLocked() {
 		CreatedAt:                  now.Add(-96 * time.Hour),
 	}
 
+	// A reporting endpoint pinned to the reader, for custom endpoint repointing
+	s.clusterEndpoints["demo-upgrade-analytics"] = &MockClusterEndpoint{
+		ID:            "demo-upgrade-analytics",
+		ClusterID:     "demo-upgrade",
+		Type:          "READER",
+		Status:        "available",
+		StaticMembers: []string{"demo-upgrade-reader-1"},
+	}
+
 	// Demo 5: Cluster with RDS Proxy (1 writer + 2 readers) - for testing proxy retargeting
 	s.clusters["demo-proxy-cluster"] = &MockCluster{
 		ID:                        "demo-proxy-cluster",
@@ -462,6 +598,7 @@ func (s *State) seedDemoClustersLocked() {
 		StatusChangedAt:           now,
 		ParameterGroupName:        "demo-proxy-cluster-pg",
 		LogicalReplicationEnabled: true, // Enabled for Blue-Green deployments
+		IAMDatabaseAuthEnabled:    true, // Proxy clients sign in with IAM
 	}
 	s.instances["demo-proxy-cluster-writer"] = &MockInstance{
 		ID:                         "demo-proxy-cluster-writer",
@@ -506,6 +643,157 @@ func (s *State) seedDemoClustersLocked() {
 		CreatedAt:                  now.Add(-120 * time.Hour),
 	}
 
+	// Demo 6: Writer listed last and sized differently from its readers, so
+	// anything that takes the first member as the writer picks the wrong one
+	s.clusters["demo-writer-last"] = &MockCluster{
+		ID:                        "demo-writer-last",
+		Engine:                    "aurora-postgresql",
+		EngineVersion:             "15.4",
+		Status:                    "available",
+		Members:                   []string{"demo-writer-last-reader-1", "demo-writer-last-reader-2", "demo-writer-last-writer"},
+		StatusChangedAt:           now,
+		ParameterGroupName:        "demo-writer-last-pg",
+		LogicalReplicationEnabled: true,
+	}
+	for i, id := range []string{"demo-writer-last-reader-1", "demo-writer-last-reader-2"} {
+		s.instances[id] = &MockInstance{
+			ID:                         id,
+			ClusterID:                  "demo-writer-last",
+			InstanceType:               "db.r6g.large",
+			Status:                     "available",
+			IsWriter:                   false,
+			IsAutoScaled:               false,
+			StorageType:                "aurora",
+			ARN:                        "arn:aws:rds:us-east-1:123456789012:db:" + id,
+			PromotionTier:              int32(2 + i),
+			PerformanceInsightsEnabled: true,
+			StatusChangedAt:            now,
+			CreatedAt:                  now.Add(-72 * time.Hour),
+		}
+	}
+	s.instances["demo-writer-last-writer"] = &MockInstance{
+		ID:                         "demo-writer-last-writer",
+		ClusterID:                  "demo-writer-last",
+		InstanceType:               "db.r6g.xlarge",
+		Status:                     "available",
+		IsWriter:                   true,
+		IsAutoScaled:               false,
+		StorageType:                "aurora",
+		ARN:                        "arn:aws:rds:us-east-1:123456789012:db:demo-writer-last-writer",
+		PromotionTier:              1,
+		PerformanceInsightsEnabled: true,
+		StatusChangedAt:            now,
+		CreatedAt:                  now.Add(-72 * time.Hour),
+	}
+
+	// Demo 7: Aurora Serverless v2 cluster (serverless writer, 0.5-8 ACUs)
+	s.clusters["demo-serverless"] = &MockCluster{
+		ID:                        "demo-serverless",
+		Engine:                    "aurora-postgresql",
+		EngineVersion:             "15.4",
+		Status:                    "available",
+		Members:                   []string{"demo-serverless-writer"},
+		StatusChangedAt:           now,
+		ParameterGroupName:        "demo-serverless-pg",
+		LogicalReplicationEnabled: true,
+		ServerlessV2MinCapacity:   0.5,
+		ServerlessV2MaxCapacity:   8,
+	}
+	s.instances["demo-serverless-writer"] = &MockInstance{
+		ID:              "demo-serverless-writer",
+		ClusterID:       "demo-serverless",
+		InstanceType:    "db.serverless",
+		Status:          "available",
+		IsWriter:        true,
+		StorageType:     "aurora",
+		ARN:             "arn:aws:rds:us-east-1:123456789012:db:demo-serverless-writer",
+		PromotionTier:   1,
+		StatusChangedAt: now,
+		CreatedAt:       now.Add(-24 * time.Hour),
+	}
+
+	// Demo 8: Aurora MySQL 5.7 cluster with custom parameter groups, for
+	// major upgrades to 8.0. tx_isolation was renamed in MySQL 8.0, so it
+	// cannot be carried over.
+	s.clusters["demo-mysql57"] = &MockCluster{
+		ID:                 "demo-mysql57",
+		Engine:             "aurora-mysql",
+		EngineVersion:      "5.7.mysql_aurora.2.11.2",
+		Status:             "available",
+		Members:            []string{"demo-mysql57-writer", "demo-mysql57-reader-1"},
+		StatusChangedAt:    now,
+		ParameterGroupName: "demo-mysql57-pg",
+	}
+	s.clusterParamGroups["demo-mysql57-pg"] = "aurora-mysql5.7"
+	s.clusterParameters["demo-mysql57-pg"] = map[string]MockParameter{
+		"binlog_format":              {Value: "ROW", ApplyType: "static"},
+		"innodb_print_all_deadlocks": {Value: "1", ApplyType: "dynamic"},
+		"tx_isolation":               {Value: "READ-COMMITTED", ApplyType: "dynamic"},
+	}
+	s.instanceParamGroups["demo-mysql57-instance-pg"] = "aurora-mysql5.7"
+	for i, id := range []string{"demo-mysql57-writer", "demo-mysql57-reader-1"} {
+		s.instances[id] = &MockInstance{
+			ID:                 id,
+			ClusterID:          "demo-mysql57",
+			InstanceType:       "db.r5.large",
+			Status:             "available",
+			IsWriter:           i == 0,
+			StorageType:        "aurora",
+			ARN:                "arn:aws:rds:us-east-1:123456789012:db:" + id,
+			PromotionTier:      int32(i + 1),
+			ParameterGroupName: "demo-mysql57-instance-pg",
+			StatusChangedAt:    now,
+			CreatedAt:          now.Add(-240 * time.Hour),
+		}
+	}
+
+	// Demo 9: Aurora MySQL 8.0 cluster on the default parameter groups
+	s.clusters["demo-mysql80"] = &MockCluster{
+		ID:                 "demo-mysql80",
+		Engine:             "aurora-mysql",
+		EngineVersion:      "8.0.mysql_aurora.3.04.0",
+		Status:             "available",
+		Members:            []string{"demo-mysql80-writer"},
+		StatusChangedAt:    now,
+		ParameterGroupName: "default.aurora-mysql8.0",
+	}
+	s.instances["demo-mysql80-writer"] = &MockInstance{
+		ID:                 "demo-mysql80-writer",
+		ClusterID:          "demo-mysql80",
+		InstanceType:       "db.r6g.large",
+		Status:             "available",
+		IsWriter:           true,
+		StorageType:        "aurora",
+		ARN:                "arn:aws:rds:us-east-1:123456789012:db:demo-mysql80-writer",
+		PromotionTier:      1,
+		ParameterGroupName: "default.aurora-mysql8.0",
+		StatusChangedAt:    now,
+		CreatedAt:          now.Add(-24 * time.Hour),
+	}
+
+	// demo-multi readers have an OS update waiting for the maintenance window
+	for _, id := range []string{"demo-multi-reader-1", "demo-multi-reader-2"} {
+		s.pendingMaintenance[id] = []MockPendingMaintenanceAction{{
+			Action:               "system-update",
+			Description:          "New Operating System update is available",
+			AutoAppliedAfterDate: now.Add(14 * 24 * time.Hour),
+		}}
+	}
+
+	for _, cluster := range s.clusters {
+		for i, id := range cluster.Members {
+			s.instances[id].AvailabilityZone = AvailabilityZones[i%len(AvailabilityZones)]
+			s.instances[id].SubnetGroupName = cluster.ID + "-subnets"
+			s.instances[id].SecurityGroupIDs = []string{"sg-" + cluster.ID}
+		}
+	}
+
+	// Custom instance parameter groups operators can switch instances to
+	s.instanceParamGroups["demo-instance-pg"] = "aurora-postgresql15"
+	s.instanceParamGroups["demo-instance-pg16"] = "aurora-postgresql16"
+
+	s.seedDemoAlarmsLocked(now)
+
 	// Seed demo proxies
 	s.seedDemoProxiesLocked()
 }
@@ -525,6 +813,16 @@ func (s *State) Reset() {
 	s.blueGreenDeployments = make(map[string]*MockBlueGreenDeployment)
 	s.proxies = make(map[string]*MockDBProxy)
 	s.proxyTargetGroups = make(map[string]*MockDBProxyTargetGroup)
+	s.pendingMaintenance = make(map[string][]MockPendingMaintenanceAction)
+	s.globalClusters = make(map[string]*MockGlobalCluster)
+	s.clusterParameters = make(map[string]map[string]MockParameter)
+	s.clusterParamGroups = make(map[string]string)
+	s.instanceParamGroups = make(map[string]string)
+	s.resourceTags = make(map[string]map[string]string)
+	s.alarms = make(map[string]*MockAlarm)
+	s.events = nil
+	s.clusterEndpoints = make(map[string]*MockClusterEndpoint)
+	s.scalableTargets = make(map[string]*MockScalableTarget)
 
 	// Seed demo clusters while still holding the lock
 	s.seedDemoClustersLocked()
@@ -547,6 +845,15 @@ func (s *State) GetCluster(id string) (*MockCluster, bool) {
 	return &clusterCopy, true
 }
 
+// iamDatabaseAuthEnabled reports whether a cluster has IAM database
+// authentication on.
+func (s *State) iamDatabaseAuthEnabled(clusterID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.clusters[clusterID]
+	return ok && c.IAMDatabaseAuthEnabled
+}
+
 // GetInstance returns an instance by ID.
 func (s *State) GetInstance(id string) (*MockInstance, bool) {
 	s.mu.RLock()
@@ -651,7 +958,7 @@ func (s *State) clusterHasPerformanceInsightsLocked(clusterID string) bool {
 // MUST be called with s.mu held.
 func (s *State) isDemoClusterLocked(clusterID string) bool {
 	switch clusterID {
-	case "demo-single", "demo-multi", "demo-autoscaled", "demo-upgrade":
+	case "demo-single", "demo-multi", "demo-autoscaled", "demo-upgrade", "demo-writer-last":
 		return true
 	default:
 		return false
@@ -689,6 +996,20 @@ func (s *State) CreateInstance(inst *MockInstance) error {
 	// in DescribeDBInstances immediately, but that's harder to simulate
 	inst.Status = "creating"
 
+	// The first instance of a cluster (e.g. one just restored) is its writer.
+	if len(cluster.Members) == 0 {
+		inst.IsWriter = true
+	}
+	if inst.AvailabilityZone == "" {
+		inst.AvailabilityZone = AvailabilityZones[len(cluster.Members)%len(AvailabilityZones)]
+	}
+	if inst.SubnetGroupName == "" {
+		inst.SubnetGroupName = DefaultSubnetGroup
+	}
+	if len(inst.SecurityGroupIDs) == 0 {
+		inst.SecurityGroupIDs = []string{DefaultSecurityGroup}
+	}
+
 	s.instances[inst.ID] = inst
 	cluster.Members = append(cluster.Members, inst.ID)
 
@@ -716,6 +1037,24 @@ func (s *State) ModifyInstance(id string, instanceType, storageType string, iops
 		inst.PendingIOPS = iops
 	}
 
+	s.beginModifyingLocked(inst)
+	return nil
+}
+
+// beginModifyingLocked moves an instance toward modifying after a modify
+// call. An armed status script starts in its place, and behind a playing
+// one the instance goes to modifying once the script ends. Callers hold s.mu.
+func (s *State) beginModifyingLocked(inst *MockInstance) {
+	if len(inst.ArmedStatusScript) > 0 {
+		startStatusScript(inst, inst.ArmedStatusScript, time.Now())
+		return
+	}
+	if inst.Scripted() {
+		inst.PendingStatusChange = "modifying"
+		inst.PendingStatusChangeAt = time.Now()
+		return
+	}
+
 	// Simulate AWS async behavior: status change is delayed
 	// The instance remains "available" briefly before transitioning to "modifying"
 	// This mimics the race condition where modify API returns before status updates
@@ -737,10 +1076,84 @@ func (s *State) ModifyInstance(id string, instanceType, storageType string, iops
 		inst.PendingStatusChange = "modifying"
 		inst.PendingStatusChangeAt = time.Now().Add(delay)
 	}
+}
+
+// QueueInstanceModification records instance changes without starting them,
+// as a modify without ApplyImmediately or scheduled maintenance does. They
+// are applied along with the instance's next modification.
+func (s *State) QueueInstanceModification(id string, instanceType, storageType string, iops *int32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+	if instanceType != "" {
+		inst.PendingInstanceType = instanceType
+	}
+	if storageType != "" {
+		inst.PendingStorageType = storageType
+	}
+	if iops != nil {
+		inst.PendingIOPS = iops
+	}
 	return nil
 }
 
+// ModifyInstanceCACert records a CA certificate change that applies on the
+// instance's next reboot.
+func (s *State) ModifyInstanceCACert(id, caIdentifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+
+	if inst.CACertificate() == caIdentifier {
+		inst.PendingCACertificateIdentifier = ""
+		return nil
+	}
+	inst.PendingCACertificateIdentifier = caIdentifier
+	return nil
+}
+
+// SetInstanceParameterGroup associates an instance with a DB parameter
+// group, as ModifyDBInstance does. Like RDS, it takes effect on the next
+// reboot, which the mock does not track.
+func (s *State) SetInstanceParameterGroup(id, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, ok := s.instances[id]
+	if !ok {
+		return fmt.Errorf("instance not found: %s", id)
+	}
+	inst.ParameterGroupName = name
+	return nil
+}
+
+// AddInstanceParameterGroup records a custom DB parameter group.
+func (s *State) AddInstanceParameterGroup(name, family string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instanceParamGroups[name] = family
+}
+
+// InstanceParameterGroupFamily returns the family of a DB parameter group.
+// Default groups (default.<family>) always exist.
+func (s *State) InstanceParameterGroupFamily(name string) (string, bool) {
+	if family, ok := strings.CutPrefix(name, "default."); ok {
+		return family, true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	family, ok := s.instanceParamGroups[name]
+	return family, ok
+}
+
 // DeleteInstance marks an instance for deletion.
 func (s *State) DeleteInstance(id string) error {
 	s.mu.Lock()
@@ -780,6 +1193,28 @@ func (s *State) DeleteCluster(id string) error {
 	return nil
 }
 
+// ModifyServerlessScaling sets a cluster's Serverless v2 capacity range. The
+// cluster is modifying until the change is applied.
+func (s *State) ModifyServerlessScaling(clusterID string, minACU, maxACU float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.clusters[clusterID]
+	if !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+	if minACU > maxACU {
+		return fmt.Errorf("MinCapacity %g is greater than MaxCapacity %g", minACU, maxACU)
+	}
+
+	cluster.ServerlessV2MinCapacity = minACU
+	cluster.ServerlessV2MaxCapacity = maxACU
+	cluster.Status = "modifying"
+	cluster.StatusChangedAt = time.Now()
+
+	return nil
+}
+
 // FailoverCluster performs a failover to the target instance.
 func (s *State) FailoverCluster(clusterID, targetInstanceID string) error {
 	s.mu.Lock()
@@ -812,6 +1247,7 @@ func (s *State) FailoverCluster(clusterID, targetInstanceID string) error {
 
 	// Promote target
 	targetInst.IsWriter = true
+	targetInst.ReplicaLagMs = 0
 	targetInst.Status = "modifying"
 	targetInst.StatusChangedAt = time.Now()
 
@@ -877,6 +1313,25 @@ func (s *State) CreateSnapshot(clusterID, snapshotID string) error {
 	return nil
 }
 
+// DeleteSnapshot removes a snapshot and its tags. Unlike clusters and
+// instances it disappears at once, which is close enough to RDS for callers
+// that do not wait on the deletion.
+func (s *State) DeleteSnapshot(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, ok := s.snapshots[id]
+	if !ok {
+		return fmt.Errorf("snapshot not found: %s", id)
+	}
+	if snap.Status != "available" {
+		return fmt.Errorf("snapshot %s is %s, not available", id, snap.Status)
+	}
+	delete(s.snapshots, id)
+	delete(s.resourceTags, mockARN("cluster-snapshot", id))
+	return nil
+}
+
 // SetInstanceTransitionalStatus sets a transitional status that the instance
 // will transition through before becoming available. This is useful for simulating
 // scenarios like "configuring-enhanced-monitoring" or "configuring-iam-database-auth".
@@ -913,6 +1368,35 @@ func (s *State) SetInstanceStatus(instanceID, status string) error {
 	return nil
 }
 
+// SetClusterStatus directly sets a cluster's status for testing purposes.
+func (s *State) SetClusterStatus(clusterID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.clusters[clusterID]
+	if !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+
+	cluster.Status = status
+	cluster.StatusChangedAt = time.Now()
+	return nil
+}
+
+// SetClusterMaintenanceWindow sets a cluster's preferred maintenance window.
+func (s *State) SetClusterMaintenanceWindow(clusterID, window string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.clusters[clusterID]
+	if !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+
+	cluster.PreferredMaintenanceWindow = window
+	return nil
+}
+
 // RebootInstance initiates a reboot of an instance.
 func (s *State) RebootInstance(instanceID string) error {
 	s.mu.Lock()
@@ -1017,7 +1501,7 @@ func (s *State) CreateBlueGreenDeployment(name, sourceClusterARN, targetEngineVe
 		Status:              "PROVISIONING",
 		StatusDetails:       "Creating green environment",
 		Tasks: []MockBlueGreenTask{
-			{Name: "CREATING_READ_REPLICA_OF_SOURCE", Status: "IN_PROGRESS"},
+			{Name: "CREATING_READ_REPLICA_OF_SOURCE", Status: "PENDING"},
 			{Name: "DB_ENGINE_VERSION_UPGRADE", Status: "PENDING"},
 			{Name: "CREATE_DB_INSTANCES_FOR_CLUSTER", Status: "PENDING"},
 		},
@@ -1044,7 +1528,7 @@ func (s *State) CreateBlueGreenDeployment(name, sourceClusterARN, targetEngineVe
 	}
 
 	s.blueGreenDeployments[identifier] = bg
-	return bg, nil
+	return copyBlueGreenDeployment(bg), nil
 }
 
 // GetBlueGreenDeployment returns a Blue-Green deployment by identifier.
@@ -1055,17 +1539,18 @@ func (s *State) GetBlueGreenDeployment(identifier string) (*MockBlueGreenDeploym
 	if !ok {
 		return nil, false
 	}
-	// Return a copy
+	return copyBlueGreenDeployment(bg), true
+}
+
+// copyBlueGreenDeployment returns a copy of bg that the waiters will not
+// modify once the lock is released.
+func copyBlueGreenDeployment(bg *MockBlueGreenDeployment) *MockBlueGreenDeployment {
 	bgCopy := *bg
 	bgCopy.Tasks = make([]MockBlueGreenTask, len(bg.Tasks))
-	for i, t := range bg.Tasks {
-		bgCopy.Tasks[i] = t
-	}
+	copy(bgCopy.Tasks, bg.Tasks)
 	bgCopy.SwitchoverDetails = make([]MockBlueGreenSwitchoverDetail, len(bg.SwitchoverDetails))
-	for i, d := range bg.SwitchoverDetails {
-		bgCopy.SwitchoverDetails[i] = d
-	}
-	return &bgCopy, true
+	copy(bgCopy.SwitchoverDetails, bg.SwitchoverDetails)
+	return &bgCopy
 }
 
 // SwitchoverBlueGreenDeployment initiates a switchover.
@@ -1121,6 +1606,12 @@ func extractClusterIDFromARN(arn string) string {
 	return arn
 }
 
+// switchoverMemberID returns the cluster or instance ID at the end of a
+// switchover member's ARN.
+func switchoverMemberID(arn string) string {
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
+
 // ListBlueGreenDeployments returns all Blue-Green deployments.
 func (s *State) ListBlueGreenDeployments() []*MockBlueGreenDeployment {
 	s.mu.RLock()
@@ -1155,10 +1646,12 @@ func (s *State) performSwitchoverLocked(sourceClusterID, targetEngineVersion str
 
 	// Step 1: Rename source instances to -old1 suffix
 	var oldMemberIDs []string
+	renamed := make(map[string]string)
 	for _, memberID := range sourceCluster.Members {
 		if inst, ok := s.instances[memberID]; ok {
 			oldInstID := memberID + "-old1"
 			oldMemberIDs = append(oldMemberIDs, oldInstID)
+			renamed[memberID] = oldInstID
 
 			// Create the "old" instance (renamed source)
 			oldInst := &MockInstance{
@@ -1193,6 +1686,7 @@ func (s *State) performSwitchoverLocked(sourceClusterID, targetEngineVersion str
 		StatusChangedAt: now,
 	}
 	s.clusters[oldClusterID] = oldCluster
+	s.retireEndpointMembersLocked(sourceClusterID, renamed)
 
 	// Step 3: Update the source cluster to the new engine version
 	// The source cluster keeps its original name but now has the upgraded engine
@@ -1226,6 +1720,52 @@ func (s *State) GetProxy(name string) (*MockDBProxy, bool) {
 	return &proxyCopy, true
 }
 
+// SetProxyStatus sets a proxy's status (e.g., to simulate a degraded proxy).
+func (s *State) SetProxyStatus(name, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.proxies[name]
+	if !ok {
+		return fmt.Errorf("proxy not found: %s", name)
+	}
+	p.Status = status
+	p.StatusChangedAt = time.Now()
+	return nil
+}
+
+// SeedProxy adds a proxy with a default target group pointing at clusterID.
+// A transitional status such as "creating" moves to "available" after the
+// configured wait, like other mock resources.
+func (s *State) SeedProxy(name, clusterID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clusters[clusterID]; !ok {
+		return fmt.Errorf("cluster not found: %s", clusterID)
+	}
+	if _, exists := s.proxies[name]; exists {
+		return fmt.Errorf("proxy already exists: %s", name)
+	}
+
+	s.proxies[name] = &MockDBProxy{
+		ProxyName:       name,
+		ProxyARN:        fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:db-proxy:prx-%s", name),
+		Status:          status,
+		EngineFamily:    "POSTGRESQL",
+		Endpoint:        fmt.Sprintf("%s.proxy-123456789012.us-east-1.rds.amazonaws.com", name),
+		VpcID:           "vpc-12345678",
+		StatusChangedAt: time.Now(),
+	}
+	s.proxyTargetGroups[name+"/default"] = &MockDBProxyTargetGroup{
+		TargetGroupName: "default",
+		DBProxyName:     name,
+		DBClusterID:     clusterID,
+		Status:          "available",
+		IsDefault:       true,
+	}
+	return nil
+}
+
 // GetProxyTargetGroups returns target groups for a proxy.
 func (s *State) GetProxyTargetGroups(proxyName string) []*MockDBProxyTargetGroup {
 	s.mu.RLock()
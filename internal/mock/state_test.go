@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -278,3 +279,321 @@ func TestState_StartStopInstance(t *testing.T) {
 		t.Errorf("Expected 'available', got %q", inst.Status)
 	}
 }
+
+func TestSeedProxy_TransitionsFromCreating(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	if err := state.SeedProxy("test-proxy", "demo-multi", "creating"); err != nil {
+		t.Fatalf("SeedProxy failed: %v", err)
+	}
+	if proxy, _ := state.GetProxy("test-proxy"); proxy.Status != "creating" {
+		t.Fatalf("expected seeded proxy to start creating, got %s", proxy.Status)
+	}
+	if groups := state.GetProxyTargetGroups("test-proxy"); len(groups) != 1 || groups[0].DBClusterID != "demo-multi" {
+		t.Fatalf("expected a default target group for demo-multi, got %+v", groups)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		proxy, _ := state.GetProxy("test-proxy")
+		if proxy.Status == "available" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("proxy did not become available, status = %s", proxy.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := state.SeedProxy("test-proxy", "demo-multi", "available"); err == nil {
+		t.Error("expected duplicate proxy name to be rejected")
+	}
+}
+
+// waitForBlueGreenStatus polls until the deployment reaches the expected status
+// or times out, returning the last observed deployment.
+func waitForBlueGreenStatus(t *testing.T, state *State, identifier, expectedStatus string) *MockBlueGreenDeployment {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		bg, ok := state.GetBlueGreenDeployment(identifier)
+		if !ok {
+			t.Fatalf("deployment %s not found", identifier)
+		}
+		if bg.Status == expectedStatus {
+			return bg
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deployment status = %s, want %s (tasks %+v)", bg.Status, expectedStatus, bg.Tasks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBlueGreenDeployment_TasksProgressToSwitchover(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	bg, err := state.CreateBlueGreenDeployment("upgrade", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	for _, task := range bg.Tasks {
+		if task.Status != "PENDING" {
+			t.Errorf("task %s starts %s, want PENDING", task.Name, task.Status)
+		}
+	}
+
+	// Tasks run one at a time, so the deployment is never available while
+	// any task is unfinished.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, _ := state.GetBlueGreenDeployment(bg.Identifier)
+		inProgress := 0
+		for _, task := range current.Tasks {
+			if task.Status == "IN_PROGRESS" {
+				inProgress++
+			}
+			if current.Status == "AVAILABLE" && task.Status != "COMPLETED" {
+				t.Fatalf("deployment available with task %s %s", task.Name, task.Status)
+			}
+		}
+		if inProgress > 1 {
+			t.Fatalf("%d tasks in progress at once", inProgress)
+		}
+		if current.Status == "AVAILABLE" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deployment did not become available, status = %s", current.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := state.SwitchoverBlueGreenDeployment(bg.Identifier); err != nil {
+		t.Fatalf("SwitchoverBlueGreenDeployment failed: %v", err)
+	}
+	waitForBlueGreenStatus(t, state, bg.Identifier, "SWITCHOVER_COMPLETED")
+
+	if cluster, _ := state.GetCluster("demo-upgrade"); cluster.EngineVersion != "16.4" {
+		t.Errorf("switched-over cluster runs %s, want 16.4", cluster.EngineVersion)
+	}
+	if old, ok := state.GetCluster("demo-upgrade-old1"); !ok || old.EngineVersion != "15.4" {
+		t.Errorf("expected old cluster on 15.4, got %+v", old)
+	}
+}
+
+// TestBlueGreenDeployment_MembersSwitchOverInTurn verifies that members
+// finish switching over one after another, and that a stuck member holds
+// the switchover back.
+func TestBlueGreenDeployment_MembersSwitchOverInTurn(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	bg, err := state.CreateBlueGreenDeployment("upgrade", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	waitForBlueGreenStatus(t, state, bg.Identifier, "AVAILABLE")
+
+	// Slow the switchover down enough for the 100ms waiter tick to see
+	// each of the three members finish on its own.
+	state.SetTiming(TimingConfig{BaseWaitMs: 1200})
+	state.Faults().AddFault(Fault{
+		Type:        FaultTypeStuck,
+		Target:      "demo-upgrade-reader-1",
+		Probability: 1,
+		Enabled:     true,
+	})
+	if err := state.SwitchoverBlueGreenDeployment(bg.Identifier); err != nil {
+		t.Fatalf("SwitchoverBlueGreenDeployment failed: %v", err)
+	}
+
+	// Record each distinct number of switched members seen along the way.
+	seen := map[int]bool{}
+	deadline := time.Now().Add(1500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		current, _ := state.GetBlueGreenDeployment(bg.Identifier)
+		switched := 0
+		for _, detail := range current.SwitchoverDetails {
+			if detail.Status == "SWITCHOVER_COMPLETED" {
+				switched++
+			}
+		}
+		seen[switched] = true
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !seen[0] || !seen[1] || !seen[2] {
+		t.Errorf("switched member counts seen = %v, want 0, 1 and 2 along the way", seen)
+	}
+	if seen[3] {
+		t.Error("the stuck member should not have switched over")
+	}
+	if current, _ := state.GetBlueGreenDeployment(bg.Identifier); current.Status != "SWITCHOVER_IN_PROGRESS" {
+		t.Errorf("deployment status = %s, want SWITCHOVER_IN_PROGRESS while a member is stuck", current.Status)
+	}
+
+	state.Faults().ClearAll()
+	waitForBlueGreenStatus(t, state, bg.Identifier, "SWITCHOVER_COMPLETED")
+}
+
+func TestBlueGreenDeployment_TaskFailFault(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Faults().AddFault(Fault{
+		Type:        FaultTypeTaskFail,
+		Target:      "upgrade",
+		Task:        "DB_ENGINE_VERSION_UPGRADE",
+		Probability: 1,
+		ErrorMsg:    "Engine upgrade failed: incompatible extension",
+		Enabled:     true,
+	})
+	state.Start()
+	defer state.Stop()
+
+	bg, err := state.CreateBlueGreenDeployment("upgrade", "demo-upgrade", "16.4")
+	if err != nil {
+		t.Fatalf("CreateBlueGreenDeployment failed: %v", err)
+	}
+	failed := waitForBlueGreenStatus(t, state, bg.Identifier, "PROVISIONING_FAILED")
+
+	if failed.StatusDetails != "Engine upgrade failed: incompatible extension" {
+		t.Errorf("status details = %q", failed.StatusDetails)
+	}
+	want := map[string]string{
+		"CREATING_READ_REPLICA_OF_SOURCE": "COMPLETED",
+		"DB_ENGINE_VERSION_UPGRADE":       "FAILED",
+		"CREATE_DB_INSTANCES_FOR_CLUSTER": "PENDING",
+	}
+	for _, task := range failed.Tasks {
+		if task.Status != want[task.Name] {
+			t.Errorf("task %s = %s, want %s", task.Name, task.Status, want[task.Name])
+		}
+	}
+}
+
+func TestState_UpgradeTargets(t *testing.T) {
+	state := NewState(TimingConfig{FastMode: true})
+
+	var versions []string
+	for _, target := range state.UpgradeTargets("aurora-postgresql", "15.4") {
+		versions = append(versions, target.Version)
+	}
+	if got, want := strings.Join(versions, ","), "15.5,15.6,15.7,16.1,16.2"; got != want {
+		t.Errorf("generated targets = %s, want %s", got, want)
+	}
+
+	state.SetUpgradeTargets("aurora-postgresql", "15.4", []MockUpgradeTarget{{Version: "16.4", IsMajor: true}})
+	if targets := state.UpgradeTargets("aurora-postgresql", "15.4"); len(targets) != 1 || targets[0].Version != "16.4" {
+		t.Errorf("targets = %+v, want the configured 16.4", targets)
+	}
+	if targets := state.UpgradeTargets("aurora-postgresql", "16.1"); len(targets) != 5 {
+		t.Errorf("other versions should keep generated targets, got %+v", targets)
+	}
+
+	versions = nil
+	for _, target := range state.UpgradeTargets("aurora-mysql", "5.7.mysql_aurora.2.11.2") {
+		if target.IsMajor != strings.HasPrefix(target.Version, "8.0.") {
+			t.Errorf("target %s has IsMajor %v", target.Version, target.IsMajor)
+		}
+		versions = append(versions, target.Version)
+	}
+	want := "5.7.mysql_aurora.2.12.0,5.7.mysql_aurora.2.13.0,5.7.mysql_aurora.2.14.0,8.0.mysql_aurora.3.04.0,8.0.mysql_aurora.3.05.2"
+	if got := strings.Join(versions, ","); got != want {
+		t.Errorf("generated Aurora MySQL targets = %s, want %s", got, want)
+	}
+	if targets := state.UpgradeTargets("aurora-mysql", "8.0.mysql_aurora.3.04.0"); len(targets) != 3 || targets[0].Version != "8.0.mysql_aurora.3.05.0" {
+		t.Errorf("8.0 targets = %+v, want three minor releases", targets)
+	}
+}
+
+func TestState_ModifyInstanceMonitoring(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	const pollTimeout = 2 * time.Second
+	off, on := false, true
+
+	if err := state.ModifyInstanceMonitoring("demo-multi-reader-1", MonitoringSettings{EnablePerformanceInsights: &off}); err != nil {
+		t.Fatalf("ModifyInstanceMonitoring failed: %v", err)
+	}
+	if !waitForInstanceStatus(state, "demo-multi-reader-1", "modifying", pollTimeout) ||
+		!waitForInstanceStatus(state, "demo-multi-reader-1", "available", pollTimeout) {
+		t.Fatal("instance did not go through modifying to available")
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); inst.PerformanceInsightsEnabled {
+		t.Fatal("Performance Insights still enabled after turning it off")
+	}
+
+	retention := int32(93)
+	if err := state.ModifyInstanceMonitoring("demo-multi-reader-1", MonitoringSettings{
+		EnablePerformanceInsights:    &on,
+		PerformanceInsightsRetention: &retention,
+	}); err != nil {
+		t.Fatalf("ModifyInstanceMonitoring failed: %v", err)
+	}
+	if !waitForInstanceStatus(state, "demo-multi-reader-1", "configuring-performance-insights", pollTimeout) {
+		t.Fatal("instance did not go through configuring-performance-insights")
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); inst.PerformanceInsightsEnabled || inst.PerformanceInsightsRetentionDays() != 93 {
+		t.Errorf("while configuring: enabled = %v, retention = %d; want off with the new retention",
+			inst.PerformanceInsightsEnabled, inst.PerformanceInsightsRetentionDays())
+	}
+	if !waitForInstanceStatus(state, "demo-multi-reader-1", "available", pollTimeout) {
+		t.Fatal("instance did not become available")
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); !inst.PerformanceInsightsEnabled {
+		t.Error("Performance Insights not enabled after configuring-performance-insights")
+	}
+
+	interval := int32(60)
+	err := state.ModifyInstanceMonitoring("demo-multi-reader-2", MonitoringSettings{MonitoringInterval: &interval})
+	if err == nil || !strings.Contains(err.Error(), "MonitoringRoleARN") {
+		t.Errorf("enabling Enhanced Monitoring without a role: err = %v", err)
+	}
+}
+
+func TestState_ModifyDuringStatusScript(t *testing.T) {
+	state := NewState(TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	if err := state.ScriptInstanceStatus("demo-multi-reader-2", []StatusScriptEntry{
+		{Status: "modifying", DurationMs: 300},
+		{Status: "available"},
+	}, false); err != nil {
+		t.Fatalf("ScriptInstanceStatus failed: %v", err)
+	}
+	if err := state.ModifyInstance("demo-multi-reader-2", "db.r6g.xlarge", "", nil); err != nil {
+		t.Fatalf("ModifyInstance failed: %v", err)
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-2"); inst.Status != "modifying" || inst.PendingStatusChange != "modifying" {
+		t.Fatalf("status = %s, pending = %q; want the modify held behind the script", inst.Status, inst.PendingStatusChange)
+	}
+
+	if !waitForInstanceStatus(state, "demo-multi-reader-2", "available", 2*time.Second) {
+		t.Fatal("script did not finish")
+	}
+	// The scripted modifying applied the change, so the held one is dropped
+	// rather than driving the instance through modifying a second time.
+	time.Sleep(200 * time.Millisecond)
+	inst, _ := state.GetInstance("demo-multi-reader-2")
+	if inst.Status != "available" || inst.PendingStatusChange != "" || inst.InstanceType != "db.r6g.xlarge" {
+		t.Errorf("after script: status %s, pending %q, type %s; want available at db.r6g.xlarge",
+			inst.Status, inst.PendingStatusChange, inst.InstanceType)
+	}
+
+	if err := state.ScriptInstanceStatus("demo-multi-reader-2", []StatusScriptEntry{{Status: "modifying", DurationMs: -1}}, false); err == nil {
+		t.Error("expected a negative duration to be rejected")
+	}
+}
@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// mockARN returns the ARN of a mock resource of the given kind (e.g. "db",
+// "cluster-snapshot", "cluster-pg", "pg").
+func mockARN(kind, id string) string {
+	return fmt.Sprintf("arn:aws:rds:us-east-1:123456789012:%s:%s", kind, id)
+}
+
+// AddResourceTags adds tags to a resource, replacing existing values.
+func (s *State) AddResourceTags(arn string, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.resourceTags[arn]
+	if !ok {
+		existing = make(map[string]string, len(tags))
+		s.resourceTags[arn] = existing
+	}
+	for key, value := range tags {
+		existing[key] = value
+	}
+}
+
+// ResourceTags returns the tags on a resource.
+func (s *State) ResourceTags(arn string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]string, len(s.resourceTags[arn]))
+	for key, value := range s.resourceTags[arn] {
+		result[key] = value
+	}
+	return result
+}
+
+// parseTags reads the Tags.Tag.N list of a request.
+func parseTags(values url.Values) map[string]string {
+	tags := make(map[string]string)
+	for i := 1; ; i++ {
+		prefix := fmt.Sprintf("Tags.Tag.%d.", i)
+		key := values.Get(prefix + "Key")
+		if key == "" {
+			return tags
+		}
+		tags[key] = values.Get(prefix + "Value")
+	}
+}
+
+func (s *Server) handleAddTagsToResource(w http.ResponseWriter, values url.Values) {
+	resourceName := values.Get("ResourceName")
+	if resourceName == "" {
+		s.sendErrorResponse(w, "InvalidParameterValue", "ResourceName is required", 400)
+		return
+	}
+
+	if s.injectFault(w, "AddTagsToResource", resourceName) {
+		return
+	}
+
+	s.state.AddResourceTags(resourceName, parseTags(values))
+	s.executeTemplate(w, "add_tags_to_resource.xml", nil)
+}
+
+// sortedTagData returns tags in key order so responses are stable.
+func sortedTagData(tags map[string]string) []tagData {
+	data := make([]tagData, 0, len(tags))
+	for key, value := range tags {
+		data = append(data, tagData{Key: key, Value: value})
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Key < data[j].Key })
+	return data
+}
@@ -0,0 +1,116 @@
+package mock
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// auroraMySQLMarker separates the MySQL version from the Aurora release in
+// Aurora MySQL versions, e.g. 8.0.mysql_aurora.3.04.0.
+const auroraMySQLMarker = ".mysql_aurora."
+
+// auroraMySQLMajorUpgrades are the major version targets of each Aurora
+// MySQL major version.
+var auroraMySQLMajorUpgrades = map[string][]string{
+	"5.7": {"8.0.mysql_aurora.3.04.0", "8.0.mysql_aurora.3.05.2"},
+}
+
+// MockUpgradeTarget is a version an engine version can be upgraded to.
+type MockUpgradeTarget struct {
+	Version string
+	IsMajor bool
+	// EngineModes are the engine modes the target supports. Blue-Green
+	// deployments need "provisioned"; empty means provisioned only.
+	EngineModes []string
+}
+
+// SetUpgradeTargets replaces the generated upgrade targets of an engine
+// version with a fixed list.
+func (s *State) SetUpgradeTargets(engine, version string, targets []MockUpgradeTarget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upgradeTargets[engine+"/"+version] = targets
+}
+
+// UpgradeTargets returns the valid upgrade targets of an engine version:
+// the list set with SetUpgradeTargets, or else the next three minor versions
+// and the first two minors of the next major version.
+func (s *State) UpgradeTargets(engine, version string) []MockUpgradeTarget {
+	s.mu.RLock()
+	targets, ok := s.upgradeTargets[engine+"/"+version]
+	s.mu.RUnlock()
+	if ok {
+		return targets
+	}
+
+	if strings.Contains(version, auroraMySQLMarker) {
+		return auroraMySQLUpgradeTargets(version)
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	for i := 1; i <= 3; i++ {
+		targets = append(targets, MockUpgradeTarget{Version: fmt.Sprintf("%d.%d", major, minor+i)})
+	}
+	for i := 1; i <= 2; i++ {
+		targets = append(targets, MockUpgradeTarget{Version: fmt.Sprintf("%d.%d", major+1, i), IsMajor: true})
+	}
+	return targets
+}
+
+// auroraMySQLUpgradeTargets returns the next three minor Aurora releases of
+// the same MySQL version, e.g. 2.12.0 to 2.14.0 after 2.11.2, and the first
+// releases of the next MySQL major version.
+func auroraMySQLUpgradeTargets(version string) []MockUpgradeTarget {
+	community, release, _ := strings.Cut(version, auroraMySQLMarker)
+	parts := strings.Split(release, ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	major, _ := strconv.Atoi(parts[0])
+	minor, _ := strconv.Atoi(parts[1])
+	var targets []MockUpgradeTarget
+	for i := 1; i <= 3; i++ {
+		next := fmt.Sprintf("%d.%0*d.0", major, len(parts[1]), minor+i)
+		targets = append(targets, MockUpgradeTarget{Version: community + auroraMySQLMarker + next})
+	}
+	for _, target := range auroraMySQLMajorUpgrades[twoPartMajor(community)] {
+		targets = append(targets, MockUpgradeTarget{Version: target, IsMajor: true})
+	}
+	return targets
+}
+
+// twoPartMajor returns the first two components of a version, the major
+// version of MySQL and of PostgreSQL before 10.
+func twoPartMajor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	return strings.Join(parts[:min(2, len(parts))], ".")
+}
+
+// parameterGroupFamily returns the parameter group family of an engine
+// version: aurora-postgresql15 for 15.4, aurora-mysql5.7 for
+// 5.7.mysql_aurora.2.11.2.
+func parameterGroupFamily(engine, version string) string {
+	if strings.Contains(engine, "mysql") {
+		community, _, _ := strings.Cut(version, auroraMySQLMarker)
+		return engine + twoPartMajor(community)
+	}
+	major, _, _ := strings.Cut(version, ".")
+	if n, err := strconv.Atoi(major); err == nil && n < 10 {
+		major = twoPartMajor(version)
+	}
+	return engine + major
+}
+
+// engineDisplayName returns the name DescribeDBEngineVersions describes an
+// engine's versions with.
+func engineDisplayName(engine string) string {
+	if strings.Contains(engine, "mysql") {
+		return "Aurora MySQL"
+	}
+	return "PostgreSQL"
+}
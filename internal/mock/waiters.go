@@ -35,6 +35,12 @@ func (s *State) processTransitions() {
 			continue
 		}
 
+		// A status script owns the status until it ends
+		if inst.Scripted() {
+			advanceStatusScript(inst, now)
+			continue
+		}
+
 		// Handle pending status change (simulates AWS async status propagation)
 		if inst.PendingStatusChange != "" && now.After(inst.PendingStatusChangeAt) {
 			inst.Status = inst.PendingStatusChange
@@ -60,6 +66,7 @@ func (s *State) processTransitions() {
 				}
 				// Remove instance
 				delete(s.instances, id)
+				s.dropEndpointMemberLocked(id)
 			}
 			continue
 		}
@@ -76,6 +83,16 @@ func (s *State) processTransitions() {
 		// Handle all transitional statuses that eventually become available
 		if IsTransitionalStatus(inst.Status) {
 			if elapsed >= waitDuration {
+				// A CA certificate change is picked up when the reboot finishes
+				if inst.Status == "rebooting" && inst.PendingCACertificateIdentifier != "" {
+					inst.CACertificateIdentifier = inst.PendingCACertificateIdentifier
+					inst.PendingCACertificateIdentifier = ""
+				}
+				// Monitoring changes take effect as modifying ends, before
+				// any configuring status that follows.
+				if inst.Status == "modifying" {
+					applyPendingMonitoring(inst)
+				}
 				// Check if there's a pending transitional status to go through first
 				if inst.TransitionalStatus != "" {
 					inst.Status = inst.TransitionalStatus
@@ -84,18 +101,7 @@ func (s *State) processTransitions() {
 				} else {
 					// Apply pending modifications if this was a modifying status
 					if inst.Status == "modifying" {
-						if inst.PendingInstanceType != "" {
-							inst.InstanceType = inst.PendingInstanceType
-							inst.PendingInstanceType = ""
-						}
-						if inst.PendingStorageType != "" {
-							inst.StorageType = inst.PendingStorageType
-							inst.PendingStorageType = ""
-						}
-						if inst.PendingIOPS != nil {
-							inst.IOPS = inst.PendingIOPS
-							inst.PendingIOPS = nil
-						}
+						applyPendingInstanceChanges(inst)
 					}
 					// Enable Performance Insights after configuring-performance-insights completes
 					if inst.Status == "configuring-performance-insights" {
@@ -132,7 +138,7 @@ func (s *State) processTransitions() {
 					delete(s.clusters, id)
 				}
 			}
-		case "modifying", "upgrading":
+		case "creating", "modifying", "upgrading":
 			if elapsed >= waitDuration {
 				// Check if all instances are also available
 				allAvailable := true
@@ -170,6 +176,22 @@ func (s *State) processTransitions() {
 		}
 	}
 
+	// Process proxies
+	for name, proxy := range s.proxies {
+		// Check if fault injection is blocking this transition
+		if s.faults.CheckStateTransition(name) {
+			continue
+		}
+
+		switch proxy.Status {
+		case "creating", "modifying":
+			if now.Sub(proxy.StatusChangedAt) >= waitDuration {
+				proxy.Status = "available"
+				proxy.StatusChangedAt = now
+			}
+		}
+	}
+
 	// Process Blue-Green deployments
 	for id, bg := range s.blueGreenDeployments {
 		// Check if fault injection is blocking this transition
@@ -181,21 +203,28 @@ func (s *State) processTransitions() {
 
 		switch bg.Status {
 		case "PROVISIONING":
-			// Progress through tasks
+			// Progress through tasks one at a time, each taking a full wait
 			allTasksComplete := true
 			for i := range bg.Tasks {
-				if bg.Tasks[i].Status == "PENDING" {
-					bg.Tasks[i].Status = "IN_PROGRESS"
-					allTasksComplete = false
-					break
-				} else if bg.Tasks[i].Status == "IN_PROGRESS" {
-					if elapsed >= waitDuration {
-						bg.Tasks[i].Status = "COMPLETED"
-						bg.StatusChangedAt = now
+				task := &bg.Tasks[i]
+				if task.Status == "COMPLETED" {
+					continue
+				}
+				allTasksComplete = false
+				if task.Status == "PENDING" {
+					task.Status = "IN_PROGRESS"
+					bg.StatusChangedAt = now
+				} else if task.Status == "IN_PROGRESS" && elapsed >= waitDuration {
+					if details, failed := s.faults.CheckTaskFailure(bg.Identifier, bg.Name, task.Name); failed {
+						task.Status = "FAILED"
+						bg.Status = "PROVISIONING_FAILED"
+						bg.StatusDetails = details
+					} else {
+						task.Status = "COMPLETED"
 					}
-					allTasksComplete = false
-					break
+					bg.StatusChangedAt = now
 				}
+				break
 			}
 			if allTasksComplete {
 				// Extract source cluster info and create green cluster/instances
@@ -227,15 +256,37 @@ func (s *State) processTransitions() {
 			}
 
 		case "SWITCHOVER_IN_PROGRESS":
-			if elapsed >= waitDuration {
+			// Members finish at staggered points across the wait, so callers
+			// see gradual progress. A stuck fault targeting a member's ID
+			// holds that member, and with it the whole switchover, back.
+			switched := 0
+			for i := range bg.SwitchoverDetails {
+				detail := &bg.SwitchoverDetails[i]
+				if detail.Status == "SWITCHING_OVER" &&
+					elapsed >= waitDuration*time.Duration(i+1)/time.Duration(len(bg.SwitchoverDetails)+1) &&
+					!s.faults.CheckStateTransition(switchoverMemberID(detail.SourceMember)) {
+					detail.Status = "SWITCHOVER_COMPLETED"
+				}
+				if detail.Status == "SWITCHOVER_COMPLETED" {
+					switched++
+				}
+			}
+
+			if elapsed >= waitDuration && switched == len(bg.SwitchoverDetails) {
 				// Perform the actual switchover in mock state
 				// This simulates what AWS does: rename source to -old1, promote green to original names
 				sourceClusterID := extractClusterIDFromARN(bg.SourceClusterARN)
 				s.performSwitchoverLocked(sourceClusterID, bg.TargetEngineVersion, now)
 
-				// Update switchover details
+				// Like AWS, report the renamed blue resources as the source
+				// and the original names, now serving the green ones, as the
+				// target.
+				bg.TargetClusterARN = bg.SourceClusterARN
+				bg.SourceClusterARN += "-old1"
 				for i := range bg.SwitchoverDetails {
-					bg.SwitchoverDetails[i].Status = "SWITCHOVER_COMPLETED"
+					detail := &bg.SwitchoverDetails[i]
+					detail.TargetMember = detail.SourceMember
+					detail.SourceMember += "-old1"
 				}
 
 				bg.Status = "SWITCHOVER_COMPLETED"
@@ -251,3 +302,20 @@ func (s *State) processTransitions() {
 		}
 	}
 }
+
+// applyPendingInstanceChanges applies a pending instance class, storage type
+// and IOPS change.
+func applyPendingInstanceChanges(inst *MockInstance) {
+	if inst.PendingInstanceType != "" {
+		inst.InstanceType = inst.PendingInstanceType
+		inst.PendingInstanceType = ""
+	}
+	if inst.PendingStorageType != "" {
+		inst.StorageType = inst.PendingStorageType
+		inst.PendingStorageType = ""
+	}
+	if inst.PendingIOPS != nil {
+		inst.IOPS = inst.PendingIOPS
+		inst.PendingIOPS = nil
+	}
+}
@@ -0,0 +1,42 @@
+package notifiers
+
+import (
+	"context"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/machine"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Multi sends every notification to each of its notifiers in turn. All are
+// called even if one fails; the first error is returned.
+type Multi []machine.Notifier
+
+func (m Multi) each(fn func(machine.Notifier) error) error {
+	var first error
+	for _, n := range m {
+		if err := fn(n); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (m Multi) NotifyOperationStarted(ctx context.Context, op *types.Operation) error {
+	return m.each(func(n machine.Notifier) error { return n.NotifyOperationStarted(ctx, op) })
+}
+
+func (m Multi) NotifyOperationCompleted(ctx context.Context, op *types.Operation) error {
+	return m.each(func(n machine.Notifier) error { return n.NotifyOperationCompleted(ctx, op) })
+}
+
+func (m Multi) NotifyOperationFailed(ctx context.Context, op *types.Operation) error {
+	return m.each(func(n machine.Notifier) error { return n.NotifyOperationFailed(ctx, op) })
+}
+
+func (m Multi) NotifyOperationPaused(ctx context.Context, op *types.Operation, reason string) error {
+	return m.each(func(n machine.Notifier) error { return n.NotifyOperationPaused(ctx, op, reason) })
+}
+
+func (m Multi) NotifyStepCompleted(ctx context.Context, op *types.Operation, step *types.Step) error {
+	return m.each(func(n machine.Notifier) error { return n.NotifyStepCompleted(ctx, op, step) })
+}
@@ -124,6 +124,20 @@ func operationTypeName(t types.OperationType) string {
 		return "Engine Upgrade"
 	case types.OperationTypeInstanceCycle:
 		return "Instance Cycle"
+	case types.OperationTypeMinorVersionUpgrade:
+		return "Minor Version Upgrade"
+	case types.OperationTypeCACertRotation:
+		return "CA Certificate Rotation"
+	case types.OperationTypeRebootCluster:
+		return "Reboot Cluster"
+	case types.OperationTypeApplyPendingMaintenance:
+		return "Apply Pending Maintenance"
+	case types.OperationTypeSnapshotRestoreTest:
+		return "Snapshot Restore Test"
+	case types.OperationTypeServerlessScaling:
+		return "Serverless Scaling"
+	case types.OperationTypeMonitoringChange:
+		return "Monitoring Change"
 	default:
 		return string(t)
 	}
@@ -0,0 +1,205 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed by
+// the webhook secret, as "sha256=<hex>".
+const SignatureHeader = "X-Signature-256"
+
+const (
+	webhookMaxAttempts = 3
+	webhookTimeout     = 10 * time.Second
+)
+
+// WebhookPayload is the JSON body POSTed to the webhook.
+type WebhookPayload struct {
+	OperationID string               `json:"operation_id"`
+	Type        types.OperationType  `json:"type"`
+	ClusterID   string               `json:"cluster_id"`
+	Region      string               `json:"region"`
+	State       types.OperationState `json:"state"`
+	PauseReason string               `json:"pause_reason,omitempty"`
+	Error       string               `json:"error,omitempty"`
+	LastEvent   *types.Event         `json:"last_event,omitempty"`
+	Timestamp   time.Time            `json:"timestamp"`
+}
+
+// WebhookConfig contains configuration for the webhook notifier.
+type WebhookConfig struct {
+	// URL receives a POST for every operation that pauses, fails or completes.
+	URL string
+	// Secret signs each body in the SignatureHeader (optional).
+	Secret string
+	// Events returns an operation's events so the payload can include the
+	// latest one (optional).
+	Events func(operationID string) ([]types.Event, error)
+	// Logger receives delivery failures (optional).
+	Logger *slog.Logger
+	// Client sends the requests. Nil uses a client with a 10s timeout.
+	Client *http.Client
+	// RetryDelay is the delay before the second attempt, doubled before the
+	// third. Zero uses one second.
+	RetryDelay time.Duration
+}
+
+// WebhookNotifier POSTs a JSON payload to a URL when an operation pauses,
+// fails or completes. Deliveries run in the background and are retried, so a
+// slow or failing receiver never holds up an operation.
+type WebhookNotifier struct {
+	cfg      WebhookConfig
+	inflight sync.WaitGroup
+}
+
+// NewWebhookNotifier creates a webhook notifier.
+func NewWebhookNotifier(cfg WebhookConfig) *WebhookNotifier {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: webhookTimeout}
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = time.Second
+	}
+	return &WebhookNotifier{cfg: cfg}
+}
+
+// NotifyOperationStarted does nothing; only state changes that may need
+// attention are sent.
+func (n *WebhookNotifier) NotifyOperationStarted(ctx context.Context, op *types.Operation) error {
+	return nil
+}
+
+// NotifyOperationCompleted sends the completed state.
+func (n *WebhookNotifier) NotifyOperationCompleted(ctx context.Context, op *types.Operation) error {
+	n.send(op, types.StateCompleted, "")
+	return nil
+}
+
+// NotifyOperationFailed sends the failed state.
+func (n *WebhookNotifier) NotifyOperationFailed(ctx context.Context, op *types.Operation) error {
+	n.send(op, types.StateFailed, "")
+	return nil
+}
+
+// NotifyOperationPaused sends the paused state and why.
+func (n *WebhookNotifier) NotifyOperationPaused(ctx context.Context, op *types.Operation, reason string) error {
+	n.send(op, types.StatePaused, reason)
+	return nil
+}
+
+// NotifyStepCompleted does nothing.
+func (n *WebhookNotifier) NotifyStepCompleted(ctx context.Context, op *types.Operation, step *types.Step) error {
+	return nil
+}
+
+// Wait blocks until deliveries in progress have finished.
+func (n *WebhookNotifier) Wait() {
+	n.inflight.Wait()
+}
+
+// send captures the payload now, since the operation keeps changing, and
+// delivers it in the background.
+func (n *WebhookNotifier) send(op *types.Operation, state types.OperationState, reason string) {
+	payload := WebhookPayload{
+		OperationID: op.ID,
+		Type:        op.Type,
+		ClusterID:   op.ClusterID,
+		Region:      op.Region,
+		State:       state,
+		PauseReason: reason,
+		Error:       op.Error,
+		Timestamp:   time.Now().UTC(),
+	}
+
+	n.inflight.Add(1)
+	go func() {
+		defer n.inflight.Done()
+
+		// Looked up here rather than by the caller, which may hold the
+		// engine's lock.
+		if n.cfg.Events != nil {
+			if events, err := n.cfg.Events(payload.OperationID); err == nil && len(events) > 0 {
+				payload.LastEvent = &events[len(events)-1]
+			}
+		}
+
+		if err := n.deliver(payload); err != nil {
+			n.cfg.Logger.Warn("webhook delivery failed",
+				"operation_id", payload.OperationID,
+				"state", payload.State,
+				"error", err)
+		}
+	}()
+}
+
+// deliver POSTs the payload, retrying failed attempts with a doubling delay.
+// Client errors other than 429 are not retried.
+func (n *WebhookNotifier) deliver(payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshal webhook payload")
+	}
+
+	delay := n.cfg.RetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookMaxAttempts {
+			return errors.Wrapf(err, "after %d attempt(s)", attempt)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func (n *WebhookNotifier) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.cfg.Secret, body))
+	}
+
+	resp, err := n.cfg.Client.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "post webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, errors.Newf("webhook returned %s", resp.Status)
+}
+
+// Sign returns the SignatureHeader value for body: "sha256=" followed by the
+// hex HMAC-SHA256 of body keyed by secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,117 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		statuses []int // responses to return, in order; 200 once exhausted
+		received []*http.Request
+		bodies   [][]byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	reset := func(responses ...int) {
+		mu.Lock()
+		defer mu.Unlock()
+		statuses, received, bodies = responses, nil, nil
+	}
+
+	n := NewWebhookNotifier(WebhookConfig{
+		URL:    server.URL,
+		Secret: "s3cret",
+		Events: func(operationID string) ([]types.Event, error) {
+			return []types.Event{
+				{OperationID: operationID, Type: "step_started", Message: "first"},
+				{OperationID: operationID, Type: "operation_paused", Message: "latest"},
+			}, nil
+		},
+		RetryDelay: time.Millisecond,
+	})
+	op := &types.Operation{ID: "op-1", Type: types.OperationTypeInstanceCycle, ClusterID: "orders", Region: "us-east-1"}
+	ctx := context.Background()
+
+	t.Run("signed payload with the latest event", func(t *testing.T) {
+		reset()
+		_ = n.NotifyOperationPaused(ctx, op, "needs approval")
+		n.Wait()
+
+		if len(received) != 1 {
+			t.Fatalf("got %d requests, want 1", len(received))
+		}
+		if got, want := received[0].Header.Get(SignatureHeader), Sign("s3cret", bodies[0]); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		var payload WebhookPayload
+		if err := json.Unmarshal(bodies[0], &payload); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		if payload.OperationID != "op-1" || payload.ClusterID != "orders" || payload.State != types.StatePaused ||
+			payload.PauseReason != "needs approval" {
+			t.Errorf("payload = %+v", payload)
+		}
+		if payload.LastEvent == nil || payload.LastEvent.Message != "latest" {
+			t.Errorf("last event = %+v, want the latest", payload.LastEvent)
+		}
+	})
+
+	t.Run("server errors are retried three times", func(t *testing.T) {
+		reset(http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK)
+		_ = n.NotifyOperationFailed(ctx, op)
+		n.Wait()
+		if len(received) != 3 {
+			t.Errorf("got %d attempts, want 3", len(received))
+		}
+	})
+
+	t.Run("delivery succeeds on retry", func(t *testing.T) {
+		reset(http.StatusTooManyRequests)
+		_ = n.NotifyOperationCompleted(ctx, op)
+		n.Wait()
+		if len(received) != 2 {
+			t.Errorf("got %d attempts, want 2", len(received))
+		}
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		reset(http.StatusBadRequest)
+		_ = n.NotifyOperationFailed(ctx, op)
+		n.Wait()
+		if len(received) != 1 {
+			t.Errorf("got %d attempts, want 1", len(received))
+		}
+	})
+
+	t.Run("started and step notifications are not sent", func(t *testing.T) {
+		reset()
+		_ = n.NotifyOperationStarted(ctx, op)
+		_ = n.NotifyStepCompleted(ctx, op, &types.Step{})
+		n.Wait()
+		if len(received) != 0 {
+			t.Errorf("got %d requests, want none", len(received))
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Package pricing holds the static price table cost estimates are computed
+// from.
+package pricing
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Table is a price list for the resources an operation adds. Prices are
+// on-demand list prices and deliberately coarse: they feed estimates, not
+// bills.
+type Table struct {
+	// Currency the prices are in.
+	Currency string `json:"currency"`
+	// Source describes where the prices came from, shown with every estimate.
+	Source string `json:"source"`
+	// InstanceHourly is the hourly price of each instance class.
+	InstanceHourly map[string]float64 `json:"instance_hourly"`
+	// SnapshotGBMonth is the price of a GiB of snapshot storage for a month.
+	SnapshotGBMonth float64 `json:"snapshot_gb_month"`
+}
+
+// Default returns the built-in table: Aurora PostgreSQL on-demand prices in
+// us-east-1 for common instance classes.
+func Default() *Table {
+	return &Table{
+		Currency: "USD",
+		Source:   "built-in Aurora PostgreSQL on-demand prices for us-east-1",
+		InstanceHourly: map[string]float64{
+			"db.m6g.large":    0.162,
+			"db.m6g.xlarge":   0.324,
+			"db.m6g.2xlarge":  0.648,
+			"db.r5.large":     0.29,
+			"db.r5.xlarge":    0.58,
+			"db.r5.2xlarge":   1.16,
+			"db.r6g.large":    0.26,
+			"db.r6g.xlarge":   0.519,
+			"db.r6g.2xlarge":  1.038,
+			"db.r6g.4xlarge":  2.076,
+			"db.r6g.8xlarge":  4.152,
+			"db.r6g.12xlarge": 6.228,
+			"db.r6g.16xlarge": 8.304,
+			"db.r6gd.xlarge":  0.624,
+			"db.r6i.large":    0.29,
+			"db.r6i.xlarge":   0.58,
+			"db.r6i.2xlarge":  1.16,
+			"db.r6id.xlarge":  0.696,
+			"db.r7g.large":    0.276,
+			"db.r7g.xlarge":   0.552,
+			"db.r7g.2xlarge":  1.104,
+			"db.r7g.4xlarge":  2.208,
+			"db.r7i.large":    0.305,
+			"db.r7i.xlarge":   0.61,
+			"db.r7i.2xlarge":  1.22,
+			"db.r8g.large":    0.29,
+			"db.r8g.xlarge":   0.58,
+			"db.r8g.2xlarge":  1.16,
+			"db.t3.medium":    0.082,
+			"db.t3.large":     0.164,
+			"db.t4g.medium":   0.073,
+			"db.t4g.large":    0.146,
+			"db.x2g.large":    0.391,
+			"db.x2g.xlarge":   0.782,
+		},
+		SnapshotGBMonth: 0.021,
+	}
+}
+
+// Load reads a table from a JSON file. Instance classes the file leaves out
+// keep their built-in price, so a file only needs the prices that differ;
+// an empty path returns the built-in table.
+func Load(path string) (*Table, error) {
+	table := Default()
+	if path == "" {
+		return table, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read price table %s", path)
+	}
+	var file Table
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrapf(err, "parse price table %s", path)
+	}
+	for class, price := range file.InstanceHourly {
+		if price < 0 {
+			return nil, errors.Newf("price table %s: negative price %v for %s", path, price, class)
+		}
+		table.InstanceHourly[class] = price
+	}
+	if file.SnapshotGBMonth < 0 {
+		return nil, errors.Newf("price table %s: negative snapshot_gb_month %v", path, file.SnapshotGBMonth)
+	}
+	if file.SnapshotGBMonth > 0 {
+		table.SnapshotGBMonth = file.SnapshotGBMonth
+	}
+	if file.Currency != "" {
+		table.Currency = file.Currency
+	}
+	table.Source = path
+	if file.Source != "" {
+		table.Source = file.Source
+	}
+	return table, nil
+}
+
+// InstancePrice returns the hourly price of an instance class, and whether
+// the table has one. Serverless v2 instances are billed by capacity used, so
+// the built-in table has no price for db.serverless.
+func (t *Table) InstancePrice(class string) (float64, bool) {
+	price, ok := t.InstanceHourly[class]
+	return price, ok
+}
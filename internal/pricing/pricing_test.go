@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prices.json")
+	data := `{"currency":"EUR","instance_hourly":{"db.r6g.large":0.3,"db.custom.big":9},"snapshot_gb_month":0.025}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if table.Currency != "EUR" || table.SnapshotGBMonth != 0.025 || table.Source != path {
+		t.Errorf("unexpected table: currency %q, snapshot %v, source %q", table.Currency, table.SnapshotGBMonth, table.Source)
+	}
+	for class, want := range map[string]float64{
+		"db.r6g.large":  0.3,
+		"db.custom.big": 9,
+		"db.r6g.xlarge": Default().InstanceHourly["db.r6g.xlarge"],
+	} {
+		if got, ok := table.InstancePrice(class); !ok || got != want {
+			t.Errorf("InstancePrice(%s) = %v, %v; want %v", class, got, ok, want)
+		}
+	}
+	if _, ok := table.InstancePrice("db.serverless"); ok {
+		t.Error("db.serverless should have no hourly price")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"malformed":          `{"instance_hourly":`,
+		"negative price":     `{"instance_hourly":{"db.r6g.large":-1}}`,
+		"negative snapshots": `{"snapshot_gb_month":-0.1}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if table, err := Load(""); err != nil || table.Currency != "USD" {
+		t.Errorf("Load(\"\") = %v, %v; want the built-in table", table, err)
+	}
+}
@@ -4,6 +4,9 @@ package rds
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,45 +14,97 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	"github.com/aws/aws-sdk-go-v2/service/rds/types"
 	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/cloudwatch"
 	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
 	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
 )
 
 // Client wraps the AWS RDS client with convenience methods.
 type Client struct {
-	rds     *rds.Client
-	baseURL string // for testing with mock servers
+	rds       *rds.Client
+	metrics   *cloudwatch.Client // nil when built around an existing RDS client
+	baseURL   string             // for testing with mock servers
+	tagLookup TagLookupConfig
+	logger    *slog.Logger
 }
 
 // ClientConfig contains configuration for the RDS client.
 type ClientConfig struct {
-	AWSConfig aws.Config
-	BaseURL   string // optional, for testing
+	AWSConfig    aws.Config
+	BaseURL      string          // optional, for testing
+	CallTimeouts CallTimeouts    // per-call deadlines; zero value uses DefaultCallTimeout
+	Retry        RetryConfig     // SDK retry behavior; zero value keeps the AWS config's
+	TagLookup    TagLookupConfig // bounds on the tag lookups a cluster lookup makes
+	Logger       *slog.Logger    // optional; defaults to slog.Default()
+}
+
+// RetryConfig controls how the SDK retries a failed RDS API call. These
+// retries sit below the engine's wait loops: they absorb throttling and
+// transient failures within one call, before a poll ever sees an error.
+type RetryConfig struct {
+	// Mode is the SDK retry mode. Adaptive mode also rate limits the client
+	// once the API starts throttling it. Empty keeps the AWS config's mode.
+	Mode aws.RetryMode
+	// MaxAttempts is the number of attempts per call, the first included.
+	// Zero keeps the AWS config's setting.
+	MaxAttempts int
 }
 
 // NewClient creates a new RDS client.
 func NewClient(cfg ClientConfig) *Client {
-	opts := []func(*rds.Options){}
+	opts := []func(*rds.Options){
+		func(o *rds.Options) {
+			o.APIOptions = append(o.APIOptions, withCallTimeouts(cfg.CallTimeouts))
+		},
+	}
 	if cfg.BaseURL != "" {
 		opts = append(opts, func(o *rds.Options) {
 			o.BaseEndpoint = aws.String(cfg.BaseURL)
 		})
 	}
+	if cfg.Retry.Mode != "" {
+		opts = append(opts, func(o *rds.Options) {
+			o.RetryMode = cfg.Retry.Mode
+		})
+	}
+	if cfg.Retry.MaxAttempts > 0 {
+		opts = append(opts, func(o *rds.Options) {
+			o.RetryMaxAttempts = cfg.Retry.MaxAttempts
+		})
+	}
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	metrics := cloudwatch.NewClient(cloudwatch.Config{
+		AWSConfig:        cfg.AWSConfig,
+		BaseURL:          cfg.BaseURL,
+		RetryMode:        cfg.Retry.Mode,
+		RetryMaxAttempts: cfg.Retry.MaxAttempts,
+	})
 
 	return &Client{
-		rds:     rds.NewFromConfig(cfg.AWSConfig, opts...),
-		baseURL: cfg.BaseURL,
+		rds:       rds.NewFromConfig(cfg.AWSConfig, opts...),
+		metrics:   metrics,
+		baseURL:   cfg.BaseURL,
+		tagLookup: cfg.TagLookup,
+		logger:    logger,
 	}
 }
 
 // NewClientWithRDS creates a client with an existing RDS client (for testing).
 func NewClientWithRDS(client *rds.Client) *Client {
-	return &Client{rds: client}
+	return &Client{rds: client, logger: slog.Default()}
 }
 
-// ListClusters returns a summary of all Aurora clusters in the region.
-func (c *Client) ListClusters(ctx context.Context) ([]internaltypes.ClusterSummary, error) {
+// ListClusters returns a summary of all Aurora clusters in the region. With
+// includeInstances, each summary also carries the writer's instance class,
+// which costs a DescribeDBInstances call per batch of clusters.
+func (c *Client) ListClusters(ctx context.Context, includeInstances bool) ([]internaltypes.ClusterSummary, error) {
 	var clusters []internaltypes.ClusterSummary
+	writers := make(map[string]string)
 
 	paginator := rds.NewDescribeDBClustersPaginator(c.rds, &rds.DescribeDBClustersInput{})
 	for paginator.HasMorePages() {
@@ -62,19 +117,44 @@ func (c *Client) ListClusters(ctx context.Context) ([]internaltypes.ClusterSumma
 			// Only include Aurora clusters
 			engine := aws.ToString(cluster.Engine)
 			if strings.HasPrefix(engine, "aurora") {
+				clusterID := aws.ToString(cluster.DBClusterIdentifier)
 				clusters = append(clusters, internaltypes.ClusterSummary{
-					ClusterID:     aws.ToString(cluster.DBClusterIdentifier),
-					Engine:        engine,
-					EngineVersion: aws.ToString(cluster.EngineVersion),
-					Status:        aws.ToString(cluster.Status),
+					ClusterID:       clusterID,
+					Engine:          engine,
+					EngineVersion:   aws.ToString(cluster.EngineVersion),
+					Status:          aws.ToString(cluster.Status),
+					InstanceCount:   len(cluster.DBClusterMembers),
+					MultiAZ:         aws.ToBool(cluster.MultiAZ),
+					GlobalClusterID: aws.ToString(cluster.GlobalClusterIdentifier),
 				})
+				for _, member := range cluster.DBClusterMembers {
+					if aws.ToBool(member.IsClusterWriter) {
+						writers[clusterID] = aws.ToString(member.DBInstanceIdentifier)
+					}
+				}
 			}
 		}
 	}
 
+	if includeInstances {
+		clusters = c.addWriterClasses(ctx, clusters, writers)
+	}
 	return clusters, nil
 }
 
+// CheckAccess makes the cheapest DescribeDBClusters call there is, to
+// confirm the endpoint is reachable and the credentials may describe
+// clusters.
+func (c *Client) CheckAccess(ctx context.Context) error {
+	_, err := c.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+		MaxRecords: aws.Int32(20), // the smallest page RDS accepts
+	})
+	if err != nil {
+		return errors.Wrap(err, "describe clusters")
+	}
+	return nil
+}
+
 // GetClusterInfo retrieves information about an RDS cluster.
 // This method is optimized to batch instance lookups and cache tag checks.
 func (c *Client) GetClusterInfo(ctx context.Context, clusterID string) (*internaltypes.ClusterInfo, error) {
@@ -99,6 +179,14 @@ func (c *Client) GetClusterInfo(ctx context.Context, clusterID string) (*interna
 		EngineVersion: aws.ToString(cluster.EngineVersion),
 		Status:        aws.ToString(cluster.Status),
 		Instances:     make([]internaltypes.InstanceInfo, 0, len(cluster.DBClusterMembers)),
+
+		ParameterGroupName: aws.ToString(cluster.DBClusterParameterGroup),
+
+		ServerlessV2Scaling: serverlessV2Scaling(cluster.ServerlessV2ScalingConfiguration),
+
+		IAMDatabaseAuthenticationEnabled: aws.ToBool(cluster.IAMDatabaseAuthenticationEnabled),
+
+		PreferredMaintenanceWindow: aws.ToString(cluster.PreferredMaintenanceWindow),
 	}
 
 	// Build a map of member IDs to their writer status
@@ -129,7 +217,7 @@ func (c *Client) GetClusterInfo(ctx context.Context, clusterID string) (*interna
 	}
 
 	// Batch check autoscaling tags
-	autoScaledSet := c.batchCheckAutoScaled(ctx, instanceARNs)
+	autoScaledSet := c.batchCheckAutoScaled(ctx, clusterID, instanceARNs)
 
 	// Process instances
 	for _, instance := range instancesOut.DBInstances {
@@ -137,17 +225,29 @@ func (c *Client) GetClusterInfo(ctx context.Context, clusterID string) (*interna
 		instanceARN := aws.ToString(instance.DBInstanceArn)
 
 		instInfo := internaltypes.InstanceInfo{
-			InstanceID:   instanceID,
-			InstanceType: aws.ToString(instance.DBInstanceClass),
-			Status:       aws.ToString(instance.DBInstanceStatus),
-			StorageType:  aws.ToString(instance.StorageType),
-			IsAutoScaled: autoScaledSet[instanceARN],
+			InstanceID:              instanceID,
+			InstanceType:            aws.ToString(instance.DBInstanceClass),
+			Status:                  aws.ToString(instance.DBInstanceStatus),
+			StorageType:             aws.ToString(instance.StorageType),
+			IsAutoScaled:            autoScaledSet[instanceARN],
+			CACertificateIdentifier: aws.ToString(instance.CACertificateIdentifier),
 		}
+		setPendingModifiedValues(&instInfo, instance)
 
 		if instance.Iops != nil {
 			iops := int32(*instance.Iops)
 			instInfo.IOPS = &iops
 		}
+		instInfo.AllocatedStorage = aws.ToInt32(instance.AllocatedStorage)
+		instInfo.AvailabilityZone = aws.ToString(instance.AvailabilityZone)
+		instInfo.DBSubnetGroup, instInfo.VpcSecurityGroupIDs = instanceNetwork(instance)
+		instInfo.ParameterGroupName = instanceParameterGroup(instance)
+		setInstanceMonitoring(&instInfo, instance)
+		instInfo.IAMDatabaseAuthenticationEnabled = aws.ToBool(instance.IAMDatabaseAuthenticationEnabled)
+
+		if instInfo.InstanceType == ServerlessInstanceClass {
+			info.IsServerless = true
+		}
 
 		if memberWriterStatus[instanceID] {
 			instInfo.Role = "writer"
@@ -158,17 +258,9 @@ func (c *Client) GetClusterInfo(ctx context.Context, clusterID string) (*interna
 		info.Instances = append(info.Instances, instInfo)
 	}
 
-	return info, nil
-}
+	info.CustomEndpoints = c.customEndpoints(ctx, clusterID)
 
-// batchCheckAutoScaled checks multiple instance ARNs for autoscaling tags.
-// Returns a map of ARN -> isAutoScaled.
-func (c *Client) batchCheckAutoScaled(ctx context.Context, arns []string) map[string]bool {
-	result := make(map[string]bool)
-	for _, arn := range arns {
-		result[arn] = c.isAutoScaledInstance(ctx, arn)
-	}
-	return result
+	return info, nil
 }
 
 // GetInstanceInfo retrieves information about a specific RDS instance.
@@ -189,16 +281,24 @@ func (c *Client) GetInstanceInfo(ctx context.Context, instanceID string) (*inter
 
 	instance := out.DBInstances[0]
 	info := &internaltypes.InstanceInfo{
-		InstanceID:   aws.ToString(instance.DBInstanceIdentifier),
-		InstanceType: aws.ToString(instance.DBInstanceClass),
-		Status:       aws.ToString(instance.DBInstanceStatus),
-		StorageType:  aws.ToString(instance.StorageType),
+		InstanceID:              aws.ToString(instance.DBInstanceIdentifier),
+		InstanceType:            aws.ToString(instance.DBInstanceClass),
+		Status:                  aws.ToString(instance.DBInstanceStatus),
+		StorageType:             aws.ToString(instance.StorageType),
+		CACertificateIdentifier: aws.ToString(instance.CACertificateIdentifier),
 	}
+	setPendingModifiedValues(info, instance)
 
 	if instance.Iops != nil {
 		iops := int32(*instance.Iops)
 		info.IOPS = &iops
 	}
+	info.AllocatedStorage = aws.ToInt32(instance.AllocatedStorage)
+	info.AvailabilityZone = aws.ToString(instance.AvailabilityZone)
+	info.DBSubnetGroup, info.VpcSecurityGroupIDs = instanceNetwork(instance)
+	info.ParameterGroupName = instanceParameterGroup(instance)
+	setInstanceMonitoring(info, instance)
+	info.IAMDatabaseAuthenticationEnabled = aws.ToBool(instance.IAMDatabaseAuthenticationEnabled)
 
 	// Check if this is an auto-scaled instance by looking at tags
 	info.IsAutoScaled = c.isAutoScaledInstance(ctx, aws.ToString(instance.DBInstanceArn))
@@ -206,21 +306,55 @@ func (c *Client) GetInstanceInfo(ctx context.Context, instanceID string) (*inter
 	return info, nil
 }
 
-// isAutoScaledInstance checks if an instance was created by autoscaling.
-func (c *Client) isAutoScaledInstance(ctx context.Context, arn string) bool {
-	out, err := c.rds.ListTagsForResource(ctx, &rds.ListTagsForResourceInput{
-		ResourceName: aws.String(arn),
-	})
-	if err != nil {
-		return false
+// instanceParameterGroup returns the DB parameter group of an instance.
+// Aurora instances belong to exactly one.
+func instanceParameterGroup(instance types.DBInstance) string {
+	if len(instance.DBParameterGroups) == 0 {
+		return ""
 	}
+	return aws.ToString(instance.DBParameterGroups[0].DBParameterGroupName)
+}
 
-	for _, tag := range out.TagList {
-		if aws.ToString(tag.Key) == "application-autoscaling:resourceId" {
-			return true
-		}
+// instanceNetwork returns the DB subnet group and VPC security group IDs of
+// an instance.
+func instanceNetwork(instance types.DBInstance) (string, []string) {
+	var subnetGroup string
+	if instance.DBSubnetGroup != nil {
+		subnetGroup = aws.ToString(instance.DBSubnetGroup.DBSubnetGroupName)
+	}
+	var securityGroups []string
+	for _, sg := range instance.VpcSecurityGroups {
+		securityGroups = append(securityGroups, aws.ToString(sg.VpcSecurityGroupId))
+	}
+	return subnetGroup, securityGroups
+}
+
+// setPendingModifiedValues copies the changes queued on an instance into
+// info, leaving PendingModifiedValues nil when there are none.
+func setPendingModifiedValues(info *internaltypes.InstanceInfo, instance types.DBInstance) {
+	pending := instance.PendingModifiedValues
+	if pending == nil {
+		return
+	}
+	info.PendingCACertificateIdentifier = aws.ToString(pending.CACertificateIdentifier)
+	values := internaltypes.PendingModifiedValues{
+		InstanceClass:           aws.ToString(pending.DBInstanceClass),
+		StorageType:             aws.ToString(pending.StorageType),
+		IOPS:                    pending.Iops,
+		StorageThroughput:       pending.StorageThroughput,
+		AllocatedStorage:        pending.AllocatedStorage,
+		EngineVersion:           aws.ToString(pending.EngineVersion),
+		CACertificateIdentifier: aws.ToString(pending.CACertificateIdentifier),
+	}
+	if !values.IsEmpty() {
+		info.PendingModifiedValues = &values
 	}
-	return false
+}
+
+// isAutoScaledInstance checks if an instance was created by autoscaling.
+func (c *Client) isAutoScaledInstance(ctx context.Context, arn string) bool {
+	scaled, err := c.lookupAutoScaled(ctx, arn)
+	return err == nil && scaled
 }
 
 // CreateClusterInstance creates a new instance in the cluster.
@@ -237,14 +371,38 @@ func (c *Client) CreateClusterInstance(ctx context.Context, params CreateInstanc
 		input.AvailabilityZone = aws.String(params.AvailabilityZone)
 	}
 
-	// Add tags to identify this as a temp maintenance instance
-	input.Tags = []types.Tag{
-		{Key: aws.String("rds-maint-machine"), Value: aws.String("temp-instance")},
-		{Key: aws.String("rds-maint-operation-id"), Value: aws.String(params.OperationID)},
+	if params.CACertificateIdentifier != "" {
+		input.CACertificateIdentifier = aws.String(params.CACertificateIdentifier)
+	}
+
+	// Aurora rejects the setting on instances and applies the cluster's.
+	if params.IAMDatabaseAuthentication && !strings.HasPrefix(params.Engine, "aurora") {
+		input.EnableIAMDatabaseAuthentication = aws.Bool(true)
 	}
 
+	if params.DBSubnetGroupName != "" {
+		input.DBSubnetGroupName = aws.String(params.DBSubnetGroupName)
+	}
+	if len(params.VpcSecurityGroupIDs) > 0 {
+		input.VpcSecurityGroupIds = params.VpcSecurityGroupIDs
+	}
+
+	// Tag the instance as a temp maintenance instance on top of any
+	// inherited and operator tags
+	input.Tags = MergeTags(map[string]string{
+		TagKeyMachine:     tempInstanceTagValue,
+		TagKeyOperationID: params.OperationID,
+	}, params.Tags)
+
 	_, err := c.rds.CreateDBInstance(ctx, input)
 	if err != nil {
+		// The cluster is full, or the account has no instance quota left.
+		if strings.Contains(err.Error(), "DBClusterQuotaExceeded") || strings.Contains(err.Error(), "InstanceQuotaExceeded") {
+			return "", errors.Mark(errors.Wrap(err, "create instance"), internalerrors.ErrClusterInstanceLimit)
+		}
+		if strings.Contains(err.Error(), "DBInstanceAlreadyExists") {
+			return "", errors.Mark(errors.Wrap(err, "create instance"), internalerrors.ErrInstanceAlreadyExists)
+		}
 		return "", errors.Wrap(err, "create instance")
 	}
 
@@ -260,6 +418,23 @@ type CreateInstanceParams struct {
 	PromotionTier    int32
 	AvailabilityZone string
 	OperationID      string
+
+	// CACertificateIdentifier sets the instance's CA certificate at creation.
+	// Empty uses the region default.
+	CACertificateIdentifier string
+
+	// DBSubnetGroupName and VpcSecurityGroupIDs place the instance in a
+	// network. Empty leaves them to RDS, which uses the account defaults.
+	DBSubnetGroupName   string
+	VpcSecurityGroupIDs []string
+
+	// IAMDatabaseAuthentication enables IAM database authentication on the
+	// instance. It is only sent for non-Aurora engines; Aurora instances
+	// always take the cluster's setting.
+	IAMDatabaseAuthentication bool
+
+	// Tags are added to the instance alongside the machine's own tags.
+	Tags map[string]string
 }
 
 // ModifyInstance modifies an existing RDS instance.
@@ -285,6 +460,10 @@ func (c *Client) ModifyInstance(ctx context.Context, params ModifyInstanceParams
 		input.StorageThroughput = aws.Int32(*params.StorageThroughput)
 	}
 
+	if params.DBParameterGroupName != "" {
+		input.DBParameterGroupName = aws.String(params.DBParameterGroupName)
+	}
+
 	_, err := c.rds.ModifyDBInstance(ctx, input)
 	if err != nil {
 		return errors.Wrap(err, "modify instance")
@@ -293,21 +472,44 @@ func (c *Client) ModifyInstance(ctx context.Context, params ModifyInstanceParams
 	return nil
 }
 
-// ModifyInstanceParams contains parameters for modifying an instance.
-type ModifyInstanceParams struct {
-	InstanceID        string
-	InstanceType      string
-	StorageType       string
-	IOPS              *int32
-	StorageThroughput *int32
-	ApplyImmediately  bool
+// ModifyInstanceCACert switches an instance to a new CA certificate. The
+// change is left pending until the instance is rebooted, so callers control
+// when the restart happens.
+func (c *Client) ModifyInstanceCACert(ctx context.Context, instanceID, caIdentifier string) error {
+	_, err := c.rds.ModifyDBInstance(ctx, &rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier:       aws.String(instanceID),
+		CACertificateIdentifier:    aws.String(caIdentifier),
+		CertificateRotationRestart: aws.Bool(false),
+		ApplyImmediately:           aws.Bool(true),
+	})
+	if err != nil {
+		return errors.Wrap(err, "modify instance CA certificate")
+	}
+
+	return nil
 }
 
-// DeleteInstance deletes an RDS instance.
-func (c *Client) DeleteInstance(ctx context.Context, instanceID string, skipFinalSnapshot bool) error {
+// ModifyInstanceParams contains parameters for modifying an instance.
+type ModifyInstanceParams struct {
+	InstanceID           string
+	InstanceType         string
+	StorageType          string
+	IOPS                 *int32
+	StorageThroughput    *int32
+	DBParameterGroupName string // takes effect on the instance's next reboot
+	ApplyImmediately     bool
+}
+
+// DeleteInstance deletes an RDS instance. RDS takes a final snapshot named
+// finalSnapshotID as it deletes the instance; an empty finalSnapshotID skips
+// the final snapshot. Aurora cluster members must be deleted without one.
+func (c *Client) DeleteInstance(ctx context.Context, instanceID, finalSnapshotID string) error {
 	input := &rds.DeleteDBInstanceInput{
 		DBInstanceIdentifier: aws.String(instanceID),
-		SkipFinalSnapshot:    aws.Bool(skipFinalSnapshot),
+		SkipFinalSnapshot:    aws.Bool(finalSnapshotID == ""),
+	}
+	if finalSnapshotID != "" {
+		input.FinalDBSnapshotIdentifier = aws.String(finalSnapshotID)
 	}
 
 	_, err := c.rds.DeleteDBInstance(ctx, input)
@@ -390,14 +592,22 @@ type ModifyClusterParams struct {
 	DeletionProtection           *bool  // nil means don't change, true/false explicitly sets it
 }
 
-// CreateClusterSnapshot creates a manual snapshot of the cluster.
-func (c *Client) CreateClusterSnapshot(ctx context.Context, clusterID, snapshotID string) error {
+// CreateClusterSnapshot creates a manual snapshot of the cluster, tagged with
+// the operation that took it. A positive retentionDays tags the snapshot so
+// snapshot cleanup removes it once that many days have passed; otherwise it
+// is kept until deleted by hand.
+func (c *Client) CreateClusterSnapshot(ctx context.Context, clusterID, snapshotID, operationID string, retentionDays int, tags map[string]string) error {
+	reserved := map[string]string{TagKeyMachine: "pre-upgrade-snapshot"}
+	if operationID != "" {
+		reserved[TagKeyOperationID] = operationID
+	}
+	if retentionDays > 0 {
+		reserved[TagKeyRetentionDays] = strconv.Itoa(retentionDays)
+	}
 	input := &rds.CreateDBClusterSnapshotInput{
 		DBClusterIdentifier:         aws.String(clusterID),
 		DBClusterSnapshotIdentifier: aws.String(snapshotID),
-		Tags: []types.Tag{
-			{Key: aws.String("rds-maint-machine"), Value: aws.String("pre-upgrade-snapshot")},
-		},
+		Tags:                        MergeTags(reserved, tags),
 	}
 
 	_, err := c.rds.CreateDBClusterSnapshot(ctx, input)
@@ -529,6 +739,10 @@ type ParameterInfo struct {
 	ApplyType    string // "static" or "dynamic"
 	IsModifiable bool
 	Source       string // "user" for custom values, "system" or "engine-default" for defaults
+	DataType     string // e.g. "integer", "boolean", "string", "list"
+	// AllowedValues lists the values the parameter accepts, as literals and
+	// numeric ranges (e.g. "0,1" or "64-2147483647").
+	AllowedValues string
 }
 
 // GetClusterParameterGroup returns information about the cluster's parameter group.
@@ -584,13 +798,7 @@ func (c *Client) GetClusterParameterGroupCustomParameters(ctx context.Context, p
 
 		for _, param := range out.Parameters {
 			if param.ParameterValue != nil {
-				customParams = append(customParams, ParameterInfo{
-					Name:         aws.ToString(param.ParameterName),
-					Value:        aws.ToString(param.ParameterValue),
-					ApplyType:    aws.ToString(param.ApplyType),
-					IsModifiable: aws.ToBool(param.IsModifiable),
-					Source:       aws.ToString(param.Source),
-				})
+				customParams = append(customParams, parameterInfo(param))
 			}
 		}
 	}
@@ -599,31 +807,50 @@ func (c *Client) GetClusterParameterGroupCustomParameters(ctx context.Context, p
 }
 
 // CreateClusterParameterGroup creates a new cluster parameter group.
-func (c *Client) CreateClusterParameterGroup(ctx context.Context, name, family, description string) error {
+func (c *Client) CreateClusterParameterGroup(ctx context.Context, name, family, description string, tags map[string]string) error {
 	_, err := c.rds.CreateDBClusterParameterGroup(ctx, &rds.CreateDBClusterParameterGroupInput{
 		DBClusterParameterGroupName: aws.String(name),
 		DBParameterGroupFamily:      aws.String(family),
 		Description:                 aws.String(description),
-		Tags: []types.Tag{
-			{Key: aws.String("created-by"), Value: aws.String("rds-maint-machine")},
-		},
+		Tags:                        MergeTags(map[string]string{"created-by": "rds-maint-machine"}, tags),
 	})
 	if err != nil {
 		// Check if it already exists
 		if strings.Contains(err.Error(), "DBParameterGroupAlreadyExists") {
-			return nil // Already exists, that's fine
+			// Reuse it, but make sure it carries this operation's tags too
+			if len(tags) == 0 {
+				return nil
+			}
+			out, err := c.rds.DescribeDBClusterParameterGroups(ctx, &rds.DescribeDBClusterParameterGroupsInput{
+				DBClusterParameterGroupName: aws.String(name),
+			})
+			if err != nil {
+				return errors.Wrap(err, "describe existing parameter group")
+			}
+			if len(out.DBClusterParameterGroups) == 0 {
+				return errors.Errorf("parameter group %s not found", name)
+			}
+			return c.AddTagsToResource(ctx, aws.ToString(out.DBClusterParameterGroups[0].DBClusterParameterGroupArn), tags)
 		}
 		return errors.Wrap(err, "create parameter group")
 	}
 	return nil
 }
 
-// ModifyClusterParameterGroupParams sets parameters on a cluster parameter group.
+// ModifyClusterParameterGroupParams sets parameters on a cluster parameter
+// group. The values are first validated against the parameters the group
+// defines, and if any is invalid none is applied and a
+// ParameterValidationError names each invalid one.
 func (c *Client) ModifyClusterParameterGroupParams(ctx context.Context, parameterGroupName string, params []ParameterInfo) error {
 	if len(params) == 0 {
 		return nil
 	}
 
+	defined, err := c.clusterParameterDefinitions(ctx, parameterGroupName)
+	if err := c.validateParameters(parameterGroupName, params, defined, err); err != nil {
+		return err
+	}
+
 	// AWS allows max 20 parameters per call
 	const batchSize = 20
 	for i := 0; i < len(params); i += batchSize {
@@ -658,6 +885,42 @@ func (c *Client) ModifyClusterParameterGroupParams(ctx context.Context, paramete
 	return nil
 }
 
+// clusterParameterDefinitions returns every parameter a cluster parameter
+// group defines, keyed by name, with its data type and allowed values.
+func (c *Client) clusterParameterDefinitions(ctx context.Context, parameterGroupName string) (map[string]ParameterInfo, error) {
+	defined := make(map[string]ParameterInfo)
+	paginator := rds.NewDescribeDBClusterParametersPaginator(c.rds, &rds.DescribeDBClusterParametersInput{
+		DBClusterParameterGroupName: aws.String(parameterGroupName),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe parameters")
+		}
+		for _, param := range out.Parameters {
+			defined[aws.ToString(param.ParameterName)] = parameterInfo(param)
+		}
+	}
+	return defined, nil
+}
+
+// validateParameters checks params against the parameters their group
+// defines, as looked up with lookupErr. The lookup is best effort: if it
+// failed or found nothing to check against, the values are sent unvalidated
+// and RDS has the final say.
+func (c *Client) validateParameters(parameterGroupName string, params []ParameterInfo, defined map[string]ParameterInfo, lookupErr error) error {
+	if lookupErr != nil {
+		c.logger.Warn("parameter definition lookup failed, applying parameters without validation",
+			"parameter_group", parameterGroupName,
+			"error", lookupErr)
+		return nil
+	}
+	if len(defined) == 0 {
+		return nil
+	}
+	return errors.Wrapf(ValidateParameters(params, defined), "parameter group %s", parameterGroupName)
+}
+
 // ParameterGroupExists checks if a parameter group exists.
 func (c *Client) ParameterGroupExists(ctx context.Context, name string) (bool, error) {
 	_, err := c.rds.DescribeDBClusterParameterGroups(ctx, &rds.DescribeDBClusterParameterGroupsInput{
@@ -672,23 +935,20 @@ func (c *Client) ParameterGroupExists(ctx context.Context, name string) (bool, e
 	return true, nil
 }
 
-// GetDefaultParameterGroupFamily returns the default parameter group family for a given engine version.
-// For example: aurora-postgresql15 -> default.aurora-postgresql15
+// GetDefaultParameterGroupFamily returns the parameter group family of an
+// engine version, e.g. aurora-postgresql15 for Aurora PostgreSQL 15.4 and
+// aurora-mysql8.0 for Aurora MySQL 8.0.mysql_aurora.3.04.0.
 func GetDefaultParameterGroupFamily(engine, version string) string {
-	// Extract major version
-	majorVersion := version
-	if idx := strings.Index(version, "."); idx > 0 {
-		majorVersion = version[:idx]
-	}
+	return engine + EngineMajorVersion(engine, version)
+}
 
-	// Handle aurora-postgresql and aurora-mysql
-	if strings.HasPrefix(engine, "aurora-postgresql") {
-		return fmt.Sprintf("aurora-postgresql%s", majorVersion)
-	} else if strings.HasPrefix(engine, "aurora-mysql") {
-		// Aurora MySQL uses different versioning
-		return fmt.Sprintf("aurora-mysql%s", majorVersion)
+// MajorVersion returns the major component of an engine version
+// (e.g., "15" for "15.4").
+func MajorVersion(version string) string {
+	if idx := strings.Index(version, "."); idx > 0 {
+		return version[:idx]
 	}
-	return fmt.Sprintf("%s%s", engine, majorVersion)
+	return version
 }
 
 // GetDefaultParameterGroupName returns the default parameter group name for a family.
@@ -720,17 +980,19 @@ func (c *Client) GetInstanceParameterGroup(ctx context.Context, instanceID strin
 		return nil, errors.New("instance has no associated parameter group")
 	}
 
-	pgName := aws.ToString(instance.DBParameterGroups[0].DBParameterGroupName)
+	return c.DescribeInstanceParameterGroup(ctx, aws.ToString(instance.DBParameterGroups[0].DBParameterGroupName))
+}
 
-	// Get parameter group details
+// DescribeInstanceParameterGroup returns a DB instance parameter group by name.
+func (c *Client) DescribeInstanceParameterGroup(ctx context.Context, name string) (*InstanceParameterGroupInfo, error) {
 	pgOut, err := c.rds.DescribeDBParameterGroups(ctx, &rds.DescribeDBParameterGroupsInput{
-		DBParameterGroupName: aws.String(pgName),
+		DBParameterGroupName: aws.String(name),
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "describe parameter group")
 	}
 	if len(pgOut.DBParameterGroups) == 0 {
-		return nil, errors.Errorf("parameter group %s not found", pgName)
+		return nil, errors.Errorf("parameter group %s not found", name)
 	}
 
 	pg := pgOut.DBParameterGroups[0]
@@ -759,13 +1021,7 @@ func (c *Client) GetInstanceParameterGroupCustomParameters(ctx context.Context,
 
 		for _, param := range out.Parameters {
 			if param.ParameterValue != nil {
-				customParams = append(customParams, ParameterInfo{
-					Name:         aws.ToString(param.ParameterName),
-					Value:        aws.ToString(param.ParameterValue),
-					ApplyType:    aws.ToString(param.ApplyType),
-					IsModifiable: aws.ToBool(param.IsModifiable),
-					Source:       aws.ToString(param.Source),
-				})
+				customParams = append(customParams, parameterInfo(param))
 			}
 		}
 	}
@@ -774,31 +1030,49 @@ func (c *Client) GetInstanceParameterGroupCustomParameters(ctx context.Context,
 }
 
 // CreateInstanceParameterGroup creates a new DB instance parameter group.
-func (c *Client) CreateInstanceParameterGroup(ctx context.Context, name, family, description string) error {
+func (c *Client) CreateInstanceParameterGroup(ctx context.Context, name, family, description string, tags map[string]string) error {
 	_, err := c.rds.CreateDBParameterGroup(ctx, &rds.CreateDBParameterGroupInput{
 		DBParameterGroupName:   aws.String(name),
 		DBParameterGroupFamily: aws.String(family),
 		Description:            aws.String(description),
-		Tags: []types.Tag{
-			{Key: aws.String("created-by"), Value: aws.String("rds-maint-machine")},
-		},
+		Tags:                   MergeTags(map[string]string{"created-by": "rds-maint-machine"}, tags),
 	})
 	if err != nil {
 		// Check if it already exists
 		if strings.Contains(err.Error(), "DBParameterGroupAlreadyExists") {
-			return nil // Already exists, that's fine
+			// Reuse it, but make sure it carries this operation's tags too
+			if len(tags) == 0 {
+				return nil
+			}
+			out, err := c.rds.DescribeDBParameterGroups(ctx, &rds.DescribeDBParameterGroupsInput{
+				DBParameterGroupName: aws.String(name),
+			})
+			if err != nil {
+				return errors.Wrap(err, "describe existing parameter group")
+			}
+			if len(out.DBParameterGroups) == 0 {
+				return errors.Errorf("parameter group %s not found", name)
+			}
+			return c.AddTagsToResource(ctx, aws.ToString(out.DBParameterGroups[0].DBParameterGroupArn), tags)
 		}
 		return errors.Wrap(err, "create parameter group")
 	}
 	return nil
 }
 
-// ModifyInstanceParameterGroupParams sets parameters on a DB instance parameter group.
+// ModifyInstanceParameterGroupParams sets parameters on a DB instance
+// parameter group, validating them first as ModifyClusterParameterGroupParams
+// does.
 func (c *Client) ModifyInstanceParameterGroupParams(ctx context.Context, parameterGroupName string, params []ParameterInfo) error {
 	if len(params) == 0 {
 		return nil
 	}
 
+	defined, err := c.instanceParameterDefinitions(ctx, parameterGroupName)
+	if err := c.validateParameters(parameterGroupName, params, defined, err); err != nil {
+		return err
+	}
+
 	// AWS allows max 20 parameters per call
 	const batchSize = 20
 	for i := 0; i < len(params); i += batchSize {
@@ -833,6 +1107,26 @@ func (c *Client) ModifyInstanceParameterGroupParams(ctx context.Context, paramet
 	return nil
 }
 
+// instanceParameterDefinitions returns every parameter a DB instance
+// parameter group defines, keyed by name, with its data type and allowed
+// values.
+func (c *Client) instanceParameterDefinitions(ctx context.Context, parameterGroupName string) (map[string]ParameterInfo, error) {
+	defined := make(map[string]ParameterInfo)
+	paginator := rds.NewDescribeDBParametersPaginator(c.rds, &rds.DescribeDBParametersInput{
+		DBParameterGroupName: aws.String(parameterGroupName),
+	})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe parameters")
+		}
+		for _, param := range out.Parameters {
+			defined[aws.ToString(param.ParameterName)] = parameterInfo(param)
+		}
+	}
+	return defined, nil
+}
+
 // InstanceParameterGroupExists checks if a DB instance parameter group exists.
 func (c *Client) InstanceParameterGroupExists(ctx context.Context, name string) (bool, error) {
 	_, err := c.rds.DescribeDBParameterGroups(ctx, &rds.DescribeDBParameterGroupsInput{
@@ -1211,6 +1505,39 @@ func (c *Client) GetOrderableInstanceTypes(ctx context.Context, engine, engineVe
 	return result, nil
 }
 
+// GetSupportedStorageTypes returns the storage types that can be ordered for each
+// instance class of the given engine and version, keyed by instance class.
+// Only options that support Aurora cluster (provisioned) mode are included.
+func (c *Client) GetSupportedStorageTypes(ctx context.Context, engine, engineVersion string) (map[string][]string, error) {
+	supported := make(map[string][]string)
+
+	paginator := rds.NewDescribeOrderableDBInstanceOptionsPaginator(c.rds, &rds.DescribeOrderableDBInstanceOptionsInput{
+		Engine:        aws.String(engine),
+		EngineVersion: aws.String(engineVersion),
+	})
+
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe orderable db instance options")
+		}
+
+		for _, opt := range out.OrderableDBInstanceOptions {
+			if !slices.Contains(opt.SupportedEngineModes, "provisioned") {
+				continue
+			}
+			instanceClass := aws.ToString(opt.DBInstanceClass)
+			storageType := aws.ToString(opt.StorageType)
+			if storageType == "" || slices.Contains(supported[instanceClass], storageType) {
+				continue
+			}
+			supported[instanceClass] = append(supported[instanceClass], storageType)
+		}
+	}
+
+	return supported, nil
+}
+
 // sortInstanceTypes sorts instance types in a logical order (by family then size).
 func sortInstanceTypes(types []OrderableInstanceType) {
 	// Define size order within a family
@@ -1572,19 +1899,54 @@ type BlueGreenPrerequisites struct {
 	LogicalReplicationEnabled bool   `json:"logical_replication_enabled"`
 	ParameterGroupName        string `json:"parameter_group_name"`
 	Engine                    string `json:"engine"`
+	EngineVersion             string `json:"engine_version"`
+	TargetEngineVersion       string `json:"target_engine_version,omitempty"`
 	// MissingParameter is the name of the parameter that needs to be set (for error messages)
 	MissingParameter string `json:"missing_parameter,omitempty"`
+	// Checks lists each prerequisite that was evaluated.
+	Checks []PrerequisiteCheck `json:"checks"`
+}
+
+// PrerequisiteCheck is the outcome of a single Blue-Green prerequisite.
+type PrerequisiteCheck struct {
+	Name        string `json:"name"`
+	Passed      bool   `json:"passed"`
+	Required    bool   `json:"required"` // a failed required check blocks the upgrade
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Prerequisite check names.
+const (
+	PrereqLogicalReplication = "logical_replication"
+	PrereqUpgradeTarget      = "upgrade_target"
+	PrereqClusterAvailable   = "cluster_available"
+)
+
+// FailedRequired returns the required checks that did not pass.
+func (p *BlueGreenPrerequisites) FailedRequired() []PrerequisiteCheck {
+	var failed []PrerequisiteCheck
+	for _, check := range p.Checks {
+		if check.Required && !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
 }
 
 // CheckBlueGreenPrerequisites checks if a cluster meets the prerequisites for Blue-Green deployments.
 // For Aurora PostgreSQL, this requires rds.logical_replication = 1.
 // For Aurora MySQL, this requires binlog_format = ROW.
-func (c *Client) CheckBlueGreenPrerequisites(ctx context.Context, clusterID string) (*BlueGreenPrerequisites, error) {
+// If targetVersion is set, it must also be a valid upgrade target for the cluster's current version.
+func (c *Client) CheckBlueGreenPrerequisites(ctx context.Context, clusterID, targetVersion string) (*BlueGreenPrerequisites, error) {
 	// Get cluster info to determine engine type
 	out, err := c.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
 		DBClusterIdentifier: aws.String(clusterID),
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterNotFound") {
+			return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+		}
 		return nil, errors.Wrap(err, "describe cluster")
 	}
 	if len(out.DBClusters) == 0 {
@@ -1596,26 +1958,42 @@ func (c *Client) CheckBlueGreenPrerequisites(ctx context.Context, clusterID stri
 	pgName := aws.ToString(cluster.DBClusterParameterGroup)
 
 	result := &BlueGreenPrerequisites{
-		ParameterGroupName: pgName,
-		Engine:             engine,
+		ParameterGroupName:  pgName,
+		Engine:              engine,
+		EngineVersion:       aws.ToString(cluster.EngineVersion),
+		TargetEngineVersion: targetVersion,
 	}
 
 	// Get all parameters (not just user-modified) to check logical replication
 	// We need to check the effective value, which includes defaults
+	var expectedValue string
 	if strings.HasPrefix(engine, "aurora-postgresql") {
 		result.MissingParameter = "rds.logical_replication"
-		enabled, err := c.checkParameterValue(ctx, pgName, "rds.logical_replication", "1")
-		if err != nil {
-			return nil, errors.Wrap(err, "check rds.logical_replication parameter")
-		}
-		result.LogicalReplicationEnabled = enabled
+		expectedValue = "1"
 	} else if strings.HasPrefix(engine, "aurora-mysql") {
 		result.MissingParameter = "binlog_format"
-		enabled, err := c.checkParameterValue(ctx, pgName, "binlog_format", "ROW")
+		expectedValue = "ROW"
+	}
+
+	if result.MissingParameter != "" {
+		enabled, err := c.checkParameterValue(ctx, pgName, result.MissingParameter, expectedValue)
 		if err != nil {
-			return nil, errors.Wrap(err, "check binlog_format parameter")
+			return nil, errors.Wrapf(err, "check %s parameter", result.MissingParameter)
 		}
 		result.LogicalReplicationEnabled = enabled
+
+		check := PrerequisiteCheck{
+			Name:     PrereqLogicalReplication,
+			Passed:   enabled,
+			Required: true,
+			Message:  fmt.Sprintf("%s is %s in parameter group %s", result.MissingParameter, expectedValue, pgName),
+		}
+		if !enabled {
+			check.Message = fmt.Sprintf("%s is not %s in parameter group %s", result.MissingParameter, expectedValue, pgName)
+			check.Remediation = fmt.Sprintf("Set %s to %s in cluster parameter group %s and reboot the writer instance",
+				result.MissingParameter, expectedValue, pgName)
+		}
+		result.Checks = append(result.Checks, check)
 	} else {
 		// For other engines, assume prerequisites are met
 		result.LogicalReplicationEnabled = true
@@ -1625,9 +2003,56 @@ func (c *Client) CheckBlueGreenPrerequisites(ctx context.Context, clusterID stri
 		result.MissingParameter = "" // Clear if enabled
 	}
 
+	if targetVersion != "" {
+		check, err := c.checkUpgradeTarget(ctx, engine, result.EngineVersion, targetVersion)
+		if err != nil {
+			return nil, err
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	status := aws.ToString(cluster.Status)
+	check := PrerequisiteCheck{
+		Name:    PrereqClusterAvailable,
+		Passed:  status == "available",
+		Message: fmt.Sprintf("cluster status is %s", status),
+	}
+	if !check.Passed {
+		check.Remediation = "Wait for the cluster to become available; the upgrade waits for this before creating the deployment"
+	}
+	result.Checks = append(result.Checks, check)
+
 	return result, nil
 }
 
+// checkUpgradeTarget checks that targetVersion is a valid Blue-Green upgrade
+// target for the engine version.
+func (c *Client) checkUpgradeTarget(ctx context.Context, engine, engineVersion, targetVersion string) (PrerequisiteCheck, error) {
+	check := PrerequisiteCheck{Name: PrereqUpgradeTarget, Required: true}
+
+	targets, err := c.GetValidUpgradeTargets(ctx, engine, engineVersion)
+	if err != nil {
+		return check, errors.Wrap(err, "get valid upgrade targets")
+	}
+	for _, target := range targets {
+		if target.EngineVersion != targetVersion {
+			continue
+		}
+		if !target.SupportsBlueGreen {
+			check.Message = fmt.Sprintf("%s does not support Blue-Green upgrades from %s", targetVersion, engineVersion)
+			check.Remediation = "Choose a target version that supports Blue-Green deployments"
+			return check, nil
+		}
+		check.Passed = true
+		check.Message = fmt.Sprintf("%s is a valid upgrade target from %s", targetVersion, engineVersion)
+		return check, nil
+	}
+
+	check.Message = fmt.Sprintf("%s is not a valid upgrade target from %s", targetVersion, engineVersion)
+	check.Remediation = "Choose one of the versions listed by the upgrade targets endpoint"
+	return check, nil
+}
+
 // checkParameterValue checks if a specific cluster parameter has the expected value.
 func (c *Client) checkParameterValue(ctx context.Context, parameterGroupName, parameterName, expectedValue string) (bool, error) {
 	paginator := rds.NewDescribeDBClusterParametersPaginator(c.rds, &rds.DescribeDBClusterParametersInput{
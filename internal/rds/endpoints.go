@@ -0,0 +1,100 @@
+package rds
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// EndpointTypeCustom is the EndpointType of Aurora custom endpoints.
+const EndpointTypeCustom = "CUSTOM"
+
+// ListClusterEndpoints returns every endpoint of a cluster: its writer and
+// reader endpoints and any custom endpoints.
+func (c *Client) ListClusterEndpoints(ctx context.Context, clusterID string) ([]internaltypes.ClusterEndpoint, error) {
+	endpoints := []internaltypes.ClusterEndpoint{}
+	paginator := rds.NewDescribeDBClusterEndpointsPaginator(c.rds, &rds.DescribeDBClusterEndpointsInput{
+		DBClusterIdentifier: aws.String(clusterID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if strings.Contains(err.Error(), "DBClusterNotFound") {
+				return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+			}
+			return nil, errors.Wrapf(err, "describe endpoints of %s", clusterID)
+		}
+		for _, ep := range page.DBClusterEndpoints {
+			endpoints = append(endpoints, clusterEndpoint(ep))
+		}
+	}
+	return endpoints, nil
+}
+
+// ModifyClusterEndpoint sets the members of a custom endpoint. RDS keeps
+// either a static or an exclusion list, so setting one clears the other; pass
+// a non-nil empty excludedMembers to clear the exclusion list.
+func (c *Client) ModifyClusterEndpoint(ctx context.Context, endpointID string, staticMembers, excludedMembers []string) (*internaltypes.ClusterEndpoint, error) {
+	out, err := c.rds.ModifyDBClusterEndpoint(ctx, &rds.ModifyDBClusterEndpointInput{
+		DBClusterEndpointIdentifier: aws.String(endpointID),
+		StaticMembers:               staticMembers,
+		ExcludedMembers:             excludedMembers,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterEndpointNotFound") {
+			return nil, errors.Wrapf(internalerrors.ErrNotFound, "cluster endpoint %s", endpointID)
+		}
+		return nil, errors.Wrapf(err, "modify endpoint %s", endpointID)
+	}
+	endpoint := clusterEndpoint(types.DBClusterEndpoint{
+		DBClusterEndpointIdentifier: out.DBClusterEndpointIdentifier,
+		DBClusterIdentifier:         out.DBClusterIdentifier,
+		Endpoint:                    out.Endpoint,
+		Status:                      out.Status,
+		EndpointType:                out.EndpointType,
+		CustomEndpointType:          out.CustomEndpointType,
+		StaticMembers:               out.StaticMembers,
+		ExcludedMembers:             out.ExcludedMembers,
+	})
+	return &endpoint, nil
+}
+
+// clusterEndpoint converts an SDK endpoint description.
+func clusterEndpoint(ep types.DBClusterEndpoint) internaltypes.ClusterEndpoint {
+	return internaltypes.ClusterEndpoint{
+		Identifier:         aws.ToString(ep.DBClusterEndpointIdentifier),
+		ClusterID:          aws.ToString(ep.DBClusterIdentifier),
+		Endpoint:           aws.ToString(ep.Endpoint),
+		Status:             aws.ToString(ep.Status),
+		EndpointType:       aws.ToString(ep.EndpointType),
+		CustomEndpointType: aws.ToString(ep.CustomEndpointType),
+		StaticMembers:      ep.StaticMembers,
+		ExcludedMembers:    ep.ExcludedMembers,
+	}
+}
+
+// customEndpoints returns the custom endpoints of a cluster for
+// GetClusterInfo. The lookup is best effort: a caller without
+// rds:DescribeDBClusterEndpoints still gets the rest of the cluster.
+func (c *Client) customEndpoints(ctx context.Context, clusterID string) []internaltypes.ClusterEndpoint {
+	endpoints, err := c.ListClusterEndpoints(ctx, clusterID)
+	if err != nil {
+		c.logger.Warn("cluster endpoint lookup failed, omitting custom endpoints",
+			"cluster_id", clusterID,
+			"error", err)
+		return nil
+	}
+	var custom []internaltypes.ClusterEndpoint
+	for _, ep := range endpoints {
+		if ep.EndpointType == EndpointTypeCustom {
+			custom = append(custom, ep)
+		}
+	}
+	return custom
+}
@@ -0,0 +1,79 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_ClusterEndpoints(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	endpoints, err := client.ListClusterEndpoints(ctx, "demo-upgrade")
+	if err != nil {
+		t.Fatalf("ListClusterEndpoints failed: %v", err)
+	}
+	var kinds []string
+	for _, ep := range endpoints {
+		kinds = append(kinds, ep.EndpointType)
+	}
+	if !slices.Equal(kinds, []string{"WRITER", "READER", EndpointTypeCustom}) {
+		t.Fatalf("endpoint types = %v, want the writer, reader and one custom endpoint", kinds)
+	}
+
+	info, err := client.GetClusterInfo(ctx, "demo-upgrade")
+	if err != nil {
+		t.Fatalf("GetClusterInfo failed: %v", err)
+	}
+	if len(info.CustomEndpoints) != 1 {
+		t.Fatalf("custom endpoints = %+v, want demo-upgrade-analytics only", info.CustomEndpoints)
+	}
+	custom := info.CustomEndpoints[0]
+	if custom.Identifier != "demo-upgrade-analytics" || custom.CustomEndpointType != "READER" ||
+		!slices.Equal(custom.StaticMembers, []string{"demo-upgrade-reader-1"}) {
+		t.Errorf("custom endpoint = %+v", custom)
+	}
+
+	// Switching to an exclusion list clears the static members, and an
+	// empty exclusion list clears that in turn.
+	updated, err := client.ModifyClusterEndpoint(ctx, custom.Identifier, nil, []string{"demo-upgrade-writer"})
+	if err != nil {
+		t.Fatalf("ModifyClusterEndpoint failed: %v", err)
+	}
+	if len(updated.StaticMembers) != 0 || !slices.Equal(updated.ExcludedMembers, []string{"demo-upgrade-writer"}) {
+		t.Errorf("after excluding the writer: %+v", updated)
+	}
+	if updated, err = client.ModifyClusterEndpoint(ctx, custom.Identifier, nil, []string{}); err != nil {
+		t.Fatalf("ModifyClusterEndpoint failed: %v", err)
+	}
+	if len(updated.StaticMembers) != 0 || len(updated.ExcludedMembers) != 0 {
+		t.Errorf("after clearing the exclusions: %+v", updated)
+	}
+
+	if _, err := client.ModifyClusterEndpoint(ctx, custom.Identifier, []string{"demo-multi-writer"}, nil); err == nil {
+		t.Error("endpoint accepted an instance of another cluster")
+	}
+	if _, err := client.ModifyClusterEndpoint(ctx, "missing", []string{"demo-upgrade-writer"}, nil); !internalerrors.IsNotFound(err) {
+		t.Errorf("unknown endpoint: error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,46 @@
+package rds
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+)
+
+// GetRecentResourceEvents returns the RDS events for a cluster or instance
+// since the given time, oldest first. DescribeEvents only filters by
+// identifier together with a source type, so the resource is looked up as
+// both a cluster and an instance and the results are merged.
+func (c *Client) GetRecentResourceEvents(ctx context.Context, resourceID string, since time.Time) ([]RDSEvent, error) {
+	var events []RDSEvent
+	for _, sourceType := range []types.SourceType{types.SourceTypeDbCluster, types.SourceTypeDbInstance} {
+		paginator := rds.NewDescribeEventsPaginator(c.rds, &rds.DescribeEventsInput{
+			SourceIdentifier: aws.String(resourceID),
+			SourceType:       sourceType,
+			StartTime:        aws.Time(since),
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "describe %s events for %s", sourceType, resourceID)
+			}
+			for _, e := range out.Events {
+				events = append(events, RDSEvent{
+					Date:          aws.ToTime(e.Date),
+					SourceID:      aws.ToString(e.SourceIdentifier),
+					SourceType:    string(e.SourceType),
+					Message:       aws.ToString(e.Message),
+					EventCategory: strings.Join(e.EventCategories, ", "),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events, nil
+}
@@ -0,0 +1,54 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_GetRecentResourceEvents(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+		Logger:  logger,
+	})
+
+	now := time.Now()
+	for _, event := range []mock.MockEvent{
+		{SourceID: "demo-single", SourceType: mock.EventSourceCluster, Message: "too old", Date: now.Add(-time.Hour)},
+		{SourceID: "demo-single", SourceType: mock.EventSourceCluster, Message: "cluster event", Date: now.Add(-2 * time.Minute)},
+		{SourceID: "demo-single", SourceType: mock.EventSourceInstance, Message: "same name, other type", Date: now.Add(-3 * time.Minute)},
+		{SourceID: "demo-multi", SourceType: mock.EventSourceCluster, Message: "other cluster", Date: now.Add(-time.Minute)},
+	} {
+		state.RecordEvent(event)
+	}
+
+	events, err := client.GetRecentResourceEvents(context.Background(), "demo-single", now.Add(-10*time.Minute))
+	if err != nil {
+		t.Fatalf("GetRecentResourceEvents failed: %v", err)
+	}
+	var messages []string
+	for _, event := range events {
+		messages = append(messages, event.Message)
+	}
+	if len(messages) != 2 || messages[0] != "same name, other type" || messages[1] != "cluster event" {
+		t.Errorf("messages = %q, want both source types of demo-single since the cutoff, oldest first", messages)
+	}
+}
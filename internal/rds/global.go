@@ -0,0 +1,79 @@
+package rds
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/cockroachdb/errors"
+)
+
+// GlobalClusterInfo describes the Aurora Global Database a cluster belongs to.
+type GlobalClusterInfo struct {
+	GlobalClusterID string                `json:"global_cluster_id"`
+	Engine          string                `json:"engine"`
+	EngineVersion   string                `json:"engine_version"`
+	Status          string                `json:"status"`
+	Members         []GlobalClusterMember `json:"members"`
+	// IsPrimary reports whether the cluster that was looked up is the
+	// global database's writer (primary) cluster.
+	IsPrimary bool `json:"is_primary"`
+}
+
+// GlobalClusterMember is one regional cluster of a global database.
+type GlobalClusterMember struct {
+	ClusterARN string `json:"cluster_arn"`
+	IsWriter   bool   `json:"is_writer"`
+}
+
+// Secondaries returns the ARNs of the global database's secondary clusters.
+func (g *GlobalClusterInfo) Secondaries() []string {
+	var arns []string
+	for _, m := range g.Members {
+		if !m.IsWriter {
+			arns = append(arns, m.ClusterARN)
+		}
+	}
+	return arns
+}
+
+// GetGlobalClusterForCluster returns the global database the cluster is a
+// member of, or nil if it is not part of one.
+func (c *Client) GetGlobalClusterForCluster(ctx context.Context, clusterID string) (*GlobalClusterInfo, error) {
+	clusterARN, err := c.GetClusterARN(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := rds.NewDescribeGlobalClustersPaginator(c.rds, &rds.DescribeGlobalClustersInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe global clusters")
+		}
+		for _, gc := range page.GlobalClusters {
+			info := &GlobalClusterInfo{
+				GlobalClusterID: aws.ToString(gc.GlobalClusterIdentifier),
+				Engine:          aws.ToString(gc.Engine),
+				EngineVersion:   aws.ToString(gc.EngineVersion),
+				Status:          aws.ToString(gc.Status),
+			}
+			found := false
+			for _, m := range gc.GlobalClusterMembers {
+				member := GlobalClusterMember{
+					ClusterARN: aws.ToString(m.DBClusterArn),
+					IsWriter:   aws.ToBool(m.IsWriter),
+				}
+				if member.ClusterARN == clusterARN {
+					found = true
+					info.IsPrimary = member.IsWriter
+				}
+				info.Members = append(info.Members, member)
+			}
+			if found {
+				return info, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,119 @@
+package rds
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// PendingMaintenanceAction is a maintenance action AWS has scheduled for a
+// cluster or one of its instances.
+type PendingMaintenanceAction struct {
+	ResourceARN          string     `json:"resource_arn"`
+	ResourceID           string     `json:"resource_id"`
+	ResourceType         string     `json:"resource_type"` // "cluster" or "instance"
+	Action               string     `json:"action"`        // e.g. "system-update", "db-upgrade"
+	Description          string     `json:"description,omitempty"`
+	AutoAppliedAfterDate *time.Time `json:"auto_applied_after_date,omitempty"`
+	ForcedApplyDate      *time.Time `json:"forced_apply_date,omitempty"`
+	CurrentApplyDate     *time.Time `json:"current_apply_date,omitempty"`
+	OptInStatus          string     `json:"opt_in_status,omitempty"` // "immediate", "next-maintenance" or empty if not opted in
+}
+
+// GetPendingMaintenanceActions returns the pending maintenance actions for a
+// cluster and all of its instances, cluster actions first. A cluster with
+// nothing pending returns an empty list.
+func (c *Client) GetPendingMaintenanceActions(ctx context.Context, clusterID string) ([]PendingMaintenanceAction, error) {
+	out, err := c.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(clusterID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterNotFound") {
+			return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+		}
+		return nil, errors.Wrap(err, "describe clusters")
+	}
+	if len(out.DBClusters) == 0 {
+		return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+	}
+
+	actions, err := c.describePendingMaintenance(ctx, "db-cluster-id", []string{clusterID}, "cluster")
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := make([]string, 0, len(out.DBClusters[0].DBClusterMembers))
+	for _, member := range out.DBClusters[0].DBClusterMembers {
+		instanceIDs = append(instanceIDs, aws.ToString(member.DBInstanceIdentifier))
+	}
+	if len(instanceIDs) > 0 {
+		instanceActions, err := c.describePendingMaintenance(ctx, "db-instance-id", instanceIDs, "instance")
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, instanceActions...)
+	}
+
+	return actions, nil
+}
+
+// describePendingMaintenance lists pending maintenance actions matching one
+// DescribePendingMaintenanceActions filter, following pagination.
+func (c *Client) describePendingMaintenance(ctx context.Context, filterName string, values []string, resourceType string) ([]PendingMaintenanceAction, error) {
+	actions := []PendingMaintenanceAction{}
+	paginator := rds.NewDescribePendingMaintenanceActionsPaginator(c.rds, &rds.DescribePendingMaintenanceActionsInput{
+		Filters: []types.Filter{{Name: aws.String(filterName), Values: values}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe pending maintenance actions")
+		}
+		for _, resource := range page.PendingMaintenanceActions {
+			arn := aws.ToString(resource.ResourceIdentifier)
+			for _, detail := range resource.PendingMaintenanceActionDetails {
+				actions = append(actions, PendingMaintenanceAction{
+					ResourceARN:          arn,
+					ResourceID:           resourceIDFromARN(arn),
+					ResourceType:         resourceType,
+					Action:               aws.ToString(detail.Action),
+					Description:          aws.ToString(detail.Description),
+					AutoAppliedAfterDate: detail.AutoAppliedAfterDate,
+					ForcedApplyDate:      detail.ForcedApplyDate,
+					CurrentApplyDate:     detail.CurrentApplyDate,
+					OptInStatus:          aws.ToString(detail.OptInStatus),
+				})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// ApplyPendingMaintenanceAction applies a pending maintenance action to a
+// cluster or instance immediately rather than in its next maintenance window.
+func (c *Client) ApplyPendingMaintenanceAction(ctx context.Context, resourceARN, action string) error {
+	_, err := c.rds.ApplyPendingMaintenanceAction(ctx, &rds.ApplyPendingMaintenanceActionInput{
+		ResourceIdentifier: aws.String(resourceARN),
+		ApplyAction:        aws.String(action),
+		OptInType:          aws.String("immediate"),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "apply pending maintenance action %s to %s", action, resourceARN)
+	}
+	return nil
+}
+
+// resourceIDFromARN returns the identifier at the end of a cluster or instance
+// ARN (arn:aws:rds:region:account:cluster:id or ...:db:id).
+func resourceIDFromARN(arn string) string {
+	if i := strings.LastIndex(arn, ":"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
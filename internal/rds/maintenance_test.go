@@ -0,0 +1,74 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_PendingMaintenanceActions(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	if err := state.AddPendingMaintenanceAction("demo-multi", mock.MockPendingMaintenanceAction{
+		Action:               "db-upgrade",
+		AutoAppliedAfterDate: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("AddPendingMaintenanceAction failed: %v", err)
+	}
+
+	actions, err := client.GetPendingMaintenanceActions(ctx, "demo-multi")
+	if err != nil {
+		t.Fatalf("GetPendingMaintenanceActions failed: %v", err)
+	}
+	if len(actions) != 3 {
+		t.Fatalf("got %d actions, want 3: %+v", len(actions), actions)
+	}
+	if actions[0].ResourceType != "cluster" || actions[0].ResourceID != "demo-multi" || actions[0].Action != "db-upgrade" {
+		t.Errorf("first action = %+v, want the cluster db-upgrade", actions[0])
+	}
+	for _, a := range actions[1:] {
+		if a.ResourceType != "instance" || a.Action != "system-update" || a.AutoAppliedAfterDate == nil {
+			t.Errorf("instance action = %+v, want a dated system-update", a)
+		}
+	}
+
+	// Applying an action removes it from the pending list.
+	if err := client.ApplyPendingMaintenanceAction(ctx, actions[0].ResourceARN, actions[0].Action); err != nil {
+		t.Fatalf("ApplyPendingMaintenanceAction failed: %v", err)
+	}
+	actions, err = client.GetPendingMaintenanceActions(ctx, "demo-multi")
+	if err != nil {
+		t.Fatalf("GetPendingMaintenanceActions failed: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Errorf("got %d actions after applying one, want 2", len(actions))
+	}
+
+	// A cluster with nothing pending is an empty list, not an error.
+	actions, err = client.GetPendingMaintenanceActions(ctx, "demo-single")
+	if err != nil {
+		t.Fatalf("GetPendingMaintenanceActions failed: %v", err)
+	}
+	if actions == nil || len(actions) != 0 {
+		t.Errorf("got %#v, want an empty list", actions)
+	}
+}
@@ -2,12 +2,15 @@ package rds
 
 import (
 	"context"
+	"log/slog"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/autoscaling"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/cloudwatch"
 )
 
 // ClientManager manages RDS clients for multiple regions.
@@ -15,10 +18,16 @@ import (
 type ClientManager struct {
 	mu         sync.RWMutex
 	clients    map[string]*Client
+	alarms     map[string]*cloudwatch.Client
+	scaling    map[string]*autoscaling.Client
 	baseConfig aws.Config
 	profile    string
 	demoMode   bool
 	baseURL    string // for demo mode
+	timeouts   CallTimeouts
+	retry      RetryConfig
+	tagLookup  TagLookupConfig
+	logger     *slog.Logger
 }
 
 // ClientManagerConfig contains configuration for the ClientManager.
@@ -31,25 +40,51 @@ type ClientManagerConfig struct {
 	DemoMode bool
 	// BaseURL is the mock server URL for demo mode.
 	BaseURL string
+	// CallTimeouts bounds individual RDS API calls made by every client.
+	CallTimeouts CallTimeouts
+	// Retry controls SDK retries for every client. Demo mode ignores it and
+	// fails fast.
+	Retry RetryConfig
+	// TagLookup bounds the tag lookups made while describing a cluster.
+	TagLookup TagLookupConfig
+	// Logger receives warnings from the clients (optional).
+	Logger *slog.Logger
 }
 
 // NewClientManager creates a new ClientManager.
 func NewClientManager(cfg ClientManagerConfig) *ClientManager {
 	return &ClientManager{
 		clients:    make(map[string]*Client),
+		alarms:     make(map[string]*cloudwatch.Client),
+		scaling:    make(map[string]*autoscaling.Client),
 		baseConfig: cfg.BaseConfig,
 		profile:    cfg.Profile,
 		demoMode:   cfg.DemoMode,
 		baseURL:    cfg.BaseURL,
+		timeouts:   cfg.CallTimeouts,
+		retry:      cfg.Retry,
+		tagLookup:  cfg.TagLookup,
+		logger:     cfg.Logger,
 	}
 }
 
+// demoClientKey is the cache key of the one client demo mode uses for every
+// region, since they would all talk to the same mock endpoint.
+const demoClientKey = "demo"
+
 // GetClient returns an RDS client for the specified region.
-// Clients are cached and reused.
+// Clients are created on first use and cached per region, so every operation
+// and request in a region shares one client and its connection pool. In demo
+// mode all regions share a single client for the mock endpoint.
 func (m *ClientManager) GetClient(ctx context.Context, region string) (*Client, error) {
+	key := region
+	if m.demoMode {
+		key = demoClientKey
+	}
+
 	// Check cache first
 	m.mu.RLock()
-	client, ok := m.clients[region]
+	client, ok := m.clients[key]
 	m.mu.RUnlock()
 	if ok {
 		return client, nil
@@ -60,45 +95,129 @@ func (m *ClientManager) GetClient(ctx context.Context, region string) (*Client,
 	defer m.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if client, ok := m.clients[region]; ok {
+	if client, ok := m.clients[key]; ok {
 		return client, nil
 	}
 
-	var awsCfg aws.Config
-	var err error
+	awsCfg, err := m.awsConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
 
+	clientCfg := ClientConfig{
+		AWSConfig:    awsCfg,
+		CallTimeouts: m.timeouts,
+		TagLookup:    m.tagLookup,
+		Logger:       m.logger,
+	}
+	if !m.demoMode {
+		clientCfg.Retry = m.retry
+	}
+	if m.baseURL != "" {
+		clientCfg.BaseURL = m.baseURL
+	}
+
+	client = NewClient(clientCfg)
+	m.clients[key] = client
+
+	return client, nil
+}
+
+// awsConfig returns the AWS configuration clients for region are built from.
+func (m *ClientManager) awsConfig(ctx context.Context, region string) (aws.Config, error) {
 	if m.demoMode {
-		// Demo mode: use anonymous credentials
-		awsCfg = aws.Config{
+		// Demo mode: use anonymous credentials, and the base config's HTTP
+		// client if it has one, e.g. to trust a mock's own CA
+		return aws.Config{
 			Region:           region,
 			RetryMaxAttempts: 1,
 			Credentials:      aws.AnonymousCredentials{},
-		}
-	} else {
-		// Normal mode: load config for the region
-		opts := []func(*awsconfig.LoadOptions) error{
-			awsconfig.WithRegion(region),
-		}
-		if m.profile != "" {
-			opts = append(opts, awsconfig.WithSharedConfigProfile(m.profile))
-		}
+			HTTPClient:       m.baseConfig.HTTPClient,
+		}, nil
+	}
 
-		awsCfg, err = awsconfig.LoadDefaultConfig(ctx, opts...)
-		if err != nil {
-			return nil, errors.Wrapf(err, "load aws config for region %s", region)
-		}
+	// Normal mode: load config for the region
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(region),
+	}
+	if m.profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(m.profile))
 	}
 
-	clientCfg := ClientConfig{
-		AWSConfig: awsCfg,
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, errors.Wrapf(err, "load aws config for region %s", region)
 	}
-	if m.baseURL != "" {
-		clientCfg.BaseURL = m.baseURL
+	return awsCfg, nil
+}
+
+// GetAlarmClient returns a CloudWatch client for reading alarms in the
+// specified region, cached like the RDS clients.
+func (m *ClientManager) GetAlarmClient(ctx context.Context, region string) (*cloudwatch.Client, error) {
+	key := region
+	if m.demoMode {
+		key = demoClientKey
 	}
 
-	client = NewClient(clientCfg)
-	m.clients[region] = client
+	m.mu.RLock()
+	client, ok := m.alarms[key]
+	m.mu.RUnlock()
+	if ok {
+		return client, nil
+	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if client, ok := m.alarms[key]; ok {
+		return client, nil
+	}
+
+	awsCfg, err := m.awsConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	cwCfg := cloudwatch.Config{AWSConfig: awsCfg, BaseURL: m.baseURL}
+	if !m.demoMode {
+		cwCfg.RetryMode = m.retry.Mode
+		cwCfg.RetryMaxAttempts = m.retry.MaxAttempts
+	}
+	client = cloudwatch.NewClient(cwCfg)
+	m.alarms[key] = client
+	return client, nil
+}
+
+// GetAutoScalingClient returns an Application Auto Scaling client for the
+// specified region, cached like the RDS clients.
+func (m *ClientManager) GetAutoScalingClient(ctx context.Context, region string) (*autoscaling.Client, error) {
+	key := region
+	if m.demoMode {
+		key = demoClientKey
+	}
+
+	m.mu.RLock()
+	client, ok := m.scaling[key]
+	m.mu.RUnlock()
+	if ok {
+		return client, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if client, ok := m.scaling[key]; ok {
+		return client, nil
+	}
+
+	awsCfg, err := m.awsConfig(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	scalingCfg := autoscaling.Config{AWSConfig: awsCfg, BaseURL: m.baseURL}
+	if !m.demoMode {
+		scalingCfg.RetryMode = m.retry.Mode
+		scalingCfg.RetryMaxAttempts = m.retry.MaxAttempts
+	}
+	client = autoscaling.NewClient(scalingCfg)
+	m.scaling[key] = client
 	return client, nil
 }
 
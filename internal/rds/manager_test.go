@@ -0,0 +1,56 @@
+package rds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClientManager_GetClientCachesPerRegion(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	ctx := context.Background()
+	m := NewClientManager(ClientManagerConfig{})
+
+	east1, err := m.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	east2, err := m.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	west, err := m.GetClient(ctx, "us-west-2")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+
+	if east1 != east2 {
+		t.Error("expected the same client for repeated lookups of a region")
+	}
+	if east1 == west {
+		t.Error("expected different regions to get different clients")
+	}
+}
+
+func TestClientManager_DemoModeSharesClient(t *testing.T) {
+	ctx := context.Background()
+	m := NewClientManager(ClientManagerConfig{
+		BaseConfig: aws.Config{Credentials: aws.AnonymousCredentials{}},
+		DemoMode:   true,
+		BaseURL:    "http://127.0.0.1:0",
+	})
+
+	east, err := m.GetClient(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	west, err := m.GetClient(ctx, "us-west-2")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if east != west {
+		t.Error("expected demo mode to use one client for every region")
+	}
+}
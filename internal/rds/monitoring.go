@@ -0,0 +1,97 @@
+package rds
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// DefaultPerformanceInsightsRetention is the free Performance Insights
+// retention period in days, which AWS uses when none is given.
+const DefaultPerformanceInsightsRetention = 7
+
+// MonitoringIntervals are the Enhanced Monitoring intervals AWS accepts, in
+// seconds. Zero turns Enhanced Monitoring off.
+var MonitoringIntervals = []int32{0, 1, 5, 10, 15, 30, 60}
+
+// ValidatePerformanceInsightsRetention checks a Performance Insights
+// retention period against the values AWS accepts: 7 days, 731 days, or a
+// whole number of months of 31 days up to 23 months.
+func ValidatePerformanceInsightsRetention(days int32) error {
+	if days == DefaultPerformanceInsightsRetention || days == 731 || (days > 0 && days%31 == 0 && days <= 23*31) {
+		return nil
+	}
+	return errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"performance insights retention of %d days is not allowed; use 7, 731 or a multiple of 31 up to 713", days)
+}
+
+// ValidateMonitoringInterval checks an Enhanced Monitoring interval.
+func ValidateMonitoringInterval(seconds int32) error {
+	if slices.Contains(MonitoringIntervals, seconds) {
+		return nil
+	}
+	return errors.Wrapf(internalerrors.ErrInvalidParameter,
+		"monitoring interval of %d seconds is not allowed; use 0, 1, 5, 10, 15, 30 or 60", seconds)
+}
+
+// ModifyInstanceMonitoringParams contains the monitoring settings to change
+// on an instance. Nil fields and an empty role are left as they are.
+type ModifyInstanceMonitoringParams struct {
+	InstanceID                         string
+	EnablePerformanceInsights          *bool
+	PerformanceInsightsRetentionPeriod *int32
+	MonitoringInterval                 *int32
+	MonitoringRoleArn                  string
+}
+
+// ModifyInstanceMonitoring changes an instance's Performance Insights and
+// Enhanced Monitoring settings, applied immediately. Neither restarts the
+// instance; it goes through modifying and, when Performance Insights is
+// turned on, configuring-performance-insights.
+func (c *Client) ModifyInstanceMonitoring(ctx context.Context, params ModifyInstanceMonitoringParams) error {
+	input := &rds.ModifyDBInstanceInput{
+		DBInstanceIdentifier: aws.String(params.InstanceID),
+		ApplyImmediately:     aws.Bool(true),
+	}
+	if params.EnablePerformanceInsights != nil {
+		input.EnablePerformanceInsights = aws.Bool(*params.EnablePerformanceInsights)
+	}
+	if params.PerformanceInsightsRetentionPeriod != nil {
+		if err := ValidatePerformanceInsightsRetention(*params.PerformanceInsightsRetentionPeriod); err != nil {
+			return err
+		}
+		input.PerformanceInsightsRetentionPeriod = aws.Int32(*params.PerformanceInsightsRetentionPeriod)
+	}
+	if params.MonitoringInterval != nil {
+		if err := ValidateMonitoringInterval(*params.MonitoringInterval); err != nil {
+			return err
+		}
+		input.MonitoringInterval = aws.Int32(*params.MonitoringInterval)
+	}
+	if params.MonitoringRoleArn != "" {
+		input.MonitoringRoleArn = aws.String(params.MonitoringRoleArn)
+	}
+
+	if _, err := c.rds.ModifyDBInstance(ctx, input); err != nil {
+		if strings.Contains(err.Error(), "DBInstanceNotFound") {
+			return errors.Wrap(internalerrors.ErrInstanceNotFound, params.InstanceID)
+		}
+		return errors.Wrap(err, "modify instance monitoring")
+	}
+	return nil
+}
+
+// setInstanceMonitoring copies an instance's monitoring settings into info.
+func setInstanceMonitoring(info *internaltypes.InstanceInfo, instance types.DBInstance) {
+	info.PerformanceInsightsEnabled = aws.ToBool(instance.PerformanceInsightsEnabled)
+	info.PerformanceInsightsRetentionPeriod = aws.ToInt32(instance.PerformanceInsightsRetentionPeriod)
+	info.MonitoringInterval = aws.ToInt32(instance.MonitoringInterval)
+	info.MonitoringRoleArn = aws.ToString(instance.MonitoringRoleArn)
+}
@@ -0,0 +1,98 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestValidatePerformanceInsightsRetention(t *testing.T) {
+	for _, days := range []int32{7, 31, 93, 713, 731} {
+		if err := ValidatePerformanceInsightsRetention(days); err != nil {
+			t.Errorf("%d days: unexpected error %v", days, err)
+		}
+	}
+	for _, days := range []int32{0, 8, 30, 100, 744, -31} {
+		if err := ValidatePerformanceInsightsRetention(days); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("%d days: err = %v, want ErrInvalidParameter", days, err)
+		}
+	}
+}
+
+func TestValidateMonitoringInterval(t *testing.T) {
+	for _, seconds := range MonitoringIntervals {
+		if err := ValidateMonitoringInterval(seconds); err != nil {
+			t.Errorf("%ds: unexpected error %v", seconds, err)
+		}
+	}
+	for _, seconds := range []int32{2, 20, 120, -1} {
+		if err := ValidateMonitoringInterval(seconds); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("%ds: err = %v, want ErrInvalidParameter", seconds, err)
+		}
+	}
+}
+
+func TestClient_ModifyInstanceMonitoring(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+	})
+	ctx := context.Background()
+
+	info, err := client.GetInstanceInfo(ctx, "demo-multi-reader-1")
+	if err != nil {
+		t.Fatalf("GetInstanceInfo failed: %v", err)
+	}
+	if !info.PerformanceInsightsEnabled || info.PerformanceInsightsRetentionPeriod != 7 || info.MonitoringInterval != 0 {
+		t.Fatalf("monitoring = %+v, want Performance Insights on for 7 days and no Enhanced Monitoring", info)
+	}
+
+	retention := int32(30)
+	err = client.ModifyInstanceMonitoring(ctx, ModifyInstanceMonitoringParams{
+		InstanceID:                         "demo-multi-reader-1",
+		PerformanceInsightsRetentionPeriod: &retention,
+	})
+	if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("30 days: err = %v, want ErrInvalidParameter", err)
+	}
+	if inst, _ := state.GetInstance("demo-multi-reader-1"); inst.PendingMonitoring != nil {
+		t.Fatal("rejected retention reached AWS")
+	}
+
+	retention, interval := int32(93), int32(15)
+	if err := client.ModifyInstanceMonitoring(ctx, ModifyInstanceMonitoringParams{
+		InstanceID:                         "demo-multi-reader-1",
+		PerformanceInsightsRetentionPeriod: &retention,
+		MonitoringInterval:                 &interval,
+		MonitoringRoleArn:                  "arn:aws:iam::123456789012:role/rds-monitoring",
+	}); err != nil {
+		t.Fatalf("ModifyInstanceMonitoring failed: %v", err)
+	}
+	inst, _ := state.GetInstance("demo-multi-reader-1")
+	if p := inst.PendingMonitoring; p == nil || *p.PerformanceInsightsRetention != 93 || *p.MonitoringInterval != 15 {
+		t.Errorf("pending monitoring = %+v, want 93 days and 15s", p)
+	}
+
+	err = client.ModifyInstanceMonitoring(ctx, ModifyInstanceMonitoringParams{InstanceID: "missing", MonitoringInterval: &interval})
+	if !errors.Is(err, internalerrors.ErrInstanceNotFound) {
+		t.Errorf("missing instance: err = %v, want ErrInstanceNotFound", err)
+	}
+}
@@ -0,0 +1,196 @@
+package rds
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// ParameterChange is a parameter whose value differs between two parameter groups.
+type ParameterChange struct {
+	Name      string `json:"name"`
+	From      string `json:"from,omitempty"` // empty when the parameter was at its default
+	To        string `json:"to,omitempty"`   // empty when the parameter reverts to its default
+	ApplyType string `json:"apply_type"`
+}
+
+// PendingReboot reports whether the change only takes effect after a reboot.
+func (c ParameterChange) PendingReboot() bool {
+	return c.ApplyType == "static"
+}
+
+// ChangedParameters compares the user-set parameters of two parameter groups
+// and returns the parameters whose value changes when moving from one to the
+// other, sorted by name. A parameter set only in from reverts to its default.
+func ChangedParameters(from, to []ParameterInfo) []ParameterChange {
+	fromByName := make(map[string]ParameterInfo, len(from))
+	for _, p := range from {
+		fromByName[p.Name] = p
+	}
+
+	var changes []ParameterChange
+	for _, p := range to {
+		old, ok := fromByName[p.Name]
+		delete(fromByName, p.Name)
+		if ok && old.Value == p.Value {
+			continue
+		}
+		changes = append(changes, ParameterChange{Name: p.Name, From: old.Value, To: p.Value, ApplyType: p.ApplyType})
+	}
+	for _, old := range fromByName {
+		changes = append(changes, ParameterChange{Name: old.Name, From: old.Value, ApplyType: old.ApplyType})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// parameterInfo converts an SDK parameter description.
+func parameterInfo(param types.Parameter) ParameterInfo {
+	return ParameterInfo{
+		Name:          aws.ToString(param.ParameterName),
+		Value:         aws.ToString(param.ParameterValue),
+		ApplyType:     aws.ToString(param.ApplyType),
+		IsModifiable:  aws.ToBool(param.IsModifiable),
+		Source:        aws.ToString(param.Source),
+		DataType:      aws.ToString(param.DataType),
+		AllowedValues: aws.ToString(param.AllowedValues),
+	}
+}
+
+// ParameterValidationError rejects a set of parameter values before any of
+// them is applied, because the target parameter group does not accept some of
+// them. It matches internalerrors.ErrInvalidParameter.
+type ParameterValidationError struct {
+	// Invalid lists each rejected parameter with the reason.
+	Invalid []internaltypes.SkippedParameter
+}
+
+func (e *ParameterValidationError) Error() string {
+	msgs := make([]string, len(e.Invalid))
+	for i, p := range e.Invalid {
+		msgs[i] = p.Error
+	}
+	return "invalid parameter values: " + strings.Join(msgs, "; ")
+}
+
+func (e *ParameterValidationError) Unwrap() error {
+	return internalerrors.ErrInvalidParameter
+}
+
+// ValidateParameters checks params against the parameters a group defines,
+// keyed by name, as DescribeDB*Parameters reports them. It returns a
+// ParameterValidationError naming every parameter the group does not define,
+// does not allow to be modified, or would reject the value of.
+func ValidateParameters(params []ParameterInfo, defined map[string]ParameterInfo) error {
+	var invalid []internaltypes.SkippedParameter
+	for _, p := range params {
+		meta, ok := defined[p.Name]
+		reason := internaltypes.SkipReasonIncompatibleValue
+		var problem string
+		switch {
+		case !ok:
+			reason, problem = internaltypes.SkipReasonUnknownParameter, "not defined by the parameter group"
+		case !meta.IsModifiable:
+			reason, problem = internaltypes.SkipReasonNotModifiable, "cannot be modified"
+		default:
+			problem = checkParameterValue(p.Value, meta)
+		}
+		if problem != "" {
+			invalid = append(invalid, internaltypes.SkippedParameter{
+				Name:   p.Name,
+				Value:  p.Value,
+				Reason: reason,
+				Error:  fmt.Sprintf("%s=%s: %s", p.Name, p.Value, problem),
+			})
+		}
+	}
+	if len(invalid) > 0 {
+		return &ParameterValidationError{Invalid: invalid}
+	}
+	return nil
+}
+
+// checkParameterValue describes why value does not fit a parameter's data
+// type and allowed values, or returns "" if it does. Formulas such as
+// {DBInstanceClassMemory/12582880} are evaluated by RDS and are not checked.
+func checkParameterValue(value string, meta ParameterInfo) string {
+	if strings.HasPrefix(value, "{") {
+		return ""
+	}
+
+	allowed := meta.AllowedValues
+	switch meta.DataType {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "expected an integer" + allowedSuffix(allowed)
+		}
+	case "float", "real":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "expected a number" + allowedSuffix(allowed)
+		}
+	case "list":
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" && !valueAllowed(item, allowed) {
+				return fmt.Sprintf("%s is not allowed%s", item, allowedSuffix(allowed))
+			}
+		}
+		return ""
+	}
+	if !valueAllowed(value, allowed) {
+		return "not allowed" + allowedSuffix(allowed)
+	}
+	return ""
+}
+
+// allowedSuffix renders a parameter's allowed values for an error message.
+func allowedSuffix(allowed string) string {
+	if allowed == "" {
+		return ""
+	}
+	return "; allowed values are " + allowed
+}
+
+// allowedRange matches a numeric range entry of AllowedValues, such as
+// 64-2147483647 or -1-2147483647.
+var allowedRange = regexp.MustCompile(`^(-?[0-9.]+)-(-?[0-9.]+)$`)
+
+// valueAllowed reports whether value is one of a parameter's allowed values.
+// AllowedValues is a comma-separated list of literals and numeric ranges, or a
+// regular expression starting with ^; an empty one allows anything.
+func valueAllowed(value, allowed string) bool {
+	if allowed == "" {
+		return true
+	}
+	if strings.HasPrefix(allowed, "^") {
+		re, err := regexp.Compile(allowed)
+		return err != nil || re.MatchString(value)
+	}
+	for _, entry := range strings.Split(allowed, ",") {
+		entry = strings.TrimSpace(entry)
+		if strings.EqualFold(entry, value) {
+			return true
+		}
+		bounds := allowedRange.FindStringSubmatch(entry)
+		if bounds == nil {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		low, errLow := strconv.ParseFloat(bounds[1], 64)
+		high, errHigh := strconv.ParseFloat(bounds[2], 64)
+		if errLow == nil && errHigh == nil && n >= low && n <= high {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+func TestChangedParameters(t *testing.T) {
+	from := []ParameterInfo{
+		{Name: "work_mem", Value: "4096", ApplyType: "dynamic"},
+		{Name: "shared_preload_libraries", Value: "pg_stat_statements", ApplyType: "static"},
+		{Name: "log_min_duration_statement", Value: "1000", ApplyType: "dynamic"},
+	}
+	to := []ParameterInfo{
+		{Name: "work_mem", Value: "8192", ApplyType: "dynamic"},
+		{Name: "shared_preload_libraries", Value: "pg_stat_statements", ApplyType: "static"},
+		{Name: "max_connections", Value: "500", ApplyType: "static"},
+	}
+
+	got := ChangedParameters(from, to)
+	want := []ParameterChange{
+		{Name: "log_min_duration_statement", From: "1000", ApplyType: "dynamic"},
+		{Name: "max_connections", To: "500", ApplyType: "static"},
+		{Name: "work_mem", From: "4096", To: "8192", ApplyType: "dynamic"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedParameters() = %+v, want %+v", got, want)
+	}
+
+	var pending []string
+	for _, c := range got {
+		if c.PendingReboot() {
+			pending = append(pending, c.Name)
+		}
+	}
+	if !reflect.DeepEqual(pending, []string{"max_connections"}) {
+		t.Errorf("pending reboot = %v, want [max_connections]", pending)
+	}
+
+	if changes := ChangedParameters(from, from); len(changes) != 0 {
+		t.Errorf("expected no changes between identical groups, got %+v", changes)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	defined := map[string]ParameterInfo{
+		"work_mem":                   {DataType: "integer", AllowedValues: "64-2147483647", IsModifiable: true},
+		"log_min_duration_statement": {DataType: "integer", AllowedValues: "-1-2147483647", IsModifiable: true},
+		"random_page_cost":           {DataType: "real", AllowedValues: "0-2147483647", IsModifiable: true},
+		"rds.force_ssl":              {DataType: "boolean", AllowedValues: "0,1", IsModifiable: true},
+		"shared_preload_libraries":   {DataType: "list", AllowedValues: "pg_cron,pg_stat_statements", IsModifiable: true},
+		"timezone":                   {DataType: "string", IsModifiable: true},
+		"rds.extensions":             {DataType: "list", IsModifiable: false},
+	}
+	tests := []struct {
+		name       string
+		param      ParameterInfo
+		wantReason internaltypes.SkipReason // empty when the value is valid
+	}{
+		{name: "integer in range", param: ParameterInfo{Name: "work_mem", Value: "65536"}},
+		{name: "negative range bound", param: ParameterInfo{Name: "log_min_duration_statement", Value: "-1"}},
+		{name: "formula", param: ParameterInfo{Name: "work_mem", Value: "{DBInstanceClassMemory/2048}"}},
+		{name: "real", param: ParameterInfo{Name: "random_page_cost", Value: "1.1"}},
+		{name: "boolean", param: ParameterInfo{Name: "rds.force_ssl", Value: "1"}},
+		{name: "list", param: ParameterInfo{Name: "shared_preload_libraries", Value: "pg_stat_statements, pg_cron"}},
+		{name: "free-form string", param: ParameterInfo{Name: "timezone", Value: "UTC"}},
+		{name: "integer with unit", param: ParameterInfo{Name: "work_mem", Value: "1TB"}, wantReason: internaltypes.SkipReasonIncompatibleValue},
+		{name: "integer below range", param: ParameterInfo{Name: "work_mem", Value: "8"}, wantReason: internaltypes.SkipReasonIncompatibleValue},
+		{name: "real not a number", param: ParameterInfo{Name: "random_page_cost", Value: "fast"}, wantReason: internaltypes.SkipReasonIncompatibleValue},
+		{name: "boolean out of set", param: ParameterInfo{Name: "rds.force_ssl", Value: "yes"}, wantReason: internaltypes.SkipReasonIncompatibleValue},
+		{name: "list item not allowed", param: ParameterInfo{Name: "shared_preload_libraries", Value: "pg_stat_statements,timescaledb"}, wantReason: internaltypes.SkipReasonIncompatibleValue},
+		{name: "not modifiable", param: ParameterInfo{Name: "rds.extensions", Value: "pg_cron"}, wantReason: internaltypes.SkipReasonNotModifiable},
+		{name: "unknown", param: ParameterInfo{Name: "removed_param", Value: "on"}, wantReason: internaltypes.SkipReasonUnknownParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParameters([]ParameterInfo{tt.param}, defined)
+			if tt.wantReason == "" {
+				if err != nil {
+					t.Fatalf("ValidateParameters() = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ParameterValidationError
+			if !errors.As(err, &invalid) || !errors.Is(err, internalerrors.ErrInvalidParameter) {
+				t.Fatalf("ValidateParameters() = %v, want a ParameterValidationError", err)
+			}
+			if len(invalid.Invalid) != 1 || invalid.Invalid[0].Reason != tt.wantReason {
+				t.Errorf("invalid = %+v, want one parameter with reason %s", invalid.Invalid, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestClient_ModifyClusterParameterGroupParams_Validates(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	err := client.ModifyClusterParameterGroupParams(ctx, "validated-pg", []ParameterInfo{
+		{Name: "max_connections", Value: "500", ApplyType: "static"},
+		{Name: "work_mem", Value: "1TB", ApplyType: "dynamic"},
+	})
+	var invalid *ParameterValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("ModifyClusterParameterGroupParams() = %v, want a ParameterValidationError", err)
+	}
+	if len(invalid.Invalid) != 1 || invalid.Invalid[0].Name != "work_mem" {
+		t.Errorf("invalid = %+v, want only work_mem", invalid.Invalid)
+	}
+	if params := state.ClusterParameters("validated-pg"); len(params) != 0 {
+		t.Errorf("parameters applied despite the invalid value: %v", params)
+	}
+
+	if err := client.ModifyClusterParameterGroupParams(ctx, "validated-pg", []ParameterInfo{
+		{Name: "max_connections", Value: "500", ApplyType: "static"},
+		{Name: "work_mem", Value: "65536", ApplyType: "dynamic"},
+	}); err != nil {
+		t.Fatalf("ModifyClusterParameterGroupParams() = %v", err)
+	}
+	custom, err := client.GetClusterParameterGroupCustomParameters(ctx, "validated-pg")
+	if err != nil {
+		t.Fatalf("GetClusterParameterGroupCustomParameters failed: %v", err)
+	}
+	if len(custom) != 2 || custom[0].Name != "max_connections" || custom[0].DataType != "integer" || custom[0].AllowedValues != "6-8388607" {
+		t.Errorf("custom parameters = %+v, want both with their data type and allowed values", custom)
+	}
+}
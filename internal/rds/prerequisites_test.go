@@ -0,0 +1,96 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_CheckBlueGreenPrerequisites(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+	})
+	ctx := context.Background()
+
+	checkByName := func(p *BlueGreenPrerequisites, name string) PrerequisiteCheck {
+		t.Helper()
+		for _, check := range p.Checks {
+			if check.Name == name {
+				return check
+			}
+		}
+		t.Fatalf("no %s check in %+v", name, p.Checks)
+		return PrerequisiteCheck{}
+	}
+
+	t.Run("all prerequisites met", func(t *testing.T) {
+		prereqs, err := client.CheckBlueGreenPrerequisites(ctx, "demo-upgrade", "16.1")
+		if err != nil {
+			t.Fatalf("CheckBlueGreenPrerequisites failed: %v", err)
+		}
+		if failed := prereqs.FailedRequired(); len(failed) != 0 {
+			t.Errorf("unexpected failed checks: %+v", failed)
+		}
+		if !checkByName(prereqs, PrereqUpgradeTarget).Passed {
+			t.Error("expected 16.1 to be a valid upgrade target")
+		}
+	})
+
+	t.Run("invalid target version", func(t *testing.T) {
+		prereqs, err := client.CheckBlueGreenPrerequisites(ctx, "demo-upgrade", "18.0")
+		if err != nil {
+			t.Fatalf("CheckBlueGreenPrerequisites failed: %v", err)
+		}
+		check := checkByName(prereqs, PrereqUpgradeTarget)
+		if check.Passed || !check.Required || check.Remediation == "" {
+			t.Errorf("expected a failed required check with remediation, got %+v", check)
+		}
+	})
+
+	t.Run("logical replication disabled", func(t *testing.T) {
+		prereqs, err := client.CheckBlueGreenPrerequisites(ctx, "demo-single", "16.1")
+		if err != nil {
+			t.Fatalf("CheckBlueGreenPrerequisites failed: %v", err)
+		}
+		check := checkByName(prereqs, PrereqLogicalReplication)
+		if check.Passed {
+			t.Fatal("expected logical replication check to fail")
+		}
+		if !strings.Contains(check.Remediation, "rds.logical_replication") || !strings.Contains(check.Remediation, "demo-single-pg") {
+			t.Errorf("remediation = %q, want parameter and group named", check.Remediation)
+		}
+		if len(prereqs.FailedRequired()) != 1 {
+			t.Errorf("failed checks = %+v, want only logical replication", prereqs.FailedRequired())
+		}
+	})
+
+	t.Run("no target skips upgrade path check", func(t *testing.T) {
+		prereqs, err := client.CheckBlueGreenPrerequisites(ctx, "demo-upgrade", "")
+		if err != nil {
+			t.Fatalf("CheckBlueGreenPrerequisites failed: %v", err)
+		}
+		for _, check := range prereqs.Checks {
+			if check.Name == PrereqUpgradeTarget {
+				t.Errorf("unexpected upgrade target check: %+v", check)
+			}
+		}
+	})
+}
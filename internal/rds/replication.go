@@ -0,0 +1,28 @@
+package rds
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// GetReplicaLag returns how far an Aurora reader trails the writer, from the
+// most recent AuroraReplicaLag datapoint in CloudWatch. The RDS API does not
+// report Aurora replica lag itself. Writers have no such metric, so asking
+// for one returns ErrNotFound, as does a reader without recent datapoints.
+func (c *Client) GetReplicaLag(ctx context.Context, instanceID string) (time.Duration, error) {
+	if c.metrics == nil {
+		return 0, errors.New("replica lag unavailable: client has no CloudWatch access")
+	}
+
+	lagMs, ok, err := c.metrics.LatestMaximum(ctx, "AWS/RDS", "AuroraReplicaLag", "DBInstanceIdentifier", instanceID)
+	if err != nil {
+		return 0, errors.Wrapf(err, "get replica lag of %s", instanceID)
+	}
+	if !ok {
+		return 0, errors.Wrapf(internalerrors.ErrNotFound, "no recent AuroraReplicaLag datapoints for %s", instanceID)
+	}
+	return time.Duration(lagMs * float64(time.Millisecond)), nil
+}
@@ -0,0 +1,46 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_GetReplicaLag(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{Region: "us-east-1", Credentials: aws.AnonymousCredentials{}},
+		BaseURL:   server.URL,
+	})
+	ctx := context.Background()
+
+	if err := state.SetReplicaLag("demo-multi-reader-1", 2500); err != nil {
+		t.Fatalf("SetReplicaLag failed: %v", err)
+	}
+	lag, err := client.GetReplicaLag(ctx, "demo-multi-reader-1")
+	if err != nil {
+		t.Fatalf("GetReplicaLag failed: %v", err)
+	}
+	if lag != 2500*time.Millisecond {
+		t.Errorf("lag = %v, want 2.5s", lag)
+	}
+
+	// Writers report no replica lag.
+	if _, err := client.GetReplicaLag(ctx, "demo-multi-writer"); !errors.Is(err, internalerrors.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for the writer, got: %v", err)
+	}
+}
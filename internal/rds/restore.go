@@ -0,0 +1,112 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// maxClusterIDLength is the longest DB cluster identifier RDS accepts.
+const maxClusterIDLength = 63
+
+// RestoreClusterParams contains parameters for restoring a cluster from a snapshot.
+type RestoreClusterParams struct {
+	SnapshotID    string
+	ClusterID     string // identifier of the new cluster
+	Engine        string
+	EngineVersion string // empty restores at the snapshot's version
+	OperationID   string
+
+	// SourceClusterID is the cluster whose subnet group, security groups and
+	// cluster parameter group the restored cluster reuses (optional).
+	SourceClusterID string
+}
+
+// RestoreClusterFromSnapshot restores a snapshot into a new cluster without
+// instances. A cluster with the requested identifier already existing
+// returns ErrClusterAlreadyExists.
+func (c *Client) RestoreClusterFromSnapshot(ctx context.Context, params RestoreClusterParams) error {
+	input := &rds.RestoreDBClusterFromSnapshotInput{
+		DBClusterIdentifier: aws.String(params.ClusterID),
+		SnapshotIdentifier:  aws.String(params.SnapshotID),
+		Engine:              aws.String(params.Engine),
+		DeletionProtection:  aws.Bool(false),
+		Tags: []types.Tag{
+			{Key: aws.String("rds-maint-machine"), Value: aws.String("restore-test")},
+			{Key: aws.String("rds-maint-operation-id"), Value: aws.String(params.OperationID)},
+		},
+	}
+	if params.EngineVersion != "" {
+		input.EngineVersion = aws.String(params.EngineVersion)
+	}
+
+	if params.SourceClusterID != "" {
+		out, err := c.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+			DBClusterIdentifier: aws.String(params.SourceClusterID),
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "DBClusterNotFound") {
+				return errors.Wrap(internalerrors.ErrClusterNotFound, params.SourceClusterID)
+			}
+			return errors.Wrap(err, "describe source cluster")
+		}
+		if len(out.DBClusters) == 0 {
+			return errors.Wrap(internalerrors.ErrClusterNotFound, params.SourceClusterID)
+		}
+		source := out.DBClusters[0]
+		if source.DBSubnetGroup != nil {
+			input.DBSubnetGroupName = source.DBSubnetGroup
+		}
+		for _, sg := range source.VpcSecurityGroups {
+			input.VpcSecurityGroupIds = append(input.VpcSecurityGroupIds, aws.ToString(sg.VpcSecurityGroupId))
+		}
+		// A parameter group for another major version would be rejected.
+		if source.DBClusterParameterGroup != nil && params.EngineVersion == "" {
+			input.DBClusterParameterGroupName = source.DBClusterParameterGroup
+		}
+	}
+
+	_, err := c.rds.RestoreDBClusterFromSnapshot(ctx, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterAlreadyExists") {
+			return errors.Wrap(internalerrors.ErrClusterAlreadyExists, params.ClusterID)
+		}
+		return errors.Wrap(err, "restore cluster from snapshot")
+	}
+
+	return nil
+}
+
+// GenerateRestoreClusterID generates the identifier of the cluster a
+// snapshot restore test restores into.
+func GenerateRestoreClusterID(clusterID, operationID string) string {
+	suffix := operationID
+	if len(suffix) > 8 {
+		suffix = suffix[len(suffix)-8:]
+	}
+	return truncateClusterID(clusterID, len("-restore-")+len(suffix)) + "-restore-" + suffix
+}
+
+// TimestampedClusterID appends a timestamp to a cluster identifier, the way
+// pre-upgrade snapshot IDs are named, shortening the identifier if the
+// result would be too long.
+func TimestampedClusterID(clusterID string, t time.Time) string {
+	ts := t.Format("20060102-150405")
+	return fmt.Sprintf("%s-%s", truncateClusterID(clusterID, len(ts)+1), ts)
+}
+
+// truncateClusterID shortens clusterID so that reserve more characters still
+// fit in a cluster identifier, dropping any trailing hyphen RDS would reject.
+func truncateClusterID(clusterID string, reserve int) string {
+	if limit := maxClusterIDLength - reserve; len(clusterID) > limit {
+		clusterID = strings.TrimRight(clusterID[:limit], "-")
+	}
+	return clusterID
+}
@@ -0,0 +1,33 @@
+package rds
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreClusterIDs(t *testing.T) {
+	ts := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	long := strings.Repeat("a", 50) + "-" + strings.Repeat("b", 20)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"restore id", GenerateRestoreClusterID("orders", "op-1234567890"), "orders-restore-34567890"},
+		{"restore id of long cluster", GenerateRestoreClusterID(long, "op-1234567890"), strings.Repeat("a", 46) + "-restore-34567890"},
+		{"timestamped", TimestampedClusterID("orders-restore-34567890", ts), "orders-restore-34567890-20260304-050607"},
+		{"timestamped long", TimestampedClusterID(long, ts), strings.Repeat("a", 47) + "-20260304-050607"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+			if len(tt.got) > maxClusterIDLength {
+				t.Errorf("%q is %d characters, longer than RDS allows", tt.got, len(tt.got))
+			}
+		})
+	}
+}
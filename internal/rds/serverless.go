@@ -0,0 +1,84 @@
+package rds
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// Aurora Serverless v2 capacity limits, in Aurora capacity units (ACUs).
+const (
+	MinServerlessV2Capacity = 0.5
+	MaxServerlessV2Capacity = 256
+)
+
+// ServerlessInstanceClass is the instance class of Aurora Serverless v2 instances.
+const ServerlessInstanceClass = "db.serverless"
+
+// ValidateServerlessV2Capacity checks a Serverless v2 capacity range against
+// the limits AWS enforces: both ends within 0.5-256 ACUs in half-ACU steps,
+// and the minimum no greater than the maximum.
+func ValidateServerlessV2Capacity(minACU, maxACU float64) error {
+	for _, c := range []struct {
+		name  string
+		value float64
+	}{{"min_capacity", minACU}, {"max_capacity", maxACU}} {
+		if c.value < MinServerlessV2Capacity || c.value > MaxServerlessV2Capacity {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s %g ACUs is outside %g-%g", c.name, c.value, MinServerlessV2Capacity, MaxServerlessV2Capacity)
+		}
+		if c.value*2 != math.Trunc(c.value*2) {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"%s %g ACUs is not a multiple of 0.5", c.name, c.value)
+		}
+	}
+	if minACU > maxACU {
+		return errors.Wrapf(internalerrors.ErrInvalidParameter,
+			"min_capacity %g ACUs is greater than max_capacity %g ACUs", minACU, maxACU)
+	}
+	return nil
+}
+
+// ModifyServerlessScaling sets the Serverless v2 capacity range of a cluster,
+// applied immediately. The range is validated before AWS is called.
+func (c *Client) ModifyServerlessScaling(ctx context.Context, clusterID string, minACU, maxACU float64) error {
+	if err := ValidateServerlessV2Capacity(minACU, maxACU); err != nil {
+		return err
+	}
+
+	_, err := c.rds.ModifyDBCluster(ctx, &rds.ModifyDBClusterInput{
+		DBClusterIdentifier: aws.String(clusterID),
+		ApplyImmediately:    aws.Bool(true),
+		ServerlessV2ScalingConfiguration: &types.ServerlessV2ScalingConfiguration{
+			MinCapacity: aws.Float64(minACU),
+			MaxCapacity: aws.Float64(maxACU),
+		},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterNotFound") {
+			return errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+		}
+		return errors.Wrap(err, "modify serverless scaling")
+	}
+
+	return nil
+}
+
+// serverlessV2Scaling converts a cluster's Serverless v2 scaling
+// configuration, or returns nil if it has none.
+func serverlessV2Scaling(info *types.ServerlessV2ScalingConfigurationInfo) *internaltypes.ServerlessV2Scaling {
+	if info == nil || info.MinCapacity == nil || info.MaxCapacity == nil {
+		return nil
+	}
+	return &internaltypes.ServerlessV2Scaling{
+		MinCapacity: *info.MinCapacity,
+		MaxCapacity: *info.MaxCapacity,
+	}
+}
@@ -0,0 +1,91 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestValidateServerlessV2Capacity(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		valid    bool
+	}{
+		{0.5, 256, true},
+		{2, 2, true},
+		{1.5, 16, true},
+		{0, 8, false},       // below the minimum
+		{0.5, 256.5, false}, // above the maximum
+		{8, 4, false},       // min greater than max
+		{0.5, 1.25, false},  // not a half-ACU step
+	}
+	for _, tt := range tests {
+		err := ValidateServerlessV2Capacity(tt.min, tt.max)
+		if tt.valid && err != nil {
+			t.Errorf("%g-%g: unexpected error %v", tt.min, tt.max, err)
+		}
+		if !tt.valid && !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("%g-%g: err = %v, want ErrInvalidParameter", tt.min, tt.max, err)
+		}
+	}
+}
+
+func TestClient_ServerlessScaling(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+	})
+	ctx := context.Background()
+
+	info, err := client.GetClusterInfo(ctx, "demo-serverless")
+	if err != nil {
+		t.Fatalf("GetClusterInfo failed: %v", err)
+	}
+	if !info.IsServerless || info.ServerlessV2Scaling == nil ||
+		info.ServerlessV2Scaling.MinCapacity != 0.5 || info.ServerlessV2Scaling.MaxCapacity != 8 {
+		t.Fatalf("serverless = %v, scaling = %+v, want serverless at 0.5-8 ACUs", info.IsServerless, info.ServerlessV2Scaling)
+	}
+
+	info, err = client.GetClusterInfo(ctx, "demo-single")
+	if err != nil {
+		t.Fatalf("GetClusterInfo failed: %v", err)
+	}
+	if info.IsServerless || info.ServerlessV2Scaling != nil {
+		t.Errorf("provisioned cluster reported serverless = %v, scaling = %+v", info.IsServerless, info.ServerlessV2Scaling)
+	}
+
+	if err := client.ModifyServerlessScaling(ctx, "demo-serverless", 2, 1); !errors.Is(err, internalerrors.ErrInvalidParameter) {
+		t.Fatalf("inverted range: err = %v, want ErrInvalidParameter", err)
+	}
+	if cluster, _ := state.GetCluster("demo-serverless"); cluster.Status != "available" {
+		t.Fatalf("rejected range reached AWS: cluster status %s", cluster.Status)
+	}
+
+	if err := client.ModifyServerlessScaling(ctx, "demo-serverless", 1, 32); err != nil {
+		t.Fatalf("ModifyServerlessScaling failed: %v", err)
+	}
+	cluster, _ := state.GetCluster("demo-serverless")
+	if cluster.ServerlessV2MinCapacity != 1 || cluster.ServerlessV2MaxCapacity != 32 {
+		t.Errorf("capacity = %g-%g, want 1-32", cluster.ServerlessV2MinCapacity, cluster.ServerlessV2MaxCapacity)
+	}
+}
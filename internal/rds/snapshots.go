@@ -0,0 +1,135 @@
+package rds
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// ClusterSnapshotInfo describes a manual cluster snapshot created by the
+// machine.
+type ClusterSnapshotInfo struct {
+	SnapshotID    string    `json:"snapshot_id"`
+	ClusterID     string    `json:"cluster_id"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	RetentionDays int       `json:"retention_days,omitempty"`
+}
+
+// ExpiresAt returns when the snapshot's retention ends. It reports false for
+// a snapshot without a retention tag, which is kept indefinitely.
+func (s ClusterSnapshotInfo) ExpiresAt() (time.Time, bool) {
+	if s.RetentionDays <= 0 || s.CreatedAt.IsZero() {
+		return time.Time{}, false
+	}
+	return s.CreatedAt.AddDate(0, 0, s.RetentionDays), true
+}
+
+// ListClusterSnapshots returns the manual cluster snapshots in the region that
+// carry the machine's tag. Snapshots taken by hand or by AWS Backup are left
+// out.
+func (c *Client) ListClusterSnapshots(ctx context.Context) ([]ClusterSnapshotInfo, error) {
+	var snapshots []ClusterSnapshotInfo
+	input := &rds.DescribeDBClusterSnapshotsInput{
+		SnapshotType: aws.String("manual"),
+	}
+	for {
+		out, err := c.rds.DescribeDBClusterSnapshots(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe cluster snapshots")
+		}
+		for _, snap := range out.DBClusterSnapshots {
+			tags := make(map[string]string, len(snap.TagList))
+			for _, tag := range snap.TagList {
+				tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			if _, ok := tags[TagKeyMachine]; !ok {
+				continue
+			}
+			info := ClusterSnapshotInfo{
+				SnapshotID: aws.ToString(snap.DBClusterSnapshotIdentifier),
+				ClusterID:  aws.ToString(snap.DBClusterIdentifier),
+				Status:     aws.ToString(snap.Status),
+				CreatedAt:  aws.ToTime(snap.SnapshotCreateTime),
+			}
+			if days, err := strconv.Atoi(tags[TagKeyRetentionDays]); err == nil {
+				info.RetentionDays = days
+			}
+			snapshots = append(snapshots, info)
+		}
+		if aws.ToString(out.Marker) == "" {
+			return snapshots, nil
+		}
+		input.Marker = out.Marker
+	}
+}
+
+// FindSnapshotByOperationID returns the cluster's newest manual snapshot
+// tagged with the operation ID that is available or still being created, or
+// nil if there is none. Snapshot steps use it to pick up a snapshot an earlier
+// attempt already started.
+func (c *Client) FindSnapshotByOperationID(ctx context.Context, clusterID, operationID string) (*ClusterSnapshotInfo, error) {
+	var found *ClusterSnapshotInfo
+	input := &rds.DescribeDBClusterSnapshotsInput{
+		DBClusterIdentifier: aws.String(clusterID),
+		SnapshotType:        aws.String("manual"),
+	}
+	for {
+		out, err := c.rds.DescribeDBClusterSnapshots(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "describe snapshots of cluster %s", clusterID)
+		}
+		for _, snap := range out.DBClusterSnapshots {
+			if aws.ToString(snap.DBClusterIdentifier) != clusterID {
+				continue
+			}
+			status := aws.ToString(snap.Status)
+			if status != "available" && status != "creating" {
+				continue
+			}
+			tagged := false
+			for _, tag := range snap.TagList {
+				if aws.ToString(tag.Key) == TagKeyOperationID && aws.ToString(tag.Value) == operationID {
+					tagged = true
+					break
+				}
+			}
+			if !tagged {
+				continue
+			}
+			info := &ClusterSnapshotInfo{
+				SnapshotID: aws.ToString(snap.DBClusterSnapshotIdentifier),
+				ClusterID:  clusterID,
+				Status:     status,
+				CreatedAt:  aws.ToTime(snap.SnapshotCreateTime),
+			}
+			if found == nil || info.CreatedAt.After(found.CreatedAt) {
+				found = info
+			}
+		}
+		if aws.ToString(out.Marker) == "" {
+			return found, nil
+		}
+		input.Marker = out.Marker
+	}
+}
+
+// DeleteClusterSnapshot deletes a manual cluster snapshot.
+func (c *Client) DeleteClusterSnapshot(ctx context.Context, snapshotID string) error {
+	_, err := c.rds.DeleteDBClusterSnapshot(ctx, &rds.DeleteDBClusterSnapshotInput{
+		DBClusterSnapshotIdentifier: aws.String(snapshotID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterSnapshotNotFound") {
+			return errors.Wrapf(internalerrors.ErrNotFound, "snapshot %s", snapshotID)
+		}
+		return errors.Wrapf(err, "delete snapshot %s", snapshotID)
+	}
+	return nil
+}
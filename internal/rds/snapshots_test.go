@@ -0,0 +1,105 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClusterSnapshotInfo_ExpiresAt(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expiresAt, ok := ClusterSnapshotInfo{CreatedAt: created, RetentionDays: 14}.ExpiresAt()
+	if !ok || !expiresAt.Equal(created.AddDate(0, 0, 14)) {
+		t.Errorf("ExpiresAt() = %v, %v; want %v, true", expiresAt, ok, created.AddDate(0, 0, 14))
+	}
+	if _, ok := (ClusterSnapshotInfo{CreatedAt: created}).ExpiresAt(); ok {
+		t.Error("a snapshot without retention should never expire")
+	}
+}
+
+// TestClient_ClusterSnapshots verifies that only the machine's snapshots are
+// listed, with their retention, and that they can be deleted.
+func TestClient_ClusterSnapshots(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+		Logger:  logger,
+	})
+	ctx := context.Background()
+
+	if err := client.CreateClusterSnapshot(ctx, "demo-single", "demo-single-kept", "op-1", 7, map[string]string{"Team": "platform"}); err != nil {
+		t.Fatalf("CreateClusterSnapshot failed: %v", err)
+	}
+	if err := state.CreateSnapshot("demo-single", "demo-single-manual"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	snapshots, err := client.ListClusterSnapshots(ctx)
+	if err != nil {
+		t.Fatalf("ListClusterSnapshots failed: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].SnapshotID != "demo-single-kept" {
+		t.Fatalf("ListClusterSnapshots() = %+v, want only demo-single-kept", snapshots)
+	}
+	if snap := snapshots[0]; snap.ClusterID != "demo-single" || snap.RetentionDays != 7 || snap.CreatedAt.IsZero() {
+		t.Errorf("unexpected snapshot info %+v", snap)
+	}
+
+	found, err := client.FindSnapshotByOperationID(ctx, "demo-single", "op-1")
+	if err != nil {
+		t.Fatalf("FindSnapshotByOperationID failed: %v", err)
+	}
+	if found == nil || found.SnapshotID != "demo-single-kept" {
+		t.Errorf("FindSnapshotByOperationID() = %+v, want demo-single-kept", found)
+	}
+	if found, err := client.FindSnapshotByOperationID(ctx, "demo-single", "op-2"); err != nil || found != nil {
+		t.Errorf("FindSnapshotByOperationID(op-2) = %+v, %v; want none", found, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		available, err := client.IsSnapshotAvailable(ctx, "demo-single-kept")
+		if err != nil {
+			t.Fatalf("IsSnapshotAvailable failed: %v", err)
+		}
+		if available {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot did not become available")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := client.DeleteClusterSnapshot(ctx, "demo-single-kept"); err != nil {
+		t.Fatalf("DeleteClusterSnapshot failed: %v", err)
+	}
+	if _, ok := state.GetSnapshot("demo-single-kept"); ok {
+		t.Error("snapshot still exists after delete")
+	}
+	if err := client.DeleteClusterSnapshot(ctx, "demo-single-kept"); !errors.Is(err, internalerrors.ErrNotFound) {
+		t.Errorf("DeleteClusterSnapshot(missing) = %v, want ErrNotFound", err)
+	}
+}
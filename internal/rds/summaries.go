@@ -0,0 +1,80 @@
+package rds
+
+import (
+	"context"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internaltypes "github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// summaryClusterBatch is how many clusters share one DescribeDBInstances
+// call when cluster summaries are enriched with instance details.
+const summaryClusterBatch = 20
+
+// addWriterClasses fills in each summary's writer instance class, looking up
+// the instances of summaryClusterBatch clusters per call instead of one call
+// per cluster. writers maps cluster IDs to their writer instance IDs.
+//
+// A cluster whose instances cannot be described fails the whole batch, so a
+// failed batch is retried one cluster at a time. Clusters that still fail
+// are dropped from the list with a warning rather than failing it.
+func (c *Client) addWriterClasses(ctx context.Context, clusters []internaltypes.ClusterSummary, writers map[string]string) []internaltypes.ClusterSummary {
+	classes := make(map[string]string)
+	skipped := make(map[string]bool)
+	for start := 0; start < len(clusters); start += summaryClusterBatch {
+		batch := make([]string, 0, summaryClusterBatch)
+		for _, cluster := range clusters[start:min(start+summaryClusterBatch, len(clusters))] {
+			batch = append(batch, cluster.ClusterID)
+		}
+
+		found, err := c.describeInstanceClasses(ctx, batch)
+		if err == nil {
+			maps.Copy(classes, found)
+			continue
+		}
+		for _, clusterID := range batch {
+			found, err := c.describeInstanceClasses(ctx, []string{clusterID})
+			if err != nil {
+				c.logger.Warn("skipping cluster that could not be described",
+					"cluster_id", clusterID,
+					"error", err)
+				skipped[clusterID] = true
+				continue
+			}
+			maps.Copy(classes, found)
+		}
+	}
+
+	kept := clusters[:0]
+	for _, cluster := range clusters {
+		if skipped[cluster.ClusterID] {
+			continue
+		}
+		cluster.WriterInstanceClass = classes[writers[cluster.ClusterID]]
+		kept = append(kept, cluster)
+	}
+	return kept
+}
+
+// describeInstanceClasses returns the instance class of every instance in
+// the given clusters, keyed by instance ID.
+func (c *Client) describeInstanceClasses(ctx context.Context, clusterIDs []string) (map[string]string, error) {
+	classes := make(map[string]string)
+	paginator := rds.NewDescribeDBInstancesPaginator(c.rds, &rds.DescribeDBInstancesInput{
+		Filters: []types.Filter{{Name: aws.String("db-cluster-id"), Values: clusterIDs}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe cluster instances")
+		}
+		for _, inst := range page.DBInstances {
+			classes[aws.ToString(inst.DBInstanceIdentifier)] = aws.ToString(inst.DBInstanceClass)
+		}
+	}
+	return classes, nil
+}
@@ -0,0 +1,99 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// TestClient_ListClusters verifies the summary metadata, the opt-in writer
+// class lookup, and that a cluster whose instances cannot be described is
+// left out instead of failing the list.
+func TestClient_ListClusters(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	if err := state.AddGlobalCluster(mock.MockGlobalCluster{
+		ID:      "demo-global",
+		Members: []mock.MockGlobalClusterMember{{ClusterID: "demo-multi", IsWriter: true}},
+	}); err != nil {
+		t.Fatalf("AddGlobalCluster failed: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+		Logger:  logger,
+	})
+	ctx := context.Background()
+
+	byID := func(clusters []types.ClusterSummary) map[string]types.ClusterSummary {
+		m := make(map[string]types.ClusterSummary, len(clusters))
+		for _, c := range clusters {
+			m[c.ClusterID] = c
+		}
+		return m
+	}
+
+	clusters, err := client.ListClusters(ctx, false)
+	if err != nil {
+		t.Fatalf("ListClusters failed: %v", err)
+	}
+	summaries := byID(clusters)
+	multi, single := summaries["demo-multi"], summaries["demo-single"]
+	if multi.InstanceCount != 3 || !multi.MultiAZ || multi.GlobalClusterID != "demo-global" {
+		t.Errorf("demo-multi = %+v, want 3 instances, multi-AZ, in demo-global", multi)
+	}
+	if single.InstanceCount != 1 || single.MultiAZ || single.GlobalClusterID != "" {
+		t.Errorf("demo-single = %+v, want 1 instance, single-AZ, not global", single)
+	}
+	if multi.WriterInstanceClass != "" {
+		t.Errorf("writer class %q should only be looked up on request", multi.WriterInstanceClass)
+	}
+
+	clusters, err = client.ListClusters(ctx, true)
+	if err != nil {
+		t.Fatalf("ListClusters failed: %v", err)
+	}
+	for _, c := range clusters {
+		if c.WriterInstanceClass == "" {
+			t.Errorf("%s has no writer instance class", c.ClusterID)
+		}
+	}
+	if got := byID(clusters)["demo-single"].WriterInstanceClass; got != "db.r6g.large" {
+		t.Errorf("demo-single writer class = %q, want db.r6g.large", got)
+	}
+
+	state.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeAPIError,
+		Action:      "DescribeDBInstances",
+		Target:      "demo-single",
+		ErrorCode:   "AccessDenied",
+		Probability: 1,
+		Enabled:     true,
+	})
+	withFault, err := client.ListClusters(ctx, true)
+	if err != nil {
+		t.Fatalf("ListClusters should skip the cluster it cannot describe, got: %v", err)
+	}
+	summaries = byID(withFault)
+	if _, ok := summaries["demo-single"]; ok {
+		t.Error("demo-single should be skipped")
+	}
+	if len(withFault) != len(clusters)-1 || summaries["demo-multi"].WriterInstanceClass == "" {
+		t.Errorf("got %d clusters, want the other %d with their writer classes", len(withFault), len(clusters)-1)
+	}
+}
@@ -0,0 +1,282 @@
+package rds
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// DefaultTagLookupConcurrency is the number of ListTagsForResource calls a
+// cluster lookup keeps in flight when no concurrency is configured.
+const DefaultTagLookupConcurrency = 4
+
+// autoScalingTagKey is the tag Application Auto Scaling puts on the readers
+// it creates.
+const autoScalingTagKey = "application-autoscaling:resourceId"
+
+// TagLookupConfig bounds the ListTagsForResource calls GetClusterInfo makes to
+// find autoscaled instances. On accounts with many clusters these calls are
+// the first to be throttled.
+type TagLookupConfig struct {
+	// Concurrency is the number of calls in flight at once. Zero uses
+	// DefaultTagLookupConcurrency.
+	Concurrency int
+	// RatePerSecond caps how many calls start per second. Zero is unlimited.
+	RatePerSecond int
+}
+
+// batchCheckAutoScaled checks multiple instance ARNs for autoscaling tags.
+// Returns a map of ARN -> isAutoScaled. Each ARN is looked up once, with at
+// most the configured number of calls in flight. An ARN whose lookup fails is
+// reported as not autoscaled and logged, so one throttled call does not fail
+// the whole cluster lookup.
+func (c *Client) batchCheckAutoScaled(ctx context.Context, clusterID string, arns []string) map[string]bool {
+	result := make(map[string]bool, len(arns))
+	pending := make([]string, 0, len(arns))
+	for _, arn := range arns {
+		if _, seen := result[arn]; seen || arn == "" {
+			continue
+		}
+		result[arn] = false
+		pending = append(pending, arn)
+	}
+	if len(pending) == 0 {
+		return result
+	}
+
+	concurrency := c.tagLookup.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultTagLookupConcurrency
+	}
+	var throttle <-chan time.Time
+	if c.tagLookup.RatePerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(c.tagLookup.RatePerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failed   int
+		firstErr error
+	)
+	sem := make(chan struct{}, min(concurrency, len(pending)))
+	for i, arn := range pending {
+		if i > 0 && throttle != nil {
+			select {
+			case <-throttle:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			mu.Lock()
+			failed += len(pending) - i
+			if firstErr == nil {
+				firstErr = ctx.Err()
+			}
+			mu.Unlock()
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(arn string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			scaled, err := c.lookupAutoScaled(ctx, arn)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			result[arn] = scaled
+		}(arn)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		c.logger.Warn("instance tag lookups failed, treating those instances as not autoscaled",
+			"cluster_id", clusterID,
+			"failed", failed,
+			"total", len(pending),
+			"error", firstErr)
+	}
+	return result
+}
+
+// lookupAutoScaled reports whether the resource carries the autoscaling tag.
+func (c *Client) lookupAutoScaled(ctx context.Context, arn string) (bool, error) {
+	out, err := c.rds.ListTagsForResource(ctx, &rds.ListTagsForResourceInput{
+		ResourceName: aws.String(arn),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "list tags for %s", arn)
+	}
+	for _, tag := range out.TagList {
+		if aws.ToString(tag.Key) == autoScalingTagKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Tag keys the machine sets on the resources it creates. Operator and copied
+// tags can never override them, since cleanup and orphan detection rely on them.
+const (
+	TagKeyMachine       = "rds-maint-machine"
+	TagKeyOperationID   = "rds-maint-operation-id"
+	TagKeyRetentionDays = "rds-maint-retention-days"
+)
+
+// IsReservedTagKey reports whether a tag key is owned by the machine or by AWS.
+func IsReservedTagKey(key string) bool {
+	switch key {
+	case TagKeyMachine, TagKeyOperationID, TagKeyRetentionDays:
+		return true
+	}
+	return strings.HasPrefix(key, "aws:")
+}
+
+// ValidateTags checks operator-supplied tags before they are put on resources.
+func ValidateTags(tags map[string]string) error {
+	for key := range tags {
+		if key == "" {
+			return errors.Wrap(internalerrors.ErrInvalidParameter, "tag keys must not be empty")
+		}
+		if IsReservedTagKey(key) {
+			return errors.Wrapf(internalerrors.ErrInvalidParameter, "tag key %q is reserved", key)
+		}
+	}
+	return nil
+}
+
+// MergeTags builds a tag list from the given maps, later maps overriding
+// earlier ones, and then the reserved tags on top so they always win.
+// Reserved keys in the merged maps are dropped. The list is sorted by key.
+func MergeTags(reserved map[string]string, tags ...map[string]string) []types.Tag {
+	merged := make(map[string]string)
+	for _, m := range tags {
+		for key, value := range m {
+			if !IsReservedTagKey(key) {
+				merged[key] = value
+			}
+		}
+	}
+	for key, value := range reserved {
+		merged[key] = value
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, types.Tag{Key: aws.String(key), Value: aws.String(merged[key])})
+	}
+	return list
+}
+
+// ListTagsForResource returns the tags on an RDS resource.
+func (c *Client) ListTagsForResource(ctx context.Context, arn string) (map[string]string, error) {
+	out, err := c.rds.ListTagsForResource(ctx, &rds.ListTagsForResourceInput{
+		ResourceName: aws.String(arn),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "list tags for %s", arn)
+	}
+	tags := make(map[string]string, len(out.TagList))
+	for _, tag := range out.TagList {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// GetInstanceTags returns the tags on an instance that can be copied onto
+// another resource. Reserved keys and the autoscaling tag are left out.
+func (c *Client) GetInstanceTags(ctx context.Context, instanceID string) (map[string]string, error) {
+	out, err := c.rds.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(instanceID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBInstanceNotFound") {
+			return nil, errors.Wrap(internalerrors.ErrInstanceNotFound, instanceID)
+		}
+		return nil, errors.Wrap(err, "describe instance")
+	}
+	if len(out.DBInstances) == 0 {
+		return nil, errors.Wrap(internalerrors.ErrInstanceNotFound, instanceID)
+	}
+
+	tags, err := c.ListTagsForResource(ctx, aws.ToString(out.DBInstances[0].DBInstanceArn))
+	if err != nil {
+		return nil, err
+	}
+	for key := range tags {
+		if IsReservedTagKey(key) || key == autoScalingTagKey {
+			delete(tags, key)
+		}
+	}
+	return tags, nil
+}
+
+// GetClusterTags returns the tags on a cluster that can be copied onto
+// another resource. Reserved keys are left out.
+func (c *Client) GetClusterTags(ctx context.Context, clusterID string) (map[string]string, error) {
+	out, err := c.rds.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{
+		DBClusterIdentifier: aws.String(clusterID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "DBClusterNotFound") {
+			return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+		}
+		return nil, errors.Wrap(err, "describe cluster")
+	}
+	if len(out.DBClusters) == 0 {
+		return nil, errors.Wrap(internalerrors.ErrClusterNotFound, clusterID)
+	}
+
+	tags, err := c.ListTagsForResource(ctx, aws.ToString(out.DBClusters[0].DBClusterArn))
+	if err != nil {
+		return nil, err
+	}
+	for key := range tags {
+		if IsReservedTagKey(key) {
+			delete(tags, key)
+		}
+	}
+	return tags, nil
+}
+
+// AddTagsToResource adds tags to an RDS resource, replacing the values of
+// keys it already has. Reserved keys are never changed.
+func (c *Client) AddTagsToResource(ctx context.Context, arn string, tags map[string]string) error {
+	list := MergeTags(nil, tags)
+	if len(list) == 0 {
+		return nil
+	}
+	_, err := c.rds.AddTagsToResource(ctx, &rds.AddTagsToResourceInput{
+		ResourceName: aws.String(arn),
+		Tags:         list,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "add tags to %s", arn)
+	}
+	return nil
+}
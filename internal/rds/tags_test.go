@@ -0,0 +1,186 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestClient_AutoScaledTagLookup(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL:   server.URL,
+		TagLookup: TagLookupConfig{Concurrency: 4},
+		Logger:    logger,
+	})
+	ctx := context.Background()
+
+	autoScaled := func() map[string]bool {
+		t.Helper()
+		got := make(map[string]bool)
+		cluster, err := client.GetClusterInfo(ctx, "demo-autoscaled")
+		if err != nil {
+			t.Fatalf("GetClusterInfo failed: %v", err)
+		}
+		for _, inst := range cluster.Instances {
+			got[inst.InstanceID] = inst.IsAutoScaled
+		}
+		return got
+	}
+
+	got := autoScaled()
+	for id, want := range map[string]bool{
+		"demo-autoscaled-writer":   false,
+		"demo-autoscaled-reader-1": false,
+		"demo-autoscaled-asg-1":    true,
+		"demo-autoscaled-asg-2":    true,
+	} {
+		if got[id] != want {
+			t.Errorf("%s IsAutoScaled = %v, want %v", id, got[id], want)
+		}
+	}
+
+	t.Run("lookups run concurrently", func(t *testing.T) {
+		faultID := state.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeDelay,
+			Action:      "ListTagsForResource",
+			Probability: 1,
+			DelayMs:     200,
+			Enabled:     true,
+		})
+		defer state.Faults().RemoveFault(faultID)
+
+		// Four serial lookups would take 800ms.
+		start := time.Now()
+		autoScaled()
+		if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+			t.Errorf("cluster lookup took %v, want the four tag lookups to overlap", elapsed)
+		}
+	})
+
+	t.Run("throttled lookups return partial results", func(t *testing.T) {
+		faultID := state.Faults().AddFault(mock.Fault{
+			Type:        mock.FaultTypeAPIError,
+			Action:      "ListTagsForResource",
+			Probability: 1,
+			ErrorCode:   "Throttling",
+			ErrorMsg:    "Rate exceeded",
+			Enabled:     true,
+		})
+		defer state.Faults().RemoveFault(faultID)
+
+		got := autoScaled()
+		if len(got) != 4 {
+			t.Fatalf("got %d instances, want 4", len(got))
+		}
+		for id, scaled := range got {
+			if scaled {
+				t.Errorf("%s IsAutoScaled = true, want false when its tags could not be read", id)
+			}
+		}
+	})
+}
+
+func TestMergeTags(t *testing.T) {
+	got := MergeTags(
+		map[string]string{TagKeyMachine: "temp-instance", TagKeyOperationID: "op-1"},
+		map[string]string{"Environment": "prod", "Team": "data", TagKeyOperationID: "copied"},
+		map[string]string{"Team": "dba", TagKeyMachine: "override", "aws:cloudformation:stack-name": "x"},
+	)
+
+	tags := make(map[string]string, len(got))
+	var keys []string
+	for _, tag := range got {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		keys = append(keys, aws.ToString(tag.Key))
+	}
+	want := map[string]string{
+		"Environment":     "prod",
+		"Team":            "dba",
+		TagKeyMachine:     "temp-instance",
+		TagKeyOperationID: "op-1",
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("MergeTags() = %v, want %v", tags, want)
+	}
+	if !reflect.DeepEqual(keys, []string{"Environment", "Team", TagKeyMachine, TagKeyOperationID}) {
+		t.Errorf("MergeTags() keys = %v, want sorted", keys)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	if err := ValidateTags(map[string]string{"CostCenter": "42"}); err != nil {
+		t.Errorf("ValidateTags() = %v, want nil", err)
+	}
+	for _, key := range []string{"", TagKeyMachine, TagKeyOperationID, "aws:createdBy"} {
+		err := ValidateTags(map[string]string{key: "x"})
+		if !errors.Is(err, internalerrors.ErrInvalidParameter) {
+			t.Errorf("ValidateTags(%q) = %v, want ErrInvalidParameter", key, err)
+		}
+	}
+}
+
+func TestClient_InstanceTags(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+		Logger:  logger,
+	})
+	ctx := context.Background()
+	arn := "arn:aws:rds:us-east-1:123456789012:db:demo-multi-writer"
+
+	if err := client.AddTagsToResource(ctx, arn, map[string]string{"CostCenter": "42", TagKeyMachine: "x"}); err != nil {
+		t.Fatalf("AddTagsToResource failed: %v", err)
+	}
+	if got := state.ResourceTags(arn)[TagKeyMachine]; got != "" {
+		t.Errorf("AddTagsToResource set reserved tag %s=%s", TagKeyMachine, got)
+	}
+
+	got, err := client.GetInstanceTags(ctx, "demo-multi-writer")
+	if err != nil {
+		t.Fatalf("GetInstanceTags failed: %v", err)
+	}
+	want := map[string]string{"Environment": "demo", "Team": "platform", "CostCenter": "42"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetInstanceTags() = %v, want %v", got, want)
+	}
+
+	if _, err := client.GetInstanceTags(ctx, "no-such-instance"); !errors.Is(err, internalerrors.ErrInstanceNotFound) {
+		t.Errorf("GetInstanceTags(missing) = %v, want ErrInstanceNotFound", err)
+	}
+}
@@ -0,0 +1,55 @@
+package rds
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/cockroachdb/errors"
+)
+
+// tempInstanceTagValue is the TagKeyMachine value on temp instances.
+const tempInstanceTagValue = "temp-instance"
+
+// TempInstanceInfo describes a temp instance created by the machine.
+type TempInstanceInfo struct {
+	InstanceID  string    `json:"instance_id"`
+	ClusterID   string    `json:"cluster_id"`
+	OperationID string    `json:"operation_id"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ListTempInstances returns the instances in the region tagged as temp
+// instances by the machine, with the operation that created each.
+func (c *Client) ListTempInstances(ctx context.Context) ([]TempInstanceInfo, error) {
+	var instances []TempInstanceInfo
+	input := &rds.DescribeDBInstancesInput{}
+	for {
+		out, err := c.rds.DescribeDBInstances(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "describe instances")
+		}
+		for _, inst := range out.DBInstances {
+			tags := make(map[string]string, len(inst.TagList))
+			for _, tag := range inst.TagList {
+				tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			if tags[TagKeyMachine] != tempInstanceTagValue {
+				continue
+			}
+			instances = append(instances, TempInstanceInfo{
+				InstanceID:  aws.ToString(inst.DBInstanceIdentifier),
+				ClusterID:   aws.ToString(inst.DBClusterIdentifier),
+				OperationID: tags[TagKeyOperationID],
+				Status:      aws.ToString(inst.DBInstanceStatus),
+				CreatedAt:   aws.ToTime(inst.InstanceCreateTime),
+			})
+		}
+		if aws.ToString(out.Marker) == "" {
+			return instances, nil
+		}
+		input.Marker = out.Marker
+	}
+}
@@ -0,0 +1,65 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+// TestClient_ListTempInstances verifies that only instances tagged as temp
+// instances are listed, with the operation that created them.
+func TestClient_ListTempInstances(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 1,
+		},
+		BaseURL: server.URL,
+		Logger:  logger,
+	})
+	ctx := context.Background()
+
+	before := time.Now().Add(-time.Second)
+	_, err := client.CreateClusterInstance(ctx, CreateInstanceParams{
+		ClusterID:    "demo-multi",
+		InstanceID:   "demo-multi-maint-temp",
+		InstanceType: "db.r6g.large",
+		Engine:       "aurora-postgresql",
+		OperationID:  "op-1234",
+		Tags:         map[string]string{"Team": "platform"},
+	})
+	if err != nil {
+		t.Fatalf("CreateClusterInstance failed: %v", err)
+	}
+
+	temps, err := client.ListTempInstances(ctx)
+	if err != nil {
+		t.Fatalf("ListTempInstances failed: %v", err)
+	}
+	if len(temps) != 1 {
+		t.Fatalf("got %d temp instances, want 1: %+v", len(temps), temps)
+	}
+	got := temps[0]
+	if got.InstanceID != "demo-multi-maint-temp" || got.ClusterID != "demo-multi" || got.OperationID != "op-1234" {
+		t.Errorf("temp instance = %+v", got)
+	}
+	if got.CreatedAt.Before(before) {
+		t.Errorf("CreatedAt = %v, want after %v", got.CreatedAt, before)
+	}
+}
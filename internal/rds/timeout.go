@@ -0,0 +1,66 @@
+package rds
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+)
+
+// DefaultCallTimeout bounds a single RDS API call when no timeout is configured.
+const DefaultCallTimeout = 30 * time.Second
+
+// CallTimeouts bounds how long a single RDS API call may take, including every
+// retry attempt the SDK makes for it. A call that runs past its timeout fails
+// with context.DeadlineExceeded so a poll loop can move on to its next tick
+// instead of hanging until the outer wait timeout.
+type CallTimeouts struct {
+	// Default applies to every action without an override. Zero uses
+	// DefaultCallTimeout; negative disables the timeout.
+	Default time.Duration
+	// PerAction overrides Default for specific API actions, keyed by the RDS
+	// action name (e.g. "DescribeBlueGreenDeployments").
+	PerAction map[string]time.Duration
+}
+
+// For returns the timeout for an API action, or zero if calls to it are unbounded.
+func (t CallTimeouts) For(action string) time.Duration {
+	if d, ok := t.PerAction[action]; ok {
+		return max(d, 0)
+	}
+	if t.Default == 0 {
+		return DefaultCallTimeout
+	}
+	return max(t.Default, 0)
+}
+
+// callTimeoutMiddleware derives the per-call deadline. It sits at the front of
+// the initialize step so the retry middleware, which runs later in the
+// finalize step, sees the deadline and does not schedule attempts past it. A
+// shorter deadline already on the caller's context still wins.
+type callTimeoutMiddleware struct {
+	timeout time.Duration
+}
+
+func (*callTimeoutMiddleware) ID() string { return "RDSMaintCallTimeout" }
+
+func (m *callTimeoutMiddleware) HandleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+	middleware.InitializeOutput, middleware.Metadata, error,
+) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	return next.HandleInitialize(ctx, in)
+}
+
+// withCallTimeouts returns an API option that installs the timeout for the
+// action a stack is built for. Stacks are built per operation and named after
+// it, so the action is known before the call is made.
+func withCallTimeouts(timeouts CallTimeouts) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		d := timeouts.For(stack.ID())
+		if d <= 0 {
+			return nil
+		}
+		return stack.Initialize.Add(&callTimeoutMiddleware{timeout: d}, middleware.Before)
+	}
+}
@@ -0,0 +1,97 @@
+package rds
+
+import (
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cockroachdb/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/mock"
+)
+
+func TestCallTimeouts_For(t *testing.T) {
+	timeouts := CallTimeouts{
+		Default: 20 * time.Second,
+		PerAction: map[string]time.Duration{
+			"DescribeBlueGreenDeployments": 5 * time.Second,
+			"CreateDBInstance":             -1,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		timeouts CallTimeouts
+		action   string
+		want     time.Duration
+	}{
+		{"override", timeouts, "DescribeBlueGreenDeployments", 5 * time.Second},
+		{"override disables", timeouts, "CreateDBInstance", 0},
+		{"default", timeouts, "DescribeDBClusters", 20 * time.Second},
+		{"zero value", CallTimeouts{}, "DescribeDBClusters", DefaultCallTimeout},
+		{"disabled", CallTimeouts{Default: -1}, "DescribeDBClusters", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.timeouts.For(tt.action); got != tt.want {
+				t.Errorf("For(%q) = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_CallTimeout(t *testing.T) {
+	state := mock.NewState(mock.TimingConfig{BaseWaitMs: 10, FastMode: true})
+	state.SeedDemoClusters()
+	state.Start()
+	defer state.Stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := httptest.NewServer(mock.NewServer(state, logger, false))
+	defer server.Close()
+
+	faultID := state.Faults().AddFault(mock.Fault{
+		Type:        mock.FaultTypeDelay,
+		Action:      "DescribeDBClusters",
+		Probability: 1,
+		DelayMs:     2000,
+		Enabled:     true,
+	})
+
+	client := NewClient(ClientConfig{
+		AWSConfig: aws.Config{
+			Region:           "us-east-1",
+			Credentials:      aws.AnonymousCredentials{},
+			RetryMaxAttempts: 3,
+		},
+		BaseURL: server.URL,
+		CallTimeouts: CallTimeouts{
+			Default:   5 * time.Second,
+			PerAction: map[string]time.Duration{"DescribeDBClusters": 200 * time.Millisecond},
+		},
+	})
+	ctx := context.Background()
+
+	start := time.Now()
+	_, err := client.GetClusterInfo(ctx, "demo-single")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded from slow call, got %v", err)
+	}
+	// The deadline covers retries too, so the call must not take 3x the timeout.
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("slow call took %v, want it cut off near 200ms", elapsed)
+	}
+
+	// Once the API responds normally the next call succeeds on the same client.
+	state.Faults().RemoveFault(faultID)
+	info, err := client.GetClusterInfo(ctx, "demo-single")
+	if err != nil {
+		t.Fatalf("GetClusterInfo after fault removed: %v", err)
+	}
+	if info.ClusterID != "demo-single" {
+		t.Errorf("ClusterID = %q, want demo-single", info.ClusterID)
+	}
+}
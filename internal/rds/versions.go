@@ -0,0 +1,98 @@
+package rds
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+)
+
+// auroraMySQLMarker separates the MySQL-compatible version from the Aurora
+// release in Aurora MySQL versions, e.g. 8.0.mysql_aurora.3.04.0.
+const auroraMySQLMarker = ".mysql_aurora."
+
+// EngineVersion is an engine version split into numeric components so that
+// versions of the same engine can be ordered.
+type EngineVersion struct {
+	// Raw is the version as RDS reports it.
+	Raw string
+	// Major is the part parameter group families are named after: "15" for
+	// Aurora PostgreSQL 15.4, "9.6" for 9.6.22 and "8.0" for Aurora MySQL
+	// 8.0.mysql_aurora.3.04.0.
+	Major string
+
+	parts []int
+}
+
+// ParseEngineVersion parses an Aurora PostgreSQL or Aurora MySQL version.
+// PostgreSQL versions are dotted numbers. Aurora MySQL versions are ordered
+// by their MySQL version and then by the Aurora release after it.
+func ParseEngineVersion(engine, version string) (EngineVersion, error) {
+	community, aurora, _ := strings.Cut(version, auroraMySQLMarker)
+	parsed := EngineVersion{Raw: version}
+
+	fields := strings.Split(community, ".")
+	if aurora != "" {
+		fields = append(fields, strings.Split(aurora, ".")...)
+	}
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return EngineVersion{}, errors.Wrapf(internalerrors.ErrInvalidParameter,
+				"cannot parse %s engine version %q", engine, version)
+		}
+		parsed.parts = append(parsed.parts, n)
+	}
+
+	// MySQL majors, and PostgreSQL majors before 10, have two components.
+	majorParts := 1
+	if strings.Contains(engine, "mysql") || parsed.parts[0] < 10 {
+		majorParts = 2
+	}
+	communityFields := strings.Split(community, ".")
+	parsed.Major = strings.Join(communityFields[:min(majorParts, len(communityFields))], ".")
+	return parsed, nil
+}
+
+// EngineMajorVersion returns the major version of an engine version as
+// parameter group families name it, e.g. "5.7" for Aurora MySQL
+// 5.7.mysql_aurora.2.11.2. A version that does not parse falls back to its
+// first component.
+func EngineMajorVersion(engine, version string) string {
+	if parsed, err := ParseEngineVersion(engine, version); err == nil {
+		return parsed.Major
+	}
+	return MajorVersion(version)
+}
+
+// VersionNameSuffix returns the version in a form resource names accept,
+// which allow only letters, digits and hyphens: "15-4" for 15.4 and
+// "8-0-mysql-aurora-3-04-0" for 8.0.mysql_aurora.3.04.0.
+func VersionNameSuffix(version string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, version)
+}
+
+// Compare returns -1, 0 or +1 as v is older than, the same as, or newer than
+// other. Missing components count as zero, so 15 and 15.0 are the same.
+func (v EngineVersion) Compare(other EngineVersion) int {
+	for i := range max(len(v.parts), len(other.parts)) {
+		var a, b int
+		if i < len(v.parts) {
+			a = v.parts[i]
+		}
+		if i < len(other.parts) {
+			b = other.parts[i]
+		}
+		if c := cmp.Compare(a, b); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
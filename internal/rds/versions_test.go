@@ -0,0 +1,86 @@
+package rds
+
+import "testing"
+
+func TestEngineVersion_Compare(t *testing.T) {
+	tests := []struct {
+		engine string
+		a, b   string
+		want   int
+	}{
+		{engine: "aurora-postgresql", a: "15.4", b: "15.4", want: 0},
+		{engine: "aurora-postgresql", a: "15.4", b: "15.3", want: 1},
+		{engine: "aurora-postgresql", a: "15.4", b: "16.1", want: -1},
+		{engine: "aurora-postgresql", a: "15.10", b: "15.9", want: 1},
+		{engine: "aurora-postgresql", a: "15", b: "15.0", want: 0},
+		{engine: "aurora-mysql", a: "8.0.mysql_aurora.3.04.0", b: "8.0.mysql_aurora.3.05.2", want: -1},
+		{engine: "aurora-mysql", a: "8.0.mysql_aurora.3.04.0", b: "5.7.mysql_aurora.2.11.2", want: 1},
+		{engine: "aurora-mysql", a: "8.0.mysql_aurora.3.04.0", b: "8.0.mysql_aurora.3.04.0", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			a, err := ParseEngineVersion(tt.engine, tt.a)
+			if err != nil {
+				t.Fatalf("ParseEngineVersion(%s) failed: %v", tt.a, err)
+			}
+			b, err := ParseEngineVersion(tt.engine, tt.b)
+			if err != nil {
+				t.Fatalf("ParseEngineVersion(%s) failed: %v", tt.b, err)
+			}
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseEngineVersion("aurora-postgresql", "15.x"); err == nil {
+		t.Error("expected an error for a non-numeric version")
+	}
+}
+
+func TestGetDefaultParameterGroupFamily(t *testing.T) {
+	tests := []struct {
+		engine, version, want string
+	}{
+		{engine: "aurora-postgresql", version: "16.1", want: "aurora-postgresql16"},
+		{engine: "aurora-postgresql", version: "9.6.22", want: "aurora-postgresql9.6"},
+		{engine: "aurora-mysql", version: "8.0.mysql_aurora.3.04.0", want: "aurora-mysql8.0"},
+		{engine: "aurora-mysql", version: "5.7.mysql_aurora.2.11.2", want: "aurora-mysql5.7"},
+	}
+	for _, tt := range tests {
+		if got := GetDefaultParameterGroupFamily(tt.engine, tt.version); got != tt.want {
+			t.Errorf("GetDefaultParameterGroupFamily(%s, %s) = %s, want %s", tt.engine, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestEngineMajorVersion(t *testing.T) {
+	tests := []struct {
+		engine, version, want string
+	}{
+		{engine: "aurora-postgresql", version: "15.4", want: "15"},
+		{engine: "aurora-postgresql", version: "9.6.22", want: "9.6"},
+		{engine: "aurora-mysql", version: "5.7.mysql_aurora.2.11.2", want: "5.7"},
+		{engine: "aurora-mysql", version: "8.0.mysql_aurora.3.04.0", want: "8.0"},
+		{engine: "aurora-postgresql", version: "15.x", want: "15"},
+	}
+	for _, tt := range tests {
+		if got := EngineMajorVersion(tt.engine, tt.version); got != tt.want {
+			t.Errorf("EngineMajorVersion(%s, %s) = %s, want %s", tt.engine, tt.version, got, tt.want)
+		}
+	}
+
+	// 5.6 and 5.7 share a first component but are different majors.
+	if EngineMajorVersion("aurora-mysql", "5.7.mysql_aurora.2.11.2") == EngineMajorVersion("aurora-mysql", "5.6.mysql_aurora.1.23.4") {
+		t.Error("5.6 and 5.7 should be different major versions")
+	}
+}
+
+func TestVersionNameSuffix(t *testing.T) {
+	if got := VersionNameSuffix("15.4"); got != "15-4" {
+		t.Errorf("VersionNameSuffix(15.4) = %s, want 15-4", got)
+	}
+	if got := VersionNameSuffix("8.0.mysql_aurora.3.04.0"); got != "8-0-mysql-aurora-3-04-0" {
+		t.Errorf("VersionNameSuffix(8.0.mysql_aurora.3.04.0) = %s, want 8-0-mysql-aurora-3-04-0", got)
+	}
+}
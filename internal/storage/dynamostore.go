@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// DynamoDB item layout. Everything lives in one table with a string partition
+// key "pk" and string sort key "sk":
+//
+//	pk = OP#{operation-id}      sk = OPERATION                    # operation state + version
+//	pk = OP#{operation-id}      sk = EVENT#{unix-nanos}#{event-id} # one item per event
+//	pk = TEMPLATE#{template-id} sk = TEMPLATE                     # saved operation template
+//	pk = BATCH#{batch-id}       sk = BATCH                        # batch operation tracking record
+//
+// Each item stores the JSON-encoded record in "data".
+const (
+	ddbAttrPK      = "pk"
+	ddbAttrSK      = "sk"
+	ddbAttrData    = "data"
+	ddbAttrVersion = "version"
+
+	ddbOperationSK = "OPERATION"
+	ddbTemplateSK  = "TEMPLATE"
+	ddbBatchSK     = "BATCH"
+	ddbEventPrefix = "EVENT#"
+
+	// ddbBatchSize is the most items BatchWriteItem accepts per call.
+	ddbBatchSize = 25
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, in *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// DynamoStore implements Store on a DynamoDB table, so several processes can
+// share operation state.
+//
+// Operations carry a version attribute for optimistic concurrency. The store
+// remembers the version of every operation it has read or written; a save is
+// conditional on the table still holding that version, so a process working
+// from a stale copy gets ErrConcurrentModification instead of overwriting
+// another process's progress.
+type DynamoStore struct {
+	client DynamoDBAPI
+	table  string
+
+	mu       sync.Mutex
+	versions map[string]int64 // operation ID -> version last seen
+}
+
+// NewDynamoStore creates a store backed by the named DynamoDB table.
+func NewDynamoStore(client DynamoDBAPI, table string) *DynamoStore {
+	return &DynamoStore{
+		client:   client,
+		table:    table,
+		versions: make(map[string]int64),
+	}
+}
+
+func operationPK(id string) string { return "OP#" + id }
+func templatePK(id string) string  { return "TEMPLATE#" + id }
+func batchPK(id string) string     { return "BATCH#" + id }
+
+func ddbKey(pk, sk string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		ddbAttrPK: &ddbtypes.AttributeValueMemberS{Value: pk},
+		ddbAttrSK: &ddbtypes.AttributeValueMemberS{Value: sk},
+	}
+}
+
+// SaveOperation persists the current state of an operation. It fails with
+// ErrConcurrentModification if another writer has saved the operation since
+// this store last saw it.
+func (s *DynamoStore) SaveOperation(ctx context.Context, op *types.Operation) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrap(err, "marshal operation")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := ddbKey(operationPK(op.ID), ddbOperationSK)
+	item[ddbAttrData] = &ddbtypes.AttributeValueMemberS{Value: string(data)}
+
+	in := &dynamodb.PutItemInput{
+		TableName:                aws.String(s.table),
+		Item:                     item,
+		ExpressionAttributeNames: map[string]string{"#version": ddbAttrVersion},
+	}
+	current, known := s.versions[op.ID]
+	if known {
+		in.ConditionExpression = aws.String("#version = :expected")
+		in.ExpressionAttributeValues = map[string]ddbtypes.AttributeValue{
+			":expected": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(current, 10)},
+		}
+	} else {
+		in.ConditionExpression = aws.String("attribute_not_exists(#version)")
+	}
+	item[ddbAttrVersion] = &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(current+1, 10)}
+
+	if _, err := s.client.PutItem(ctx, in); err != nil {
+		var conditionFailed *ddbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return errors.Wrapf(internalerrors.ErrConcurrentModification, "operation %s", op.ID)
+		}
+		return errors.Wrap(err, "put operation")
+	}
+
+	s.versions[op.ID] = current + 1
+	return nil
+}
+
+// GetOperation retrieves an operation by ID. It returns nil if the operation
+// does not exist.
+func (s *DynamoStore) GetOperation(ctx context.Context, id string) (*types.Operation, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            ddbKey(operationPK(id), ddbOperationSK),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "get operation")
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	return s.decodeOperation(out.Item)
+}
+
+// decodeOperation unmarshals an operation item and records its version.
+func (s *DynamoStore) decodeOperation(item map[string]ddbtypes.AttributeValue) (*types.Operation, error) {
+	var op types.Operation
+	if err := json.Unmarshal([]byte(stringAttr(item, ddbAttrData)), &op); err != nil {
+		return nil, errors.Wrap(err, "unmarshal operation")
+	}
+	if err := op.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validation failed")
+	}
+
+	version, err := strconv.ParseInt(numberAttr(item, ddbAttrVersion), 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse version of operation %s", op.ID)
+	}
+	s.mu.Lock()
+	s.versions[op.ID] = version
+	s.mu.Unlock()
+
+	return &op, nil
+}
+
+// ListOperations returns all operations. Items that cannot be decoded are
+// skipped with a log entry, as FileStore skips corrupted files.
+func (s *DynamoStore) ListOperations(ctx context.Context) ([]*types.Operation, error) {
+	items, err := s.scanBySortKey(ctx, ddbOperationSK)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan operations")
+	}
+
+	ops := make([]*types.Operation, 0, len(items))
+	for _, item := range items {
+		op, err := s.decodeOperation(item)
+		if err != nil {
+			slog.Error("skipping corrupted operation item",
+				"pk", stringAttr(item, ddbAttrPK),
+				"error", err)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// DeleteOperation removes an operation and all its events.
+func (s *DynamoStore) DeleteOperation(ctx context.Context, id string) error {
+	items, err := s.queryPartition(ctx, operationPK(id), "")
+	if err != nil {
+		return errors.Wrap(err, "query operation items")
+	}
+
+	for start := 0; start < len(items); start += ddbBatchSize {
+		end := min(start+ddbBatchSize, len(items))
+		requests := make([]ddbtypes.WriteRequest, 0, end-start)
+		for _, item := range items[start:end] {
+			requests = append(requests, ddbtypes.WriteRequest{
+				DeleteRequest: &ddbtypes.DeleteRequest{
+					Key: ddbKey(stringAttr(item, ddbAttrPK), stringAttr(item, ddbAttrSK)),
+				},
+			})
+		}
+		if err := s.batchWrite(ctx, requests); err != nil {
+			return errors.Wrap(err, "delete operation items")
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.versions, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// batchWrite sends write requests, resubmitting any DynamoDB left unprocessed.
+func (s *DynamoStore) batchWrite(ctx context.Context, requests []ddbtypes.WriteRequest) error {
+	pending := map[string][]ddbtypes.WriteRequest{s.table: requests}
+	for len(pending[s.table]) > 0 {
+		out, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+		if err != nil {
+			return err
+		}
+		pending = out.UnprocessedItems
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendEvent adds an event to an operation's event log. The sort key orders
+// events by timestamp, with the event ID breaking ties.
+func (s *DynamoStore) AppendEvent(ctx context.Context, event types.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "marshal event")
+	}
+
+	sk := fmt.Sprintf("%s%020d#%s", ddbEventPrefix, event.Timestamp.UnixNano(), event.ID)
+	item := ddbKey(operationPK(event.OperationID), sk)
+	item[ddbAttrData] = &ddbtypes.AttributeValueMemberS{Value: string(data)}
+
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	}); err != nil {
+		return errors.Wrap(err, "put event")
+	}
+	return nil
+}
+
+// GetEvents retrieves all events for an operation in the order they occurred.
+func (s *DynamoStore) GetEvents(ctx context.Context, operationID string) ([]types.Event, error) {
+	items, err := s.queryPartition(ctx, operationPK(operationID), ddbEventPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "query events")
+	}
+
+	events := make([]types.Event, 0, len(items))
+	for _, item := range items {
+		var event types.Event
+		if err := json.Unmarshal([]byte(stringAttr(item, ddbAttrData)), &event); err != nil {
+			slog.Warn("skipping corrupted event item",
+				"operation_id", operationID,
+				"sk", stringAttr(item, ddbAttrSK),
+				"error", err)
+			continue
+		}
+		if err := event.Validate(); err != nil {
+			slog.Warn("skipping invalid event item",
+				"operation_id", operationID,
+				"sk", stringAttr(item, ddbAttrSK),
+				"error", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// LoadAll loads all operations and events from the table.
+func (s *DynamoStore) LoadAll(ctx context.Context) (map[string]*types.Operation, map[string][]types.Event, error) {
+	ops, err := s.ListOperations(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "list operations")
+	}
+
+	operations := make(map[string]*types.Operation, len(ops))
+	events := make(map[string][]types.Event, len(ops))
+	for _, op := range ops {
+		operations[op.ID] = op
+
+		opEvents, err := s.GetEvents(ctx, op.ID)
+		if err != nil {
+			slog.Error("failed to load events for operation, skipping",
+				"operation_id", op.ID,
+				"error", err)
+			continue
+		}
+		events[op.ID] = opEvents
+	}
+	return operations, events, nil
+}
+
+// SaveTemplate persists an operation template.
+func (s *DynamoStore) SaveTemplate(ctx context.Context, tmpl *types.Template) error {
+	data, err := json.Marshal(tmpl)
+	if err != nil {
+		return errors.Wrap(err, "marshal template")
+	}
+
+	item := ddbKey(templatePK(tmpl.ID), ddbTemplateSK)
+	item[ddbAttrData] = &ddbtypes.AttributeValueMemberS{Value: string(data)}
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	}); err != nil {
+		return errors.Wrap(err, "put template")
+	}
+	return nil
+}
+
+// ListTemplates returns all saved operation templates.
+// Items that cannot be decoded are skipped with a warning.
+func (s *DynamoStore) ListTemplates(ctx context.Context) ([]*types.Template, error) {
+	items, err := s.scanBySortKey(ctx, ddbTemplateSK)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan templates")
+	}
+
+	var templates []*types.Template
+	for _, item := range items {
+		var tmpl types.Template
+		if err := json.Unmarshal([]byte(stringAttr(item, ddbAttrData)), &tmpl); err != nil {
+			slog.Warn("skipping corrupted template item", "pk", stringAttr(item, ddbAttrPK), "error", err)
+			continue
+		}
+		if err := tmpl.Validate(); err != nil {
+			slog.Warn("skipping invalid template item", "pk", stringAttr(item, ddbAttrPK), "error", err)
+			continue
+		}
+		templates = append(templates, &tmpl)
+	}
+	return templates, nil
+}
+
+// DeleteTemplate removes an operation template.
+func (s *DynamoStore) DeleteTemplate(ctx context.Context, id string) error {
+	if _, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       ddbKey(templatePK(id), ddbTemplateSK),
+	}); err != nil {
+		return errors.Wrap(err, "delete template")
+	}
+	return nil
+}
+
+// SaveBatch persists a batch operation.
+func (s *DynamoStore) SaveBatch(ctx context.Context, batch *types.BatchOperation) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return errors.Wrap(err, "marshal batch")
+	}
+
+	item := ddbKey(batchPK(batch.ID), ddbBatchSK)
+	item[ddbAttrData] = &ddbtypes.AttributeValueMemberS{Value: string(data)}
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	}); err != nil {
+		return errors.Wrap(err, "put batch")
+	}
+	return nil
+}
+
+// ListBatches returns all saved batch operations.
+// Items that cannot be decoded are skipped with a warning.
+func (s *DynamoStore) ListBatches(ctx context.Context) ([]*types.BatchOperation, error) {
+	items, err := s.scanBySortKey(ctx, ddbBatchSK)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan batches")
+	}
+
+	var batches []*types.BatchOperation
+	for _, item := range items {
+		var batch types.BatchOperation
+		if err := json.Unmarshal([]byte(stringAttr(item, ddbAttrData)), &batch); err != nil {
+			slog.Warn("skipping corrupted batch item", "pk", stringAttr(item, ddbAttrPK), "error", err)
+			continue
+		}
+		if err := batch.Validate(); err != nil {
+			slog.Warn("skipping invalid batch item", "pk", stringAttr(item, ddbAttrPK), "error", err)
+			continue
+		}
+		batches = append(batches, &batch)
+	}
+	return batches, nil
+}
+
+// queryPartition returns every item under a partition key whose sort key
+// starts with prefix. DynamoDB returns them in sort key order.
+func (s *DynamoStore) queryPartition(ctx context.Context, pk, prefix string) ([]map[string]ddbtypes.AttributeValue, error) {
+	in := &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": ddbAttrPK,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pk": &ddbtypes.AttributeValueMemberS{Value: pk},
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+	if prefix != "" {
+		in.KeyConditionExpression = aws.String("#pk = :pk AND begins_with(#sk, :prefix)")
+		in.ExpressionAttributeNames["#sk"] = ddbAttrSK
+		in.ExpressionAttributeValues[":prefix"] = &ddbtypes.AttributeValueMemberS{Value: prefix}
+	}
+
+	var items []map[string]ddbtypes.AttributeValue
+	for {
+		out, err := s.client.Query(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	return items, nil
+}
+
+// scanBySortKey returns every item with the given sort key.
+func (s *DynamoStore) scanBySortKey(ctx context.Context, sk string) ([]map[string]ddbtypes.AttributeValue, error) {
+	in := &dynamodb.ScanInput{
+		TableName:                aws.String(s.table),
+		FilterExpression:         aws.String("#sk = :sk"),
+		ExpressionAttributeNames: map[string]string{"#sk": ddbAttrSK},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":sk": &ddbtypes.AttributeValueMemberS{Value: sk},
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+
+	var items []map[string]ddbtypes.AttributeValue
+	for {
+		out, err := s.client.Scan(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	return items, nil
+}
+
+func stringAttr(item map[string]ddbtypes.AttributeValue, name string) string {
+	if v, ok := item[name].(*ddbtypes.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+func numberAttr(item map[string]ddbtypes.AttributeValue, name string) string {
+	if v, ok := item[name].(*ddbtypes.AttributeValueMemberN); ok {
+		return v.Value
+	}
+	return "0"
+}
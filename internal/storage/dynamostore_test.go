@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cockroachdb/errors"
+	internalerrors "github.com/mpz/devops/tools/rds-maint-machine/internal/errors"
+	"github.com/mpz/devops/tools/rds-maint-machine/internal/types"
+)
+
+// fakeDynamoDB is an in-memory table that understands the key layout and
+// condition expressions DynamoStore uses. Query and Scan return pages of two
+// items so pagination is exercised.
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]map[string]ddbtypes.AttributeValue // pk + "|" + sk -> item
+}
+
+const fakePageSize = 2
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[string]map[string]ddbtypes.AttributeValue)}
+}
+
+func fakeKey(item map[string]ddbtypes.AttributeValue) string {
+	return stringAttr(item, ddbAttrPK) + "|" + stringAttr(item, ddbAttrSK)
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: f.items[fakeKey(in.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, exists := f.items[fakeKey(in.Item)]
+	switch cond := stringValue(in.ConditionExpression); cond {
+	case "":
+	case "attribute_not_exists(#version)":
+		if exists && existing[ddbAttrVersion] != nil {
+			return nil, &ddbtypes.ConditionalCheckFailedException{}
+		}
+	case "#version = :expected":
+		want := in.ExpressionAttributeValues[":expected"].(*ddbtypes.AttributeValueMemberN).Value
+		if !exists || numberAttr(existing, ddbAttrVersion) != want {
+			return nil, &ddbtypes.ConditionalCheckFailedException{}
+		}
+	default:
+		return nil, errors.Newf("fake: unsupported condition %q", cond)
+	}
+	f.items[fakeKey(in.Item)] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, fakeKey(in.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, requests := range in.RequestItems {
+		if len(requests) > ddbBatchSize {
+			return nil, errors.Newf("fake: batch of %d exceeds %d", len(requests), ddbBatchSize)
+		}
+		for _, r := range requests {
+			delete(f.items, fakeKey(r.DeleteRequest.Key))
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Query(ctx context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	pk := in.ExpressionAttributeValues[":pk"].(*ddbtypes.AttributeValueMemberS).Value
+	prefix := ""
+	if v, ok := in.ExpressionAttributeValues[":prefix"]; ok {
+		prefix = v.(*ddbtypes.AttributeValueMemberS).Value
+	}
+	items, last := f.page(in.ExclusiveStartKey, func(item map[string]ddbtypes.AttributeValue) bool {
+		return stringAttr(item, ddbAttrPK) == pk && strings.HasPrefix(stringAttr(item, ddbAttrSK), prefix)
+	})
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: last}, nil
+}
+
+func (f *fakeDynamoDB) Scan(ctx context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	sk := in.ExpressionAttributeValues[":sk"].(*ddbtypes.AttributeValueMemberS).Value
+	items, last := f.page(in.ExclusiveStartKey, func(item map[string]ddbtypes.AttributeValue) bool {
+		return stringAttr(item, ddbAttrSK) == sk
+	})
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: last}, nil
+}
+
+// page returns the matching items after start, in key order, one page at a time.
+func (f *fakeDynamoDB) page(start map[string]ddbtypes.AttributeValue, match func(map[string]ddbtypes.AttributeValue) bool) ([]map[string]ddbtypes.AttributeValue, map[string]ddbtypes.AttributeValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.items))
+	for k, item := range f.items {
+		if match(item) && (start == nil || k > fakeKey(start)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var items []map[string]ddbtypes.AttributeValue
+	for _, k := range keys {
+		items = append(items, f.items[k])
+		if len(items) == fakePageSize && len(keys) > fakePageSize {
+			return items, ddbKey(stringAttr(f.items[k], ddbAttrPK), stringAttr(f.items[k], ddbAttrSK))
+		}
+	}
+	return items, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestDynamoStore_RoundTrip(t *testing.T) {
+	store := NewDynamoStore(newFakeDynamoDB(), "rds-maint")
+	ctx := context.Background()
+
+	for _, id := range []string{"op-1", "op-2", "op-3"} {
+		if err := store.SaveOperation(ctx, createTestOperation(id, "cluster-"+id)); err != nil {
+			t.Fatalf("SaveOperation(%s) failed: %v", id, err)
+		}
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, typ := range []string{"operation_created", "step_started", "step_completed", "operation_completed", "info"} {
+		event := types.Event{
+			ID:          "evt-" + typ,
+			OperationID: "op-1",
+			Type:        typ,
+			Message:     typ,
+			Timestamp:   base.Add(time.Duration(i) * time.Second),
+		}
+		if err := store.AppendEvent(ctx, event); err != nil {
+			t.Fatalf("AppendEvent failed: %v", err)
+		}
+	}
+
+	op, err := store.GetOperation(ctx, "op-2")
+	if err != nil || op == nil || op.ClusterID != "cluster-op-2" {
+		t.Fatalf("GetOperation = %+v, %v", op, err)
+	}
+	if missing, err := store.GetOperation(ctx, "missing"); err != nil || missing != nil {
+		t.Errorf("GetOperation(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	operations, events, err := store.LoadAll(ctx)
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(operations) != 3 {
+		t.Errorf("loaded %d operations, want 3", len(operations))
+	}
+	if got := events["op-1"]; len(got) != 5 || got[0].Type != "operation_created" || got[4].Type != "info" {
+		t.Errorf("events for op-1 out of order or missing: %+v", got)
+	}
+
+	if err := store.DeleteOperation(ctx, "op-1"); err != nil {
+		t.Fatalf("DeleteOperation failed: %v", err)
+	}
+	if op, _ := store.GetOperation(ctx, "op-1"); op != nil {
+		t.Error("operation still present after delete")
+	}
+	if evts, _ := store.GetEvents(ctx, "op-1"); len(evts) != 0 {
+		t.Errorf("%d events left after delete", len(evts))
+	}
+
+	tmpl := &types.Template{ID: "tmpl-1", Name: "Resize", Type: types.OperationTypeInstanceTypeChange, CreatedAt: base}
+	if err := store.SaveTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	templates, err := store.ListTemplates(ctx)
+	if err != nil || len(templates) != 1 || templates[0].Name != "Resize" {
+		t.Fatalf("ListTemplates = %+v, %v", templates, err)
+	}
+	if err := store.DeleteTemplate(ctx, "tmpl-1"); err != nil {
+		t.Fatalf("DeleteTemplate failed: %v", err)
+	}
+	if templates, _ := store.ListTemplates(ctx); len(templates) != 0 {
+		t.Errorf("%d templates left after delete", len(templates))
+	}
+
+	batch := &types.BatchOperation{
+		ID:       "batch-1",
+		Type:     types.OperationTypeInstanceCycle,
+		Children: []types.BatchChild{{ClusterID: "cluster-a", OperationID: "op-2"}},
+	}
+	if err := store.SaveBatch(ctx, batch); err != nil {
+		t.Fatalf("SaveBatch failed: %v", err)
+	}
+	batches, err := store.ListBatches(ctx)
+	if err != nil || len(batches) != 1 || batches[0].Children[0].OperationID != "op-2" {
+		t.Fatalf("ListBatches = %+v, %v", batches, err)
+	}
+}
+
+func TestDynamoStore_OptimisticConcurrency(t *testing.T) {
+	table := newFakeDynamoDB()
+	ctx := context.Background()
+
+	// Two processes sharing the table, as two concurrent invocations would.
+	first := NewDynamoStore(table, "rds-maint")
+	second := NewDynamoStore(table, "rds-maint")
+
+	op := createTestOperation("op-1", "cluster-a")
+	if err := first.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("initial save failed: %v", err)
+	}
+
+	// A process that never read the operation cannot create it over the top.
+	if err := second.SaveOperation(ctx, createTestOperation("op-1", "cluster-a")); !errors.Is(err, internalerrors.ErrConcurrentModification) {
+		t.Fatalf("blind save error = %v, want ErrConcurrentModification", err)
+	}
+
+	stale, err := second.GetOperation(ctx, "op-1")
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+
+	op.State = types.StateRunning
+	if err := first.SaveOperation(ctx, op); err != nil {
+		t.Fatalf("save from up-to-date store failed: %v", err)
+	}
+
+	stale.State = types.StateCancelled
+	if err := second.SaveOperation(ctx, stale); !errors.Is(err, internalerrors.ErrConcurrentModification) {
+		t.Fatalf("stale save error = %v, want ErrConcurrentModification", err)
+	}
+	if current, _ := first.GetOperation(ctx, "op-1"); current.State != types.StateRunning {
+		t.Errorf("state = %s, stale write must not clobber running", current.State)
+	}
+
+	// After re-reading, the second store may save again.
+	fresh, err := second.GetOperation(ctx, "op-1")
+	if err != nil {
+		t.Fatalf("GetOperation failed: %v", err)
+	}
+	fresh.State = types.StatePaused
+	if err := second.SaveOperation(ctx, fresh); err != nil {
+		t.Fatalf("save after re-read failed: %v", err)
+	}
+}
@@ -20,12 +20,16 @@ import (
 // Structure:
 //
 //	{dataDir}/
-//	└── operations/
-//	    └── {operation-id}/
-//	        ├── operation.json           # Current operation state
-//	        └── events/
-//	            ├── 0001-{timestamp}-{type}.json
-//	            └── ...
+//	├── operations/
+//	│   └── {operation-id}/
+//	│       ├── operation.json           # Current operation state
+//	│       └── events/
+//	│           ├── 0001-{timestamp}-{type}.json
+//	│           └── ...
+//	├── templates/
+//	│   └── {template-id}.json           # Saved operation template
+//	└── batches/
+//	    └── {batch-id}.json              # Batch operation tracking record
 type FileStore struct {
 	dataDir       string
 	mu            sync.RWMutex
@@ -354,6 +358,155 @@ func (s *FileStore) LoadAll(ctx context.Context) (map[string]*types.Operation, m
 	return operations, events, nil
 }
 
+// templateFile returns the path to a template's file.
+func (s *FileStore) templateFile(templateID string) string {
+	return filepath.Join(s.dataDir, "templates", sanitizeFilename(templateID)+".json")
+}
+
+// SaveTemplate persists an operation template.
+func (s *FileStore) SaveTemplate(ctx context.Context, tmpl *types.Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dataDir, "templates"), 0755); err != nil {
+		return errors.Wrap(err, "create templates directory")
+	}
+
+	data, err := json.MarshalIndent(tmpl, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal template")
+	}
+
+	if err := atomicWriteFile(s.templateFile(tmpl.ID), data, 0644); err != nil {
+		return errors.Wrap(err, "write template file")
+	}
+
+	return nil
+}
+
+// ListTemplates returns all saved operation templates.
+// Corrupted template files are skipped with a warning.
+func (s *FileStore) ListTemplates(ctx context.Context) ([]*types.Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templatesDir := filepath.Join(s.dataDir, "templates")
+	entries, err := os.ReadDir(templatesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read templates directory")
+	}
+
+	var templates []*types.Template
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(templatesDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("skipping unreadable template file", "path", path, "error", err)
+			continue
+		}
+
+		var tmpl types.Template
+		if err := json.Unmarshal(data, &tmpl); err != nil {
+			slog.Warn("skipping corrupted template file", "path", path, "error", err)
+			continue
+		}
+		if err := tmpl.Validate(); err != nil {
+			slog.Warn("skipping invalid template file", "path", path, "error", err)
+			continue
+		}
+		templates = append(templates, &tmpl)
+	}
+
+	return templates, nil
+}
+
+// DeleteTemplate removes an operation template.
+func (s *FileStore) DeleteTemplate(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.templateFile(id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove template file")
+	}
+	return nil
+}
+
+// batchFile returns the path to a batch operation's file.
+func (s *FileStore) batchFile(batchID string) string {
+	return filepath.Join(s.dataDir, "batches", sanitizeFilename(batchID)+".json")
+}
+
+// SaveBatch persists a batch operation.
+func (s *FileStore) SaveBatch(ctx context.Context, batch *types.BatchOperation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(s.dataDir, "batches"), 0755); err != nil {
+		return errors.Wrap(err, "create batches directory")
+	}
+
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal batch")
+	}
+
+	if err := atomicWriteFile(s.batchFile(batch.ID), data, 0644); err != nil {
+		return errors.Wrap(err, "write batch file")
+	}
+
+	return nil
+}
+
+// ListBatches returns all saved batch operations.
+// Corrupted batch files are skipped with a warning.
+func (s *FileStore) ListBatches(ctx context.Context) ([]*types.BatchOperation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batchesDir := filepath.Join(s.dataDir, "batches")
+	entries, err := os.ReadDir(batchesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read batches directory")
+	}
+
+	var batches []*types.BatchOperation
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(batchesDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("skipping unreadable batch file", "path", path, "error", err)
+			continue
+		}
+
+		var batch types.BatchOperation
+		if err := json.Unmarshal(data, &batch); err != nil {
+			slog.Warn("skipping corrupted batch file", "path", path, "error", err)
+			continue
+		}
+		if err := batch.Validate(); err != nil {
+			slog.Warn("skipping invalid batch file", "path", path, "error", err)
+			continue
+		}
+		batches = append(batches, &batch)
+	}
+
+	return batches, nil
+}
+
 // sanitizeFilename removes characters that are problematic in filenames.
 func sanitizeFilename(s string) string {
 	// Replace problematic characters with underscore
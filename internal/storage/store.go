@@ -34,6 +34,23 @@ type Store interface {
 	// LoadAll loads all operations and events from storage.
 	// Used for recovery on startup.
 	LoadAll(ctx context.Context) (map[string]*types.Operation, map[string][]types.Event, error)
+
+	// SaveTemplate persists an operation template, overwriting any previous
+	// version with the same ID.
+	SaveTemplate(ctx context.Context, tmpl *types.Template) error
+
+	// ListTemplates returns all saved operation templates.
+	ListTemplates(ctx context.Context) ([]*types.Template, error)
+
+	// DeleteTemplate removes an operation template.
+	DeleteTemplate(ctx context.Context, id string) error
+
+	// SaveBatch persists a batch operation, overwriting any previous version
+	// with the same ID.
+	SaveBatch(ctx context.Context, batch *types.BatchOperation) error
+
+	// ListBatches returns all saved batch operations.
+	ListBatches(ctx context.Context) ([]*types.BatchOperation, error)
 }
 
 // NullStore is a no-op store implementation for when persistence is disabled.
@@ -66,3 +83,23 @@ func (s *NullStore) GetEvents(ctx context.Context, operationID string) ([]types.
 func (s *NullStore) LoadAll(ctx context.Context) (map[string]*types.Operation, map[string][]types.Event, error) {
 	return make(map[string]*types.Operation), make(map[string][]types.Event), nil
 }
+
+func (s *NullStore) SaveTemplate(ctx context.Context, tmpl *types.Template) error {
+	return nil
+}
+
+func (s *NullStore) ListTemplates(ctx context.Context) ([]*types.Template, error) {
+	return nil, nil
+}
+
+func (s *NullStore) DeleteTemplate(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *NullStore) SaveBatch(ctx context.Context, batch *types.BatchOperation) error {
+	return nil
+}
+
+func (s *NullStore) ListBatches(ctx context.Context) ([]*types.BatchOperation, error) {
+	return nil, nil
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BatchState is the overall state of a batch operation, derived from the
+// states of its child operations.
+type BatchState string
+
+const (
+	// BatchStateRunning means some children have not finished yet.
+	BatchStateRunning BatchState = "running"
+	// BatchStateCompleted means every child completed.
+	BatchStateCompleted BatchState = "completed"
+	// BatchStatePartiallyCompleted means every child has finished, but only
+	// some of them completed.
+	BatchStatePartiallyCompleted BatchState = "partially_completed"
+	// BatchStateFailed means every child has finished and none completed.
+	BatchStateFailed BatchState = "failed"
+)
+
+// BatchOperation runs the same operation against several clusters. It is a
+// tracking record only: each cluster gets an ordinary operation of its own,
+// and the batch starts them a few clusters at a time.
+type BatchOperation struct {
+	// ID is the unique identifier for this batch.
+	ID string `json:"id"`
+	// Type is the operation type created for every cluster.
+	Type OperationType `json:"type"`
+	// Region is the AWS region of the clusters.
+	Region string `json:"region"`
+	// Parameters are the operation parameters shared by every cluster.
+	Parameters json.RawMessage `json:"params,omitempty"`
+	// MaxParallelClusters is how many child operations may be active at once.
+	MaxParallelClusters int `json:"max_parallel_clusters"`
+	// State is the overall state of the batch.
+	State BatchState `json:"state"`
+	// Children lists the child operation of each cluster, in request order.
+	Children []BatchChild `json:"children"`
+	// Counts is the number of children in each operation state.
+	Counts map[OperationState]int `json:"counts"`
+	// CreatedAt is when the batch was created.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is when the batch was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+	// CompletedAt is when the last child finished.
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BatchChild is one cluster of a batch operation.
+type BatchChild struct {
+	// ClusterID is the RDS cluster identifier.
+	ClusterID string `json:"cluster_id"`
+	// OperationID is the child operation, empty if it could not be created.
+	OperationID string `json:"operation_id,omitempty"`
+	// State is the child operation's state when the batch was last updated.
+	State OperationState `json:"state"`
+	// Error explains why the child operation could not be created or started.
+	Error string `json:"error,omitempty"`
+}
+
+// Done reports whether the child will make no further progress.
+func (c *BatchChild) Done() bool {
+	return c.Error != "" || c.State.IsTerminal()
+}
+
+// Validate checks if the batch has valid required fields.
+func (b *BatchOperation) Validate() error {
+	if b.ID == "" {
+		return &ValidationError{Field: "id", Message: "batch ID is required"}
+	}
+	if !ValidOperationTypes[b.Type] {
+		return &ValidationError{Field: "type", Message: "invalid operation type: " + string(b.Type)}
+	}
+	if len(b.Children) == 0 {
+		return &ValidationError{Field: "children", Message: "a batch needs at least one cluster"}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+// BlueGreenMember pairs a member of the blue (source) environment with its
+// green (target) counterpart, as RDS reports them.
+type BlueGreenMember struct {
+	// SourceMember is the ARN of the blue cluster or instance.
+	SourceMember string `json:"source_member"`
+	// TargetMember is the ARN of the green cluster or instance; empty until
+	// RDS has provisioned it.
+	TargetMember string `json:"target_member,omitempty"`
+	// Status is the member's switchover status.
+	Status string `json:"status,omitempty"`
+}
+
+// BlueGreenResources records the resources of an operation's Blue-Green
+// deployment. It is stored when the deployment is created and refreshed from
+// every describe, so cleanup knows exactly which resources to delete.
+type BlueGreenResources struct {
+	// DeploymentIdentifier is the Blue-Green deployment's identifier.
+	DeploymentIdentifier string `json:"deployment_identifier"`
+	// Status is the deployment status last seen.
+	Status string `json:"status"`
+	// SourceARN is the blue cluster's ARN.
+	SourceARN string `json:"source_arn"`
+	// TargetARN is the green cluster's ARN, once provisioned.
+	TargetARN string `json:"target_arn,omitempty"`
+	// Members maps each blue member to its green counterpart.
+	Members []BlueGreenMember `json:"members,omitempty"`
+	// SwitchedOver is set once the mapping was read after the switchover
+	// completed. RDS renames the blue resources on switchover, so only then
+	// do the source ARNs name the retired resources rather than live ones.
+	SwitchedOver bool `json:"switched_over,omitempty"`
+	// UpdatedAt is when the mapping was last read from RDS.
+	UpdatedAt time.Time `json:"updated_at"`
+}
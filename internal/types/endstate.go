@@ -0,0 +1,34 @@
+package types
+
+import "time"
+
+// EndStateMismatch is one way a cluster differs from the end state an
+// operation set out to reach.
+type EndStateMismatch struct {
+	// Resource is the cluster or instance that differs.
+	Resource string `json:"resource"`
+	// Field is the setting that differs, e.g. instance_type or engine_version.
+	Field string `json:"field"`
+	// Expected is the value the operation asked for.
+	Expected string `json:"expected"`
+	// Actual is the value RDS reports.
+	Actual string `json:"actual"`
+}
+
+// EndStateReport compares a cluster, as RDS describes it, with the end state
+// an operation intended.
+type EndStateReport struct {
+	// CheckedAt is when the cluster was described.
+	CheckedAt time.Time `json:"checked_at"`
+	// Checked lists the settings that were compared, e.g. "instance_type".
+	Checked []string `json:"checked"`
+	// Instances lists the instances that were compared.
+	Instances []string `json:"instances,omitempty"`
+	// Mismatches lists every difference found; empty when the cluster matches.
+	Mismatches []EndStateMismatch `json:"mismatches,omitempty"`
+}
+
+// Matches reports whether the cluster matched the intended end state.
+func (r *EndStateReport) Matches() bool {
+	return len(r.Mismatches) == 0
+}
@@ -4,6 +4,7 @@ package types
 import (
 	"encoding/json"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +20,25 @@ const (
 	OperationTypeEngineUpgrade OperationType = "engine_upgrade"
 	// OperationTypeInstanceCycle reboots all instances in the cluster to apply pending changes.
 	OperationTypeInstanceCycle OperationType = "instance_cycle"
+	// OperationTypeMinorVersionUpgrade upgrades the engine within its major version in place.
+	OperationTypeMinorVersionUpgrade OperationType = "minor_version_upgrade"
+	// OperationTypeCACertRotation rotates the CA certificate on every instance in the cluster.
+	OperationTypeCACertRotation OperationType = "ca_cert_rotation"
+	// OperationTypeRebootCluster reboots the readers, and optionally the writer,
+	// in place to apply pending-reboot parameter changes.
+	OperationTypeRebootCluster OperationType = "reboot_cluster"
+	// OperationTypeApplyPendingMaintenance applies AWS pending maintenance
+	// actions to the cluster and its instances immediately.
+	OperationTypeApplyPendingMaintenance OperationType = "apply_pending_maintenance"
+	// OperationTypeSnapshotRestoreTest restores a cluster snapshot into a
+	// temporary cluster to prove it is usable, then deletes the restore.
+	OperationTypeSnapshotRestoreTest OperationType = "snapshot_restore_test"
+	// OperationTypeMonitoringChange changes the Performance Insights and
+	// Enhanced Monitoring settings of every instance in the cluster.
+	OperationTypeMonitoringChange OperationType = "monitoring_change"
+	// OperationTypeServerlessScaling changes the Aurora Serverless v2
+	// capacity range of the cluster.
+	OperationTypeServerlessScaling OperationType = "serverless_scaling"
 )
 
 // OperationState represents the current state of an operation.
@@ -27,6 +47,15 @@ type OperationState string
 const (
 	// StateCreated indicates operation was created but not started.
 	StateCreated OperationState = "created"
+	// StatePlanned indicates operation was built as a dry run and will not
+	// start until it is confirmed.
+	StatePlanned OperationState = "planned"
+	// StatePendingApproval indicates operation was built but will not start
+	// until someone other than its creator approves it.
+	StatePendingApproval OperationState = "pending_approval"
+	// StateScheduled indicates operation was started and is waiting for
+	// its cluster's maintenance window before it runs.
+	StateScheduled OperationState = "scheduled"
 	// StateRunning indicates operation is actively executing.
 	StateRunning OperationState = "running"
 	// StatePaused indicates operation is paused waiting for intervention.
@@ -39,8 +68,34 @@ const (
 	StateRollingBack OperationState = "rolling_back"
 	// StateRolledBack indicates operation was rolled back.
 	StateRolledBack OperationState = "rolled_back"
+	// StateCancelling indicates cancellation was requested and the operation
+	// is stopping its current step and cleaning up.
+	StateCancelling OperationState = "cancelling"
+	// StateCancelled indicates operation was cancelled by an operator.
+	StateCancelled OperationState = "cancelled"
 )
 
+// IsTerminal reports whether an operation in this state will make no further progress.
+func (s OperationState) IsTerminal() bool {
+	switch s {
+	case StateCompleted, StateFailed, StateRolledBack, StateCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsActive reports whether an operation in this state holds its cluster:
+// it has started and has not yet reached a terminal state.
+func (s OperationState) IsActive() bool {
+	switch s {
+	case StateRunning, StatePaused, StateRollingBack, StateCancelling:
+		return true
+	default:
+		return false
+	}
+}
+
 // StepState represents the current state of a step within an operation.
 type StepState string
 
@@ -81,12 +136,57 @@ type Operation struct {
 	Error string `json:"error,omitempty"`
 	// PauseReason explains why the operation is paused.
 	PauseReason string `json:"pause_reason,omitempty"`
+	// PausedAt is when the operation last entered the paused state.
+	PausedAt *time.Time `json:"paused_at,omitempty"`
 	// WaitTimeout is the timeout for wait operations in seconds.
 	// If 0, the engine's default timeout is used.
 	WaitTimeout int `json:"wait_timeout,omitempty"`
 	// PauseBeforeSteps is a list of step indices where the operation should auto-pause.
 	// When execution reaches a step in this list, it will pause before starting that step.
 	PauseBeforeSteps []int `json:"pause_before_steps,omitempty"`
+	// Warnings lists concerns detected while building the steps that did not
+	// prevent the operation from being created.
+	Warnings []string `json:"warnings,omitempty"`
+	// Plan summarizes the decisions made while building the steps.
+	Plan *PlanSummary `json:"plan,omitempty"`
+	// IdempotencyKey is the key the operation was created with, if any. A
+	// repeated create with the same key returns this operation.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// RequiresApproval holds the operation in pending_approval until someone
+	// other than CreatedBy approves it.
+	RequiresApproval bool `json:"requires_approval,omitempty"`
+	// CreatedBy identifies who created the operation, for the audit trail.
+	CreatedBy string `json:"created_by,omitempty"`
+	// ApprovedBy identifies who approved the operation.
+	ApprovedBy string `json:"approved_by,omitempty"`
+	// ApprovedAt is when the operation was approved.
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+	// MaxDuration is how long the operation may run, in seconds from when it
+	// first started. Zero means no limit.
+	MaxDuration int `json:"max_duration_seconds,omitempty"`
+	// Deadline is when the operation stops advancing: it fails, or pauses if
+	// a failover or switchover has already started. Set when it starts.
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// PollMode advances the operation only when it is polled: each poll runs
+	// steps until a wait step, which checks its condition once and returns
+	// rather than blocking until it is met.
+	PollMode bool `json:"poll_mode,omitempty"`
+	// EndState is the result of the last comparison of the cluster with the
+	// end state the operation intended.
+	EndState *EndStateReport `json:"end_state,omitempty"`
+	// BlueGreen records the Blue-Green deployment's source and target
+	// resources, for engine upgrades that use one.
+	BlueGreen *BlueGreenResources `json:"blue_green,omitempty"`
+	// BatchID is the batch operation that created this one, if any.
+	BatchID string `json:"batch_id,omitempty"`
+	// ScheduleInClusterWindow holds the operation, once started, until the
+	// cluster's preferred maintenance window opens.
+	ScheduleInClusterWindow bool `json:"schedule_in_cluster_window,omitempty"`
+	// ClusterMaintenanceWindow is the cluster's preferred maintenance window
+	// when the operation was created, which a scheduled start follows.
+	ClusterMaintenanceWindow string `json:"cluster_maintenance_window,omitempty"`
+	// ScheduledStart is when a scheduled operation will begin.
+	ScheduledStart *time.Time `json:"scheduled_start,omitempty"`
 	// CreatedAt is when the operation was created.
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt is when the operation was last updated.
@@ -97,6 +197,68 @@ type Operation struct {
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
+// OperationSummary is the listing view of an operation, without its steps.
+type OperationSummary struct {
+	ID               string         `json:"id"`
+	Type             OperationType  `json:"type"`
+	State            OperationState `json:"state"`
+	ClusterID        string         `json:"cluster_id"`
+	Region           string         `json:"region"`
+	CurrentStepIndex int            `json:"current_step_index"`
+	StepCount        int            `json:"step_count"`
+	ScheduledStart   *time.Time     `json:"scheduled_start,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Summary returns the listing view of the operation.
+func (op *Operation) Summary() OperationSummary {
+	return OperationSummary{
+		ID:               op.ID,
+		Type:             op.Type,
+		State:            op.State,
+		ClusterID:        op.ClusterID,
+		Region:           op.Region,
+		CurrentStepIndex: op.CurrentStepIndex,
+		StepCount:        len(op.Steps),
+		ScheduledStart:   op.ScheduledStart,
+		CreatedAt:        op.CreatedAt,
+		UpdatedAt:        op.UpdatedAt,
+	}
+}
+
+// OperationFilter selects operations to list. Empty fields match everything.
+type OperationFilter struct {
+	State     OperationState
+	ClusterID string
+	Type      OperationType
+	// Limit caps the number of operations returned. Zero returns them all.
+	Limit int
+	// Cursor resumes a listing after the last operation of a previous page.
+	Cursor string
+}
+
+// OperationPage is one page of an operation listing, newest first.
+type OperationPage struct {
+	Operations []OperationSummary `json:"operations"`
+	// NextCursor fetches the next page. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PlanSummary describes the notable decisions behind an operation's steps so
+// they can be reviewed before the operation runs.
+type PlanSummary struct {
+	// StepCount is the number of steps that will be executed.
+	StepCount int `json:"step_count"`
+	// CreatesTempInstance indicates a temporary reader will be created.
+	CreatesTempInstance bool `json:"creates_temp_instance"`
+	// TempInstanceType is the instance class of the temporary reader, if any.
+	TempInstanceType string `json:"temp_instance_type,omitempty"`
+	// IncludesFailover indicates the writer will be failed over, which happens
+	// when the writer is not excluded from the operation.
+	IncludesFailover bool `json:"includes_failover"`
+}
+
 // Step represents a single step in a maintenance operation.
 type Step struct {
 	// ID is the unique identifier for this step.
@@ -105,6 +267,10 @@ type Step struct {
 	Name string `json:"name"`
 	// Description describes what this step does.
 	Description string `json:"description"`
+	// Rationale explains why the builder included the step, naming the
+	// parameter or cluster state that decided it. Empty for steps every
+	// plan of the operation type contains.
+	Rationale string `json:"rationale,omitempty"`
 	// State is the current state of this step.
 	State StepState `json:"state"`
 	// Action is the action to perform (e.g., "create_instance", "failover").
@@ -119,12 +285,70 @@ type Step struct {
 	StartedAt *time.Time `json:"started_at,omitempty"`
 	// CompletedAt is when the step completed.
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// Attempts is how many times the step's handler has run. The polls of a
+	// wait in poll mode are one attempt.
+	Attempts int `json:"attempts,omitempty"`
 	// WaitCondition describes what the step is waiting for.
 	WaitCondition string `json:"wait_condition,omitempty"`
+	// WaitStartedAt is when the step first began waiting. Unlike
+	// WaitProgress, it is kept across retries and once the step is done.
+	WaitStartedAt *time.Time `json:"wait_started_at,omitempty"`
+	// Polls is how many times the step's wait condition was checked, across
+	// all attempts.
+	Polls int `json:"polls,omitempty"`
 	// RetryCount tracks how many times this step has been retried.
 	RetryCount int `json:"retry_count"`
 	// MaxRetries is the maximum number of retries allowed.
 	MaxRetries int `json:"max_retries"`
+	// TimeoutSeconds overrides the engine's default wait timeout for this step.
+	// Zero uses the default.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// PollIntervalSeconds overrides the engine's default poll interval for this
+	// step. Zero uses the default.
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"`
+	// WaitProgress is what a wait step has seen so far, kept on the step so
+	// a wait in poll mode can pick up where the previous poll left off.
+	WaitProgress *WaitProgress `json:"wait_progress,omitempty"`
+	// Rollback marks steps appended to undo the operation's changes. They run
+	// only while the operation is rolling back.
+	Rollback bool `json:"rollback,omitempty"`
+}
+
+// WaitProgress records the progress of a wait step across polls.
+type WaitProgress struct {
+	// StartedAt is when the wait began. A wait in poll mode times out
+	// counting from it.
+	StartedAt time.Time `json:"started_at"`
+	// WaitingOn names the resources the step waits for: instances, a
+	// snapshot, a cluster or a Blue-Green deployment.
+	WaitingOn []string `json:"waiting_on,omitempty"`
+	// Polls is how many times the condition has been checked.
+	Polls int `json:"polls"`
+	// MismatchPolls counts, per instance, consecutive polls that found the
+	// instance available but without its modification applied.
+	MismatchPolls map[string]int `json:"mismatch_polls,omitempty"`
+	// Reissued lists instances whose modification was re-issued.
+	Reissued []string `json:"reissued,omitempty"`
+}
+
+// PlannedStep is a step as it will run, with parameters reflecting any
+// updates made by earlier steps (e.g. parameter group names resolved by
+// prepare_parameter_group).
+type PlannedStep struct {
+	// Index is the position of the step within the operation.
+	Index int `json:"index"`
+	// ID is the unique identifier for the step.
+	ID string `json:"id"`
+	// Name is a human-readable name for the step.
+	Name string `json:"name"`
+	// Rationale explains why the step is part of the plan.
+	Rationale string `json:"rationale,omitempty"`
+	// Action is the action the step performs.
+	Action string `json:"action"`
+	// State is the current state of the step.
+	State StepState `json:"state"`
+	// Parameters are the step's current parameters.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
 }
 
 // Duration returns the duration of the operation, or time since start if still running.
@@ -151,6 +375,21 @@ func (s *Step) Duration() time.Duration {
 	return endTime.Sub(*s.StartedAt)
 }
 
+// MarshalJSON adds the step's duration in seconds, so clients need not
+// compute it from the timestamps.
+func (s Step) MarshalJSON() ([]byte, error) {
+	type step Step
+	out := struct {
+		step
+		DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	}{step: step(s)}
+	if s.StartedAt != nil {
+		seconds := s.Duration().Seconds()
+		out.DurationSeconds = &seconds
+	}
+	return json.Marshal(out)
+}
+
 // InstanceTypeChangeParams contains parameters for instance type change operation.
 type InstanceTypeChangeParams struct {
 	// TargetInstanceType is the new instance type (e.g., "db.r6g.xlarge").
@@ -163,11 +402,53 @@ type InstanceTypeChangeParams struct {
 	// By default (false), a temp instance is created for redundancy.
 	// Set to true to skip temp instance creation (faster but less safe).
 	SkipTempInstance bool `json:"skip_temp_instance,omitempty"`
+	// AllowDowntime accepts that a cluster with no readers is unavailable
+	// while its writer is modified in place. Without it, skip_temp_instance
+	// is overridden on such a cluster and a temp instance is created anyway.
+	AllowDowntime bool `json:"allow_downtime,omitempty"`
+	// TempInstanceAvailabilityZone places the temp instance in this AZ
+	// instead of the writer's.
+	TempInstanceAvailabilityZone string `json:"temp_instance_availability_zone,omitempty"`
+	// FinalSnapshot overrides the server default for taking a final snapshot
+	// before the temp instance is deleted. Nil uses the server default.
+	FinalSnapshot *bool `json:"final_snapshot,omitempty"`
+	// TempInstancePromotionTier is the failover priority (0-15, lower is
+	// preferred) of the temp instance. Defaults to 0 so it is the failover target.
+	TempInstancePromotionTier int32 `json:"temp_instance_promotion_tier,omitempty"`
+	// StrictWriterClass rejects the operation when excluding the writer would
+	// leave it on a different instance class than the resized readers.
+	// By default (false), the mismatch is only reported as a warning.
+	StrictWriterClass bool `json:"strict_writer_class,omitempty"`
+	// Tags are added to the temp instance and the final snapshot, e.g. for
+	// cost allocation. The temp instance also inherits the writer's tags.
+	// Keys used by the machine itself (rds-maint-*) are reserved.
+	Tags map[string]string `json:"tags,omitempty"`
+	// MaxReplicaLagMs is the replica lag, in milliseconds, above which a
+	// failover target is refused. Defaults to 1000; negative disables the check.
+	MaxReplicaLagMs int `json:"max_replica_lag_ms,omitempty"`
+	// MaxParallelReaders is how many readers are modified at once. Defaults
+	// to 1; larger values are capped so at least one reader stays in service,
+	// and the writer is never modified together with readers.
+	MaxParallelReaders int `json:"max_parallel_readers,omitempty"`
+	// MinAvailableReaders is how many other readers must be available before
+	// a reader is modified or rebooted; the step waits for them, then pauses
+	// the operation. Defaults to 0 (no minimum).
+	MinAvailableReaders int `json:"min_available_readers,omitempty"`
+	// TargetInstanceParameterGroupName switches each modified instance to
+	// this DB parameter group along with its class. It must exist and be of
+	// the family the instances already use. Each instance is rebooted after
+	// its modification so the group takes effect.
+	TargetInstanceParameterGroupName string `json:"target_instance_parameter_group_name,omitempty"`
+	// SuspendAutoscaling suspends the cluster's read replica auto scaling
+	// while the instances change and restores its prior state afterward.
+	SuspendAutoscaling bool `json:"suspend_autoscaling,omitempty"`
 }
 
 // StorageTypeChangeParams contains parameters for storage type change operation.
 type StorageTypeChangeParams struct {
-	// TargetStorageType is the new storage type (e.g., "io1", "gp3").
+	// TargetStorageType is the new storage type (e.g., "aurora-iopt1").
+	// It must be orderable for the cluster engine and every modified instance class.
+	// When empty, the server default (APP_DEFAULT_STORAGE_TYPE) is used.
 	TargetStorageType string `json:"target_storage_type"`
 	// IOPS is the provisioned IOPS (required for io1/io2).
 	IOPS *int32 `json:"iops,omitempty"`
@@ -181,6 +462,30 @@ type StorageTypeChangeParams struct {
 	// By default (false), a temp instance is created for redundancy.
 	// Set to true to skip temp instance creation (faster but less safe).
 	SkipTempInstance bool `json:"skip_temp_instance,omitempty"`
+	// TempInstanceAvailabilityZone places the temp instance in this AZ
+	// instead of the writer's.
+	TempInstanceAvailabilityZone string `json:"temp_instance_availability_zone,omitempty"`
+	// FinalSnapshot overrides the server default for taking a final snapshot
+	// before the temp instance is deleted. Nil uses the server default.
+	FinalSnapshot *bool `json:"final_snapshot,omitempty"`
+	// Tags are added to the temp instance and the final snapshot, e.g. for
+	// cost allocation. The temp instance also inherits the writer's tags.
+	// Keys used by the machine itself (rds-maint-*) are reserved.
+	Tags map[string]string `json:"tags,omitempty"`
+	// MaxReplicaLagMs is the replica lag, in milliseconds, above which a
+	// failover target is refused. Defaults to 1000; negative disables the check.
+	MaxReplicaLagMs int `json:"max_replica_lag_ms,omitempty"`
+	// MaxParallelReaders is how many readers are modified at once. Defaults
+	// to 1; larger values are capped so at least one reader stays in service,
+	// and the writer is never modified together with readers.
+	MaxParallelReaders int `json:"max_parallel_readers,omitempty"`
+	// MinAvailableReaders is how many other readers must be available before
+	// a reader is modified or rebooted; the step waits for them, then pauses
+	// the operation. Defaults to 0 (no minimum).
+	MinAvailableReaders int `json:"min_available_readers,omitempty"`
+	// SuspendAutoscaling suspends the cluster's read replica auto scaling
+	// while the instances change and restores its prior state afterward.
+	SuspendAutoscaling bool `json:"suspend_autoscaling,omitempty"`
 }
 
 // EngineUpgradeParams contains parameters for engine upgrade operation using Blue-Green deployment.
@@ -206,11 +511,15 @@ type EngineUpgradeParams struct {
 	// SkipProxyRetarget controls whether to skip RDS Proxy validation and retargeting.
 	// By default (nil or false), the operation will:
 	// 1. Discover RDS Proxies pointing at this cluster
-	// 2. Validate proxy health (fail if unhealthy)
+	// 2. Validate proxy health (fail if unhealthy, see ContinueOnProxyHealthWarning)
 	// 3. Deregister proxies before Blue-Green deployment (required by AWS)
 	// 4. Re-register proxies after switchover
 	// Set to true to skip all proxy-related steps (useful if no proxies exist).
 	SkipProxyRetarget *bool `json:"skip_proxy_retarget,omitempty"`
+	// ContinueOnProxyHealthWarning downgrades an unhealthy proxy from a step
+	// failure to a warning event. Unhealthy proxies are still deregistered and
+	// re-registered. Defaults to false (hard-fail).
+	ContinueOnProxyHealthWarning bool `json:"continue_on_proxy_health_warning,omitempty"`
 	// PauseBeforeProxyDeregister controls whether to auto-pause before deregistering proxy targets.
 	// Defaults to true if not specified (nil).
 	// WARNING: Deregistering proxy targets will cause applications using the proxy to fail
@@ -220,8 +529,38 @@ type EngineUpgradeParams struct {
 	// Defaults to true if not specified (nil).
 	// This allows verification that the upgrade was successful before deleting old resources.
 	PauseBeforeCleanup *bool `json:"pause_before_cleanup,omitempty"`
+	// Tags are added to the pre-upgrade snapshot and the parameter groups
+	// created for the target version. Keys used by the machine itself
+	// (rds-maint-*) are reserved.
+	Tags map[string]string `json:"tags,omitempty"`
+	// PreUpgradeSnapshot controls the snapshot taken before the upgrade.
+	// Defaults to PreUpgradeSnapshotCreate.
+	PreUpgradeSnapshot PreUpgradeSnapshotMode `json:"pre_upgrade_snapshot,omitempty"`
+	// SnapshotRetentionDays overrides how long the pre-upgrade snapshot is
+	// kept before snapshot cleanup deletes it.
+	SnapshotRetentionDays int `json:"snapshot_retention_days,omitempty"`
+	// SkipAlarmCheck removes the CloudWatch alarm check before switchover,
+	// for clusters that have no alarms.
+	SkipAlarmCheck bool `json:"skip_alarm_check,omitempty"`
+	// AlarmNamePatterns selects the alarms checked before switchover, as
+	// glob patterns where {cluster} is the cluster ID. Empty uses the
+	// service default (APP_ALARM_NAME_PATTERNS), or every alarm on the cluster.
+	AlarmNamePatterns []string `json:"alarm_name_patterns,omitempty"`
 }
 
+// PreUpgradeSnapshotMode controls the cluster snapshot taken before an upgrade.
+type PreUpgradeSnapshotMode string
+
+const (
+	// PreUpgradeSnapshotCreate snapshots the cluster with the operation's tags.
+	PreUpgradeSnapshotCreate PreUpgradeSnapshotMode = "create"
+	// PreUpgradeSnapshotSkip upgrades without a snapshot.
+	PreUpgradeSnapshotSkip PreUpgradeSnapshotMode = "skip"
+	// PreUpgradeSnapshotCopyTags snapshots the cluster and also copies the
+	// cluster's own tags onto the snapshot.
+	PreUpgradeSnapshotCopyTags PreUpgradeSnapshotMode = "copy-tags"
+)
+
 // InstanceCycleParams contains parameters for instance cycle (reboot) operation.
 // This operation has no required parameters - it will reboot all instances in the cluster.
 type InstanceCycleParams struct {
@@ -233,6 +572,145 @@ type InstanceCycleParams struct {
 	// By default (false), a temp instance is created for redundancy.
 	// Set to true to skip temp instance creation (faster but less safe).
 	SkipTempInstance bool `json:"skip_temp_instance,omitempty"`
+	// TempInstanceAvailabilityZone places the temp instance in this AZ
+	// instead of the writer's.
+	TempInstanceAvailabilityZone string `json:"temp_instance_availability_zone,omitempty"`
+	// FinalSnapshot overrides the server default for taking a final snapshot
+	// before the temp instance is deleted. Nil uses the server default.
+	FinalSnapshot *bool `json:"final_snapshot,omitempty"`
+	// MaxReplicaLagMs is the replica lag, in milliseconds, above which a
+	// failover target is refused. Defaults to 1000; negative disables the check.
+	MaxReplicaLagMs int `json:"max_replica_lag_ms,omitempty"`
+	// MinAvailableReaders is how many other readers must be available before
+	// a reader is modified or rebooted; the step waits for them, then pauses
+	// the operation. Defaults to 0 (no minimum).
+	MinAvailableReaders int `json:"min_available_readers,omitempty"`
+}
+
+// RebootClusterParams contains parameters for a reboot cluster operation.
+// Without parameters only the readers are rebooted, one at a time.
+type RebootClusterParams struct {
+	// ExcludeInstances is a list of instance IDs that will not be rebooted.
+	ExcludeInstances []string `json:"exclude_instances,omitempty"`
+	// RebootWriter also reboots the writer after the readers, failing over to
+	// the first rebooted reader beforehand. Defaults to false (readers only).
+	RebootWriter bool `json:"reboot_writer,omitempty"`
+	// FailBack fails back to the original writer once it has been rebooted.
+	// Defaults to true if not specified (nil). Ignored unless RebootWriter is set.
+	FailBack *bool `json:"fail_back,omitempty"`
+	// MaxReplicaLagMs is the replica lag, in milliseconds, above which a
+	// failover target is refused. Defaults to 1000; negative disables the check.
+	MaxReplicaLagMs int `json:"max_replica_lag_ms,omitempty"`
+	// MinAvailableReaders is how many other readers must be available before
+	// a reader is modified or rebooted; the step waits for them, then pauses
+	// the operation. Defaults to 0 (no minimum).
+	MinAvailableReaders int `json:"min_available_readers,omitempty"`
+}
+
+// ApplyPendingMaintenanceParams contains parameters for applying pending
+// maintenance actions.
+type ApplyPendingMaintenanceParams struct {
+	// Actions limits the operation to pending actions with these names
+	// (e.g., "system-update"). Empty applies every pending action.
+	Actions []string `json:"actions,omitempty"`
+}
+
+// SnapshotRestoreTestParams contains parameters for a snapshot restore test.
+type SnapshotRestoreTestParams struct {
+	// SnapshotID is the cluster snapshot to restore. Empty takes a new
+	// snapshot of the cluster and restores that.
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	// ValidationQuery is run against the restored cluster before it is
+	// deleted. Empty only checks that the restored cluster becomes available.
+	ValidationQuery string `json:"validation_query,omitempty"`
+	// InstanceType is the class of the instance created in the restored
+	// cluster to run the validation query. Empty uses the writer's class.
+	InstanceType string `json:"instance_type,omitempty"`
+}
+
+// ServerlessScalingParams contains parameters for changing the Aurora
+// Serverless v2 capacity range.
+type ServerlessScalingParams struct {
+	// MinCapacity is the minimum capacity in ACUs (0.5-256, in 0.5 steps).
+	MinCapacity float64 `json:"min_capacity"`
+	// MaxCapacity is the maximum capacity in ACUs (0.5-256, in 0.5 steps).
+	MaxCapacity float64 `json:"max_capacity"`
+}
+
+// MonitoringChangeParams contains parameters for changing the monitoring
+// settings of every instance in a cluster. Settings left unset keep their
+// current value.
+type MonitoringChangeParams struct {
+	// EnablePerformanceInsights turns Performance Insights on or off.
+	EnablePerformanceInsights *bool `json:"enable_performance_insights,omitempty"`
+	// PerformanceInsightsRetentionPeriod is how many days Performance
+	// Insights data is kept: 7, 731, or a multiple of 31 up to 713.
+	PerformanceInsightsRetentionPeriod *int32 `json:"performance_insights_retention_period,omitempty"`
+	// MonitoringInterval is the Enhanced Monitoring interval in seconds (0,
+	// 1, 5, 10, 15, 30 or 60); 0 turns Enhanced Monitoring off.
+	MonitoringInterval *int32 `json:"monitoring_interval,omitempty"`
+	// MonitoringRoleArn is the IAM role Enhanced Monitoring publishes with.
+	// It is required for a non-zero interval on instances without a role.
+	MonitoringRoleArn string `json:"monitoring_role_arn,omitempty"`
+	// FailoverWriter moves writes to a reader while the writer is changed
+	// and back afterwards. The change does not restart the writer, so by
+	// default it is modified in place.
+	FailoverWriter bool `json:"failover_writer,omitempty"`
+	// ExcludeInstances lists instances to leave unchanged.
+	ExcludeInstances []string `json:"exclude_instances,omitempty"`
+}
+
+// MinorVersionUpgradeParams contains parameters for an in-place minor version upgrade.
+type MinorVersionUpgradeParams struct {
+	// TargetEngineVersion is the engine version to upgrade to. It must share
+	// the cluster's current major version; use an engine upgrade otherwise.
+	TargetEngineVersion string `json:"target_engine_version"`
+	// DBClusterParameterGroupName optionally associates a new cluster
+	// parameter group before the upgrade. Instances are rebooted only when
+	// the change leaves static parameters pending reboot.
+	DBClusterParameterGroupName string `json:"db_cluster_parameter_group_name,omitempty"`
+}
+
+// SnapshotCleanupResult reports what a snapshot cleanup did in one region.
+type SnapshotCleanupResult struct {
+	// Region is the region that was swept.
+	Region string `json:"region"`
+	// Deleted lists the expired snapshots that were deleted.
+	Deleted []string `json:"deleted"`
+	// Retained counts the machine's snapshots that are not yet expired, have
+	// no retention, or are still being created.
+	Retained int `json:"retained"`
+	// Failed maps snapshots that could not be deleted to the error.
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// OrphanReconcileResult reports what an orphaned temp instance sweep did in
+// one region.
+type OrphanReconcileResult struct {
+	// Region is the region that was swept.
+	Region string `json:"region"`
+	// Deleted lists the orphaned temp instances that were deleted.
+	Deleted []string `json:"deleted"`
+	// Retained maps the temp instances that were kept to the reason.
+	Retained map[string]string `json:"retained,omitempty"`
+	// Failed maps temp instances that could not be deleted to the error.
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// CACertRotationParams contains parameters for a CA certificate rotation.
+type CACertRotationParams struct {
+	// TargetCACertificate is the CA certificate identifier to rotate to
+	// (e.g., "rds-ca-rsa2048-g1").
+	TargetCACertificate string `json:"target_ca_certificate"`
+	// SkipTempInstance reboots the writer in place instead of failing over to
+	// a temporary instance while it is rotated.
+	SkipTempInstance bool `json:"skip_temp_instance,omitempty"`
+	// TempInstanceAvailabilityZone places the temp instance in this AZ
+	// instead of the writer's.
+	TempInstanceAvailabilityZone string `json:"temp_instance_availability_zone,omitempty"`
+	// MaxReplicaLagMs is the replica lag, in milliseconds, above which a
+	// failover target is refused. Defaults to 1000; negative disables the check.
+	MaxReplicaLagMs int `json:"max_replica_lag_ms,omitempty"`
 }
 
 // ClusterSummary contains summary information about an RDS cluster for listing.
@@ -245,6 +723,17 @@ type ClusterSummary struct {
 	EngineVersion string `json:"engine_version"`
 	// Status is the current cluster status.
 	Status string `json:"status"`
+	// InstanceCount is the number of instances in the cluster, the writer included.
+	InstanceCount int `json:"instance_count"`
+	// MultiAZ reports whether the cluster's instances span more than one AZ.
+	MultiAZ bool `json:"multi_az"`
+	// GlobalClusterID is the Aurora Global Database the cluster belongs to, if any.
+	GlobalClusterID string `json:"global_cluster_id,omitempty"`
+	// WriterInstanceClass is the writer's instance class. It is only looked
+	// up when the list is requested with include=instances.
+	WriterInstanceClass string `json:"writer_instance_class,omitempty"`
+	// ActiveOperationID is the operation in progress on the cluster, if any.
+	ActiveOperationID string `json:"active_operation_id,omitempty"`
 }
 
 // ClusterInfo contains information about an RDS cluster.
@@ -257,8 +746,97 @@ type ClusterInfo struct {
 	EngineVersion string `json:"engine_version"`
 	// Status is the current cluster status.
 	Status string `json:"status"`
+	// ParameterGroupName is the cluster's DB cluster parameter group.
+	ParameterGroupName string `json:"db_cluster_parameter_group_name,omitempty"`
 	// Instances contains info about each instance in the cluster.
 	Instances []InstanceInfo `json:"instances"`
+	// IsServerless indicates the cluster has Aurora Serverless v2 instances.
+	IsServerless bool `json:"is_serverless,omitempty"`
+	// ServerlessV2Scaling is the cluster's Serverless v2 capacity range, if
+	// it has one.
+	ServerlessV2Scaling *ServerlessV2Scaling `json:"serverless_v2_scaling,omitempty"`
+	// CustomEndpoints are the cluster's Aurora custom endpoints.
+	CustomEndpoints []ClusterEndpoint `json:"custom_endpoints,omitempty"`
+	// IAMDatabaseAuthenticationEnabled reports whether clients can sign in
+	// with IAM credentials. Aurora manages it for the whole cluster.
+	IAMDatabaseAuthenticationEnabled bool `json:"iam_database_authentication_enabled,omitempty"`
+	// PreferredMaintenanceWindow is the weekly window, in UTC and
+	// ddd:hh24:mi-ddd:hh24:mi form, in which RDS applies the cluster's
+	// pending maintenance.
+	PreferredMaintenanceWindow string `json:"preferred_maintenance_window,omitempty"`
+}
+
+// ClusterEndpoint is an Aurora cluster endpoint. Custom endpoints route to
+// the instances in StaticMembers or, when that is empty, to every instance
+// of their type except those in ExcludedMembers.
+type ClusterEndpoint struct {
+	// Identifier is the endpoint identifier.
+	Identifier string `json:"identifier"`
+	// ClusterID is the cluster the endpoint belongs to.
+	ClusterID string `json:"cluster_id"`
+	// Endpoint is the DNS name clients connect to.
+	Endpoint string `json:"endpoint"`
+	// Status is the endpoint status (e.g., "available", "modifying").
+	Status string `json:"status"`
+	// EndpointType is WRITER, READER or CUSTOM.
+	EndpointType string `json:"endpoint_type"`
+	// CustomEndpointType is READER or ANY for custom endpoints.
+	CustomEndpointType string `json:"custom_endpoint_type,omitempty"`
+	// StaticMembers are the instances a custom endpoint is limited to.
+	StaticMembers []string `json:"static_members,omitempty"`
+	// ExcludedMembers are the instances a custom endpoint leaves out.
+	ExcludedMembers []string `json:"excluded_members,omitempty"`
+}
+
+// ServerlessV2Scaling is an Aurora Serverless v2 capacity range.
+type ServerlessV2Scaling struct {
+	// MinCapacity is the minimum capacity in ACUs.
+	MinCapacity float64 `json:"min_capacity"`
+	// MaxCapacity is the maximum capacity in ACUs.
+	MaxCapacity float64 `json:"max_capacity"`
+}
+
+// PendingModifiedValues are instance changes queued but not yet applied,
+// such as a modify made without ApplyImmediately or a change scheduled for
+// the maintenance window. Unset fields are not changing.
+type PendingModifiedValues struct {
+	InstanceClass           string `json:"instance_class,omitempty"`
+	StorageType             string `json:"storage_type,omitempty"`
+	IOPS                    *int32 `json:"iops,omitempty"`
+	StorageThroughput       *int32 `json:"storage_throughput,omitempty"`
+	AllocatedStorage        *int32 `json:"allocated_storage,omitempty"`
+	EngineVersion           string `json:"engine_version,omitempty"`
+	CACertificateIdentifier string `json:"ca_certificate_identifier,omitempty"`
+}
+
+// IsEmpty reports whether nothing is pending.
+func (p PendingModifiedValues) IsEmpty() bool {
+	return p == (PendingModifiedValues{})
+}
+
+// String lists the pending changes, e.g. "instance class db.r6g.xlarge,
+// storage type gp3".
+func (p PendingModifiedValues) String() string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+" "+value)
+		}
+	}
+	add("instance class", p.InstanceClass)
+	add("storage type", p.StorageType)
+	if p.IOPS != nil {
+		add("IOPS", strconv.Itoa(int(*p.IOPS)))
+	}
+	if p.StorageThroughput != nil {
+		add("storage throughput", strconv.Itoa(int(*p.StorageThroughput)))
+	}
+	if p.AllocatedStorage != nil {
+		add("allocated storage", strconv.Itoa(int(*p.AllocatedStorage))+" GiB")
+	}
+	add("engine version", p.EngineVersion)
+	add("CA certificate", p.CACertificateIdentifier)
+	return strings.Join(parts, ", ")
 }
 
 // InstanceInfo contains information about an RDS instance.
@@ -277,6 +855,38 @@ type InstanceInfo struct {
 	StorageType string `json:"storage_type,omitempty"`
 	// IOPS is the provisioned IOPS.
 	IOPS *int32 `json:"iops,omitempty"`
+	// AllocatedStorage is the allocated storage in GiB. Aurora reports a
+	// nominal value since its storage is managed by the cluster.
+	AllocatedStorage int32 `json:"allocated_storage,omitempty"`
+	// AvailabilityZone is the AZ the instance runs in.
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	// CACertificateIdentifier is the CA certificate the instance currently serves.
+	CACertificateIdentifier string `json:"ca_certificate_identifier,omitempty"`
+	// PendingCACertificateIdentifier is a CA certificate change that takes
+	// effect at the next reboot.
+	PendingCACertificateIdentifier string `json:"pending_ca_certificate_identifier,omitempty"`
+	// PendingModifiedValues are changes queued on the instance that AWS has
+	// not applied yet, or nil when there are none.
+	PendingModifiedValues *PendingModifiedValues `json:"pending_modified_values,omitempty"`
+	// ParameterGroupName is the instance's DB parameter group.
+	ParameterGroupName string `json:"db_parameter_group_name,omitempty"`
+	// DBSubnetGroup is the DB subnet group the instance runs in.
+	DBSubnetGroup string `json:"db_subnet_group,omitempty"`
+	// VpcSecurityGroupIDs are the VPC security groups attached to the instance.
+	VpcSecurityGroupIDs []string `json:"vpc_security_group_ids,omitempty"`
+	// PerformanceInsightsEnabled reports whether Performance Insights is on.
+	PerformanceInsightsEnabled bool `json:"performance_insights_enabled,omitempty"`
+	// PerformanceInsightsRetentionPeriod is how many days Performance
+	// Insights data is kept.
+	PerformanceInsightsRetentionPeriod int32 `json:"performance_insights_retention_period,omitempty"`
+	// MonitoringInterval is the Enhanced Monitoring interval in seconds, or
+	// zero when Enhanced Monitoring is off.
+	MonitoringInterval int32 `json:"monitoring_interval,omitempty"`
+	// MonitoringRoleArn is the IAM role Enhanced Monitoring publishes with.
+	MonitoringRoleArn string `json:"monitoring_role_arn,omitempty"`
+	// IAMDatabaseAuthenticationEnabled reports whether the instance accepts
+	// IAM credentials; Aurora instances follow their cluster.
+	IAMDatabaseAuthenticationEnabled bool `json:"iam_database_authentication_enabled,omitempty"`
 }
 
 // Event represents an event that occurred during an operation.
@@ -287,14 +897,140 @@ type Event struct {
 	OperationID string `json:"operation_id"`
 	// Type is the event type (e.g., "step_started", "step_completed").
 	Type string `json:"type"`
+	// Severity is how much attention the event needs: info, warning or error.
+	Severity EventSeverity `json:"severity"`
+	// StepID is the step that was running when the event occurred, if any.
+	StepID string `json:"step_id,omitempty"`
 	// Message is a human-readable message.
 	Message string `json:"message"`
 	// Data contains additional event data.
 	Data json.RawMessage `json:"data,omitempty"`
-	// Timestamp is when the event occurred.
+	// Timestamp is when the event occurred. Timestamps strictly increase
+	// within an operation, so they order its events and can be used as a
+	// cursor for incremental fetches.
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// EventSeverity is how much attention an event needs.
+type EventSeverity string
+
+// Event severities.
+const (
+	EventSeverityInfo    EventSeverity = "info"
+	EventSeverityWarning EventSeverity = "warning"
+	EventSeverityError   EventSeverity = "error"
+)
+
+// OperationProgress is a snapshot of where an operation is, streamed to
+// progress subscribers whenever the operation or its current step changes state.
+type OperationProgress struct {
+	// OperationID is the operation this snapshot describes.
+	OperationID string `json:"operation_id"`
+	// State is the operation state.
+	State OperationState `json:"state"`
+	// CurrentStepIndex is the index of the step being executed.
+	CurrentStepIndex int `json:"current_step_index"`
+	// StepName is the name of the current step, if any.
+	StepName string `json:"step_name,omitempty"`
+	// StepState is the state of the current step, if any.
+	StepState StepState `json:"step_state,omitempty"`
+	// WaitCondition describes what the current step is waiting for.
+	WaitCondition string `json:"wait_condition,omitempty"`
+}
+
+// DurationStats summarizes how long completed operations of one type took.
+type DurationStats struct {
+	// Count is the number of completed operations included.
+	Count int `json:"count"`
+	// P50Seconds is the median duration in seconds.
+	P50Seconds float64 `json:"p50_seconds"`
+	// P95Seconds is the 95th percentile duration in seconds.
+	P95Seconds float64 `json:"p95_seconds"`
+	// MaxSeconds is the longest duration in seconds.
+	MaxSeconds float64 `json:"max_seconds"`
+}
+
+// CostEstimate is the approximate extra spend an operation causes: the
+// resources it adds on top of what the cluster already costs.
+type CostEstimate struct {
+	// OperationID is the operation estimated.
+	OperationID string `json:"operation_id"`
+	// Estimate is always true; the figures are approximations, not a quote.
+	Estimate bool `json:"estimate"`
+	// Currency the costs are in.
+	Currency string `json:"currency"`
+	// Total is the sum of the item costs.
+	Total float64 `json:"total"`
+	// ExpectedDurationHours is how long the operation is expected to run.
+	ExpectedDurationHours float64 `json:"expected_duration_hours"`
+	// Items are the priced resources.
+	Items []CostItem `json:"items"`
+	// Assumptions lists what the estimate takes for granted, including
+	// anything it could not price.
+	Assumptions []string `json:"assumptions"`
+}
+
+// CostItem is one resource in a cost estimate.
+type CostItem struct {
+	// Description names the resource, e.g. "temp instance db.r6g.large".
+	Description string `json:"description"`
+	// Quantity is how much of the resource is used, in Unit.
+	Quantity float64 `json:"quantity"`
+	// Unit is the unit Rate is charged per, e.g. "instance-hours".
+	Unit string `json:"unit"`
+	// Rate is the price per unit.
+	Rate float64 `json:"rate"`
+	// Cost is Quantity times Rate.
+	Cost float64 `json:"cost"`
+}
+
+// PollResult is what one poll of an operation in poll mode found.
+type PollResult struct {
+	// OperationID is the operation polled.
+	OperationID string `json:"operation_id"`
+	// Ready is false while the operation is waiting on a condition and
+	// should be polled again, and true once it no longer is: it completed,
+	// paused or failed.
+	Ready bool `json:"ready"`
+	// State is the operation's state after the poll.
+	State OperationState `json:"state"`
+	// CurrentStepIndex is the step the operation is at.
+	CurrentStepIndex int `json:"current_step_index"`
+	// Step is the name of that step.
+	Step string `json:"step,omitempty"`
+	// WaitCondition describes what the step is waiting for.
+	WaitCondition string `json:"wait_condition,omitempty"`
+	// WaitingOn names the resources the step is waiting for.
+	WaitingOn []string `json:"waiting_on,omitempty"`
+	// Deadline is when the operation stops advancing, if it has one.
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// SkipReason explains why a parameter could not be migrated.
+type SkipReason string
+
+const (
+	// SkipReasonNotModifiable means the parameter is read-only in the target group.
+	SkipReasonNotModifiable SkipReason = "not_modifiable"
+	// SkipReasonUnknownParameter means the target family does not define the parameter.
+	SkipReasonUnknownParameter SkipReason = "unknown_parameter"
+	// SkipReasonIncompatibleValue means the target family rejected the value.
+	SkipReasonIncompatibleValue SkipReason = "incompatible_value"
+)
+
+// SkippedParameter records a custom parameter that was not carried over
+// during a parameter group migration.
+type SkippedParameter struct {
+	// Name is the parameter name.
+	Name string `json:"name"`
+	// Value is the value that could not be applied.
+	Value string `json:"value"`
+	// Reason classifies why the parameter was skipped.
+	Reason SkipReason `json:"reason"`
+	// Error is the underlying error message, if the API rejected the parameter.
+	Error string `json:"error,omitempty"`
+}
+
 // InterventionRequest represents a request for human intervention.
 type InterventionRequest struct {
 	// Type is the type of intervention (e.g., "approval", "decision").
@@ -305,31 +1041,86 @@ type InterventionRequest struct {
 	Options []string `json:"options,omitempty"`
 }
 
+// PendingIntervention describes an operation paused for an operator decision.
+type PendingIntervention struct {
+	// OperationID is the paused operation.
+	OperationID string `json:"operation_id"`
+	// Type is the operation type.
+	Type OperationType `json:"type"`
+	// ClusterID is the cluster the operation targets.
+	ClusterID string `json:"cluster_id"`
+	// Region is the AWS region of the cluster.
+	Region string `json:"region"`
+	// PauseReason explains why the operation is paused.
+	PauseReason string `json:"pause_reason"`
+	// StepIndex is the index of the step the operation stopped at.
+	StepIndex int `json:"step_index"`
+	// StepName is the name of that step, if the operation has not run past its last step.
+	StepName string `json:"step_name,omitempty"`
+	// Options lists the resume actions the operation currently accepts.
+	Options []string `json:"options"`
+	// PausedAt is when the operation paused.
+	PausedAt time.Time `json:"paused_at"`
+	// WaitingSeconds is how long the operation has been waiting for a decision.
+	WaitingSeconds float64 `json:"waiting_seconds"`
+}
+
+// ActiveOperation describes an operation currently holding its cluster.
+type ActiveOperation struct {
+	// OperationID is the active operation.
+	OperationID string `json:"operation_id"`
+	// Type is the operation type.
+	Type OperationType `json:"type"`
+	// ClusterID is the cluster the operation holds.
+	ClusterID string `json:"cluster_id"`
+	// Region is the AWS region of the cluster.
+	Region string `json:"region"`
+	// State is the operation state.
+	State OperationState `json:"state"`
+	// StartedAt is when the operation started.
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
 // InterventionResponse represents a human response to an intervention request.
 type InterventionResponse struct {
-	// Action is the chosen action (e.g., "continue", "rollback", "abort").
+	// Action is the chosen decision (e.g., "continue", "override", "abort").
+	// It must be one of the options the operation's pause currently accepts.
 	Action string `json:"action"`
 	// Comment is an optional comment from the operator.
 	Comment string `json:"comment,omitempty"`
+	// Operator identifies who made the decision, for the audit event.
+	Operator string `json:"operator,omitempty"`
 }
 
 // ValidOperationStates contains all valid operation states.
 var ValidOperationStates = map[OperationState]bool{
-	StateCreated:     true,
-	StateRunning:     true,
-	StatePaused:      true,
-	StateCompleted:   true,
-	StateFailed:      true,
-	StateRollingBack: true,
-	StateRolledBack:  true,
+	StateCreated:         true,
+	StatePlanned:         true,
+	StatePendingApproval: true,
+	StateScheduled:       true,
+	StateRunning:         true,
+	StatePaused:          true,
+	StateCompleted:       true,
+	StateFailed:          true,
+	StateRollingBack:     true,
+	StateRolledBack:      true,
+	StateCancelling:      true,
+	StateCancelled:       true,
 }
 
 // ValidOperationTypes contains all valid operation types.
 var ValidOperationTypes = map[OperationType]bool{
-	OperationTypeInstanceTypeChange: true,
-	OperationTypeStorageTypeChange:  true,
-	OperationTypeEngineUpgrade:      true,
-	OperationTypeInstanceCycle:      true,
+	OperationTypeInstanceTypeChange:      true,
+	OperationTypeStorageTypeChange:       true,
+	OperationTypeEngineUpgrade:           true,
+	OperationTypeInstanceCycle:           true,
+	OperationTypeMinorVersionUpgrade:     true,
+	OperationTypeCACertRotation:          true,
+	OperationTypeRebootCluster:           true,
+	OperationTypeApplyPendingMaintenance: true,
+	OperationTypeSnapshotRestoreTest:     true,
+	OperationTypeServerlessScaling:       true,
+	OperationTypeMonitoringChange:        true,
 }
 
 // ValidStepStates contains all valid step states.
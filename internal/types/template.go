@@ -0,0 +1,124 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Template is a reusable operation definition. It holds the request that
+// created an operation without the bits tied to a specific cluster, so the
+// same maintenance can be repeated against other clusters.
+type Template struct {
+	// ID is the unique identifier for this template.
+	ID string `json:"id"`
+	// Name is a human-readable label for the template.
+	Name string `json:"name"`
+	// Description explains what the template is for.
+	Description string `json:"description,omitempty"`
+	// Type is the operation type the template creates.
+	Type OperationType `json:"type"`
+	// Parameters are the operation parameters with cluster-specific fields removed.
+	Parameters json.RawMessage `json:"params,omitempty"`
+	// WaitTimeout is the operation-level wait timeout in seconds (0 uses the default).
+	WaitTimeout int `json:"wait_timeout,omitempty"`
+	// SourceOperationID is the operation the template was exported from, if any.
+	SourceOperationID string `json:"source_operation_id,omitempty"`
+	// Configured marks a template defined in the server's templates file.
+	// Its ID is its name; it is not stored and cannot be deleted.
+	Configured bool `json:"configured,omitempty"`
+	// CreatedAt is when the template was saved.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClusterSpecificParams lists operation parameters that name resources of a
+// single cluster. They are dropped when an operation is saved as a template.
+var ClusterSpecificParams = []string{
+	"exclude_instances",
+	"db_cluster_parameter_group_name",
+	"db_instance_parameter_group_name",
+}
+
+// Validate checks if the template has valid required fields.
+func (t *Template) Validate() error {
+	if t.ID == "" {
+		return &ValidationError{Field: "id", Message: "template ID is required"}
+	}
+	if t.Name == "" {
+		return &ValidationError{Field: "name", Message: "template name is required"}
+	}
+	if !ValidOperationTypes[t.Type] {
+		return &ValidationError{Field: "type", Message: "invalid operation type: " + string(t.Type)}
+	}
+	if t.CreatedAt.IsZero() {
+		return &ValidationError{Field: "created_at", Message: "created_at is required"}
+	}
+	return nil
+}
+
+// operationParams returns a new value of the parameter type for an operation
+// type, or nil for an unknown type.
+func operationParams(opType OperationType) any {
+	switch opType {
+	case OperationTypeInstanceTypeChange:
+		return &InstanceTypeChangeParams{}
+	case OperationTypeStorageTypeChange:
+		return &StorageTypeChangeParams{}
+	case OperationTypeEngineUpgrade:
+		return &EngineUpgradeParams{}
+	case OperationTypeInstanceCycle:
+		return &InstanceCycleParams{}
+	case OperationTypeMinorVersionUpgrade:
+		return &MinorVersionUpgradeParams{}
+	case OperationTypeCACertRotation:
+		return &CACertRotationParams{}
+	case OperationTypeRebootCluster:
+		return &RebootClusterParams{}
+	case OperationTypeApplyPendingMaintenance:
+		return &ApplyPendingMaintenanceParams{}
+	case OperationTypeSnapshotRestoreTest:
+		return &SnapshotRestoreTestParams{}
+	case OperationTypeServerlessScaling:
+		return &ServerlessScalingParams{}
+	case OperationTypeMonitoringChange:
+		return &MonitoringChangeParams{}
+	}
+	return nil
+}
+
+// commonParams are read from the parameters of any operation that needs them,
+// such as the tags of a temp instance, rather than from a per-type field.
+var commonParams = []string{"tags", "max_replica_lag_ms"}
+
+// ValidateParams checks the template's parameters against its operation
+// type's parameter schema: every key must be one the type knows and every
+// value must have the right JSON type. Required parameters may be left for
+// the request that uses the template to supply.
+func (t *Template) ValidateParams() error {
+	target := operationParams(t.Type)
+	if target == nil {
+		return &ValidationError{Field: "type", Message: "invalid operation type: " + string(t.Type)}
+	}
+	if len(t.Parameters) == 0 || string(t.Parameters) == "null" {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(t.Parameters, &fields); err != nil {
+		return &ValidationError{Field: "params", Message: "params must be a JSON object"}
+	}
+	for _, key := range commonParams {
+		delete(fields, key)
+	}
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return &ValidationError{Field: "params", Message: err.Error()}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(rest))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return &ValidationError{Field: "params", Message: "not valid for " + string(t.Type) + ": " + err.Error()}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -30,6 +31,17 @@ func TestOperation_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "planned operation",
+			op: Operation{
+				ID:        "test-op",
+				Type:      OperationTypeInstanceTypeChange,
+				State:     StatePlanned,
+				ClusterID: "test-cluster",
+				CreatedAt: time.Now(),
+			},
+			wantErr: false,
+		},
 		{
 			name: "missing ID",
 			op: Operation{
@@ -159,3 +171,38 @@ func TestEvent_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestStep_MarshalJSON(t *testing.T) {
+	started := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	completed := started.Add(90 * time.Second)
+	step := Step{ID: "step-1", Action: "wait_cluster_available", StartedAt: &started, CompletedAt: &completed, Attempts: 2, Polls: 7}
+
+	data, err := json.Marshal(step)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if fields["duration_seconds"] != 90.0 || fields["attempts"] != 2.0 || fields["polls"] != 7.0 {
+		t.Errorf("marshalled step = %s, want its duration, attempts and polls", data)
+	}
+
+	var back Step
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if back.Attempts != 2 || !back.CompletedAt.Equal(completed) {
+		t.Errorf("round trip = %+v", back)
+	}
+
+	data, _ = json.Marshal(Step{ID: "step-2"})
+	var unstarted map[string]any
+	if err := json.Unmarshal(data, &unstarted); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := unstarted["duration_seconds"]; ok {
+		t.Errorf("step that never started has a duration: %s", data)
+	}
+}
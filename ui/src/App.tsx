@@ -24,6 +24,7 @@ import * as api from '@/api/client';
 import { ApiError } from '@/api/client';
 import type {
   Operation,
+  OperationSummary,
   OperationEvent,
   CreateOperationRequest,
   ResumeAction,
@@ -41,7 +42,7 @@ function App() {
   const [credentialError, setCredentialError] = useState<string | null>(null);
 
   // Operations state
-  const [operations, setOperations] = useState<Operation[]>([]);
+  const [operations, setOperations] = useState<OperationSummary[]>([]);
   const [selectedOperationId, setSelectedOperationId] = useState<string | null>(
     null
   );
@@ -405,7 +406,7 @@ function App() {
 
 // Extracted operations view for reuse
 interface OperationsViewProps {
-  operations: Operation[];
+  operations: OperationSummary[];
   selectedOperationId: string | null;
   selectedOperation: Operation | null;
   operationEvents: OperationEvent[];
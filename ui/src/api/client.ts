@@ -1,11 +1,13 @@
 import type {
   Operation,
+  OperationSummary,
+  OperationPage,
   OperationEvent,
   ClusterSummary,
   ClusterInfo,
   BlueGreenDeployment,
-  UpgradeTarget,
-  InstanceTypeOption,
+  UpgradeTargetOptions,
+  InstanceOptions,
   RegionsResponse,
   CreateOperationRequest,
   ResumeRequest,
@@ -107,20 +109,20 @@ export async function getClusterBlueGreen(
 export async function getClusterUpgradeTargets(
   clusterId: string,
   region?: string
-): Promise<{ upgrade_targets: UpgradeTarget[] }> {
-  const headers: Record<string, string> = { 'X-Cluster-Id': clusterId };
+): Promise<UpgradeTargetOptions> {
+  const headers: Record<string, string> = {};
   if (region) headers['X-Region'] = region;
-  const res = await fetch('/api/cluster/upgrade-targets', { headers });
+  const res = await fetch(`/api/clusters/${encodeURIComponent(clusterId)}/upgrade-targets`, { headers });
   return handleResponse(res);
 }
 
 export async function getClusterInstanceTypes(
   clusterId: string,
   region?: string
-): Promise<{ instance_types: InstanceTypeOption[] }> {
-  const headers: Record<string, string> = { 'X-Cluster-Id': clusterId };
+): Promise<InstanceOptions> {
+  const headers: Record<string, string> = {};
   if (region) headers['X-Region'] = region;
-  const res = await fetch('/api/cluster/instance-types', { headers });
+  const res = await fetch(`/api/clusters/${encodeURIComponent(clusterId)}/instance-options`, { headers });
   return handleResponse(res);
 }
 
@@ -149,10 +151,28 @@ export async function getBlueGreenPrerequisites(
 }
 
 // Operations
-export async function getOperations(): Promise<Operation[]> {
-  const res = await fetch('/api/operations');
-  const ops = await handleResponse<Operation[]>(res);
-  return (ops ?? []).map(normalizeOperation);
+export interface OperationListFilter {
+  state?: string;
+  cluster?: string;
+  type?: string;
+}
+
+// Fetches every page of the operations listing
+export async function getOperations(filter: OperationListFilter = {}): Promise<OperationSummary[]> {
+  const ops: OperationSummary[] = [];
+  let cursor: string | undefined;
+  do {
+    const params = new URLSearchParams({ limit: '500' });
+    for (const [key, value] of Object.entries(filter)) {
+      if (value) params.set(key, value);
+    }
+    if (cursor) params.set('cursor', cursor);
+    const res = await fetch(`/api/operations?${params}`);
+    const page = await handleResponse<OperationPage>(res);
+    ops.push(...(page.operations ?? []));
+    cursor = page.next_cursor;
+  } while (cursor);
+  return ops;
 }
 
 export async function getOperation(id: string): Promise<Operation> {
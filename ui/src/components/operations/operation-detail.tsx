@@ -455,6 +455,9 @@ function StepItem({
         >
           {step.description}
         </p>
+        {step.rationale && (
+          <p className="text-xs italic text-muted-foreground/70 mt-0.5">{step.rationale}</p>
+        )}
         {step.wait_condition && (
           <div className="flex items-center gap-1.5 mt-1.5">
             <span className="inline-block h-1.5 w-1.5 rounded-full bg-status-yellow animate-pulse" />
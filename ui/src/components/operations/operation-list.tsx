@@ -3,11 +3,11 @@ import { Button } from '@/components/ui/button';
 import { ScrollArea } from '@/components/ui/scroll-area';
 import { StatusBadge } from '@/components/ui/status-badge';
 import { OPERATION_TYPE_NAMES, cn, formatRelativeTime } from '@/lib/utils';
-import type { Operation } from '@/types';
+import type { OperationSummary } from '@/types';
 import { RefreshCw, Database } from 'lucide-react';
 
 interface OperationListProps {
-  operations: Operation[];
+  operations: OperationSummary[];
   selectedId: string | null;
   onSelect: (id: string) => void;
   onRefresh: () => void;
@@ -6,7 +6,9 @@ export type OperationType =
   | 'instance_cycle';
 
 export type OperationState =
+  | 'pending_approval'
   | 'created'
+  | 'scheduled'
   | 'running'
   | 'paused'
   | 'completed'
@@ -26,6 +28,7 @@ export interface Step {
   id: string;
   name: string;
   description: string;
+  rationale?: string;
   state: StepState;
   action: string;
   parameters?: Record<string, unknown>;
@@ -33,11 +36,25 @@ export interface Step {
   error?: string;
   started_at?: string;
   completed_at?: string;
+  duration_seconds?: number;
+  attempts?: number;
   wait_condition?: string;
+  wait_started_at?: string;
+  polls?: number;
+  wait_progress?: WaitProgress;
   retry_count: number;
   max_retries: number;
 }
 
+// Progress of a wait step checked once per poll in poll mode
+export interface WaitProgress {
+  started_at: string;
+  waiting_on?: string[];
+  polls: number;
+  mismatch_polls?: Record<string, number>;
+  reissued?: string[];
+}
+
 export interface Operation {
   id: string;
   type: OperationType;
@@ -51,16 +68,119 @@ export interface Operation {
   pause_reason?: string;
   wait_timeout?: number;
   pause_before_steps?: number[];
+  idempotency_key?: string;
+  requires_approval?: boolean;
+  created_by?: string;
+  approved_by?: string;
+  approved_at?: string;
+  poll_mode?: boolean;
+  max_duration_seconds?: number;
+  deadline?: string;
+  end_state?: EndStateReport;
+  blue_green?: BlueGreenResources;
+  batch_id?: string;
+  schedule_in_cluster_window?: boolean;
+  cluster_maintenance_window?: string;
+  scheduled_start?: string;
   created_at: string;
   updated_at: string;
   started_at?: string;
   completed_at?: string;
 }
 
+export interface EndStateMismatch {
+  resource: string;
+  field: string;
+  expected: string;
+  actual: string;
+}
+
+// Comparison of a cluster with the end state its operation intended
+export interface EndStateReport {
+  checked_at: string;
+  checked: string[];
+  instances?: string[];
+  mismatches?: EndStateMismatch[];
+}
+
+export interface BlueGreenMember {
+  source_member: string;
+  target_member?: string;
+  status?: string;
+}
+
+// Source and target resources of an operation's Blue-Green deployment
+export interface BlueGreenResources {
+  deployment_identifier: string;
+  status: string;
+  source_arn: string;
+  target_arn?: string;
+  members?: BlueGreenMember[];
+  switched_over?: boolean;
+  updated_at: string;
+}
+
+export type BatchState = 'running' | 'completed' | 'partially_completed' | 'failed';
+
+export interface BatchChild {
+  cluster_id: string;
+  operation_id?: string;
+  state: OperationState;
+  error?: string;
+}
+
+// Returned by POST /api/batch-operations and GET /api/batch-operations/:id
+export interface BatchOperation {
+  id: string;
+  type: OperationType;
+  region: string;
+  params?: Record<string, unknown>;
+  max_parallel_clusters: number;
+  state: BatchState;
+  children: BatchChild[];
+  counts: Partial<Record<OperationState, number>>;
+  created_at: string;
+  updated_at: string;
+  completed_at?: string;
+}
+
+// Listing view of an operation, returned by GET /api/operations
+export interface OperationSummary {
+  id: string;
+  type: OperationType;
+  state: OperationState;
+  cluster_id: string;
+  region: string;
+  current_step_index: number;
+  step_count: number;
+  scheduled_start?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface OperationPage {
+  operations: OperationSummary[];
+  next_cursor?: string;
+}
+
+// Returned by POST /api/operations/:id/poll
+export interface PollResult {
+  operation_id: string;
+  ready: boolean;
+  state: OperationState;
+  current_step_index: number;
+  step?: string;
+  wait_condition?: string;
+  waiting_on?: string[];
+  deadline?: string;
+}
+
 export interface OperationEvent {
   id: string;
   operation_id: string;
   type: string;
+  severity: 'info' | 'warning' | 'error';
+  step_id?: string;
   message: string;
   data?: Record<string, unknown>;
   timestamp: string;
@@ -71,6 +191,11 @@ export interface ClusterSummary {
   engine: string;
   engine_version: string;
   status: string;
+  instance_count: number;
+  multi_az: boolean;
+  global_cluster_id?: string;
+  writer_instance_class?: string; // only with ?include=instances
+  active_operation_id?: string;
 }
 
 export interface InstanceInfo {
@@ -81,14 +206,41 @@ export interface InstanceInfo {
   is_auto_scaled: boolean;
   storage_type?: string;
   iops?: number;
+  db_parameter_group_name?: string;
+  pending_modified_values?: PendingModifiedValues;
+  iam_database_authentication_enabled?: boolean;
+}
+
+export interface PendingModifiedValues {
+  instance_class?: string;
+  storage_type?: string;
+  iops?: number;
+  storage_throughput?: number;
+  allocated_storage?: number;
+  engine_version?: string;
+  ca_certificate_identifier?: string;
 }
 
 export interface ClusterInfo {
   cluster_id: string;
   engine: string;
   engine_version: string;
+  db_cluster_parameter_group_name?: string;
   status: string;
   instances: InstanceInfo[];
+  custom_endpoints?: ClusterEndpoint[];
+  iam_database_authentication_enabled?: boolean;
+}
+
+export interface ClusterEndpoint {
+  identifier: string;
+  cluster_id: string;
+  endpoint: string;
+  status: string;
+  endpoint_type: string;
+  custom_endpoint_type?: string;
+  static_members?: string[];
+  excluded_members?: string[];
 }
 
 export interface BlueGreenDeployment {
@@ -113,6 +265,21 @@ export interface InstanceTypeOption {
   instance_class: string;
 }
 
+export interface UpgradeTargetOptions {
+  cluster_id: string;
+  engine: string;
+  current_version: string;
+  upgrade_targets: UpgradeTarget[];
+}
+
+export interface InstanceOptions {
+  cluster_id: string;
+  engine: string;
+  engine_version: string;
+  current_instance_type: string;
+  instance_types: InstanceTypeOption[];
+}
+
 export interface RegionsResponse {
   regions: string[];
   default_region: string;
@@ -127,10 +294,18 @@ export interface MockTiming {
 
 export interface MockFault {
   id: string;
-  type: 'api_error' | 'delay' | 'stuck';
+  type: 'api_error' | 'delay' | 'stuck' | 'partial_fail' | 'intermittent' | 'task_fail';
   action?: string;
   target?: string;
   probability: number;
+  error_code?: string;
+  error_message?: string;
+  delay_ms?: number;
+  fail_after_n_calls?: number;
+  fail_every_n?: number;
+  failure_rate?: number;
+  task?: string;
+  call_count?: number;
   enabled: boolean;
   params?: Record<string, unknown>;
 }
@@ -164,11 +339,18 @@ export interface CreateOperationRequest {
 }
 
 // Resume action
-export type ResumeAction = 'continue' | 'rollback' | 'abort' | 'mark_complete';
+export type ResumeAction =
+  | 'continue'
+  | 'rollback'
+  | 'abort'
+  | 'mark_complete'
+  | 'retry_cleanup'
+  | 'override';
 
 export interface ResumeRequest {
   action: ResumeAction;
   comment?: string;
+  operator?: string;
 }
 
 // RDS Proxy types
@@ -214,5 +396,16 @@ export interface BlueGreenPrerequisites {
   logical_replication_enabled: boolean;
   parameter_group_name: string;
   engine: string;
+  engine_version: string;
+  target_engine_version?: string;
   missing_parameter?: string;
+  checks: PrerequisiteCheck[];
+}
+
+export interface PrerequisiteCheck {
+  name: string;
+  passed: boolean;
+  required: boolean;
+  message: string;
+  remediation?: string;
 }